@@ -7,139 +7,153 @@ import (
 	"os"
 
 	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/artifact"
+	"github.com/deanturpin/lft2/internal/books"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/httpconf"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/quota"
+	"github.com/deanturpin/lft2/internal/report"
+	"github.com/deanturpin/lft2/internal/risk"
+	"github.com/deanturpin/lft2/internal/schema"
+	"github.com/deanturpin/lft2/internal/store"
+	"github.com/deanturpin/lft2/internal/version"
+	"time"
 )
 
-// Account data from Alpaca
-type Account struct {
-	AccountNumber         string `json:"account_number"`
-	Status                string `json:"status"`
-	Currency              string `json:"currency"`
-	Cash                  string `json:"cash"`
-	PortfolioValue        string `json:"portfolio_value"`
-	BuyingPower           string `json:"buying_power"`
-	Equity                string `json:"equity"`
-	LastEquity            string `json:"last_equity"`
-	LongMarketValue       string `json:"long_market_value"`
-	ShortMarketValue      string `json:"short_market_value"`
-	InitialMargin         string `json:"initial_margin"`
-	MaintenanceMargin     string `json:"maintenance_margin"`
-	DaytradingBuyingPower string `json:"daytrading_buying_power"`
-}
-
-// Position data from Alpaca
-type Position struct {
-	Symbol         string `json:"symbol"`
-	Qty            string `json:"qty"`
-	AvgEntryPrice  string `json:"avg_entry_price"`
-	CurrentPrice   string `json:"current_price"`
-	MarketValue    string `json:"market_value"`
-	CostBasis      string `json:"cost_basis"`
-	UnrealizedPL   string `json:"unrealized_pl"`
-	UnrealizedPLPC string `json:"unrealized_plpc"`
-	ChangeToday    string `json:"change_today"`
-	Side           string `json:"side"`
-	AssetClass     string `json:"asset_class"`
-}
-
-// Order data from Alpaca /v2/orders
-type Order struct {
-	Symbol        string `json:"symbol"`
-	ClientOrderID string `json:"client_order_id"`
-	Side          string `json:"side"`
-	Status        string `json:"status"`
-}
-
-
 var client alpaca.Client
 
 func init() {
-	apiKey := os.Getenv("ALPACA_API_KEY")
-	apiSecret := os.Getenv("ALPACA_API_SECRET")
-
-	if apiKey == "" || apiSecret == "" {
-		log.Fatal("ALPACA_API_KEY and ALPACA_API_SECRET must be set")
+	var err error
+	if client, err = alpaca.FromEnv(); err != nil {
+		log.Fatal(err)
 	}
-
-	client = alpaca.New(apiKey, apiSecret, os.Getenv("ALPACA_BASE_URL"), "")
-}
-
-func fetchAccount() (*Account, error) {
-	body, err := client.Get(client.BaseURL + "/v2/account")
+	policy, err := httpconf.ForStage("account")
 	if err != nil {
-		return nil, err
+		log.Fatal(err)
 	}
+	client.HTTP = policy
+}
 
-	var account Account
-	if err := json.Unmarshal(body, &account); err != nil {
-		return nil, err
-	}
+// Account snapshots the Alpaca account each cycle: positions.json for exits,
+// each position attributed to the book whose strategy bought it, the day's
+// balances in account-history/, each position's change since the last cycle
+// in positions-diff.json, and the VaR in exposure.json that gates entries.
+// It also writes liquidity.json, each candidate's intraday volume curve,
+// and with LFT2_STORE set records the latest fills.
+func main() {
+	version.Handle("account")
+	defer crash.Guard("account")
 
-	return &account, nil
-}
+	// Ctrl-C stops between the account, positions and risk files
+	ctx := interrupt.Context()
 
-func fetchPositions() ([]Position, error) {
-	body, err := client.Get(client.BaseURL + "/v2/positions")
+	fmt.Println("Low Frequency Trader v2 - Account Module")
+	fmt.Println()
+
+	// Fetch account info
+	account, err := client.Account()
 	if err != nil {
-		return nil, err
+		log.Fatalf("Error fetching account: %v", err)
 	}
 
-	var positions []Position
-	if err := json.Unmarshal(body, &positions); err != nil {
-		return nil, err
+	fmt.Printf("Account Status: %s\n", account.Status)
+	fmt.Printf("  Cash:            $%s\n", account.Cash.Money())
+	fmt.Printf("  Buying Power:    $%s\n", account.BuyingPower.Money())
+	fmt.Printf("  Portfolio Value: $%s\n", account.PortfolioValue.Money())
+	fmt.Printf("  Equity:          $%s\n", account.Equity.Money())
+	fmt.Printf("  Day Trades:      %d (pattern day trader: %t)\n", account.DaytradeCount, account.PatternDayTrader)
+	if reason := account.TradingBlock(); reason != "" {
+		fmt.Printf("  ✗ %s — execute will submit no orders\n", reason)
+	}
+	if reason := account.DayTradeBlock(); reason != "" {
+		fmt.Printf("  ✗ %s — execute will skip buys\n", reason)
 	}
 
-	return positions, nil
-}
-
-// Fetch open buy orders to get client_order_id for each position
-func fetchOpenOrders() ([]Order, error) {
-	body, err := client.Get(client.BaseURL + "/v2/orders?status=open&side=buy&limit=500")
+	// With books.json each book sees its own slice of the account and the
+	// positions its strategies opened, under its own root
+	bookCfg, book, err := books.FromEnv()
 	if err != nil {
-		return nil, err
+		log.Fatalf("Error: %v", err)
 	}
-
-	var orders []Order
-	if err := json.Unmarshal(body, &orders); err != nil {
-		return nil, err
+	root := book.Root()
+	bookName := ""
+	if book != nil {
+		bookName = book.Name
 	}
 
-	return orders, nil
-}
-
-
-func main() {
-	fmt.Println("Low Frequency Trader v2 - Account Module\n")
+	// Ensure docs directory exists
+	if err := os.MkdirAll(root, 0755); err != nil {
+		log.Fatalf("Error creating %s: %v", root, err)
+	}
 
-	// Fetch account info
-	account, err := fetchAccount()
+	// Fetch positions
+	positions, err := client.Positions()
 	if err != nil {
-		log.Fatalf("Error fetching account: %v", err)
+		log.Fatalf("Error fetching positions: %v", err)
 	}
 
-	fmt.Printf("Account Status: %s\n", account.Status)
-	fmt.Printf("  Cash:            $%s\n", account.Cash)
-	fmt.Printf("  Buying Power:    $%s\n", account.BuyingPower)
-	fmt.Printf("  Portfolio Value: $%s\n", account.PortfolioValue)
-	fmt.Printf("  Equity:          $%s\n", account.Equity)
-
-	// Ensure docs directory exists
-	if err := os.MkdirAll("docs", 0755); err != nil {
-		log.Fatalf("Error creating docs directory: %v", err)
+	// Each position's book, from the strategy in its entry's client_order_id
+	var owners map[string]string
+	if len(bookCfg.Books) > 0 {
+		filled, err := client.Orders("status=closed&side=buy&limit=500&direction=desc")
+		if err != nil {
+			log.Fatalf("Error fetching orders: %v", err)
+		}
+		owners = positionBooks(bookCfg, positions, filled)
 	}
 
 	// Write account.json for entries module
-	accountFile, err := os.Create("docs/account.json")
+	accountFile, err := os.Create(root + "account.json")
 	if err != nil {
 		log.Fatalf("Error creating account.json: %v", err)
 	}
 	defer accountFile.Close()
 
 	// Simplified account info for entries module
-	accountData := map[string]interface{}{
-		"cash":            account.Cash,
-		"buying_power":    account.BuyingPower,
-		"portfolio_value": account.PortfolioValue,
-		"equity":          account.Equity,
+	accountData := struct {
+		schema.Header
+		BuyingPower    alpaca.Decimal `json:"buying_power"`
+		Cash           alpaca.Decimal `json:"cash"`
+		Equity         alpaca.Decimal `json:"equity"`
+		PortfolioValue alpaca.Decimal `json:"portfolio_value"`
+
+		// Set with books.json: entries trades only the book's strategies.
+		// Strategies stays last, since entries scans the flat fields first.
+		Book       string   `json:"book,omitempty"`
+		Budget     *float64 `json:"budget,omitempty"` // Fraction of the account
+		BarMinutes int      `json:"bar_minutes,omitempty"`
+		Strategies []string `json:"strategies,omitempty"`
+	}{
+		Header:         schema.Current(),
+		BuyingPower:    account.BuyingPower.Round(2),
+		Cash:           account.Cash.Round(2),
+		Equity:         account.Equity.Round(2),
+		PortfolioValue: account.PortfolioValue.Round(2),
+	}
+	equity := account.Equity.Float()
+	if len(bookCfg.Books) > 0 {
+		budget := bookCfg.Budget(bookName)
+		held := 0.0
+		for _, pos := range positions {
+			if owners[pos.Symbol] == bookName {
+				held += pos.MarketValue.Float()
+			}
+		}
+		a := books.Allot(budget, equity, account.Cash.Float(), account.BuyingPower.Float(), held)
+		equity = a.Equity
+		accountData.BuyingPower = alpaca.Decimal(a.BuyingPower).Round(2)
+		accountData.Cash = alpaca.Decimal(a.Cash).Round(2)
+		accountData.Equity = alpaca.Decimal(a.Equity).Round(2)
+		accountData.PortfolioValue = accountData.Equity
+		accountData.Book, accountData.Budget = bookName, &budget
+		label := "Default book"
+		if book != nil {
+			accountData.Strategies, accountData.BarMinutes = book.Strategies, book.TimeframeMin
+			label = "Book " + bookName
+		}
+		fmt.Printf("\n%s: %.0f%% of the account, $%s held, $%s free\n",
+			label, budget*100, alpaca.Decimal(held).Money(), accountData.BuyingPower.Money())
 	}
 
 	encoder := json.NewEncoder(accountFile)
@@ -148,22 +162,35 @@ func main() {
 		log.Fatalf("Error writing account.json: %v", err)
 	}
 
-	fmt.Println("\n✓ Wrote docs/account.json")
+	fmt.Printf("\n✓ Wrote %saccount.json\n", root)
 
-	// Fetch positions
-	positions, err := fetchPositions()
-	if err != nil {
-		log.Fatalf("Error fetching positions: %v", err)
+	// The day's balances for reconcile's day-over-day report; the last
+	// cycle of the day leaves the closing snapshot. They're the account's,
+	// so only the default book writes them.
+	if book == nil {
+		snapshot := report.Snapshot(*account, time.Now())
+		if err := report.SaveSnapshot(report.AccountHistoryDir, snapshot); err != nil {
+			log.Fatalf("Error writing account snapshot: %v", err)
+		}
+		fmt.Printf("✓ Wrote %s/%s.json\n", report.AccountHistoryDir, snapshot.Date)
+
+		if n, err := storeFills(bookCfg); err != nil {
+			fmt.Printf("⚠ fills not stored: %v\n", err)
+		} else if n >= 0 {
+			fmt.Printf("✓ Stored %d new fill(s) in %s\n", n, os.Getenv(store.Env))
+		}
 	}
 
+	interrupt.Stop(ctx, "account")
+
 	fmt.Printf("\nCurrently holding %d position(s):\n", len(positions))
 	for _, pos := range positions {
-		fmt.Printf("  %s: %s shares @ $%s (P/L: $%s / %s%%)\n",
-			pos.Symbol, pos.Qty, pos.AvgEntryPrice, pos.UnrealizedPL, pos.UnrealizedPLPC)
+		fmt.Printf("  %s: %s shares @ $%s (P/L: $%s / %.2f%%)\n",
+			pos.Symbol, pos.Qty, pos.AvgEntryPrice.Money(), pos.UnrealizedPL.Money(), pos.UnrealizedPLPC.Float()*100)
 	}
 
 	// Fetch open orders to get client_order_id for each position
-	orders, err := fetchOpenOrders()
+	orders, err := client.Orders("status=open&side=buy&limit=500")
 	if err != nil {
 		log.Fatalf("Error fetching orders: %v", err)
 	}
@@ -177,7 +204,7 @@ func main() {
 	}
 
 	// Write positions.json for exits module
-	positionsFile, err := os.Create("docs/positions.json")
+	positionsFile, err := os.Create(root + "positions.json")
 	if err != nil {
 		log.Fatalf("Error creating positions.json: %v", err)
 	}
@@ -185,11 +212,12 @@ func main() {
 
 	// Simplified position data for exits module with client_order_id
 	type SimplePosition struct {
-		Symbol        string `json:"symbol"`
-		Qty           string `json:"qty"`
-		AvgEntryPrice string `json:"avg_entry_price"`
-		Side          string `json:"side"`
-		ClientOrderID string `json:"client_order_id"` // Original buy order ID
+		Symbol        string         `json:"symbol"`
+		Qty           alpaca.Decimal `json:"qty"`
+		AvgEntryPrice alpaca.Decimal `json:"avg_entry_price"`
+		Side          string         `json:"side"`
+		ClientOrderID string         `json:"client_order_id"` // Original buy order ID
+		Book          string         `json:"book,omitempty"`  // With books.json; exits sells only its own book's
 	}
 
 	simplePositions := make([]SimplePosition, len(positions))
@@ -200,6 +228,7 @@ func main() {
 			AvgEntryPrice: pos.AvgEntryPrice,
 			Side:          pos.Side,
 			ClientOrderID: orderIDMap[pos.Symbol], // Lookup from orders
+			Book:          owners[pos.Symbol],
 		}
 	}
 
@@ -209,5 +238,204 @@ func main() {
 		log.Fatalf("Error writing positions.json: %v", err)
 	}
 
-	fmt.Println("\n✓ Wrote docs/positions.json")
+	fmt.Printf("\n✓ Wrote %spositions.json\n", root)
+
+	// What changed since the previous cycle, for the daily summary
+	if book == nil {
+		diff := report.NewPositionsDiff(previousPositions(), report.Holdings(positions), time.Now())
+		if err := report.SavePositionsDiff(report.PositionsDiffPath, diff); err != nil {
+			log.Fatalf("Error writing positions-diff.json: %v", err)
+		}
+		for _, c := range diff.Changes {
+			if c.Change != report.Held {
+				fmt.Printf("  %-6s %s (qty %s → %s)\n", c.Symbol, c.Change, c.QtyBefore, c.QtyAfter)
+			}
+		}
+		fmt.Printf("✓ Wrote %s\n", report.PositionsDiffPath)
+	}
+
+	interrupt.Stop(ctx, "account")
+
+	// Exposure: one-day VaR over current positions from stored bar history,
+	// which entries uses as a gate
+	maxVaR, err := risk.MaxVaRFromEnv()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	// A book's VaR is over its own positions, against its own equity
+	held := positions
+	if owners != nil {
+		held = nil
+		for _, pos := range positions {
+			if owners[pos.Symbol] == bookName {
+				held = append(held, pos)
+			}
+		}
+	}
+	exposure := estimateExposure(held, root+"bars", equity, maxVaR)
+	if err := risk.Save(root+"exposure.json", exposure); err != nil {
+		log.Fatalf("Error writing exposure.json: %v", err)
+	}
+
+	fmt.Printf("\nExposure (%d day(s) simulated):\n", exposure.Scenarios)
+	fmt.Printf("  VaR %.0f%%:    $%.2f (%.2f%% of equity, limit %.2f%%)\n",
+		exposure.Confidence*100, exposure.VaR, exposure.VaRPct*100, exposure.MaxVaRPct*100)
+	fmt.Printf("  Worst day:  $%.2f (%.2f%%) %s\n", exposure.WorstDay, exposure.WorstDayPct*100, exposure.WorstDate)
+	if exposure.Breached {
+		fmt.Println("  ✗ VaR over limit — entries paused")
+	}
+	fmt.Printf("\n✓ Wrote %sexposure.json\n", root)
+
+	// Liquidity: how each candidate's volume spreads across the session,
+	// which execute sizes entries by when LFT2_LIQUIDITY_FLOOR is set
+	liquidity := liquidityCurves(root+"candidates.json", root+"bars")
+	if err := risk.SaveLiquidity(root+"liquidity.json", liquidity); err != nil {
+		log.Fatalf("Error writing liquidity.json: %v", err)
+	}
+	fmt.Printf("✓ Wrote %sliquidity.json (%d symbol(s))\n", root, len(liquidity.Symbols))
+
+	if err := quota.Record(quota.DefaultPath, nil, time.Now()); err != nil {
+		fmt.Printf("⚠ %s not written: %v\n", quota.DefaultPath, err)
+	}
+
+	interrupt.Stop(ctx, "account")
+}
+
+// liquidityCurves builds a volume curve for each symbol in the candidates
+// file from stored bar history. Symbols without enough history are reported
+// and left out, so their entries go in at full size.
+func liquidityCurves(candidatesPath, barsDir string) risk.Liquidity {
+	l := risk.Liquidity{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Symbols:   map[string]risk.Curve{},
+	}
+	var candidates struct {
+		Symbols []string `json:"symbols"`
+	}
+	data, err := os.ReadFile(candidatesPath)
+	if err == nil {
+		err = json.Unmarshal(data, &candidates)
+	}
+	if err != nil {
+		fmt.Printf("  [skip] liquidity: %v\n", err)
+		return l
+	}
+	for _, symbol := range candidates.Symbols {
+		curve, ok, err := risk.LiquidityCurve(barsDir, symbol)
+		switch {
+		case err != nil:
+			fmt.Printf("  [skip] %s liquidity: %v\n", symbol, err)
+		case !ok:
+			fmt.Printf("  [skip] %s liquidity: %d session(s), need %d\n", symbol, curve.Days, risk.MinLiquidityDays)
+		default:
+			l.Symbols[symbol] = curve
+		}
+	}
+	return l
+}
+
+// previousPositions returns the last cycle's snapshot, preferring the local
+// positions-diff.json and falling back to the published one so a fresh CI
+// checkout still has something to diff against. Nil when neither is usable.
+func previousPositions() *report.PositionsDiff {
+	prev, err := report.LoadPositionsDiff(report.PositionsDiffPath)
+	if err != nil {
+		fmt.Printf("  [skip] previous positions: %v\n", err)
+	}
+	if prev != nil {
+		return prev
+	}
+	data, err := artifact.Fetch(artifact.Base(), "positions-diff.json")
+	if err != nil {
+		return nil
+	}
+	published, err := report.ParsePositionsDiff("positions-diff.json", data)
+	if err != nil {
+		fmt.Printf("  [skip] published positions: %v\n", err)
+		return nil
+	}
+	return &published
+}
+
+// estimateExposure replays stored bar history against current positions.
+// Symbols without bars are reported and left out of the simulation.
+func estimateExposure(positions []alpaca.Position, barsDir string, equity, maxVaR float64) risk.Exposure {
+	holdings := make([]risk.Holding, 0, len(positions))
+	returns := map[string]map[string]float64{}
+	for _, pos := range positions {
+		holdings = append(holdings, risk.Holding{Symbol: pos.Symbol, Value: pos.MarketValue.Float()})
+		r, err := risk.DailyReturns(barsDir, pos.Symbol)
+		if err != nil {
+			fmt.Printf("  [skip] %s history: %v\n", pos.Symbol, err)
+			continue
+		}
+		returns[pos.Symbol] = r
+	}
+
+	e := risk.Estimate(holdings, returns, equity, risk.DefaultConfidence)
+	e.Gate(maxVaR)
+	return e
+}
+
+// positionBooks attributes each position to a book by the strategy in the
+// client_order_id of its latest filled buy; filled is newest first. Orders
+// entries didn't write, and strategies no book lists, are the default
+// book's ("").
+func positionBooks(cfg books.Config, positions []alpaca.Position, filled []alpaca.Order) map[string]string {
+	owners := make(map[string]string, len(positions))
+	for _, pos := range positions {
+		for _, o := range filled {
+			if o.Symbol != pos.Symbol || o.Side != "buy" || o.FilledQty <= 0 {
+				continue
+			}
+			if name, _, ok := report.OrderStrategy(o.Symbol, o.ClientOrderID); ok {
+				owners[pos.Symbol] = cfg.Owner(name)
+			}
+			break
+		}
+	}
+	return owners
+}
+
+// storeFills records the account's latest filled orders in the LFT2_STORE
+// database, and returns how many are new, or -1 without a store.
+func storeFills(cfg books.Config) (int, error) {
+	s, err := store.FromEnv()
+	if err != nil || s == nil {
+		return -1, err
+	}
+	defer s.Close()
+	closed, err := client.Orders("status=closed&limit=500&direction=desc&nested=true")
+	if err != nil {
+		return 0, err
+	}
+	return s.PutFills(fillsOf(cfg, closed, ""))
+}
+
+// fillsOf flattens orders and their bracket legs into fills, attributed to
+// books as positions are. A leg's own client_order_id is Alpaca's, so it
+// takes strategy, its parent's.
+func fillsOf(cfg books.Config, orders []alpaca.Order, strategy string) []store.Fill {
+	var fills []store.Fill
+	for _, o := range orders {
+		name := strategy
+		if own, _, ok := report.OrderStrategy(o.Symbol, o.ClientOrderID); ok {
+			name = own
+		}
+		if o.FilledQty > 0 {
+			fills = append(fills, store.Fill{
+				OrderID:       o.ID,
+				ClientOrderID: o.ClientOrderID,
+				Symbol:        o.Symbol,
+				Side:          o.Side,
+				Qty:           o.FilledQty.Float(),
+				Price:         o.FilledAvgPrice.Float(),
+				FilledAt:      o.FilledAt,
+				Strategy:      name,
+				Book:          cfg.Owner(name),
+			})
+		}
+		fills = append(fills, fillsOf(cfg, o.Legs, name)...)
+	}
+	return fills
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/corporate"
+)
+
+// types are the announcements asked for, in Alpaca's query spelling.
+var types = []string{"Split", "Dividend", "Merger", "Spinoff"}
+
+// loadWatchlist reads the symbols from a watchlist file, trimmed and
+// uppercased as fetch reads them.
+func loadWatchlist(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading watchlist: %w", err)
+	}
+	var watchlist struct {
+		Symbols []string `json:"symbols"`
+	}
+	if err := json.Unmarshal(data, &watchlist); err != nil {
+		return nil, fmt.Errorf("parsing watchlist: %w", err)
+	}
+	symbols := map[string]bool{}
+	for _, s := range watchlist.Symbols {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			symbols[s] = true
+		}
+	}
+	return symbols, nil
+}
+
+// selectActions keeps the announcements that touch a watched symbol, as
+// the company acting or, in a merger or spinoff, the one acted on. Repeats
+// of one announcement are kept once.
+func selectActions(announcements []alpaca.Announcement, watched map[string]bool) []corporate.Action {
+	seen := map[string]bool{}
+	var actions []corporate.Action
+	for _, a := range announcements {
+		if !watched[a.InitiatingSymbol] && !watched[a.TargetSymbol] || seen[a.ID] {
+			continue
+		}
+		seen[a.ID] = true
+		actions = append(actions, corporate.Action{
+			ID:           a.ID,
+			Symbol:       a.InitiatingSymbol,
+			Type:         strings.ToLower(a.Type),
+			SubType:      a.SubType,
+			TargetSymbol: a.TargetSymbol,
+			ExDate:       a.ExDate,
+			Cash:         a.Cash.Float(),
+			OldRate:      a.OldRate.Float(),
+			NewRate:      a.NewRate.Float(),
+		})
+	}
+	corporate.Sort(actions)
+	return actions
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+func TestSelectActions(t *testing.T) {
+	announcements := []alpaca.Announcement{
+		{ID: "1", Type: "split", SubType: "forward_split", InitiatingSymbol: "NVDA", ExDate: "2026-03-10", OldRate: 1, NewRate: 4},
+		{ID: "2", Type: "dividend", InitiatingSymbol: "AAPL", ExDate: "2026-03-09", Cash: 0.26},
+		{ID: "3", Type: "merger", InitiatingSymbol: "BIG", TargetSymbol: "SMALL", ExDate: "2026-03-20"},
+		{ID: "4", Type: "dividend", InitiatingSymbol: "IBM", ExDate: "2026-03-09", Cash: 1.67},
+		{ID: "1", Type: "split", InitiatingSymbol: "NVDA", ExDate: "2026-03-10", OldRate: 1, NewRate: 4},
+	}
+	watched := map[string]bool{"NVDA": true, "AAPL": true, "SMALL": true}
+
+	got := selectActions(announcements, watched)
+	var ids []string
+	for _, a := range got {
+		ids = append(ids, a.ID)
+	}
+	if len(ids) != 3 || ids[0] != "2" || ids[1] != "1" || ids[2] != "3" {
+		t.Fatalf("got %v, want 2, 1, 3: watched only, once each, by ex date", ids)
+	}
+	if got[1].NewRate != 4 || got[1].OldRate != 1 || got[1].Type != "split" {
+		t.Errorf("split: got %+v", got[1])
+	}
+	if got[2].TargetSymbol != "SMALL" {
+		t.Errorf("merger: got %+v, want the watched target kept", got[2])
+	}
+}
+
+func TestLoadWatchlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watchlist.json")
+	os.WriteFile(path, []byte(`{"symbols": [" aapl", "MSFT", ""]}`), 0644)
+	got, err := loadWatchlist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got["AAPL"] || !got["MSFT"] {
+		t.Errorf("got %v", got)
+	}
+}
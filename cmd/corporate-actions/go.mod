@@ -0,0 +1,24 @@
+module github.com/deanturpin/lft2/cmd/corporate-actions
+
+go 1.21
+
+require (
+	github.com/deanturpin/lft2/internal/alpaca v0.0.0
+	github.com/deanturpin/lft2/internal/corporate v0.0.0
+	github.com/deanturpin/lft2/internal/crash v0.0.0
+	github.com/deanturpin/lft2/internal/httpconf v0.0.0
+	github.com/deanturpin/lft2/internal/interrupt v0.0.0
+	github.com/deanturpin/lft2/internal/tz v0.0.0
+	github.com/deanturpin/lft2/internal/version v0.0.0
+)
+
+replace (
+	github.com/deanturpin/lft2/internal/alpaca => ../../internal/alpaca
+	github.com/deanturpin/lft2/internal/corporate => ../../internal/corporate
+	github.com/deanturpin/lft2/internal/crash => ../../internal/crash
+	github.com/deanturpin/lft2/internal/httpconf => ../../internal/httpconf
+	github.com/deanturpin/lft2/internal/interrupt => ../../internal/interrupt
+	github.com/deanturpin/lft2/internal/schema => ../../internal/schema
+	github.com/deanturpin/lft2/internal/tz => ../../internal/tz
+	github.com/deanturpin/lft2/internal/version => ../../internal/version
+)
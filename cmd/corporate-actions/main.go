@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/corporate"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/httpconf"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/tz"
+	"github.com/deanturpin/lft2/internal/version"
+)
+
+// Corporate actions pulls the splits, dividends, mergers and spinoffs
+// announced for the watchlist, from -back days ago to -ahead days on, and
+// writes them to docs/corporate_actions.json. Fetch reads the splits to put
+// saved bars from before an ex date on the new price scale.
+func main() {
+	version.Handle("corporate-actions")
+	tz.SetLog()
+
+	watchlistFile := flag.String("watchlist", "watchlist.json", "Path to watchlist JSON file")
+	out := flag.String("o", corporate.DefaultPath, "Where to write the announcements")
+	back := flag.Int("back", 60, "Days of past ex dates to include")
+	ahead := flag.Int("ahead", 30, "Days of upcoming ex dates to include")
+	flag.Parse()
+	defer crash.Guard("corporate-actions", *watchlistFile)
+
+	// Ctrl-C still saves what was fetched, then exits
+	ctx := interrupt.Context()
+
+	if *back < 0 || *ahead < 0 || *back+*ahead > alpaca.MaxAnnouncementDays {
+		log.Fatalf("-back and -ahead must not be negative and at most %d days together", alpaca.MaxAnnouncementDays)
+	}
+
+	fmt.Println("Low Frequency Trader v2 - Corporate Actions")
+	fmt.Println()
+
+	client, err := alpaca.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	policy, err := httpconf.ForStage("corporate-actions")
+	if err != nil {
+		log.Fatal(err)
+	}
+	client.HTTP = policy
+
+	watched, err := loadWatchlist(*watchlistFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	now := time.Now()
+	f := &corporate.File{
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Since:     now.AddDate(0, 0, -*back).Format(time.DateOnly),
+		Until:     now.AddDate(0, 0, *ahead).Format(time.DateOnly),
+	}
+	announcements, err := client.Announcements(types, f.Since, f.Until)
+	if err != nil {
+		log.Fatalf("fetching corporate actions: %v", err)
+	}
+	f.Actions = selectActions(announcements, watched)
+
+	fmt.Printf("Ex dates %s to %s, %d watchlist symbol(s)\n", f.Since, f.Until, len(watched))
+	for _, a := range f.Actions {
+		detail := ""
+		switch {
+		case a.Type == corporate.Dividend:
+			detail = fmt.Sprintf("$%.4f a share", a.Cash)
+		case a.NewRate > 0 && a.OldRate > 0:
+			detail = fmt.Sprintf("%g for %g", a.NewRate, a.OldRate)
+		}
+		fmt.Printf("  %s  %-6s %-9s %s\n", a.ExDate, a.Symbol, a.Type, detail)
+	}
+
+	if err := corporate.Save(*out, f); err != nil {
+		log.Fatalf("writing %s: %v", *out, err)
+	}
+	fmt.Printf("\n✓ Wrote %s (%d action(s))\n", *out, len(f.Actions))
+
+	interrupt.Stop(ctx, "corporate-actions")
+}
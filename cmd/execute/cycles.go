@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// cyclesFile records the pipeline cycles execute has run, so a retried
+// execute step refuses the orders it already placed. It's under the book's
+// root, docs/ for the default book.
+const cyclesFile = "executed-cycles.json"
+
+// keepCycles is how many executed cycles are remembered, newest last: about
+// six trading days of 5-minute cycles.
+const keepCycles = 500
+
+// cycleField is the pseudo-field readOrders fills from the heartbeat's FIX
+// tag 5001: the cycle entries or exits wrote the order in.
+const cycleField = "cycle"
+
+// ExecutedCycle is one cycle execute has submitted orders for.
+type ExecutedCycle struct {
+	ID       string `json:"id"`
+	Executed string `json:"executed"` // RFC 3339
+}
+
+// CycleLog is docs/executed-cycles.json.
+type CycleLog struct {
+	schema.Header
+	Timestamp string          `json:"timestamp"`
+	Cycles    []ExecutedCycle `json:"cycles"`
+}
+
+// loadCycles reads the cycle log. A missing file is an empty log.
+func loadCycles(path string) (*CycleLog, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &CycleLog{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cycles: %w", err)
+	}
+	if err := schema.Check(path, data); err != nil {
+		return nil, err
+	}
+	l := &CycleLog{}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return l, nil
+}
+
+func (l *CycleLog) has(id string) bool {
+	for _, c := range l.Cycles {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// save records id as executed at now and writes the log to path.
+func (l *CycleLog) save(path, id string, now time.Time) error {
+	l.Cycles = append(l.Cycles, ExecutedCycle{ID: id, Executed: now.UTC().Format(time.RFC3339)})
+	if n := len(l.Cycles); n > keepCycles {
+		l.Cycles = append([]ExecutedCycle(nil), l.Cycles[n-keepCycles:]...)
+	}
+	l.Header = schema.Current()
+	l.Timestamp = now.UTC().Format(time.RFC3339)
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cycles: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// cycleOf returns the cycle entries appended to a client_order_id as
+// _c{cycle}, or "" if it has none.
+func cycleOf(clientOrderID string) string {
+	i := strings.LastIndex(clientOrderID, "_c")
+	if i < 0 {
+		return ""
+	}
+	if id := clientOrderID[i+2:]; schema.ValidCycle(id) {
+		return id
+	}
+	return ""
+}
+
+// cycleGuard admits each order's cycle once. A cycle is recorded as executed
+// before its first order is sent, so an execute that dies part way through
+// can't resend the rest on a retry: a lost order is safer than a doubled
+// one, and the next cycle signals afresh.
+type cycleGuard struct {
+	path    string
+	log     *CycleLog
+	orders  func() ([]alpaca.Order, error) // The broker's recent orders
+	broker  map[string]bool                // Cycles with an order at the broker; nil until asked
+	claimed map[string]bool                // Cycles this run recorded
+	warned  bool
+}
+
+// newCycleGuard loads the log at path. One that can't be read is returned as
+// an error beside a guard with an empty log, which asks the broker as a
+// fresh container does and rewrites the log on the first cycle it admits.
+func newCycleGuard(path string, orders func() ([]alpaca.Order, error)) (*cycleGuard, error) {
+	l, err := loadCycles(path)
+	if err != nil {
+		l = &CycleLog{}
+	}
+	return &cycleGuard{path: path, log: l, orders: orders, claimed: map[string]bool{}}, err
+}
+
+// admit returns why an order from cycle id is refused, or "" once the cycle
+// is recorded as this run's. An order without a cycle comes from a pipeline
+// that doesn't stamp one and is let through unchecked.
+func (g *cycleGuard) admit(id string, now time.Time) (string, error) {
+	if id == "" {
+		if !g.warned {
+			fmt.Println("  [WARNING] orders carry no cycle ID (LFT2_CYCLE) — a retried execute can't detect them")
+			g.warned = true
+		}
+		return "", nil
+	}
+	if g.claimed[id] {
+		return "", nil
+	}
+	if g.log.has(id) {
+		return fmt.Sprintf("cycle %s already executed", id), nil
+	}
+
+	// The log doesn't survive a fresh CI container, so the broker is asked
+	// too: an order tagged with the cycle means it already ran
+	if g.broker == nil {
+		g.broker = map[string]bool{}
+		orders, err := g.orders()
+		if err != nil {
+			fmt.Printf("  [WARNING] broker orders: %v — cycles checked against %s only\n", err, g.path)
+		}
+		for _, o := range orders {
+			if c := cycleOf(o.ClientOrderID); c != "" {
+				g.broker[c] = true
+			}
+		}
+	}
+	if g.broker[id] {
+		return fmt.Sprintf("cycle %s already has orders at the broker", id), nil
+	}
+
+	if err := g.log.save(g.path, id, now); err != nil {
+		return "", fmt.Errorf("recording cycle %s: %w", id, err)
+	}
+	g.claimed[id] = true
+	fmt.Printf("  [cycle] %s\n", id)
+	return "", nil
+}
+
+// recentOrders is the broker's orders from the last day, every status.
+func recentOrders() ([]alpaca.Order, error) {
+	after := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	return client.Orders("status=all&after=" + after + "&limit=500")
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// brokerOrders returns a broker whose recent orders carry the given
+// client_order_ids, counting how often it's asked.
+func brokerOrders(asked *int, ids ...string) func() ([]alpaca.Order, error) {
+	return func() ([]alpaca.Order, error) {
+		*asked++
+		var orders []alpaca.Order
+		for _, id := range ids {
+			orders = append(orders, alpaca.Order{ClientOrderID: id})
+		}
+		return orders, nil
+	}
+}
+
+// --- cycleOf ---
+
+func TestCycleOf(t *testing.T) {
+	for id, want := range map[string]string{
+		"AAPL_vwap-v1_tp1.50_sl1.00_tsl0.75_p0_1741617900_cgh42": "gh42",
+		"AAPL_vwap-v1_tp1.50_sl1.00_tsl0.75_p0_1741617900":       "",
+		"AAPL_x_c":         "",
+		"AAPL_x_cbad.id":   "",
+		"manual":           "",
+		"SYNA_c1_cretry-2": "retry-2",
+	} {
+		if got := cycleOf(id); got != want {
+			t.Errorf("cycleOf(%q): got %q, want %q", id, got, want)
+		}
+	}
+}
+
+// --- cycleGuard ---
+
+func TestCycleGuard_RefusesExecutedCycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "executed-cycles.json")
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	asked := 0
+
+	first, err := newCycleGuard(path, brokerOrders(&asked))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if why, err := first.admit("gh42", now); err != nil || why != "" {
+			t.Fatalf("first run, order %d: got %q, %v; want admitted", i, why, err)
+		}
+	}
+	if asked != 1 {
+		t.Errorf("broker asked %d times, want once per run", asked)
+	}
+
+	// The retry reads the log the first run left
+	retry, err := newCycleGuard(path, brokerOrders(&asked))
+	if err != nil {
+		t.Fatal(err)
+	}
+	why, err := retry.admit("gh42", now.Add(time.Minute))
+	if err != nil || !strings.Contains(why, "already executed") {
+		t.Errorf("retry: got %q, %v; want already executed", why, err)
+	}
+	if why, err := retry.admit("gh43", now.Add(time.Minute)); err != nil || why != "" {
+		t.Errorf("next cycle: got %q, %v; want admitted", why, err)
+	}
+
+	l, err := loadCycles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Cycles) != 2 || l.Cycles[0].ID != "gh42" || l.Cycles[1].ID != "gh43" {
+		t.Errorf("log: got %+v, want gh42 then gh43", l.Cycles)
+	}
+}
+
+// A fresh CI container has no log, but the broker still has the orders
+func TestCycleGuard_RefusesCycleAtBroker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "executed-cycles.json")
+	asked := 0
+	g, err := newCycleGuard(path, brokerOrders(&asked, "AAPL_x_1741617900_cgh42", "manual"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	why, err := g.admit("gh42", time.Now())
+	if err != nil || !strings.Contains(why, "already has orders at the broker") {
+		t.Errorf("got %q, %v; want refused for the broker's orders", why, err)
+	}
+	if l, _ := loadCycles(path); len(l.Cycles) != 0 {
+		t.Errorf("refused cycle recorded: %+v", l.Cycles)
+	}
+}
+
+// A corrupt log is replaced, with the broker's orders guarding meanwhile
+func TestCycleGuard_CorruptLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "executed-cycles.json")
+	os.WriteFile(path, []byte(`{"cycles": [`), 0644)
+	asked := 0
+	g, err := newCycleGuard(path, brokerOrders(&asked, "AAPL_x_1741617900_cgh42"))
+	if err == nil || g == nil {
+		t.Fatalf("got %v, %v; want a guard and the error", g, err)
+	}
+	if why, err := g.admit("gh42", time.Now()); err != nil || !strings.Contains(why, "at the broker") {
+		t.Errorf("gh42: got %q, %v; want refused for the broker's orders", why, err)
+	}
+	if why, err := g.admit("gh43", time.Now()); err != nil || why != "" {
+		t.Errorf("gh43: got %q, %v; want admitted", why, err)
+	}
+	if l, err := loadCycles(path); err != nil || !l.has("gh43") {
+		t.Errorf("log: got %+v, %v; want rewritten with gh43", l, err)
+	}
+}
+
+// Without the broker's orders the log alone still guards
+func TestCycleGuard_BrokerUnavailable(t *testing.T) {
+	g, err := newCycleGuard(filepath.Join(t.TempDir(), "executed-cycles.json"), func() ([]alpaca.Order, error) {
+		return nil, errors.New("HTTP 500")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if why, err := g.admit("gh42", time.Now()); err != nil || why != "" {
+		t.Errorf("got %q, %v; want admitted", why, err)
+	}
+}
+
+func TestCycleGuard_NoCycle(t *testing.T) {
+	asked := 0
+	g, err := newCycleGuard(filepath.Join(t.TempDir(), "executed-cycles.json"), brokerOrders(&asked))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if why, err := g.admit("", time.Now()); err != nil || why != "" {
+			t.Errorf("order %d: got %q, %v; want admitted", i, why, err)
+		}
+	}
+	if asked != 0 {
+		t.Errorf("broker asked %d times for orders without a cycle", asked)
+	}
+}
+
+func TestCycleGuard_UnwritableLog(t *testing.T) {
+	asked := 0
+	g, err := newCycleGuard(filepath.Join(t.TempDir(), "missing", "executed-cycles.json"), brokerOrders(&asked))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.admit("gh42", time.Now()); err == nil {
+		t.Error("got nil, want an error — an unrecorded cycle could be sent twice")
+	}
+}
+
+// --- CycleLog ---
+
+func TestCycleLog_KeepsNewest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "executed-cycles.json")
+	l := &CycleLog{}
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	for i := 0; i < keepCycles+3; i++ {
+		if err := l.save(path, fmt.Sprintf("c%d", i), now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := loadCycles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Cycles) != keepCycles || got.Cycles[0].ID != "c3" || !got.has(fmt.Sprintf("c%d", keepCycles+2)) {
+		t.Errorf("got %d cycles from %s, want the newest %d", len(got.Cycles), got.Cycles[0].ID, keepCycles)
+	}
+	if got.has("c0") {
+		t.Error("oldest cycle kept")
+	}
+}
@@ -120,3 +120,29 @@ func TestReadOrders_MixedContent(t *testing.T) {
 		t.Errorf("unexpected symbols: %q %q", orders[0]["55"], orders[1]["55"])
 	}
 }
+
+func TestReadOrders_BuildFromHeartbeat(t *testing.T) {
+	content := "" +
+		"8=FIX.5.0SP2|35=0|52=20260310-14:35:05|58=1 buy order(s)|1604=0123456789ab|\n" +
+		"8=FIX.5.0SP2|35=D|55=AAPL|11=ORDER_001|\n"
+	orders, err := readOrders(writeFixFile(t, content))
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("got %v, %v", orders, err)
+	}
+	if got := orders[0][builtBy]; got != "0123456789ab" {
+		t.Errorf("build: got %q, want the heartbeat's tag 1604", got)
+	}
+}
+
+func TestReadOrders_CycleFromHeartbeat(t *testing.T) {
+	content := "" +
+		"8=FIX.5.0SP2|35=0|52=20260310-14:35:05|58=1 buy order(s)|5001=gh42|\n" +
+		"8=FIX.5.0SP2|35=D|55=AAPL|11=AAPL_x_1741617300_cgh42|\n"
+	orders, err := readOrders(writeFixFile(t, content))
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("got %v, %v", orders, err)
+	}
+	if got := orders[0][cycleField]; got != "gh42" {
+		t.Errorf("cycle: got %q, want the heartbeat's tag 5001", got)
+	}
+}
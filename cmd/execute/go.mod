@@ -2,6 +2,49 @@ module github.com/deanturpin/lft2/cmd/execute
 
 go 1.21
 
-require github.com/deanturpin/lft2/internal/alpaca v0.0.0
+require (
+	github.com/deanturpin/lft2/internal/alpaca v0.0.0
+	github.com/deanturpin/lft2/internal/audit v0.0.0
+	github.com/deanturpin/lft2/internal/blocklist v0.0.0
+	github.com/deanturpin/lft2/internal/books v0.0.0
+	github.com/deanturpin/lft2/internal/crash v0.0.0
+	github.com/deanturpin/lft2/internal/httpconf v0.0.0
+	github.com/deanturpin/lft2/internal/interrupt v0.0.0
+	github.com/deanturpin/lft2/internal/journal v0.0.0
+	github.com/deanturpin/lft2/internal/latency v0.0.0
+	github.com/deanturpin/lft2/internal/manifest v0.0.0
+	github.com/deanturpin/lft2/internal/quota v0.0.0
+	github.com/deanturpin/lft2/internal/risk v0.0.0
+	github.com/deanturpin/lft2/internal/schema v0.0.0
+	github.com/deanturpin/lft2/internal/sizing v0.0.0
+	github.com/deanturpin/lft2/internal/spreads v0.0.0
+	github.com/deanturpin/lft2/internal/tz v0.0.0
+	github.com/deanturpin/lft2/internal/version v0.0.0
+)
 
-replace github.com/deanturpin/lft2/internal/alpaca => ../../internal/alpaca
+require (
+	github.com/deanturpin/lft2/internal/barfile v0.0.0 // indirect
+	github.com/deanturpin/lft2/internal/vault v0.0.0 // indirect
+)
+
+replace (
+	github.com/deanturpin/lft2/internal/alpaca => ../../internal/alpaca
+	github.com/deanturpin/lft2/internal/audit => ../../internal/audit
+	github.com/deanturpin/lft2/internal/barfile => ../../internal/barfile
+	github.com/deanturpin/lft2/internal/blocklist => ../../internal/blocklist
+	github.com/deanturpin/lft2/internal/books => ../../internal/books
+	github.com/deanturpin/lft2/internal/crash => ../../internal/crash
+	github.com/deanturpin/lft2/internal/httpconf => ../../internal/httpconf
+	github.com/deanturpin/lft2/internal/interrupt => ../../internal/interrupt
+	github.com/deanturpin/lft2/internal/journal => ../../internal/journal
+	github.com/deanturpin/lft2/internal/latency => ../../internal/latency
+	github.com/deanturpin/lft2/internal/manifest => ../../internal/manifest
+	github.com/deanturpin/lft2/internal/quota => ../../internal/quota
+	github.com/deanturpin/lft2/internal/risk => ../../internal/risk
+	github.com/deanturpin/lft2/internal/schema => ../../internal/schema
+	github.com/deanturpin/lft2/internal/sizing => ../../internal/sizing
+	github.com/deanturpin/lft2/internal/spreads => ../../internal/spreads
+	github.com/deanturpin/lft2/internal/tz => ../../internal/tz
+	github.com/deanturpin/lft2/internal/vault => ../../internal/vault
+	github.com/deanturpin/lft2/internal/version => ../../internal/version
+)
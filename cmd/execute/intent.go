@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// fixTime is the FIX UTCTimestamp layout used for tags 52 and 126.
+const fixTime = "20060102-15:04:05"
+
+// validUntil reads an order's valid-until time (FIX tag 126, set by entries
+// and exits). Orders written before the tag existed have none and return the
+// zero time.
+func validUntil(fields map[string]string) (time.Time, error) {
+	raw, ok := fields["126"]
+	if !ok {
+		return time.Time{}, nil
+	}
+	until, err := time.Parse(fixTime, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unreadable expiry %q", raw)
+	}
+	return until, nil
+}
+
+// effectiveFrom reads the start of an order's execution window (FIX tag 168,
+// set by entries for strategies that trade only some hours). Orders without
+// one are valid from the moment they're written and return the zero time.
+func effectiveFrom(fields map[string]string) (time.Time, error) {
+	raw, ok := fields["168"]
+	if !ok {
+		return time.Time{}, nil
+	}
+	from, err := time.Parse(fixTime, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unreadable effective time %q", raw)
+	}
+	return from, nil
+}
+
+// until is validUntil for an order already checked by intentExpired.
+func until(fields map[string]string) time.Time {
+	t, _ := validUntil(fields)
+	return t
+}
+
+// intentExpired reports whether an order's valid-until time has passed, so
+// each order in a replayed or late file is judged on its own. An order
+// without one is accepted; a malformed one is refused, since its age can't
+// be known.
+func intentExpired(fields map[string]string, now time.Time) (bool, string) {
+	until, err := validUntil(fields)
+	if err != nil {
+		return true, err.Error()
+	}
+	if !until.IsZero() && now.After(until) {
+		return true, fmt.Sprintf("intent expired at %s UTC (%s ago)", until.Format("15:04:05"), now.Sub(until).Round(time.Second))
+	}
+	return false, ""
+}
+
+// outsideWindow reports whether an order arrived before its strategy's
+// execution window opened. The window's close is already the order's
+// valid-until time, so intentExpired covers the other end.
+func outsideWindow(fields map[string]string, now time.Time) (bool, string) {
+	from, err := effectiveFrom(fields)
+	if err != nil {
+		return true, err.Error()
+	}
+	if !from.IsZero() && now.Before(from) {
+		return true, fmt.Sprintf("outside its execution window, which opens at %s UTC", from.Format("15:04:05"))
+	}
+	return false, ""
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- intentExpired ---
+
+func TestIntentExpired(t *testing.T) {
+	now := time.Date(2026, 1, 2, 14, 38, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		fields  map[string]string
+		expired bool
+		reason  string
+	}{
+		{"no tag", map[string]string{"55": "AAPL"}, false, ""},
+		{"still valid", map[string]string{"126": "20260102-14:42:00"}, false, ""},
+		{"at the deadline", map[string]string{"126": "20260102-14:38:00"}, false, ""},
+		{"lapsed", map[string]string{"126": "20260102-14:37:00"}, true, "intent expired at 14:37:00 UTC (1m0s ago)"},
+		{"malformed", map[string]string{"126": "soon"}, true, "unreadable expiry"},
+	}
+	for _, tt := range tests {
+		expired, reason := intentExpired(tt.fields, now)
+		if expired != tt.expired || !strings.HasPrefix(reason, tt.reason) {
+			t.Errorf("%s: got %t %q, want %t %q", tt.name, expired, reason, tt.expired, tt.reason)
+		}
+	}
+}
+
+// --- outsideWindow ---
+
+func TestOutsideWindow(t *testing.T) {
+	now := time.Date(2026, 1, 2, 14, 38, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		fields map[string]string
+		early  bool
+		reason string
+	}{
+		{"no tag", map[string]string{"55": "AAPL"}, false, ""},
+		{"open", map[string]string{"168": "20260102-14:30:00"}, false, ""},
+		{"at the open", map[string]string{"168": "20260102-14:38:00"}, false, ""},
+		{"not yet", map[string]string{"168": "20260102-15:00:00"}, true, "outside its execution window, which opens at 15:00:00 UTC"},
+		{"malformed", map[string]string{"168": "later"}, true, "unreadable effective time"},
+	}
+	for _, tt := range tests {
+		early, reason := outsideWindow(tt.fields, now)
+		if early != tt.early || !strings.HasPrefix(reason, tt.reason) {
+			t.Errorf("%s: got %t %q, want %t %q", tt.name, early, reason, tt.early, tt.reason)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/latency"
+	"github.com/deanturpin/lft2/internal/manifest"
+)
+
+// sendingTime is the pseudo-field readOrders fills from the heartbeat's FIX
+// tag 52: when entries or exits wrote the file.
+const sendingTime = "sent"
+
+// stopwatch collects a latency sample for each order submitted this run.
+// The bar and fetch times come from the bars manifest fetch wrote.
+type stopwatch struct {
+	fetched string
+	bars    map[string]string // Symbol → close of its latest bar
+	samples []latency.Sample
+}
+
+// newStopwatch reads the manifest at path. Without one the bar and fetch
+// hops go untimed.
+func newStopwatch(path string) *stopwatch {
+	w := &stopwatch{bars: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("  [skip] latency: bar and fetch times unknown: %v\n", err)
+		return w
+	}
+	m, err := manifest.Parse(path, data)
+	if err != nil {
+		fmt.Printf("  [skip] latency: bar and fetch times unknown: %v\n", err)
+		return w
+	}
+	w.fetched = m.Timestamp
+	for _, e := range m.Symbols {
+		if last, err := time.Parse(time.RFC3339, e.LastBarTime); err == nil {
+			w.bars[e.Symbol] = stamp(last.Add(latency.BarLength))
+		}
+	}
+	return w
+}
+
+// submitted times an order the broker accepted at.
+func (w *stopwatch) submitted(req OrderRequest, at time.Time) {
+	s := latency.Sample{
+		ClientOrderID: req.ClientOrdID,
+		Symbol:        req.Symbol,
+		Side:          req.Side,
+		BarClose:      w.bars[req.Symbol],
+		Fetched:       w.fetched,
+		Submitted:     stamp(at),
+	}
+	if !req.Signalled.IsZero() {
+		s.Signalled = stamp(req.Signalled)
+	}
+	w.samples = append(w.samples, s)
+}
+
+// save adds this run's samples to the latency file at path.
+func (w *stopwatch) save(path string, now time.Time) error {
+	if len(w.samples) == 0 {
+		return nil
+	}
+	f, err := latency.Load(path)
+	if err != nil {
+		return err
+	}
+	for _, s := range w.samples {
+		f.Add(s)
+	}
+	return latency.Save(path, f, now)
+}
+
+// signalledAt reads when an order was written, from its file's heartbeat.
+func signalledAt(fields map[string]string) time.Time {
+	t, err := time.Parse(fixTime, fields[sendingTime])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func stamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/latency"
+)
+
+// --- readOrders / signalledAt ---
+
+func TestReadOrders_SendingTime(t *testing.T) {
+	f := writeFixFile(t, "8=FIX.5.0SP2|35=0|52=20260310-15:06:30.250|58=entries|\n"+
+		"8=FIX.5.0SP2|35=D|55=AAPL|11=ORDER_001|\n")
+	orders, err := readOrders(f)
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("got %d orders, %v", len(orders), err)
+	}
+	want := time.Date(2026, 3, 10, 15, 6, 30, 250e6, time.UTC)
+	if got := signalledAt(orders[0]); !got.Equal(want) {
+		t.Errorf("signalled at %s, want %s", got, want)
+	}
+	if got := signalledAt(map[string]string{}); !got.IsZero() {
+		t.Errorf("no heartbeat: got %s, want the zero time", got)
+	}
+}
+
+// --- stopwatch ---
+
+func TestStopwatch(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "bars-manifest.json")
+	if err := os.WriteFile(manifestPath, []byte(`{"schema_version": 1, "timestamp": "2026-03-10T15:05:50Z",
+		"symbols": [{"symbol": "AAPL", "last_bar_time": "2026-03-10T15:00:00Z"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "latency.json")
+
+	w := newStopwatch(manifestPath)
+	w.submitted(OrderRequest{Symbol: "AAPL", Side: "buy", ClientOrdID: "a",
+		Signalled: time.Date(2026, 3, 10, 15, 6, 30, 0, time.UTC)}, time.Date(2026, 3, 10, 15, 6, 40, 0, time.UTC))
+	w.submitted(OrderRequest{Symbol: "MSFT", Side: "sell", ClientOrdID: "b"}, time.Date(2026, 3, 10, 15, 6, 41, 0, time.UTC))
+	if err := w.save(path, time.Date(2026, 3, 10, 15, 7, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := latency.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []latency.Sample{
+		{ClientOrderID: "a", Symbol: "AAPL", Side: "buy", BarClose: "2026-03-10T15:05:00Z",
+			Fetched: "2026-03-10T15:05:50Z", Signalled: "2026-03-10T15:06:30Z", Submitted: "2026-03-10T15:06:40Z"},
+		{ClientOrderID: "b", Symbol: "MSFT", Side: "sell", // Not in the manifest, no heartbeat
+			Fetched: "2026-03-10T15:05:50Z", Submitted: "2026-03-10T15:06:41Z"},
+	}
+	if len(f.Samples) != len(want) || f.Samples[0] != want[0] || f.Samples[1] != want[1] {
+		t.Errorf("got  %+v\nwant %+v", f.Samples, want)
+	}
+	if h := f.Hops[0]; h.Name != "bar_close→fetched" || h.P50 != 50 {
+		t.Errorf("first hop: got %+v", h)
+	}
+}
+
+func TestStopwatch_NoManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "latency.json")
+	w := newStopwatch(filepath.Join(t.TempDir(), "missing.json"))
+	if err := w.save(path, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("wrote latency.json with nothing submitted")
+	}
+
+	w.submitted(OrderRequest{Symbol: "AAPL", ClientOrdID: "a"}, time.Now())
+	if s := w.samples[0]; s.BarClose != "" || s.Fetched != "" || s.Submitted == "" {
+		t.Errorf("got %+v, want only the submit time", s)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/risk"
+	"github.com/deanturpin/lft2/internal/sizing"
+)
+
+// liquiditySizer trims buys placed while a symbol trades thinly, by its
+// curve in liquidity.json. A zero floor turns it off.
+type liquiditySizer struct {
+	curves *risk.Liquidity
+	floor  float64
+}
+
+// size returns qty scaled by symbol's liquidity at now, in whole shares,
+// and the factor applied. Without a curve the order is left at full size.
+func (l liquiditySizer) size(symbol string, qty alpaca.Decimal, now time.Time) (alpaca.Decimal, float64, error) {
+	if l.floor <= 0 || l.curves == nil {
+		return qty, 1, nil
+	}
+	curve, ok := l.curves.Symbols[symbol]
+	if !ok {
+		return qty, 1, nil
+	}
+	factor := curve.Factor(now, l.floor)
+	if factor >= 1 {
+		return qty, 1, nil
+	}
+	scaled, err := sizing.Shares.Order(qty*alpaca.Decimal(factor), 0)
+	return scaled, factor, err
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/risk"
+)
+
+func TestLiquiditySizer(t *testing.T) {
+	weights := make([]float64, risk.Buckets)
+	for i := range weights {
+		weights[i] = 1.2
+	}
+	weights[5] = 0.4 // 12:00 to 12:30 New York
+	curves := &risk.Liquidity{Symbols: map[string]risk.Curve{"AAPL": {Days: 10, Weights: weights}}}
+	lunch := time.Date(2026, 3, 10, 16, 10, 0, 0, time.UTC)
+	open := time.Date(2026, 3, 10, 13, 35, 0, 0, time.UTC)
+
+	l := liquiditySizer{curves: curves, floor: 0.5}
+	if qty, factor, err := l.size("AAPL", 15, lunch); err != nil || qty != 7 || factor != 0.5 {
+		t.Errorf("lunch: got %s at %g (%v), want 7 shares at the 0.5 floor", qty, factor, err)
+	}
+	if qty, factor, _ := l.size("AAPL", 15, open); qty != 15 || factor != 1 {
+		t.Errorf("open: got %s at %g, want full size", qty, factor)
+	}
+	if qty, _, _ := l.size("MSFT", 15, lunch); qty != 15 {
+		t.Errorf("no curve: got %s, want full size", qty)
+	}
+	if _, _, err := l.size("AAPL", 1, lunch); err == nil {
+		t.Error("one share halved: want an error")
+	}
+	if qty, _, _ := (liquiditySizer{curves: curves}).size("AAPL", 15, lunch); qty != 15 {
+		t.Errorf("off: got %s, want full size", qty)
+	}
+}
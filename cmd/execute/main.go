@@ -7,59 +7,49 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/audit"
+	"github.com/deanturpin/lft2/internal/blocklist"
+	"github.com/deanturpin/lft2/internal/books"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/httpconf"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/journal"
+	"github.com/deanturpin/lft2/internal/latency"
+	"github.com/deanturpin/lft2/internal/manifest"
+	"github.com/deanturpin/lft2/internal/quota"
+	"github.com/deanturpin/lft2/internal/risk"
+	"github.com/deanturpin/lft2/internal/sizing"
+	"github.com/deanturpin/lft2/internal/spreads"
+	"github.com/deanturpin/lft2/internal/tz"
+	"github.com/deanturpin/lft2/internal/version"
 )
 
-// Account data from Alpaca /v2/account
-type Account struct {
-	Cash           string `json:"cash"`
-	BuyingPower    string `json:"buying_power"`
-	PortfolioValue string `json:"portfolio_value"`
-}
-
-// Position data from Alpaca /v2/positions
-type Position struct {
-	Symbol string `json:"symbol"`
-	Qty    string `json:"qty"`
-	Side   string `json:"side"`
-}
-
 // OrderRequest is the JSON body for POST /v2/orders
 type OrderRequest struct {
-	Symbol      string `json:"symbol"`
-	Qty         string `json:"qty"`
-	Side        string `json:"side"`        // "buy" or "sell"
-	Type        string `json:"type"`        // "market"
-	TimeInForce string `json:"time_in_force"` // "day"
-	ClientOrdID string `json:"client_order_id,omitempty"`
+	Symbol      string         `json:"symbol"`
+	Qty         alpaca.Decimal `json:"qty"`
+	Side        string         `json:"side"`          // "buy" or "sell"
+	Type        string         `json:"type"`          // "market"
+	TimeInForce string         `json:"time_in_force"` // "day"
+	ClientOrdID string         `json:"client_order_id,omitempty"`
+
+	ValidUntil time.Time `json:"-"` // From FIX tag 126; retries stop here too
+	Signalled  time.Time `json:"-"` // From the heartbeat's tag 52, for latency.json
+	Build      string    `json:"-"` // From the heartbeat's tag 1604: the entries or exits build
 }
 
 var client alpaca.Client
 
-func fetchAccount() (*Account, error) {
-	body, err := client.Get(client.BaseURL + "/v2/account")
-	if err != nil {
-		return nil, err
-	}
-	var account Account
-	if err := json.Unmarshal(body, &account); err != nil {
-		return nil, err
-	}
-	return &account, nil
-}
-
-func fetchPositions() (map[string]Position, error) {
-	body, err := client.Get(client.BaseURL + "/v2/positions")
+func fetchPositions() (map[string]alpaca.Position, error) {
+	list, err := client.Positions()
 	if err != nil {
 		return nil, err
 	}
-	var list []Position
-	if err := json.Unmarshal(body, &list); err != nil {
-		return nil, err
-	}
 	// Index by symbol for O(1) lookup
-	positions := make(map[string]Position, len(list))
+	positions := make(map[string]alpaca.Position, len(list))
 	for _, p := range list {
 		positions[p.Symbol] = p
 	}
@@ -80,7 +70,8 @@ func parseFIX(line string) map[string]string {
 }
 
 // readOrders parses a .fix file and returns the list of order field maps
-// (heartbeat lines are filtered out). Returns nil if the file doesn't exist.
+// (heartbeat lines are filtered out, their sending time, build and cycle
+// copied to the orders that follow). Returns nil if the file doesn't exist.
 func readOrders(path string) ([]map[string]string, error) {
 	f, err := os.Open(path)
 	if os.IsNotExist(err) {
@@ -93,6 +84,7 @@ func readOrders(path string) ([]map[string]string, error) {
 	defer f.Close()
 
 	var orders []map[string]string
+	sent, build, cycle := "", "", ""
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -100,11 +92,16 @@ func readOrders(path string) ([]map[string]string, error) {
 			continue
 		}
 		fields := parseFIX(line)
+		fields[rawLine] = line
 		if fields["35"] == "0" {
 			// Heartbeat — confirm pipeline ran
-			fmt.Printf("  [heartbeat] ts=%s text=%s\n", fields["52"], fields["58"])
+			fmt.Printf("  [heartbeat] ts=%s text=%s build=%s cycle=%s\n", fields["52"], fields["58"], fields["1604"], fields["5001"])
+			sent, build, cycle = fields["52"], fields["1604"], fields["5001"]
 			continue
 		}
+		fields[sendingTime] = sent
+		fields[builtBy] = build
+		fields[cycleField] = cycle
 		orders = append(orders, fields)
 	}
 	return orders, scanner.Err()
@@ -139,26 +136,91 @@ func submitOrder(req OrderRequest) error {
 	return nil
 }
 
+// Execute submits the cycle's orders from buy.fix and sell.fix to Alpaca,
+// one at a time in file order, paced by LFT2_ORDER_DELAY and
+// LFT2_ORDER_JITTER. An order is refused before it's sent if its signature
+// doesn't match LFT2_ORDER_KEY, its valid-until time has passed or its
+// window hasn't opened, or its cycle was already executed; a buy also if
+// the symbol is blocked, already held or quoted too wide. A blocked account
+// sends nothing, and a pattern day trader under $25,000 sends only sells.
+// Settings that only tune or gate buys fall back with a warning, so a bad
+// one never stops the exits. Orders refused with a 429 are retried until
+// LFT2_SUBMIT_BUDGET runs out. Each submission goes in the day's audit log
+// and each order's outcome in execution-result.json, and the orders sent
+// are then looked up at the broker. It exits 1 if any order was rejected,
+// errored or is missing there.
 func main() {
-	apiKey := os.Getenv("ALPACA_API_KEY")
-	apiSecret := os.Getenv("ALPACA_API_SECRET")
-	if apiKey == "" || apiSecret == "" {
-		log.Fatal("ALPACA_API_KEY and ALPACA_API_SECRET must be set")
+	version.Handle("execute")
+
+	// A book's orders, cycles and results are under its own root
+	_, book, err := books.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	root := book.Root()
+	defer crash.Guard("execute", root+"buy.fix", root+"sell.fix", blocklist.DefaultPath)
+
+	tz.SetLog()
+
+	// Ctrl-C lets the order being sent finish, then skips the rest
+	ctx := interrupt.Context()
+
+	if client, err = alpaca.FromEnv(); err != nil {
+		log.Fatal(err)
+	}
+	policy, err := httpconf.ForStage("execute")
+	if err != nil {
+		log.Fatal(err)
+	}
+	client.HTTP = policy
+
+	// Every order request goes on record with the broker's reply before
+	// execute acts on it, so a disputed fill can be checked against what
+	// was actually sent
+	auditLog, err := audit.Open(audit.DirFromEnv(), time.Now())
+	if err != nil {
+		log.Fatal("opening order audit log: ", err)
+	}
+	client.Audit = func(x alpaca.Exchange) {
+		if err := auditLog.Record(audit.NewEntry(x.Sent, x.Request, x.Body, x.Response, x.Reply, x.Err)); err != nil {
+			fmt.Printf("  [ERROR] audit log: %v\n", err)
+		}
 	}
-	client = alpaca.New(apiKey, apiSecret, os.Getenv("ALPACA_BASE_URL"), "")
 
 	fmt.Println("Low Frequency Trader v2 - Trade Executor")
 	fmt.Println(strings.Repeat("─", 50))
 
 	// ── Account ──────────────────────────────────────────
 	fmt.Println("\n[account]")
-	account, err := fetchAccount()
+	account, err := client.Account()
 	if err != nil {
 		log.Fatal("fetching account: ", err)
 	}
-	fmt.Printf("  Cash:            $%s\n", account.Cash)
-	fmt.Printf("  Buying Power:    $%s\n", account.BuyingPower)
-	fmt.Printf("  Portfolio Value: $%s\n", account.PortfolioValue)
+	fmt.Printf("  Cash:            $%s\n", account.Cash.Money())
+	fmt.Printf("  Buying Power:    $%s\n", account.BuyingPower.Money())
+	fmt.Printf("  Portfolio Value: $%s\n", account.PortfolioValue.Money())
+	fmt.Printf("  Day Trades:      %d (pattern day trader: %t)\n", account.DaytradeCount, account.PatternDayTrader)
+	fmt.Printf("  Shorting:        %t\n", account.ShortingEnabled)
+
+	// Every outcome is tallied for execution-result.json
+	result := &Result{}
+
+	// Restrictions are checked up front; Alpaca would reject each order with
+	// an opaque 403 otherwise
+	if reason := account.TradingBlock(); reason != "" {
+		fmt.Printf("\n✗ %s — no orders submitted\n", reason)
+		result.Blocked = reason
+		if err := result.save(root+resultFile, time.Now()); err != nil {
+			log.Fatal("writing execution result: ", err)
+		}
+		return
+	}
+
+	// Positions are closed before market close, so every entry is a day trade
+	dayTradeBlock := account.DayTradeBlock()
+	if dayTradeBlock != "" {
+		fmt.Printf("  [WARNING] %s — buys suspended, sells still submitted\n", dayTradeBlock)
+	}
 
 	// ── Positions ─────────────────────────────────────────
 	fmt.Println("\n[positions]")
@@ -173,9 +235,86 @@ func main() {
 		fmt.Printf("  %-6s qty=%s side=%s\n", sym, p.Qty, p.Side)
 	}
 
+	// Orders are paced so a large batch doesn't hit the broker in one burst.
+	// These and the settings below only tune or gate orders, so one that
+	// doesn't parse falls back with a warning rather than stop the exits.
+	delay, jitter, err := pacingFromEnv()
+	if err != nil {
+		fmt.Printf("\n  [WARNING] %v — default pacing\n", err)
+		delay, jitter = defaultOrderDelay, defaultOrderJitter
+	}
+	pace := newPacer(delay, jitter)
+
+	// Rate-limited orders are retried until the submit budget runs out
+	budget, err := submitBudgetFromEnv()
+	if err != nil {
+		fmt.Printf("\n  [WARNING] %v — submit budget %s\n", err, defaultSubmitBudget)
+		budget = defaultSubmitBudget
+	}
+	deadline := time.Now().Add(budget)
+	retries := newRequeue(ctx, submitOrder)
+	signalled := map[string]bool{}
+
+	// Tags for slicing results by experiment, journalled once orders are in
+	tags, err := journal.TagsFromEnv()
+	if err != nil {
+		fmt.Printf("\n  [WARNING] %v — orders not tagged\n", err)
+		tags = nil
+	}
+	if len(tags) > 0 {
+		fmt.Printf("\n  Order tags: %s\n", journal.FormatTags(tags))
+	}
+
+	// Each submitted order is timed from its bar to the broker
+	clock := newStopwatch(manifest.DefaultPath)
+
+	// Orders are only trusted as entries and exits wrote them
+	orderKey := orderKeyFromEnv()
+	if len(orderKey) == 0 {
+		fmt.Println("\n  [WARNING] LFT2_ORDER_KEY not set — order signatures not verified")
+	}
+
+	// Blocklist is re-checked here as it may have changed since filter ran.
+	// One that can't be read refuses every buy; the exits still go out.
+	buysRefused := ""
+	blocks, err := blocklist.Load(blocklist.DefaultPath)
+	if err != nil {
+		buysRefused = err.Error()
+		fmt.Printf("\n  [WARNING] %s — buys refused, sells still submitted\n", buysRefused)
+		blocks = &blocklist.List{}
+	}
+
+	// Buys are checked against this cycle's quotes from fetch
+	latest, err := spreads.LoadQuotes(spreads.QuotesPath)
+	if err != nil {
+		fmt.Printf("\n  [WARNING] %v — spreads not checked\n", err)
+		latest = &spreads.Quotes{}
+	}
+
+	// Buys are trimmed while a symbol trades thinly, if asked to
+	floor, err := risk.LiquidityFloorFromEnv()
+	if err != nil {
+		fmt.Printf("\n  [WARNING] %v — buys at full size\n", err)
+		floor = 0
+	}
+	liquidity := liquiditySizer{floor: floor}
+	if floor > 0 {
+		if liquidity.curves, err = risk.LoadLiquidity(root + "liquidity.json"); err != nil {
+			fmt.Printf("\n  [WARNING] %v — buys at full size\n", err)
+		} else {
+			fmt.Printf("\n  Liquidity sizing: curves for %d symbol(s), floor %.0f%%\n", len(liquidity.curves.Symbols), floor*100)
+		}
+	}
+
+	// Each cycle's orders are sent once, however often execute is retried
+	cycles, err := newCycleGuard(root+cyclesFile, recentOrders)
+	if err != nil {
+		fmt.Printf("\n  [WARNING] %v — cycles checked at the broker only\n", err)
+	}
+
 	// ── Buys first ────────────────────────────────────────
-	fmt.Println("\n[buy orders] docs/buy.fix")
-	buyOrders, err := readOrders("docs/buy.fix")
+	fmt.Printf("\n[buy orders] %sbuy.fix\n", root)
+	buyOrders, err := readOrders(root + "buy.fix")
 	if err != nil {
 		log.Fatal("reading buy.fix: ", err)
 	}
@@ -183,11 +322,59 @@ func main() {
 	buysSubmitted := 0
 	for _, fields := range buyOrders {
 		symbol := fields["55"]
-		clientOrdID := fields["11"] // symbol_strategy_tp_sl_tsl_timestamp — built by entries.cxx
+		clientOrdID := fields["11"] // symbol_strategy-vN_tp_sl_tsl_phash_timestamp — built by entries.cxx
 		strategy := fields["58"]    // FIX tag 58: strategy name for display only
 
+		if ctx.Err() != nil {
+			result.skip(symbol, "buy", "interrupted")
+			continue
+		}
+
 		if symbol == "" {
 			fmt.Printf("  [skip] missing symbol\n")
+			result.skip(symbol, "buy", "missing symbol")
+			continue
+		}
+
+		if err := checkSignature(fields, orderKey); err != nil {
+			fmt.Printf("  [ERROR] %s %v — not submitted\n", symbol, err)
+			result.add(symbol, "buy", outcomeError, err.Error())
+			continue
+		}
+
+		if expired, why := intentExpired(fields, time.Now()); expired {
+			fmt.Printf("  [skip] %s %s\n", symbol, why)
+			result.skip(symbol, "buy", why)
+			continue
+		}
+
+		if early, why := outsideWindow(fields, time.Now()); early {
+			fmt.Printf("  [skip] %s %s\n", symbol, why)
+			result.skip(symbol, "buy", why)
+			continue
+		}
+
+		if dayTradeBlock != "" {
+			fmt.Printf("  [skip] %s day trading restricted: %s\n", symbol, dayTradeBlock)
+			result.skip(symbol, "buy", "day trading restricted: "+dayTradeBlock)
+			continue
+		}
+
+		if buysRefused != "" {
+			fmt.Printf("  [skip] %s buys refused: %s\n", symbol, buysRefused)
+			result.skip(symbol, "buy", "buys refused: "+buysRefused)
+			continue
+		}
+
+		if entry, blocked := blocks.Check(symbol, time.Now()); blocked {
+			fmt.Printf("  [skip] %s blocked: %s\n", symbol, entry.Reason)
+			result.skip(symbol, "buy", "blocked: "+entry.Reason)
+			continue
+		}
+
+		if why := wideSpread(latest, symbol, time.Now()); why != "" {
+			fmt.Printf("  [skip] %s %s\n", symbol, why)
+			result.skip(symbol, "buy", why)
 			continue
 		}
 
@@ -195,36 +382,79 @@ func main() {
 		if held, ok := positions[symbol]; ok {
 			fmt.Printf("  [skip] %s already held (qty=%s side=%s)\n",
 				symbol, held.Qty, held.Side)
+			result.skip(symbol, "buy", "already held")
 			continue
 		}
 
-		// Quantity is set by entries.cxx (FIX tag 38) — trust it, don't recalculate
-		qty := fields["38"]
-		if qty == "" || qty == "0" {
-			fmt.Printf("  [skip] %s missing qty in FIX message\n", symbol)
+		// Quantity is set by entries.cxx (FIX tag 38) — trust it, don't
+		// recalculate, but round it to whole shares as entries sizes them
+		qty, err := alpaca.ParseDecimal(fields["38"])
+		if err != nil || qty <= 0 {
+			fmt.Printf("  [skip] %s missing or invalid qty %q in FIX message\n", symbol, fields["38"])
+			result.skip(symbol, "buy", fmt.Sprintf("invalid qty %q", fields["38"]))
+			continue
+		}
+		if qty, err = sizing.Shares.Order(qty, 0); err != nil {
+			fmt.Printf("  [skip] %s %v\n", symbol, err)
+			result.skip(symbol, "buy", err.Error())
 			continue
 		}
 
+		full := qty
+		qty, factor, err := liquidity.size(symbol, qty, time.Now())
+		if err != nil {
+			why := fmt.Sprintf("liquidity sizing to %.0f%%: %v", factor*100, err)
+			fmt.Printf("  [skip] %s %s\n", symbol, why)
+			result.skip(symbol, "buy", why)
+			continue
+		}
+		if factor < 1 {
+			fmt.Printf("  [liquidity] %s thin at this time of day — %s of %s shares (%.0f%%)\n", symbol, qty, full, factor*100)
+		}
+
+		if why, err := cycles.admit(fields[cycleField], time.Now()); err != nil {
+			fmt.Printf("  [ERROR] %s %v — not submitted\n", symbol, err)
+			result.add(symbol, "buy", outcomeError, err.Error())
+			continue
+		} else if why != "" {
+			fmt.Printf("  [skip] %s %s\n", symbol, why)
+			result.skip(symbol, "buy", why)
+			continue
+		}
+
+		pace.wait()
 		fmt.Printf("  [buy]  %s strategy=%s qty=%s id=%s\n", symbol, strategy, qty, clientOrdID)
-		if err := submitOrder(OrderRequest{
+		signalled[symbol] = true
+		req := OrderRequest{
 			Symbol:      symbol,
 			Qty:         qty,
 			Side:        "buy",
 			Type:        "market",
 			TimeInForce: "day",
 			ClientOrdID: clientOrdID,
-		}); err != nil {
+			ValidUntil:  until(fields),
+			Signalled:   signalledAt(fields),
+			Build:       fields[builtBy],
+		}
+		requeued, err := retries.offer(req)
+		if err != nil {
 			fmt.Printf("  [ERROR] %v\n", err)
 		}
-		buysSubmitted++
+		if !requeued {
+			result.submitted(req, err)
+			if err == nil {
+				clock.submitted(req, time.Now())
+				buysSubmitted++
+			}
+		}
 	}
 	if buysSubmitted == 0 && len(buyOrders) == 0 {
 		fmt.Println("  (no orders)")
 	}
 
 	// ── Sells after buys ──────────────────────────────────
-	fmt.Println("\n[sell orders] docs/sell.fix")
-	sellOrders, err := readOrders("docs/sell.fix")
+	fmt.Printf("\n[sell orders] %ssell.fix\n", root)
+	sellOrders, err := readOrders(root + "sell.fix")
 	if err != nil {
 		log.Fatal("reading sell.fix: ", err)
 	}
@@ -234,38 +464,179 @@ func main() {
 		symbol := fields["55"]
 		clOrdID := fields["11"]
 
+		if ctx.Err() != nil {
+			result.skip(symbol, "sell", "interrupted")
+			continue
+		}
+
 		if symbol == "" {
 			fmt.Printf("  [skip] missing symbol in order id=%s\n", clOrdID)
+			result.skip(symbol, "sell", "missing symbol")
+			continue
+		}
+
+		if err := checkSignature(fields, orderKey); err != nil {
+			fmt.Printf("  [ERROR] %s %v — not submitted\n", symbol, err)
+			result.add(symbol, "sell", outcomeError, err.Error())
+			continue
+		}
+
+		if expired, why := intentExpired(fields, time.Now()); expired {
+			fmt.Printf("  [skip] %s %s\n", symbol, why)
+			result.skip(symbol, "sell", why)
+			continue
+		}
+
+		if early, why := outsideWindow(fields, time.Now()); early {
+			fmt.Printf("  [skip] %s %s\n", symbol, why)
+			result.skip(symbol, "sell", why)
 			continue
 		}
 
 		// Check we actually hold this — don't sell what we don't own.
 		// This should never happen: exits.cxx reads positions.json which is
 		// written by the account module from the same live API. If it does,
-		// there is a pipeline ordering bug or a stale positions.json.
+		// there is a pipeline ordering bug or a stale positions.json, as
+		// after an outage (e2e/downtime_test.go).
 		held, ok := positions[symbol]
 		if !ok {
 			fmt.Printf("  [WARNING] %s in sell.fix but NOT in live positions — pipeline bug? skipping\n", symbol)
+			result.skip(symbol, "sell", "not in live positions")
+			continue
+		}
+
+		// Selling a short position would extend it rather than close it
+		if held.Side != "long" {
+			fmt.Printf("  [skip] %s position is %s — sells only close longs (shorting enabled: %t)\n",
+				symbol, held.Side, account.ShortingEnabled)
+			result.skip(symbol, "sell", held.Side+" position")
+			continue
+		}
+
+		// The whole position, which may be fractional
+		qty, err := sizing.Position.Order(held.Qty, held.CurrentPrice)
+		if err != nil {
+			fmt.Printf("  [skip] %s %v\n", symbol, err)
+			result.skip(symbol, "sell", err.Error())
 			continue
 		}
 
-		fmt.Printf("  [sell] %s qty=%s (full position)\n", symbol, held.Qty)
-		if err := submitOrder(OrderRequest{
+		if why, err := cycles.admit(fields[cycleField], time.Now()); err != nil {
+			fmt.Printf("  [ERROR] %s %v — not submitted\n", symbol, err)
+			result.add(symbol, "sell", outcomeError, err.Error())
+			continue
+		} else if why != "" {
+			fmt.Printf("  [skip] %s %s\n", symbol, why)
+			result.skip(symbol, "sell", why)
+			continue
+		}
+
+		pace.wait()
+		fmt.Printf("  [sell] %s qty=%s (full position)\n", symbol, qty)
+		signalled[symbol] = true
+		req := OrderRequest{
 			Symbol:      symbol,
-			Qty:         held.Qty,
+			Qty:         qty,
 			Side:        "sell",
 			Type:        "market",
 			TimeInForce: "day",
 			ClientOrdID: clOrdID,
-		}); err != nil {
+			ValidUntil:  until(fields),
+			Signalled:   signalledAt(fields),
+			Build:       fields[builtBy],
+		}
+		requeued, err := retries.offer(req)
+		if err != nil {
 			fmt.Printf("  [ERROR] %v\n", err)
 		}
-		sellsSubmitted++
+		if !requeued {
+			result.submitted(req, err)
+			if err == nil {
+				clock.submitted(req, time.Now())
+				sellsSubmitted++
+			}
+		}
 	}
 	if sellsSubmitted == 0 && len(sellOrders) == 0 {
 		fmt.Println("  (no orders)")
 	}
 
+	// ── Expired earlier today ─────────────────────────────
+	// Orders that ran out of budget in an earlier cycle are resubmitted only
+	// if this cycle's pipeline still signals them. Checked before this
+	// cycle's own expiries are journalled.
+	if notes, err := journal.Load(journal.DefaultPath); err != nil {
+		fmt.Printf("\n[WARNING] reading journal: %v\n", err)
+	} else if lines := reviewExpired(notes.Tagged(journal.ExpiredUnsubmitted, time.Time{}), signalled, time.Now()); len(lines) > 0 {
+		fmt.Println("\n[expired unsubmitted today]")
+		for _, l := range lines {
+			fmt.Println("  " + l)
+		}
+	}
+
+	// ── Rate-limited retries ──────────────────────────────
+	if len(retries.pending) > 0 {
+		fmt.Printf("\n[retries] %d rate-limited order(s), budget until %s\n",
+			len(retries.pending), tz.Clock(deadline))
+		submitted, expired, failed := retries.drain(deadline)
+		for _, req := range submitted {
+			result.submitted(req, nil)
+			clock.submitted(req, time.Now())
+			if req.Side == "buy" {
+				buysSubmitted++
+			} else {
+				sellsSubmitted++
+			}
+		}
+		for _, req := range expired {
+			fmt.Printf("  [expired] %s %s unsubmitted — journalled for next cycle\n", req.Side, req.Symbol)
+			result.add(req.Symbol, req.Side, outcomeExpired, "rate limited until the submit budget or the intent ran out")
+		}
+		for _, f := range failed {
+			result.submitted(f.req, f.err)
+		}
+		if len(expired) > 0 {
+			if err := journalExpired(journal.DefaultPath, expired, time.Now()); err != nil {
+				fmt.Printf("  [ERROR] journalling expired orders: %v\n", err)
+			}
+		}
+	}
+
+	if n, err := journalTags(journal.DefaultPath, result.Orders, tags, time.Now()); err != nil {
+		fmt.Printf("\n[WARNING] journalling order tags: %v\n", err)
+	} else if n > 0 {
+		fmt.Printf("\n  Tagged %d order(s) in %s\n", n, journal.DefaultPath)
+	}
+
+	// ── Verify at the broker ──────────────────────────────
+	// A reply lost after the broker accepted an order, or an order placed
+	// despite an error here, would otherwise go unseen until reconcile
+	if result.Verification = verifyOrders(result.Orders, recentOrders); result.Verification != nil {
+		result.Verification.report()
+	}
+
+	result.Interrupted = ctx.Err() != nil
+	if err := result.save(root+resultFile, time.Now()); err != nil {
+		log.Fatal("writing execution result: ", err)
+	}
+	if err := clock.save(latency.DefaultPath, time.Now()); err != nil {
+		fmt.Printf("\n[WARNING] writing latency samples: %v\n", err)
+	}
+	if err := quota.Record(quota.DefaultPath, nil, time.Now()); err != nil {
+		fmt.Printf("\n[WARNING] writing API usage: %v\n", err)
+	}
+
+	// A failed order exits non-zero so CI can tell it from a quiet cycle
 	fmt.Println("\n" + strings.Repeat("─", 50))
+	if result.Interrupted {
+		fmt.Printf("✗ Execution interrupted  buys=%d  sells=%d  skipped=%d\n",
+			buysSubmitted, sellsSubmitted, result.Skipped)
+		interrupt.Exit("execute")
+	}
+	if result.Failed() {
+		fmt.Printf("✗ Execution finished with failures  buys=%d  sells=%d  rejected=%d  errors=%d  unverified=%d\n",
+			buysSubmitted, sellsSubmitted, result.Rejected, result.Errors, result.unverified())
+		os.Exit(1)
+	}
 	fmt.Printf("✓ Execution complete  buys=%d  sells=%d\n", buysSubmitted, sellsSubmitted)
 }
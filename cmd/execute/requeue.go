@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/journal"
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+// Backoff for rate-limited orders when Alpaca doesn't send Retry-After:
+// 1s, 2s, 4s… capped so one order can't eat the whole budget in a single wait.
+const (
+	defaultSubmitBudget = 2 * time.Minute
+	retryBase           = time.Second
+	retryCap            = 30 * time.Second
+)
+
+// failedOrder is a retried order refused for a reason other than the rate
+// limit.
+type failedOrder struct {
+	req OrderRequest
+	err error
+}
+
+// queued is a rate-limited order waiting for another attempt.
+type queued struct {
+	req      OrderRequest
+	attempts int
+	due      time.Time
+}
+
+// requeue holds orders Alpaca refused with a 429. They're retried with
+// backoff after the first pass, in the order they were refused, until they
+// go through or the cycle's time budget runs out, or execute is interrupted.
+type requeue struct {
+	ctx     context.Context // Nil for never interrupted
+	pending []queued
+	now     func() time.Time
+	sleep   func(time.Duration)
+	submit  func(OrderRequest) error
+}
+
+func newRequeue(ctx context.Context, submit func(OrderRequest) error) *requeue {
+	return &requeue{
+		ctx:    ctx,
+		now:    time.Now,
+		sleep:  func(d time.Duration) { interrupt.Sleep(ctx, d) },
+		submit: submit,
+	}
+}
+
+func (q *requeue) interrupted() bool {
+	return q.ctx != nil && q.ctx.Err() != nil
+}
+
+// backoff is the wait before attempt n+1, preferring the server's Retry-After.
+func backoff(attempts int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	d := retryBase << attempts
+	if d <= 0 || d > retryCap {
+		return retryCap
+	}
+	return d
+}
+
+// offer submits req. A 429 queues it for a retry instead of dropping it and
+// reports requeued; any other error is returned as before.
+func (q *requeue) offer(req OrderRequest) (requeued bool, err error) {
+	err = q.submit(req)
+	if limited, wait := alpaca.RateLimited(err); limited {
+		fmt.Printf("  [429]  %s rate limited — requeued\n", req.Symbol)
+		q.pending = append(q.pending, queued{req: req, attempts: 1, due: q.now().Add(backoff(0, wait))})
+		return true, nil
+	}
+	return false, err
+}
+
+// drain retries queued orders until each is submitted, fails outright, or
+// its next attempt would fall after deadline or the order's own valid-until
+// time. Once interrupted, whatever is left expires unsubmitted. Returns the
+// orders that went through, those that expired unsubmitted and those that
+// failed.
+func (q *requeue) drain(deadline time.Time) (submitted, expired []OrderRequest, failed []failedOrder) {
+	for len(q.pending) > 0 {
+		next := q.pending[0]
+		q.pending = q.pending[1:]
+
+		lapsed := !next.req.ValidUntil.IsZero() && next.due.After(next.req.ValidUntil)
+		if next.due.After(deadline) || lapsed || q.interrupted() {
+			expired = append(expired, next.req)
+			continue
+		}
+		if wait := next.due.Sub(q.now()); wait > 0 {
+			q.sleep(wait)
+		}
+		if q.interrupted() {
+			expired = append(expired, next.req)
+			continue
+		}
+
+		err := q.submit(next.req)
+		if limited, wait := alpaca.RateLimited(err); limited {
+			fmt.Printf("  [429]  %s still rate limited after %d attempt(s)\n", next.req.Symbol, next.attempts+1)
+			next.due = q.now().Add(backoff(next.attempts, wait))
+			next.attempts++
+			q.pending = append(q.pending, next)
+			continue
+		}
+		if err != nil {
+			fmt.Printf("  [ERROR] %v\n", err)
+			failed = append(failed, failedOrder{req: next.req, err: err})
+			continue
+		}
+		submitted = append(submitted, next.req)
+	}
+	return submitted, expired, failed
+}
+
+// submitBudgetFromEnv reads LFT2_SUBMIT_BUDGET, how long execute may keep
+// retrying rate-limited orders after it starts (a Go duration).
+func submitBudgetFromEnv() (time.Duration, error) {
+	s := os.Getenv("LFT2_SUBMIT_BUDGET")
+	if s == "" {
+		return defaultSubmitBudget, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("LFT2_SUBMIT_BUDGET must be a non-negative duration such as 2m, got %q", s)
+	}
+	return d, nil
+}
+
+// journalExpired records orders that ran out of budget as expired-unsubmitted.
+func journalExpired(path string, expired []OrderRequest, now time.Time) error {
+	j, err := journal.Load(path)
+	if err != nil {
+		return err
+	}
+	for _, req := range expired {
+		text := fmt.Sprintf("%s %s qty=%s rate limited until the submit budget or the intent ran out", req.Side, req.Symbol, req.Qty)
+		if err := j.AddTagged(req.ClientOrdID, journal.ExpiredUnsubmitted, text, now); err != nil {
+			return fmt.Errorf("%s: %w", req.Symbol, err)
+		}
+	}
+	return j.Save(path)
+}
+
+// reviewExpired reports today's expired-unsubmitted orders against this
+// cycle's signals: a symbol with an order again still has its signal and was
+// resubmitted; one without has lapsed. Symbols are the client_order_id prefix.
+func reviewExpired(notes []journal.Note, signalled map[string]bool, now time.Time) []string {
+	var lines []string
+	for _, n := range notes {
+		if tz.Date(n.CreatedAt) != tz.Date(now) {
+			continue
+		}
+		symbol, _, _ := strings.Cut(n.OrderID, "_")
+		status := "signal lapsed, not resubmitted"
+		if signalled[symbol] {
+			status = "signal still present, resubmitted this cycle"
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s at %s): %s", symbol, n.OrderID, tz.Clock(n.CreatedAt), status))
+	}
+	return lines
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/journal"
+)
+
+// fakeClock advances only when the requeue sleeps.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time        { return c.t }
+func (c *fakeClock) sleep(d time.Duration) { c.t = c.t.Add(d) }
+func rateLimited(after time.Duration) error {
+	return &alpaca.StatusError{StatusCode: 429, RetryAfter: after}
+}
+
+// --- backoff ---
+
+func TestBackoff(t *testing.T) {
+	if got := backoff(0, 0); got != time.Second {
+		t.Errorf("first retry: got %v, want 1s", got)
+	}
+	if got := backoff(3, 0); got != 8*time.Second {
+		t.Errorf("fourth retry: got %v, want 8s", got)
+	}
+	if got := backoff(20, 0); got != retryCap {
+		t.Errorf("capped: got %v, want %v", got, retryCap)
+	}
+	if got := backoff(5, 7*time.Second); got != 7*time.Second {
+		t.Errorf("Retry-After: got %v, want 7s", got)
+	}
+}
+
+// --- requeue ---
+
+func TestRequeue_RetriesUntilAccepted(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)}
+	calls := map[string]int{}
+	q := &requeue{now: clock.now, sleep: clock.sleep, submit: func(r OrderRequest) error {
+		calls[r.Symbol]++
+		if r.Symbol == "AAPL" && calls[r.Symbol] < 3 {
+			return rateLimited(0)
+		}
+		return nil
+	}}
+
+	if requeued, err := q.offer(OrderRequest{Symbol: "AAPL", Side: "buy"}); !requeued || err != nil {
+		t.Fatalf("AAPL: got requeued=%t err=%v", requeued, err)
+	}
+	if requeued, err := q.offer(OrderRequest{Symbol: "MSFT", Side: "buy"}); requeued || err != nil {
+		t.Fatalf("MSFT: got requeued=%t err=%v", requeued, err)
+	}
+
+	submitted, expired, _ := q.drain(clock.t.Add(time.Minute))
+	if len(submitted) != 1 || submitted[0].Symbol != "AAPL" || len(expired) != 0 {
+		t.Errorf("got submitted=%v expired=%v", submitted, expired)
+	}
+	if calls["AAPL"] != 3 {
+		t.Errorf("AAPL submitted %d times, want 3", calls["AAPL"])
+	}
+	if waited := clock.t.Sub(time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)); waited != 3*time.Second {
+		t.Errorf("waited %v, want 1s + 2s of backoff", waited)
+	}
+}
+
+func TestRequeue_Interrupted(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)}
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	q := &requeue{ctx: ctx, now: clock.now, submit: func(OrderRequest) error {
+		calls++
+		return rateLimited(0)
+	}}
+	// Interrupted during the wait before the first retry
+	q.sleep = func(d time.Duration) { cancel() }
+
+	q.offer(OrderRequest{Symbol: "AAPL", Side: "buy"})
+	q.offer(OrderRequest{Symbol: "MSFT", Side: "buy"})
+	submitted, expired, _ := q.drain(clock.t.Add(time.Minute))
+	if calls != 2 || len(submitted) != 0 || len(expired) != 2 {
+		t.Errorf("got %d calls, submitted=%v expired=%v, want both expired unsent", calls, submitted, expired)
+	}
+}
+
+func TestRequeue_ExpiresPastDeadline(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)}
+	q := &requeue{now: clock.now, sleep: clock.sleep, submit: func(OrderRequest) error { return rateLimited(20 * time.Second) }}
+
+	q.offer(OrderRequest{Symbol: "AAPL", Side: "buy"})
+	submitted, expired, _ := q.drain(clock.t.Add(30 * time.Second))
+	if len(submitted) != 0 || len(expired) != 1 || expired[0].Symbol != "AAPL" {
+		t.Errorf("got submitted=%v expired=%v", submitted, expired)
+	}
+}
+
+func TestRequeue_OtherErrorsNotRequeued(t *testing.T) {
+	q := newRequeue(context.Background(), func(OrderRequest) error { return errors.New("HTTP 403: forbidden") })
+	requeued, err := q.offer(OrderRequest{Symbol: "AAPL"})
+	if requeued || err == nil || len(q.pending) != 0 {
+		t.Errorf("got requeued=%t err=%v pending=%d", requeued, err, len(q.pending))
+	}
+}
+
+// --- journalExpired / reviewExpired ---
+
+func TestJournalExpiredAndReview(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	expired := []OrderRequest{
+		{Symbol: "AAPL", Side: "buy", Qty: 5, ClientOrdID: "AAPL_sma_crossover_tp1.00_sl1.00_tsl1.00_p0_1741615200"},
+		{Symbol: "MSFT", Side: "buy", Qty: 2, ClientOrdID: "MSFT_momentum_tp1.00_sl1.00_tsl1.00_p0_1741615200"},
+	}
+	if err := journalExpired(path, expired, now); err != nil {
+		t.Fatalf("journalExpired: %v", err)
+	}
+
+	j, err := journal.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	notes := j.Tagged(journal.ExpiredUnsubmitted, time.Time{})
+	if len(notes) != 2 || !strings.Contains(notes[0].Text, "buy AAPL qty=5") {
+		t.Fatalf("got %+v", notes)
+	}
+
+	lines := reviewExpired(notes, map[string]bool{"AAPL": true}, now.Add(5*time.Minute))
+	if len(lines) != 2 || !strings.Contains(lines[0], "resubmitted") || !strings.Contains(lines[1], "lapsed") {
+		t.Errorf("got %q", lines)
+	}
+	if got := reviewExpired(notes, nil, now.Add(24*time.Hour)); len(got) != 0 {
+		t.Errorf("yesterday's expiries reported: %q", got)
+	}
+}
+
+// --- submitBudgetFromEnv ---
+
+func TestSubmitBudgetFromEnv(t *testing.T) {
+	t.Setenv("LFT2_SUBMIT_BUDGET", "")
+	if d, err := submitBudgetFromEnv(); err != nil || d != defaultSubmitBudget {
+		t.Errorf("default: got %v, %v", d, err)
+	}
+	t.Setenv("LFT2_SUBMIT_BUDGET", "90s")
+	if d, err := submitBudgetFromEnv(); err != nil || d != 90*time.Second {
+		t.Errorf("override: got %v, %v", d, err)
+	}
+	t.Setenv("LFT2_SUBMIT_BUDGET", "soon")
+	if _, err := submitBudgetFromEnv(); err == nil {
+		t.Error("expected error for bad duration")
+	}
+}
+
+func TestDrain_StopsAtIntentExpiry(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 2, 14, 36, 0, 0, time.UTC)}
+	q := &requeue{now: clock.now, sleep: clock.sleep, submit: func(OrderRequest) error { return nil }}
+
+	q.pending = []queued{
+		{req: OrderRequest{Symbol: "AAPL", ValidUntil: clock.t.Add(30 * time.Second)}, due: clock.t.Add(time.Minute)},
+		{req: OrderRequest{Symbol: "MSFT", ValidUntil: clock.t.Add(2 * time.Minute)}, due: clock.t.Add(time.Minute)},
+	}
+	submitted, expired, _ := q.drain(clock.t.Add(10 * time.Minute))
+	if len(submitted) != 1 || submitted[0].Symbol != "MSFT" {
+		t.Errorf("submitted %+v, want MSFT", submitted)
+	}
+	if len(expired) != 1 || expired[0].Symbol != "AAPL" {
+		t.Errorf("expired %+v, want AAPL, whose intent lapsed before its retry", expired)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// resultFile is under the book's root, docs/ for the default book.
+const resultFile = "execution-result.json"
+
+// builtBy is the pseudo-field readOrders fills from the heartbeat's FIX tag
+// 1604, the build of the entries or exits that wrote the order.
+const builtBy = "build"
+
+// Order outcomes in execution-result.json
+const (
+	outcomeSubmitted = "submitted"
+	outcomeRejected  = "rejected" // the broker refused it
+	outcomeSkipped   = "skipped"  // refused here before it was sent
+	outcomeError     = "error"    // couldn't be sent, or the broker failed
+	outcomeExpired   = "expired"  // rate limited until the budget ran out
+)
+
+// Outcome is what happened to one order intent. An order that was sent
+// carries its client order ID and the build of the module that signalled
+// it; execute's own build is in the header.
+type Outcome struct {
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"`
+	Status        string `json:"status"`
+	Reason        string `json:"reason,omitempty"`
+	ClientOrderID string `json:"client_order_id,omitempty"`
+	Build         string `json:"build,omitempty"`
+}
+
+// Result is docs/execution-result.json, written every run so the
+// orchestrator can branch on the outcome without scraping the log.
+type Result struct {
+	schema.Header
+	Timestamp   string    `json:"timestamp"`
+	Blocked     string    `json:"blocked,omitempty"`     // Account restriction that stopped every order
+	Interrupted bool      `json:"interrupted,omitempty"` // Stopped by Ctrl-C; orders not reached are skipped
+	Submitted   int       `json:"submitted"`
+	Rejected    int       `json:"rejected"`
+	Skipped     int       `json:"skipped"`
+	Errors      int       `json:"errors"`
+	Expired     int       `json:"expired"`
+	Orders      []Outcome `json:"orders"`
+
+	Verification *Verification `json:"verification,omitempty"` // Sent orders checked at the broker
+}
+
+func (r *Result) add(symbol, side, status, reason string) {
+	switch status {
+	case outcomeSubmitted:
+		r.Submitted++
+	case outcomeRejected:
+		r.Rejected++
+	case outcomeSkipped:
+		r.Skipped++
+	case outcomeError:
+		r.Errors++
+	case outcomeExpired:
+		r.Expired++
+	}
+	r.Orders = append(r.Orders, Outcome{Symbol: symbol, Side: side, Status: status, Reason: reason})
+}
+
+// skip records an order refused before submission.
+func (r *Result) skip(symbol, side, reason string) {
+	r.add(symbol, side, outcomeSkipped, reason)
+}
+
+// submitted records the outcome of sending req: err is nil when it went
+// through.
+func (r *Result) submitted(req OrderRequest, err error) {
+	if err == nil {
+		r.add(req.Symbol, req.Side, outcomeSubmitted, "")
+	} else {
+		r.add(req.Symbol, req.Side, failure(err), err.Error())
+	}
+	o := &r.Orders[len(r.Orders)-1]
+	o.ClientOrderID, o.Build = req.ClientOrdID, req.Build
+}
+
+// failure classifies a submission error: a 4xx is the broker refusing the
+// order, anything else is an error getting it there.
+func failure(err error) string {
+	var se *alpaca.StatusError
+	if errors.As(err, &se) && se.StatusCode >= http.StatusBadRequest && se.StatusCode < http.StatusInternalServerError {
+		return outcomeRejected
+	}
+	return outcomeError
+}
+
+// Failed reports whether any order was rejected or errored, or the broker
+// disagrees about one. Skipped and expired orders are expected in normal
+// running and don't count, nor does a verification that couldn't run.
+func (r *Result) Failed() bool {
+	return r.Rejected+r.Errors+r.unverified() > 0
+}
+
+// save writes the result to path.
+func (r *Result) save(path string, now time.Time) error {
+	r.Header = schema.Current()
+	r.Timestamp = now.UTC().Format(time.RFC3339)
+	if r.Orders == nil {
+		r.Orders = []Outcome{}
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding result: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
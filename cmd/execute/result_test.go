@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// --- failure ---
+
+func TestFailure(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{&alpaca.StatusError{StatusCode: 403}, outcomeRejected},
+		{&alpaca.StatusError{StatusCode: 422}, outcomeRejected},
+		{fmt.Errorf("submitting order: %w", &alpaca.StatusError{StatusCode: 400}), outcomeRejected},
+		{&alpaca.StatusError{StatusCode: 500}, outcomeError},
+		{errors.New("HTTP request failed: connection refused"), outcomeError},
+	}
+	for _, c := range cases {
+		if got := failure(c.err); got != c.want {
+			t.Errorf("%v: got %s, want %s", c.err, got, c.want)
+		}
+	}
+}
+
+// --- Result ---
+
+func TestResult_Tally(t *testing.T) {
+	var r Result
+	r.skip("AAPL", "buy", "already held")
+	r.submitted(OrderRequest{Symbol: "MSFT", Side: "buy", ClientOrdID: "MSFT_x", Build: "0123456789ab"}, nil)
+	r.add("TSLA", "sell", outcomeExpired, "rate limited")
+	if r.Failed() {
+		t.Errorf("skipped and expired orders counted as failures: %+v", r)
+	}
+
+	r.submitted(OrderRequest{Symbol: "NVDA", Side: "buy"}, &alpaca.StatusError{StatusCode: 403, Body: "forbidden"})
+	r.submitted(OrderRequest{Symbol: "AMD", Side: "sell"}, errors.New("timeout"))
+	if !r.Failed() {
+		t.Error("rejected and errored orders not reported as failed")
+	}
+	if r.Submitted != 1 || r.Skipped != 1 || r.Expired != 1 || r.Rejected != 1 || r.Errors != 1 {
+		t.Errorf("got %+v", r)
+	}
+	if got := r.Orders[1]; got.ClientOrderID != "MSFT_x" || got.Build != "0123456789ab" {
+		t.Errorf("MSFT: got %+v, want its order ID and signalling build", got)
+	}
+	if got := r.Orders[3]; got.Symbol != "NVDA" || got.Status != outcomeRejected || got.Reason != "HTTP 403: forbidden" {
+		t.Errorf("NVDA: got %+v", got)
+	}
+}
+
+func TestResult_Save(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "execution-result.json")
+	var r Result
+	if err := r.save(path, time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got["timestamp"] != "2026-03-10T15:00:00Z" || got["errors"] != 0.0 {
+		t.Errorf("got %s", data)
+	}
+	if orders, ok := got["orders"].([]any); !ok || len(orders) != 0 {
+		t.Errorf("orders: got %v, want an empty list", got["orders"])
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Signed by fix::sign in src/fix.h with the key "k" — the same vector its
+// static_assert checks, so the two implementations can't drift apart
+const signedLine = "8=FIX.5.0SP2|35=D|55=AAPL|10=001|89=14b619f076761cad29576804f18c5965ad7f1abdffdcedab17b6afe92f89a2ca|"
+
+func order(line string) map[string]string {
+	fields := parseFIX(line)
+	fields[rawLine] = line
+	return fields
+}
+
+// --- checkSignature ---
+
+func TestCheckSignature_MatchesCPlusPlus(t *testing.T) {
+	if err := checkSignature(order(signedLine), []byte("k")); err != nil {
+		t.Errorf("got %v, want the C++ signature accepted", err)
+	}
+}
+
+func TestCheckSignature_NoKeyAcceptsAnything(t *testing.T) {
+	if err := checkSignature(order("8=FIX.5.0SP2|35=D|55=AAPL|"), nil); err != nil {
+		t.Errorf("got %v, want no check without a key", err)
+	}
+}
+
+func TestCheckSignature_Refused(t *testing.T) {
+	for name, tc := range map[string]struct {
+		line, key, want string
+	}{
+		"unsigned":  {"8=FIX.5.0SP2|35=D|55=AAPL|10=001|", "k", "unsigned"},
+		"edited":    {strings.Replace(signedLine, "AAPL", "TSLA", 1), "k", "mismatch"},
+		"appended":  {signedLine + "38=1000|", "k", "mismatch"},
+		"other key": {signedLine, "not-k", "mismatch"},
+	} {
+		err := checkSignature(order(tc.line), []byte(tc.key))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got %v, want %q", name, err, tc.want)
+		}
+	}
+}
+
+func TestReadOrders_KeepsLineForSignature(t *testing.T) {
+	key := []byte("secret")
+	line := "8=FIX.5.0SP2|35=D|55=NVDA|38=5|"
+	f := writeFixFile(t, line+"89="+signature(key, line)+"|\n")
+	orders, err := readOrders(f)
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("got %d orders, %v", len(orders), err)
+	}
+	if err := checkSignature(orders[0], key); err != nil {
+		t.Errorf("got %v, want the order as read to verify", err)
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/spreads"
+)
+
+// wideSpread reports why a buy shouldn't go in at symbol's latest quote, or
+// "" if it may. A market buy pays half the spread on the way in, so one
+// blown out since filter ran is skipped rather than crossed. Without a
+// current quote the buy goes ahead: filter has already screened it.
+func wideSpread(latest *spreads.Quotes, symbol string, now time.Time) string {
+	bps, ok := latest.Spread(symbol, now)
+	if !ok || bps <= spreads.MaxEntryBps {
+		return ""
+	}
+	return fmt.Sprintf("quoted spread too wide (%.1f bps > %.0f bps)", bps, spreads.MaxEntryBps)
+}
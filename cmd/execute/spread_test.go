@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/spreads"
+)
+
+func TestWideSpread(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	latest := &spreads.Quotes{
+		Timestamp: now.Add(-time.Minute).Format(time.RFC3339),
+		Symbols: map[string]spreads.Quote{
+			"AAPL": {SpreadBps: 2},
+			"THIN": {SpreadBps: 80},
+		},
+	}
+	if why := wideSpread(latest, "AAPL", now); why != "" {
+		t.Errorf("AAPL: got %q, want allowed", why)
+	}
+	if why := wideSpread(latest, "THIN", now); why != "quoted spread too wide (80.0 bps > 50 bps)" {
+		t.Errorf("THIN: got %q", why)
+	}
+	if why := wideSpread(latest, "MSFT", now); why != "" {
+		t.Errorf("unquoted: got %q, want allowed", why)
+	}
+	if why := wideSpread(latest, "THIN", now.Add(time.Hour)); why != "" {
+		t.Errorf("stale snapshot: got %q, want allowed", why)
+	}
+}
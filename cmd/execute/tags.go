@@ -0,0 +1,34 @@
+package main
+
+import (
+	"time"
+
+	"github.com/deanturpin/lft2/internal/journal"
+)
+
+// journalTags records LFT2_ORDER_TAGS against every order this run
+// submitted, so the summary and statements can slice fills by them. Orders
+// that didn't reach the broker have no fills to slice.
+func journalTags(path string, orders []Outcome, tags map[string]string, now time.Time) (int, error) {
+	if len(tags) == 0 {
+		return 0, nil
+	}
+	j, err := journal.Load(path)
+	if err != nil {
+		return 0, err
+	}
+	tagged := 0
+	for _, o := range orders {
+		if o.Status != outcomeSubmitted || o.ClientOrderID == "" {
+			continue
+		}
+		if err := j.Tag(o.ClientOrderID, tags, now); err != nil {
+			return 0, err
+		}
+		tagged++
+	}
+	if tagged == 0 {
+		return 0, nil
+	}
+	return tagged, j.Save(path)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/journal"
+)
+
+// --- journalTags ---
+
+func TestJournalTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	orders := []Outcome{
+		{Symbol: "AAPL", Side: "buy", Status: outcomeSubmitted, ClientOrderID: "AAPL_momentum-v1_tp1.25"},
+		{Symbol: "MSFT", Side: "buy", Status: outcomeRejected, ClientOrderID: "MSFT_momentum-v1_tp1.25"},
+		{Symbol: "NVDA", Side: "sell", Status: outcomeSkipped},
+	}
+
+	// Without tags the journal isn't touched
+	if n, err := journalTags(path, orders, nil, now); err != nil || n != 0 {
+		t.Fatalf("no tags: got %d, %v", n, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("no tags: journal written")
+	}
+
+	tags := map[string]string{"experiment": "wide-stops"}
+	if n, err := journalTags(path, orders, tags, now); err != nil || n != 1 {
+		t.Fatalf("got %d, %v, want the one submitted order", n, err)
+	}
+	j, err := journal.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := journal.FormatTags(j.TagsFor("AAPL_momentum-v1_tp1.25")); got != "experiment=wide-stops" {
+		t.Errorf("AAPL: got %q", got)
+	}
+	if got := j.TagsFor("MSFT_momentum-v1_tp1.25"); got != nil {
+		t.Errorf("rejected order tagged: %v", got)
+	}
+}
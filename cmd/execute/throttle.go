@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// Pacing defaults: about four orders a second, well inside Alpaca's 200
+// requests a minute, with jitter so fills don't all land on the same tick.
+const (
+	defaultOrderDelay  = 200 * time.Millisecond
+	defaultOrderJitter = 100 * time.Millisecond
+)
+
+// pacer spaces order submissions by a fixed delay plus random jitter. Orders
+// are still sent one at a time in file order, so the ranking entries wrote
+// into buy.fix is preserved; only the gaps between them change.
+type pacer struct {
+	delay  time.Duration
+	jitter time.Duration
+	rand   func() float64      // [0, 1)
+	sleep  func(time.Duration) // swapped out in tests
+	sent   int
+}
+
+func newPacer(delay, jitter time.Duration) *pacer {
+	return &pacer{delay: delay, jitter: jitter, rand: rand.Float64, sleep: time.Sleep}
+}
+
+// gap returns the pause before the next order: the delay plus up to jitter.
+func (p *pacer) gap() time.Duration {
+	return p.delay + time.Duration(p.rand()*float64(p.jitter))
+}
+
+// wait blocks until the next order may be sent. The first order goes
+// immediately.
+func (p *pacer) wait() {
+	if p.sent > 0 {
+		if d := p.gap(); d > 0 {
+			p.sleep(d)
+		}
+	}
+	p.sent++
+}
+
+// pacingFromEnv reads LFT2_ORDER_DELAY and LFT2_ORDER_JITTER as Go durations
+// ("250ms", "1s"). Unset values keep the defaults; "0" disables either.
+func pacingFromEnv() (delay, jitter time.Duration, err error) {
+	parse := func(name string, def time.Duration) (time.Duration, error) {
+		s := os.Getenv(name)
+		if s == "" {
+			return def, nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("%s must be a non-negative duration such as 250ms, got %q", name, s)
+		}
+		return d, nil
+	}
+	if delay, err = parse("LFT2_ORDER_DELAY", defaultOrderDelay); err != nil {
+		return 0, 0, err
+	}
+	if jitter, err = parse("LFT2_ORDER_JITTER", defaultOrderJitter); err != nil {
+		return 0, 0, err
+	}
+	return delay, jitter, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// --- pacer ---
+
+func TestPacer_FirstOrderImmediate(t *testing.T) {
+	var slept []time.Duration
+	p := &pacer{delay: time.Second, rand: func() float64 { return 0 }, sleep: func(d time.Duration) { slept = append(slept, d) }}
+
+	p.wait()
+	if len(slept) != 0 {
+		t.Errorf("first order slept %v", slept)
+	}
+	p.wait()
+	p.wait()
+	if len(slept) != 2 || slept[0] != time.Second {
+		t.Errorf("got sleeps %v, want two of 1s", slept)
+	}
+}
+
+func TestPacer_JitterWithinBounds(t *testing.T) {
+	p := &pacer{delay: 200 * time.Millisecond, jitter: 100 * time.Millisecond}
+	for _, r := range []float64{0, 0.5, 0.999} {
+		p.rand = func() float64 { return r }
+		got := p.gap()
+		if got < p.delay || got >= p.delay+p.jitter {
+			t.Errorf("rand=%g: gap %v outside [200ms, 300ms)", r, got)
+		}
+	}
+}
+
+func TestPacer_ZeroDelayNeverSleeps(t *testing.T) {
+	p := &pacer{rand: func() float64 { return 0.5 }, sleep: func(time.Duration) { t.Error("unexpected sleep") }}
+	for i := 0; i < 3; i++ {
+		p.wait()
+	}
+}
+
+// --- pacingFromEnv ---
+
+func TestPacingFromEnv(t *testing.T) {
+	t.Setenv("LFT2_ORDER_DELAY", "")
+	t.Setenv("LFT2_ORDER_JITTER", "")
+	if d, j, err := pacingFromEnv(); err != nil || d != defaultOrderDelay || j != defaultOrderJitter {
+		t.Errorf("defaults: got %v, %v, %v", d, j, err)
+	}
+
+	t.Setenv("LFT2_ORDER_DELAY", "1s")
+	t.Setenv("LFT2_ORDER_JITTER", "0")
+	if d, j, err := pacingFromEnv(); err != nil || d != time.Second || j != 0 {
+		t.Errorf("overrides: got %v, %v, %v", d, j, err)
+	}
+
+	for _, bad := range []string{"fast", "-1s"} {
+		t.Setenv("LFT2_ORDER_DELAY", bad)
+		if _, _, err := pacingFromEnv(); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
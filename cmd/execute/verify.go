@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// A sent order is looked for at the broker up to verifyAttempts times,
+// verifyPause apart, as a just-accepted order can take a moment to list.
+var (
+	verifyAttempts = 3
+	verifyPause    = 2 * time.Second
+)
+
+// Verification is the broker check in execution-result.json. An order
+// recorded as submitted that the broker doesn't list was lost between the
+// POST and its reply; one recorded as failed that the broker does list
+// reached it all the same, and is trading unjournalled.
+type Verification struct {
+	Checked    int      `json:"checked"`              // Sent orders looked up by client_order_id
+	Missing    []string `json:"missing,omitempty"`    // Submitted here, not at the broker
+	Unexpected []string `json:"unexpected,omitempty"` // Failed here, yet at the broker
+	Error      string   `json:"error,omitempty"`      // The broker's orders couldn't be listed
+}
+
+// Discrepancies is how many orders the broker disagrees about.
+func (v *Verification) Discrepancies() int {
+	return len(v.Missing) + len(v.Unexpected)
+}
+
+// checkOrders compares the outcomes of the orders sent with the broker's
+// orders, by client order ID. Skipped and expired orders were never sent.
+func checkOrders(outcomes []Outcome, orders []alpaca.Order) Verification {
+	atBroker := make(map[string]bool, len(orders))
+	for _, o := range orders {
+		atBroker[o.ClientOrderID] = true
+	}
+	var v Verification
+	for _, o := range outcomes {
+		if o.ClientOrderID == "" {
+			continue
+		}
+		switch o.Status {
+		case outcomeSubmitted:
+			v.Checked++
+			if !atBroker[o.ClientOrderID] {
+				v.Missing = append(v.Missing, o.ClientOrderID)
+			}
+		case outcomeRejected, outcomeError:
+			v.Checked++
+			if atBroker[o.ClientOrderID] {
+				v.Unexpected = append(v.Unexpected, o.ClientOrderID)
+			}
+		}
+	}
+	return v
+}
+
+// verifyOrders lists the broker's orders and checks the outcomes against
+// them, listing again while any submitted order is missing. A run that sent
+// nothing asks the broker nothing.
+func verifyOrders(outcomes []Outcome, list func() ([]alpaca.Order, error)) *Verification {
+	checked := checkOrders(outcomes, nil).Checked
+	if checked == 0 {
+		return nil
+	}
+	for attempt := 1; ; attempt++ {
+		orders, err := list()
+		v := Verification{Checked: checked}
+		if err == nil {
+			v = checkOrders(outcomes, orders)
+		} else {
+			v.Error = err.Error()
+		}
+		if (err == nil && len(v.Missing) == 0) || attempt == verifyAttempts {
+			return &v
+		}
+		time.Sleep(verifyPause)
+	}
+}
+
+// report prints the verification, with an annotation CI shows on the run
+// for each order the broker disagrees about.
+func (v *Verification) report() {
+	fmt.Printf("\n[verify] %d sent order(s) against the broker\n", v.Checked)
+	if v.Error != "" {
+		fmt.Printf("  [WARNING] listing broker orders: %s — not verified\n", v.Error)
+		return
+	}
+	for _, id := range v.Missing {
+		fmt.Printf("  [ERROR] %s submitted but not at the broker\n", id)
+		fmt.Printf("::error title=execute verify::order %s submitted but not at the broker\n", id)
+	}
+	for _, id := range v.Unexpected {
+		fmt.Printf("  [ERROR] %s failed here but is at the broker\n", id)
+		fmt.Printf("::error title=execute verify::order %s failed here but is at the broker\n", id)
+	}
+	if v.Discrepancies() == 0 {
+		fmt.Printf("  ✓ every sent order is where execute recorded it\n")
+	}
+}
+
+// unverified is how many orders the broker disagreed about, 0 if none were
+// checked.
+func (r *Result) unverified() int {
+	if r.Verification == nil {
+		return 0
+	}
+	return r.Verification.Discrepancies()
+}
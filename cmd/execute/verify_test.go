@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+func TestCheckOrders(t *testing.T) {
+	outcomes := []Outcome{
+		{Symbol: "AAPL", Status: outcomeSubmitted, ClientOrderID: "AAPL_a"},
+		{Symbol: "MSFT", Status: outcomeSubmitted, ClientOrderID: "MSFT_a"}, // Reply lost, order never placed
+		{Symbol: "TSLA", Status: outcomeError, ClientOrderID: "TSLA_a"},     // Timed out, but placed
+		{Symbol: "NVDA", Status: outcomeRejected, ClientOrderID: "NVDA_a"},
+		{Symbol: "AMD", Status: outcomeSkipped, Reason: "already held"},
+	}
+	orders := []alpaca.Order{{ClientOrderID: "AAPL_a"}, {ClientOrderID: "TSLA_a"}, {ClientOrderID: "GOOG_earlier"}}
+
+	v := checkOrders(outcomes, orders)
+	if v.Checked != 4 {
+		t.Errorf("checked: got %d, want 4", v.Checked)
+	}
+	if len(v.Missing) != 1 || v.Missing[0] != "MSFT_a" {
+		t.Errorf("missing: got %v", v.Missing)
+	}
+	if len(v.Unexpected) != 1 || v.Unexpected[0] != "TSLA_a" {
+		t.Errorf("unexpected: got %v", v.Unexpected)
+	}
+}
+
+func TestVerifyOrders(t *testing.T) {
+	defer func(pause time.Duration) { verifyPause = pause }(verifyPause)
+	verifyPause = 0
+
+	// Nothing sent: the broker isn't asked
+	called := 0
+	list := func() ([]alpaca.Order, error) { called++; return nil, nil }
+	if v := verifyOrders([]Outcome{{Symbol: "AMD", Status: outcomeSkipped}}, list); v != nil || called != 0 {
+		t.Errorf("nothing sent: got %+v after %d calls", v, called)
+	}
+
+	// Listed on the second try, as a just-accepted order can lag
+	sent := []Outcome{{Symbol: "AAPL", Status: outcomeSubmitted, ClientOrderID: "AAPL_a"}}
+	called = 0
+	list = func() ([]alpaca.Order, error) {
+		called++
+		if called == 1 {
+			return nil, nil
+		}
+		return []alpaca.Order{{ClientOrderID: "AAPL_a"}}, nil
+	}
+	if v := verifyOrders(sent, list); v == nil || v.Discrepancies() != 0 || called != 2 {
+		t.Errorf("lagging: got %+v after %d calls", v, called)
+	}
+
+	// Never listed
+	called = 0
+	list = func() ([]alpaca.Order, error) { called++; return nil, nil }
+	if v := verifyOrders(sent, list); v == nil || len(v.Missing) != 1 || called != verifyAttempts {
+		t.Errorf("missing: got %+v after %d calls", v, called)
+	}
+
+	// Listing fails: unverified, not a discrepancy
+	list = func() ([]alpaca.Order, error) { return nil, errors.New("503") }
+	v := verifyOrders(sent, list)
+	if v == nil || v.Error != "503" || v.Discrepancies() != 0 || v.Checked != 1 {
+		t.Errorf("list error: got %+v", v)
+	}
+	r := Result{Verification: v}
+	if r.Failed() {
+		t.Error("an unverified run counted as failed")
+	}
+	r.Verification = &Verification{Checked: 1, Missing: []string{"AAPL_a"}}
+	if !r.Failed() {
+		t.Error("a missing order didn't count as failed")
+	}
+}
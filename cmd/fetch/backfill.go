@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// fallbackDays is how far back a full fetch starts without a trading
+// calendar. It covers 1000 5-minute bars with room for holidays, but not a
+// warm-up much longer than that.
+const fallbackDays = 42
+
+// sessionBars is how many bars of timeframeMin a full regular session holds.
+func sessionBars(timeframeMin int) int {
+	return (6*60 + 30) / timeframeMin
+}
+
+// calendarSpan is how many calendar days of sessions to ask for to cover
+// bars: at most five sessions a week, plus a fortnight for holidays.
+func calendarSpan(bars, timeframeMin int) int {
+	sessions := (bars + sessionBars(timeframeMin) - 1) / sessionBars(timeframeMin)
+	return sessions*7/5 + 14
+}
+
+// backfillStart returns where a request for the latest bars at timeframeMin
+// must start to reach back that many of them: the open of the session they
+// begin in. It counts back from now through sessions (oldest first), so half
+// days count for what they hold and holidays for nothing, and a session in
+// progress only for the bars it has had so far. ok is false when the
+// sessions don't go back far enough.
+func backfillStart(sessions []alpaca.Session, bars, timeframeMin int, now time.Time) (start string, ok bool) {
+	need := bars
+	for i := len(sessions) - 1; i >= 0; i-- {
+		open, close, err := sessions[i].Bounds()
+		if err != nil {
+			return "", false
+		}
+		if !open.Before(now) {
+			continue
+		}
+		if close.After(now) {
+			close = now
+		}
+		need -= int(close.Sub(open) / (time.Duration(timeframeMin) * time.Minute))
+		if need <= 0 {
+			return open.UTC().Format(time.RFC3339), true
+		}
+	}
+	return "", false
+}
+
+// historyStart is where a full fetch of cfg.BarsPerSymbol bars starts: by
+// the trading calendar when fetch has one, else fallbackDays ago.
+func historyStart(cfg Config, now time.Time) string {
+	if start, ok := backfillStart(cfg.Sessions, cfg.BarsPerSymbol, cfg.TimeframeMin, now); ok {
+		return start
+	}
+	return now.UTC().AddDate(0, 0, -fallbackDays).Format(time.RFC3339)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+
+	"github.com/deanturpin/lft2/internal/quota"
+)
+
+// fitBudget forecasts whether fetching symbols every cycle fits the data
+// rate, and warns when it doesn't. With the reduce policy it also trims the
+// universe to what fits, keeping the best ranked recommended symbols as
+// -max-symbols does.
+func fitBudget(cfg Config, symbols []string, reqs map[string]Requirement) ([]string, quota.Forecast) {
+	forecast := quota.Plan(len(symbols), cfg.TimeframeMin, cfg.Rate)
+	log.Printf("API budget: %d data requests a cycle, %.1f minutes at %d a minute (%d a day)",
+		forecast.RequestsPerCycle, forecast.MinutesPerCycle, forecast.Rate, forecast.RequestsPerDay)
+	if forecast.Fits {
+		return symbols, forecast
+	}
+
+	log.Printf("⚠ %d symbols every %d minutes is over %.0f%% of the data rate, which fits %d",
+		len(symbols), cfg.TimeframeMin, quota.Headroom*100, forecast.MaxSymbols)
+	if cfg.Budget != quota.Reduce {
+		return symbols, forecast
+	}
+	kept, dropped := capSymbols(symbols, reqs, forecast.MaxSymbols)
+	log.Printf("  [skip] %d symbol(s) over the API budget", len(dropped))
+	return kept, forecast
+}
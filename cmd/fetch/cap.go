@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// maxSymbolsFromEnv returns LFT2_MAX_SYMBOLS, the most symbols live mode
+// fetches and trades, or 0 (no cap) when unset. Entries reads the same
+// variable, so both stop at the same symbols.
+func maxSymbolsFromEnv() (int, error) {
+	s := os.Getenv("LFT2_MAX_SYMBOLS")
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("LFT2_MAX_SYMBOLS must be a number of symbols, 0 for no cap, got %q", s)
+	}
+	return n, nil
+}
+
+// gzipFromEnv returns LFT2_BARS_GZIP, whether bar files are written
+// gzipped, false when unset.
+func gzipFromEnv() (bool, error) {
+	s := os.Getenv("LFT2_BARS_GZIP")
+	if s == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("LFT2_BARS_GZIP must be true or false, got %q", s)
+	}
+	return v, nil
+}
+
+// capSymbols keeps the first n candidates, which filter ranks best first,
+// with a viable recommendation: the symbols entries would trade. A permissive
+// backtest recommending hundreds of symbols then can't swamp the account or
+// the rate limit. Without recommendations to go on (reqs is empty) it keeps
+// the first n. Everything else is returned as dropped.
+func capSymbols(symbols []string, reqs map[string]Requirement, n int) (kept, dropped []string) {
+	for _, symbol := range symbols {
+		_, recommended := reqs[symbol]
+		if len(kept) < n && (recommended || len(reqs) == 0) {
+			kept = append(kept, symbol)
+		} else {
+			dropped = append(dropped, symbol)
+		}
+	}
+	return kept, dropped
+}
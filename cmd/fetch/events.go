@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/events"
+)
+
+// publishBars sends each refreshed symbol's bar file to the event bus,
+// skipping those that failed this run. It stops at the first error, since
+// the bus is then usually unreachable, and reports how many went out.
+func publishBars(bus events.Bus, dir string, symbols []string, failures []Failure, now time.Time) (int, error) {
+	failed := map[string]bool{}
+	for _, f := range failures {
+		failed[f.Symbol] = true
+	}
+
+	sent := 0
+	for _, symbol := range symbols {
+		if failed[symbol] {
+			continue
+		}
+		data, err := barfile.Read(dir, symbol)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return sent, err
+		}
+		if err := events.Publish(bus, events.Bars, symbol, data, now); err != nil {
+			return sent, fmt.Errorf("%s: %w", symbol, err)
+		}
+		sent++
+	}
+	return sent, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// retryDelay spaces the end-of-run retries, keeping them well inside
+// Alpaca's 200 requests a minute after the concurrent pass has used its share.
+const retryDelay = 300 * time.Millisecond
+
+// Failure is a symbol fetch couldn't refresh this run.
+type Failure struct {
+	Symbol  string `json:"symbol"`
+	Cause   string `json:"cause"` // See category
+	Error   string `json:"error"`
+	Retried bool   `json:"retried"`
+}
+
+// FailureReport is the layout of fetch-failures.json, read by filter so a
+// symbol without fresh bars is rejected by name rather than silently absent.
+type FailureReport struct {
+	schema.Header
+	Timestamp string    `json:"timestamp"`
+	Failures  []Failure `json:"failures"`
+}
+
+// retryable reports whether a second attempt at a cause might succeed.
+func retryable(cause string) bool {
+	switch cause {
+	case "rate_limited", "server", "network":
+		return true
+	}
+	return false
+}
+
+// retryFailed gives each transient failure from the concurrent pass one more
+// attempt, one symbol at a time, and returns how many recovered and what is
+// still missing. A short history isn't a failure here: its bars were saved
+// and filter's minimum bar count judges them. Nothing is retried once ctx is
+// cancelled.
+func retryFailed(ctx context.Context, failed []FetchResult, fetch func(symbol string) FetchResult, pause func(time.Duration)) (recovered int, failures []Failure) {
+	attempts := 0
+	for _, r := range failed {
+		f := Failure{Symbol: r.Symbol, Cause: category(r.Error), Error: r.Error.Error()}
+		if f.Cause == "short_history" {
+			continue
+		}
+		if retryable(f.Cause) && ctx.Err() == nil {
+			if attempts > 0 {
+				pause(retryDelay)
+			}
+			attempts++
+			f.Retried = true
+
+			again := fetch(r.Symbol)
+			if again.Error == nil {
+				log.Printf("✓ %s: %d bars (retry)", again.Symbol, again.Count)
+				recovered++
+				continue
+			}
+			log.Printf("✗ %s: %v (retry)", again.Symbol, again.Error)
+			f.Cause, f.Error = category(again.Error), again.Error.Error()
+			if f.Cause == "short_history" {
+				continue
+			}
+		}
+		failures = append(failures, f)
+	}
+
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Symbol < failures[j].Symbol })
+	return recovered, failures
+}
+
+// saveFailures writes fetch-failures.json. It's written on every run, empty
+// when nothing failed, so a stale list never outlives the fetch it describes.
+func saveFailures(path string, failures []Failure, now time.Time) error {
+	report := FailureReport{
+		Header:    schema.Current(),
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Failures:  failures,
+	}
+	if report.Failures == nil {
+		report.Failures = []Failure{}
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding failures: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
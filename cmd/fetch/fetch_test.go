@@ -1,10 +1,27 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/corporate"
+	"github.com/deanturpin/lft2/internal/httpconf"
+	"github.com/deanturpin/lft2/internal/marketdata"
+	"github.com/deanturpin/lft2/internal/quota"
+	"github.com/deanturpin/lft2/internal/spreads"
 )
 
 // --- loadWatchlist ---
@@ -49,11 +66,66 @@ func TestLoadWatchlist_Empty(t *testing.T) {
 	}
 }
 
+func TestLoadWatchlist_Normalises(t *testing.T) {
+	f := writeTemp(t, `{"symbols":[" aapl ","MSFT","","AAPL","brk.b","NOT A TICKER","$SPY","msft"]}`)
+	wl, err := loadWatchlist(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(wl.Symbols, ","); got != "AAPL,MSFT,BRK.B" {
+		t.Errorf("symbols: got %s, want AAPL,MSFT,BRK.B", got)
+	}
+	want := []string{
+		`"": empty`,
+		`"AAPL": duplicate of AAPL`,
+		`"NOT A TICKER": not a ticker`,
+		`"$SPY": not a ticker`,
+		`"msft": duplicate of MSFT`,
+	}
+	if strings.Join(wl.Dropped, "|") != strings.Join(want, "|") {
+		t.Errorf("dropped: got %q, want %q", wl.Dropped, want)
+	}
+}
+
+func TestLoadLiveWatchlist_LocalBase(t *testing.T) {
+	dir := t.TempDir()
+	candidates := `{"timestamp":"2024-01-01T00:00:00Z","symbols":["AAPL","MSFT"],"total_candidates":2}`
+	if err := os.WriteFile(filepath.Join(dir, "candidates.json"), []byte(candidates), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wl, err := loadLiveWatchlist(dir, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(wl.Symbols) != 2 || wl.Symbols[1] != "MSFT" {
+		t.Errorf("got %v, want [AAPL MSFT]", wl.Symbols)
+	}
+}
+
+func TestLoadLiveWatchlist_Book(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "books", "swing"), 0755)
+	candidates := `{"symbols":["NVDA"]}`
+	if err := os.WriteFile(filepath.Join(dir, "books", "swing", "candidates.json"), []byte(candidates), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wl, err := loadLiveWatchlist(dir, "books/swing/")
+	if err != nil || len(wl.Symbols) != 1 || wl.Symbols[0] != "NVDA" {
+		t.Errorf("got %+v, %v, want the swing book's [NVDA]", wl, err)
+	}
+}
+
+func TestLoadLiveWatchlist_Missing(t *testing.T) {
+	if _, err := loadLiveWatchlist(t.TempDir(), ""); err == nil {
+		t.Error("expected error for missing candidates.json, got nil")
+	}
+}
+
 // --- bar reversal (desc → asc) ---
 
 func TestBarsReversed(t *testing.T) {
 	// Simulate the reversal applied inside fetchBars after receiving desc-sorted bars.
-	bars := []AlpacaBar{
+	bars := []barfile.Bar{
 		{Timestamp: "2024-01-03"},
 		{Timestamp: "2024-01-02"},
 		{Timestamp: "2024-01-01"},
@@ -70,7 +142,7 @@ func TestBarsReversed(t *testing.T) {
 }
 
 func TestBarsReversed_Single(t *testing.T) {
-	bars := []AlpacaBar{{Timestamp: "2024-01-01"}}
+	bars := []barfile.Bar{{Timestamp: "2024-01-01"}}
 	for i, j := 0, len(bars)-1; i < j; i, j = i+1, j-1 {
 		bars[i], bars[j] = bars[j], bars[i]
 	}
@@ -83,13 +155,13 @@ func TestBarsReversed_Single(t *testing.T) {
 
 func TestSaveJSON(t *testing.T) {
 	dir := t.TempDir()
-	data := &SymbolData{
+	data := &barfile.Data{
 		Symbol:    "AAPL",
-		Bars:      []AlpacaBar{{Timestamp: "2024-01-01", Close: 180.0}},
+		Bars:      []barfile.Bar{{Timestamp: "2024-01-01", Close: 180.0}},
 		Count:     1,
 		FetchedAt: "2024-01-01T00:00:00Z",
 	}
-	if err := saveJSON(data, dir); err != nil {
+	if err := saveJSON(data, dir, false); err != nil {
 		t.Fatalf("saveJSON error: %v", err)
 	}
 
@@ -98,7 +170,7 @@ func TestSaveJSON(t *testing.T) {
 		t.Fatalf("reading saved file: %v", err)
 	}
 
-	var got SymbolData
+	var got barfile.Data
 	if err := json.Unmarshal(raw, &got); err != nil {
 		t.Fatalf("parsing saved JSON: %v", err)
 	}
@@ -113,6 +185,93 @@ func TestSaveJSON(t *testing.T) {
 	}
 }
 
+func TestSaveJSONGzip(t *testing.T) {
+	dir := t.TempDir()
+	data := &barfile.Data{Symbol: "AAPL", Bars: []barfile.Bar{{Timestamp: "2026-03-09T14:30:00Z", Close: 180}}, Count: 1}
+	if err := saveJSON(data, dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveJSON(data, dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "AAPL.json")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("plain file left beside the gzipped one: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "AAPL.json.gz")); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := loadSaved(dir, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Bars) != 1 || saved.Bars[0].Close != 180 {
+		t.Errorf("bars: got %+v", saved.Bars)
+	}
+}
+
+// --- incremental ---
+
+func TestLoadSaved(t *testing.T) {
+	dir := t.TempDir()
+	if saved, err := loadSaved(dir, "AAPL"); saved != nil || err != nil {
+		t.Fatalf("missing file: got %v, %v, want nil, nil", saved, err)
+	}
+
+	data := &barfile.Data{Symbol: "AAPL", Bars: []barfile.Bar{{Timestamp: "2026-02-18T14:30:00Z"}}, Count: 1}
+	if err := saveJSON(data, dir, false); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := loadSaved(dir, "AAPL")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(saved.Bars) != 1 || saved.Bars[0].Timestamp != "2026-02-18T14:30:00Z" {
+		t.Errorf("bars: got %+v", saved.Bars)
+	}
+
+	os.WriteFile(filepath.Join(dir, "MSFT.json"), []byte("not json"), 0644)
+	if _, err := loadSaved(dir, "MSFT"); err == nil {
+		t.Error("expected error for a corrupt file, got nil")
+	}
+}
+
+func TestResumeFrom(t *testing.T) {
+	saved := &barfile.Data{Bars: []barfile.Bar{
+		{Timestamp: "2026-02-18T14:30:00Z"},
+		{Timestamp: "2026-02-18T14:35:00Z"},
+	}}
+	if got := resumeFrom(saved, 2); got != "2026-02-18T14:35:00Z" {
+		t.Errorf("got %q, want the last saved bar", got)
+	}
+	// Too few saved bars: the gap is older history, so fetch in full
+	if got := resumeFrom(saved, 3); got != "" {
+		t.Errorf("short file: got %q, want empty", got)
+	}
+	if got := resumeFrom(nil, 2); got != "" {
+		t.Errorf("no file: got %q, want empty", got)
+	}
+}
+
+func TestSaveJSON_Canonical(t *testing.T) {
+	dir := t.TempDir()
+	data := &barfile.Data{Symbol: "AAPL", Count: 3, Bars: []barfile.Bar{
+		{Timestamp: "2026-03-10T15:05:00Z"},
+		{Timestamp: "2026-03-10T15:00:00Z"},
+		{Timestamp: "2026-03-10T15:05:00Z"},
+	}}
+	if err := saveJSON(data, dir, false); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := loadSaved(dir, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Count != 2 || len(saved.Bars) != 2 || saved.Bars[0].Timestamp != "2026-03-10T15:00:00Z" {
+		t.Errorf("got %+v", saved)
+	}
+}
+
 // writeTemp writes content to a temporary file and returns its path.
 func writeTemp(t *testing.T, content string) string {
 	t.Helper()
@@ -126,3 +285,789 @@ func writeTemp(t *testing.T, content string) string {
 	f.Close()
 	return f.Name()
 }
+
+// --- warm-up requirements ---
+
+func TestParseRequirements_MaxOfViable(t *testing.T) {
+	reqs, err := parseRequirements([]byte(`{"recommendations": [
+		{"symbol": "AAPL", "strategy": "price_dip", "required_bars": 2, "viable": true},
+		{"symbol": "AAPL", "strategy": "macd_crossover", "required_bars": 35, "viable": true},
+		{"symbol": "AAPL", "strategy": "sma_crossover", "required_bars": 200, "viable": false}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := reqs["AAPL"]; got.Bars != 35 || got.Strategy != "macd_crossover" {
+		t.Errorf("got %+v, want 35 bars for macd_crossover", got)
+	}
+}
+
+func TestCapSymbols(t *testing.T) {
+	reqs := map[string]Requirement{"MSFT": {}, "NVDA": {Bars: 35}, "TSLA": {}}
+	kept, dropped := capSymbols([]string{"AAPL", "MSFT", "NVDA", "TSLA"}, reqs, 2)
+	if got := strings.Join(kept, ","); got != "MSFT,NVDA" {
+		t.Errorf("kept: got %s, want the two best ranked recommended symbols", got)
+	}
+	if got := strings.Join(dropped, ","); got != "AAPL,TSLA" {
+		t.Errorf("dropped: got %s, want AAPL,TSLA", got)
+	}
+
+	// No strategies.json: just the best ranked
+	kept, _ = capSymbols([]string{"AAPL", "MSFT", "NVDA"}, nil, 2)
+	if got := strings.Join(kept, ","); got != "AAPL,MSFT" {
+		t.Errorf("no recommendations: got %s, want AAPL,MSFT", got)
+	}
+}
+
+func TestMaxSymbolsFromEnv(t *testing.T) {
+	t.Setenv("LFT2_MAX_SYMBOLS", "")
+	if n, err := maxSymbolsFromEnv(); err != nil || n != 0 {
+		t.Errorf("unset: got %d, %v, want no cap", n, err)
+	}
+	t.Setenv("LFT2_MAX_SYMBOLS", "20")
+	if n, err := maxSymbolsFromEnv(); err != nil || n != 20 {
+		t.Errorf("got %d, %v, want 20", n, err)
+	}
+	for _, bad := range []string{"-1", "lots"} {
+		t.Setenv("LFT2_MAX_SYMBOLS", bad)
+		if _, err := maxSymbolsFromEnv(); err == nil {
+			t.Errorf("%q: got nil, want an error", bad)
+		}
+	}
+}
+
+func TestGzipFromEnv(t *testing.T) {
+	t.Setenv("LFT2_BARS_GZIP", "")
+	if v, err := gzipFromEnv(); err != nil || v {
+		t.Errorf("unset: got %v, %v, want false", v, err)
+	}
+	t.Setenv("LFT2_BARS_GZIP", "true")
+	if v, err := gzipFromEnv(); err != nil || !v {
+		t.Errorf("got %v, %v, want true", v, err)
+	}
+	t.Setenv("LFT2_BARS_GZIP", "yes please")
+	if _, err := gzipFromEnv(); err == nil {
+		t.Error("got nil, want an error")
+	}
+}
+
+func TestLongest(t *testing.T) {
+	reqs := map[string]Requirement{
+		"MSFT": {Bars: 35, Strategy: "macd_crossover"},
+		"AAPL": {Bars: 35, Strategy: "macd_crossover"},
+		"TSLA": {Bars: 2, Strategy: "price_dip"},
+	}
+	if symbol, req := longest(reqs); symbol != "AAPL" || req.Bars != 35 {
+		t.Errorf("got %s %+v, want AAPL at 35 bars", symbol, req)
+	}
+	if symbol, req := longest(nil); symbol != "" || req.Bars != 0 {
+		t.Errorf("empty: got %s %+v", symbol, req)
+	}
+}
+
+func TestBarsFor(t *testing.T) {
+	cfg := Config{BarsPerSymbol: 25}
+	if got := barsFor(cfg, Requirement{Bars: 35}); got != 35 {
+		t.Errorf("got %d, want 35", got)
+	}
+	if got := barsFor(cfg, Requirement{}); got != 25 {
+		t.Errorf("got %d, want 25", got)
+	}
+}
+
+func TestCheckWarmup(t *testing.T) {
+	req := Requirement{Bars: 35, Strategy: "macd_crossover"}
+	if err := checkWarmup(34, req); err == nil || !strings.Contains(err.Error(), "macd_crossover needs 35") {
+		t.Errorf("got %v, want insufficient history error", err)
+	}
+	if err := checkWarmup(35, req); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// --- progress ---
+
+func TestProgress_ReportsEachTenth(t *testing.T) {
+	clock := time.Date(2025, 1, 2, 14, 30, 0, 0, time.UTC)
+	p := newProgress(20)
+	p.now = func() time.Time { return clock }
+	p.start, p.reported = clock, clock
+
+	var lines []string
+	for i := 0; i < 20; i++ {
+		clock = clock.Add(time.Second)
+		var err error
+		if i == 0 {
+			err = &statusError{Code: 429}
+		}
+		if line, due := p.record(err); due {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 10 {
+		t.Fatalf("got %d progress lines, want 10: %q", len(lines), lines)
+	}
+	if want := "Progress: 2/20 (10%) · 1.0 symbols/s · ETA 18s · 1 errors"; lines[0] != want {
+		t.Errorf("got %q, want %q", lines[0], want)
+	}
+	if !strings.Contains(lines[9], "20/20 (100%)") || !strings.Contains(lines[9], "ETA 0s") {
+		t.Errorf("final line %q", lines[9])
+	}
+}
+
+func TestProgress_ReportsAfterInterval(t *testing.T) {
+	clock := time.Date(2025, 1, 2, 14, 30, 0, 0, time.UTC)
+	p := newProgress(1000)
+	p.now = func() time.Time { return clock }
+	p.start, p.reported = clock, clock
+
+	if _, due := p.record(nil); due {
+		t.Error("first result should not report")
+	}
+	clock = clock.Add(progressInterval)
+	if _, due := p.record(nil); !due {
+		t.Error("should report once the interval has passed")
+	}
+}
+
+func TestCategory(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&statusError{Code: 429}, "rate_limited"},
+		{fmt.Errorf("executing request: %w", &statusError{Code: 422}), "bad_symbol"},
+		{&statusError{Code: 403}, "auth"},
+		{&statusError{Code: 503}, "server"},
+		{errNoBars, "no_data"},
+		{checkWarmup(10, Requirement{Bars: 50, Strategy: "x"}), "short_history"},
+		{fmt.Errorf("saving JSON: %w", &fs.PathError{Op: "open", Err: fs.ErrPermission}), "save"},
+		{json.Unmarshal([]byte("}"), &struct{}{}), "bad_response"},
+		{errors.New("mystery"), "other"},
+	}
+	for _, tt := range tests {
+		if got := category(tt.err); got != tt.want {
+			t.Errorf("category(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestProgressSummary(t *testing.T) {
+	p := newProgress(4)
+	p.record(&statusError{Code: 404})
+	p.record(&statusError{Code: 429})
+	p.record(&statusError{Code: 429})
+	p.record(nil)
+
+	got := p.summary()
+	if len(got) != 2 || !strings.HasPrefix(got[0], "rate_limited") || !strings.HasSuffix(got[0], " 2") {
+		t.Errorf("got %q, want rate_limited 2 first", got)
+	}
+}
+
+// --- retryFailed / saveFailures ---
+
+func TestRetryFailed(t *testing.T) {
+	failed := []FetchResult{
+		{Symbol: "ZZZ", Error: &statusError{Code: 429}},
+		{Symbol: "BAD", Error: &statusError{Code: 422}},
+		{Symbol: "SHORT", Error: checkWarmup(10, Requirement{Bars: 50, Strategy: "x"})},
+		{Symbol: "AAA", Error: &statusError{Code: 503}},
+	}
+	var fetched []string
+	fetch := func(symbol string) FetchResult {
+		fetched = append(fetched, symbol)
+		if symbol == "ZZZ" {
+			return FetchResult{Symbol: symbol, Count: 100}
+		}
+		return FetchResult{Symbol: symbol, Error: &statusError{Code: 429}}
+	}
+	pauses := 0
+
+	recovered, failures := retryFailed(context.Background(), failed, fetch, func(time.Duration) { pauses++ })
+
+	if recovered != 1 {
+		t.Errorf("recovered %d, want 1", recovered)
+	}
+	if strings.Join(fetched, ",") != "ZZZ,AAA" || pauses != 1 {
+		t.Errorf("retried %v with %d pauses, want ZZZ,AAA with 1", fetched, pauses)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("got %+v, want AAA and BAD", failures)
+	}
+	if f := failures[0]; f.Symbol != "AAA" || f.Cause != "rate_limited" || !f.Retried {
+		t.Errorf("AAA: got %+v, want rate_limited after retry", f)
+	}
+	if f := failures[1]; f.Symbol != "BAD" || f.Cause != "bad_symbol" || f.Retried {
+		t.Errorf("BAD: got %+v, want bad_symbol without retry", f)
+	}
+}
+
+func TestSaveFailures_EmptyList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fetch-failures.json")
+	if err := saveFailures(path, nil, time.Date(2025, 1, 2, 21, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"failures": []`) || !strings.Contains(string(data), `"schema_version": 1`) {
+		t.Errorf("got %s", data)
+	}
+}
+
+// --- sampleQuotes ---
+
+func TestSampleQuotes_Batches(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC) // 11:00 New York
+	symbols := make([]string, marketdata.MaxQuoteSymbols+2)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("S%03d", i)
+	}
+
+	var batches []int
+	quotes := func(batch []string) (map[string]marketdata.Quote, error) {
+		batches = append(batches, len(batch))
+		out := map[string]marketdata.Quote{}
+		for _, s := range batch {
+			if s == "S000" {
+				continue // No quote
+			}
+			out[s] = marketdata.Quote{Timestamp: now.Add(-10 * time.Second).Format(time.RFC3339), BidPrice: 99.99, BidSize: 3, AskPrice: 100.01, AskSize: 5}
+		}
+		return out, nil
+	}
+
+	f := &spreads.File{Symbols: map[string]*spreads.Stats{}}
+	latest := &spreads.Quotes{Symbols: map[string]spreads.Quote{}}
+	added, err := sampleQuotes(f, latest, symbols, quotes, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 || batches[0] != marketdata.MaxQuoteSymbols || batches[1] != 2 {
+		t.Errorf("batches: got %v", batches)
+	}
+	if added != len(symbols)-1 || f.Symbols["S001"].MedianBps != 2 || f.Symbols["S000"] != nil {
+		t.Errorf("got %d added, S001 %+v", added, f.Symbols["S001"])
+	}
+	if q := latest.Symbols["S001"]; len(latest.Symbols) != added || q.Bid != 99.99 || q.AskSize != 5 || q.SpreadBps != 2 {
+		t.Errorf("latest: got %d quotes, S001 %+v", len(latest.Symbols), q)
+	}
+	if latest.Timestamp != "2026-03-10T15:00:00Z" {
+		t.Errorf("latest timestamp: got %q", latest.Timestamp)
+	}
+
+	failing := func([]string) (map[string]marketdata.Quote, error) { return nil, errors.New("HTTP 403") }
+	if _, err := sampleQuotes(f, latest, symbols, failing, now); err == nil {
+		t.Error("quotes error: want it returned")
+	}
+}
+
+// --- marketOpen ---
+
+// fakeClock is a provider whose clock answers open, or fails with err.
+type fakeClock struct {
+	marketdata.Provider
+	open bool
+	err  error
+}
+
+func (f fakeClock) Name() string { return "fake" }
+
+func (f fakeClock) GetClock() (marketdata.Clock, error) {
+	return marketdata.Clock{Open: f.open}, f.err
+}
+
+func TestMarketOpen(t *testing.T) {
+	session := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC) // 11:00 New York
+	evening := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		clock fakeClock
+		now   time.Time
+		want  bool
+	}{
+		{"open", fakeClock{open: true}, session, true},
+		{"holiday", fakeClock{open: false}, session, false},
+		{"after the close", fakeClock{open: true}, evening, false},
+		{"clock unreadable", fakeClock{err: errors.New("HTTP 404")}, session, true},
+	}
+	for _, tt := range tests {
+		if got, _ := marketOpen(tt.clock, tt.now); got != tt.want {
+			t.Errorf("%s: got %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+// --- fetchSymbol ---
+
+// fakeBars is a provider that answers every symbol with bars, or err.
+type fakeBars struct {
+	marketdata.Provider
+	name, feed string
+	bars       []marketdata.Bar
+	err        error
+}
+
+func (f fakeBars) Name() string { return f.name }
+
+func (f fakeBars) Feed() string { return f.feed }
+
+func (f fakeBars) GetBars(string, marketdata.BarsRequest) ([]marketdata.Bar, error) {
+	return f.bars, f.err
+}
+
+func TestFetchSymbol_Fallback(t *testing.T) {
+	bars := []marketdata.Bar{{Timestamp: "2026-03-10T15:00:00Z", Open: 1, High: 1, Low: 1, Close: 1, Volume: 10}}
+	cfg := Config{
+		OutputDir:    t.TempDir(),
+		TimeframeMin: 5,
+		Feed:         "iex",
+		Provider:     fakeBars{name: "alpaca", feed: "iex"},
+		Fallback:     fakeBars{name: "yahoo", feed: "sip", bars: bars},
+	}
+	if r := fetchSymbol(cfg, "THIN", Requirement{}); r.Error != nil || r.Count != 1 {
+		t.Fatalf("got %+v, want one bar from the fallback", r)
+	}
+	saved, err := loadSaved(cfg.OutputDir, "THIN")
+	if err != nil || saved == nil {
+		t.Fatalf("saved: %v", err)
+	}
+	if saved.Source != "yahoo" || saved.Feed != "sip" {
+		t.Errorf("got source %q feed %q, want yahoo sip", saved.Source, saved.Feed)
+	}
+
+	// A fallback that fails leaves the provider's cause
+	cfg.Fallback = fakeBars{name: "yahoo", err: errors.New("HTTP 404")}
+	if r := fetchSymbol(cfg, "GONE", Requirement{}); !errors.Is(r.Error, errNoBars) {
+		t.Errorf("got %v, want errNoBars", r.Error)
+	}
+
+	// The main provider's bars don't ask the fallback
+	cfg.Provider = fakeBars{name: "alpaca", feed: "iex", bars: bars}
+	if r := fetchSymbol(cfg, "AAPL", Requirement{}); r.Error != nil {
+		t.Fatal(r.Error)
+	}
+	if saved, _ := loadSaved(cfg.OutputDir, "AAPL"); saved == nil || saved.Source != "alpaca" {
+		t.Errorf("got %+v, want bars from alpaca", saved)
+	}
+}
+
+// --- publishBars ---
+
+// busRecorder is an events.Bus that keeps the subjects published to.
+type busRecorder struct {
+	subjects []string
+	fail     bool
+}
+
+func (b *busRecorder) Publish(subject string, data []byte) error {
+	if b.fail {
+		return errors.New("connection reset")
+	}
+	b.subjects = append(b.subjects, subject)
+	return nil
+}
+
+func (b *busRecorder) Subscribe(string, func(string, []byte) error) error { return nil }
+func (b *busRecorder) Close() error                                       { return nil }
+
+func TestPublishBars(t *testing.T) {
+	dir := t.TempDir()
+	for _, s := range []string{"AAPL", "MSFT", "TSLA"} {
+		if err := os.WriteFile(filepath.Join(dir, s+".json"), []byte(`{"symbol":"`+s+`"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Date(2026, 3, 10, 15, 5, 40, 0, time.UTC)
+
+	// MSFT failed this run and NVDA has no file
+	bus := &busRecorder{}
+	n, err := publishBars(bus, dir, []string{"AAPL", "MSFT", "NVDA", "TSLA"}, []Failure{{Symbol: "MSFT"}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || fmt.Sprint(bus.subjects) != "[lft2.bars.AAPL lft2.bars.TSLA]" {
+		t.Errorf("got %d: %v", n, bus.subjects)
+	}
+
+	if _, err := publishBars(&busRecorder{fail: true}, dir, []string{"AAPL"}, nil, now); err == nil || !strings.Contains(err.Error(), "AAPL") {
+		t.Errorf("got %v, want the failing symbol", err)
+	}
+}
+
+// --- tokenBucket ---
+
+// fakeBucket returns a bucket on a clock that moves only when it sleeps,
+// and the total time slept.
+func fakeBucket(perMinute int) (*tokenBucket, *time.Duration) {
+	clock := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+	slept := new(time.Duration)
+	b := newTokenBucket(context.Background(), perMinute, httpconf.Default)
+	b.now = func() time.Time { return clock }
+	b.sleep = func(d time.Duration) { clock = clock.Add(d); *slept += d }
+	b.last = clock
+	return b, slept
+}
+
+func TestTokenBucket_PacesAfterBurst(t *testing.T) {
+	b, slept := fakeBucket(60)
+	for i := 0; i < rateBurst; i++ {
+		b.wait()
+	}
+	if *slept != 0 {
+		t.Fatalf("burst: slept %v, want none", *slept)
+	}
+	for i := 0; i < 5; i++ {
+		b.wait()
+	}
+	if *slept != 5*time.Second {
+		t.Errorf("after the burst: slept %v, want a second a request", *slept)
+	}
+}
+
+func TestTokenBucket_Queues(t *testing.T) {
+	b, _ := fakeBucket(60)
+	for i := 0; i < rateBurst; i++ {
+		b.reserve()
+	}
+	// Goroutines arriving together are given successive slots
+	if first, second := b.reserve(), b.reserve(); first != time.Second || second != 2*time.Second {
+		t.Errorf("got %v and %v, want 1s and 2s", first, second)
+	}
+}
+
+func TestTokenBucket_Pause(t *testing.T) {
+	b, slept := fakeBucket(60)
+	b.pause(3 * time.Second)
+	b.wait()
+	if *slept != 3*time.Second {
+		t.Errorf("slept %v, want the 3s pause", *slept)
+	}
+	// The burst was dropped, so only what the pause refilled goes at once
+	for i := 0; i < 3; i++ {
+		b.wait()
+	}
+	if *slept != 4*time.Second {
+		t.Errorf("after the pause: slept %v, want 4s", *slept)
+	}
+}
+
+func TestTokenBucket_RetriesRateLimit(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= 2 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"bars":[]}`)
+	}))
+	defer srv.Close()
+
+	b, slept := fakeBucket(200)
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := b.do(req)
+	if err != nil || string(body) != `{"bars":[]}` || calls != 3 {
+		t.Fatalf("got %q, %v after %d calls", body, err, calls)
+	}
+	if *slept != 14*time.Second {
+		t.Errorf("slept %v, want Retry-After twice", *slept)
+	}
+}
+
+func TestTokenBucket_GivesUp(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	b, slept := fakeBucket(200)
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = b.do(req)
+	if category(err) != "rate_limited" || calls != rateRetries+1 {
+		t.Errorf("got %v after %d calls, want rate_limited after %d", err, calls, rateRetries+1)
+	}
+	// 2s, 4s, 8s, 16s without a Retry-After
+	if want := rateBackoff * 15; *slept < want {
+		t.Errorf("slept %v, want at least the %v of backoff", *slept, want)
+	}
+}
+
+func TestTokenBucket_RetriesServerErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"bars":[]}`)
+	}))
+	defer srv.Close()
+
+	b, slept := fakeBucket(200)
+	b.policy = httpconf.Policy{Timeout: time.Second, Retries: 2, Backoff: 3 * time.Second}
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, err := b.do(req); err != nil || string(body) != `{"bars":[]}` || calls != 2 || *slept != 3*time.Second {
+		t.Errorf("got %q, %v after %d calls and %v", body, err, calls, *slept)
+	}
+
+	// A 4xx won't improve, and without retries a 5xx is final
+	for policy, code := range map[int]int{2: http.StatusNotFound, 0: http.StatusBadGateway} {
+		calls = 0
+		srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(code)
+		})
+		b.policy.Retries = policy
+		if _, err := b.do(req); err == nil || calls != 1 {
+			t.Errorf("HTTP %d with %d retries: got %v after %d calls, want one", code, policy, err, calls)
+		}
+	}
+}
+
+func TestTokenBucket_CountsAlpacaHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+	bars := func() int {
+		calls, _ := alpaca.Usage()
+		return calls["data GET /v2/stocks/{}/bars"]
+	}
+
+	b, _ := fakeBucket(200)
+	req, err := http.NewRequest("GET", srv.URL+"/v2/stocks/AAPL/bars", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := bars()
+	if _, err := b.do(req); err != nil || bars() != before {
+		t.Errorf("another host's request counted: %v", err)
+	}
+	b.counted = req.URL.Host
+	// Signed as an OAuth app would be, with no Alpaca key header
+	req.Header.Set("Authorization", "Bearer tok")
+	if _, err := b.do(req); err != nil || bars() != before+1 {
+		t.Errorf("Alpaca's request not counted: %v", err)
+	}
+}
+
+func TestRateFromEnv(t *testing.T) {
+	t.Setenv("LFT2_DATA_RATE", "")
+	if n, err := rateFromEnv(); err != nil || n != defaultRate {
+		t.Errorf("unset: got %d, %v", n, err)
+	}
+	t.Setenv("LFT2_DATA_RATE", "1000")
+	if n, err := rateFromEnv(); err != nil || n != 1000 {
+		t.Errorf("paid plan: got %d, %v", n, err)
+	}
+	for _, bad := range []string{"0", "-5", "fast"} {
+		t.Setenv("LFT2_DATA_RATE", bad)
+		if _, err := rateFromEnv(); err == nil {
+			t.Errorf("%q: got nil, want an error", bad)
+		}
+	}
+}
+
+// --- calendar-aware backfill ---
+
+// yearEnd is the 2025/26 holiday stretch: a half day on Christmas Eve, then
+// Christmas and New Year's Day closed.
+var yearEnd = []alpaca.Session{
+	{Date: "2025-12-22", Open: "09:30", Close: "16:00"},
+	{Date: "2025-12-23", Open: "09:30", Close: "16:00"},
+	{Date: "2025-12-24", Open: "09:30", Close: "13:00"},
+	{Date: "2025-12-26", Open: "09:30", Close: "16:00"},
+	{Date: "2025-12-29", Open: "09:30", Close: "16:00"},
+	{Date: "2025-12-30", Open: "09:30", Close: "16:00"},
+	{Date: "2025-12-31", Open: "09:30", Close: "16:00"},
+	{Date: "2026-01-02", Open: "09:30", Close: "16:00"},
+}
+
+func TestBackfillStart_YearEnd(t *testing.T) {
+	// After the close on 2 January
+	now := time.Date(2026, 1, 2, 22, 0, 0, 0, time.UTC)
+	tests := []struct {
+		bars int
+		want string
+	}{
+		{78, "2026-01-02T14:30:00Z"},
+		{79, "2025-12-31T14:30:00Z"},               // Skips New Year's Day
+		{78 * 5, "2025-12-26T14:30:00Z"},           // Skips the weekend and Christmas
+		{78*5 + 42, "2025-12-24T14:30:00Z"},        // The half day holds 42 bars
+		{78*5 + 43, "2025-12-23T14:30:00Z"},        // One more reaches the day before
+		{78*5 + 42 + 78*2, "2025-12-22T14:30:00Z"}, // Exactly the whole calendar
+	}
+	for _, tt := range tests {
+		got, ok := backfillStart(yearEnd, tt.bars, 5, now)
+		if !ok || got != tt.want {
+			t.Errorf("%d bars: got %q, %t, want %s", tt.bars, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := backfillStart(yearEnd, 78*5+42+78*2+1, 5, now); ok {
+		t.Error("more bars than the calendar holds: want ok false")
+	}
+}
+
+func TestBackfillStart_InSession(t *testing.T) {
+	// 11:00 New York on 2 January: 18 bars so far today
+	now := time.Date(2026, 1, 2, 16, 0, 0, 0, time.UTC)
+	if got, _ := backfillStart(yearEnd, 18, 5, now); got != "2026-01-02T14:30:00Z" {
+		t.Errorf("18 bars: got %s, want today's open", got)
+	}
+	if got, _ := backfillStart(yearEnd, 19, 5, now); got != "2025-12-31T14:30:00Z" {
+		t.Errorf("19 bars: got %s, want the last session's open", got)
+	}
+
+	// Before the open today's session doesn't count at all
+	early := time.Date(2026, 1, 2, 13, 0, 0, 0, time.UTC)
+	if got, _ := backfillStart(yearEnd, 1, 5, early); got != "2025-12-31T14:30:00Z" {
+		t.Errorf("before the open: got %s, want the last session's open", got)
+	}
+}
+
+func TestHistoryStart_NoCalendar(t *testing.T) {
+	now := time.Date(2026, 1, 2, 22, 0, 0, 0, time.UTC)
+	if got := historyStart(Config{BarsPerSymbol: 1000, TimeframeMin: 5}, now); got != "2025-11-21T22:00:00Z" {
+		t.Errorf("got %s, want %d days back", got, fallbackDays)
+	}
+}
+
+func TestCalendarSpan(t *testing.T) {
+	// 1000 5-minute bars are 13 sessions, under three weeks
+	if got := calendarSpan(1000, 5); got != 13*7/5+14 {
+		t.Errorf("got %d days", got)
+	}
+}
+
+// --- API budget ---
+
+func TestFitBudget(t *testing.T) {
+	symbols := make([]string, 20)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("S%02d", i)
+	}
+	// 1 minute at 10 a minute leaves 8 requests: 7 bars and a quotes batch
+	cfg := Config{TimeframeMin: 1, Rate: 10, Budget: quota.Warn}
+	kept, forecast := fitBudget(cfg, symbols, nil)
+	if len(kept) != 20 || forecast.Fits || forecast.MaxSymbols != 7 {
+		t.Errorf("warn: got %d symbols, forecast %+v, want all 20 and 7 fitting", len(kept), forecast)
+	}
+
+	cfg.Budget = quota.Reduce
+	if kept, _ = fitBudget(cfg, symbols, nil); strings.Join(kept, ",") != "S00,S01,S02,S03,S04,S05,S06" {
+		t.Errorf("reduce: got %v, want the 7 best ranked", kept)
+	}
+}
+
+// --- Splits ---
+
+func TestAdjustSplits(t *testing.T) {
+	data := &barfile.Data{Symbol: "NVDA", Bars: []barfile.Bar{
+		{Timestamp: "2026-03-09T19:55:00Z", Open: 800, High: 804, Low: 796, Close: 800, Volume: 1000},
+		{Timestamp: "2026-03-10T13:30:00Z", Open: 201, High: 202, Low: 200, Close: 201, Volume: 4000},
+	}}
+	splits := []corporate.Action{{ID: "s1", Symbol: "NVDA", Type: corporate.Split, ExDate: "2026-03-10", OldRate: 1, NewRate: 4}}
+
+	if n := adjustSplits(data, splits); n != 1 {
+		t.Fatalf("applied %d, want 1", n)
+	}
+	before := data.Bars[0]
+	if before.Open != 200 || before.High != 201 || before.Low != 199 || before.Close != 200 || before.Volume != 4000 {
+		t.Errorf("bar before the ex date: got %+v, want a quarter of the price and four times the volume", before)
+	}
+	if data.Bars[1].Close != 201 {
+		t.Errorf("bar on the ex date changed: %+v", data.Bars[1])
+	}
+
+	// An incremental run that merges into the file mustn't apply it again
+	if n := adjustSplits(data, splits); n != 0 || data.Bars[0].Close != 200 {
+		t.Errorf("reapplied: %d, close %v", n, data.Bars[0].Close)
+	}
+}
+
+// --- fetchAll ---
+
+func TestFetchAll(t *testing.T) {
+	symbols := make([]string, 50)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("S%d", i)
+	}
+
+	var running, most atomic.Int32
+	var done int
+	results := fetchAll(context.Background(), symbols, 4, func(symbol string) FetchResult {
+		n := running.Add(1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return FetchResult{Symbol: symbol}
+	}, func(FetchResult) { done++ })
+
+	if m := most.Load(); m > 4 || m < 2 {
+		t.Errorf("at most %d ran at once, want 2 to 4", m)
+	}
+	if done != len(symbols) {
+		t.Errorf("done called %d times, want %d", done, len(symbols))
+	}
+	for i, r := range results {
+		if r.Symbol != symbols[i] {
+			t.Fatalf("result %d is %s, want watchlist order", i, r.Symbol)
+		}
+	}
+}
+
+func TestFetchAll_Interrupted(t *testing.T) {
+	symbols := []string{"AAPL", "MSFT", "NVDA", "TSLA"}
+	ctx, cancel := context.WithCancel(context.Background())
+	results := fetchAll(ctx, symbols, 1, func(symbol string) FetchResult {
+		cancel() // Interrupted during the first symbol
+		return FetchResult{Symbol: symbol, Count: 10}
+	}, func(FetchResult) {})
+
+	if results[0].Error != nil || results[0].Count != 10 {
+		t.Errorf("the symbol under way should finish: got %+v", results[0])
+	}
+	for _, r := range results[2:] {
+		if r.Symbol == "" || category(r.Error) != "interrupted" {
+			t.Errorf("got %+v, want the symbol marked interrupted", r)
+		}
+	}
+
+	// Interrupted symbols are reported but never retried
+	retried := 0
+	_, failures := retryFailed(ctx, results[2:], func(symbol string) FetchResult {
+		retried++
+		return FetchResult{Symbol: symbol}
+	}, func(time.Duration) {})
+	if retried != 0 || len(failures) != 2 || failures[0].Cause != "interrupted" {
+		t.Errorf("got %d retries and %+v", retried, failures)
+	}
+}
+
+func TestFetchAll_Empty(t *testing.T) {
+	if results := fetchAll(context.Background(), nil, 4, nil, nil); len(results) != 0 {
+		t.Errorf("got %v", results)
+	}
+}
@@ -4,24 +4,26 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-// NewAlpacaRequest creates an HTTP request with Alpaca authentication headers
-func NewAlpacaRequest(method, url, apiKey, apiSecret string) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("APCA-API-KEY-ID", apiKey)
-	req.Header.Set("APCA-API-SECRET-KEY", apiSecret)
+// statusError is a non-200 response, kept typed so failures can be grouped by
+// cause at the end of a run.
+type statusError struct {
+	Code       int
+	Body       string
+	RetryAfter time.Duration // From the Retry-After header, 0 if absent
+}
 
-	return req, nil
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.Code, e.Body)
 }
 
-// ExecuteRequest executes an HTTP request and returns the response body
-func ExecuteRequest(req *http.Request) ([]byte, error) {
-	client := &http.Client{}
+// ExecuteRequest executes an HTTP request, giving up after timeout, and
+// returns the response body.
+func ExecuteRequest(req *http.Request, timeout time.Duration) ([]byte, error) {
+	client := &http.Client{Timeout: timeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
@@ -34,7 +36,11 @@ func ExecuteRequest(req *http.Request) ([]byte, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		err := &statusError{Code: resp.StatusCode, Body: string(body)}
+		if secs, e := strconv.Atoi(resp.Header.Get("Retry-After")); e == nil && secs > 0 {
+			err.RetryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, err
 	}
 
 	return body, nil
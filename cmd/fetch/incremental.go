@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+)
+
+// savedBars is what an incremental fetch builds on: the store's bars for
+// symbol with LFT2_STORE set, else its bar file, as on the first run with
+// the store.
+func (cfg Config) savedBars(symbol string) (*barfile.Data, error) {
+	if cfg.Store != nil {
+		if saved, err := cfg.Store.Bars(cfg.BookName, symbol); saved != nil || err != nil {
+			return saved, err
+		}
+	}
+	return loadSaved(cfg.OutputDir, symbol)
+}
+
+// loadSaved reads a symbol's bar file from an earlier run, for -incremental.
+// A missing file isn't an error, just nothing to build on: nil is returned
+// and the symbol is fetched in full.
+func loadSaved(outputDir, symbol string) (*barfile.Data, error) {
+	raw, err := barfile.Read(outputDir, symbol)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading saved bars: %w", err)
+	}
+
+	var saved barfile.Data
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		return nil, fmt.Errorf("parsing saved bars: %w", err)
+	}
+	if saved.Symbol != symbol {
+		return nil, fmt.Errorf("saved bars are for %q", saved.Symbol)
+	}
+	return &saved, nil
+}
+
+// resumeFrom returns the timestamp an incremental fetch starts from: the last
+// saved bar, which is requested again as it may have been still forming. It
+// is empty when the saved file can't be extended, because it's missing or
+// holds fewer than want bars. Those missing bars are older ones, which only a
+// full fetch brings back.
+func resumeFrom(saved *barfile.Data, want int) string {
+	if saved == nil || len(saved.Bars) == 0 || len(saved.Bars) < want {
+		return ""
+	}
+	return saved.Bars[len(saved.Bars)-1].Timestamp
+}
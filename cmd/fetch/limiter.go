@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/httpconf"
+)
+
+// defaultRate is Alpaca's data limit on the free plan, in requests a minute.
+// A paid plan allows more; raise it with -rate or LFT2_DATA_RATE.
+const defaultRate = 200
+
+// rateBurst is how many requests may go back to back before the bucket
+// paces them, so a small watchlist isn't slowed at all.
+const rateBurst = 10
+
+// Rate limit retries: each 429 pauses every goroutine for Retry-After, or
+// for a doubling backoff from rateBackoff when Alpaca doesn't say.
+const (
+	rateRetries = 4
+	rateBackoff = 2 * time.Second
+)
+
+// tokenBucket paces requests from every fetch goroutine to perMinute. A
+// request that finds the bucket empty takes a token in advance and sleeps
+// until it's due, so waiting goroutines queue fairly rather than racing for
+// the next token. A 429 pauses the whole bucket: if one request was refused
+// the rest would be too. A timeout, network error or 5xx is retried as the
+// fetch stage's HTTP policy allows, holding up only the request that failed.
+// Cancelling ctx abandons requests in flight and refuses new ones.
+type tokenBucket struct {
+	ctx      context.Context
+	mu       sync.Mutex
+	interval time.Duration // One token per interval
+	burst    float64
+	tokens   float64 // Below zero when requests are queued
+	last     time.Time
+	paused   time.Time // No request starts before this
+	policy   httpconf.Policy
+	counted  string // Host whose requests count against Alpaca's budget
+	now      func() time.Time
+	sleep    func(time.Duration)
+}
+
+func newTokenBucket(ctx context.Context, perMinute int, policy httpconf.Policy) *tokenBucket {
+	b := &tokenBucket{
+		ctx:      ctx,
+		policy:   policy,
+		interval: time.Minute / time.Duration(perMinute),
+		burst:    rateBurst,
+		tokens:   rateBurst,
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+	b.last = b.now()
+	return b
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens = min(b.burst, b.tokens+float64(now.Sub(b.last))/float64(b.interval))
+	b.last = now
+	b.tokens--
+
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens * float64(b.interval))
+	}
+	if b.paused.After(now) {
+		wait = max(wait, b.paused.Sub(now))
+	}
+	return wait
+}
+
+// wait blocks until the caller may send its request. A pause that began
+// while it slept holds it too, after which it queues again: its slot passed
+// during the pause, and every request whose slot did can't go at once.
+func (b *tokenBucket) wait() {
+	for d := b.reserve(); d > 0; {
+		b.sleep(d)
+		d = 0
+		if h := b.held(); h > 0 {
+			b.sleep(h)
+			d = b.reserve()
+		}
+	}
+}
+
+// held returns what's left of a pause.
+func (b *tokenBucket) held() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now := b.now(); b.paused.After(now) {
+		return b.paused.Sub(now)
+	}
+	return 0
+}
+
+// pause holds every request for d, and drops any saved-up burst so they
+// resume at the steady rate.
+func (b *tokenBucket) pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until := b.now().Add(d); until.After(b.paused) {
+		b.paused = until
+	}
+	b.tokens = min(b.tokens, 0)
+}
+
+// do sends req at the bucket's pace, waiting out and retrying a 429 up to
+// rateRetries times and any other transient failure up to the policy's
+// retries.
+func (b *tokenBucket) do(req *http.Request) ([]byte, error) {
+	limited, failed := 0, 0
+	req = req.WithContext(b.ctx)
+	for {
+		b.wait()
+		if err := b.ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Alpaca's requests count against its budget, another provider's
+		// don't. The host tells them apart whichever way either signs.
+		if req.URL.Host == b.counted {
+			alpaca.Count(req.Method, req.URL.String(), b.now())
+		}
+		body, err := ExecuteRequest(req, b.policy.Timeout)
+		if err == nil {
+			return body, nil
+		}
+		var status *statusError
+		switch {
+		case errors.As(err, &status) && status.Code == http.StatusTooManyRequests:
+			if limited == rateRetries {
+				return nil, err
+			}
+			d := status.RetryAfter
+			if d <= 0 {
+				d = rateBackoff << limited
+			}
+			limited++
+			log.Printf("  [WARNING] rate limited, pausing %v (retry %d of %d)", d, limited, rateRetries)
+			b.pause(d)
+		case (status == nil || status.Code >= 500) && failed < b.policy.Retries && b.ctx.Err() == nil:
+			failed++
+			d := b.policy.Delay(failed)
+			log.Printf("  [WARNING] %v, retrying in %v (retry %d of %d)", err, d, failed, b.policy.Retries)
+			b.sleep(d)
+		default:
+			return nil, err
+		}
+	}
+}
+
+// rateFromEnv returns LFT2_DATA_RATE in requests a minute, or defaultRate
+// when unset.
+func rateFromEnv() (int, error) {
+	s := os.Getenv("LFT2_DATA_RATE")
+	if s == "" {
+		return defaultRate, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("LFT2_DATA_RATE must be a positive number of requests a minute, got %q", s)
+	}
+	return n, nil
+}
@@ -1,76 +1,214 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
-	"path/filepath"
-	"sync"
+	"regexp"
+	"strings"
 	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/artifact"
+	"github.com/deanturpin/lft2/internal/assets"
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/books"
+	"github.com/deanturpin/lft2/internal/corporate"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/events"
+	"github.com/deanturpin/lft2/internal/httpconf"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/manifest"
+	"github.com/deanturpin/lft2/internal/marketdata"
+	"github.com/deanturpin/lft2/internal/quota"
+	"github.com/deanturpin/lft2/internal/schema"
+	"github.com/deanturpin/lft2/internal/spreads"
+	"github.com/deanturpin/lft2/internal/store"
+	"github.com/deanturpin/lft2/internal/tz"
+	"github.com/deanturpin/lft2/internal/version"
 )
 
 type Config struct {
-	APIKey        string
-	APISecret     string
+	Auth          alpaca.Auth
+	BaseURL       string
 	DataURL       string
 	WatchlistFile string
+	Live          bool
+	PagesBase     string
+	BookDir       string      // The book's artifacts under PagesBase, e.g. books/swing/; "" for the default book
+	BookName      string      // From LFT2_BOOK, keying the book's bars in Store; "" for the default book
+	Store         store.Store // Bars are merged into and saved to it, from LFT2_STORE; nil without one
 	OutputDir     string
 	BarsPerSymbol int
+	LiveBars      int
 	TimeframeMin  int
+	Feed          string // Bar feed: sip or iex, sip from Polygon, default when Alpaca picks
+	AssetsFile    string
+	Fundamentals  string
+	FailuresFile  string
+	ManifestFile  string
+	SpreadsFile   string
+	QuotesFile    string
+	MaxSymbols    int              // Cap on symbols with -live; 0 for none
+	Incremental   bool             // Extend the saved bar files rather than refetch them
+	Sessions      []alpaca.Session // Trading calendar for backfill, oldest first
+	Rate          int              // Data requests a minute, across every goroutine
+	Concurrency   int              // Symbols fetched at once
+	Budget        string           // quota.Warn or quota.Reduce, from LFT2_API_BUDGET
+	ActionsFile   string           // Corporate actions whose splits adjust the saved bars
+	Actions       *corporate.File  // Loaded from ActionsFile
+	UsageFile     string           // API usage tally, updated as fetch finishes
+	Gzip          bool             // Write bar files as {SYMBOL}.json.gz
+	Limiter       *tokenBucket     // Paces bar requests to Rate
+	HTTP          httpconf.Policy  // Timeout and retries, from http.json's "fetch"
+	ProviderName  string           // Market data provider, from LFT2_DATA_PROVIDER
+	Provider      marketdata.Provider
+	FallbackName  string              // Asked for bars the provider has none of, from LFT2_FALLBACK_PROVIDER
+	Fallback      marketdata.Provider // nil without one
 }
 
 type Watchlist struct {
 	Symbols []string `json:"symbols"`
+	Dropped []string `json:"-"` // Entries removed by normalise, with the reason
 }
 
-type AlpacaBar struct {
-	Timestamp string  `json:"t"`
-	Open      float64 `json:"o"`
-	High      float64 `json:"h"`
-	Low       float64 `json:"l"`
-	Close     float64 `json:"c"`
-	Volume    int64   `json:"v"`
-}
-
-type AlpacaBarsResponse struct {
-	Bars          []AlpacaBar `json:"bars"`
-	Symbol        string      `json:"symbol"`
-	NextPageToken string      `json:"next_page_token,omitempty"`
-}
-
-type SymbolData struct {
-	Symbol    string      `json:"symbol"`
-	Bars      []AlpacaBar `json:"bars"`
-	Count     int         `json:"count"`
-	FetchedAt string      `json:"fetched_at"`
+// tickerPattern matches a US equity ticker: up to six letters or digits,
+// starting with a letter, with an optional share class such as BRK.B.
+var tickerPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,5}(\.[A-Z]{1,2})?$`)
+
+// normalise trims and uppercases each symbol, then drops blanks, anything
+// that isn't a ticker, and repeats, keeping the first occurrence. A bad entry
+// would otherwise fetch twice or write a bar file no later stage looks for.
+func (w *Watchlist) normalise() {
+	seen := map[string]bool{}
+	kept := w.Symbols[:0]
+	for _, raw := range w.Symbols {
+		symbol := strings.ToUpper(strings.TrimSpace(raw))
+		switch {
+		case symbol == "":
+			w.Dropped = append(w.Dropped, fmt.Sprintf("%q: empty", raw))
+		case !tickerPattern.MatchString(symbol):
+			w.Dropped = append(w.Dropped, fmt.Sprintf("%q: not a ticker", raw))
+		case seen[symbol]:
+			w.Dropped = append(w.Dropped, fmt.Sprintf("%q: duplicate of %s", raw, symbol))
+		default:
+			seen[symbol] = true
+			kept = append(kept, symbol)
+		}
+	}
+	w.Symbols = kept
 }
 
 type FetchResult struct {
 	Symbol string
 	Count  int
+	Added  int // Bars not in the saved file, with -incremental
 	Error  error
 }
 
-func loadConfig() Config {
+func loadConfig(ctx context.Context) Config {
 	cfg := Config{}
+
+	// A book fetches its own timeframe into its own root. The default book's
+	// fetch samples spreads and asset metadata, which every book shares.
+	_, book, err := books.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	root := book.Root()
+	cfg.BookDir = strings.TrimPrefix(root, "docs/")
+	timeframe, assetsFile, spreadsFile, quotesFile := 5, assets.DefaultPath, spreads.DefaultPath, spreads.QuotesPath
+	if book != nil {
+		timeframe, assetsFile, spreadsFile, quotesFile = book.TimeframeMin, "", "", ""
+		cfg.BookName = book.Name
+	}
+	if cfg.Store, err = store.FromEnv(); err != nil {
+		log.Fatalf("%s: %v", store.Env, err)
+	}
+
 	flag.StringVar(&cfg.WatchlistFile, "watchlist", "watchlist.json", "Path to watchlist JSON file")
-	flag.StringVar(&cfg.OutputDir, "output", "docs/bars", "Output directory for bar data")
+	flag.BoolVar(&cfg.Live, "live", false, "Use the published candidates.json as the watchlist")
+	flag.StringVar(&cfg.PagesBase, "pages-base", artifact.Base(), "Artifact base URL or directory for -live (default $LFT2_ARTIFACT_BASE)")
+	flag.StringVar(&cfg.OutputDir, "output", root+"bars", "Output directory for bar data")
 	flag.IntVar(&cfg.BarsPerSymbol, "bars", 1000, "Number of bars to fetch per symbol")
-	flag.IntVar(&cfg.TimeframeMin, "timeframe", 5, "Timeframe in minutes")
+	flag.IntVar(&cfg.LiveBars, "live-bars", 25, "Bars to fetch per symbol with -live, raised to each symbol's warm-up requirement")
+	maxSymbols, err := maxSymbolsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	flag.IntVar(&cfg.MaxSymbols, "max-symbols", maxSymbols, "Most recommended symbols to fetch with -live, best ranked first; 0 for no cap (default $LFT2_MAX_SYMBOLS)")
+	flag.IntVar(&cfg.TimeframeMin, "timeframe", timeframe, "Timeframe in minutes (default the book's, $LFT2_BOOK)")
+	flag.StringVar(&cfg.Feed, "feed", marketdata.FeedFromEnv(), "Alpaca bar feed, sip or iex; empty leaves it to Alpaca's plan default (default $ALPACA_FEED)")
+	flag.StringVar(&cfg.ProviderName, "provider", marketdata.FromEnv(), "Market data provider for bars, quotes and the clock: alpaca or polygon (default $LFT2_DATA_PROVIDER or alpaca)")
+	flag.StringVar(&cfg.FallbackName, "fallback", marketdata.FallbackFromEnv(), "Provider asked for a symbol's bars when -provider returns none, e.g. yahoo; empty for none (default $LFT2_FALLBACK_PROVIDER)")
+	flag.StringVar(&cfg.AssetsFile, "assets", assetsFile, "Asset metadata output file (empty to skip)")
+	flag.StringVar(&cfg.FailuresFile, "failures", root+"fetch-failures.json", "Where to record symbols that couldn't be fetched (empty to skip)")
+	flag.StringVar(&cfg.ManifestFile, "manifest", root+"bars-manifest.json", "Checksum manifest of the bar files, verified by filter and backtest (empty to skip)")
+	flag.StringVar(&cfg.SpreadsFile, "spreads", spreadsFile, "Rolling quoted spread statistics, sampled during the regular session (empty to skip)")
+	flag.StringVar(&cfg.QuotesFile, "quotes", quotesFile, "Latest bid, ask and spread per symbol, written with the spread samples (empty to skip)")
+	flag.BoolVar(&cfg.Incremental, "incremental", false, "Fetch only bars after the last one in each saved bar file and merge them in")
+	flag.StringVar(&cfg.Fundamentals, "fundamentals", os.Getenv("LFT2_FUNDAMENTALS"), "Market cap provider: file:PATH or fmp (default $LFT2_FUNDAMENTALS)")
+	rate, err := rateFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	flag.IntVar(&cfg.Rate, "rate", rate, "Alpaca data requests a minute, shared by every symbol (default $LFT2_DATA_RATE or 200)")
+	flag.IntVar(&cfg.Concurrency, "concurrency", defaultConcurrency, "Symbols fetched at once; -rate paces their requests")
+	flag.StringVar(&cfg.ActionsFile, "corporate-actions", corporate.DefaultPath, "Corporate actions from cmd/corporate-actions, whose splits adjust the bar history (empty to skip)")
+	flag.StringVar(&cfg.UsageFile, "usage", quota.DefaultPath, "API usage tally and budget forecast (empty to skip)")
+	if cfg.Budget, err = quota.PolicyFromEnv(); err != nil {
+		log.Fatal(err)
+	}
+	compress, err := gzipFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	flag.BoolVar(&cfg.Gzip, "gzip", compress, "Write bar files gzipped, as {SYMBOL}.json.gz (default $LFT2_BARS_GZIP)")
 	flag.Parse()
+	if cfg.Rate < 1 {
+		log.Fatalf("-rate must be positive, got %d", cfg.Rate)
+	}
+	if cfg.Concurrency < 1 {
+		log.Fatalf("-concurrency must be positive, got %d", cfg.Concurrency)
+	}
+	if err := marketdata.CheckFeed(cfg.Feed); err != nil {
+		log.Fatalf("-feed: %v", err)
+	}
+	if cfg.HTTP, err = httpconf.ForStage("fetch"); err != nil {
+		log.Fatal(err)
+	}
+	cfg.Limiter = newTokenBucket(ctx, cfg.Rate, cfg.HTTP)
 
-	cfg.APIKey = os.Getenv("ALPACA_API_KEY")
-	cfg.APISecret = os.Getenv("ALPACA_API_SECRET")
+	cfg.BaseURL = os.Getenv("ALPACA_BASE_URL")
 	cfg.DataURL = os.Getenv("ALPACA_DATA_URL")
 
 	if cfg.DataURL == "" {
 		cfg.DataURL = "https://data.alpaca.markets"
 	}
+	if u, err := url.Parse(cfg.DataURL); err == nil {
+		cfg.Limiter.counted = u.Host
+	}
 
-	if cfg.APIKey == "" || cfg.APISecret == "" {
-		log.Fatal("ALPACA_API_KEY and ALPACA_API_SECRET environment variables required")
+	// Alpaca's trading API still serves the calendar and asset metadata
+	if cfg.Auth, err = alpaca.AuthFromEnv(); err != nil {
+		log.Fatal(err)
+	}
+
+	client := cfg.alpacaClient().WithContext(ctx)
+	feed := cfg.Feed
+	if cfg.Provider, err = marketdata.Open(cfg.ProviderName, client, feed, cfg.Limiter.do); err != nil {
+		log.Fatalf("-provider: %v", err)
+	}
+	cfg.Feed = cfg.Provider.Feed()
+	if cfg.FallbackName != "" {
+		if cfg.Fallback, err = marketdata.Open(cfg.FallbackName, client, feed, cfg.Limiter.do); err != nil {
+			log.Fatalf("-fallback: %v", err)
+		}
 	}
 
 	return cfg
@@ -82,149 +220,470 @@ func loadWatchlist(path string) (*Watchlist, error) {
 		return nil, fmt.Errorf("reading watchlist: %w", err)
 	}
 
+	return parseWatchlist(data)
+}
+
+// loadLiveWatchlist downloads a book's candidates.json from the artifact
+// base — its "symbols" array has the same shape as a watchlist.
+func loadLiveWatchlist(base, bookDir string) (*Watchlist, error) {
+	data, err := artifact.Fetch(base, bookDir+"candidates.json")
+	if err != nil {
+		return nil, fmt.Errorf("downloading candidates: %w", err)
+	}
+
+	return parseWatchlist(data)
+}
+
+func parseWatchlist(data []byte) (*Watchlist, error) {
 	var watchlist Watchlist
 	if err := json.Unmarshal(data, &watchlist); err != nil {
 		return nil, fmt.Errorf("parsing watchlist: %w", err)
 	}
+	watchlist.normalise()
 
 	return &watchlist, nil
 }
 
-func fetchBars(cfg Config, symbol string) (*SymbolData, error) {
-	// start=6 weeks ago + sort=desc + limit gives the most recent N bars.
-	// Without a start bound the API only returns today's bars (~120 max).
-	// feed=iex is intentionally omitted — IEX only retains today's bars;
-	// the default SIP feed provides weeks of history needed for backtesting.
-	// Bars are reversed to ascending (oldest first) order before saving.
-	start := time.Now().UTC().AddDate(0, 0, -42).Format(time.RFC3339)
-	url := fmt.Sprintf("%s/v2/stocks/%s/bars?timeframe=%dMin&limit=%d&sort=desc&start=%s",
-		cfg.DataURL,
-		symbol,
-		cfg.TimeframeMin,
-		cfg.BarsPerSymbol,
-		start,
-	)
-
-	req, err := NewAlpacaRequest("GET", url, cfg.APIKey, cfg.APISecret)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-
-	body, err := ExecuteRequest(req)
+// fetchBars asks p for the most recent BarsPerSymbol bars from start, oldest
+// first.
+func fetchBars(cfg Config, p marketdata.Provider, symbol, start string) (*barfile.Data, error) {
+	got, err := p.GetBars(symbol, marketdata.BarsRequest{
+		TimeframeMin: cfg.TimeframeMin,
+		Limit:        cfg.BarsPerSymbol,
+		Start:        start,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("executing request: %w", err)
 	}
-
-	var response AlpacaBarsResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("parsing response: %w", err)
-	}
-
-	if len(response.Bars) == 0 {
-		return nil, fmt.Errorf("no bars returned")
+	if len(got) == 0 {
+		return nil, errNoBars
 	}
 
-	bars := response.Bars
-	if len(bars) > cfg.BarsPerSymbol {
-		bars = bars[:cfg.BarsPerSymbol]
-	}
-	// Reverse from desc back to chronological (oldest first)
-	for i, j := 0, len(bars)-1; i < j; i, j = i+1, j-1 {
-		bars[i], bars[j] = bars[j], bars[i]
+	bars := make([]barfile.Bar, len(got))
+	for i, b := range got {
+		bars[i] = barfile.Bar(b)
 	}
 
-	return &SymbolData{
+	return &barfile.Data{
+		Header:    schema.Current(),
 		Symbol:    symbol,
 		Bars:      bars,
 		Count:     len(bars),
+		Feed:      p.Feed(),
 		FetchedAt: time.Now().UTC().Format(time.RFC3339),
+		Source:    p.Name(),
 	}, nil
 }
 
-func saveJSON(data *SymbolData, outputDir string) error {
-	filename := filepath.Join(outputDir, fmt.Sprintf("%s.json", data.Symbol))
+// saveJSON writes a symbol's bar file, gzipped as {SYMBOL}.json.gz when
+// compress is set, and removes the file in the other form. The bars are put
+// in canonical order first, whichever path they came by.
+func saveJSON(data *barfile.Data, outputDir string, compress bool) error {
+	var dropped int
+	data.Bars, dropped = barfile.Canonical(data.Bars)
+	data.Count = len(data.Bars)
+	if dropped > 0 {
+		log.Printf("  %s: dropped %d duplicate bar(s)", data.Symbol, dropped)
+	}
+	buf, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding JSON: %w", err)
+	}
+	_, err = barfile.Write(outputDir, data.Symbol, append(buf, '\n'), compress)
+	return err
+}
+
+// alpacaClient returns a client for cfg's credentials, endpoints and HTTP
+// policy.
+func (cfg Config) alpacaClient() alpaca.Client {
+	c := alpaca.New("", "", cfg.BaseURL, cfg.DataURL)
+	c.Auth = cfg.Auth
+	c.HTTP = cfg.HTTP
+	return c
+}
 
-	file, err := os.Create(filename)
+// saveAssets classifies each watchlist symbol from Alpaca's asset metadata
+// so filter can exclude leveraged/inverse ETFs and ADRs by class.
+func saveAssets(cfg Config, symbols []string) (int, error) {
+	client := cfg.alpacaClient()
+	all, err := client.Assets()
 	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
+		return 0, fmt.Errorf("fetching assets: %w", err)
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
-		return fmt.Errorf("encoding JSON: %w", err)
+	bySymbol := make(map[string]alpaca.Asset, len(all))
+	for _, a := range all {
+		bySymbol[a.Symbol] = a
 	}
 
-	return nil
+	out := assets.File{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Assets:    make(map[string]assets.Info, len(symbols)),
+	}
+	for _, symbol := range symbols {
+		a, ok := bySymbol[symbol]
+		if !ok {
+			continue
+		}
+		out.Assets[symbol] = assets.Info{
+			Symbol:   symbol,
+			Name:     a.Name,
+			Exchange: a.Exchange,
+			Class:    assets.Classify(a.Name),
+		}
+	}
+
+	// Market cap enrichment is optional — a provider outage shouldn't stop
+	// classification being written
+	provider, err := assets.OpenFundamentals(cfg.Fundamentals)
+	if err != nil {
+		log.Printf("⚠ fundamentals provider: %v", err)
+	} else if provider != nil {
+		caps, err := provider.MarketCaps(symbols)
+		if err != nil {
+			log.Printf("⚠ market caps not all updated: %v", err)
+		}
+		for symbol, marketCap := range caps {
+			if info, ok := out.Assets[symbol]; ok {
+				info.MarketCap = marketCap
+				out.Assets[symbol] = info
+			}
+		}
+	}
+
+	return len(out.Assets), assets.Save(cfg.AssetsFile, out)
 }
 
-func processSymbol(cfg Config, symbol string, resultChan chan<- FetchResult, wg *sync.WaitGroup) {
-	defer wg.Done()
+// fetchSymbol fetches and saves one symbol's bars. With -incremental it
+// requests only what's newer than the saved file and merges that in.
+func fetchSymbol(cfg Config, symbol string, req Requirement) FetchResult {
+	cfg.BarsPerSymbol = barsFor(cfg, req)
+
+	start := historyStart(cfg, time.Now())
+	var saved *barfile.Data
+	if cfg.Incremental {
+		var err error
+		if saved, err = cfg.savedBars(symbol); err != nil {
+			log.Printf("⚠ %s: %v, fetching in full", symbol, err)
+		}
+		if saved != nil && marketdata.EffectiveFeed(saved.Feed) != cfg.Feed {
+			// Bars from two feeds don't belong in one history
+			log.Printf("  %s: saved bars are from feed %q, fetching %s in full", symbol, saved.Feed, cfg.Feed)
+			saved = nil
+		}
+		if saved != nil && saved.Source != "" && saved.Source != cfg.Provider.Name() {
+			// Nor do two providers' bars; files from before sources were
+			// recorded came from the provider in use
+			log.Printf("  %s: saved bars are from %s, fetching from %s in full", symbol, saved.Source, cfg.Provider.Name())
+			saved = nil
+		}
+		if from := resumeFrom(saved, cfg.BarsPerSymbol); from != "" {
+			start = from
+		} else {
+			saved = nil
+		}
+	}
 
-	data, err := fetchBars(cfg, symbol)
+	data, err := fetchBars(cfg, cfg.Provider, symbol, start)
+	if errors.Is(err, errNoBars) && saved == nil && cfg.Fallback != nil {
+		// Thinly traded symbols can go a whole history without a print on
+		// IEX; another provider may have the consolidated tape's
+		fallback, ferr := fetchBars(cfg, cfg.Fallback, symbol, start)
+		switch {
+		case ferr == nil:
+			log.Printf("  %s: no bars from %s, %d from %s", symbol, cfg.Provider.Name(), fallback.Count, cfg.Fallback.Name())
+			data, err = fallback, nil
+		case !errors.Is(ferr, errNoBars):
+			log.Printf("⚠ %s: %s fallback: %v", symbol, cfg.Fallback.Name(), ferr)
+		}
+	}
+	added := 0
+	if saved != nil {
+		switch {
+		case errors.Is(err, errNoBars):
+			// Nothing since the last run, with the market closed; the saved
+			// bars are still the latest
+			data, err = saved, nil
+			data.Header = schema.Current()
+			data.FetchedAt = time.Now().UTC().Format(time.RFC3339)
+		case err == nil:
+			// Only the most recent BarsPerSymbol are kept
+			data.Bars, added = barfile.Merge(saved.Bars, data.Bars)
+			if len(data.Bars) > cfg.BarsPerSymbol {
+				data.Bars = data.Bars[len(data.Bars)-cfg.BarsPerSymbol:]
+			}
+			data.Count = len(data.Bars)
+			data.Splits = saved.Splits
+		}
+	} else if err == nil {
+		added = data.Count
+	}
 	if err != nil {
-		resultChan <- FetchResult{Symbol: symbol, Error: err}
-		return
+		return FetchResult{Symbol: symbol, Error: err}
+	}
+	if cfg.Actions != nil {
+		if n := adjustSplits(data, cfg.Actions.Splits(symbol, time.Now())); n > 0 {
+			log.Printf("  %s: adjusted for %d split(s)", symbol, n)
+		}
 	}
 
-	if err := saveJSON(data, cfg.OutputDir); err != nil {
-		resultChan <- FetchResult{Symbol: symbol, Error: fmt.Errorf("saving JSON: %w", err)}
-		return
+	if err := saveJSON(data, cfg.OutputDir, cfg.Gzip); err != nil {
+		return FetchResult{Symbol: symbol, Error: fmt.Errorf("saving JSON: %w", err)}
+	}
+	if cfg.Store != nil {
+		if err := cfg.Store.PutBars(cfg.BookName, data); err != nil {
+			return FetchResult{Symbol: symbol, Error: err}
+		}
 	}
 
-	resultChan <- FetchResult{Symbol: symbol, Count: data.Count}
+	// Saved regardless, but flagged so the shortfall is visible here rather
+	// than as a missing signal in entries
+	return FetchResult{Symbol: symbol, Count: data.Count, Added: added, Error: checkWarmup(data.Count, req)}
 }
 
+// Fetch refreshes docs/bars for the watchlist, or with -live the best
+// ranked candidates with a viable recommendation, from LFT2_DATA_PROVIDER
+// on -concurrency workers sharing one LFT2_DATA_RATE token bucket. Requests
+// reach back to the session open that covers the bars wanted, by Alpaca's
+// calendar. Saved files are merged in canonical order, split-adjusted from
+// corporate_actions.json, and refetched in full when their feed or source
+// no longer matches. Symbols that still fail after a retry at the end go in
+// fetch-failures.json for filter to reject. In session it also samples
+// quoted spreads, and it tallies the cycle's API usage against the day's
+// budget and writes bars-manifest.json once every file is saved.
 func main() {
-	cfg := loadConfig()
+	version.Handle("fetch")
+	tz.SetLog()
+
+	// Ctrl-C stops new symbols; those under way finish and are saved
+	ctx := interrupt.Context()
+	cfg := loadConfig(ctx)
+	defer crash.Guard("fetch", cfg.WatchlistFile)
+	if cfg.Store != nil {
+		defer cfg.Store.Close()
+		log.Printf("Storing bars in %s", os.Getenv(store.Env))
+	}
 
-	log.Printf("Loading watchlist from %s", cfg.WatchlistFile)
-	watchlist, err := loadWatchlist(cfg.WatchlistFile)
+	var watchlist *Watchlist
+	var err error
+	if cfg.Live {
+		log.Printf("Loading live watchlist from %s/candidates.json", cfg.PagesBase)
+		watchlist, err = loadLiveWatchlist(cfg.PagesBase, cfg.BookDir)
+	} else {
+		log.Printf("Loading watchlist from %s", cfg.WatchlistFile)
+		watchlist, err = loadWatchlist(cfg.WatchlistFile)
+	}
 	if err != nil {
 		log.Fatalf("Failed to load watchlist: %v", err)
 	}
+	for _, d := range watchlist.Dropped {
+		log.Printf("  [skip] watchlist entry %s", d)
+	}
 
 	if len(watchlist.Symbols) == 0 {
 		log.Fatal("No symbols in watchlist")
 	}
 
-	log.Printf("Creating output directory: %s", cfg.OutputDir)
-	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
-		log.Fatalf("Failed to create output directory: %v", err)
+	// Live mode fetches only a few bars; raise that per symbol to whatever
+	// its strategies need to warm up
+	reqs := map[string]Requirement{}
+	if cfg.Live {
+		cfg.BarsPerSymbol = cfg.LiveBars
+		data, err := artifact.Fetch(cfg.PagesBase, cfg.BookDir+"strategies.json")
+		if err == nil {
+			reqs, err = parseRequirements(data)
+		}
+		if err != nil {
+			log.Printf("⚠ no warm-up requirements, using -live-bars %d for every symbol: %v", cfg.BarsPerSymbol, err)
+			reqs = map[string]Requirement{}
+		} else if symbol, req := longest(reqs); req.Bars > cfg.BarsPerSymbol {
+			log.Printf("Warm-up raises -live-bars %d for some symbols, to at most %d (%s %s)",
+				cfg.BarsPerSymbol, req.Bars, symbol, req.Strategy)
+		}
+
+		if cfg.MaxSymbols > 0 {
+			kept, dropped := capSymbols(watchlist.Symbols, reqs, cfg.MaxSymbols)
+			if len(dropped) > 0 {
+				log.Printf("  [skip] %d symbol(s) beyond -max-symbols %d", len(dropped), cfg.MaxSymbols)
+			}
+			watchlist.Symbols = kept
+		}
 	}
 
-	log.Printf("Fetching %d bars for %d symbols (timeframe: %dMin)",
-		cfg.BarsPerSymbol, len(watchlist.Symbols), cfg.TimeframeMin)
-	log.Println()
+	if cfg.ActionsFile != "" {
+		if cfg.Actions, err = corporate.Load(cfg.ActionsFile); err != nil {
+			log.Printf("⚠ bars not adjusted for splits: %v", err)
+		} else if n := len(cfg.Actions.Actions); n > 0 {
+			log.Printf("Corporate actions: %d from %s", n, cfg.ActionsFile)
+		}
+	}
 
-	var wg sync.WaitGroup
-	resultChan := make(chan FetchResult, len(watchlist.Symbols))
+	symbols, forecast := fitBudget(cfg, watchlist.Symbols, reqs)
+	watchlist.Symbols = symbols
+
+	// How far back each symbol's history starts comes from the trading
+	// calendar, so holidays don't leave a long fetch short
+	most := cfg.BarsPerSymbol
+	if _, req := longest(reqs); req.Bars > most {
+		most = req.Bars
+	}
+	client := cfg.alpacaClient().WithContext(ctx)
+	now := time.Now()
+	cfg.Sessions, err = client.Calendar(
+		now.AddDate(0, 0, -calendarSpan(most, cfg.TimeframeMin)).Format(time.DateOnly),
+		now.Format(time.DateOnly))
+	if err != nil {
+		log.Printf("⚠ no trading calendar, fetching from %d days back: %v", fallbackDays, err)
+	}
 
-	for _, symbol := range watchlist.Symbols {
-		wg.Add(1)
-		go processSymbol(cfg, symbol, resultChan, &wg)
+	log.Printf("Creating output directory: %s", cfg.OutputDir)
+	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
 	}
 
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+	log.Printf("Fetching %d bars for %d symbols (timeframe: %dMin, provider: %s, feed: %s, %d at a time, at most %d requests a minute)",
+		cfg.BarsPerSymbol, len(watchlist.Symbols), cfg.TimeframeMin, cfg.Provider.Name(), cfg.Feed, cfg.Concurrency, cfg.Rate)
+	if cfg.Incremental {
+		log.Printf("Incremental: only bars after each saved file's last one")
+	}
+	log.Println()
 
 	successCount := 0
 	failCount := 0
+	prog := newProgress(len(watchlist.Symbols))
+	var failed []FetchResult
 
-	for result := range resultChan {
+	results := fetchAll(ctx, watchlist.Symbols, cfg.Concurrency, func(symbol string) FetchResult {
+		return fetchSymbol(cfg, symbol, reqs[symbol])
+	}, func(result FetchResult) {
 		if result.Error != nil {
 			log.Printf("✗ %s: %v", result.Symbol, result.Error)
-			failCount++
+		} else if cfg.Incremental {
+			log.Printf("✓ %s: %d bars (%d new)", result.Symbol, result.Count, result.Added)
 		} else {
 			log.Printf("✓ %s: %d bars", result.Symbol, result.Count)
+		}
+		if line, due := prog.record(result.Error); due {
+			log.Print(line)
+		}
+	})
+	for _, result := range results {
+		if result.Error != nil {
+			failCount++
+			failed = append(failed, result)
+		} else {
 			successCount++
 		}
 	}
 
+	prog.logSummary()
+
+	// One more sequential attempt for anything that failed transiently, once
+	// the concurrent burst is over
+	if len(failed) > 0 && ctx.Err() == nil {
+		log.Println()
+		log.Printf("Retrying failed symbols")
+	}
+	recovered, failures := retryFailed(ctx, failed, func(symbol string) FetchResult {
+		return fetchSymbol(cfg, symbol, reqs[symbol])
+	}, time.Sleep)
+	successCount += recovered
+	failCount -= recovered
+
+	if cfg.FailuresFile != "" {
+		if err := saveFailures(cfg.FailuresFile, failures, time.Now()); err != nil {
+			log.Printf("⚠ %s not written: %v", cfg.FailuresFile, err)
+		} else if len(failures) > 0 {
+			log.Printf("✗ %d symbols without fresh bars → %s", len(failures), cfg.FailuresFile)
+		}
+	}
+
+	// Written last, once every bar file is final, so it describes exactly the
+	// set that will be published
+	if cfg.ManifestFile != "" {
+		if m, err := manifest.Build(cfg.OutputDir, time.Now()); err != nil {
+			log.Printf("⚠ %s not written: %v", cfg.ManifestFile, err)
+		} else if err := manifest.Save(cfg.ManifestFile, m); err != nil {
+			log.Printf("⚠ %s not written: %v", cfg.ManifestFile, err)
+		} else {
+			log.Printf("✓ %d bar files → %s", len(m.Symbols), cfg.ManifestFile)
+		}
+	}
+
+	// An interrupted run stops here: the failures report names the symbols
+	// left unfetched, and the manifest matches the files on disk
+	if ctx.Err() != nil {
+		recordUsage(cfg, &forecast)
+		log.Println()
+		log.Printf("Interrupted! Success: %d, Failed: %d", successCount, failCount)
+		interrupt.Exit("fetch")
+	}
+
+	// With LFT2_EVENT_BUS set the refreshed bar files also go to the bus,
+	// for workers on other hosts
+	if bus, err := events.FromEnv(); err != nil {
+		log.Printf("⚠ bars not published: %v", err)
+	} else if bus != nil {
+		n, err := publishBars(bus, cfg.OutputDir, watchlist.Symbols, failures, time.Now())
+		if err != nil {
+			log.Printf("⚠ bars not published: %v", err)
+		}
+		log.Printf("✓ published %d bar files to the event bus", n)
+		bus.Close()
+	}
+
+	if cfg.AssetsFile != "" {
+		log.Println()
+		if n, err := saveAssets(cfg, watchlist.Symbols); err != nil {
+			log.Printf("⚠ asset metadata not updated: %v", err)
+		} else {
+			log.Printf("✓ classified %d assets → %s", n, cfg.AssetsFile)
+		}
+	}
+
+	// One NBBO sample per symbol per cycle; filter screens on the rolling
+	// median once a symbol has enough, and on the latest quote until then
+	if cfg.SpreadsFile != "" {
+		log.Println()
+		now := time.Now()
+		if open, reason := marketOpen(cfg.Provider, now); !open {
+			log.Printf("  [skip] spreads: %s", reason)
+		} else if f, err := loadSpreads(cfg.SpreadsFile, cfg.PagesBase); err != nil {
+			log.Printf("⚠ spreads not sampled: %v", err)
+		} else {
+			latest := &spreads.Quotes{Symbols: map[string]spreads.Quote{}}
+			n, err := sampleQuotes(f, latest, watchlist.Symbols, cfg.Provider.GetQuotes, now)
+			if err != nil {
+				log.Printf("⚠ spreads: %v", err)
+			}
+			if err := spreads.Save(cfg.SpreadsFile, f); err != nil {
+				log.Printf("⚠ %s not written: %v", cfg.SpreadsFile, err)
+			} else {
+				log.Printf("✓ sampled %d spreads → %s (%d symbols reliable)", n, cfg.SpreadsFile, len(f.Medians()))
+			}
+			if cfg.QuotesFile != "" {
+				if err := spreads.SaveQuotes(cfg.QuotesFile, latest); err != nil {
+					log.Printf("⚠ %s not written: %v", cfg.QuotesFile, err)
+				} else {
+					log.Printf("✓ latest quotes for %d symbols → %s", len(latest.Symbols), cfg.QuotesFile)
+				}
+			}
+		}
+	}
+
+	recordUsage(cfg, &forecast)
+
 	log.Println()
 	log.Printf("Done! Success: %d, Failed: %d", successCount, failCount)
 	log.Printf("Files saved to %s/", cfg.OutputDir)
 }
+
+// recordUsage adds this run's API calls to the usage tally.
+func recordUsage(cfg Config, forecast *quota.Forecast) {
+	if cfg.UsageFile == "" {
+		return
+	}
+	if err := quota.Record(cfg.UsageFile, forecast, time.Now()); err != nil {
+		log.Printf("⚠ %s not written: %v", cfg.UsageFile, err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultConcurrency is how many symbols fetch works on at once. The token
+// bucket paces their requests whatever the count; the cap keeps a large
+// universe from starting a goroutine, a file and a queued request per
+// symbol all at once.
+const defaultConcurrency = 10
+
+// fetchAll runs fetch for every symbol on at most workers goroutines. done
+// is called on the caller's goroutine as each symbol completes, for logging
+// progress, and the results come back in the order of symbols, so what
+// follows doesn't depend on which request happened to finish first. Once
+// ctx is cancelled no further symbol is started; those already under way
+// finish, and the rest come back with ctx's error.
+func fetchAll(ctx context.Context, symbols []string, workers int, fetch func(symbol string) FetchResult, done func(FetchResult)) []FetchResult {
+	workers = max(1, min(workers, len(symbols)))
+
+	type indexed struct {
+		i      int
+		result FetchResult
+	}
+	jobs := make(chan int)
+	out := make(chan indexed)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				out <- indexed{i, fetch(symbols[i])}
+			}
+		}()
+	}
+	go func() {
+	feed:
+		for i := range symbols {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(out)
+	}()
+
+	results := make([]FetchResult, len(symbols))
+	started := make([]bool, len(symbols))
+	for r := range out {
+		results[r.i], started[r.i] = r.result, true
+		done(r.result)
+	}
+	for i, ok := range started {
+		if !ok {
+			results[i] = FetchResult{Symbol: symbols[i], Error: ctx.Err()}
+		}
+	}
+	return results
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"time"
+
+	"encoding/json"
+
+	"io/fs"
+)
+
+var (
+	errNoBars       = errors.New("no bars returned")
+	errShortHistory = errors.New("insufficient history")
+)
+
+// progressInterval is how often a long run reports progress, in addition to
+// every tenth of the watchlist.
+const progressInterval = 15 * time.Second
+
+// progress tracks a fetch run so a 1000-symbol watchlist shows how far it
+// has got and how long is left, and tallies failures by cause.
+type progress struct {
+	total    int
+	done     int
+	failed   int
+	start    time.Time
+	reported time.Time
+	causes   map[string]int
+	now      func() time.Time
+}
+
+func newProgress(total int) *progress {
+	p := &progress{total: total, causes: map[string]int{}, now: time.Now}
+	p.start = p.now()
+	p.reported = p.start
+	return p
+}
+
+// record counts one symbol's result and returns a progress line when one is
+// due: at each tenth of the total, after progressInterval, and at the end.
+func (p *progress) record(err error) (string, bool) {
+	p.done++
+	if err != nil {
+		p.failed++
+		p.causes[category(err)]++
+	}
+
+	now := p.now()
+	step := max(1, p.total/10)
+	if p.done%step != 0 && p.done != p.total && now.Sub(p.reported) < progressInterval {
+		return "", false
+	}
+	p.reported = now
+	return p.line(now), true
+}
+
+// line formats done/total, rate, ETA and the error count.
+func (p *progress) line(now time.Time) string {
+	elapsed := now.Sub(p.start)
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(p.done) / elapsed.Seconds()
+	}
+	eta := "—"
+	if rate > 0 {
+		left := time.Duration(float64(p.total-p.done) / rate * float64(time.Second))
+		eta = left.Round(time.Second).String()
+	}
+	return fmt.Sprintf("Progress: %d/%d (%.0f%%) · %.1f symbols/s · ETA %s · %d errors",
+		p.done, p.total, 100*float64(p.done)/float64(max(1, p.total)), rate, eta, p.failed)
+}
+
+// summary lists failure causes, most frequent first.
+func (p *progress) summary() []string {
+	names := make([]string, 0, len(p.causes))
+	for name := range p.causes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if p.causes[names[i]] != p.causes[names[j]] {
+			return p.causes[names[i]] > p.causes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%-18s %d", name, p.causes[name]))
+	}
+	return lines
+}
+
+// category names the cause of a fetch failure. Rate limiting and server
+// errors are worth retrying; bad symbols and missing data are not.
+func category(err error) string {
+	var status *statusError
+	var netErr net.Error
+	var pathErr *fs.PathError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "interrupted"
+	case errors.As(err, &status):
+		switch {
+		case status.Code == 429:
+			return "rate_limited"
+		case status.Code == 401 || status.Code == 403:
+			return "auth"
+		case status.Code == 400 || status.Code == 404 || status.Code == 422:
+			return "bad_symbol"
+		case status.Code >= 500:
+			return "server"
+		}
+		return fmt.Sprintf("http_%d", status.Code)
+	case errors.Is(err, errNoBars):
+		return "no_data"
+	case errors.Is(err, errShortHistory):
+		return "short_history"
+	case errors.As(err, &netErr):
+		return "network"
+	case errors.As(err, &syntaxErr):
+		return "bad_response"
+	case errors.As(err, &pathErr):
+		return "save"
+	}
+	return "other"
+}
+
+// logSummary prints the failure breakdown, if there were failures.
+func (p *progress) logSummary() {
+	if p.failed == 0 {
+		return
+	}
+	log.Println()
+	log.Printf("Failures by cause:")
+	for _, line := range p.summary() {
+		log.Printf("  %s", line)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/corporate"
+)
+
+// adjustSplits puts the bars from before each split's ex date on the
+// post-split scale: prices times the split's price factor, volumes divided
+// by it. Alpaca's bars are raw, so without this a saved history that spans
+// a 4-for-1 split shows a 75% overnight drop. Each split applied is recorded
+// in data.Splits, so an incremental run that merges into the file doesn't
+// apply it twice. It returns how many were applied.
+func adjustSplits(data *barfile.Data, splits []corporate.Action) int {
+	applied := 0
+	for _, split := range splits {
+		factor, ok := split.PriceFactor()
+		if !ok || slices.Contains(data.Splits, split.ID) {
+			continue
+		}
+		for i := range data.Bars {
+			t, err := time.Parse(time.RFC3339, data.Bars[i].Timestamp)
+			if err != nil || !split.Before(t) {
+				continue
+			}
+			b := &data.Bars[i]
+			b.Open *= factor
+			b.High *= factor
+			b.Low *= factor
+			b.Close *= factor
+			b.Volume = int64(math.Round(float64(b.Volume) / factor))
+		}
+		data.Splits = append(data.Splits, split.ID)
+		applied++
+	}
+	return applied
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/artifact"
+	"github.com/deanturpin/lft2/internal/marketdata"
+	"github.com/deanturpin/lft2/internal/spreads"
+)
+
+// loadSpreads reads the spread statistics collected so far, falling back to
+// the published copy so a fresh CI checkout carries on the rolling window
+// rather than starting again.
+func loadSpreads(path, base string) (*spreads.File, error) {
+	if _, err := os.Stat(path); err == nil || base == "" {
+		return spreads.Load(path)
+	}
+	name := filepath.Base(path)
+	data, err := artifact.Fetch(base, name)
+	if err != nil {
+		return spreads.Load(path)
+	}
+	return spreads.Parse(name, data)
+}
+
+// marketOpen reports whether quotes are worth sampling at now: inside the
+// regular session, and on a day the provider's clock says the market is
+// open, which catches holidays. A clock that can't be read is logged and
+// the session hours decide alone.
+func marketOpen(p marketdata.Provider, now time.Time) (bool, string) {
+	if !spreads.InSession(now) {
+		return false, "outside the regular session"
+	}
+	clock, err := p.GetClock()
+	if err != nil {
+		log.Printf("⚠ %s clock: %v", p.Name(), err)
+		return true, ""
+	}
+	if !clock.Open {
+		return false, "market closed"
+	}
+	return true, ""
+}
+
+// sampleQuotes takes each symbol's latest NBBO, in batches of as many
+// symbols as one request allows, adding its spread to f and the quote
+// itself to latest. It returns how many were added; quotes that can't be
+// used are logged and left out of both.
+func sampleQuotes(f *spreads.File, latest *spreads.Quotes, symbols []string, quotes func([]string) (map[string]marketdata.Quote, error), now time.Time) (int, error) {
+	added := 0
+	stamp := func() {
+		f.Timestamp = now.UTC().Format(time.RFC3339)
+		latest.Timestamp = f.Timestamp
+	}
+	for start := 0; start < len(symbols); start += marketdata.MaxQuoteSymbols {
+		batch := symbols[start:min(start+marketdata.MaxQuoteSymbols, len(symbols))]
+		got, err := quotes(batch)
+		if err != nil {
+			stamp()
+			return added, fmt.Errorf("latest quotes: %w", err)
+		}
+		for _, symbol := range batch {
+			q, ok := got[symbol]
+			if !ok {
+				log.Printf("  [skip] %s spread: no quote", symbol)
+				continue
+			}
+			quoted, err := time.Parse(time.RFC3339Nano, q.Timestamp)
+			if err != nil {
+				log.Printf("  [skip] %s spread: bad quote time %q", symbol, q.Timestamp)
+				continue
+			}
+			if reason := f.Add(symbol, q.BidPrice, q.AskPrice, quoted, now); reason != "" {
+				log.Printf("  [skip] %s spread: %s", symbol, reason)
+				continue
+			}
+			latest.Add(symbol, q.BidPrice, q.AskPrice, q.BidSize, q.AskSize, quoted, now)
+			added++
+		}
+	}
+	stamp()
+	return added, nil
+}
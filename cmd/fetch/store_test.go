@@ -0,0 +1,55 @@
+//go:build sqlite
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/marketdata"
+	"github.com/deanturpin/lft2/internal/store"
+)
+
+func TestFetchSymbol_Store(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "lft2.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	saved := &barfile.Data{Symbol: "AAPL", Feed: "iex", Source: "alpaca", Bars: []barfile.Bar{
+		{Timestamp: "2026-03-10T15:00:00Z", Close: 1},
+		{Timestamp: "2026-03-10T15:05:00Z", Close: 2},
+	}}
+	if err := db.PutBars("swing", saved); err != nil {
+		t.Fatal(err)
+	}
+
+	// The store's bars are extended though there's no bar file yet
+	cfg := Config{
+		OutputDir:     t.TempDir(),
+		BarsPerSymbol: 2,
+		TimeframeMin:  5,
+		Feed:          "iex",
+		Incremental:   true,
+		BookName:      "swing",
+		Store:         db,
+		Provider: fakeBars{name: "alpaca", feed: "iex", bars: []marketdata.Bar{
+			{Timestamp: "2026-03-10T15:05:00Z", Close: 2.5},
+			{Timestamp: "2026-03-10T15:10:00Z", Close: 3},
+		}},
+	}
+	if r := fetchSymbol(cfg, "AAPL", Requirement{}); r.Error != nil || r.Added != 1 {
+		t.Fatalf("got %+v, want one bar added to the stored two", r)
+	}
+	got, err := db.Bars("swing", "AAPL")
+	if err != nil || got == nil || got.Count != 2 || got.Bars[1].Close != 3 || got.Bars[0].Close != 2.5 {
+		t.Fatalf("stored: got %+v, %v, want the latest two", got, err)
+	}
+	if file, err := loadSaved(cfg.OutputDir, "AAPL"); err != nil || file == nil || file.Count != 2 {
+		t.Errorf("bar file: got %+v, %v, want the stored bars", file, err)
+	}
+	if other, _ := db.Bars("", "AAPL"); other != nil {
+		t.Errorf("default book: got %+v, want none", other)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Requirement is the warm-up history a symbol needs: the largest
+// required_bars among its viable strategies in strategies.json.
+type Requirement struct {
+	Bars     int
+	Strategy string
+}
+
+// parseRequirements reads strategies.json (written by backtest) and returns
+// the warm-up requirement per symbol. Non-viable strategies are ignored as
+// entries never trades them, so the map holds exactly the symbols it may.
+func parseRequirements(data []byte) (map[string]Requirement, error) {
+	var strategies struct {
+		Recommendations []struct {
+			Symbol       string `json:"symbol"`
+			Strategy     string `json:"strategy"`
+			RequiredBars int    `json:"required_bars"`
+			Viable       bool   `json:"viable"`
+		} `json:"recommendations"`
+	}
+	if err := json.Unmarshal(data, &strategies); err != nil {
+		return nil, fmt.Errorf("parsing strategies: %w", err)
+	}
+
+	reqs := make(map[string]Requirement)
+	for _, r := range strategies.Recommendations {
+		if !r.Viable {
+			continue
+		}
+		if cur, ok := reqs[r.Symbol]; !ok || r.RequiredBars > cur.Bars {
+			reqs[r.Symbol] = Requirement{Bars: r.RequiredBars, Strategy: r.Strategy}
+		}
+	}
+	return reqs, nil
+}
+
+// longest returns the symbol with the largest warm-up requirement, the first
+// alphabetically on a tie.
+func longest(reqs map[string]Requirement) (string, Requirement) {
+	var symbol string
+	var req Requirement
+	for s, r := range reqs {
+		if r.Bars > req.Bars || r.Bars == req.Bars && (symbol == "" || s < symbol) {
+			symbol, req = s, r
+		}
+	}
+	return symbol, req
+}
+
+// barsFor returns how many bars to request for a symbol: the configured
+// count, raised to the warm-up requirement when that is larger.
+func barsFor(cfg Config, req Requirement) int {
+	return max(cfg.BarsPerSymbol, req.Bars)
+}
+
+// checkWarmup reports an error when fewer bars came back than the symbol's
+// strategies need — otherwise they would silently never signal.
+func checkWarmup(got int, req Requirement) error {
+	if got < req.Bars {
+		return fmt.Errorf("%w: %d bars, %s needs %d", errShortHistory, got, req.Strategy, req.Bars)
+	}
+	return nil
+}
@@ -1,3 +1,53 @@
 module github.com/deanturpin/lft2/filter
 
 go 1.21
+
+require (
+	github.com/deanturpin/lft2/internal/artifact v0.0.0
+	github.com/deanturpin/lft2/internal/assets v0.0.0
+	github.com/deanturpin/lft2/internal/barfile v0.0.0
+	github.com/deanturpin/lft2/internal/blocklist v0.0.0
+	github.com/deanturpin/lft2/internal/books v0.0.0
+	github.com/deanturpin/lft2/internal/crash v0.0.0
+	github.com/deanturpin/lft2/internal/dashboard v0.0.0
+	github.com/deanturpin/lft2/internal/filter v0.0.0
+	github.com/deanturpin/lft2/internal/interrupt v0.0.0
+	github.com/deanturpin/lft2/internal/manifest v0.0.0
+	github.com/deanturpin/lft2/internal/spreads v0.0.0
+	github.com/deanturpin/lft2/internal/store v0.0.0
+	github.com/deanturpin/lft2/internal/tz v0.0.0
+	github.com/deanturpin/lft2/internal/version v0.0.0
+)
+
+require (
+	github.com/deanturpin/lft2/internal/schema v0.0.0 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/exp v0.0.0-20230315142452-642cacee5cc0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	modernc.org/libc v1.61.13 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.8.2 // indirect
+	modernc.org/sqlite v1.36.0 // indirect
+)
+
+replace (
+	github.com/deanturpin/lft2/internal/artifact => ../../internal/artifact
+	github.com/deanturpin/lft2/internal/assets => ../../internal/assets
+	github.com/deanturpin/lft2/internal/barfile => ../../internal/barfile
+	github.com/deanturpin/lft2/internal/blocklist => ../../internal/blocklist
+	github.com/deanturpin/lft2/internal/books => ../../internal/books
+	github.com/deanturpin/lft2/internal/crash => ../../internal/crash
+	github.com/deanturpin/lft2/internal/dashboard => ../../internal/dashboard
+	github.com/deanturpin/lft2/internal/filter => ../../internal/filter
+	github.com/deanturpin/lft2/internal/interrupt => ../../internal/interrupt
+	github.com/deanturpin/lft2/internal/manifest => ../../internal/manifest
+	github.com/deanturpin/lft2/internal/schema => ../../internal/schema
+	github.com/deanturpin/lft2/internal/spreads => ../../internal/spreads
+	github.com/deanturpin/lft2/internal/store => ../../internal/store
+	github.com/deanturpin/lft2/internal/tz => ../../internal/tz
+	github.com/deanturpin/lft2/internal/version => ../../internal/version
+)
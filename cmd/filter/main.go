@@ -1,261 +1,268 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
-	"math"
 	"os"
-	"path/filepath"
-	"sort"
 	"strings"
 	"time"
-)
-
-type FilterCriteria struct {
-	MinAvgVolume   float64 `json:"min_avg_volume"`
-	MinPrice       float64 `json:"min_price"`
-	MaxPrice       float64 `json:"max_price"`
-	MinBarCount    int     `json:"min_bar_count"`
-	MaxBarRangePct float64 `json:"max_bar_range_pct"` // Max (high-low)/close on last bar — spread proxy
-}
-
-type BarData struct {
-	Symbol    string `json:"symbol"`
-	Bars      []Bar  `json:"bars"`
-	Count     int    `json:"count"`
-	FetchedAt string `json:"fetched_at"`
-}
-
-type Bar struct {
-	Timestamp string  `json:"t"`
-	Open      float64 `json:"o"`
-	High      float64 `json:"h"`
-	Low       float64 `json:"l"`
-	Close     float64 `json:"c"`
-	Volume    int64   `json:"v"`
-}
-
-type SymbolStats struct {
-	Symbol        string  `json:"symbol"`
-	AvgVolume     float64 `json:"avg_volume"`
-	AvgPrice      float64 `json:"avg_price"`
-	AvgVolatility float64 `json:"avg_volatility"`
-	LastRangePct  float64 `json:"last_bar_range_pct"`
-	BarCount      int     `json:"bar_count"`
-	Tradeable     bool    `json:"tradeable"`
-	SkipReason    string  `json:"skip_reason,omitempty"`
-}
-
-type MarketStats struct {
-	VolumeMin    float64 `json:"volume_min"`
-	VolumeMax    float64 `json:"volume_max"`
-	VolumeMedian float64 `json:"volume_median"`
-	PriceMin     float64 `json:"price_min"`
-	PriceMax     float64 `json:"price_max"`
-	PriceMedian  float64 `json:"price_median"`
-	VolMin       float64 `json:"volatility_min"`
-	VolMax       float64 `json:"volatility_max"`
-	VolMedian    float64 `json:"volatility_median"`
-}
-
-type CandidatesOutput struct {
-	Timestamp       string         `json:"timestamp"`
-	FirstBarTime    string         `json:"first_bar_time"`
-	LastBarTime     string         `json:"last_bar_time"`
-	Symbols         []string       `json:"symbols"`
-	Criteria        FilterCriteria `json:"criteria"`
-	MarketStats     MarketStats    `json:"market_stats"`
-	AllSymbols      []SymbolStats  `json:"all_symbols"`
-	TotalCandidates int            `json:"total_candidates"`
-}
-
-func calculateStats(bars []Bar) (avgVolume float64, avgPrice float64, avgVolatility float64) {
-	if len(bars) == 0 {
-		return 0, 0, 0
-	}
 
-	var totalVolume int64
-	var totalPrice float64
-	var totalRange float64
+	"github.com/deanturpin/lft2/internal/assets"
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/blocklist"
+	"github.com/deanturpin/lft2/internal/books"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/dashboard"
+	"github.com/deanturpin/lft2/internal/filter"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/spreads"
+	"github.com/deanturpin/lft2/internal/store"
+	"github.com/deanturpin/lft2/internal/tz"
+	"github.com/deanturpin/lft2/internal/version"
+)
 
-	for _, bar := range bars {
-		totalVolume += bar.Volume
-		totalPrice += bar.Close
-		if bar.Close > 0 {
-			totalRange += (bar.High - bar.Low) / bar.Close
-		}
+// scoreText formats a symbol's score, a dash for rejected symbols.
+func scoreText(s filter.SymbolStats) string {
+	if !s.Tradeable {
+		return "—"
 	}
-
-	count := float64(len(bars))
-	avgVolume = float64(totalVolume) / count
-	avgPrice = totalPrice / count
-	avgVolatility = totalRange / count
-
-	return avgVolume, avgPrice, avgVolatility
+	return fmt.Sprintf("%.3f", s.Score)
 }
 
-// filterReason returns "" if the symbol passes all criteria, or a short
-// explanation of the first failing check.
-func filterReason(data *BarData, criteria FilterCriteria) string {
-	if data.Count < criteria.MinBarCount {
-		return fmt.Sprintf("insufficient bars (%d < %d)", data.Count, criteria.MinBarCount)
-	}
-
-	avgVolume, avgPrice, _ := calculateStats(data.Bars)
-
-	if avgVolume < criteria.MinAvgVolume {
-		return fmt.Sprintf("low volume (%.0f < %.0f)", avgVolume, criteria.MinAvgVolume)
-	}
-	if avgPrice < criteria.MinPrice {
-		return fmt.Sprintf("price too low ($%.2f < $%.2f)", avgPrice, criteria.MinPrice)
+// spreadText formats the quoted spread a symbol was screened on, a dash
+// when there's no quote.
+func spreadText(s filter.SymbolStats) string {
+	if s.SpreadPct == 0 {
+		return "—"
 	}
-	if avgPrice > criteria.MaxPrice {
-		return fmt.Sprintf("price too high ($%.2f > $%.2f)", avgPrice, criteria.MaxPrice)
-	}
-	// Spread proxy: reject if the last bar's range is implausibly wide.
-	// For liquid stocks (high-low)/close is typically <0.4%; wide spreads
-	// or illiquid stocks produce much larger values.
-	if last := data.Bars[len(data.Bars)-1]; last.Close > 0 {
-		lastRangePct := (last.High - last.Low) / last.Close * 100.0
-		if lastRangePct > criteria.MaxBarRangePct {
-			return fmt.Sprintf("spread too wide (%.3f%% > %.2f%%)", lastRangePct, criteria.MaxBarRangePct)
-		}
-	}
-
-	return ""
+	return fmt.Sprintf("%.3f%%", s.SpreadPct)
 }
 
-func median(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
+// candidatesHTML renders candidates.json as a dashboard page.
+func candidatesHTML(output filter.Output) (string, error) {
+	var rows [][]dashboard.Cell
+	for _, s := range output.AllSymbols {
+		status, class := "✓", "good"
+		if !s.Tradeable {
+			status, class = s.SkipReason, "detail"
+		} else if s.Demoted != "" {
+			status, class = "demoted: "+s.Demoted, "warn"
+		}
+		rows = append(rows, []dashboard.Cell{
+			{Text: s.Symbol, Bold: true},
+			{Text: fmt.Sprintf("%.0f", s.AvgVolume)},
+			{Text: fmt.Sprintf("$%.2f", s.AvgPrice)},
+			{Text: fmt.Sprintf("%.3f%%", s.AvgVolatility*100)},
+			{Text: fmt.Sprintf("%.3f%%", s.LastRangePct)},
+			{Text: spreadText(s)},
+			{Text: scoreText(s)},
+			{Text: status, Class: class},
+		})
 	}
 
-	sorted := make([]float64, len(values))
-	copy(sorted, values)
-	sort.Float64s(sorted)
-
-	mid := len(sorted) / 2
-	if len(sorted)%2 == 0 {
-		return (sorted[mid-1] + sorted[mid]) / 2
-	}
-	return sorted[mid]
+	return dashboard.Render(dashboard.Page{
+		Title:    "Candidates",
+		Subtitle: fmt.Sprintf("Bars %s → %s", output.FirstBarTime, output.LastBarTime),
+		Stats: []dashboard.Stat{
+			{Label: "Candidates", Value: fmt.Sprintf("%d", output.TotalCandidates), Class: "good"},
+			{Label: "Scanned", Value: fmt.Sprintf("%d", len(output.AllSymbols))},
+			{Label: "Median Volume", Value: fmt.Sprintf("%.0f", output.MarketStats.VolumeMedian)},
+			{Label: "Median Price", Value: fmt.Sprintf("$%.2f", output.MarketStats.PriceMedian)},
+		},
+		Tables: []dashboard.Table{{
+			Headers: []string{"Symbol", "Volume", "Price", "Vol%", "Rng%", "Sprd%", "Score", "Status"},
+			Rows:    rows,
+			Empty:   "No symbols scanned",
+		}},
+	})
 }
 
-func calculateMarketStats(allStats []SymbolStats) MarketStats {
-	if len(allStats) == 0 {
-		return MarketStats{}
-	}
-
-	volumes := make([]float64, 0, len(allStats))
-	prices := make([]float64, 0, len(allStats))
-	volatilities := make([]float64, 0, len(allStats))
-
-	for _, s := range allStats {
-		volumes = append(volumes, s.AvgVolume)
-		prices = append(prices, s.AvgPrice)
-		volatilities = append(volatilities, s.AvgVolatility)
-	}
-
-	return MarketStats{
-		VolumeMin:    math.Floor(minFloat64(volumes)),
-		VolumeMax:    math.Ceil(maxFloat64(volumes)),
-		VolumeMedian: median(volumes),
-		PriceMin:     minFloat64(prices),
-		PriceMax:     maxFloat64(prices),
-		PriceMedian:  median(prices),
-		VolMin:       minFloat64(volatilities),
-		VolMax:       maxFloat64(volatilities),
-		VolMedian:    median(volatilities),
+// storeCandidates saves candidates.json as the book's latest in the
+// LFT2_STORE database, and says whether there is one.
+func storeCandidates(book string, body []byte, now time.Time) (bool, error) {
+	s, err := store.FromEnv()
+	if err != nil || s == nil {
+		return false, err
 	}
+	defer s.Close()
+	return true, s.Put(book, store.Candidates, body, now)
 }
 
-func minFloat64(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	min := values[0]
-	for _, v := range values[1:] {
-		if v < min {
-			min = v
-		}
-	}
-	return min
-}
+// Filter screens the bars for the day's candidates (internal/filter) and
+// writes candidates.json, ranked best first by a score of liquidity,
+// volatility fit, momentum, data quality and backtest expectancy. A symbol
+// fetch failed on, whose bar file doesn't match the manifest, or that the
+// blocklist or its asset class rules out is rejected; one validate marked
+// bad is demoted below every clean one. It also writes data-quality.html
+// from the bars it read.
+func main() {
+	version.Handle("filter")
 
-func maxFloat64(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	max := values[0]
-	for _, v := range values[1:] {
-		if v > max {
-			max = v
-		}
+	// A book ranks its own bars into its own candidates, under its root
+	_, book, err := books.FromEnv()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
-	return max
-}
+	root := book.Root()
+	defer crash.Guard("filter", root+"bars-manifest.json", root+"fetch-failures.json", root+"data_quality.json", blocklist.DefaultPath)
+
+	tz.SetLog()
+
+	// Ctrl-C during the scan stops before anything is written: a
+	// candidates.json from part of the market would look complete
+	ctx := interrupt.Context()
+
+	excludeClasses := flag.String("exclude-class",
+		strings.Join([]string{assets.LeveragedETF, assets.InverseETF}, ","),
+		"Comma-separated asset classes to exclude (equity, etf, leveraged_etf, inverse_etf, adr)")
+	minMarketCap := flag.Float64("min-market-cap", 300e6, "Minimum market cap in USD for equities (requires fetch -fundamentals)")
+	barsSpec := flag.String("bars", root+"bars", "Bar source: a directory of {SYMBOL}.json files, or an artifact base URL serving bars/")
+	failuresPath := flag.String("failures", root+"fetch-failures.json", "Symbols the last fetch couldn't refresh, rejected by name (ignored for a remote -bars)")
+	qualityPath := flag.String("data-quality", root+"data_quality.json", "Bar checks from validate; symbols marked bad are ranked after the rest")
+	manifestPath := flag.String("manifest", root+"bars-manifest.json", "Checksum manifest the bar files are verified against (ignored for a remote -bars)")
+	spreadsPath := flag.String("spreads", spreads.DefaultPath, "Quoted spread statistics from fetch; symbols with enough samples are screened on them")
+	quotesPath := flag.String("quotes", spreads.QuotesPath, "Latest quotes from fetch; symbols without enough samples are screened on them")
+	only := flag.String("symbols", "", "Comma-separated symbols to scan (default: every symbol in the source)")
+	flag.Parse()
 
-func main() {
 	log.Println("Filter Module - Identifying candidate stocks")
 	log.Println("")
 
-	barsDir := "docs/bars"
-
-	if _, err := os.Stat(barsDir); os.IsNotExist(err) {
-		log.Fatalf("Error: bars directory not found: %s", barsDir)
+	source, err := openSource(*barsSpec)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
 
-	// First pass: load all data and calculate statistics
-	entries, err := os.ReadDir(barsDir)
+	symbols, err := source.Symbols()
 	if err != nil {
-		log.Fatalf("Error reading directory: %v", err)
+		log.Fatalf("Error listing symbols in %s: %v", source, err)
 	}
+	symbols, missing := restrict(symbols, *only)
+	for _, sym := range missing {
+		log.Printf("✗ %s: not in %s", sym, source)
+	}
+	log.Printf("Scanning %d symbols from %s", len(symbols), source)
 
-	var allStats []SymbolStats
-	allBarData := map[string]*BarData{}
-	totalFiles := 0
+	// A bar file that doesn't match the manifest fetch wrote, or one the
+	// manifest lists that isn't there, means a partial or corrupted set
+	m, err := loadManifest(source, *manifestPath)
+	if err != nil {
+		log.Fatalf("Error loading bars manifest: %v", err)
+	}
+	if m == nil {
+		log.Printf("Warning: %s missing — bar files not verified", *manifestPath)
+	}
+	integrity := map[string]string{}
+	for _, symbol := range unlisted(source, m, symbols, *only) {
+		log.Printf("✗ %s: in manifest but not in %s", symbol, source)
+		integrity[symbol] = "in manifest but missing"
+	}
 
-	log.Println("Calculating market statistics...")
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+	// Digest each file as it's read and keep only the digest, so a full-market
+	// scan holds one file's bytes at a time rather than every symbol's bars
+	var digests []filter.Digest
+	for _, symbol := range symbols {
+		interrupt.Stop(ctx, "filter")
+		data, err := source.Load(symbol)
+		if err != nil {
+			log.Printf("✗ %s: could not read bars: %v", symbol, err)
 			continue
 		}
 
-		totalFiles++
-		filePath := filepath.Join(barsDir, entry.Name())
+		if m != nil {
+			if problem := m.Verify(symbol, data); problem != "" {
+				log.Printf("✗ %s: %s", symbol, problem)
+				integrity[symbol] = problem
+				continue
+			}
+		}
 
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			log.Printf("✗ %s: could not read file: %v", entry.Name(), err)
+		// Checked as written, parsed decompressed
+		if data, err = barfile.Decode(data); err != nil {
+			log.Printf("✗ %s: could not decompress bars: %v", symbol, err)
 			continue
 		}
 
-		var barData BarData
-		if err := json.Unmarshal(data, &barData); err != nil {
-			log.Printf("✗ %s: could not parse JSON: %v", entry.Name(), err)
+		digest, err := filter.ReadDigest(bytes.NewReader(data))
+		if err != nil {
+			log.Printf("✗ %s: could not parse JSON: %v", symbol, err)
 			continue
 		}
 
-		if barData.Symbol == "" {
-			log.Printf("✗ %s: missing symbol", entry.Name())
+		if digest.Symbol == "" {
+			log.Printf("✗ %s: missing symbol", symbol)
 			continue
 		}
+		digests = append(digests, digest)
+	}
 
-		allBarData[barData.Symbol] = &barData
-		avgVolume, avgPrice, avgVolatility := calculateStats(barData.Bars)
-		allStats = append(allStats, SymbolStats{
-			Symbol:        barData.Symbol,
-			AvgVolume:     avgVolume,
-			AvgPrice:      avgPrice,
-			AvgVolatility: avgVolatility,
-			BarCount:      barData.Count,
-		})
+	failures, err := loadFailures(source, *failuresPath, *only)
+	if err != nil {
+		log.Printf("Warning: no fetch failures read, assuming every symbol was fetched: %v", err)
+	}
+	for symbol, cause := range failures {
+		log.Printf("✗ %s: fetch failed (%s)", symbol, cause)
+	}
+
+	demoted, err := loadDataQuality(source, *qualityPath)
+	if err != nil {
+		log.Printf("Warning: no data quality report read, nothing demoted: %v", err)
+	}
+	for symbol, issues := range demoted {
+		log.Printf("⚠ %s: demoted for bad data (%s)", symbol, issues)
+	}
+
+	blocks, err := blocklist.Load(blocklist.DefaultPath)
+	if err != nil {
+		log.Fatalf("Error loading blocklist: %v", err)
 	}
 
-	// Calculate market-wide statistics
-	marketStats := calculateMarketStats(allStats)
+	assetInfo, err := assets.Load(assets.DefaultPath)
+	if err != nil {
+		log.Fatalf("Error loading asset metadata: %v", err)
+	}
+	if len(assetInfo) == 0 {
+		log.Printf("Warning: %s missing — asset class exclusions not applied", assets.DefaultPath)
+	}
+
+	// Sampled NBBO spreads replace the bar range proxy symbol by symbol as
+	// fetch collects enough of them, with this cycle's quote standing in
+	// until then
+	quoted, err := spreads.Load(*spreadsPath)
+	if err != nil {
+		log.Printf("Warning: %v — screening on bar range only", err)
+		quoted = &spreads.File{}
+	}
+	latest, err := spreads.LoadQuotes(*quotesPath)
+	if err != nil {
+		log.Printf("Warning: %v — latest quotes not used", err)
+		latest = &spreads.Quotes{}
+	}
+	medians := quoted.Medians()
+	screening := quoted.Screening(latest, time.Now())
+	log.Printf("Median quoted spreads for %d symbols, latest quote for %d, bar range proxy for the rest",
+		len(medians), len(screening)-len(medians))
+
+	output := filter.Run(filter.Input{
+		Digests:    digests,
+		Assets:     assetInfo,
+		Blocks:     blocks,
+		Expectancy: filter.LoadExpectancy(root + "strategies.json"),
+		Failures:   failures,
+		Integrity:  integrity,
+		Demoted:    demoted,
+		Spreads:    screening,
+		Options: filter.Options{
+			ExcludeClasses: assets.ParseClasses(*excludeClasses),
+			MinMarketCap:   *minMarketCap,
+		},
+		Now: time.Now(),
+	})
 
+	marketStats, criteria := output.MarketStats, output.Criteria
 	log.Println("")
 	log.Println("Market Statistics:")
 	log.Printf("  Volume:     %.0f - %.0f (median: %.0f)",
@@ -266,110 +273,84 @@ func main() {
 		marketStats.VolMin*100, marketStats.VolMax*100, marketStats.VolMedian*100)
 	log.Println("")
 
-	// Set criteria based on market statistics
-	criteria := FilterCriteria{
-		MinAvgVolume:   marketStats.VolumeMedian * 0.5, // Half of median volume
-		MinPrice:       10.0,                            // Keep minimum price floor
-		MaxPrice:       marketStats.PriceMax * 1.1,      // Allow all prices up to max + 10%
-		MinBarCount:    100,                             // Minimum history for reliable strategy signals
-		MaxBarRangePct: 0.5,                             // 50 bps — spread proxy from last bar range
-	}
-
 	log.Println("Filter Criteria:")
 	log.Printf("  Min avg volume:   %.0f (50%% of median)", criteria.MinAvgVolume)
 	log.Printf("  Price range:      $%.2f - $%.2f", criteria.MinPrice, criteria.MaxPrice)
 	log.Printf("  Min bar count:    %d", criteria.MinBarCount)
 	log.Printf("  Max bar range:    %.2f%% (spread proxy)", criteria.MaxBarRangePct)
+	log.Printf("  Max spread:       %.2f%% (median quoted)", criteria.MaxSpreadPct)
+	log.Printf("  Excluded classes: %s", strings.Join(criteria.ExcludeClasses, ", "))
+	log.Printf("  Min market cap:   $%.0fM", criteria.MinMarketCap/1e6)
 	log.Println("")
 
-	// Second pass: apply filter criteria, annotate all symbols with tradeable flag
-	var candidates []string
-
 	fmt.Printf("\n%-6s  %8s  %8s  %6s  %6s  %s\n", "Symbol", "Volume", "Price", "Vol%", "Rng%", "Status")
 	fmt.Println(strings.Repeat("-", 60))
-
-	for i, stats := range allStats {
-		bd := allBarData[stats.Symbol]
-
-		var lastRangePct float64
-		if bd != nil && len(bd.Bars) > 0 {
-			last := bd.Bars[len(bd.Bars)-1]
-			if last.Close > 0 {
-				lastRangePct = (last.High - last.Low) / last.Close * 100.0
-			}
-		}
-		allStats[i].LastRangePct = lastRangePct
-
-		reason := ""
-		if bd == nil {
-			reason = "no data"
-		} else {
-			reason = filterReason(bd, criteria)
-		}
-
-		allStats[i].Tradeable = reason == ""
-		allStats[i].SkipReason = reason
-
+	for _, stats := range output.AllSymbols {
 		status := "✓"
-		if reason != "" {
-			status = reason
-		} else {
-			candidates = append(candidates, stats.Symbol)
+		if !stats.Tradeable {
+			status = stats.SkipReason
+		} else if stats.Demoted != "" {
+			status = "✓ demoted, " + stats.Demoted
 		}
 		fmt.Printf("%-6s  %8.0f  %8.2f  %6.3f  %6.3f  %s\n",
 			stats.Symbol, stats.AvgVolume, stats.AvgPrice,
-			stats.AvgVolatility*100, lastRangePct, status)
+			stats.AvgVolatility*100, stats.LastRangePct, status)
 	}
 
 	log.Println("")
-	log.Printf("Candidates: %d/%d", len(candidates), totalFiles)
-
-	// Sort symbols by volume (highest first) for readability
-	sort.Slice(allStats, func(i, j int) bool {
-		return allStats[i].AvgVolume > allStats[j].AvgVolume
-	})
-
-	// Find earliest and latest bar timestamps across all symbols
-	var firstBarTime, lastBarTime string
-	for _, barData := range allBarData {
-		if len(barData.Bars) > 0 {
-			first := barData.Bars[0].Timestamp
-			last := barData.Bars[len(barData.Bars)-1].Timestamp
-			if firstBarTime == "" || first < firstBarTime {
-				firstBarTime = first
-			}
-			if lastBarTime == "" || last > lastBarTime {
-				lastBarTime = last
-			}
+	log.Printf("Candidates: %d/%d", output.TotalCandidates, len(symbols))
+	for i, c := range output.Ranked {
+		if i < 10 {
+			log.Printf("  #%-2d %-6s %.3f", i+1, c.Symbol, c.Score)
 		}
 	}
 
-	// Write candidates.json
-	output := CandidatesOutput{
-		Timestamp:       time.Now().UTC().Format(time.RFC3339),
-		FirstBarTime:    firstBarTime,
-		LastBarTime:     lastBarTime,
-		Symbols:         candidates,
-		Criteria:        criteria,
-		MarketStats:     marketStats,
-		AllSymbols:      allStats,
-		TotalCandidates: len(candidates),
+	// Write candidates.json, and with LFT2_STORE set store it as well
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(output); err != nil {
+		log.Fatalf("Error encoding JSON: %v", err)
+	}
+	outputFile := root + "candidates.json"
+	if err := os.WriteFile(outputFile, buf.Bytes(), 0644); err != nil {
+		log.Fatalf("Error writing %s: %v", outputFile, err)
+	}
+	log.Printf("Wrote %s", outputFile)
+	bookName := ""
+	if book != nil {
+		bookName = book.Name
+	}
+	if stored, err := storeCandidates(bookName, buf.Bytes(), time.Now()); err != nil {
+		log.Fatalf("Error storing candidates: %v", err)
+	} else if stored {
+		log.Printf("Stored candidates in %s", os.Getenv(store.Env))
 	}
 
-	outputFile := "docs/candidates.json"
-	file, err := os.Create(outputFile)
+	htmlFile := root + "candidates.html"
+	html, err := candidatesHTML(output)
 	if err != nil {
-		log.Fatalf("Error creating output file: %v", err)
+		log.Fatalf("Error rendering %s: %v", htmlFile, err)
+	}
+	if err := os.WriteFile(htmlFile, []byte(html), 0644); err != nil {
+		log.Fatalf("Error writing %s: %v", htmlFile, err)
 	}
-	defer file.Close()
+	log.Printf("Wrote %s", htmlFile)
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	encoder.SetEscapeHTML(false)
-	if err := encoder.Encode(output); err != nil {
-		log.Fatalf("Error encoding JSON: %v", err)
+	// Bar data QA: a broken feed shows here before the nightly backtest
+	// trains on it
+	quality, days := assessQuality(digests)
+	qaFile := root + "data-quality.html"
+	html, err = dataQualityHTML(quality, days)
+	if err != nil {
+		log.Fatalf("Error rendering %s: %v", qaFile, err)
+	}
+	if err := os.WriteFile(qaFile, []byte(html), 0644); err != nil {
+		log.Fatalf("Error writing %s: %v", qaFile, err)
 	}
+	log.Printf("Wrote %s (%d of %d symbols flagged)", qaFile, flaggedCount(quality), len(quality))
 
-	log.Printf("Wrote %s", outputFile)
+	interrupt.Stop(ctx, "filter")
 	fmt.Println("\nFilter complete!")
 }
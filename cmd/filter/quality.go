@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/dashboard"
+	"github.com/deanturpin/lft2/internal/filter"
+)
+
+// maxQualityRows caps the data quality table on a full-market scan; the
+// worst symbols come first, so the ones cut are the clean ones.
+const maxQualityRows = 500
+
+// assessQuality scores every digest against the market's trading days,
+// worst first.
+func assessQuality(digests []filter.Digest) ([]filter.DataQuality, []string) {
+	days := filter.MarketDays(digests)
+	var out []filter.DataQuality
+	for _, d := range digests {
+		out = append(out, filter.AssessQuality(d, days))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score < out[j].Score
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out, days
+}
+
+// flaggedCount is how many symbols have a data quality issue.
+func flaggedCount(quality []filter.DataQuality) int {
+	n := 0
+	for _, q := range quality {
+		if len(q.Issues) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// dataQualityHTML renders the bar data QA page: for each symbol a sparkline
+// of daily closes and one of bars per day, with missing days marked, and
+// its quality score. A broken feed shows as a flat line or a red gap.
+func dataQualityHTML(quality []filter.DataQuality, days []string) (string, error) {
+	reporting := make([]float64, len(days))
+	index := make(map[string]int, len(days))
+	for i, date := range days {
+		index[date] = i
+	}
+
+	var rows [][]dashboard.Cell
+	for _, q := range quality {
+		for _, d := range q.Days {
+			if d.Bars > 0 {
+				reporting[index[d.Date]]++
+			}
+		}
+		status, class := "✓", "good"
+		if len(q.Issues) > 0 {
+			status, class = strings.Join(q.Issues, "; "), "bad"
+		}
+		if len(rows) == maxQualityRows {
+			continue
+		}
+
+		closes := make([]float64, len(q.Days))
+		counts := make([]float64, len(q.Days))
+		for i, d := range q.Days {
+			closes[i], counts[i] = d.Close, float64(d.Bars)
+			if d.Bars == 0 {
+				closes[i] = math.NaN()
+			}
+		}
+		latest := "—"
+		if n := len(q.Days); n > 0 {
+			latest = fmt.Sprintf("%d", q.Days[n-1].Bars)
+		}
+		rows = append(rows, []dashboard.Cell{
+			{Text: q.Symbol, Bold: true},
+			{Spark: &dashboard.Spark{Values: closes, Marks: q.Missing}},
+			{Text: latest, Spark: &dashboard.Spark{Values: counts, Columns: true, Marks: q.Missing}},
+			{Text: fmt.Sprintf("%.3f", q.Score)},
+			{Text: status, Class: class},
+		})
+	}
+
+	subtitle := "No bars"
+	if len(days) > 0 {
+		subtitle = fmt.Sprintf("Trading days %s → %s", days[0], days[len(days)-1])
+	}
+	if len(quality) > maxQualityRows {
+		subtitle += fmt.Sprintf(" · worst %d of %d symbols shown", maxQualityRows, len(quality))
+	}
+
+	flagged, flaggedClass := flaggedCount(quality), "good"
+	if flagged > 0 {
+		flaggedClass = "bad"
+	}
+
+	return dashboard.Render(dashboard.Page{
+		Title:    "Data Quality",
+		Subtitle: subtitle,
+		Stats: []dashboard.Stat{
+			{Label: "Symbols", Value: fmt.Sprintf("%d", len(quality))},
+			{Label: "Flagged", Value: fmt.Sprintf("%d", flagged), Class: flaggedClass},
+			{Label: "Trading Days", Value: fmt.Sprintf("%d", len(days))},
+		},
+		Charts: []dashboard.Chart{{
+			Caption: "Symbols With Bars per Day",
+			Lines:   []dashboard.Line{{Label: "symbols", Values: reporting}},
+		}},
+		Tables: []dashboard.Table{{
+			Headers: []string{"Symbol", "Close", "Bars/Day", "Score", "Status"},
+			Rows:    rows,
+			Empty:   "No symbols scanned",
+		}},
+	})
+}
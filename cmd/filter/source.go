@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/artifact"
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/filter"
+	"github.com/deanturpin/lft2/internal/manifest"
+)
+
+// barSource supplies the per-symbol bar files filter scans.
+type barSource interface {
+	Symbols() ([]string, error)
+	Load(symbol string) ([]byte, error)
+	String() string
+}
+
+// dirSource reads {SYMBOL}.json or {SYMBOL}.json.gz files from a local
+// directory, as written by fetch.
+type dirSource string
+
+func (d dirSource) Symbols() ([]string, error) {
+	return barfile.Symbols(string(d))
+}
+
+// Load returns the file's bytes as written, compressed or not, which is what
+// the manifest checksums.
+func (d dirSource) Load(symbol string) ([]byte, error) {
+	return barfile.ReadRaw(string(d), symbol)
+}
+
+func (d dirSource) String() string { return string(d) }
+
+// remoteSource reads bars/{SYMBOL}.json, or bars/{SYMBOL}.json.gz when
+// there's no plain file, from a published artifact base. A
+// URL can't be listed, so the symbols come from that run's candidates.json.
+type remoteSource string
+
+func (r remoteSource) Symbols() ([]string, error) {
+	data, err := artifact.Fetch(string(r), "candidates.json")
+	if err != nil {
+		return nil, fmt.Errorf("downloading candidates: %w", err)
+	}
+	var published struct {
+		Symbols    []string `json:"symbols"`
+		AllSymbols []struct {
+			Symbol string `json:"symbol"`
+		} `json:"all_symbols"`
+	}
+	if err := json.Unmarshal(data, &published); err != nil {
+		return nil, fmt.Errorf("parsing candidates: %w", err)
+	}
+
+	// Every symbol the published run scanned, not just those it passed
+	symbols := published.Symbols
+	if len(published.AllSymbols) > 0 {
+		symbols = nil
+		for _, s := range published.AllSymbols {
+			symbols = append(symbols, s.Symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+func (r remoteSource) Load(symbol string) ([]byte, error) {
+	data, err := artifact.Fetch(string(r), "bars/"+barfile.Name(symbol, false))
+	if errors.Is(err, fs.ErrNotExist) {
+		return artifact.Fetch(string(r), "bars/"+barfile.Name(symbol, true))
+	}
+	return data, err
+}
+
+func (r remoteSource) String() string { return string(r) }
+
+// openSource picks a bar source from -bars: an http(s) URL is an artifact
+// base (the published docs/ root); anything else is a local bars directory.
+func openSource(spec string) (barSource, error) {
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+		return remoteSource(spec), nil
+	}
+	if info, err := os.Stat(spec); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("bars directory not found: %s", spec)
+	}
+	return dirSource(spec), nil
+}
+
+// restrict keeps the symbols named in only (comma-separated, any case),
+// preserving source order, and returns any named symbols the source lacks.
+// An empty list keeps everything.
+func restrict(symbols []string, only string) (kept, missing []string) {
+	var want []string
+	for _, s := range strings.Split(only, ",") {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			want = append(want, s)
+		}
+	}
+	if len(want) == 0 {
+		return symbols, nil
+	}
+
+	have := map[string]bool{}
+	for _, s := range symbols {
+		have[s] = true
+	}
+	wanted := map[string]bool{}
+	for _, s := range want {
+		wanted[s] = true
+		if !have[s] {
+			missing = append(missing, s)
+		}
+	}
+	for _, s := range symbols {
+		if wanted[s] {
+			kept = append(kept, s)
+		}
+	}
+	return kept, missing
+}
+
+// loadFailures reads the fetch-failures.json that goes with source: the
+// published one for a remote source, path for a local one. Only symbols named
+// in only are kept, as for the scan itself. Without the file every symbol is
+// assumed fetched, as before fetch wrote one.
+func loadFailures(source barSource, path, only string) (map[string]string, error) {
+	data, err := readReport(source, path, "fetch-failures.json")
+	if data == nil || err != nil {
+		return nil, err
+	}
+
+	failures, err := filter.ParseFailures(path, data)
+	if err != nil {
+		return nil, err
+	}
+	symbols := make([]string, 0, len(failures))
+	for symbol := range failures {
+		symbols = append(symbols, symbol)
+	}
+	kept, _ := restrict(symbols, only)
+	scanned := make(map[string]string, len(kept))
+	for _, symbol := range kept {
+		scanned[symbol] = failures[symbol]
+	}
+	return scanned, nil
+}
+
+// loadManifest reads the bars-manifest.json that goes with source, like
+// loadFailures. Without one, nil is returned and bar files go unverified.
+func loadManifest(source barSource, path string) (*manifest.File, error) {
+	var data []byte
+	var err error
+	if remote, ok := source.(remoteSource); ok {
+		data, err = artifact.Fetch(string(remote), "bars-manifest.json")
+	} else {
+		data, err = os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := manifest.Parse(path, data)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// unlisted returns the manifest's symbols that a local directory lacks,
+// restricted to those named in only. A remote source is listed from
+// candidates.json rather than its files, so a gap there shows up as a failed
+// download instead.
+func unlisted(source barSource, m *manifest.File, symbols []string, only string) []string {
+	if _, local := source.(dirSource); !local || m == nil {
+		return nil
+	}
+	missing, _ := restrict(m.Missing(symbols), only)
+	return missing
+}
+
+// readReport reads a file another stage wrote alongside the bars: name from
+// a remote source, path for a local one. A local file that isn't there reads
+// as nil.
+func readReport(source barSource, path, name string) ([]byte, error) {
+	if remote, ok := source.(remoteSource); ok {
+		return artifact.Fetch(string(remote), name)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// loadDataQuality reads the data_quality.json validate wrote for source and
+// returns the symbols it marked bad. Without the file nothing is demoted.
+func loadDataQuality(source barSource, path string) (map[string]string, error) {
+	data, err := readReport(source, path, "data_quality.json")
+	if data == nil || err != nil {
+		return nil, err
+	}
+	return filter.ParseDataQuality(path, data)
+}
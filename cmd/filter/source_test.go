@@ -0,0 +1,178 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"time"
+
+	"github.com/deanturpin/lft2/internal/manifest"
+)
+
+// --- openSource ---
+
+func TestOpenSource(t *testing.T) {
+	dir := t.TempDir()
+	if src, err := openSource(dir); err != nil || src.String() != dir {
+		t.Errorf("directory: got %v, %v", src, err)
+	}
+	if _, err := openSource(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+	if src, err := openSource("https://example.com/lft2"); err != nil {
+		t.Errorf("URL: unexpected error %v", err)
+	} else if _, ok := src.(remoteSource); !ok {
+		t.Errorf("URL: got %T, want remoteSource", src)
+	}
+}
+
+// --- dirSource ---
+
+func TestDirSource(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "AAPL.json"), []byte(`{"symbol":"AAPL"}`), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644)
+	os.Mkdir(filepath.Join(dir, "sub.json"), 0755)
+
+	symbols, err := dirSource(dir).Symbols()
+	if err != nil || len(symbols) != 1 || symbols[0] != "AAPL" {
+		t.Fatalf("got %v, %v", symbols, err)
+	}
+	data, err := dirSource(dir).Load("AAPL")
+	if err != nil || !strings.Contains(string(data), "AAPL") {
+		t.Errorf("got %q, %v", data, err)
+	}
+}
+
+// --- remoteSource ---
+
+func TestRemoteSource(t *testing.T) {
+	t.Setenv("LFT2_ARTIFACT_CACHE", "off")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/candidates.json":
+			w.Write([]byte(`{"symbols":["MSFT"],"all_symbols":[{"symbol":"MSFT"},{"symbol":"AAPL"}]}`))
+		case "/bars/AAPL.json":
+			w.Write([]byte(`{"symbol":"AAPL","bars":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	src := remoteSource(srv.URL)
+	symbols, err := src.Symbols()
+	if err != nil || strings.Join(symbols, ",") != "AAPL,MSFT" {
+		t.Fatalf("got %v, %v; want every scanned symbol, sorted", symbols, err)
+	}
+	if data, err := src.Load("AAPL"); err != nil || !strings.Contains(string(data), `"AAPL"`) {
+		t.Errorf("got %q, %v", data, err)
+	}
+	if _, err := src.Load("TSLA"); err == nil {
+		t.Error("expected error for missing bars")
+	}
+}
+
+// --- restrict ---
+
+func TestRestrict(t *testing.T) {
+	all := []string{"AAPL", "MSFT", "NVDA"}
+
+	kept, missing := restrict(all, "")
+	if len(kept) != 3 || missing != nil {
+		t.Errorf("empty filter: got %v, %v", kept, missing)
+	}
+
+	kept, missing = restrict(all, " nvda, AAPL,TSLA,")
+	if strings.Join(kept, ",") != "AAPL,NVDA" {
+		t.Errorf("kept %v, want source order AAPL,NVDA", kept)
+	}
+	if len(missing) != 1 || missing[0] != "TSLA" {
+		t.Errorf("missing %v, want [TSLA]", missing)
+	}
+}
+
+// --- loadFailures ---
+
+func TestLoadFailures(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fetch-failures.json")
+	if got, err := loadFailures(dirSource(dir), path, ""); got != nil || err != nil {
+		t.Errorf("missing file: got %v, %v; want nothing", got, err)
+	}
+
+	report := `{"schema_version": 1, "failures": [{"symbol": "MSFT", "cause": "rate_limited"}, {"symbol": "GONE", "cause": "bad_symbol"}]}`
+	if err := os.WriteFile(path, []byte(report), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadFailures(dirSource(dir), path, "")
+	if err != nil || len(got) != 2 || got["GONE"] != "bad_symbol" {
+		t.Errorf("got %v, %v", got, err)
+	}
+	if got, _ := loadFailures(dirSource(dir), path, "msft,aapl"); len(got) != 1 || got["MSFT"] != "rate_limited" {
+		t.Errorf("-symbols: got %v, want MSFT only", got)
+	}
+}
+
+func TestLoadDataQuality(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data_quality.json")
+	if got, err := loadDataQuality(dirSource(dir), path); got != nil || err != nil {
+		t.Errorf("missing file: got %v, %v; want nothing", got, err)
+	}
+
+	report := `{"schema_version": 1, "symbols": [{"symbol": "AAPL", "status": "ok"}, {"symbol": "GAPPY", "status": "bad", "issues": ["1 bad prices"]}]}`
+	if err := os.WriteFile(path, []byte(report), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := loadDataQuality(dirSource(dir), path); err != nil || len(got) != 1 || got["GAPPY"] != "1 bad prices" {
+		t.Errorf("got %v, %v", got, err)
+	}
+}
+
+// --- loadManifest / unlisted ---
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	bars := filepath.Join(dir, "bars")
+	if err := os.Mkdir(bars, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "bars-manifest.json")
+	if m, err := loadManifest(dirSource(bars), path); m != nil || err != nil {
+		t.Errorf("missing manifest: got %v, %v; want nothing", m, err)
+	}
+
+	for _, symbol := range []string{"AAPL", "MSFT"} {
+		if err := os.WriteFile(filepath.Join(bars, symbol+".json"), []byte(`{"symbol":"`+symbol+`","bars":[]}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	built, err := manifest.Build(bars, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Save(path, built); err != nil {
+		t.Fatal(err)
+	}
+	// An interrupted publish: one file gone
+	if err := os.Remove(filepath.Join(bars, "MSFT.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := loadManifest(dirSource(bars), path)
+	if err != nil || m == nil || len(m.Symbols) != 2 {
+		t.Fatalf("got %+v, %v", m, err)
+	}
+	symbols, _ := dirSource(bars).Symbols()
+	if got := unlisted(dirSource(bars), m, symbols, ""); strings.Join(got, ",") != "MSFT" {
+		t.Errorf("got %v, want MSFT", got)
+	}
+	if got := unlisted(dirSource(bars), m, symbols, "AAPL"); len(got) != 0 {
+		t.Errorf("-symbols AAPL: got %v, want nothing", got)
+	}
+}
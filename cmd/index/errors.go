@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// errorsFile is written by index each cycle, gathering what went wrong
+// across the stages from the artifacts they left.
+const errorsFile = "errors.json"
+
+// Severities in errors.json
+const (
+	severityError   = "error"   // A stage failed or an order didn't go through
+	severityWarning = "warning" // Something to look at; the cycle carried on
+)
+
+// maxListed is how many symbols a grouped problem names before "and N more".
+const maxListed = 5
+
+// Problem is one error or warning from a stage.
+type Problem struct {
+	Time     string `json:"time"`
+	Stage    string `json:"stage"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Source   string `json:"source"` // The artifact it was read from
+}
+
+// ErrorReport is the layout of errors.json, errors first and newest first.
+type ErrorReport struct {
+	schema.Header
+	Timestamp string    `json:"timestamp"`
+	Errors    int       `json:"errors"`
+	Warnings  int       `json:"warnings"`
+	Problems  []Problem `json:"problems"`
+}
+
+// collectProblems gathers the cycle's problems from the artifacts under dir:
+// crash reports from the last pipelineCadence, the latest fetch failures,
+// execution result, stale positions, exposure and API usage, and every stale or
+// missing artifact in statuses. Unreadable artifacts are skipped; their
+// freshness says enough.
+func collectProblems(dir string, statuses []Status, now time.Time) []Problem {
+	var problems []Problem
+	problems = append(problems, crashProblems(filepath.Join(dir, "crash"), now)...)
+	problems = append(problems, fetchProblems(filepath.Join(dir, "fetch-failures.json"))...)
+	problems = append(problems, executeProblems(filepath.Join(dir, "execution-result.json"))...)
+	problems = append(problems, staleProblems(filepath.Join(dir, "stale-positions.json"))...)
+	problems = append(problems, exposureProblems(filepath.Join(dir, "exposure.json"))...)
+	problems = append(problems, usageProblems(filepath.Join(dir, "api-usage.json"), now)...)
+	problems = append(problems, freshnessProblems(statuses, now)...)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Severity != problems[j].Severity {
+			return problems[i].Severity == severityError
+		}
+		return problems[i].Time > problems[j].Time
+	})
+	return problems
+}
+
+// readArtifact decodes a JSON artifact, reporting false when it's missing or
+// unreadable.
+func readArtifact(path string, v any) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && json.Unmarshal(data, v) == nil
+}
+
+// crashProblems reports each stage that panicked in the last cycle. Older
+// reports stay under crash/ but are history, not this cycle's problem.
+func crashProblems(dir string, now time.Time) []Problem {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	var problems []Problem
+	for _, path := range paths {
+		var report crash.Report
+		if !readArtifact(path, &report) {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, report.Timestamp)
+		if err != nil || now.Sub(t) > pipelineCadence {
+			continue
+		}
+		problems = append(problems, Problem{
+			Time:     report.Timestamp,
+			Stage:    report.Stage,
+			Severity: severityError,
+			Message:  "crashed: " + report.Panic,
+			Source:   filepath.Join("crash", filepath.Base(path)),
+		})
+	}
+	return problems
+}
+
+// fetchProblems reports the symbols fetch couldn't refresh, one warning per
+// cause so a full-market outage is one line rather than thousands.
+func fetchProblems(path string) []Problem {
+	var report struct {
+		Timestamp string `json:"timestamp"`
+		Failures  []struct {
+			Symbol string `json:"symbol"`
+			Cause  string `json:"cause"`
+		} `json:"failures"`
+	}
+	if !readArtifact(path, &report) {
+		return nil
+	}
+
+	byCause := map[string][]string{}
+	var causes []string
+	for _, f := range report.Failures {
+		if _, ok := byCause[f.Cause]; !ok {
+			causes = append(causes, f.Cause)
+		}
+		byCause[f.Cause] = append(byCause[f.Cause], f.Symbol)
+	}
+
+	var problems []Problem
+	for _, cause := range causes {
+		symbols := byCause[cause]
+		problems = append(problems, Problem{
+			Time:     report.Timestamp,
+			Stage:    "fetch",
+			Severity: severityWarning,
+			Message:  fmt.Sprintf("%d symbol(s) not refreshed, %s: %s", len(symbols), cause, listSymbols(symbols)),
+			Source:   filepath.Base(path),
+		})
+	}
+	return problems
+}
+
+// executeProblems reports a blocked account and every order that didn't go
+// through. Orders execute skipped on purpose aren't problems.
+func executeProblems(path string) []Problem {
+	var result struct {
+		Timestamp string `json:"timestamp"`
+		Blocked   string `json:"blocked"`
+		Orders    []struct {
+			Symbol string `json:"symbol"`
+			Side   string `json:"side"`
+			Status string `json:"status"`
+			Reason string `json:"reason"`
+		} `json:"orders"`
+		Verification *struct {
+			Missing    []string `json:"missing"`
+			Unexpected []string `json:"unexpected"`
+			Error      string   `json:"error"`
+		} `json:"verification"`
+	}
+	if !readArtifact(path, &result) {
+		return nil
+	}
+
+	var problems []Problem
+	add := func(severity, message string) {
+		problems = append(problems, Problem{
+			Time:     result.Timestamp,
+			Stage:    "execute",
+			Severity: severity,
+			Message:  message,
+			Source:   filepath.Base(path),
+		})
+	}
+	if result.Blocked != "" {
+		add(severityError, "account blocked: "+result.Blocked)
+	}
+	for _, o := range result.Orders {
+		message := fmt.Sprintf("%s %s %s", o.Symbol, o.Side, o.Status)
+		if o.Reason != "" {
+			message += ": " + o.Reason
+		}
+		switch o.Status {
+		case "rejected", "error":
+			add(severityError, message)
+		case "expired":
+			add(severityWarning, message)
+		}
+	}
+	if v := result.Verification; v != nil {
+		for _, id := range v.Missing {
+			add(severityError, id+" submitted but not at the broker")
+		}
+		for _, id := range v.Unexpected {
+			add(severityError, id+" failed but is at the broker")
+		}
+		if v.Error != "" {
+			add(severityWarning, "orders not verified at the broker: "+v.Error)
+		}
+	}
+	return problems
+}
+
+// staleProblems reports positions still held under a strategy the backtest
+// no longer recommends, and not sold this cycle.
+func staleProblems(path string) []Problem {
+	var report struct {
+		Timestamp string `json:"timestamp"`
+		Positions []struct {
+			Symbol   string `json:"symbol"`
+			Strategy string `json:"strategy"`
+			Exited   bool   `json:"exited"`
+		} `json:"positions"`
+	}
+	if !readArtifact(path, &report) {
+		return nil
+	}
+
+	var problems []Problem
+	for _, p := range report.Positions {
+		if p.Exited {
+			continue
+		}
+		problems = append(problems, Problem{
+			Time:     report.Timestamp,
+			Stage:    "exits",
+			Severity: severityWarning,
+			Message:  fmt.Sprintf("%s held, but %s is no longer recommended for it", p.Symbol, p.Strategy),
+			Source:   filepath.Base(path),
+		})
+	}
+	return problems
+}
+
+// exposureProblems reports a VaR breach, which pauses new entries.
+func exposureProblems(path string) []Problem {
+	var exposure struct {
+		Timestamp string  `json:"timestamp"`
+		VaRPct    float64 `json:"var_pct"`
+		MaxVaRPct float64 `json:"max_var_pct"`
+		Breached  bool    `json:"breached"`
+	}
+	if !readArtifact(path, &exposure) || !exposure.Breached {
+		return nil
+	}
+	return []Problem{{
+		Time:     exposure.Timestamp,
+		Stage:    "account",
+		Severity: severityWarning,
+		Message: fmt.Sprintf("portfolio VaR %.2f%% of equity exceeds %.2f%%, entries paused",
+			exposure.VaRPct*100, exposure.MaxVaRPct*100),
+		Source: filepath.Base(path),
+	}}
+}
+
+// usageHeadroom is the share of an API's per-minute limit that counts as
+// close to it, as in internal/quota.
+const usageHeadroom = 0.8
+
+// usageProblems reports a fetch forecast over the data budget, and each API
+// whose busiest minute on the latest day came within usageHeadroom of its
+// limit. A tally older than a cycle is left to its freshness.
+func usageProblems(path string, now time.Time) []Problem {
+	var usage struct {
+		Timestamp string         `json:"timestamp"`
+		Limits    map[string]int `json:"limits_per_minute"`
+		Forecast  *struct {
+			Symbols      int  `json:"symbols"`
+			TimeframeMin int  `json:"timeframe_min"`
+			MaxSymbols   int  `json:"max_symbols"`
+			Fits         bool `json:"fits"`
+		} `json:"forecast"`
+		Days map[string]struct {
+			Peaks map[string]int `json:"peak_per_minute"`
+		} `json:"days"`
+	}
+	if !readArtifact(path, &usage) {
+		return nil
+	}
+	if t, err := time.Parse(time.RFC3339, usage.Timestamp); err != nil || now.Sub(t) > pipelineCadence {
+		return nil
+	}
+
+	var problems []Problem
+	add := func(stage, message string) {
+		problems = append(problems, Problem{
+			Time:     usage.Timestamp,
+			Stage:    stage,
+			Severity: severityWarning,
+			Message:  message,
+			Source:   filepath.Base(path),
+		})
+	}
+	if f := usage.Forecast; f != nil && !f.Fits {
+		add("fetch", fmt.Sprintf("%d symbols every %d minutes is over the data budget, which fits %d",
+			f.Symbols, f.TimeframeMin, f.MaxSymbols))
+	}
+
+	latest := ""
+	for day := range usage.Days {
+		latest = max(latest, day)
+	}
+	var apis []string
+	for api := range usage.Days[latest].Peaks {
+		apis = append(apis, api)
+	}
+	sort.Strings(apis)
+	for _, api := range apis {
+		peak, limit := usage.Days[latest].Peaks[api], usage.Limits[api]
+		if limit > 0 && float64(peak) >= usageHeadroom*float64(limit) {
+			add("pipeline", fmt.Sprintf("%s API peaked at %d requests a minute on %s, limit %d", api, peak, latest, limit))
+		}
+	}
+	return problems
+}
+
+// freshnessProblems reports every artifact a stage stopped producing.
+// errors.json itself is about to be rewritten, so it isn't judged.
+func freshnessProblems(statuses []Status, now time.Time) []Problem {
+	var problems []Problem
+	for _, s := range statuses {
+		if s.Name == errorsFile || !s.Stale {
+			continue
+		}
+		p := Problem{Stage: "pipeline", Severity: severityWarning, Source: s.Name}
+		if s.GeneratedAt.IsZero() {
+			p.Time = now.UTC().Format(time.RFC3339)
+			p.Message = s.Name + " missing"
+		} else {
+			p.Time = s.GeneratedAt.UTC().Format(time.RFC3339)
+			p.Message = fmt.Sprintf("%s stale, last written %s", s.Name, formatAge(now.Sub(s.GeneratedAt)))
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
+
+// listSymbols names the first few symbols and counts the rest.
+func listSymbols(symbols []string) string {
+	if len(symbols) <= maxListed {
+		return strings.Join(symbols, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(symbols[:maxListed], ", "), len(symbols)-maxListed)
+}
+
+// saveProblems writes errors.json under dir.
+func saveProblems(dir string, problems []Problem, now time.Time) error {
+	report := ErrorReport{
+		Header:    schema.Current(),
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Problems:  problems,
+	}
+	if report.Problems == nil {
+		report.Problems = []Problem{}
+	}
+	for _, p := range problems {
+		if p.Severity == severityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding errors: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, errorsFile), append(data, '\n'), 0644)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- generatedAt ---
+
+func TestGeneratedAt_JSONTimestamp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "candidates.json")
+	os.WriteFile(path, []byte(`{"timestamp": "2026-03-10T14:30:00Z"}`), 0644)
+
+	got, err := generatedAt(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestGeneratedAt_FallsBackToModTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buy.fix")
+	os.WriteFile(path, []byte("8=FIX.5.0SP2|"), 0644)
+	mtime := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	os.Chtimes(path, mtime, mtime)
+
+	got, err := generatedAt(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Equal(mtime) {
+		t.Errorf("got %v, want %v", got, mtime)
+	}
+}
+
+// --- check ---
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	os.WriteFile(filepath.Join(dir, "fresh.json"), []byte(`{"timestamp": "2026-03-10T14:55:00Z"}`), 0644)
+	os.WriteFile(filepath.Join(dir, "stale.json"), []byte(`{"generated_at": "2026-03-10T13:00:00Z"}`), 0644)
+
+	got := check(dir, []Artifact{
+		{Name: "fresh.json", Cadence: 15 * time.Minute},
+		{Name: "stale.json", Cadence: 15 * time.Minute},
+		{Name: "missing.json", Cadence: 15 * time.Minute},
+	}, now)
+
+	if got[0].Stale {
+		t.Error("fresh.json: got stale, want fresh")
+	}
+	if !got[1].Stale {
+		t.Error("stale.json: got fresh, want stale")
+	}
+	if !got[2].Stale || !got[2].GeneratedAt.IsZero() {
+		t.Errorf("missing.json: got %+v, want stale with zero time", got[2])
+	}
+}
+
+// --- formatAge ---
+
+func TestFormatAge(t *testing.T) {
+	cases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "just now"},
+		{12 * time.Minute, "12m ago"},
+		{5 * time.Hour, "5h ago"},
+		{72 * time.Hour, "3d ago"},
+	}
+	for _, c := range cases {
+		if got := formatAge(c.d); got != c.want {
+			t.Errorf("formatAge(%v) = %q, want %q", c.d, got, c.want)
+		}
+	}
+}
+
+// --- errors.json ---
+
+func TestCollectProblems(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	write := func(name, content string) {
+		t.Helper()
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("crash/filter-20260310T145500Z.json", `{"timestamp": "2026-03-10T14:55:00Z", "stage": "filter", "panic": "nil map"}`)
+	write("crash/fetch-20260309T145500Z.json", `{"timestamp": "2026-03-09T14:55:00Z", "stage": "fetch", "panic": "yesterday's"}`)
+	write("fetch-failures.json", `{"timestamp": "2026-03-10T14:50:00Z", "failures": [
+		{"symbol": "A", "cause": "rate_limited"}, {"symbol": "B", "cause": "rate_limited"},
+		{"symbol": "C", "cause": "rate_limited"}, {"symbol": "D", "cause": "rate_limited"},
+		{"symbol": "E", "cause": "rate_limited"}, {"symbol": "F", "cause": "rate_limited"},
+		{"symbol": "XYZ", "cause": "bad_symbol"}]}`)
+	write("execution-result.json", `{"timestamp": "2026-03-10T14:58:00Z", "orders": [
+		{"symbol": "AAPL", "side": "buy", "status": "submitted"},
+		{"symbol": "MSFT", "side": "buy", "status": "rejected", "reason": "insufficient buying power"},
+		{"symbol": "NVDA", "side": "sell", "status": "skipped", "reason": "duplicate"},
+		{"symbol": "TSLA", "side": "buy", "status": "expired"}],
+		"verification": {"checked": 2, "missing": ["AAPL_x"]}}`)
+	write("stale-positions.json", `{"timestamp": "2026-03-10T14:57:00Z", "positions": [
+		{"symbol": "AMD", "strategy": "momentum", "exited": false},
+		{"symbol": "INTC", "strategy": "gap_fill", "exited": true}]}`)
+	write("exposure.json", `{"timestamp": "2026-03-10T14:52:00Z", "var_pct": 0.031, "max_var_pct": 0.02, "breached": true}`)
+	write("api-usage.json", `{"timestamp": "2026-03-10T14:51:00Z", "limits_per_minute": {"data": 200, "trading": 200},
+		"forecast": {"symbols": 1000, "timeframe_min": 5, "max_symbols": 792, "fits": false},
+		"days": {"2026-03-09": {"peak_per_minute": {"trading": 199}},
+			"2026-03-10": {"peak_per_minute": {"data": 180, "trading": 12}}}}`)
+
+	statuses := []Status{
+		{Artifact: Artifact{Name: "strategies.json"}, GeneratedAt: now.Add(-2 * time.Hour), Stale: true},
+		{Artifact: Artifact{Name: "latency.json"}, Stale: true},
+		{Artifact: Artifact{Name: errorsFile}, Stale: true},
+		{Artifact: Artifact{Name: "candidates.json"}, GeneratedAt: now},
+	}
+	problems := collectProblems(dir, statuses, now)
+
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.Severity+" "+p.Stage+" "+p.Message)
+	}
+	want := []string{
+		"error execute MSFT buy rejected: insufficient buying power",
+		"error execute AAPL_x submitted but not at the broker",
+		"error filter crashed: nil map",
+		"warning pipeline latency.json missing",
+		"warning execute TSLA buy expired",
+		"warning exits AMD held, but momentum is no longer recommended for it",
+		"warning account portfolio VaR 3.10% of equity exceeds 2.00%, entries paused",
+		"warning fetch 1000 symbols every 5 minutes is over the data budget, which fits 792",
+		"warning pipeline data API peaked at 180 requests a minute on 2026-03-10, limit 200",
+		"warning fetch 6 symbol(s) not refreshed, rate_limited: A, B, C, D, E and 1 more",
+		"warning fetch 1 symbol(s) not refreshed, bad_symbol: XYZ",
+		"warning pipeline strategies.json stale, last written 2h ago",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSaveProblems(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	if err := saveProblems(dir, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, errorsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"problems": []`) {
+		t.Errorf("no problems: want an empty list, got %s", data)
+	}
+
+	problems := []Problem{
+		{Severity: severityError, Stage: "execute"},
+		{Severity: severityWarning, Stage: "fetch"},
+		{Severity: severityWarning, Stage: "exits"},
+	}
+	if err := saveProblems(dir, problems, now); err != nil {
+		t.Fatal(err)
+	}
+	var report ErrorReport
+	data, _ = os.ReadFile(filepath.Join(dir, errorsFile))
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Errors != 1 || report.Warnings != 2 || report.Timestamp != "2026-03-10T15:00:00Z" {
+		t.Errorf("got %d errors, %d warnings at %s", report.Errors, report.Warnings, report.Timestamp)
+	}
+}
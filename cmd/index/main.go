@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/dashboard"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/tz"
+	"github.com/deanturpin/lft2/internal/version"
+)
+
+// Artifact is a published file and how often the pipeline should refresh it.
+type Artifact struct {
+	Name        string
+	Description string
+	Cadence     time.Duration
+}
+
+// The pipeline runs every 5 minutes; allowing three missed runs before
+// flagging an artifact keeps a single slow cycle from turning the page red.
+const pipelineCadence = 15 * time.Minute
+
+// A once-a-day artifact, allowing for a long weekend.
+const dailyCadence = 4 * 24 * time.Hour
+
+var artifacts = []Artifact{
+	{"daily-summary.html", "Today's trades and P&L", pipelineCadence},
+	{"preclose-preview.html", "Open positions, unrealised P&L and pending exits before the close", dailyCadence},
+	{"candidates.html", "Filtered candidate stocks", pipelineCadence},
+	{"data-quality.html", "Bar data QA: price sparklines, gaps and quality scores", pipelineCadence},
+	{"candidates.json", "Filtered candidate stocks (JSON)", pipelineCadence},
+	{"strategies.html", "Backtested strategy recommendations", pipelineCadence},
+	{"strategies.json", "Backtested strategy recommendations (JSON)", pipelineCadence},
+	{"equity-curves.json", "Backtest equity curve per strategy (JSON)", pipelineCadence},
+	{"reconciliation.json", "Journal vs broker reconciliation", pipelineCadence},
+	{"exposure.json", "Portfolio VaR and worst-day stress", pipelineCadence},
+	{"liquidity.json", "Intraday volume curve per candidate", pipelineCadence},
+	{"stale-positions.json", "Positions whose strategy is no longer recommended", pipelineCadence},
+	{"positions-diff.json", "Positions opened, closed and resized since the last cycle", pipelineCadence},
+	{"account-changes.json", "Cash, equity and margin usage since the previous day", pipelineCadence},
+	{"assets.json", "Asset classes and market caps", pipelineCadence},
+	{"spreads.json", "Rolling quoted NBBO spreads per symbol", pipelineCadence},
+	{"quotes.json", "Latest bid, ask and spread per symbol", pipelineCadence},
+	{"corporate_actions.json", "Splits, dividends, mergers and spinoffs for the watchlist", pipelineCadence},
+	{"fetch-failures.json", "Symbols the last fetch couldn't refresh", pipelineCadence},
+	{"bars-manifest.json", "Bar file counts and checksums", pipelineCadence},
+	{"execution-result.json", "Orders submitted, rejected and skipped last run", pipelineCadence},
+	{"errors.json", "Errors and warnings from every stage this cycle", pipelineCadence},
+	{"executed-cycles.json", "Pipeline cycles execute has sent orders for", pipelineCadence},
+	{"api-usage.json", "Alpaca requests per endpoint and the data budget forecast", pipelineCadence},
+	{"latency.json", "Bar close to fill timing per pipeline hop", pipelineCadence},
+	{"performance.json", "Sharpe, alpha and beta against the benchmark", pipelineCadence},
+	{"buy.fix", "Entry signals (FIX 5.0 SP2)", pipelineCadence},
+	{"sell.fix", "Exit signals (FIX 5.0 SP2)", pipelineCadence},
+	{"pipeline-metadata.json", "Pipeline execution metadata", pipelineCadence},
+	{"tech-stack.json", "Build environment and tool versions", pipelineCadence},
+	{"coverage/index.html", "Code coverage report (lcov)", pipelineCadence},
+	{"callgraph.svg", "Backtest call graph (gprof)", pipelineCadence},
+}
+
+// Status is the freshness of one artifact at index generation time.
+type Status struct {
+	Artifact
+	GeneratedAt time.Time // zero when the file is missing
+	Stale       bool
+}
+
+// generatedAt returns when an artifact was produced. JSON artifacts carry
+// their own timestamp; everything else falls back to the file's mtime.
+func generatedAt(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if filepath.Ext(path) == ".json" {
+		if data, err := os.ReadFile(path); err == nil {
+			var meta struct {
+				Timestamp   string `json:"timestamp"`
+				GeneratedAt string `json:"generated_at"`
+			}
+			if json.Unmarshal(data, &meta) == nil {
+				for _, s := range []string{meta.GeneratedAt, meta.Timestamp} {
+					if t, err := time.Parse(time.RFC3339, s); err == nil {
+						return t, nil
+					}
+				}
+			}
+		}
+	}
+
+	return info.ModTime(), nil
+}
+
+// check reports the freshness of each artifact under dir. Ages are measured
+// from now, which is the end of the current pipeline run, so the page only
+// goes red when a stage has stopped producing output — not over weekends.
+func check(dir string, list []Artifact, now time.Time) []Status {
+	statuses := make([]Status, 0, len(list))
+	for _, a := range list {
+		s := Status{Artifact: a, Stale: true}
+		if t, err := generatedAt(filepath.Join(dir, a.Name)); err == nil {
+			s.GeneratedAt = t
+			s.Stale = now.Sub(t) > a.Cadence
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// formatAge renders a duration as a short human-readable age.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// indexPage builds the dashboard page: the cycle's problems, then every
+// artifact.
+func indexPage(statuses []Status, problems []Problem, now time.Time) dashboard.Page {
+	fresh, stale, missing := 0, 0, 0
+	var rows [][]dashboard.Cell
+	for _, s := range statuses {
+		name := dashboard.Cell{Text: s.Name, Bold: true, Href: s.Name}
+		switch {
+		case s.GeneratedAt.IsZero():
+			missing++
+			name.Href = ""
+			rows = append(rows, []dashboard.Cell{
+				name, {Text: s.Description}, {Text: "—"}, {Text: "—"},
+				{Text: "missing", Class: "bad"},
+			})
+			continue
+		case s.Stale:
+			stale++
+		default:
+			fresh++
+		}
+
+		status, class := "fresh", "good"
+		if s.Stale {
+			status, class = "stale", "bad"
+		}
+		rows = append(rows, []dashboard.Cell{
+			name,
+			{Text: s.Description},
+			{Text: tz.Format(s.GeneratedAt)},
+			{Text: formatAge(now.Sub(s.GeneratedAt))},
+			{Text: status, Class: class},
+		})
+	}
+
+	staleClass := "good"
+	if stale+missing > 0 {
+		staleClass = "bad"
+	}
+
+	errors, warnings := 0, 0
+	var problemRows [][]dashboard.Cell
+	for _, p := range problems {
+		class := "warn"
+		if p.Severity == severityError {
+			errors++
+			class = "bad"
+		} else {
+			warnings++
+		}
+		when := p.Time
+		if t, err := time.Parse(time.RFC3339, p.Time); err == nil {
+			when = tz.Format(t)
+		}
+		problemRows = append(problemRows, []dashboard.Cell{
+			{Text: p.Severity, Class: class, Bold: true},
+			{Text: when},
+			{Text: p.Stage},
+			{Text: p.Message, Href: p.Source, Title: p.Source},
+		})
+	}
+	errorClass, warningClass := "good", "good"
+	if errors > 0 {
+		errorClass = "bad"
+	}
+	if warnings > 0 {
+		warningClass = "warn"
+	}
+
+	return dashboard.Page{
+		Title:    "Low Frequency Trader",
+		Subtitle: "Pipeline artifacts",
+		Stats: []dashboard.Stat{
+			{Label: "Artifacts", Value: fmt.Sprintf("%d", len(statuses))},
+			{Label: "Fresh", Value: fmt.Sprintf("%d", fresh), Class: "good"},
+			{Label: "Stale", Value: fmt.Sprintf("%d", stale), Class: staleClass},
+			{Label: "Missing", Value: fmt.Sprintf("%d", missing), Class: staleClass},
+			{Label: "Errors", Value: fmt.Sprintf("%d", errors), Class: errorClass},
+			{Label: "Warnings", Value: fmt.Sprintf("%d", warnings), Class: warningClass},
+		},
+		Tables: []dashboard.Table{
+			{
+				Caption: "Errors and warnings",
+				Headers: []string{"Severity", "Time", "Stage", "Message"},
+				Rows:    problemRows,
+				Empty:   "No errors or warnings this cycle",
+			},
+			{
+				Headers: []string{"Artifact", "Description", "Generated", "Age", "Status"},
+				Rows:    rows,
+				Empty:   "No artifacts configured",
+			},
+			{
+				Caption: "Links",
+				Headers: []string{"Link", "Description"},
+				Rows: [][]dashboard.Cell{
+					{{Text: "Live Dashboard", Href: "https://lft.turpin.dev"}, {Text: "Positions and orders in real time"}},
+					{{Text: "GitHub", Href: "https://github.com/deanturpin/lft2"}, {Text: "Source code"}},
+				},
+			},
+		},
+		GeneratedAt: now,
+	}
+}
+
+// Index regenerates docs/index.html at the end of each pipeline run, linking
+// every artifact with its age so a broken stage is visible at a glance.
+func main() {
+	version.Handle("index")
+	defer crash.Guard("index")
+	ctx := interrupt.Context()
+
+	dir := flag.String("dir", "docs", "Artifact directory")
+	flag.Parse()
+
+	fmt.Println("Low Frequency Trader v2 - Index Module")
+	fmt.Println()
+
+	now := time.Now()
+	statuses := check(*dir, artifacts, now)
+	for _, s := range statuses {
+		switch {
+		case s.GeneratedAt.IsZero():
+			fmt.Printf("  ✗ %-24s missing\n", s.Name)
+		case s.Stale:
+			fmt.Printf("  ✗ %-24s stale (%s)\n", s.Name, formatAge(now.Sub(s.GeneratedAt)))
+		default:
+			fmt.Printf("  ✓ %-24s %s\n", s.Name, formatAge(now.Sub(s.GeneratedAt)))
+		}
+	}
+
+	interrupt.Stop(ctx, "index")
+
+	// Problems are gathered before errors.json is rewritten, then freshness
+	// is checked again so the page shows the new file
+	problems := collectProblems(*dir, statuses, now)
+	if err := saveProblems(*dir, problems, now); err != nil {
+		log.Printf("⚠ %s not written: %v", errorsFile, err)
+	} else {
+		fmt.Printf("✓ Wrote %s (%d problem(s))\n", filepath.Join(*dir, errorsFile), len(problems))
+	}
+	statuses = check(*dir, artifacts, now)
+
+	html, err := dashboard.Render(indexPage(statuses, problems, now))
+	if err != nil {
+		log.Fatalf("Error rendering index: %v", err)
+	}
+
+	out := filepath.Join(*dir, "index.html")
+	if err := os.WriteFile(out, []byte(html), 0644); err != nil {
+		log.Fatalf("Error writing %s: %v", out, err)
+	}
+	fmt.Printf("✓ Wrote %s\n", out)
+	interrupt.Stop(ctx, "index")
+}
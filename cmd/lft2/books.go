@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/books"
+)
+
+// runBooks checks books.json and lists its books, or with -names just their
+// names, one a line, for make to run each after the default book:
+//
+//	lft2 books          each book's timeframe, budget, strategies and root
+//	lft2 books -names   for a shell loop
+func runBooks(args []string) int {
+	fs := flag.NewFlagSet("books", flag.ContinueOnError)
+	path := fs.String("f", books.DefaultPath, "Books to check")
+	names := fs.Bool("names", false, "Print only the book names")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := books.Load(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	if *names {
+		for _, b := range cfg.Books {
+			fmt.Println(b.Name)
+		}
+		return 0
+	}
+	printBooks(os.Stdout, cfg)
+	return 0
+}
+
+// printBooks writes one line per book, then the default book's share.
+func printBooks(w io.Writer, cfg books.Config) {
+	if len(cfg.Books) == 0 {
+		fmt.Fprintf(w, "No books: the pipeline trades the whole account from %s\n", books.Root(""))
+		return
+	}
+	fmt.Fprintf(w, "  %-16s  %9s  %6s  %-22s  %s\n", "Book", "Timeframe", "Budget", "Root", "Strategies")
+	for _, b := range cfg.Books {
+		fmt.Fprintf(w, "  %-16s  %7dm  %5.0f%%  %-22s  %s\n",
+			b.Name, b.TimeframeMin, b.Budget*100, books.Root(b.Name), strings.Join(b.Strategies, ", "))
+	}
+	fmt.Fprintf(w, "  %-16s  %7dm  %5.0f%%  %-22s  %s\n", "(default)", 5, cfg.Budget("")*100, books.Root(""), "the rest")
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/lock"
+)
+
+// lockPoll is how often a held lock is retried.
+const lockPoll = 10 * time.Second
+
+// nightlyRun is one scheduled backtest: after Session closes, finished
+// before the next session opens.
+type nightlyRun struct {
+	Session  string // YYYY-MM-DD of the session it follows
+	Start    time.Time
+	Deadline time.Time
+}
+
+// nextRun returns the first backtest due after done (a session date, empty
+// for none yet) that can still start before its deadline: the close plus
+// after, and the next open less margin. One already due starts now, so a
+// daemon started in the evening runs that night's rather than waiting a day.
+// sessions are oldest first, as Calendar returns them.
+func nextRun(sessions []alpaca.Session, now time.Time, done string, after, margin time.Duration) (nightlyRun, error) {
+	for i := 0; i+1 < len(sessions); i++ {
+		if sessions[i].Date <= done {
+			continue
+		}
+		_, close, err := sessions[i].Bounds()
+		if err != nil {
+			return nightlyRun{}, err
+		}
+		open, _, err := sessions[i+1].Bounds()
+		if err != nil {
+			return nightlyRun{}, err
+		}
+		run := nightlyRun{Session: sessions[i].Date, Start: close.Add(after), Deadline: open.Add(-margin)}
+		if !run.Start.Before(run.Deadline) || !now.Before(run.Deadline) {
+			continue
+		}
+		if run.Start.Before(now) {
+			run.Start = now
+		}
+		return run, nil
+	}
+	return nightlyRun{}, fmt.Errorf("no session to follow after %s", now.Format(time.DateOnly))
+}
+
+// runDaemon runs the candidate scan and backtest after every session close,
+// by Alpaca's calendar, so no cron is needed for it. The backtest holds the
+// run lock, and is stopped if it hasn't finished -margin before the next
+// open; a live cycle run under `lft2 lock` waits for it, and it waits for a
+// live cycle still running after the close. Runs until Ctrl-C.
+//
+//	lft2 daemon                            make backtest after each close
+//	lft2 daemon -cmd "make backtest-cpp"   backtest the candidates already filtered
+func runDaemon(args []string) int {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	command := fs.String("cmd", "make backtest", "Shell command to run after each close")
+	after := fs.Duration("after", 15*time.Minute, "How long after the close to start, for the last bars to settle")
+	margin := fs.Duration("margin", 30*time.Minute, "Stop the run this long before the next open")
+	lockPath := fs.String("lock", lock.DefaultPath, "Lock file shared with the live cycle")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	client, err := alpaca.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	ctx := interrupt.Context()
+	client = client.WithContext(ctx)
+
+	done := ""
+	for {
+		now := time.Now()
+		sessions, err := client.Calendar(now.AddDate(0, 0, -7).Format(time.DateOnly), now.AddDate(0, 0, 14).Format(time.DateOnly))
+		var run nightlyRun
+		if err == nil {
+			run, err = nextRun(sessions, now, done, *after, *margin)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			fmt.Fprintf(os.Stderr, "⚠ calendar: %v; retrying in 5m\n", err)
+			if !interrupt.Sleep(ctx, 5*time.Minute) {
+				break
+			}
+			continue
+		}
+
+		fmt.Printf("→ %s: %q at %s, stopped by %s\n", run.Session, *command,
+			run.Start.Local().Format("Mon 15:04"), run.Deadline.Local().Format("Mon 15:04"))
+		if !interrupt.Sleep(ctx, time.Until(run.Start)) {
+			break
+		}
+		if err := runNightly(ctx, *command, *lockPath, run.Deadline); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", run.Session, err)
+		} else {
+			fmt.Printf("✓ %s: %q finished\n", run.Session, *command)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		// Failed or not, the next try is after the next close: by now the
+		// inputs of a retry would be no different
+		done = run.Session
+	}
+	fmt.Fprintln(os.Stderr, "✗ daemon interrupted")
+	return interrupt.ExitCode
+}
+
+// runNightly takes the lock, waiting for it until deadline, and runs command
+// under sh, interrupting it at deadline or on Ctrl-C.
+func runNightly(ctx context.Context, command, lockPath string, deadline time.Time) error {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	l, err := lock.Acquire(ctx, lockPath, "backtest", lockPoll)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	// Its own process group, so the interrupt reaches make's children too,
+	// and each stage stops at a step boundary with its files complete
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGINT) }
+	cmd.WaitDelay = time.Minute
+
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("stopped at %s so it can't overlap the open: %v", deadline.Local().Format("15:04"), err)
+	}
+	return err
+}
+
+// runLock runs a command holding the lock the daemon's backtest takes, so a
+// live cycle from cron doesn't start while one is still going. It waits up
+// to -wait for the lock, then fails without running the command. The
+// command's exit code is returned.
+//
+//	lft2 lock -wait 2m -- make
+func runLock(args []string) int {
+	fs := flag.NewFlagSet("lock", flag.ContinueOnError)
+	wait := fs.Duration("wait", 0, "How long to wait for the lock before giving up")
+	name := fs.String("name", "live", "Holder name recorded in the lock file")
+	lockPath := fs.String("lock", lock.DefaultPath, "Lock file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: lft2 lock [-wait D] -- COMMAND [ARGS...]")
+		return 2
+	}
+
+	// Ctrl-C reaches the command from the terminal; waiting for it to stop
+	// keeps the lock held until it has
+	ctx := interrupt.Context()
+	waitCtx, cancel := context.WithTimeout(ctx, *wait)
+	defer cancel()
+	l, err := lock.Acquire(waitCtx, *lockPath, *name, min(lockPoll, max(*wait, time.Millisecond)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %s: %v\n", *lockPath, err)
+		return 1
+	}
+	defer l.Release()
+
+	cmd := exec.Command(fs.Arg(0), fs.Args()[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = cmd.Run()
+	var exit *exec.ExitError
+	switch {
+	case errors.As(err, &exit) && exit.ExitCode() < 0:
+		return interrupt.ExitCode // Killed by a signal
+	case errors.As(err, &exit):
+		return exit.ExitCode()
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	return 0
+}
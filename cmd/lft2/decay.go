@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/artifact"
+	"github.com/deanturpin/lft2/internal/fees"
+	"github.com/deanturpin/lft2/internal/notify"
+	"github.com/deanturpin/lft2/internal/report"
+	"github.com/deanturpin/lft2/internal/schema"
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+// decayPath is where lft2 decay writes each strategy's live win rate
+// against its backtest, read back to alert once per decline.
+const decayPath = "docs/strategy-decay.json"
+
+// backtestRate is a strategy's win rate over every viable recommendation's
+// backtest trades, and the lowest the true rate plausibly is.
+type backtestRate struct {
+	WinRate float64
+	Trades  int
+	Lower   float64
+}
+
+// strategyDecay is one strategy's live record against its backtest.
+type strategyDecay struct {
+	Strategy       string  `json:"strategy"` // name-vN
+	BacktestRate   float64 `json:"backtest_win_rate"`
+	BacktestTrades int     `json:"backtest_trades"`
+	LowerBound     float64 `json:"lower_bound"`
+	LiveRate       float64 `json:"live_win_rate"` // Over the window to Through
+	LiveTrades     int     `json:"live_trades"`
+	DaysBelow      int     `json:"days_below"` // Consecutive, up to Through
+	Decaying       bool    `json:"decaying"`
+}
+
+// decayReport is strategy-decay.json.
+type decayReport struct {
+	schema.Header
+	Timestamp  string          `json:"timestamp"`
+	Through    string          `json:"through"` // Last day judged, YYYY-MM-DD
+	Window     int             `json:"window"`  // Days of closed trades per win rate
+	Days       int             `json:"days"`    // Consecutive days below the bound to alert
+	MinTrades  int             `json:"min_trades"`
+	Z          float64         `json:"z"`
+	Strategies []strategyDecay `json:"strategies"`
+}
+
+// runDecay compares each strategy's rolling live win rate with the lower
+// confidence bound of its backtest win rate, and sends a notification when
+// it has been below it -days in a row. Only whole days are judged, up to
+// yesterday, so it checks once a day however often it's run:
+//
+//	lft2 decay                  after the day's fills, e.g. from make
+//	lft2 decay -days 5 -z 2.33  slower to alert, and on a wider bound
+func runDecay(args []string) int {
+	now := time.Now()
+	fs := flag.NewFlagSet("decay", flag.ContinueOnError)
+	path := fs.String("strategies", "docs/strategies.json", "Backtest output with each recommendation's win_rate and trade_count")
+	window := fs.Int("window", 30, "Days of closed trades each live win rate is taken over")
+	days := fs.Int("days", 3, "Consecutive days below the bound before alerting")
+	minTrades := fs.Int("min-trades", 10, "Closed trades a window needs to be judged")
+	z := fs.Float64("z", 1.645, "Standard scores below the backtest win rate for the bound (1.645: 95% one-sided)")
+	out := fs.String("o", decayPath, "Where to write the results, read back to alert once per decline")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *window < 1 || *days < 1 || *minTrades < 1 || *z <= 0 {
+		fmt.Fprintln(os.Stderr, "✗ -window, -days, -min-trades and -z must be positive")
+		return 2
+	}
+
+	through := tz.Date(tz.In(now).AddDate(0, 0, -1))
+	prev := previousDecay(*out)
+	if prev != nil && prev.Through >= through {
+		fmt.Printf("[skip] strategy decay already checked through %s\n", prev.Through)
+		return 0
+	}
+
+	bounds, err := backtestBounds(*path, *z)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	client, err := alpaca.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	feeModel, err := fees.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ fee model: %v\n", err)
+		return 1
+	}
+	strategyMap, err := report.LoadStrategyMap(report.StrategyMapPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	// Far enough back for the window to each of the days judged
+	last, _ := time.Parse(time.DateOnly, through)
+	from := last.AddDate(0, 0, 2-*window-*days).Format(time.DateOnly)
+	reporter := report.Reporter{Broker: client, Fees: feeModel, Log: os.Stderr}
+	acts, err := reporter.Statement(from, through)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	trades, _ := roundTrips(acts)
+
+	results := decay(trades, bounds, strategyMap, through, *window, *days, *minTrades)
+	fmt.Printf("Strategy decay, %d-day live win rate to %s against the backtest's lower bound\n\n", *window, through)
+	printDecay(os.Stdout, results)
+
+	alerts := newlyDecaying(results, prev)
+	notifier := notify.FromEnv()
+	for _, d := range alerts {
+		fmt.Printf("\n⚠ %s has been below its backtest for %d days\n", d.Strategy, d.DaysBelow)
+		title := fmt.Sprintf("LFT2: %s win rate below its backtest", d.Strategy)
+		body := fmt.Sprintf("Live %.0f%% over %d trades in the %d days to %s, under the backtest's lower bound of %.0f%% (%.0f%% over %d trades) for %d days running. Review it.",
+			d.LiveRate*100, d.LiveTrades, *window, through, d.LowerBound*100, d.BacktestRate*100, d.BacktestTrades, d.DaysBelow)
+		if err := notifier.Send(title, body, now); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			return 1
+		}
+	}
+	if len(alerts) > 0 && notifier == nil {
+		fmt.Println("  [skip] notification: LFT2_NOTIFY_WEBHOOK not set")
+	}
+
+	data, err := json.MarshalIndent(decayReport{
+		Header:     schema.Current(),
+		Timestamp:  now.UTC().Format(time.RFC3339),
+		Through:    through,
+		Window:     *window,
+		Days:       *days,
+		MinTrades:  *minTrades,
+		Z:          *z,
+		Strategies: results,
+	}, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ encoding %s: %v\n", *out, err)
+		return 1
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	fmt.Printf("\n✓ Wrote %s\n", *out)
+	return 0
+}
+
+// backtestBounds pools each strategy version's viable recommendations, the
+// ones entries trades, into one win rate over all their backtest trades,
+// with its Wilson lower bound at z.
+func backtestBounds(path string, z float64) (map[string]backtestRate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Check(path, data); err != nil {
+		return nil, err
+	}
+	var file struct {
+		Recommendations []report.Recommendation `json:"recommendations"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	wins := map[string]float64{}
+	bounds := map[string]backtestRate{}
+	for _, r := range file.Recommendations {
+		if !r.Viable || r.TradeCount < 1 {
+			continue
+		}
+		key := fmt.Sprintf("%s-v%d", r.Strategy, max(r.Version, 1))
+		wins[key] += r.WinRate * float64(r.TradeCount)
+		b := bounds[key]
+		b.Trades += r.TradeCount
+		bounds[key] = b
+	}
+	for key, b := range bounds {
+		b.WinRate = wins[key] / float64(b.Trades)
+		b.Lower = wilsonLower(b.WinRate, b.Trades, z)
+		bounds[key] = b
+	}
+	return bounds, nil
+}
+
+// wilsonLower is the lower end of the Wilson score interval for a rate p
+// observed over n trials: unlike p less z standard errors, it stays inside
+// 0 to 1 and allows for a small n.
+func wilsonLower(p float64, n int, z float64) float64 {
+	if n < 1 {
+		return 0
+	}
+	nf := float64(n)
+	centre := p + z*z/(2*nf)
+	spread := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf))
+	return math.Max(0, (centre-spread)/(1+z*z/nf))
+}
+
+// decay judges each strategy with a backtest on the trades closed in the
+// window days to through, and to each of the days-1 days before it. A day
+// counts as below when the window has at least minTrades trades and their
+// win rate is under the bound; the run of such days back from through is
+// DaysBelow, and days of them make it decaying. Trades are grouped as
+// the reports group them, after any strategy mapping. Sorted by strategy.
+func decay(trades []trade, bounds map[string]backtestRate, m report.StrategyMap, through string, window, days, minTrades int) []strategyDecay {
+	type closed struct {
+		day string
+		win bool
+	}
+	byStrategy := map[string][]closed{}
+	for _, t := range trades {
+		name, version, ok := report.OrderStrategy(t.Symbol, t.OrderID)
+		if !ok {
+			continue
+		}
+		key := m.Key(name, version)
+		byStrategy[key] = append(byStrategy[key], closed{day: tz.Date(t.Closed), win: t.Return > 0})
+	}
+
+	last, err := time.Parse(time.DateOnly, through)
+	if err != nil {
+		return nil
+	}
+	rate := func(trades []closed, end time.Time) (float64, int) {
+		to, from := end.Format(time.DateOnly), end.AddDate(0, 0, -window).Format(time.DateOnly)
+		wins, n := 0, 0
+		for _, t := range trades {
+			if t.day > from && t.day <= to {
+				n++
+				if t.win {
+					wins++
+				}
+			}
+		}
+		if n == 0 {
+			return 0, 0
+		}
+		return float64(wins) / float64(n), n
+	}
+
+	var results []strategyDecay
+	for key, b := range bounds {
+		d := strategyDecay{
+			Strategy:       key,
+			BacktestRate:   math.Round(b.WinRate*1e4) / 1e4,
+			BacktestTrades: b.Trades,
+			LowerBound:     math.Round(b.Lower*1e4) / 1e4,
+		}
+		live, n := rate(byStrategy[key], last)
+		d.LiveRate, d.LiveTrades = math.Round(live*1e4)/1e4, n
+		for k := 0; k < days; k++ {
+			r, n := rate(byStrategy[key], last.AddDate(0, 0, -k))
+			if n < minTrades || r >= b.Lower {
+				break
+			}
+			d.DaysBelow++
+		}
+		d.Decaying = d.DaysBelow >= days
+		results = append(results, d)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Strategy < results[j].Strategy })
+	return results
+}
+
+// newlyDecaying returns the decaying strategies prev didn't already report
+// as decaying, so a decline is notified once rather than every day.
+func newlyDecaying(results []strategyDecay, prev *decayReport) []strategyDecay {
+	seen := map[string]bool{}
+	if prev != nil {
+		for _, d := range prev.Strategies {
+			seen[d.Strategy] = d.Decaying
+		}
+	}
+	var alerts []strategyDecay
+	for _, d := range results {
+		if d.Decaying && !seen[d.Strategy] {
+			alerts = append(alerts, d)
+		}
+	}
+	return alerts
+}
+
+// previousDecay returns the last run's strategy-decay.json: path, else the
+// published one, so a CI checkout doesn't check or alert again. Nil when
+// neither is usable.
+func previousDecay(path string) *decayReport {
+	data, err := os.ReadFile(path)
+	name := path
+	if err != nil {
+		if data, err = artifact.Fetch(artifact.Base(), "strategy-decay.json"); err != nil {
+			return nil
+		}
+		name = "strategy-decay.json"
+	}
+	if err := schema.Check(name, data); err != nil {
+		fmt.Printf("  [skip] previous strategy decay: %v\n", err)
+		return nil
+	}
+	var prev decayReport
+	if err := json.Unmarshal(data, &prev); err != nil {
+		fmt.Printf("  [skip] previous strategy decay: %v\n", err)
+		return nil
+	}
+	return &prev
+}
+
+// printDecay writes one line per strategy.
+func printDecay(w io.Writer, results []strategyDecay) {
+	fmt.Fprintf(w, "  %-24s  %9s  %6s  %9s  %6s  %5s\n", "Strategy", "Backtest", "Bound", "Live", "Trades", "Below")
+	for _, d := range results {
+		mark := "✓"
+		if d.Decaying {
+			mark = "⚠ decaying"
+		}
+		fmt.Fprintf(w, "  %-24s  %8.1f%%  %5.1f%%  %8.1f%%  %6d  %5d  %s\n",
+			d.Strategy, d.BacktestRate*100, d.LowerBound*100, d.LiveRate*100, d.LiveTrades, d.DaysBelow, mark)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/fees"
+	"github.com/deanturpin/lft2/internal/journal"
+	"github.com/deanturpin/lft2/internal/report"
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+// runExport writes every fill in a date range as a broker-style CSV, with
+// journal notes, for importing into a portfolio tracker:
+//
+//	lft2 export                                   today to stdout
+//	lft2 export -from 2026-01-01 -o trades.csv    year to date
+func runExport(args []string) int {
+	today := tz.Date(time.Now())
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	from := fs.String("from", today, "First trading day (YYYY-MM-DD, reporting timezone)")
+	to := fs.String("to", today, "Last trading day, inclusive")
+	out := fs.String("o", "", "Output file (default stdout)")
+	path := fs.String("journal", journal.DefaultPath, "Journal file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	client, err := alpaca.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	feeModel, err := fees.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ fee model: %v\n", err)
+		return 1
+	}
+	notes, err := journal.Load(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  [skip] journal: %v\n", err)
+		notes = &journal.Journal{}
+	}
+
+	reporter := report.Reporter{
+		Broker: client,
+		Fees:   feeModel,
+		Notes:  notes,
+		Log:    os.Stderr,
+	}
+	acts, err := reporter.Statement(*from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := report.WriteStatement(w, acts); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ writing statement: %v\n", err)
+		return 1
+	}
+	if *out != "" {
+		fmt.Printf("✓ Wrote %s (%d fills, %s to %s)\n", *out, len(acts), *from, *to)
+	}
+	return 0
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// defaultUniverse is the watchlist a new setup starts from: the broad and
+// sector ETFs and the most heavily traded large caps, liquid enough that
+// every strategy has bars to work with and fills near the quote.
+var defaultUniverse = []string{
+	"SPY", "QQQ", "DIA", "IWM", "XLK", "XLF", "XLE", "XLV", "XLU", "GLD", "TLT",
+	"AAPL", "AMZN", "GOOGL", "META", "MSFT", "NVDA", "TSLA", "AMD", "NFLX",
+	"CRM", "ORCL", "JPM", "BAC", "V", "MA", "UNH", "LLY", "JNJ", "XOM", "CVX",
+	"WMT", "COST", "KO", "PG",
+}
+
+// runInit sets up a fresh checkout or fork: it checks the Alpaca
+// credentials, writes a watchlist of the default symbols Alpaca can trade and
+// an empty blocklist, creates docs/, then runs the first fetch and backtest.
+// Existing config is left alone unless -force is given.
+//
+//	lft2 init                  everything, from the repository root
+//	lft2 init -skip-backtest   config only
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "Repository root")
+	force := fs.Bool("force", false, "Overwrite an existing watchlist.json and blocklist.json")
+	skip := fs.Bool("skip-backtest", false, "Write config only; don't run fetch and backtest")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	fmt.Println("→ credentials")
+	client, err := checkCredentials()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	fmt.Println("→ universe")
+	assets, err := client.Assets()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ listing assets: %v\n", err)
+		return 1
+	}
+	universe := tradable(defaultUniverse, assets)
+	if len(universe) == 0 {
+		fmt.Fprintln(os.Stderr, "✗ Alpaca reports none of the default symbols as tradable")
+		return 1
+	}
+	fmt.Printf("✓ %d of %d default symbols tradable\n", len(universe), len(defaultUniverse))
+
+	fmt.Println("→ config")
+	if err := scaffold(*dir, universe, *force, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	if !*skip {
+		fmt.Println("→ fetch and backtest")
+		cmd := exec.Command("make", "backtest")
+		cmd.Dir, cmd.Stdout, cmd.Stderr = *dir, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ make backtest: %v\n", err)
+			fmt.Fprintln(os.Stderr, "  The backtest needs g++-15; with another C++26 compiler run GCXX=g++ make backtest")
+			return 1
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("Next steps:")
+	if *skip {
+		fmt.Println("  make backtest                  fetch bars and backtest the watchlist")
+	}
+	fmt.Println("  open docs/strategies.html      see which strategies are viable for which symbols")
+	fmt.Println("  edit watchlist.json            add or remove symbols; fetch picks them up next run")
+	fmt.Println("  make run                       run one trading cycle against", client.BaseURL)
+	fmt.Println("  bin/lft2 key                   make a key to encrypt the journal (LFT2_STATE_KEY)")
+	return 0
+}
+
+// checkCredentials confirms the trading and market data keys work: the
+// account must be readable and open for trading, and bars must be readable,
+// since fetch fails on a data subscription the trading keys don't include.
+func checkCredentials() (alpaca.Client, error) {
+	client, err := alpaca.FromEnv()
+	if err != nil {
+		return client, fmt.Errorf("%w (paper keys from https://app.alpaca.markets)", err)
+	}
+
+	account, err := client.Account()
+	if err != nil {
+		return client, fmt.Errorf("reading the account at %s: %w", client.BaseURL, explain(err))
+	}
+	if block := account.TradingBlock(); block != "" {
+		return client, errors.New(block)
+	}
+	mode := "live"
+	if strings.Contains(client.BaseURL, "paper") {
+		mode = "paper"
+	}
+	fmt.Printf("✓ %s account %s, equity $%s\n", mode, account.AccountNumber, account.Equity.Money())
+	if mode == "live" {
+		fmt.Println("  [WARNING] ALPACA_BASE_URL is not the paper endpoint — make run will trade real money")
+	}
+
+	if _, err := client.Get(client.DataURL + "/v2/stocks/SPY/bars?timeframe=1Day&limit=1"); err != nil {
+		return client, fmt.Errorf("reading bars from %s: %w", client.DataURL, explain(err))
+	}
+	fmt.Println("✓ market data")
+	return client, nil
+}
+
+// explain adds what usually causes an authentication failure.
+func explain(err error) error {
+	var se *alpaca.StatusError
+	if errors.As(err, &se) && (se.StatusCode == http.StatusUnauthorized || se.StatusCode == http.StatusForbidden) {
+		return fmt.Errorf("%w (check the key and secret, and that paper keys go with the paper endpoint)", err)
+	}
+	return err
+}
+
+// tradable keeps the symbols Alpaca lists as active and tradable, in order.
+func tradable(symbols []string, assets []alpaca.Asset) []string {
+	ok := map[string]bool{}
+	for _, a := range assets {
+		if a.Tradable && a.Status == "active" {
+			ok[a.Symbol] = true
+		}
+	}
+	var keep []string
+	for _, s := range symbols {
+		if ok[s] {
+			keep = append(keep, s)
+		}
+	}
+	return keep
+}
+
+// scaffold writes watchlist.json and an empty blocklist.json under dir and
+// creates docs/bars, reporting each step to out. Files that exist are kept
+// unless force is set.
+func scaffold(dir string, universe []string, force bool, out io.Writer) error {
+	if err := os.MkdirAll(filepath.Join(dir, "docs", "bars"), 0755); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"watchlist.json", map[string][]string{"symbols": universe}},
+		{"blocklist.json", map[string][]string{"blocked": {}, "allowed": {}}},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if _, err := os.Stat(path); err == nil && !force {
+			fmt.Fprintf(out, "  [skip] %s exists (-force to replace)\n", f.name)
+			continue
+		}
+		data, err := json.MarshalIndent(f.v, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "✓ Wrote %s\n", f.name)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/deanturpin/lft2/internal/vault"
+)
+
+// runKey prints a new random key for LFT2_STATE_KEY. Store it somewhere
+// other than the repo: without it an encrypted journal can't be read.
+func runKey(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: lft2 key")
+		return 2
+	}
+	key, err := vault.NewKey()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	fmt.Println(key)
+	return 0
+}
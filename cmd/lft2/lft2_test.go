@@ -0,0 +1,1126 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/books"
+	"github.com/deanturpin/lft2/internal/events"
+	"github.com/deanturpin/lft2/internal/journal"
+	"github.com/deanturpin/lft2/internal/lock"
+	"github.com/deanturpin/lft2/internal/manifest"
+	"github.com/deanturpin/lft2/internal/report"
+	"github.com/deanturpin/lft2/internal/store"
+	"github.com/deanturpin/lft2/internal/vault"
+)
+
+// --- runNote ---
+
+func TestRunNote_EncryptedJournal(t *testing.T) {
+	key, err := vault.NewKey()
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	t.Setenv("LFT2_STATE_KEY", key)
+	path := filepath.Join(t.TempDir(), "journal.json")
+
+	if code := runNote([]string{"-journal", path, "uuid-1", "quiet"}); code != 0 {
+		t.Fatalf("got exit code %d, want 0", code)
+	}
+	raw, _ := os.ReadFile(path)
+	if !vault.Encrypted(raw) {
+		t.Errorf("journal written in the clear: %s", raw)
+	}
+	if j, err := journal.Load(path); err != nil || len(j.Notes) != 1 {
+		t.Errorf("got %+v, %v", j, err)
+	}
+
+	t.Setenv("LFT2_STATE_KEY", "")
+	if code := runNote([]string{"-journal", path}); code != 1 {
+		t.Errorf("without the key: got exit code %d, want 1", code)
+	}
+}
+
+func TestRunNote_AddsAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+
+	if code := runNote([]string{"-journal", path, "uuid-1", "chased", "the", "gap"}); code != 0 {
+		t.Fatalf("got exit code %d, want 0", code)
+	}
+
+	j, err := journal.Load(path)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if len(j.Notes) != 1 || j.Notes[0].Text != "chased the gap" {
+		t.Errorf("got %+v", j.Notes)
+	}
+}
+
+func TestRunNote_ListDoesNotWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	if code := runNote([]string{"-journal", path, "uuid-1"}); code != 0 {
+		t.Fatalf("got exit code %d, want 0", code)
+	}
+	if j, _ := journal.Load(path); len(j.Notes) != 0 {
+		t.Errorf("listing should not add notes, got %+v", j.Notes)
+	}
+}
+
+// --- runInit ---
+
+// fakeAlpaca answers the account, assets and bars requests init makes.
+func fakeAlpaca(t *testing.T, status int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			http.Error(w, `{"message":"forbidden"}`, status)
+			return
+		}
+		var v any
+		switch r.URL.Path {
+		case "/v2/account":
+			v = map[string]any{"account_number": "PA123", "status": "ACTIVE", "equity": "100000"}
+		case "/v2/assets":
+			v = []map[string]any{
+				{"symbol": "SPY", "status": "active", "tradable": true},
+				{"symbol": "AAPL", "status": "active", "tradable": true},
+				{"symbol": "TSLA", "status": "active", "tradable": false},
+			}
+		case "/v2/stocks/SPY/bars":
+			v = map[string]any{"bars": []any{}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(v)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRunInit_WritesConfig(t *testing.T) {
+	srv := fakeAlpaca(t, http.StatusOK)
+	t.Setenv("ALPACA_API_KEY", "key")
+	t.Setenv("ALPACA_API_SECRET", "secret")
+	t.Setenv("ALPACA_BASE_URL", srv.URL)
+	t.Setenv("ALPACA_DATA_URL", srv.URL)
+	dir := t.TempDir()
+
+	if code := runInit([]string{"-dir", dir, "-skip-backtest"}); code != 0 {
+		t.Fatalf("got exit code %d, want 0", code)
+	}
+	var watchlist struct {
+		Symbols []string `json:"symbols"`
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "watchlist.json"))
+	if err := json.Unmarshal(data, &watchlist); err != nil || strings.Join(watchlist.Symbols, ",") != "SPY,AAPL" {
+		t.Errorf("watchlist: got %s, %v; want the tradable defaults SPY and AAPL", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "blocklist.json")); err != nil {
+		t.Error(err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "docs", "bars")); err != nil || !info.IsDir() {
+		t.Errorf("docs/bars not created: %v", err)
+	}
+}
+
+func TestRunInit_BadCredentials(t *testing.T) {
+	srv := fakeAlpaca(t, http.StatusUnauthorized)
+	t.Setenv("ALPACA_API_KEY", "key")
+	t.Setenv("ALPACA_API_SECRET", "wrong")
+	t.Setenv("ALPACA_BASE_URL", srv.URL)
+	dir := t.TempDir()
+
+	if code := runInit([]string{"-dir", dir, "-skip-backtest"}); code != 1 {
+		t.Errorf("got exit code %d, want 1", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "watchlist.json")); err == nil {
+		t.Error("wrote config despite rejected credentials")
+	}
+}
+
+func TestScaffold_KeepsExistingConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "watchlist.json")
+	os.WriteFile(path, []byte(`{"symbols": ["MINE"]}`), 0644)
+
+	var out strings.Builder
+	if err := scaffold(dir, []string{"SPY"}, false, &out); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "MINE") {
+		t.Errorf("existing watchlist replaced: %s", data)
+	}
+	if !strings.Contains(out.String(), "[skip] watchlist.json exists") {
+		t.Errorf("got %q", out.String())
+	}
+
+	if err := scaffold(dir, []string{"SPY"}, true, &out); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "SPY") {
+		t.Errorf("-force: got %s", data)
+	}
+}
+
+// --- runListen ---
+
+// event encodes an event as the bus carries it.
+func event(t *testing.T, kind, key string, data any) []byte {
+	t.Helper()
+	bus := &capture{}
+	if err := events.Publish(bus, kind, key, data, time.Date(2026, 3, 10, 15, 5, 40, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	return bus.data
+}
+
+// capture is an events.Bus that keeps the last message published.
+type capture struct{ data []byte }
+
+func (c *capture) Publish(_ string, data []byte) error                { c.data = data; return nil }
+func (c *capture) Subscribe(string, func(string, []byte) error) error { return nil }
+func (c *capture) Close() error                                       { return nil }
+
+func TestListener(t *testing.T) {
+	dir := t.TempDir()
+	var out, log strings.Builder
+	l := &listener{bars: dir, manifestPath: filepath.Join(dir, "manifest.json"), out: &out, log: &log}
+
+	bars := []byte(`{"symbol":"AAPL","bars":[{"t":"2026-03-10T15:00:00Z","c":100}]}`)
+	for _, msg := range [][]byte{
+		event(t, events.Bars, "AAPL", bars),
+		event(t, events.Bars, "../etc", bars), // Not a symbol
+		event(t, events.Fills, "AAPL", map[string]string{"side": "buy"}),
+		[]byte(`not json`),
+	} {
+		if err := l.handle("lft2.test", msg); err != nil {
+			t.Fatalf("handle: %v", err)
+		}
+	}
+
+	if got, _ := os.ReadFile(filepath.Join(dir, "AAPL.json")); string(got) != string(bars) {
+		t.Errorf("bar file: got %s", got)
+	}
+	data, err := os.ReadFile(l.manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := manifest.Parse(l.manifestPath, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Timestamp != "2026-03-10T15:05:40Z" || m.Verify("AAPL", bars) != "" || len(m.Symbols) != 1 {
+		t.Errorf("manifest: got %+v", m)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "etc.json")); !os.IsNotExist(err) {
+		t.Error("wrote bars outside the bars directory")
+	}
+	if n := strings.Count(log.String(), "\n"); n != 1 || !strings.Contains(log.String(), `"kind":"fills"`) {
+		t.Errorf("log: got %q", log.String())
+	}
+	if !strings.Contains(out.String(), "[skip] bars for unusable symbol") {
+		t.Errorf("output: %s", out.String())
+	}
+}
+
+func TestRunListen_NoBus(t *testing.T) {
+	t.Setenv("LFT2_EVENT_BUS", "")
+	if code := runListen(nil); code != 1 {
+		t.Errorf("got exit code %d, want 1", code)
+	}
+}
+
+// --- runMerge ---
+
+// writeShard writes shard i/n's outputs as backtest --shard does; each
+// trade is "SYMBOL strategy timestamp return".
+func writeShard(t *testing.T, dir string, i, n int, seed uint64, recs []string, trades [][4]string) {
+	t.Helper()
+	header := fmt.Sprintf(`"schema_version": 1, "timestamp": "2026-03-1%dT21:00:00Z", "seed": %d, "shard": "%d/%d", `, i, seed, i, n)
+	strategies := "{" + header + `"fill_sampled": 0, "recommendations": [` + strings.Join(recs, ",") + "]}\n"
+
+	type curve struct {
+		Strategy   string    `json:"strategy"`
+		Timestamps []string  `json:"timestamps"`
+		Equity     []float64 `json:"equity"`
+		Symbols    []string  `json:"symbols"`
+	}
+	var curves []*curve
+	by := map[string]*curve{}
+	for _, tr := range trades {
+		c, ok := by[tr[1]]
+		if !ok {
+			c = &curve{Strategy: tr[1]}
+			by[tr[1]] = c
+			curves = append(curves, c)
+		}
+		ret, _ := strconv.ParseFloat(tr[3], 64)
+		equity := 1.0
+		if len(c.Equity) > 0 {
+			equity = c.Equity[len(c.Equity)-1]
+		}
+		c.Timestamps = append(c.Timestamps, tr[2])
+		c.Equity = append(c.Equity, equity+ret)
+		c.Symbols = append(c.Symbols, tr[0])
+	}
+	body, _ := json.Marshal(curves)
+	equity := "{" + header + `"curves": ` + string(body) + "}\n"
+
+	suffix := fmt.Sprintf("%d-of-%d.json", i, n)
+	writeTestFile(t, filepath.Join(dir, "strategies-"+suffix), strategies)
+	writeTestFile(t, filepath.Join(dir, "equity-curves-"+suffix), equity)
+}
+
+func writeTestFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func rec(symbol, strategy string, viable bool, winRate float64) string {
+	return fmt.Sprintf(`{"symbol": "%s", "strategy": "%s", "viable": %t, "win_rate": %.3f, "avg_profit": 0.0012}`,
+		symbol, strategy, viable, winRate)
+}
+
+func TestRunMerge(t *testing.T) {
+	dir := t.TempDir()
+	shards := filepath.Join(dir, "shards")
+	if err := os.MkdirAll(shards, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeShard(t, shards, 1, 2, 7,
+		[]string{rec("AAPL", "dip", false, 0.4), rec("AAPL", "breakout", true, 0.6), rec("MSFT", "dip", true, 0.55)},
+		[][4]string{
+			{"AAPL", "dip", "2026-03-02T15:00:00Z", "0.02"},
+			{"MSFT", "dip", "2026-03-04T15:00:00Z", "-0.05"},
+			{"AAPL", "breakout", "2026-03-03T15:00:00Z", "0.01"},
+		})
+	writeShard(t, shards, 2, 2, 7,
+		[]string{rec("AMD", "dip", true, 0.7)},
+		[][4]string{
+			{"AMD", "dip", "2026-03-02T15:00:00Z", "0.03"},
+			{"AMD", "dip", "2026-03-03T15:00:00Z", "-0.01"},
+		})
+
+	if code := runMerge([]string{"-dir", shards, "-o", dir}); code != 0 {
+		t.Fatalf("got exit code %d, want 0", code)
+	}
+
+	var strategies struct {
+		Seed            uint64 `json:"seed"`
+		Shards          int    `json:"shards"`
+		Timestamp       string `json:"timestamp"`
+		Recommendations []struct {
+			Symbol    string  `json:"symbol"`
+			Strategy  string  `json:"strategy"`
+			AvgProfit float64 `json:"avg_profit"`
+		} `json:"recommendations"`
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "strategies.json"))
+	if err := json.Unmarshal(data, &strategies); err != nil {
+		t.Fatalf("strategies.json: %v", err)
+	}
+	var order []string
+	for _, r := range strategies.Recommendations {
+		order = append(order, r.Symbol+" "+r.Strategy)
+	}
+	if got, want := strings.Join(order, ", "), "AAPL breakout, AAPL dip, AMD dip, MSFT dip"; got != want {
+		t.Errorf("order: got %s, want %s", got, want)
+	}
+	if strategies.Seed != 7 || strategies.Shards != 2 || strategies.Timestamp != "2026-03-12T21:00:00Z" {
+		t.Errorf("header: got seed %d, %d shards, %s", strategies.Seed, strategies.Shards, strategies.Timestamp)
+	}
+	if !strings.Contains(string(data), `"avg_profit": 0.0012`) {
+		t.Errorf("recommendation fields not kept as written:\n%s", data)
+	}
+
+	curves, err := report.LoadEquityCurves(filepath.Join(dir, "equity-curves.json"))
+	if err != nil || len(curves) != 2 {
+		t.Fatalf("got %+v, %v; want two curves", curves, err)
+	}
+	dip := curves[1]
+	// AAPL and AMD tie on the 2nd (AAPL first), then AMD, then MSFT
+	if dip.Strategy != "dip" || fmt.Sprint(dip.Equity) != "[1.02 1.05 1.04 0.99]" {
+		t.Errorf("dip curve: got %s %v", dip.Strategy, dip.Equity)
+	}
+	if dip.Trades != 4 || dip.FinalEquity != 0.99 || dip.MaxDrawdown != 0.0571 {
+		t.Errorf("dip: got %d trades, final %g, drawdown %g", dip.Trades, dip.FinalEquity, dip.MaxDrawdown)
+	}
+}
+
+func TestMergeShards_Rejects(t *testing.T) {
+	cases := map[string]func(dir string){
+		"shard 2/3 missing": func(dir string) {
+			writeShard(t, dir, 1, 3, 0, nil, nil)
+			writeShard(t, dir, 3, 3, 0, nil, nil)
+		},
+		"seed": func(dir string) {
+			writeShard(t, dir, 1, 2, 0, nil, nil)
+			writeShard(t, dir, 2, 2, 1, nil, nil)
+		},
+		"same candidates.json": func(dir string) {
+			writeShard(t, dir, 1, 2, 0, []string{rec("AAPL", "dip", true, 0.6)}, nil)
+			writeShard(t, dir, 2, 2, 0, []string{rec("AAPL", "breakout", true, 0.6)}, nil)
+		},
+	}
+	for want, setup := range cases {
+		dir := t.TempDir()
+		setup(dir)
+		strategies, curves, err := loadShards(dir)
+		if err == nil {
+			_, _, err = mergeShards(strategies, curves)
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want an error mentioning %q", err, want)
+		}
+	}
+
+	if _, _, err := loadShards(t.TempDir()); err == nil {
+		t.Error("empty directory merged")
+	}
+}
+
+// --- runWhatIf ---
+
+// fill is a journal activity at minute m of 10 March 2026.
+func fill(id, side string, m int, qty, price float64) report.Activity {
+	return report.Activity{
+		TransactTime:  time.Date(2026, 3, 10, 14, m, 0, 0, time.UTC).Format(time.RFC3339),
+		Symbol:        strings.SplitN(id, "_", 2)[0],
+		Qty:           alpaca.Decimal(qty),
+		Price:         alpaca.Decimal(price),
+		Value:         alpaca.Decimal(qty * price),
+		Side:          side,
+		ClientOrderID: id,
+	}
+}
+
+func TestRoundTrips(t *testing.T) {
+	acts := []report.Activity{
+		fill("AAPL_a", "buy", 0, 10, 100),
+		fill("MSFT_b", "buy", 1, 4, 250),
+		fill("MSFT_b", "sell", 2, 2, 240),
+		fill("AAPL_a", "sell", 3, 10, 110),
+		fill("TSLA_c", "sell", 4, 5, 200), // Opened before the range
+		fill("NVDA_d", "buy", 5, 1, 900),
+	}
+	acts[3].Fee = 1
+
+	trades, open := roundTrips(acts)
+	if open != 2 {
+		t.Errorf("open: got %d, want MSFT half sold and NVDA", open)
+	}
+	if len(trades) != 1 || trades[0].Symbol != "AAPL" {
+		t.Fatalf("got %+v, want AAPL alone", trades)
+	}
+	if got := trades[0].Return; math.Abs(got-0.099) > 1e-9 {
+		t.Errorf("AAPL return: got %g, want 9.9%% after the $1 fee", got)
+	}
+}
+
+func TestKellyFraction(t *testing.T) {
+	trips := func(returns ...float64) []trade {
+		var out []trade
+		for _, r := range returns {
+			out = append(out, trade{Return: r})
+		}
+		return out
+	}
+	// W 0.6, R 1: 0.6 - 0.4
+	if got := kellyFraction(trips(0.02, 0.02, 0.02, -0.02, -0.02)); math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("even payoff: got %g, want 0.2", got)
+	}
+	if got := kellyFraction(trips(0.01, -0.03, -0.03)); got != 0 {
+		t.Errorf("no edge: got %g, want 0", got)
+	}
+	if got := kellyFraction(trips(0.01, 0.02)); got != 1 {
+		t.Errorf("never lost: got %g, want 1", got)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	at := func(m int) time.Time { return time.Date(2026, 3, 10, 14, m, 0, 0, time.UTC) }
+	trades := []trade{
+		{Symbol: "AAPL", Opened: at(0), Closed: at(10), Cost: 1000, Return: 0.10},
+		{Symbol: "MSFT", Opened: at(5), Closed: at(20), Cost: 1000, Return: -0.05},
+		{Symbol: "TSLA", Opened: at(10), Closed: at(30), Cost: 1000, Return: 0.20},
+	}
+	rules := sizingRules(0.5, 2, 0.5)
+
+	actual := replay(trades, rules[0], 10000)
+	if actual.FinalEquity != 10250 || actual.Traded != 3 {
+		t.Errorf("actual: got %+v, want the $250 made", actual)
+	}
+
+	// Half the equity per trade: MSFT's entry takes the rest of the cash, and
+	// AAPL's exit at the same minute frees it for TSLA, sized from 10500
+	fixed := replay(trades, rules[1], 10000)
+	if fixed.FinalEquity != 11300 || fixed.Traded != 3 || fixed.Skipped != 0 {
+		t.Errorf("fixed: got %+v, want 10000 + 500 - 250 + 1050", fixed)
+	}
+	if len(fixed.Equity) != 3 || fixed.Equity[0] != 10500 || fixed.Equity[1] != 10250 {
+		t.Errorf("fixed curve: got %v", fixed.Equity)
+	}
+	if got := fixed.MaxDrawdown; math.Abs(got-round4(250.0/10500)) > 1e-9 {
+		t.Errorf("fixed drawdown: got %g", got)
+	}
+
+	// Two slots: MSFT gets half of 10000 at cost, the same as fixed here
+	if equal := replay(trades, rules[2], 10000); equal.FinalEquity != fixed.FinalEquity {
+		t.Errorf("equal weight: got %g, want %g", equal.FinalEquity, fixed.FinalEquity)
+	}
+
+	// Too few trades for an estimate, so Kelly sizes as fixed
+	if k := replay(trades, rules[3], 10000); k.FinalEquity != fixed.FinalEquity {
+		t.Errorf("Kelly before %d trades: got %g, want %g", kellyMinTrades, k.FinalEquity, fixed.FinalEquity)
+	}
+}
+
+func TestReplay_KellyStopsWithoutEdge(t *testing.T) {
+	var trades []trade
+	for i := 0; i < kellyMinTrades+2; i++ {
+		at := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Hour)
+		trades = append(trades, trade{Opened: at, Closed: at.Add(time.Minute), Cost: 1000, Return: -0.01})
+	}
+	k := replay(trades, sizingRules(0.02, 10, 0.5)[3], 10000)
+	if k.Traded != kellyMinTrades || k.Skipped != 2 {
+		t.Errorf("got %d traded, %d skipped; want Kelly to stop after %d losers", k.Traded, k.Skipped, kellyMinTrades)
+	}
+}
+
+// --- runPromote ---
+
+func TestChanged(t *testing.T) {
+	live := []candidate{
+		{Symbol: "AAPL", Strategy: "gap_fill", Version: 2, ParamsHash: "deadbeef"},
+		{Symbol: "MSFT", Strategy: "dip_buy", ParamsHash: "0000abcd"}, // Unversioned, so v1
+	}
+	got := changed([]candidate{
+		{Symbol: "AAPL", Strategy: "gap_fill", Version: 2, ParamsHash: "deadbeef"},
+		{Symbol: "AAPL", Strategy: "gap_fill", Version: 2, ParamsHash: "feedface"}, // Re-tuned
+		{Symbol: "MSFT", Strategy: "dip_buy", Version: 1, ParamsHash: "0000abcd"},
+		{Symbol: "MSFT", Strategy: "dip_buy", Version: 2, ParamsHash: "0000abcd"},  // New logic
+		{Symbol: "NVDA", Strategy: "momentum", Version: 1, ParamsHash: "12345678"}, // New symbol
+	}, live)
+	if len(got) != 3 || got[0].ParamsHash != "feedface" || got[1].Version != 2 || got[2].Symbol != "NVDA" {
+		t.Errorf("got %+v, want the re-tune, the new version and NVDA", got)
+	}
+}
+
+func TestJudge(t *testing.T) {
+	id := func(symbol, hash string) string {
+		return symbol + "_gap_fill-v2_tp1.25_sl1.25_tsl1.00_p" + hash + "_1741617300"
+	}
+	var trades []trade
+	for _, r := range []float64{0.01, 0.02, 0.015} {
+		trades = append(trades, trade{Symbol: "AAPL", OrderID: id("AAPL", "deadbeef"), Return: r})
+		trades = append(trades, trade{Symbol: "MSFT", OrderID: id("MSFT", "deadbeef"), Return: -r})
+	}
+	trades = append(trades,
+		trade{Symbol: "AAPL", OrderID: id("AAPL", "feedface"), Return: 0.5}, // Other parameters
+		trade{Symbol: "AAPL", OrderID: "manual-1", Return: 0.5},
+	)
+	changes := []candidate{
+		{Symbol: "NVDA", Strategy: "gap_fill", Version: 2, ParamsHash: "deadbeef", AvgProfit: 0.01},
+		{Symbol: "MSFT", Strategy: "gap_fill", Version: 2, ParamsHash: "deadbeef", AvgProfit: 0.01},
+		{Symbol: "AAPL", Strategy: "gap_fill", Version: 2, ParamsHash: "deadbeef", AvgProfit: 0.012},
+	}
+
+	got := judge(changes, trades, 3, 0.005)
+	if len(got) != 3 || got[0].Symbol != "AAPL" || got[1].Symbol != "MSFT" || got[2].Symbol != "NVDA" {
+		t.Fatalf("got %+v, want AAPL, MSFT, NVDA", got)
+	}
+	if aapl := got[0]; !aapl.Passed || aapl.Trades != 3 || aapl.Observed != 0.015 {
+		t.Errorf("AAPL: got %+v, want passed on 3 trades at 1.5%%", aapl)
+	}
+	if msft := got[1]; msft.Passed || !strings.Contains(msft.Reason, "more than 0.50% apart") {
+		t.Errorf("MSFT: got %+v, want failed on divergence", msft)
+	}
+	if nvda := got[2]; nvda.Passed || nvda.Reason != "0 paper trade(s), need 3" {
+		t.Errorf("NVDA: got %+v, want failed on too few trades", nvda)
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	change := func(symbol string, passed bool, trades int) report.Promotion {
+		return report.Promotion{Symbol: symbol, Strategy: "gap_fill-v2", ParamsHash: "deadbeef", Passed: passed, Trades: trades, Reason: "r"}
+	}
+	prev := &report.Promotions{Changes: []report.Promotion{
+		{Symbol: "AAPL", Strategy: "gap_fill-v2", ParamsHash: "deadbeef", Status: report.Shadow, Since: "2026-03-01"},
+		{Symbol: "MSFT", Strategy: "gap_fill-v2", ParamsHash: "deadbeef", Status: report.Shadow, Since: "2026-03-08"},
+		{Symbol: "NVDA", Strategy: "gap_fill-v2", ParamsHash: "deadbeef", Status: report.Promoted, Since: "2026-02-01", PromotedOn: "2026-02-11"},
+		{Symbol: "AMD", Strategy: "gap_fill-v2", ParamsHash: "deadbeef", Status: report.Shadow, Since: "2026-03-01"},
+	}}
+	got := advance([]report.Promotion{
+		change("AAPL", true, 5),  // Day 10 of 10
+		change("MSFT", true, 5),  // Day 3
+		change("NVDA", false, 5), // Promoted before, now off
+		change("AMD", false, 5),  // Diverged
+		change("TSLA", false, 1), // New, few trades
+	}, prev, "2026-03-10", 10, 3)
+
+	want := []struct{ status, since, promoted string }{
+		{report.Promoted, "2026-03-01", "2026-03-10"},
+		{report.Shadow, "2026-03-08", ""},
+		{report.Promoted, "2026-02-01", "2026-02-11"},
+		{report.Diverged, "2026-03-01", ""},
+		{report.Shadow, "2026-03-10", ""},
+	}
+	for i, w := range want {
+		if g := got[i]; g.Status != w.status || g.Since != w.since || g.PromotedOn != w.promoted {
+			t.Errorf("%s: got %s since %s promoted %q, want %s since %s promoted %q",
+				g.Symbol, g.Status, g.Since, g.PromotedOn, w.status, w.since, w.promoted)
+		}
+	}
+	if got[1].Reason != "day 3 of 10 on paper" {
+		t.Errorf("MSFT reason: got %q", got[1].Reason)
+	}
+}
+
+func TestRunPromote(t *testing.T) {
+	dir := t.TempDir()
+	strategies := filepath.Join(dir, "strategies.json")
+	os.WriteFile(strategies, []byte(`{"recommendations": [
+		{"symbol": "AAPL", "strategy": "gap_fill", "version": 2, "params_hash": "deadbeef", "avg_profit": 0.01, "viable": true},
+		{"symbol": "MSFT", "strategy": "gap_fill", "version": 2, "params_hash": "deadbeef", "avg_profit": -0.01, "viable": false}
+	]}`), 0644)
+
+	// Live already trades everything viable, so there's nothing to check
+	if code := runPromote([]string{"-strategies", strategies, "-live", strategies}); code != 0 {
+		t.Errorf("no changes: got exit code %d, want 0", code)
+	}
+
+	t.Setenv("ALPACA_API_KEY", "key")
+	t.Setenv("ALPACA_API_SECRET", "secret")
+	t.Setenv("ALPACA_BASE_URL", "https://api.alpaca.markets")
+	if code := runPromote([]string{"-strategies", strategies}); code != 1 {
+		t.Errorf("live endpoint: got exit code %d, want 1", code)
+	}
+	if code := runPromote([]string{"-strategies", strategies, "-min-trades", "0"}); code != 2 {
+		t.Errorf("bad flag: got exit code %d, want 2", code)
+	}
+}
+
+// --- runDecay ---
+
+func TestWilsonLower(t *testing.T) {
+	if got := wilsonLower(0.6, 100, 1.645); math.Abs(got-0.5181) > 1e-3 {
+		t.Errorf("60%% of 100: got %.4f, want 0.5181", got)
+	}
+	if few, many := wilsonLower(0.6, 10, 1.645), wilsonLower(0.6, 1000, 1.645); few >= many || many >= 0.6 {
+		t.Errorf("got %.4f over 10 and %.4f over 1000, want the bound to tighten below 0.6", few, many)
+	}
+	if got := wilsonLower(0, 5, 1.645); got != 0 {
+		t.Errorf("no wins: got %.4f, want 0", got)
+	}
+	if got := wilsonLower(0.6, 0, 1.645); got != 0 {
+		t.Errorf("no trades: got %.4f, want 0", got)
+	}
+}
+
+func TestDecay(t *testing.T) {
+	at := func(day string) time.Time {
+		d, _ := time.Parse(time.DateOnly, day)
+		return d.Add(16 * time.Hour) // Mid-session in New York
+	}
+	id := func(strategy string) string {
+		return "AAPL_" + strategy + "_tp1.25_sl1.25_tsl1.00_pdeadbeef_1741617300"
+	}
+	var trades []trade
+	add := func(strategy, day string, returns ...float64) {
+		for _, r := range returns {
+			trades = append(trades, trade{Symbol: "AAPL", OrderID: id(strategy), Closed: at(day), Return: r})
+		}
+	}
+	add("gap_fill-v2", "2026-03-05", -0.01, -0.01, -0.01, -0.01, 0.02) // Below for all 3 days
+	add("dip_buy", "2026-03-05", 0.01, 0.01, 0.01, 0.01)               // Above
+	add("momentum", "2026-03-09", -0.01, -0.01, -0.01, -0.01)          // Below for 2 days
+	add("breakout", "2026-03-10", -0.01)                               // Too few to judge
+	add("gap_fill-v2", "2026-01-05", 0.02, 0.02, 0.02, 0.02, 0.02)     // Before the window
+
+	bound := backtestRate{WinRate: 0.65, Trades: 200, Lower: 0.6}
+	bounds := map[string]backtestRate{"gap_fill-v2": bound, "dip_buy-v1": bound, "momentum-v1": bound, "breakout-v1": bound}
+	got := decay(trades, bounds, report.StrategyMap{}, "2026-03-10", 30, 3, 4)
+
+	want := []struct {
+		strategy    string
+		trades      int
+		below       int
+		decaying    bool
+		liveWinRate float64
+	}{
+		{"breakout-v1", 1, 0, false, 0},
+		{"dip_buy-v1", 4, 0, false, 1},
+		{"gap_fill-v2", 5, 3, true, 0.2},
+		{"momentum-v1", 4, 2, false, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %d strategies", got, len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Strategy != w.strategy || g.LiveTrades != w.trades || g.DaysBelow != w.below || g.Decaying != w.decaying || g.LiveRate != w.liveWinRate {
+			t.Errorf("got %+v, want %+v", g, w)
+		}
+	}
+}
+
+func TestNewlyDecaying(t *testing.T) {
+	results := []strategyDecay{
+		{Strategy: "gap_fill-v2", Decaying: true},
+		{Strategy: "dip_buy-v1", Decaying: true},
+		{Strategy: "momentum-v1"},
+	}
+	if got := newlyDecaying(results, nil); len(got) != 2 {
+		t.Errorf("no previous run: got %+v, want both decaying", got)
+	}
+	prev := &decayReport{Strategies: []strategyDecay{
+		{Strategy: "gap_fill-v2", Decaying: true}, // Already alerted
+		{Strategy: "dip_buy-v1"},
+	}}
+	if got := newlyDecaying(results, prev); len(got) != 1 || got[0].Strategy != "dip_buy-v1" {
+		t.Errorf("got %+v, want dip_buy-v1 alone", got)
+	}
+}
+
+func TestBacktestBounds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "strategies.json")
+	os.WriteFile(path, []byte(`{"recommendations": [
+		{"symbol": "AAPL", "strategy": "gap_fill", "version": 2, "win_rate": 0.7, "trade_count": 30, "viable": true},
+		{"symbol": "MSFT", "strategy": "gap_fill", "version": 2, "win_rate": 0.5, "trade_count": 10, "viable": true},
+		{"symbol": "NVDA", "strategy": "gap_fill", "version": 2, "win_rate": 0.1, "trade_count": 50, "viable": false}
+	]}`), 0644)
+
+	got, err := backtestBounds(path, 1.645)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ok := got["gap_fill-v2"]
+	if len(got) != 1 || !ok || b.Trades != 40 || math.Abs(b.WinRate-0.65) > 1e-9 {
+		t.Fatalf("got %+v, want gap_fill-v2 at 65%% over 40 trades", got)
+	}
+	if b.Lower != wilsonLower(0.65, 40, 1.645) {
+		t.Errorf("got lower bound %.4f", b.Lower)
+	}
+}
+
+func TestRunDecay_BadFlags(t *testing.T) {
+	if code := runDecay([]string{"-days", "0"}); code != 2 {
+		t.Errorf("got exit code %d, want 2", code)
+	}
+}
+
+// --- runTry ---
+
+func TestTryArgs(t *testing.T) {
+	got := tryArgs("momentum", "AAPL", 7, map[string]float64{"--tp": 2, "--sl": 0, "--tsl": 0.75})
+	want := "--try momentum AAPL --seed 7 --tp 2 --tsl 0.75"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestRunTry(t *testing.T) {
+	dir := t.TempDir()
+	logged := filepath.Join(dir, "args")
+	backtest := filepath.Join(dir, "backtest")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\n[ \"$2\" = momentum ] || exit 1\n", logged)
+	if err := os.WriteFile(backtest, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := runTry([]string{"-backtest", backtest, "-sl", "1", "momentum", "AAPL"}); code != 0 {
+		t.Errorf("got exit code %d, want 0", code)
+	}
+	if data, _ := os.ReadFile(logged); string(data) != "--try momentum AAPL --seed 0 --sl 1\n" {
+		t.Errorf("backtest got %q", data)
+	}
+	if code := runTry([]string{"-backtest", backtest, "nope", "AAPL"}); code != 1 {
+		t.Errorf("backtest failed: got exit code %d, want its 1", code)
+	}
+	if code := runTry([]string{"-backtest", filepath.Join(dir, "missing"), "momentum", "AAPL"}); code != 1 {
+		t.Errorf("no binary: got exit code %d, want 1", code)
+	}
+	if code := runTry([]string{"-backtest", backtest, "-tp", "150", "momentum", "AAPL"}); code != 2 {
+		t.Errorf("bad level: got exit code %d, want 2", code)
+	}
+	if code := runTry([]string{"-backtest", backtest, "momentum"}); code != 2 {
+		t.Errorf("no symbol: got exit code %d, want 2", code)
+	}
+}
+
+// --- runBooks ---
+
+func TestRunBooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "books.json")
+	if code := runBooks([]string{"-f", path, "-names"}); code != 0 {
+		t.Errorf("no books.json: got exit code %d, want 0", code)
+	}
+	os.WriteFile(path, []byte(`{"books": [
+		{"name": "swing", "timeframe": 60, "strategies": ["momentum"], "budget": 0.7},
+		{"name": "scalp", "timeframe": 5, "strategies": ["momentum"], "budget": 0.2}
+	]}`), 0644)
+	if code := runBooks([]string{"-f", path}); code != 1 {
+		t.Errorf("a strategy in two books: got exit code %d, want 1", code)
+	}
+}
+
+func TestPrintBooks(t *testing.T) {
+	var b strings.Builder
+	printBooks(&b, books.Config{Books: []books.Book{
+		{Name: "swing", TimeframeMin: 60, Strategies: []string{"momentum", "breakout"}, Budget: 0.25},
+	}})
+	out := b.String()
+	for _, want := range []string{"swing", "60m", "25%", "docs/books/swing/", "momentum, breakout", "(default)", "75%"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+// --- runStore ---
+
+func TestRunStore(t *testing.T) {
+	t.Setenv(store.Env, "")
+	if code := runStore(nil); code != 2 {
+		t.Errorf("no store: got exit code %d, want 2", code)
+	}
+	if code := runStore([]string{"vacuum", "-db", filepath.Join(t.TempDir(), "lft2.db")}); code != 2 {
+		t.Errorf("unknown action: got exit code %d, want 2", code)
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+var pruneNow = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// --- runPrune ---
+
+func TestSplitBars(t *testing.T) {
+	bars := []barfile.Bar{
+		{Timestamp: "2024-01-01T14:30:00Z"},
+		{Timestamp: "2024-02-01T14:30:00Z"},
+		{Timestamp: "2024-02-20T14:30:00Z"},
+	}
+	old, keep := splitBars(bars, time.Date(2024, 2, 1, 14, 30, 0, 0, time.UTC))
+	if len(old) != 1 || len(keep) != 2 {
+		t.Fatalf("got old=%d keep=%d, want 1/2", len(old), len(keep))
+	}
+	if keep[0].Timestamp != "2024-02-01T14:30:00Z" {
+		t.Errorf("bar at cutoff should be kept, got %q", keep[0].Timestamp)
+	}
+}
+
+func TestSplitBars_AllOld(t *testing.T) {
+	bars := []barfile.Bar{{Timestamp: "2024-01-01T14:30:00Z"}}
+	old, keep := splitBars(bars, pruneNow)
+	if len(old) != 1 || len(keep) != 0 {
+		t.Errorf("got old=%d keep=%d, want 1/0", len(old), len(keep))
+	}
+}
+
+func TestPruneFile_ArchivesOldBars(t *testing.T) {
+	dir := t.TempDir()
+	cfg := pruneConfig{BarsDir: dir, ArchiveDir: filepath.Join(dir, "archive"), KeepDays: 14, StaleDays: 14}
+	path := writePruneBars(t, dir, "AAPL", "2024-01-10T14:30:00Z", "2024-02-25T14:30:00Z")
+
+	n, retired, err := pruneFile(cfg, path, pruneNow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 1 || retired {
+		t.Errorf("got archived=%d retired=%v, want 1/false", n, retired)
+	}
+
+	var live barfile.Data
+	readPruneJSON(t, path, &live)
+	if live.Count != 1 || len(live.Bars) != 1 {
+		t.Errorf("live file: count=%d bars=%d, want 1/1", live.Count, len(live.Bars))
+	}
+
+	archived := readArchive(t, filepath.Join(cfg.ArchiveDir, "AAPL.json.gz"))
+	if archived.Count != 1 || archived.Bars[0].Timestamp != "2024-01-10T14:30:00Z" {
+		t.Errorf("archive: %+v", archived)
+	}
+}
+
+func TestPruneFile_RetiresStaleSymbol(t *testing.T) {
+	dir := t.TempDir()
+	cfg := pruneConfig{BarsDir: dir, ArchiveDir: filepath.Join(dir, "archive"), KeepDays: 42, StaleDays: 14}
+	path := writePruneBars(t, dir, "OLD", "2024-01-10T14:30:00Z", "2024-01-11T14:30:00Z")
+
+	n, retired, err := pruneFile(cfg, path, pruneNow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 || !retired {
+		t.Errorf("got archived=%d retired=%v, want 2/true", n, retired)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("retired file should be removed")
+	}
+}
+
+func TestPruneFile_DryRunChangesNothing(t *testing.T) {
+	dir := t.TempDir()
+	cfg := pruneConfig{BarsDir: dir, ArchiveDir: filepath.Join(dir, "archive"), KeepDays: 14, DryRun: true}
+	path := writePruneBars(t, dir, "AAPL", "2024-01-10T14:30:00Z", "2024-02-25T14:30:00Z")
+
+	n, _, err := pruneFile(cfg, path, pruneNow)
+	if err != nil || n != 1 {
+		t.Fatalf("got n=%d err=%v, want 1/nil", n, err)
+	}
+	var live barfile.Data
+	readPruneJSON(t, path, &live)
+	if len(live.Bars) != 2 {
+		t.Errorf("dry run modified live file: %d bars", len(live.Bars))
+	}
+	if _, err := os.Stat(cfg.ArchiveDir); !os.IsNotExist(err) {
+		t.Error("dry run created archive directory")
+	}
+}
+
+func TestPruneFile_KeepsGzip(t *testing.T) {
+	dir := t.TempDir()
+	cfg := pruneConfig{BarsDir: dir, ArchiveDir: filepath.Join(dir, "archive"), KeepDays: 14, StaleDays: 14}
+	path := filepath.Join(dir, "AAPL.json.gz")
+	data := barfile.Data{Symbol: "AAPL", Count: 2, Bars: []barfile.Bar{
+		{Timestamp: "2024-01-10T14:30:00Z", Close: 100},
+		{Timestamp: "2024-02-25T14:30:00Z", Close: 100},
+	}}
+	if err := writeJSON(path, &data); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, _, err := pruneFile(cfg, path, pruneNow); err != nil || n != 1 {
+		t.Fatalf("got n=%d err=%v, want 1/nil", n, err)
+	}
+	live := readArchive(t, path) // Rewritten gzipped, as it was read
+	if live.Count != 1 || len(live.Bars) != 1 {
+		t.Errorf("live file: count=%d bars=%d, want 1/1", live.Count, len(live.Bars))
+	}
+}
+
+func TestPruneFile_KeepsProvenance(t *testing.T) {
+	dir := t.TempDir()
+	cfg := pruneConfig{BarsDir: dir, ArchiveDir: filepath.Join(dir, "archive"), KeepDays: 14, StaleDays: 14}
+	path := filepath.Join(dir, "AAPL.json")
+	data := barfile.Data{Symbol: "AAPL", Count: 2, Feed: "iex", Splits: []string{"ca-1"}, Source: "alpaca", Bars: []barfile.Bar{
+		{Timestamp: "2024-01-10T14:30:00Z", Close: 100},
+		{Timestamp: "2024-02-25T14:30:00Z", Close: 100},
+	}}
+	if err := writeJSON(path, &data); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, _, err := pruneFile(cfg, path, pruneNow); err != nil || n != 1 {
+		t.Fatalf("got n=%d err=%v, want 1/nil", n, err)
+	}
+	// Stream appends only to a file of its own feed, and fetch -incremental
+	// only builds on splits it has already applied
+	var live barfile.Data
+	readPruneJSON(t, path, &live)
+	if live.Feed != "iex" || live.Source != "alpaca" || len(live.Splits) != 1 {
+		t.Errorf("live file lost its provenance: %+v", live)
+	}
+}
+
+func writePruneBars(t *testing.T, dir, symbol string, timestamps ...string) string {
+	t.Helper()
+	data := barfile.Data{Symbol: symbol, Count: len(timestamps)}
+	for _, ts := range timestamps {
+		data.Bars = append(data.Bars, barfile.Bar{Timestamp: ts, Close: 100})
+	}
+	path := filepath.Join(dir, symbol+".json")
+	if err := writeJSON(path, &data); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readPruneJSON(t *testing.T, path string, v any) {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readArchive(t *testing.T, path string) barfile.Data {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data barfile.Data
+	if err := json.NewDecoder(zr).Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestRefreshManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(t.TempDir(), "bars-manifest.json") // Beside the bars, not in them
+
+	if err := refreshManifest(path, dir, pruneNow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("no manifest should be created where fetch hadn't written one")
+	}
+
+	if err := os.WriteFile(path, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte(`{"symbol":"AAPL","bars":[{"t":"2026-03-02T14:30:00Z"}]}`)
+	if err := os.WriteFile(filepath.Join(dir, "AAPL.json"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := refreshManifest(path, dir, pruneNow); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := manifest.Parse(path, data)
+	if err != nil || m.Verify("AAPL", content) != "" {
+		t.Errorf("got %+v, %v", m, err)
+	}
+}
+
+// --- runDaemon ---
+
+func TestNextRun(t *testing.T) {
+	sessions := []alpaca.Session{
+		{Date: "2026-03-12", Open: "09:30", Close: "16:00"},
+		{Date: "2026-03-13", Open: "09:30", Close: "13:00"}, // Half day
+		{Date: "2026-03-16", Open: "09:30", Close: "16:00"},
+	}
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	for _, tc := range []struct {
+		name, now, done          string
+		session, start, deadline string
+	}{
+		// New York is four hours behind UTC in March
+		{"before the close", "2026-03-12T18:00:00Z", "", "2026-03-12", "2026-03-12T20:15:00Z", "2026-03-13T13:00:00Z"},
+		{"started late", "2026-03-12T23:00:00Z", "", "2026-03-12", "2026-03-12T23:00:00Z", "2026-03-13T13:00:00Z"},
+		{"already run", "2026-03-12T23:00:00Z", "2026-03-12", "2026-03-13", "2026-03-13T17:15:00Z", "2026-03-16T13:00:00Z"},
+		{"too late for it", "2026-03-13T13:10:00Z", "", "2026-03-13", "2026-03-13T17:15:00Z", "2026-03-16T13:00:00Z"},
+		{"over the weekend", "2026-03-14T12:00:00Z", "2026-03-12", "2026-03-13", "2026-03-14T12:00:00Z", "2026-03-16T13:00:00Z"},
+	} {
+		run, err := nextRun(sessions, at(tc.now), tc.done, 15*time.Minute, 30*time.Minute)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if run.Session != tc.session || !run.Start.Equal(at(tc.start)) || !run.Deadline.Equal(at(tc.deadline)) {
+			t.Errorf("%s: got %s %s–%s", tc.name, run.Session, run.Start.UTC().Format(time.RFC3339), run.Deadline.UTC().Format(time.RFC3339))
+		}
+	}
+
+	// The last session has no next open to finish before
+	if _, err := nextRun(sessions, at("2026-03-16T21:00:00Z"), "2026-03-13", 15*time.Minute, 30*time.Minute); err == nil {
+		t.Error("no next session: want an error")
+	}
+}
+
+func TestRunNightly(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "lft2.lock")
+	ran := filepath.Join(dir, "ran")
+
+	if err := runNightly(context.Background(), "touch "+ran, lockPath, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ran); err != nil {
+		t.Error("command didn't run")
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("lock not released")
+	}
+
+	// Interrupted at the deadline rather than left to run into the open
+	began := time.Now()
+	err := runNightly(context.Background(), "sleep 10", lockPath, time.Now().Add(200*time.Millisecond))
+	if err == nil || !strings.Contains(err.Error(), "overlap the open") {
+		t.Errorf("overran: got %v", err)
+	}
+	if time.Since(began) > 5*time.Second {
+		t.Errorf("took %v to stop", time.Since(began))
+	}
+
+	// Held by the live cycle past the deadline: the command never starts
+	os.Remove(ran)
+	l, err := lock.TryAcquire(lockPath, "live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release()
+	if err := runNightly(context.Background(), "touch "+ran, lockPath, time.Now().Add(50*time.Millisecond)); err == nil {
+		t.Error("lock held: want an error")
+	}
+	if _, err := os.Stat(ran); err == nil {
+		t.Error("ran without the lock")
+	}
+}
+
+func TestRunLock(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "lft2.lock")
+	if code := runLock([]string{"-lock", lockPath, "--", "sh", "-c", "exit 3"}); code != 3 {
+		t.Errorf("got exit code %d, want the command's 3", code)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("lock not released")
+	}
+
+	l, err := lock.TryAcquire(lockPath, "backtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release()
+	if code := runLock([]string{"-lock", lockPath, "-wait", "20ms", "--", "true"}); code != 1 {
+		t.Errorf("held: got exit code %d, want 1", code)
+	}
+	if code := runLock([]string{"-lock", lockPath}); code != 2 {
+		t.Errorf("no command: got exit code %d, want 2", code)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/events"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/manifest"
+)
+
+// runListen subscribes to the event bus in LFT2_EVENT_BUS and writes each
+// bar event out as a bar file, with the manifest kept in step, so filter and
+// backtest can run on a host that doesn't fetch. Signals and fills are
+// printed, and appended to -log as NDJSON if given. It runs until the bus
+// connection drops, or Ctrl-C once the event in hand is written.
+//
+//	lft2 listen                      bars into docs/bars, print the rest
+//	lft2 listen -log events.ndjson   also keep signals and fills
+func runListen(args []string) int {
+	fs := flag.NewFlagSet("listen", flag.ContinueOnError)
+	bars := fs.String("bars", "docs/bars", "Directory to write bar files to")
+	manifestPath := fs.String("manifest", manifest.DefaultPath, "Bars manifest to keep in step")
+	logPath := fs.String("log", "", "Append signal and fill events to this NDJSON file")
+	subjects := fs.String("subjects", "lft2.>", "Subjects to subscribe to")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	bus, err := events.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	if bus == nil {
+		fmt.Fprintln(os.Stderr, "✗ LFT2_EVENT_BUS is not set (nats://host:4222 or redis://host:6379)")
+		return 1
+	}
+	defer bus.Close()
+
+	if err := os.MkdirAll(*bars, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	l := &listener{bars: *bars, manifestPath: *manifestPath, out: os.Stdout}
+	if data, err := os.ReadFile(*manifestPath); err == nil {
+		if l.manifest, err = manifest.Parse(*manifestPath, data); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			return 1
+		}
+	}
+	if *logPath != "" {
+		f, err := os.OpenFile(*logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		l.log = f
+	}
+
+	// Closing the connection ends Subscribe between events
+	ctx := interrupt.Context()
+	context.AfterFunc(ctx, func() { bus.Close() })
+
+	fmt.Printf("→ listening on %s\n", *subjects)
+	err = bus.Subscribe(*subjects, l.handle)
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "✗ listen interrupted")
+		return interrupt.ExitCode
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// listener handles events for runListen. A malformed event is reported and
+// skipped; only a failure to write locally stops it.
+type listener struct {
+	bars         string
+	manifestPath string
+	manifest     manifest.File
+	out          io.Writer
+	log          io.Writer // Signals and fills, if set
+}
+
+func (l *listener) handle(subject string, data []byte) error {
+	e, err := events.Decode(subject, data)
+	if err != nil {
+		fmt.Fprintf(l.out, "  [skip] %v\n", err)
+		return nil
+	}
+
+	switch e.Kind {
+	case events.Bars:
+		return l.saveBars(e)
+	case events.Signals, events.Fills:
+		fmt.Fprintf(l.out, "  %-7s %-6s %s\n", e.Kind, e.Key, e.Data)
+		if l.log != nil {
+			if _, err := fmt.Fprintf(l.log, "%s\n", data); err != nil {
+				return err
+			}
+		}
+	default:
+		fmt.Fprintf(l.out, "  [skip] %s: unknown kind %q\n", subject, e.Kind)
+	}
+	return nil
+}
+
+// saveBars writes a bar event as {SYMBOL}.json and updates the manifest
+// entry, so filter and backtest verify it like a file fetch wrote. The file
+// is written beside its final name and renamed, so a reader never sees it
+// half written, and a gzipped copy from an earlier fetch is removed.
+func (l *listener) saveBars(e events.Event) error {
+	symbol := e.Key
+	if symbol == "" || strings.ContainsAny(symbol, `/\`) || strings.HasPrefix(symbol, ".") {
+		fmt.Fprintf(l.out, "  [skip] bars for unusable symbol %q\n", symbol)
+		return nil
+	}
+	entry, err := manifest.Describe(symbol, e.Data)
+	if err != nil {
+		fmt.Fprintf(l.out, "  [skip] bars: %v\n", err)
+		return nil
+	}
+
+	if _, err := barfile.Write(l.bars, symbol, e.Data, false); err != nil {
+		return err
+	}
+
+	l.manifest.Put(entry)
+	if t, err := time.Parse(time.RFC3339, e.Timestamp); err == nil {
+		l.manifest.Timestamp = t.UTC().Format(time.RFC3339)
+	}
+	if err := manifest.Save(l.manifestPath, l.manifest); err != nil {
+		return err
+	}
+	fmt.Fprintf(l.out, "✓ %s: %d bars to %s\n", symbol, entry.Count, entry.LastBarTime)
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/version"
+)
+
+// command is an lft2 subcommand. run receives the arguments after the
+// subcommand name and returns the process exit code.
+type command struct {
+	usage string
+	run   func(args []string) int
+}
+
+var commands = map[string]command{
+	"books":   {"books [-names]              check books.json and list each book's timeframe, budget and strategies", runBooks},
+	"daemon":  {"daemon [-cmd CMD]           run the candidate scan and backtest after each session close", runDaemon},
+	"decay":   {"decay [-days N] [-z Z]      alert when a strategy's live win rate stays below its backtest's", runDecay},
+	"export":  {"export [-from DATE] [-o FILE] write fills and notes as a broker CSV", runExport},
+	"init":    {"init [-skip-backtest]       set up config, check credentials and run the first backtest", runInit},
+	"key":     {"key                         print a new LFT2_STATE_KEY for encrypting the journal", runKey},
+	"listen":  {"listen [-bars DIR] [-log FILE] write bars from the event bus to files, print signals and fills", runListen},
+	"lock":    {"lock [-wait D] -- COMMAND   run a command holding the lock the daemon's backtest takes", runLock},
+	"merge":   {"merge [-dir DIR] [-o DIR]   join backtest --shard outputs into strategies.json", runMerge},
+	"note":    {"note [ORDER_ID [TEXT...]]  attach a note to an order, or list notes", runNote},
+	"prune":   {"prune [-keep-days N] [-dry-run] archive old bars and retire stale symbol files", runPrune},
+	"promote": {"promote [-live FILE]        require strategy changes to match their backtest on paper before going live", runPromote},
+	"store":   {"store [import|export]      move a book's bars, candidates and recommendations in and out of $LFT2_STORE", runStore},
+	"try":     {"try [-tp PCT] STRATEGY SYMBOL backtest one strategy on one symbol's bars and print its trades", runTry},
+	"version": {"version                     print the commit, build time and modules lft2 was built from", runVersion},
+	"whatif":  {"whatif [-from DATE] [-o FILE] replay fills under fixed fractional, equal weight and Kelly sizing", runWhatIf},
+}
+
+// lft2 is the operator CLI for tasks outside the scheduled pipeline.
+func main() {
+	defer crash.Guard("lft2")
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	// Only as the first argument: elsewhere it could be a note's text
+	if version.Requested(os.Args[1:2]) {
+		os.Exit(runVersion(nil))
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "lft2: unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	os.Exit(cmd.run(os.Args[2:]))
+}
+
+func runVersion([]string) int {
+	version.Write(os.Stdout, "lft2")
+	return 0
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: lft2 <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range sortedCommands() {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+}
+
+// sortedCommands returns subcommand names in a stable order for help output.
+func sortedCommands() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/report"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// shardHeader is what every backtest --shard output starts with.
+type shardHeader struct {
+	schema.Header
+	Timestamp string `json:"timestamp"`
+	Seed      uint64 `json:"seed"`
+	Shard     string `json:"shard"` // "i/n"
+}
+
+// shardStrategies is docs/shards/strategies-i-of-n.json. Recommendations
+// are kept as written so merging doesn't reformat a number.
+type shardStrategies struct {
+	shardHeader
+	FillSampled     int               `json:"fill_sampled"`
+	Recommendations []json.RawMessage `json:"recommendations"`
+}
+
+// shardCurve is one strategy's curve from a shard: full-precision equity and
+// the symbol that traded each point.
+type shardCurve struct {
+	Strategy   string    `json:"strategy"`
+	Timestamps []string  `json:"timestamps"`
+	Equity     []float64 `json:"equity"`
+	Symbols    []string  `json:"symbols"`
+}
+
+// shardCurves is docs/shards/equity-curves-i-of-n.json.
+type shardCurves struct {
+	shardHeader
+	Curves []shardCurve `json:"curves"`
+}
+
+// mergedStrategies is strategies.json as backtest writes it, plus the number
+// of shards it was joined from.
+type mergedStrategies struct {
+	schema.Header
+	Timestamp       string            `json:"timestamp"`
+	Seed            uint64            `json:"seed"`
+	Shards          int               `json:"shards"`
+	FillSampled     int               `json:"fill_sampled"`
+	Recommendations []json.RawMessage `json:"recommendations"`
+}
+
+// mergedCurves is equity-curves.json as backtest writes it.
+type mergedCurves struct {
+	schema.Header
+	Timestamp string               `json:"timestamp"`
+	Seed      uint64               `json:"seed"`
+	Curves    []report.EquityCurve `json:"curves"`
+}
+
+// runMerge joins the outputs of `backtest --shard i/n` into the
+// strategies.json and equity-curves.json one unsharded run would write:
+//
+//	lft2 merge                          docs/shards → docs
+//	lft2 merge -dir shards -o out       elsewhere
+//
+// Every shard of the set must be present and share one seed and fill model.
+func runMerge(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	dir := fs.String("dir", "docs/shards", "Directory of shard outputs")
+	out := fs.String("o", "docs", "Directory to write strategies.json and equity-curves.json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	strategies, curves, err := loadShards(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	merged, mergedCurves, err := mergeShards(strategies, curves)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	for name, v := range map[string]any{"strategies.json": merged, "equity-curves.json": mergedCurves} {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ encoding %s: %v\n", name, err)
+			return 1
+		}
+		if err := os.WriteFile(filepath.Join(*out, name), append(data, '\n'), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			return 1
+		}
+	}
+	fmt.Printf("✓ Merged %d shard(s): %d recommendation(s), %d equity curve(s) → %s\n",
+		merged.Shards, len(merged.Recommendations), len(mergedCurves.Curves), *out)
+	return 0
+}
+
+// loadShards reads every strategies shard in dir and its equity curves.
+func loadShards(dir string) ([]shardStrategies, []shardCurves, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "strategies-*-of-*.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("no shard outputs in %s — run backtest --shard i/n first", dir)
+	}
+	sort.Strings(names)
+
+	var strategies []shardStrategies
+	var curves []shardCurves
+	for _, name := range names {
+		var s shardStrategies
+		if err := readShard(name, &s); err != nil {
+			return nil, nil, err
+		}
+		var c shardCurves
+		curvesName := filepath.Join(dir, "equity-curves-"+strings.TrimPrefix(filepath.Base(name), "strategies-"))
+		if err := readShard(curvesName, &c); err != nil {
+			return nil, nil, err
+		}
+		strategies = append(strategies, s)
+		curves = append(curves, c)
+	}
+	return strategies, curves, nil
+}
+
+func readShard(name string, v any) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if err := schema.Check(name, data); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return nil
+}
+
+// parseShard splits "i/n", requiring 1 <= i <= n.
+func parseShard(s string) (index, count int, err error) {
+	i, n, ok := strings.Cut(s, "/")
+	index, errI := strconv.Atoi(i)
+	count, errN := strconv.Atoi(n)
+	if !ok || errI != nil || errN != nil || index < 1 || index > count {
+		return 0, 0, fmt.Errorf("bad shard %q, want i/n", s)
+	}
+	return index, count, nil
+}
+
+// mergeShards checks the shards form one complete run and joins them.
+func mergeShards(strategies []shardStrategies, curves []shardCurves) (mergedStrategies, mergedCurves, error) {
+	if len(strategies) == 0 {
+		return mergedStrategies{}, mergedCurves{}, fmt.Errorf("no shards to merge")
+	}
+	first := strategies[0]
+	_, count, err := parseShard(first.Shard)
+	if err != nil {
+		return mergedStrategies{}, mergedCurves{}, err
+	}
+
+	seen := map[int]bool{}
+	timestamp := ""
+	for i, s := range strategies {
+		index, n, err := parseShard(s.Shard)
+		switch {
+		case err != nil:
+			return mergedStrategies{}, mergedCurves{}, err
+		case n != count:
+			return mergedStrategies{}, mergedCurves{}, fmt.Errorf("shard %s is from a %d-way split, others from %d", s.Shard, n, count)
+		case seen[index]:
+			return mergedStrategies{}, mergedCurves{}, fmt.Errorf("shard %s appears twice", s.Shard)
+		case s.Seed != first.Seed:
+			return mergedStrategies{}, mergedCurves{}, fmt.Errorf("shard %s has seed %d, shard %s has %d", s.Shard, s.Seed, first.Shard, first.Seed)
+		case s.FillSampled != first.FillSampled:
+			return mergedStrategies{}, mergedCurves{}, fmt.Errorf("shard %s ran a different fill model from shard %s", s.Shard, first.Shard)
+		case curves[i].Shard != s.Shard || curves[i].Seed != s.Seed:
+			return mergedStrategies{}, mergedCurves{}, fmt.Errorf("shard %s: equity curves are from shard %s, seed %d", s.Shard, curves[i].Shard, curves[i].Seed)
+		}
+		seen[index] = true
+		if s.Timestamp > timestamp {
+			timestamp = s.Timestamp
+		}
+	}
+	for i := 1; i <= count; i++ {
+		if !seen[i] {
+			return mergedStrategies{}, mergedCurves{}, fmt.Errorf("shard %d/%d missing", i, count)
+		}
+	}
+
+	recs, err := mergeRecommendations(strategies)
+	if err != nil {
+		return mergedStrategies{}, mergedCurves{}, err
+	}
+	merged := mergedStrategies{
+		Header:          schema.Current(),
+		Timestamp:       timestamp,
+		Seed:            first.Seed,
+		Shards:          count,
+		FillSampled:     first.FillSampled,
+		Recommendations: recs,
+	}
+	return merged, mergedCurves{
+		Header:    schema.Current(),
+		Timestamp: timestamp,
+		Seed:      first.Seed,
+		Curves:    mergeCurves(curves),
+	}, nil
+}
+
+// mergeRecommendations joins the shards' recommendations in backtest's
+// order: symbol, then viable first, then win rate descending, then name. A
+// symbol in two shards means they ran against different candidates.
+func mergeRecommendations(strategies []shardStrategies) ([]json.RawMessage, error) {
+	type keyed struct {
+		raw      json.RawMessage
+		Symbol   string  `json:"symbol"`
+		Strategy string  `json:"strategy"`
+		Viable   bool    `json:"viable"`
+		WinRate  float64 `json:"win_rate"`
+	}
+
+	owner := map[string]string{}
+	var recs []keyed
+	for _, s := range strategies {
+		for _, raw := range s.Recommendations {
+			k := keyed{raw: raw}
+			if err := json.Unmarshal(raw, &k); err != nil {
+				return nil, fmt.Errorf("shard %s: %w", s.Shard, err)
+			}
+			if o, ok := owner[k.Symbol]; ok && o != s.Shard {
+				return nil, fmt.Errorf("%s is in shards %s and %s — were they run on the same candidates.json?", k.Symbol, o, s.Shard)
+			}
+			owner[k.Symbol] = s.Shard
+			recs = append(recs, k)
+		}
+	}
+
+	sort.SliceStable(recs, func(i, j int) bool {
+		a, b := recs[i], recs[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.Viable != b.Viable {
+			return a.Viable
+		}
+		if a.WinRate != b.WinRate {
+			return a.WinRate > b.WinRate
+		}
+		return a.Strategy < b.Strategy
+	})
+
+	out := make([]json.RawMessage, len(recs))
+	for i, r := range recs {
+		out[i] = r.raw
+	}
+	return out, nil
+}
+
+// mergeCurves rebuilds each strategy's equity curve across shards. Each
+// point's step from the one before is a trade's return; the steps from every
+// shard are replayed in exit order, ties by symbol, as backtest orders them.
+func mergeCurves(shards []shardCurves) []report.EquityCurve {
+	type step struct {
+		timestamp string
+		symbol    string
+		ret       float64
+	}
+
+	by := map[string][]step{}
+	for _, s := range shards {
+		for _, c := range s.Curves {
+			prev := 1.0
+			for i, e := range c.Equity {
+				st := step{ret: e - prev}
+				if i < len(c.Timestamps) {
+					st.timestamp = c.Timestamps[i]
+				}
+				if i < len(c.Symbols) {
+					st.symbol = c.Symbols[i]
+				}
+				by[c.Strategy] = append(by[c.Strategy], st)
+				prev = e
+			}
+		}
+	}
+
+	names := make([]string, 0, len(by))
+	for name := range by {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	curves := make([]report.EquityCurve, 0, len(names))
+	for _, name := range names {
+		steps := by[name]
+		sort.SliceStable(steps, func(i, j int) bool {
+			if steps[i].timestamp != steps[j].timestamp {
+				return steps[i].timestamp < steps[j].timestamp
+			}
+			return steps[i].symbol < steps[j].symbol
+		})
+
+		c := report.EquityCurve{Strategy: name, Trades: len(steps), FinalEquity: 1}
+		equity, peak, drawdown := 1.0, 1.0, 0.0
+		for _, st := range steps {
+			equity += st.ret
+			peak = math.Max(peak, equity)
+			drawdown = math.Max(drawdown, (peak-equity)/peak)
+			c.Timestamps = append(c.Timestamps, st.timestamp)
+			c.Equity = append(c.Equity, round4(equity))
+		}
+		c.FinalEquity = round4(equity)
+		c.MaxDrawdown = round4(drawdown)
+		curves = append(curves, c)
+	}
+	return curves
+}
+
+func round4(v float64) float64 { return math.Round(v*10000) / 10000 }
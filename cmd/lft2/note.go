@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/journal"
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+// runNote attaches a note to an order, or lists notes when no text is given:
+//
+//	lft2 note ORDER_ID "chased the gap"   add a note
+//	lft2 note ORDER_ID                    list notes for one order
+//	lft2 note                             list every note
+func runNote(args []string) int {
+	fs := flag.NewFlagSet("note", flag.ContinueOnError)
+	path := fs.String("journal", journal.DefaultPath, "Journal file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	j, err := journal.Load(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	rest := fs.Args()
+	if len(rest) < 2 {
+		var notes []journal.Note
+		if len(rest) == 1 {
+			notes = j.For(rest[0])
+		} else {
+			notes = j.Notes
+		}
+		printNotes(os.Stdout, notes)
+		return 0
+	}
+
+	if err := j.Add(rest[0], strings.Join(rest[1:], " "), time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	if err := j.Save(*path); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ writing %s: %v\n", *path, err)
+		return 1
+	}
+	fmt.Printf("✓ Noted %s\n", rest[0])
+	return 0
+}
+
+// printNotes lists notes one per line.
+func printNotes(w io.Writer, notes []journal.Note) {
+	if len(notes) == 0 {
+		fmt.Fprintln(w, "No notes")
+		return
+	}
+	for _, n := range notes {
+		fmt.Fprintf(w, "%s  %s  %s\n", tz.Format(n.CreatedAt), n.OrderID, n.Text)
+	}
+}
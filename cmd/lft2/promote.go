@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/artifact"
+	"github.com/deanturpin/lft2/internal/fees"
+	"github.com/deanturpin/lft2/internal/report"
+	"github.com/deanturpin/lft2/internal/schema"
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+// candidate is a viable recommendation from strategies.json, identified as
+// entries writes it into the client_order_id.
+type candidate struct {
+	Symbol     string  `json:"symbol"`
+	Strategy   string  `json:"strategy"`
+	Version    int     `json:"version"` // 0 in backtests from before versioning
+	ParamsHash string  `json:"params_hash"`
+	AvgProfit  float64 `json:"avg_profit"`
+	Viable     bool    `json:"viable"`
+}
+
+// key is the symbol, versioned strategy and parameters a change is judged
+// on. A bare strategy counts as version 1, as in report.OrderStrategy.
+func (c candidate) key() string {
+	return fmt.Sprintf("%s %s-v%d %s", c.Symbol, c.Strategy, max(c.Version, 1), c.ParamsHash)
+}
+
+// runPromote checks a strategies.json is fit for the live account: every
+// recommendation it adds or re-tunes must have traded on the paper account
+// for -days and returned close to what its backtest expected. The exit code
+// is the verdict, so a deploy can be gated on it:
+//
+//	lft2 promote -live live/strategies.json && deploy
+//
+// Only the changes are judged. A recommendation the live file already has,
+// with the same version and params_hash, was promoted before. Each change's
+// cohort is kept in -o between runs: a change is in shadow from the first
+// run that sees it, and promoted once it has been there -days and its paper
+// trades match its backtest.
+func runPromote(args []string) int {
+	now := time.Now()
+	fs := flag.NewFlagSet("promote", flag.ContinueOnError)
+	path := fs.String("strategies", "docs/strategies.json", "Backtest output to promote")
+	live := fs.String("live", "", "strategies.json the live account trades now (default: none, so everything is a change)")
+	from := fs.String("from", tz.Date(now.AddDate(0, 0, -30)), "First paper trading day (YYYY-MM-DD, reporting timezone)")
+	to := fs.String("to", tz.Date(now), "Last paper trading day, inclusive")
+	minTrades := fs.Int("min-trades", 5, "Closed paper trades each change needs")
+	tolerance := fs.Float64("tolerance", 0.005, "Largest gap between mean paper return and backtest avg_profit, per trade")
+	days := fs.Int("days", 10, "Days each change trades on paper before it can be promoted")
+	out := fs.String("o", report.PromotionsPath, "Cohort state and verdicts, read back on the next run (empty to skip)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *minTrades < 1 || *tolerance < 0 || *days < 0 {
+		fmt.Fprintln(os.Stderr, "✗ -min-trades must be positive, and -tolerance and -days not negative")
+		return 2
+	}
+
+	candidates, err := loadCandidates(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	var promoted []candidate
+	if *live != "" {
+		if promoted, err = loadCandidates(*live); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			return 1
+		}
+	}
+	changes := changed(candidates, promoted)
+	if len(changes) == 0 {
+		fmt.Printf("✓ no new or re-tuned recommendations in %s — nothing to promote\n", *path)
+		return 0
+	}
+
+	client, err := alpaca.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	if !strings.Contains(client.BaseURL, "paper") {
+		fmt.Fprintf(os.Stderr, "✗ %s is not the paper endpoint — the evidence has to come from paper trading\n", client.BaseURL)
+		return 1
+	}
+	feeModel, err := fees.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ fee model: %v\n", err)
+		return 1
+	}
+	reporter := report.Reporter{Broker: client, Fees: feeModel, Log: os.Stderr}
+	acts, err := reporter.Statement(*from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	trades, _ := roundTrips(acts)
+
+	verdicts := advance(judge(changes, trades, *minTrades, *tolerance), previousCohorts(*out), tz.Date(now), *days, *minTrades)
+	promote := true
+	for _, v := range verdicts {
+		promote = promote && v.Status == report.Promoted
+	}
+	fmt.Printf("Promotion check, paper trades %s to %s: %d change(s)\n\n", *from, *to, len(verdicts))
+	printPromotion(os.Stdout, verdicts)
+
+	if *out != "" {
+		data, err := json.MarshalIndent(report.Promotions{
+			Header:    schema.Current(),
+			Timestamp: now.UTC().Format(time.RFC3339),
+			From:      *from,
+			To:        *to,
+			MinTrades: *minTrades,
+			Tolerance: *tolerance,
+			Days:      *days,
+			Promote:   promote,
+			Changes:   verdicts,
+		}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ encoding %s: %v\n", *out, err)
+			return 1
+		}
+		if err := os.WriteFile(*out, append(data, '\n'), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			return 1
+		}
+		fmt.Printf("\n✓ Wrote %s\n", *out)
+	}
+
+	if !promote {
+		fmt.Println("\n✗ Not promoted: run the changes on paper until they have served their days and match their backtest")
+		return 1
+	}
+	fmt.Println("\n✓ Promoted")
+	return 0
+}
+
+// loadCandidates reads the viable recommendations from a strategies.json,
+// the only ones entries trades.
+func loadCandidates(path string) ([]candidate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Recommendations []candidate `json:"recommendations"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	var viable []candidate
+	for _, c := range file.Recommendations {
+		if c.Viable {
+			viable = append(viable, c)
+		}
+	}
+	return viable, nil
+}
+
+// changed returns the candidates live doesn't already trade with the same
+// version and parameters.
+func changed(candidates, live []candidate) []candidate {
+	seen := map[string]bool{}
+	for _, c := range live {
+		seen[c.key()] = true
+	}
+	var changes []candidate
+	for _, c := range candidates {
+		if !seen[c.key()] {
+			changes = append(changes, c)
+			seen[c.key()] = true
+		}
+	}
+	return changes
+}
+
+// judge compares each change's closed paper trades with its backtest. A
+// change passes with at least minTrades trades whose mean return is within
+// tolerance of avg_profit either way: paper beating the backtest by a wide
+// margin is as much a sign the model is wrong as falling short. Sorted by
+// symbol then strategy.
+func judge(changes []candidate, trades []trade, minTrades int, tolerance float64) []report.Promotion {
+	returns := map[string][]float64{}
+	for _, t := range trades {
+		name, version, ok := report.OrderStrategy(t.Symbol, t.OrderID)
+		if !ok {
+			continue
+		}
+		hash, ok := report.OrderParams(t.OrderID)
+		if !ok {
+			continue
+		}
+		c := candidate{Symbol: t.Symbol, Strategy: name, Version: version, ParamsHash: hash}
+		returns[c.key()] = append(returns[c.key()], t.Return)
+	}
+
+	verdicts := make([]report.Promotion, 0, len(changes))
+	for _, c := range changes {
+		got := returns[c.key()]
+		v := report.Promotion{
+			Symbol:     c.Symbol,
+			Strategy:   fmt.Sprintf("%s-v%d", c.Strategy, max(c.Version, 1)),
+			ParamsHash: c.ParamsHash,
+			Expected:   c.AvgProfit,
+			Trades:     len(got),
+		}
+		if len(got) > 0 {
+			sum := 0.0
+			for _, r := range got {
+				sum += r
+			}
+			v.Observed = math.Round(sum/float64(len(got))*1e6) / 1e6
+		}
+		switch {
+		case v.Trades < minTrades:
+			v.Reason = fmt.Sprintf("%d paper trade(s), need %d", v.Trades, minTrades)
+		case math.Abs(v.Observed-v.Expected) > tolerance:
+			v.Reason = fmt.Sprintf("paper %+.2f%% per trade vs backtest %+.2f%%, more than %.2f%% apart",
+				v.Observed*100, v.Expected*100, tolerance*100)
+		default:
+			v.Passed = true
+		}
+		verdicts = append(verdicts, v)
+	}
+	sort.SliceStable(verdicts, func(i, j int) bool {
+		if verdicts[i].Symbol != verdicts[j].Symbol {
+			return verdicts[i].Symbol < verdicts[j].Symbol
+		}
+		return verdicts[i].Strategy < verdicts[j].Strategy
+	})
+	return verdicts
+}
+
+// advance moves each change through its paper cohort. A change enters in
+// shadow on the first day it's judged, keeping that day in later runs from
+// prev, and is promoted once it has been in the cohort days and passed.
+// One that has enough trades but has drifted from its backtest is diverged,
+// and stays in the cohort until it comes back within tolerance or is
+// re-tuned, which makes it a new change. A promotion stands: the change
+// is waiting for the live file to take it.
+func advance(verdicts []report.Promotion, prev *report.Promotions, today string, days, minTrades int) []report.Promotion {
+	before := map[string]report.Promotion{}
+	if prev != nil {
+		for _, p := range prev.Changes {
+			before[p.Key()] = p
+		}
+	}
+	start, err := time.Parse(time.DateOnly, today)
+	if err != nil {
+		return verdicts
+	}
+
+	for i := range verdicts {
+		v := &verdicts[i]
+		p := before[v.Key()]
+		if p.Status == report.Promoted {
+			v.Status, v.Since, v.PromotedOn, v.Reason = report.Promoted, p.Since, p.PromotedOn, ""
+			continue
+		}
+		v.Since = today
+		if p.Since != "" && p.Since < today {
+			v.Since = p.Since
+		}
+		since, err := time.Parse(time.DateOnly, v.Since)
+		if err != nil {
+			since = start
+		}
+		served := int(start.Sub(since).Hours()/24) + 1
+		switch {
+		case v.Passed && served >= days:
+			v.Status, v.PromotedOn, v.Reason = report.Promoted, today, ""
+		case v.Passed:
+			v.Status, v.Reason = report.Shadow, fmt.Sprintf("day %d of %d on paper", served, days)
+		case v.Trades >= minTrades:
+			v.Status = report.Diverged
+		default:
+			v.Status = report.Shadow
+		}
+	}
+	return verdicts
+}
+
+// previousCohorts returns the last run's promotions.json: path, else the
+// published one, so a CI checkout still knows when each change entered.
+// Nil when neither is usable, which starts every change afresh.
+func previousCohorts(path string) *report.Promotions {
+	if path == "" {
+		return nil
+	}
+	prev, err := report.LoadPromotions(path)
+	if err != nil {
+		fmt.Printf("  [skip] previous cohorts: %v\n", err)
+	}
+	if prev != nil {
+		return prev
+	}
+	data, err := artifact.Fetch(artifact.Base(), "promotions.json")
+	if err != nil {
+		return nil
+	}
+	published, err := report.ParsePromotions("promotions.json", data)
+	if err != nil {
+		fmt.Printf("  [skip] published cohorts: %v\n", err)
+		return nil
+	}
+	return &published
+}
+
+// printPromotion writes one line per change.
+func printPromotion(w io.Writer, verdicts []report.Promotion) {
+	fmt.Fprintf(w, "  %-6s  %-24s  %-8s  %-10s  %6s  %9s  %9s\n", "Symbol", "Strategy", "Params", "Since", "Trades", "Expected", "Paper")
+	for _, v := range verdicts {
+		mark := "✓ " + v.Status
+		if v.Status != report.Promoted {
+			mark = "✗ " + v.Status + ": " + v.Reason
+		}
+		fmt.Fprintf(w, "  %-6s  %-24s  %-8s  %-10s  %6d  %+8.2f%%  %+8.2f%%  %s\n",
+			v.Symbol, v.Strategy, v.ParamsHash, v.Since, v.Trades, v.Expected*100, v.Observed*100, mark)
+	}
+}
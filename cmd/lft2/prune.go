@@ -0,0 +1,242 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/manifest"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// runPrune archives bars past the retention window, gzipped and out of
+// docs/, and retires the files of symbols that have left the watchlist:
+//
+//	lft2 prune                    keep 42 days, retire after 14 idle
+//	lft2 prune -dry-run           report what would go
+func runPrune(args []string) int {
+	cfg := pruneConfig{}
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	fs.StringVar(&cfg.BarsDir, "bars", "docs/bars", "Bar data directory to prune")
+	fs.StringVar(&cfg.ArchiveDir, "archive", "archive/bars", "Directory for compressed archived bars (kept out of docs/)")
+	fs.IntVar(&cfg.KeepDays, "keep-days", 42, "Keep this many calendar days of bars in each live file")
+	fs.IntVar(&cfg.StaleDays, "stale-days", 14, "Archive and remove files with no bars in this many days (0 disables)")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "Report what would be pruned without changing anything")
+	fs.StringVar(&cfg.Manifest, "manifest", manifest.DefaultPath, "Bars manifest to rebuild after pruning, if fetch wrote one")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	entries, err := os.ReadDir(cfg.BarsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Retention: %d days live, retire after %d idle days → %s/\n",
+		cfg.KeepDays, cfg.StaleDays, cfg.ArchiveDir)
+	if cfg.DryRun {
+		fmt.Println("Dry run — no files will be changed")
+	}
+	fmt.Println()
+
+	// Ctrl-C finishes the file in hand, then still rebuilds the manifest
+	ctx := interrupt.Context()
+
+	now := time.Now().UTC()
+	totalArchived, retired, failed := 0, 0, 0
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, ok := barfile.Symbol(entry.Name()); entry.IsDir() || !ok {
+			continue
+		}
+
+		n, gone, err := pruneFile(cfg, filepath.Join(cfg.BarsDir, entry.Name()), now)
+		switch {
+		case err != nil:
+			fmt.Printf("  ✗ %s: %v\n", entry.Name(), err)
+			failed++
+		case gone:
+			fmt.Printf("  ⏏ %s: retired (%d bars archived)\n", entry.Name(), n)
+			retired++
+			totalArchived += n
+		case n > 0:
+			fmt.Printf("  ✓ %s: %d bars archived\n", entry.Name(), n)
+			totalArchived += n
+		}
+	}
+
+	fmt.Printf("\n✓ Archived %d bars, retired %d file(s), %d error(s)\n", totalArchived, retired, failed)
+
+	// Pruned files no longer match fetch's checksums, so describe them afresh
+	if !cfg.DryRun && (totalArchived > 0 || retired > 0) {
+		if err := refreshManifest(cfg.Manifest, cfg.BarsDir, now); err != nil {
+			fmt.Printf("  ✗ %s: %v\n", cfg.Manifest, err)
+			failed++
+		}
+	}
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "✗ prune interrupted")
+		return interrupt.ExitCode
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// pruneConfig is what runPrune was asked to do.
+type pruneConfig struct {
+	BarsDir    string
+	ArchiveDir string
+	KeepDays   int
+	StaleDays  int
+	DryRun     bool
+	Manifest   string
+}
+
+// splitBars partitions ascending bars into those strictly before cutoff
+// (to archive) and those at or after it (to keep).
+func splitBars(bars []barfile.Bar, cutoff time.Time) (old, keep []barfile.Bar) {
+	for i, b := range bars {
+		t, err := time.Parse(time.RFC3339, b.Timestamp)
+		if err != nil || !t.Before(cutoff) {
+			return bars[:i], bars[i:]
+		}
+	}
+	return bars, nil
+}
+
+// archiveBars merges bars into ARCHIVE/SYMBOL.json.gz.
+func archiveBars(dir, symbol string, bars []barfile.Bar) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating archive directory: %w", err)
+	}
+
+	path := filepath.Join(dir, symbol+".json.gz")
+	archived := barfile.Data{Symbol: symbol}
+
+	if f, err := os.Open(path); err == nil {
+		zr, err := gzip.NewReader(f)
+		if err == nil {
+			err = json.NewDecoder(zr).Decode(&archived)
+		}
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("reading existing archive: %w", err)
+		}
+	}
+
+	archived.Header = schema.Current()
+	archived.Bars, _ = barfile.Merge(archived.Bars, bars)
+	archived.Count = len(archived.Bars)
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("creating archive: %w", err)
+	}
+	zw := gzip.NewWriter(f)
+	if err := json.NewEncoder(zw).Encode(archived); err != nil {
+		f.Close()
+		return fmt.Errorf("encoding archive: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("compressing archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// writeJSON rewrites a bar file in the form it was read in: gzipped when
+// path is a .json.gz.
+func writeJSON(path string, data *barfile.Data) error {
+	data.Header = schema.Current()
+	buf, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding JSON: %w", err)
+	}
+	buf, err = barfile.Encode(append(buf, '\n'), strings.HasSuffix(path, barfile.GzipExt))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf, 0644)
+}
+
+// pruneFile applies the retention rules to one bar file and returns the
+// number of bars archived and whether the whole file was retired.
+func pruneFile(cfg pruneConfig, path string, now time.Time) (archived int, retired bool, err error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false, err
+	}
+	if raw, err = barfile.Decode(raw); err != nil {
+		return 0, false, err
+	}
+
+	// Rewriting a file from a newer fetch would silently downgrade it
+	if err := schema.Check(path, raw); err != nil {
+		return 0, false, err
+	}
+	var data barfile.Data
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return 0, false, fmt.Errorf("parsing JSON: %w", err)
+	}
+	if data.Symbol == "" || len(data.Bars) == 0 {
+		return 0, false, nil
+	}
+
+	// A symbol whose newest bar is older than StaleDays has left the
+	// watchlist; archive everything and remove the live file
+	if cfg.StaleDays > 0 {
+		last, err := time.Parse(time.RFC3339, data.Bars[len(data.Bars)-1].Timestamp)
+		if err == nil && last.Before(now.AddDate(0, 0, -cfg.StaleDays)) {
+			if cfg.DryRun {
+				return len(data.Bars), true, nil
+			}
+			if err := archiveBars(cfg.ArchiveDir, data.Symbol, data.Bars); err != nil {
+				return 0, false, err
+			}
+			return len(data.Bars), true, os.Remove(path)
+		}
+	}
+
+	old, keep := splitBars(data.Bars, now.AddDate(0, 0, -cfg.KeepDays))
+	if len(old) == 0 || cfg.DryRun {
+		return len(old), false, nil
+	}
+
+	if err := archiveBars(cfg.ArchiveDir, data.Symbol, old); err != nil {
+		return 0, false, err
+	}
+
+	data.Bars = keep
+	data.Count = len(keep)
+	return len(old), false, writeJSON(path, &data)
+}
+
+// refreshManifest rebuilds the bars manifest from dir, but only where fetch
+// has already written one.
+func refreshManifest(path, dir string, now time.Time) error {
+	if _, err := os.Stat(path); path == "" || os.IsNotExist(err) {
+		return nil
+	}
+	m, err := manifest.Build(dir, now)
+	if err != nil {
+		return err
+	}
+	return manifest.Save(path, m)
+}
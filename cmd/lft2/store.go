@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/books"
+	"github.com/deanturpin/lft2/internal/manifest"
+	"github.com/deanturpin/lft2/internal/store"
+)
+
+// runStore moves a book's files in and out of the LFT2_STORE database, for
+// the book LFT2_BOOK names:
+//
+//	lft2 store                what the store holds for each book
+//	lft2 store import         store candidates.json and strategies.json
+//	lft2 store import -bars   and the bar files, e.g. to start a store
+//	lft2 store export         write the bar files, their manifest and the
+//	                          documents back from the store
+//
+// Fetch, filter and account write the store themselves; the C++ backtest
+// writes only strategies.json, so make imports it after each run.
+func runStore(args []string) int {
+	action := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("store", flag.ContinueOnError)
+	path := fs.String("db", os.Getenv(store.Env), "Database (default $LFT2_STORE)")
+	bars := fs.Bool("bars", false, "With import, store the bar files too")
+	compress := fs.Bool("gzip", false, "With export, write the bar files gzipped")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if action != "" && action != "import" && action != "export" {
+		fmt.Fprintf(os.Stderr, "lft2 store: unknown action %q, want import or export\n", action)
+		return 2
+	}
+	if *path == "" {
+		fmt.Fprintf(os.Stderr, "✗ no store: set %s or -db\n", store.Env)
+		return 2
+	}
+
+	cfg, book, err := books.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	s, err := store.Open(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	defer s.Close()
+
+	name := ""
+	if book != nil {
+		name = book.Name
+	}
+	switch action {
+	case "import":
+		err = importStore(os.Stdout, s, name, book.Root(), *bars, time.Now())
+	case "export":
+		err = exportStore(os.Stdout, s, name, book.Root(), *compress, time.Now())
+	default:
+		err = printStore(os.Stdout, s, cfg)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// importStore stores the book's documents under root, and with bars its bar
+// files. A document the stages haven't written yet is skipped.
+func importStore(w io.Writer, s store.Store, book, root string, bars bool, now time.Time) error {
+	for _, name := range store.Documents {
+		data, err := os.ReadFile(root + name)
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(w, "  [skip] %s%s: not written yet\n", root, name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.Put(book, name, data, now); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "✓ stored %s%s\n", root, name)
+	}
+	if !bars {
+		return nil
+	}
+
+	symbols, err := barfile.Symbols(root + "bars")
+	if err != nil {
+		return err
+	}
+	for _, symbol := range symbols {
+		raw, err := barfile.Read(root+"bars", symbol)
+		if err != nil {
+			return fmt.Errorf("%s: %w", symbol, err)
+		}
+		var d barfile.Data
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return fmt.Errorf("%s: %w", symbol, err)
+		}
+		if err := s.PutBars(book, &d); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "✓ stored %d bar file(s) from %sbars\n", len(symbols), root)
+	return nil
+}
+
+// exportStore writes the book's bars under root from the store, with the
+// manifest filter and backtest verify them against, then its documents.
+func exportStore(w io.Writer, s store.Store, book, root string, compress bool, now time.Time) error {
+	symbols, err := s.Symbols(book)
+	if err != nil {
+		return err
+	}
+	dir := root + "bars"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, symbol := range symbols {
+		d, err := s.Bars(book, symbol)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding %s: %w", symbol, err)
+		}
+		if _, err := barfile.Write(dir, symbol, append(data, '\n'), compress); err != nil {
+			return err
+		}
+	}
+	m, err := manifest.Build(dir, now)
+	if err != nil {
+		return err
+	}
+	if err := manifest.Save(root+"bars-manifest.json", m); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "✓ %d bar file(s) → %s\n", len(symbols), dir)
+
+	for _, name := range store.Documents {
+		data, at, err := s.Get(book, name)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			fmt.Fprintf(w, "  [skip] %s: not stored\n", name)
+			continue
+		}
+		if err := os.WriteFile(root+name, data, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "✓ %s from %s → %s%s\n", name, at.Format(time.RFC3339), root, name)
+	}
+	return nil
+}
+
+// printStore writes what the store holds for each book, the default first.
+func printStore(w io.Writer, s store.Store, cfg books.Config) error {
+	names := []string{""}
+	for _, b := range cfg.Books {
+		names = append(names, b.Name)
+	}
+	fmt.Fprintf(w, "  %-16s  %7s  %8s  %9s  %5s\n", "Book", "Symbols", "Bars", "Documents", "Fills")
+	for _, name := range names {
+		c, err := s.Count(name)
+		if err != nil {
+			return err
+		}
+		label := name
+		if label == "" {
+			label = "(default)"
+		}
+		fmt.Fprintf(w, "  %-16s  %7d  %8d  %9d  %5d\n", label, c.Symbols, c.Bars, c.Documents, c.Fills)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+//go:build sqlite
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/manifest"
+	"github.com/deanturpin/lft2/internal/store"
+)
+
+func TestStoreImportExport(t *testing.T) {
+	s, err := store.Open(filepath.Join(t.TempDir(), "lft2.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	now := time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)
+
+	from := t.TempDir() + "/"
+	os.MkdirAll(from+"bars", 0755)
+	os.WriteFile(from+"bars/AAPL.json", []byte(`{"symbol": "AAPL", "feed": "iex", "bars": [{"t": "2026-10-16T19:55:00Z", "c": 1.5, "v": 100}]}`), 0644)
+	os.WriteFile(from+"candidates.json", []byte(`{"candidates": ["AAPL"]}`), 0644)
+	var out strings.Builder
+	if err := importStore(&out, s, "swing", from, true, now); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "[skip] "+from+"strategies.json") || !strings.Contains(out.String(), "1 bar file(s)") {
+		t.Errorf("import output:\n%s", out.String())
+	}
+
+	to := t.TempDir() + "/"
+	if err := exportStore(&out, s, "swing", to, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(to + "candidates.json"); err != nil || string(got) != `{"candidates": ["AAPL"]}` {
+		t.Errorf("candidates: got %s, %v", got, err)
+	}
+	var d struct {
+		Symbol string `json:"symbol"`
+		Feed   string `json:"feed"`
+		Count  int    `json:"count"`
+	}
+	raw, err := os.ReadFile(to + "bars/AAPL.json")
+	if err != nil || json.Unmarshal(raw, &d) != nil || d.Symbol != "AAPL" || d.Feed != "iex" || d.Count != 1 {
+		t.Errorf("bar file: got %+v from %s, %v", d, raw, err)
+	}
+	m, err := manifest.Parse("manifest", mustRead(t, to+"bars-manifest.json"))
+	if err != nil || m.Verify("AAPL", raw) != "" {
+		t.Errorf("manifest doesn't cover the exported bars: %v", err)
+	}
+
+	// Another book's export has nothing
+	if err := exportStore(&out, s, "", t.TempDir()+"/", false, now); err != nil {
+		t.Fatal(err)
+	}
+}
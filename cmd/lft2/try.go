@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// runTry is the strategy sandbox: one strategy, built in or a rule from
+// rules.json, on one symbol's stored bars, printing its trades, their stats
+// and the equity as a sparkline. It runs the backtest binary, so the signals
+// come from the same code the pipeline trades, and nothing is written.
+// Run it from the repository root after make build:
+//
+//	lft2 try momentum AAPL
+//	lft2 try -tp 2 -sl 0.75 mean_reversion MSFT
+func runTry(args []string) int {
+	fs := flag.NewFlagSet("try", flag.ContinueOnError)
+	backtest := fs.String("backtest", "build/backtest", "Backtest binary, from make build")
+	seed := fs.Uint64("seed", 0, "Seed for sampled partial fills, as backtest --seed")
+	tp := fs.Float64("tp", 0, "Take profit, percent above entry (default the backtest's)")
+	sl := fs.Float64("sl", 0, "Stop loss, percent below entry (default the backtest's)")
+	tsl := fs.Float64("tsl", 0, "Trailing stop, percent below the peak (default the backtest's)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: lft2 try [flags] STRATEGY SYMBOL")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	levels := map[string]float64{"--tp": *tp, "--sl": *sl, "--tsl": *tsl}
+	for name, pct := range levels {
+		if pct < 0 || pct >= 100 {
+			fmt.Fprintf(os.Stderr, "✗ -%s must be a percentage from 0 to 100, got %g\n", name[2:], pct)
+			return 2
+		}
+	}
+
+	cmd := exec.Command(*backtest, tryArgs(fs.Arg(0), fs.Arg(1), *seed, levels)...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return exit.ExitCode()
+		}
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		fmt.Fprintln(os.Stderr, "  Build the backtest first with make build, and run lft2 try from the repository root")
+		return 1
+	}
+	return 0
+}
+
+// tryArgs are the backtest's arguments for the sandbox. Exit levels left at
+// zero aren't passed, so the backtest's defaults apply.
+func tryArgs(strategy, symbol string, seed uint64, levels map[string]float64) []string {
+	args := []string{"--try", strategy, symbol, "--seed", strconv.FormatUint(seed, 10)}
+	for _, name := range []string{"--tp", "--sl", "--tsl"} {
+		if pct := levels[name]; pct > 0 {
+			args = append(args, name, strconv.FormatFloat(pct, 'f', -1, 64))
+		}
+	}
+	return args
+}
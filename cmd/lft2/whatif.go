@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/fees"
+	"github.com/deanturpin/lft2/internal/report"
+	"github.com/deanturpin/lft2/internal/schema"
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+// kellyMinTrades is how many closed trades Kelly needs before it sizes from
+// its own estimate; until then it falls back to the fixed fraction.
+const kellyMinTrades = 10
+
+// trade is one closed position from the fills: buys and sells sharing a
+// client_order_id, as entries and exits write them.
+type trade struct {
+	Symbol  string
+	OrderID string
+	Opened  time.Time
+	Closed  time.Time
+	Cost    float64 // What the buys actually cost
+	Return  float64 // Net of fees, as a fraction of Cost
+}
+
+// sizingRule sizes an entry from the equity at the time and the trades
+// closed before it. A size of zero skips the trade.
+type sizingRule struct {
+	Name string
+	size func(equity float64, t trade, closed []trade) float64
+}
+
+// whatIfCurve is how one rule's equity evolved, a point per closed trade.
+type whatIfCurve struct {
+	Rule        string    `json:"rule"`
+	Timestamps  []string  `json:"timestamps"`
+	Equity      []float64 `json:"equity"`
+	FinalEquity float64   `json:"final_equity"`
+	Return      float64   `json:"return"`
+	MaxDrawdown float64   `json:"max_drawdown"`
+	Traded      int       `json:"traded"`
+	Skipped     int       `json:"skipped"` // Sized to nothing, or no cash left
+}
+
+// whatIf is the -o output.
+type whatIf struct {
+	schema.Header
+	Timestamp string        `json:"timestamp"`
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	Capital   float64       `json:"capital"`
+	Trades    int           `json:"trades"`
+	Open      int           `json:"open"` // Positions still open at the end, left out
+	Curves    []whatIfCurve `json:"curves"`
+}
+
+// runWhatIf replays the fills in a date range under other sizing rules, so
+// a sizing setting can be chosen from how the same trades would have
+// compounded rather than by guesswork:
+//
+//	lft2 whatif                                  the last 90 days
+//	lft2 whatif -from 2026-01-01 -fraction 0.05  year to date, 5% per trade
+//
+// Every rule takes the same trades at the same returns; only the amount put
+// into each differs. Sizes are in dollars, not whole shares, and an entry is
+// capped at the cash free when it opens.
+func runWhatIf(args []string) int {
+	now := time.Now()
+	fs := flag.NewFlagSet("whatif", flag.ContinueOnError)
+	from := fs.String("from", tz.Date(now.AddDate(0, 0, -90)), "First trading day (YYYY-MM-DD, reporting timezone)")
+	to := fs.String("to", tz.Date(now), "Last trading day, inclusive")
+	capital := fs.Float64("capital", 100000, "Starting equity for every rule")
+	fraction := fs.Float64("fraction", 0.02, "Fixed fractional: share of equity per trade")
+	slots := fs.Int("slots", 10, "Equal weight: positions the equity is split between")
+	kelly := fs.Float64("kelly", 0.5, "Kelly: multiple of the full Kelly fraction")
+	out := fs.String("o", "", "Also write the equity curves as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *capital <= 0 || *fraction <= 0 || *fraction > 1 || *slots < 1 || *kelly <= 0 {
+		fmt.Fprintln(os.Stderr, "✗ -capital, -fraction (up to 1), -slots and -kelly must be positive")
+		return 2
+	}
+
+	client, err := alpaca.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+	feeModel, err := fees.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ fee model: %v\n", err)
+		return 1
+	}
+	reporter := report.Reporter{
+		Broker: client,
+		Fees:   feeModel,
+		Log:    os.Stderr,
+	}
+	acts, err := reporter.Statement(*from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	trades, open := roundTrips(acts)
+	if len(trades) == 0 {
+		fmt.Fprintf(os.Stderr, "✗ no closed trades from %s to %s\n", *from, *to)
+		return 1
+	}
+	rules := sizingRules(*fraction, *slots, *kelly)
+	curves := make([]whatIfCurve, len(rules))
+	for i, rule := range rules {
+		curves[i] = replay(trades, rule, *capital)
+	}
+
+	fmt.Printf("What-if sizing, %s to %s: %d closed trade(s), $%.0f starting equity\n\n", *from, *to, len(trades), *capital)
+	printWhatIf(os.Stdout, curves)
+	if open > 0 {
+		fmt.Printf("\n  [skip] %d position(s) still open, left out\n", open)
+	}
+
+	if *out != "" {
+		data, err := json.MarshalIndent(whatIf{
+			Header:    schema.Current(),
+			Timestamp: now.UTC().Format(time.RFC3339),
+			From:      *from,
+			To:        *to,
+			Capital:   *capital,
+			Trades:    len(trades),
+			Open:      open,
+			Curves:    curves,
+		}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ encoding %s: %v\n", *out, err)
+			return 1
+		}
+		if err := os.WriteFile(*out, append(data, '\n'), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			return 1
+		}
+		fmt.Printf("\n✓ Wrote %s\n", *out)
+	}
+	return 0
+}
+
+// roundTrips pairs fills into closed trades, oldest opened first. A position
+// whose sells don't cover its buys by the end of the range is still open and
+// only counted.
+func roundTrips(acts []report.Activity) ([]trade, int) {
+	type position struct {
+		trade
+		bought, sold float64
+		proceeds     float64
+		fees         float64
+	}
+	byID := map[string]*position{}
+	var order []string
+	for _, a := range acts {
+		if a.ClientOrderID == "" {
+			continue
+		}
+		at, err := tz.Parse(a.TransactTime)
+		if err != nil {
+			continue
+		}
+		p, ok := byID[a.ClientOrderID]
+		if !ok {
+			if a.Side != "buy" {
+				continue // Opened before the range
+			}
+			p = &position{trade: trade{Symbol: a.Symbol, OrderID: a.ClientOrderID, Opened: at}}
+			byID[a.ClientOrderID] = p
+			order = append(order, a.ClientOrderID)
+		}
+		p.fees += float64(a.Fee)
+		switch a.Side {
+		case "buy":
+			p.bought += float64(a.Qty)
+			p.Cost += float64(a.Value)
+		case "sell":
+			p.sold += float64(a.Qty)
+			p.proceeds += float64(a.Value)
+			p.Closed = at
+		}
+	}
+
+	var trades []trade
+	open := 0
+	for _, id := range order {
+		p := byID[id]
+		if p.Cost <= 0 || p.sold < p.bought-1e-9 {
+			open++
+			continue
+		}
+		p.Return = (p.proceeds - p.Cost - p.fees) / p.Cost
+		trades = append(trades, p.trade)
+	}
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].Opened.Before(trades[j].Opened) })
+	return trades, open
+}
+
+// sizingRules are the rules compared, with what was actually traded first
+// as the baseline.
+func sizingRules(fraction float64, slots int, kelly float64) []sizingRule {
+	fixed := func(equity float64, _ trade, _ []trade) float64 { return equity * fraction }
+	return []sizingRule{
+		{"actual", func(_ float64, t trade, _ []trade) float64 { return t.Cost }},
+		{fmt.Sprintf("fixed %g%%", fraction*100), fixed},
+		{fmt.Sprintf("equal weight (%d)", slots), func(equity float64, _ trade, _ []trade) float64 {
+			return equity / float64(slots)
+		}},
+		{fmt.Sprintf("Kelly ×%g", kelly), func(equity float64, t trade, closed []trade) float64 {
+			if len(closed) < kellyMinTrades {
+				return fixed(equity, t, closed)
+			}
+			return equity * math.Min(1, kelly*kellyFraction(closed))
+		}},
+	}
+}
+
+// kellyFraction is the full Kelly fraction W - (1-W)/R from closed trades:
+// W the win rate, R the mean win over the mean loss. No edge, no bet.
+func kellyFraction(closed []trade) float64 {
+	wins, losses := 0, 0
+	won, lost := 0.0, 0.0
+	for _, t := range closed {
+		switch {
+		case t.Return > 0:
+			wins++
+			won += t.Return
+		case t.Return < 0:
+			losses++
+			lost -= t.Return
+		}
+	}
+	if wins == 0 {
+		return 0
+	}
+	if losses == 0 {
+		return 1
+	}
+	w := float64(wins) / float64(len(closed))
+	r := (won / float64(wins)) / (lost / float64(losses))
+	return math.Max(0, w-(1-w)/r)
+}
+
+// replay runs the trades through rule from capital. Entries and exits are
+// taken in time order; equity is cash plus open positions at cost, so it
+// moves only when a trade closes. A rule sees only trades closed before the
+// entry it's sizing.
+func replay(trades []trade, rule sizingRule, capital float64) whatIfCurve {
+	type event struct {
+		at    time.Time
+		exit  bool
+		index int
+	}
+	var events []event
+	for i, t := range trades {
+		events = append(events, event{t.Opened, false, i}, event{t.Closed, true, i})
+	}
+	// Exits first at the same instant, so the cash is free for the entry
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].at.Equal(events[j].at) {
+			return events[i].at.Before(events[j].at)
+		}
+		return events[i].exit && !events[j].exit
+	})
+
+	c := whatIfCurve{Rule: rule.Name}
+	cash, invested := capital, 0.0
+	peak := capital
+	size := make([]float64, len(trades))
+	var closed []trade
+	for _, e := range events {
+		t := trades[e.index]
+		if !e.exit {
+			s := math.Min(rule.size(cash+invested, t, closed), cash)
+			if s <= 0 {
+				c.Skipped++
+				continue
+			}
+			size[e.index] = s
+			cash -= s
+			invested += s
+			c.Traded++
+			continue
+		}
+
+		closed = append(closed, t)
+		if size[e.index] == 0 {
+			continue
+		}
+		invested -= size[e.index]
+		cash += size[e.index] * (1 + t.Return)
+		equity := cash + invested
+		peak = math.Max(peak, equity)
+		c.MaxDrawdown = math.Max(c.MaxDrawdown, (peak-equity)/peak)
+		c.Timestamps = append(c.Timestamps, t.Closed.UTC().Format(time.RFC3339))
+		c.Equity = append(c.Equity, round2(equity))
+	}
+	c.FinalEquity = round2(cash + invested)
+	c.Return = round4(c.FinalEquity/capital - 1)
+	c.MaxDrawdown = round4(c.MaxDrawdown)
+	return c
+}
+
+func printWhatIf(w io.Writer, curves []whatIfCurve) {
+	fmt.Fprintf(w, "  %-20s %14s %9s %13s %7s %8s\n", "Rule", "Final equity", "Return", "Max drawdown", "Traded", "Skipped")
+	for _, c := range curves {
+		fmt.Fprintf(w, "  %-20s %14.2f %8.2f%% %12.2f%% %7d %8d\n",
+			c.Rule, c.FinalEquity, c.Return*100, c.MaxDrawdown*100, c.Traded, c.Skipped)
+	}
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
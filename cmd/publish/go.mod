@@ -0,0 +1,18 @@
+module github.com/deanturpin/lft2/cmd/publish
+
+go 1.21
+
+require (
+	github.com/deanturpin/lft2/internal/artifact v0.0.0
+	github.com/deanturpin/lft2/internal/crash v0.0.0
+	github.com/deanturpin/lft2/internal/interrupt v0.0.0
+	github.com/deanturpin/lft2/internal/version v0.0.0
+)
+
+replace (
+	github.com/deanturpin/lft2/internal/artifact => ../../internal/artifact
+	github.com/deanturpin/lft2/internal/crash => ../../internal/crash
+	github.com/deanturpin/lft2/internal/interrupt => ../../internal/interrupt
+	github.com/deanturpin/lft2/internal/schema => ../../internal/schema
+	github.com/deanturpin/lft2/internal/version => ../../internal/version
+)
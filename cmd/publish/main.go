@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/artifact"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/version"
+)
+
+// Publish uploads the pipeline artifacts in docs/ to a blob store so the
+// dashboard and live fetch can read them without waiting for a GitHub Pages
+// deploy, which lags the pipeline by several minutes.
+func main() {
+	version.Handle("publish")
+	defer crash.Guard("publish")
+
+	dir := flag.String("dir", "docs", "Local artifact directory to publish")
+	storeURL := flag.String("store", os.Getenv("LFT2_ARTIFACT_STORE"),
+		"Destination: directory, s3://bucket/prefix or gs://bucket/prefix (default $LFT2_ARTIFACT_STORE)")
+	bars := flag.Bool("bars", true, "Include per-symbol bar files under bars/")
+	flag.Parse()
+
+	fmt.Println("Low Frequency Trader v2 - Publish Artifacts")
+	fmt.Println()
+
+	if *storeURL == "" {
+		fmt.Println("No artifact store configured (set LFT2_ARTIFACT_STORE) — nothing to do")
+		return
+	}
+
+	store, err := artifact.Open(*storeURL)
+	if err != nil {
+		log.Fatalf("Opening artifact store: %v", err)
+	}
+
+	fmt.Printf("Publishing %s/ → %s\n", *dir, store)
+
+	// Ctrl-C stops the walk once the upload in flight is done
+	ctx := interrupt.Context()
+
+	published, failed := 0, 0
+	err = filepath.WalkDir(*dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+
+		name, _ := filepath.Rel(*dir, path)
+		name = filepath.ToSlash(name)
+
+		if d.IsDir() {
+			if !*bars && name == "bars" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if err := store.Put(name, data); err != nil {
+			fmt.Printf("  ✗ %s: %v\n", name, err)
+			failed++
+			return nil
+		}
+		if !strings.HasPrefix(name, "bars/") {
+			fmt.Printf("  ✓ %s\n", name)
+		}
+		published++
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Walking %s: %v", *dir, err)
+	}
+
+	fmt.Printf("\n✓ Published %d file(s), %d failed\n", published, failed)
+	interrupt.Stop(ctx, "publish")
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
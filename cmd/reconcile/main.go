@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/artifact"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/httpconf"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/report"
+	"github.com/deanturpin/lft2/internal/schema"
+	"github.com/deanturpin/lft2/internal/tz"
+	"github.com/deanturpin/lft2/internal/version"
+)
+
+const reportName = "reconciliation.json"
+
+// loadPrevious returns the last reconciliation report, preferring the local
+// copy and falling back to the published one so a fresh CI checkout still
+// knows yesterday's closing cash.
+func loadPrevious(dir string) *Report {
+	data, err := os.ReadFile(dir + "/" + reportName)
+	if err != nil {
+		if data, err = artifact.Fetch(artifact.Base(), reportName); err != nil {
+			return nil
+		}
+	}
+	var r Report
+	if schema.Check(reportName, data) != nil || json.Unmarshal(data, &r) != nil {
+		return nil
+	}
+	return &r
+}
+
+// Reconcile compares the day's journal (daily-summary.json) with Alpaca's
+// account balances and activities, flagging any unexplained difference.
+// The last run after the close is the day's statement.
+func main() {
+	version.Handle("reconcile")
+	defer crash.Guard("reconcile", "docs/daily-summary.json", "docs/"+reportName)
+
+	// Ctrl-C stops once the reconciliation report is written
+	ctx := interrupt.Context()
+
+	tz.SetLog()
+
+	dir := flag.String("dir", "docs", "Artifact directory")
+	tolerance := flag.Float64("tolerance", 0.01, "Largest acceptable difference in USD")
+	strict := flag.Bool("strict", false, "Exit non-zero on any mismatch")
+	jump := flag.Float64("jump", report.DefaultJump, "Unexplained day-over-day equity or margin usage move to flag, as a fraction")
+	flag.Parse()
+
+	fmt.Println("Low Frequency Trader v2 - Reconciliation")
+	fmt.Println()
+
+	client, err := alpaca.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	policy, err := httpconf.ForStage("reconcile")
+	if err != nil {
+		log.Fatal(err)
+	}
+	client.HTTP = policy
+
+	data, err := os.ReadFile(*dir + "/daily-summary.json")
+	if err != nil {
+		log.Fatalf("reading journal (run summary first): %v", err)
+	}
+	if err := schema.Check("daily-summary.json", data); err != nil {
+		log.Fatal(err)
+	}
+	var journal Journal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		log.Fatalf("parsing daily-summary.json: %v", err)
+	}
+
+	account, err := client.Account()
+	if err != nil {
+		log.Fatalf("fetching account: %v", err)
+	}
+	activities, err := client.Activities(journal.Date)
+	if err != nil {
+		log.Fatalf("fetching activities: %v", err)
+	}
+
+	opening := openingCash(loadPrevious(*dir), journal.Date)
+	rec := reconcile(journal, activities, *account, opening, alpaca.Decimal(*tolerance))
+	rec.Header = schema.Current()
+	rec.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+
+	fmt.Printf("Date %s, tolerance $%s\n", rec.Date, rec.Tolerance.Money())
+	for _, c := range rec.Checks {
+		mark := "✓"
+		if !c.OK {
+			mark = "✗"
+		}
+		fmt.Printf("  %s %-7s journal $%-12s broker $%-12s diff $%s\n",
+			mark, c.Name, c.Expected.Money(), c.Actual.Money(), c.Diff.Money())
+		if c.Note != "" {
+			fmt.Printf("            %s\n", c.Note)
+		}
+	}
+
+	out, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		log.Fatalf("encoding report: %v", err)
+	}
+	path := *dir + "/" + reportName
+	if err := os.WriteFile(path, append(out, '\n'), 0644); err != nil {
+		log.Fatalf("writing %s: %v", path, err)
+	}
+	fmt.Printf("\n✓ Wrote %s (%s)\n", path, rec.Status)
+
+	interrupt.Stop(ctx, "reconcile")
+
+	// Balances against the last day account snapshotted: a jump the day's
+	// trades and transfers don't explain usually surfaces here first
+	cur := report.Snapshot(*account, time.Now())
+	if prev := previousSnapshot(*dir, cur.Date); prev == nil {
+		fmt.Println("  [skip] account changes: no earlier snapshot")
+	} else {
+		_, _, other := brokerFlows(activities)
+		flows := report.Flows{TradeCash: journal.Summary.NetCashFlow, Other: other, Buys: journal.buys()}
+		changes := report.CompareAccounts(*prev, cur, flows, alpaca.Decimal(*tolerance), *jump)
+		fmt.Printf("\nSince %s:\n", changes.Previous)
+		for _, c := range changes.Changes {
+			mark := "✓"
+			if c.Flagged {
+				mark = "✗"
+			}
+			fmt.Printf("  %s %-12s %12.4f → %-12.4f change %+.4f\n", mark, c.Name, c.Before, c.After, c.Change)
+			if c.Note != "" {
+				fmt.Printf("                 %s\n", c.Note)
+			}
+		}
+		path := *dir + "/" + filepath.Base(report.AccountChangesPath)
+		if err := report.SaveAccountChanges(path, changes); err != nil {
+			log.Fatalf("writing %s: %v", path, err)
+		}
+		fmt.Printf("✓ Wrote %s (%s)\n", path, changes.Status)
+	}
+
+	interrupt.Stop(ctx, "reconcile")
+	if *strict && rec.Status != "ok" {
+		os.Exit(1)
+	}
+}
+
+// previousSnapshot returns the latest account snapshot from before date,
+// preferring the local history and falling back to the snapshot carried in
+// the published account-changes.json, so a fresh CI checkout still has
+// yesterday's balances.
+func previousSnapshot(dir, date string) *report.AccountSnapshot {
+	prev, err := report.PreviousSnapshot(dir+"/"+filepath.Base(report.AccountHistoryDir), date)
+	if err != nil {
+		fmt.Printf("  [skip] account history: %v\n", err)
+	}
+	if prev != nil {
+		return prev
+	}
+	name := filepath.Base(report.AccountChangesPath)
+	data, err := artifact.Fetch(artifact.Base(), name)
+	if err != nil {
+		return nil
+	}
+	published, err := report.ParseAccountChanges(name, data)
+	if err != nil {
+		fmt.Printf("  [skip] published account changes: %v\n", err)
+		return nil
+	}
+	if published.Current.Date == "" || published.Current.Date >= date {
+		return nil
+	}
+	return &published.Current
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// Journal is the subset of daily-summary.json needed to reconcile.
+type Journal struct {
+	Date       string `json:"date"`
+	Activities []struct {
+		Side  string         `json:"side"`
+		Value alpaca.Decimal `json:"value"`
+		Fee   alpaca.Decimal `json:"fee"`
+	} `json:"activities"`
+	Summary struct {
+		Fees        alpaca.Decimal `json:"fees"`
+		NetCashFlow alpaca.Decimal `json:"net_cash_flow"`
+	} `json:"summary"`
+}
+
+// buys counts the journal's buy fills.
+func (j Journal) buys() int {
+	n := 0
+	for _, a := range j.Activities {
+		if a.Side == "buy" {
+			n++
+		}
+	}
+	return n
+}
+
+// Check compares one journal-derived figure with the broker's.
+type Check struct {
+	Name     string         `json:"name"`
+	Expected alpaca.Decimal `json:"expected"` // from our journal
+	Actual   alpaca.Decimal `json:"actual"`   // from Alpaca
+	Diff     alpaca.Decimal `json:"diff"`
+	OK       bool           `json:"ok"`
+	Note     string         `json:"note,omitempty"`
+}
+
+// Report is written to docs/reconciliation.json. ClosingCash becomes the
+// next trading day's opening balance.
+type Report struct {
+	schema.Header
+	Date        string         `json:"date"`
+	GeneratedAt string         `json:"generated_at"`
+	Status      string         `json:"status"` // "ok" or "mismatch"
+	Tolerance   alpaca.Decimal `json:"tolerance"`
+	OpeningCash alpaca.Decimal `json:"opening_cash"`
+	ClosingCash alpaca.Decimal `json:"closing_cash"`
+	Checks      []Check        `json:"checks"`
+}
+
+// brokerFlows splits the day's activities into trade cash flow, fees
+// charged, and everything else (dividends, deposits, interest).
+func brokerFlows(acts []alpaca.Activity) (fills, fees, other alpaca.Decimal) {
+	for _, a := range acts {
+		switch {
+		case a.IsFill():
+			value := a.Qty.Mul(a.Price, 2)
+			if a.Side == "buy" {
+				fills -= value
+			} else {
+				fills += value
+			}
+		case a.ActivityType == "FEE":
+			fees -= a.NetAmount // charged as negative amounts
+		default:
+			other += a.NetAmount
+		}
+	}
+	return fills.Round(2), fees.Round(2), other.Round(2)
+}
+
+func check(name string, expected, actual, tolerance alpaca.Decimal) Check {
+	diff := (actual - expected).Round(2)
+	abs := diff
+	if abs < 0 {
+		abs = -abs
+	}
+	return Check{Name: name, Expected: expected.Round(2), Actual: actual.Round(2), Diff: diff, OK: abs <= tolerance}
+}
+
+// reconcile compares the journal with the broker's account and activities.
+// opening is the previous close's cash balance; nil means there is no
+// history yet, in which case it is inferred from the broker's own figures
+// and the cash check only confirms the account is internally consistent.
+func reconcile(j Journal, acts []alpaca.Activity, acct alpaca.Account, opening *alpaca.Decimal, tolerance alpaca.Decimal) Report {
+	fills, fees, other := brokerFlows(acts)
+
+	r := Report{Date: j.Date, Tolerance: tolerance, ClosingCash: acct.Cash}
+
+	// Trades: our gross cash flow against the broker's fills
+	journalFills := j.Summary.NetCashFlow + j.Summary.Fees
+	r.Checks = append(r.Checks, check("fills", journalFills, fills, tolerance))
+
+	// Fees: our fee model against what the broker actually charged
+	r.Checks = append(r.Checks, check("fees", j.Summary.Fees, fees, tolerance))
+
+	// Cash: opening balance plus every explained movement
+	note := ""
+	if opening != nil {
+		r.OpeningCash = *opening
+	} else {
+		r.OpeningCash = (acct.Cash - fills + fees - other).Round(2)
+		note = "no prior close on record; opening cash inferred from broker activities"
+	}
+	cash := check("cash", r.OpeningCash+j.Summary.NetCashFlow+other, acct.Cash, tolerance)
+	cash.Note = note
+	if other != 0 {
+		cash.Note = appendNote(cash.Note, fmt.Sprintf("includes $%s non-trade activity", other.Money()))
+	}
+	r.Checks = append(r.Checks, cash)
+
+	// Equity: the broker's own identity — cash plus market value
+	r.Checks = append(r.Checks, check("equity", acct.Cash+acct.LongMarketValue+acct.ShortMarketValue, acct.Equity, tolerance))
+
+	r.Status = "ok"
+	for _, c := range r.Checks {
+		if !c.OK {
+			r.Status = "mismatch"
+		}
+	}
+	return r
+}
+
+func appendNote(note, more string) string {
+	if note == "" {
+		return more
+	}
+	return note + "; " + more
+}
+
+// openingCash picks the opening balance for date from the previous report.
+// A report from the same day carries its own opening balance forward; an
+// earlier report's closing cash is today's opening.
+func openingCash(prev *Report, date string) *alpaca.Decimal {
+	switch {
+	case prev == nil || prev.Date == "":
+		return nil
+	case prev.Date == date:
+		v := prev.OpeningCash
+		return &v
+	case prev.Date < date:
+		v := prev.ClosingCash
+		return &v
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+func journalFrom(t *testing.T, s string) Journal {
+	t.Helper()
+	var j Journal
+	if err := json.Unmarshal([]byte(s), &j); err != nil {
+		t.Fatalf("bad journal fixture: %v", err)
+	}
+	return j
+}
+
+// A day with one round trip: buy 10 @ 100, sell 10 @ 101.
+const roundTrip = `{
+  "date": "2026-03-10",
+  "activities": [
+    {"side": "buy", "value": "1000", "fee": "0"},
+    {"side": "sell", "value": "1010", "fee": "0.02"}
+  ],
+  "summary": {"fees": "0.02", "net_cash_flow": "9.98"}
+}`
+
+var roundTripActivities = []alpaca.Activity{
+	{ActivityType: "FILL", Side: "buy", Qty: 10, Price: 100},
+	{ActivityType: "FILL", Side: "sell", Qty: 10, Price: 101},
+	{ActivityType: "FEE", NetAmount: -0.02},
+}
+
+// --- brokerFlows ---
+
+func TestBrokerFlows(t *testing.T) {
+	acts := append(roundTripActivities, alpaca.Activity{ActivityType: "DIV", NetAmount: 1.50})
+	fills, fees, other := brokerFlows(acts)
+	if fills != 10 || fees != 0.02 || other != 1.5 {
+		t.Errorf("got fills=%v fees=%v other=%v", fills, fees, other)
+	}
+}
+
+// --- reconcile ---
+
+func TestReconcile_Balanced(t *testing.T) {
+	opening := alpaca.Decimal(5000)
+	acct := alpaca.Account{Cash: 5009.98, Equity: 5009.98}
+
+	r := reconcile(journalFrom(t, roundTrip), roundTripActivities, acct, &opening, 0.01)
+	if r.Status != "ok" {
+		t.Errorf("got %s, want ok: %+v", r.Status, r.Checks)
+	}
+	if r.ClosingCash != 5009.98 {
+		t.Errorf("closing cash: got %v", r.ClosingCash)
+	}
+}
+
+func TestReconcile_FeeMismatch(t *testing.T) {
+	opening := alpaca.Decimal(5000)
+	acts := append([]alpaca.Activity{}, roundTripActivities...)
+	acts[2].NetAmount = -0.05 // broker charged more than we modelled
+	acct := alpaca.Account{Cash: 5009.95, Equity: 5009.95}
+
+	r := reconcile(journalFrom(t, roundTrip), acts, acct, &opening, 0.01)
+	if r.Status != "mismatch" {
+		t.Fatalf("got %s, want mismatch", r.Status)
+	}
+	want := map[string]string{"fills": "0.00", "fees": "0.03", "cash": "-0.03", "equity": "0.00"}
+	for _, c := range r.Checks {
+		if got := c.Diff.Money(); got != want[c.Name] {
+			t.Errorf("%s diff: got %s, want %s", c.Name, got, want[c.Name])
+		}
+	}
+}
+
+func TestReconcile_NoHistoryInfersOpening(t *testing.T) {
+	acct := alpaca.Account{Cash: 5009.98, Equity: 5009.98}
+	r := reconcile(journalFrom(t, roundTrip), roundTripActivities, acct, nil, 0.01)
+	if r.OpeningCash != 5000 {
+		t.Errorf("opening: got %v, want 5000", r.OpeningCash)
+	}
+	if r.Status != "ok" {
+		t.Errorf("got %s, want ok", r.Status)
+	}
+}
+
+// --- openingCash ---
+
+func TestOpeningCash(t *testing.T) {
+	prev := &Report{Date: "2026-03-09", OpeningCash: 4000, ClosingCash: 5000}
+	if got := openingCash(prev, "2026-03-10"); got == nil || *got != 5000 {
+		t.Errorf("next day: got %v, want 5000", got)
+	}
+	if got := openingCash(prev, "2026-03-09"); got == nil || *got != 4000 {
+		t.Errorf("same day: got %v, want 4000", got)
+	}
+	if openingCash(nil, "2026-03-10") != nil {
+		t.Error("no previous report should give nil")
+	}
+}
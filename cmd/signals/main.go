@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/events"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/version"
+)
+
+// Signals mirrors this cycle's order intents to an external webhook or NDJSON
+// file, and to the event bus if there is one, before execute runs, so they
+// can drive another broker or analysis without going through execution. It
+// only reads buy.fix and sell.fix.
+func main() {
+	version.Handle("signals")
+	defer crash.Guard("signals", "docs/buy.fix", "docs/sell.fix")
+
+	dest := flag.String("to", os.Getenv("LFT2_SIGNALS_WEBHOOK"),
+		"Destination: an http(s) URL to POST NDJSON to, or a file to append it to (default $LFT2_SIGNALS_WEBHOOK)")
+	flag.Parse()
+
+	fmt.Println("Low Frequency Trader v2 - Signals")
+	fmt.Println()
+
+	bus, err := events.FromEnv()
+	if err != nil {
+		log.Fatalf("Event bus: %v", err)
+	}
+	if bus != nil {
+		defer bus.Close()
+	}
+
+	if *dest == "" && bus == nil {
+		fmt.Println("No signals destination configured (set LFT2_SIGNALS_WEBHOOK or LFT2_EVENT_BUS) — nothing to do")
+		return
+	}
+
+	// Ctrl-C after the webhook leaves the bus unpublished
+	ctx := interrupt.Context()
+
+	now := time.Now()
+	var signals []Signal
+	for _, path := range []string{"docs/buy.fix", "docs/sell.fix"} {
+		f, err := os.Open(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("  [skip] %s not found\n", path)
+			continue
+		}
+		if err != nil {
+			log.Fatalf("Opening %s: %v", path, err)
+		}
+		read, err := readSignals(f, now)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Reading %s: %v", path, err)
+		}
+		signals = append(signals, read...)
+	}
+
+	if len(signals) == 0 {
+		fmt.Println("✓ No signals this cycle — nothing sent")
+		return
+	}
+	for _, s := range signals {
+		fmt.Printf("  %-4s %-6s qty=%s\n", s.Side, s.Symbol, s.Qty)
+	}
+
+	if *dest != "" {
+		body, err := ndjson(signals)
+		if err != nil {
+			log.Fatalf("Encoding signals: %v", err)
+		}
+		if err := emit(*dest, body); err != nil {
+			log.Fatalf("Sending signals: %v", err)
+		}
+		fmt.Printf("\n✓ Sent %d signal(s)\n", len(signals))
+	}
+
+	interrupt.Stop(ctx, "signals")
+	if bus != nil {
+		for _, s := range signals {
+			if err := events.Publish(bus, events.Signals, s.Symbol, s, now); err != nil {
+				log.Fatalf("Publishing signals: %v", err)
+			}
+		}
+		fmt.Printf("\n✓ Published %d signal(s) to the event bus\n", len(signals))
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// fixTime is the layout of FIX UTCTimestamp fields such as ExpireTime (126).
+const fixTime = "20060102-15:04:05"
+
+// Signal is one order intent from buy.fix or sell.fix, as sent to the
+// webhook. Qty is the intended size; execute may still round or skip it.
+type Signal struct {
+	schema.Header
+	Timestamp     string `json:"timestamp"` // When this run emitted it
+	Side          string `json:"side"`
+	Symbol        string `json:"symbol"`
+	Qty           string `json:"qty"`
+	ClientOrderID string `json:"client_order_id,omitempty"`
+	Strategy      string `json:"strategy,omitempty"` // Buys: the entry strategy
+	Reason        string `json:"reason,omitempty"`   // Sells: why the position exits
+	ValidUntil    string `json:"valid_until,omitempty"`
+}
+
+// parseFIX splits a pipe-delimited FIX line into tag → value.
+func parseFIX(line string) map[string]string {
+	fields := map[string]string{}
+	for _, pair := range strings.Split(line, "|") {
+		if tag, value, ok := strings.Cut(pair, "="); ok {
+			fields[tag] = value
+		}
+	}
+	return fields
+}
+
+// readSignals returns the orders in a .fix file as signals, skipping
+// heartbeats and anything without a symbol or side.
+func readSignals(r io.Reader, now time.Time) ([]Signal, error) {
+	var signals []Signal
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := parseFIX(strings.TrimSpace(scanner.Text()))
+		if fields["35"] != "D" || fields["55"] == "" {
+			continue
+		}
+		s := Signal{
+			Header:        schema.Current(),
+			Timestamp:     now.UTC().Format(time.RFC3339),
+			Symbol:        fields["55"],
+			Qty:           fields["38"],
+			ClientOrderID: fields["11"],
+		}
+		switch fields["54"] {
+		case "1":
+			s.Side, s.Strategy = "buy", fields["58"]
+		case "2":
+			s.Side, s.Reason = "sell", fields["58"]
+		default:
+			continue
+		}
+		if t, err := time.Parse(fixTime, fields["126"]); err == nil {
+			s.ValidUntil = t.UTC().Format(time.RFC3339)
+		}
+		signals = append(signals, s)
+	}
+	return signals, scanner.Err()
+}
+
+// ndjson encodes signals one JSON object per line.
+func ndjson(signals []Signal) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, s := range signals {
+		if err := enc.Encode(s); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// emit sends body to dest: an http(s) URL receives it as one POST of
+// application/x-ndjson, anything else is a file it's appended to.
+func emit(dest string, body []byte) error {
+	if !strings.HasPrefix(dest, "http://") && !strings.HasPrefix(dest, "https://") {
+		f, err := os.OpenFile(dest, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(body); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	resp, err := webhookClient.Post(dest, "application/x-ndjson", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(reply)))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const fixFile = `8=FIX.5.0SP2|9=40|35=0|58=2 buy order(s)|10=000|
+8=FIX.5.0SP2|9=120|35=D|11=AAPL_macd_crossover_p0_1741615200|21=1|55=AAPL|54=1|38=5|40=1|59=0|58=macd_crossover|126=20260310-15:07:00|10=000|
+8=FIX.5.0SP2|9=90|35=D|11=MSFT_x|21=1|55=MSFT|54=2|38=3|40=1|59=0|58=take_profit|10=000|
+8=FIX.5.0SP2|9=0|35=D|10=000|
+`
+
+// --- readSignals ---
+
+func TestReadSignals(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 3, 0, 0, time.UTC)
+	signals, err := readSignals(strings.NewReader(fixFile), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signals) != 2 {
+		t.Fatalf("got %d signals, want 2 (heartbeat and empty order skipped): %+v", len(signals), signals)
+	}
+	buy, sell := signals[0], signals[1]
+	if buy.Side != "buy" || buy.Symbol != "AAPL" || buy.Qty != "5" || buy.Strategy != "macd_crossover" || buy.ValidUntil != "2026-03-10T15:07:00Z" {
+		t.Errorf("buy: got %+v", buy)
+	}
+	if sell.Side != "sell" || sell.Symbol != "MSFT" || sell.Reason != "take_profit" || sell.ValidUntil != "" {
+		t.Errorf("sell: got %+v", sell)
+	}
+	if buy.Timestamp != "2026-03-10T15:03:00Z" || buy.SchemaVersion == 0 {
+		t.Errorf("header: got %+v", buy)
+	}
+}
+
+// --- ndjson / emit ---
+
+func TestEmit_Webhook(t *testing.T) {
+	var got []string
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		got = strings.Split(strings.TrimSpace(string(body)), "\n")
+	}))
+	defer srv.Close()
+
+	signals, _ := readSignals(strings.NewReader(fixFile), time.Now())
+	body, err := ndjson(signals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := emit(srv.URL, body); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+	if contentType != "application/x-ndjson" || len(got) != 2 {
+		t.Fatalf("got %q with %d line(s)", contentType, len(got))
+	}
+	var first Signal
+	if err := json.Unmarshal([]byte(got[0]), &first); err != nil || first.Symbol != "AAPL" {
+		t.Errorf("first line: got %+v, %v", first, err)
+	}
+}
+
+func TestEmit_WebhookError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	if err := emit(srv.URL, []byte("{}\n")); err == nil || !strings.Contains(err.Error(), "HTTP 403") {
+		t.Errorf("got %v, want HTTP 403", err)
+	}
+}
+
+func TestEmit_FileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signals.ndjson")
+	for i := 0; i < 2; i++ {
+		if err := emit(path, []byte(`{"symbol":"AAPL"}`+"\n")); err != nil {
+			t.Fatalf("emit: %v", err)
+		}
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Count(string(data), "\n") != 2 {
+		t.Errorf("got %q, want two appended lines", data)
+	}
+}
@@ -0,0 +1,29 @@
+module github.com/deanturpin/lft2/cmd/stream
+
+go 1.21
+
+require (
+	github.com/deanturpin/lft2/internal/artifact v0.0.0
+	github.com/deanturpin/lft2/internal/barfile v0.0.0
+	github.com/deanturpin/lft2/internal/crash v0.0.0
+	github.com/deanturpin/lft2/internal/interrupt v0.0.0
+	github.com/deanturpin/lft2/internal/manifest v0.0.0
+	github.com/deanturpin/lft2/internal/marketdata v0.0.0
+	github.com/deanturpin/lft2/internal/schema v0.0.0
+	github.com/deanturpin/lft2/internal/version v0.0.0
+	github.com/deanturpin/lft2/internal/websocket v0.0.0
+)
+
+replace (
+	github.com/deanturpin/lft2/internal/alpaca => ../../internal/alpaca
+	github.com/deanturpin/lft2/internal/artifact => ../../internal/artifact
+	github.com/deanturpin/lft2/internal/barfile => ../../internal/barfile
+	github.com/deanturpin/lft2/internal/crash => ../../internal/crash
+	github.com/deanturpin/lft2/internal/httpconf => ../../internal/httpconf
+	github.com/deanturpin/lft2/internal/interrupt => ../../internal/interrupt
+	github.com/deanturpin/lft2/internal/manifest => ../../internal/manifest
+	github.com/deanturpin/lft2/internal/marketdata => ../../internal/marketdata
+	github.com/deanturpin/lft2/internal/schema => ../../internal/schema
+	github.com/deanturpin/lft2/internal/version => ../../internal/version
+	github.com/deanturpin/lft2/internal/websocket => ../../internal/websocket
+)
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/artifact"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/manifest"
+	"github.com/deanturpin/lft2/internal/marketdata"
+	"github.com/deanturpin/lft2/internal/version"
+	"github.com/deanturpin/lft2/internal/websocket"
+)
+
+// streamURL is Alpaca's market data stream; the feed, sip or iex, is the
+// last path element.
+const streamURL = "wss://stream.data.alpaca.markets/v2/"
+
+// dialTimeout bounds connecting and authenticating.
+const dialTimeout = 10 * time.Second
+
+// Backoff between reconnects, doubling from the first to the most
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+type Config struct {
+	Symbols      []string
+	BarsDir      string
+	ManifestFile string
+	Keep         int
+	TimeframeMin int
+	Feed         string
+	URL          string
+	Duration     time.Duration
+	APIKey       string
+	APISecret    string
+}
+
+// errAuth marks a stream that turned the credentials away, which retrying
+// won't fix.
+var errAuth = errors.New("stream authentication failed")
+
+// loadSymbols returns the symbols in list, comma separated, or else the
+// published candidates.json's.
+func loadSymbols(list, base string) ([]string, error) {
+	var symbols []string
+	if list != "" {
+		symbols = strings.Split(list, ",")
+	} else {
+		data, err := artifact.Fetch(base, "candidates.json")
+		if err != nil {
+			return nil, fmt.Errorf("downloading candidates: %w", err)
+		}
+		var candidates struct {
+			Symbols []string `json:"symbols"`
+		}
+		if err := json.Unmarshal(data, &candidates); err != nil {
+			return nil, fmt.Errorf("parsing candidates: %w", err)
+		}
+		symbols = candidates.Symbols
+	}
+
+	seen := map[string]bool{}
+	var kept []string
+	for _, s := range symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s != "" && !seen[s] {
+			seen[s] = true
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, errors.New("no symbols to stream")
+	}
+	return kept, nil
+}
+
+// session connects once, authenticates, subscribes to bars and hands every
+// frame to s until the connection drops, deadline passes or ctx is
+// cancelled. A frame being handled is finished first.
+func session(ctx context.Context, cfg Config, s *streamer, deadline time.Time) error {
+	conn, err := websocket.Dial(cfg.URL, dialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// The stream says "connected", then "authenticated" once it has the key
+	conn.SetReadDeadline(time.Now().Add(dialTimeout))
+	if err := expect(conn, "connected"); err != nil {
+		return err
+	}
+	auth, _ := json.Marshal(map[string]string{"action": "auth", "key": cfg.APIKey, "secret": cfg.APISecret})
+	if err := conn.Write(auth); err != nil {
+		return err
+	}
+	if err := expect(conn, "authenticated"); err != nil {
+		return err
+	}
+	subscribe, _ := json.Marshal(map[string]any{"action": "subscribe", "bars": cfg.Symbols})
+	if err := conn.Write(subscribe); err != nil {
+		return err
+	}
+
+	conn.SetReadDeadline(deadline)
+	for {
+		frame, err := conn.Read()
+		if err != nil {
+			return err
+		}
+		if err := s.handle(frame); err != nil {
+			return err
+		}
+	}
+}
+
+// expect reads one frame and checks it's the success message msg. An error
+// message during the handshake is an auth failure.
+func expect(conn *websocket.Conn, msg string) error {
+	frame, err := conn.Read()
+	if err != nil {
+		return err
+	}
+	var msgs []message
+	if err := json.Unmarshal(frame, &msgs); err != nil || len(msgs) == 0 {
+		return fmt.Errorf("stream handshake: unexpected %s", frame)
+	}
+	switch m := msgs[0]; {
+	case m.Type == "success" && m.Msg == msg:
+		return nil
+	case m.Type == "error":
+		return fmt.Errorf("%w: %d %s", errAuth, m.Code, m.Msg)
+	default:
+		return fmt.Errorf("stream handshake: got %s, want %s", frame, msg)
+	}
+}
+
+// Stream appends the candidates' bars as they close, from Alpaca's market
+// data WebSocket for the named feed. Minute bars are combined into
+// -timeframe bars and appended only to files fetch already wrote from the
+// same feed and provider; a bar the stream joined partway through is left
+// for the next fetch. It reconnects with backoff until -duration passes.
+func main() {
+	version.Handle("stream")
+	defer crash.Guard("stream")
+
+	cfg := Config{}
+	symbols := flag.String("symbols", "", "Comma-separated symbols to stream (default the published candidates.json's)")
+	base := flag.String("pages-base", artifact.Base(), "Artifact base URL or directory for candidates.json (default $LFT2_ARTIFACT_BASE)")
+	flag.StringVar(&cfg.BarsDir, "bars", "docs/bars", "Bar data directory to append to")
+	flag.StringVar(&cfg.ManifestFile, "manifest", manifest.DefaultPath, "Bars manifest to keep in step (empty to skip)")
+	flag.IntVar(&cfg.Keep, "keep", 1000, "Most bars to keep per symbol, as fetch's -bars")
+	flag.IntVar(&cfg.TimeframeMin, "timeframe", 5, "Timeframe in minutes the stream's minute bars are combined into")
+	flag.StringVar(&cfg.Feed, "feed", marketdata.FeedFromEnv(), "Alpaca bar feed, sip or iex, the same as fetch's (default $ALPACA_FEED)")
+	flag.StringVar(&cfg.URL, "url", "", "Stream URL (default Alpaca's for -feed)")
+	flag.DurationVar(&cfg.Duration, "duration", 0, "Stop after this long, e.g. 6h30m; 0 runs until interrupted")
+	flag.Parse()
+
+	fmt.Println("Low Frequency Trader v2 - Stream Bars")
+	fmt.Println()
+
+	if err := marketdata.CheckFeed(cfg.Feed); err != nil {
+		log.Fatalf("-feed: %v", err)
+	}
+	// A stream names its feed in the URL, and appends only to bar files
+	// fetched from the same one, so it can't be left to Alpaca
+	if cfg.Feed == "" {
+		log.Fatal("-feed or ALPACA_FEED required: set the same feed for fetch, whose bar files the stream appends to")
+	}
+	if cfg.TimeframeMin < 1 || 60%cfg.TimeframeMin != 0 {
+		log.Fatalf("-timeframe must divide an hour, got %d", cfg.TimeframeMin)
+	}
+	if cfg.URL == "" {
+		cfg.URL = streamURL + cfg.Feed
+	}
+	cfg.APIKey = os.Getenv("ALPACA_API_KEY")
+	cfg.APISecret = os.Getenv("ALPACA_API_SECRET")
+	if cfg.APIKey == "" || cfg.APISecret == "" {
+		log.Fatal("ALPACA_API_KEY and ALPACA_API_SECRET environment variables required")
+	}
+
+	var err error
+	if cfg.Symbols, err = loadSymbols(*symbols, *base); err != nil {
+		log.Fatal(err)
+	}
+
+	s := &streamer{
+		bars:         cfg.BarsDir,
+		manifestPath: cfg.ManifestFile,
+		keep:         cfg.Keep,
+		feed:         cfg.Feed,
+		out:          os.Stdout,
+		now:          time.Now,
+	}
+	if cfg.ManifestFile != "" {
+		if data, err := os.ReadFile(cfg.ManifestFile); err == nil {
+			if s.manifest, err = manifest.Parse(cfg.ManifestFile, data); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	ctx := interrupt.Context()
+
+	// Far enough off to mean never, and still a valid read deadline
+	deadline := time.Now().AddDate(1, 0, 0)
+	if cfg.Duration > 0 {
+		deadline = time.Now().Add(cfg.Duration)
+	}
+	fmt.Printf("Streaming %d-minute %s bars for %d symbol(s) into %s/\n", cfg.TimeframeMin, cfg.Feed, len(cfg.Symbols), cfg.BarsDir)
+
+	backoff := minBackoff
+	for {
+		// Minutes missed while disconnected would leave a bar short, so
+		// each connection starts its bars afresh
+		s.agg = newAggregator(cfg.TimeframeMin)
+		started := time.Now()
+		err := session(ctx, cfg, s, deadline)
+		if ctx.Err() != nil {
+			interrupt.Exit("stream")
+		}
+		if !time.Now().Before(deadline) {
+			fmt.Println("✓ Duration reached")
+			return
+		}
+		if errors.Is(err, errAuth) {
+			log.Fatal(err)
+		}
+		// A connection that lasted a while was healthy; start the backoff over
+		if time.Since(started) > maxBackoff {
+			backoff = minBackoff
+		}
+		fmt.Printf("⚠ %v, reconnecting in %s\n", err, backoff)
+		interrupt.Sleep(ctx, backoff)
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/websocket"
+)
+
+// fakeStream upgrades one request and plays Alpaca's side: connected, then
+// reply to the auth message, then send frames once subscribed. It returns
+// the ws:// URL.
+func fakeStream(t *testing.T, authReply string, frames ...string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + websocket.Accept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+
+		send := func(s string) {
+			head := []byte{0x81, 126}
+			conn.Write(append(binary.BigEndian.AppendUint16(head, uint16(len(s))), s...))
+		}
+		send(`[{"T":"success","msg":"connected"}]`)
+		if readFrame(rw.Reader) == "" {
+			return
+		}
+		send(authReply)
+		if !strings.Contains(readFrame(rw.Reader), `"subscribe"`) {
+			return
+		}
+		for _, f := range frames {
+			send(f)
+		}
+		conn.Write([]byte{0x88, 0})
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// readFrame reads one masked client text frame.
+func readFrame(r *bufio.Reader) string {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return ""
+	}
+	n := int(head[1] & 0x7F)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	var mask [4]byte
+	io.ReadFull(r, mask[:])
+	payload := make([]byte, n)
+	io.ReadFull(r, payload)
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return string(payload)
+}
+
+func TestSession(t *testing.T) {
+	dir := t.TempDir()
+	writeBars(t, dir, "AAPL", barfile.Data{Symbol: "AAPL", Feed: "sip"}, false)
+	url := fakeStream(t, `[{"T":"success","msg":"authenticated"}]`,
+		`[{"T":"subscription","bars":["AAPL"]}]`,
+		`[{"T":"b","S":"AAPL","o":1,"h":1,"l":1,"c":1,"v":7,"t":"2026-03-10T15:00:00Z"}]`)
+
+	var out strings.Builder
+	s := &streamer{bars: dir, keep: 10, feed: "sip", agg: newAggregator(1), out: &out, now: time.Now}
+	cfg := Config{URL: url, Symbols: []string{"AAPL"}, APIKey: "k", APISecret: "s"}
+	if err := session(context.Background(), cfg, s, time.Now().Add(5*time.Second)); !errors.Is(err, websocket.ErrClosed) {
+		t.Fatalf("got %v, want the server's close", err)
+	}
+	if got := readBars(t, dir, "AAPL"); got.Count != 1 || got.Bars[0].Volume != 7 {
+		t.Errorf("got %+v\n%s", got, out.String())
+	}
+}
+
+func TestSession_AuthFailed(t *testing.T) {
+	url := fakeStream(t, `[{"T":"error","code":402,"msg":"auth failed"}]`)
+	s := &streamer{agg: newAggregator(5), out: io.Discard, now: time.Now}
+	cfg := Config{URL: url, Symbols: []string{"AAPL"}}
+	if err := session(context.Background(), cfg, s, time.Now().Add(5*time.Second)); !errors.Is(err, errAuth) {
+		t.Errorf("got %v, want errAuth", err)
+	}
+}
+
+func TestSession_Interrupted(t *testing.T) {
+	dir := t.TempDir()
+	writeBars(t, dir, "AAPL", barfile.Data{Symbol: "AAPL", Feed: "sip"}, false)
+	url := fakeStream(t, `[{"T":"success","msg":"authenticated"}]`,
+		`[{"T":"b","S":"AAPL","o":1,"h":1,"l":1,"c":1,"v":7,"t":"2026-03-10T15:00:00Z"}]`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := &streamer{bars: dir, keep: 10, feed: "sip", agg: newAggregator(1), out: io.Discard, now: time.Now}
+	cfg := Config{URL: url, Symbols: []string{"AAPL"}, APIKey: "k", APISecret: "s"}
+	if err := session(ctx, cfg, s, time.Now().Add(5*time.Second)); err == nil || errors.Is(err, websocket.ErrClosed) {
+		t.Errorf("got %v, want the connection closed from this side", err)
+	}
+	if got := readBars(t, dir, "AAPL"); got.Count != 0 {
+		t.Errorf("interrupted before subscribing: got %d bars", got.Count)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/manifest"
+	"github.com/deanturpin/lft2/internal/marketdata"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// streamSource is the provider fetch names for Alpaca; the stream's bars
+// only join files that came from it.
+const streamSource = "alpaca"
+
+// message is one entry of the arrays Alpaca's stream sends: a control
+// message ("success", "error", "subscription") or a minute bar ("b").
+type message struct {
+	Type   string   `json:"T"`
+	Msg    string   `json:"msg"`
+	Code   int      `json:"code"`
+	Symbol string   `json:"S"`
+	Open   float64  `json:"o"`
+	High   float64  `json:"h"`
+	Low    float64  `json:"l"`
+	Close  float64  `json:"c"`
+	Volume int64    `json:"v"`
+	Time   string   `json:"t"` // The minute's start, RFC3339
+	Bars   []string `json:"bars"`
+}
+
+// bucket is a bar being built from minute bars.
+type bucket struct {
+	start   time.Time
+	bar     barfile.Bar
+	partial bool // Joined after the bucket's first minute
+}
+
+// aggregator builds timeframe bars from the stream's minute bars. A bar is
+// complete on its last minute, or when a minute from a later bar arrives if
+// nothing traded in the last. A bar the stream joined partway through is
+// dropped, as its open and volume would be wrong; the next fetch fills it.
+type aggregator struct {
+	timeframe time.Duration
+	open      map[string]*bucket
+}
+
+func newAggregator(timeframeMin int) *aggregator {
+	return &aggregator{
+		timeframe: time.Duration(timeframeMin) * time.Minute,
+		open:      map[string]*bucket{},
+	}
+}
+
+// add takes one minute bar and returns the bar it completes, if any.
+func (a *aggregator) add(symbol string, t time.Time, m barfile.Bar) (barfile.Bar, bool) {
+	start := t.Truncate(a.timeframe)
+	var done *bucket
+	b := a.open[symbol]
+	if b != nil && !b.start.Equal(start) {
+		if start.Before(b.start) {
+			return barfile.Bar{}, false // A minute that arrived late for a bar already gone
+		}
+		done, b = b, nil
+	}
+	if b == nil {
+		b = &bucket{start: start, partial: !t.Equal(start), bar: m}
+		b.bar.Timestamp = start.UTC().Format(time.RFC3339)
+		a.open[symbol] = b
+	} else {
+		b.bar.High = max(b.bar.High, m.High)
+		b.bar.Low = min(b.bar.Low, m.Low)
+		b.bar.Close = m.Close
+		b.bar.Volume += m.Volume
+	}
+	if t.Add(time.Minute).Equal(start.Add(a.timeframe)) {
+		delete(a.open, symbol)
+		done = b
+	}
+	if done == nil || done.partial {
+		return barfile.Bar{}, false
+	}
+	return done.bar, true
+}
+
+// errNoFile marks a symbol without a bar file to append to.
+var errNoFile = errors.New("no bar file; run fetch first")
+
+// appendBars merges bars into symbol's file in dir, keeping the most recent
+// keep, and writes it back in the form it was in. Bars for a timestamp the
+// file has replace it. It returns the bytes written, for the manifest.
+func appendBars(dir, symbol string, bars []barfile.Bar, keep int, feed string, now time.Time) ([]byte, error) {
+	raw, err := barfile.ReadRaw(dir, symbol)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errNoFile
+	}
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := barfile.Decode(raw)
+	if err != nil {
+		return nil, err
+	}
+	// Rewriting a file from a newer fetch would silently downgrade it
+	if err := schema.Check(symbol, decoded); err != nil {
+		return nil, err
+	}
+	var data barfile.Data
+	if err := json.Unmarshal(decoded, &data); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", symbol, err)
+	}
+	if got := marketdata.EffectiveFeed(data.Feed); got != feed {
+		return nil, fmt.Errorf("file is %s bars, stream is %s", got, feed)
+	}
+	if data.Source != "" && data.Source != streamSource {
+		return nil, fmt.Errorf("file is from %s, stream is %s", data.Source, streamSource)
+	}
+
+	data.Header = schema.Current()
+	data.Bars, _ = barfile.Merge(data.Bars, bars)
+	if keep > 0 && len(data.Bars) > keep {
+		data.Bars = data.Bars[len(data.Bars)-keep:]
+	}
+	data.Count = len(data.Bars)
+	data.FetchedAt = now.UTC().Format(time.RFC3339)
+
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", symbol, err)
+	}
+	return barfile.Write(dir, symbol, out, barfile.Compressed(raw))
+}
+
+// streamer turns stream messages into appended bar files, with the
+// manifest kept in step so filter and backtest verify them like files fetch
+// wrote.
+type streamer struct {
+	bars         string
+	manifestPath string // Empty to skip
+	manifest     manifest.File
+	keep         int
+	feed         string
+	agg          *aggregator
+	out          io.Writer
+	now          func() time.Time
+}
+
+// handle processes one stream frame, an array of messages. A malformed
+// message or a symbol that can't be appended to is reported and skipped;
+// only a failure to write the manifest stops it. An "error" message is
+// returned, as the stream sends one before it drops the connection.
+func (s *streamer) handle(frame []byte) error {
+	var msgs []message
+	if err := json.Unmarshal(frame, &msgs); err != nil {
+		fmt.Fprintf(s.out, "  [skip] %v\n", err)
+		return nil
+	}
+	for _, m := range msgs {
+		switch m.Type {
+		case "b":
+			if err := s.addBar(m); err != nil {
+				return err
+			}
+		case "error":
+			return fmt.Errorf("stream error %d: %s", m.Code, m.Msg)
+		case "subscription":
+			fmt.Fprintf(s.out, "→ subscribed to bars for %d symbol(s)\n", len(m.Bars))
+		}
+	}
+	return nil
+}
+
+func (s *streamer) addBar(m message) error {
+	t, err := time.Parse(time.RFC3339, m.Time)
+	if err != nil {
+		fmt.Fprintf(s.out, "  [skip] %s bar: %v\n", m.Symbol, err)
+		return nil
+	}
+	bar, ok := s.agg.add(m.Symbol, t, barfile.Bar{Open: m.Open, High: m.High, Low: m.Low, Close: m.Close, Volume: m.Volume})
+	if !ok {
+		return nil
+	}
+
+	raw, err := appendBars(s.bars, m.Symbol, []barfile.Bar{bar}, s.keep, s.feed, s.now())
+	if err != nil {
+		fmt.Fprintf(s.out, "  [skip] %s: %v\n", m.Symbol, err)
+		return nil
+	}
+	if s.manifestPath == "" {
+		fmt.Fprintf(s.out, "✓ %s: bar at %s\n", m.Symbol, bar.Timestamp)
+		return nil
+	}
+	entry, err := manifest.Describe(m.Symbol, raw)
+	if err != nil {
+		return err
+	}
+	s.manifest.Put(entry)
+	s.manifest.Timestamp = s.now().UTC().Format(time.RFC3339)
+	if err := manifest.Save(s.manifestPath, s.manifest); err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "✓ %s: bar at %s, %d bars\n", m.Symbol, bar.Timestamp, entry.Count)
+	return nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/manifest"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+func minute(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+// --- aggregator ---
+
+func TestAggregator(t *testing.T) {
+	a := newAggregator(5)
+	for i, m := range []barfile.Bar{
+		{Open: 10, High: 11, Low: 9, Close: 10.5, Volume: 100},
+		{Open: 10.5, High: 12, Low: 10, Close: 11, Volume: 50},
+		{Open: 11, High: 11, Low: 8, Close: 9, Volume: 25},
+		{Open: 9, High: 9.5, Low: 9, Close: 9.2, Volume: 10},
+	} {
+		ts := minute("2026-03-10T15:00:00Z").Add(time.Duration(i) * time.Minute)
+		if _, ok := a.add("AAPL", ts, m); ok {
+			t.Fatalf("minute %d: bar emitted before the last minute", i)
+		}
+	}
+	bar, ok := a.add("AAPL", minute("2026-03-10T15:04:00Z"), barfile.Bar{Open: 9.2, High: 9.3, Low: 9.1, Close: 9.3, Volume: 5})
+	want := barfile.Bar{Timestamp: "2026-03-10T15:00:00Z", Open: 10, High: 12, Low: 8, Close: 9.3, Volume: 190}
+	if !ok || bar != want {
+		t.Errorf("got %+v, %t, want %+v", bar, ok, want)
+	}
+}
+
+func TestAggregator_LastMinuteMissing(t *testing.T) {
+	a := newAggregator(5)
+	a.add("AAPL", minute("2026-03-10T15:00:00Z"), barfile.Bar{Open: 1, High: 1, Low: 1, Close: 1, Volume: 1})
+	bar, ok := a.add("AAPL", minute("2026-03-10T15:06:00Z"), barfile.Bar{Open: 2, High: 2, Low: 2, Close: 2, Volume: 2})
+	if !ok || bar.Timestamp != "2026-03-10T15:00:00Z" || bar.Volume != 1 {
+		t.Errorf("a later bar's minute should complete the earlier bar: got %+v, %t", bar, ok)
+	}
+	// The new bar started partway through, so it's dropped when done
+	if _, ok := a.add("AAPL", minute("2026-03-10T15:09:00Z"), barfile.Bar{Close: 3}); ok {
+		t.Error("partial bar emitted")
+	}
+}
+
+func TestAggregator_JoinedPartway(t *testing.T) {
+	a := newAggregator(5)
+	a.add("AAPL", minute("2026-03-10T15:02:00Z"), barfile.Bar{Close: 1})
+	if _, ok := a.add("AAPL", minute("2026-03-10T15:04:00Z"), barfile.Bar{Close: 2}); ok {
+		t.Error("bar joined partway through emitted")
+	}
+	if _, ok := a.add("AAPL", minute("2026-03-10T15:05:00Z"), barfile.Bar{Close: 3}); ok {
+		t.Error("one minute of five emitted")
+	}
+}
+
+// --- appendBars ---
+
+func writeBars(t *testing.T, dir, symbol string, data barfile.Data, compress bool) {
+	t.Helper()
+	out, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := barfile.Write(dir, symbol, out, compress); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readBars(t *testing.T, dir, symbol string) barfile.Data {
+	t.Helper()
+	raw, err := barfile.Read(dir, symbol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data barfile.Data
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestAppendBars(t *testing.T) {
+	dir := t.TempDir()
+	writeBars(t, dir, "AAPL", barfile.Data{
+		Header: schema.Current(),
+		Symbol: "AAPL",
+		Bars: []barfile.Bar{
+			{Timestamp: "2026-03-10T14:50:00Z", Close: 1},
+			{Timestamp: "2026-03-10T14:55:00Z", Close: 2},
+		},
+		Count:  2,
+		Feed:   "iex",
+		Splits: []string{"ca-1"},
+		Source: "alpaca",
+	}, true)
+
+	now := minute("2026-03-10T15:05:40Z")
+	raw, err := appendBars(dir, "AAPL", []barfile.Bar{{Timestamp: "2026-03-10T15:00:00Z", Close: 3}}, 2, "iex", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !barfile.Compressed(raw) {
+		t.Error("gzipped file written back uncompressed")
+	}
+	got := readBars(t, dir, "AAPL")
+	if got.Count != 2 || len(got.Bars) != 2 || got.Bars[0].Close != 2 || got.Bars[1].Close != 3 {
+		t.Errorf("want the two most recent bars: got %+v", got.Bars)
+	}
+	if got.FetchedAt != "2026-03-10T15:05:40Z" || got.Feed != "iex" || len(got.Splits) != 1 || got.Source != "alpaca" {
+		t.Errorf("fields not kept: got %+v", got)
+	}
+}
+
+func TestAppendBars_Refused(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	bars := []barfile.Bar{{Timestamp: "2026-03-10T15:00:00Z"}}
+	if _, err := appendBars(dir, "AAPL", bars, 10, "sip", now); !errors.Is(err, errNoFile) {
+		t.Errorf("missing file: got %v, want errNoFile", err)
+	}
+
+	writeBars(t, dir, "IEX", barfile.Data{Header: schema.Current(), Symbol: "IEX", Feed: "iex"}, false)
+	if _, err := appendBars(dir, "IEX", bars, 10, "sip", now); err == nil {
+		t.Error("iex file from a sip stream: want an error")
+	}
+	writeBars(t, dir, "YHOO", barfile.Data{Header: schema.Current(), Symbol: "YHOO", Feed: "sip", Source: "yahoo"}, false)
+	if _, err := appendBars(dir, "YHOO", bars, 10, "sip", now); err == nil {
+		t.Error("yahoo file: want an error")
+	}
+}
+
+func TestAppendBars_NoFeedIsDefault(t *testing.T) {
+	// Fetched without a feed named, or before one was recorded: the plan
+	// picked the tape, so neither stream can vouch for matching it
+	dir := t.TempDir()
+	writeBars(t, dir, "AAPL", barfile.Data{Header: schema.Current(), Symbol: "AAPL"}, false)
+	bars := []barfile.Bar{{Timestamp: "2026-03-10T15:00:00Z"}}
+	for _, feed := range []string{"iex", "sip"} {
+		if _, err := appendBars(dir, "AAPL", bars, 10, feed, time.Now()); err == nil {
+			t.Errorf("default-feed file from a %s stream: want an error", feed)
+		}
+	}
+}
+
+// --- streamer ---
+
+func TestHandle(t *testing.T) {
+	dir := t.TempDir()
+	writeBars(t, dir, "AAPL", barfile.Data{Header: schema.Current(), Symbol: "AAPL", Feed: "sip"}, false)
+	var out bytes.Buffer
+	s := &streamer{
+		bars:         dir,
+		manifestPath: filepath.Join(dir, "manifest.json"),
+		keep:         1000,
+		feed:         "sip",
+		agg:          newAggregator(1),
+		out:          &out,
+		now:          func() time.Time { return minute("2026-03-10T15:01:05Z") },
+	}
+
+	frame := `[{"T":"subscription","bars":["AAPL","MSFT"]},
+		{"T":"b","S":"AAPL","o":1,"h":2,"l":0.5,"c":1.5,"v":100,"t":"2026-03-10T15:00:00Z","n":3,"vw":1.2},
+		{"T":"b","S":"MSFT","o":1,"h":1,"l":1,"c":1,"v":1,"t":"2026-03-10T15:00:00Z"}]`
+	if err := s.handle([]byte(frame)); err != nil {
+		t.Fatal(err)
+	}
+	if got := readBars(t, dir, "AAPL"); got.Count != 1 || got.Bars[0].Volume != 100 {
+		t.Errorf("AAPL: got %+v", got)
+	}
+	if !strings.Contains(out.String(), "[skip] MSFT") {
+		t.Errorf("MSFT without a file should be skipped: got %s", out.String())
+	}
+
+	data, err := os.ReadFile(s.manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := manifest.Parse("manifest", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := barfile.ReadRaw(dir, "AAPL")
+	if problem := m.Verify("AAPL", raw); problem != "" {
+		t.Errorf("manifest: %s", problem)
+	}
+
+	if err := s.handle([]byte(`[{"T":"error","code":406,"msg":"connection limit exceeded"}]`)); err == nil {
+		t.Error("error message: want an error")
+	}
+}
+
+func TestLoadSymbols(t *testing.T) {
+	got, err := loadSymbols(" aapl,MSFT,,AAPL ", "")
+	if err != nil || strings.Join(got, ",") != "AAPL,MSFT" {
+		t.Errorf("got %v, %v", got, err)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "candidates.json"), []byte(`{"symbols": ["NVDA", "AMD"]}`), 0644)
+	if got, err := loadSymbols("", dir); err != nil || strings.Join(got, ",") != "NVDA,AMD" {
+		t.Errorf("from candidates: got %v, %v", got, err)
+	}
+	if _, err := loadSymbols(",", dir); err == nil {
+		t.Error("no symbols: want an error")
+	}
+}
@@ -2,4 +2,36 @@ module github.com/deanturpin/lft2/summary
 
 go 1.21
 
-replace github.com/deanturpin/lft2/internal/alpaca => ../../internal/alpaca
+require (
+	github.com/deanturpin/lft2/internal/alpaca v0.0.0
+	github.com/deanturpin/lft2/internal/crash v0.0.0
+	github.com/deanturpin/lft2/internal/events v0.0.0
+	github.com/deanturpin/lft2/internal/fees v0.0.0
+	github.com/deanturpin/lft2/internal/httpconf v0.0.0
+	github.com/deanturpin/lft2/internal/interrupt v0.0.0
+	github.com/deanturpin/lft2/internal/journal v0.0.0
+	github.com/deanturpin/lft2/internal/latency v0.0.0
+	github.com/deanturpin/lft2/internal/report v0.0.0
+	github.com/deanturpin/lft2/internal/risk v0.0.0
+	github.com/deanturpin/lft2/internal/version v0.0.0
+)
+
+replace (
+	github.com/deanturpin/lft2/internal/alpaca => ../../internal/alpaca
+	github.com/deanturpin/lft2/internal/barfile => ../../internal/barfile
+	github.com/deanturpin/lft2/internal/crash => ../../internal/crash
+	github.com/deanturpin/lft2/internal/dashboard => ../../internal/dashboard
+	github.com/deanturpin/lft2/internal/events => ../../internal/events
+	github.com/deanturpin/lft2/internal/fees => ../../internal/fees
+	github.com/deanturpin/lft2/internal/httpconf => ../../internal/httpconf
+	github.com/deanturpin/lft2/internal/interrupt => ../../internal/interrupt
+	github.com/deanturpin/lft2/internal/journal => ../../internal/journal
+	github.com/deanturpin/lft2/internal/labels => ../../internal/labels
+	github.com/deanturpin/lft2/internal/latency => ../../internal/latency
+	github.com/deanturpin/lft2/internal/report => ../../internal/report
+	github.com/deanturpin/lft2/internal/risk => ../../internal/risk
+	github.com/deanturpin/lft2/internal/schema => ../../internal/schema
+	github.com/deanturpin/lft2/internal/tz => ../../internal/tz
+	github.com/deanturpin/lft2/internal/vault => ../../internal/vault
+	github.com/deanturpin/lft2/internal/version => ../../internal/version
+)
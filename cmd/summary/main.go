@@ -3,129 +3,111 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/events"
+	"github.com/deanturpin/lft2/internal/fees"
+	"github.com/deanturpin/lft2/internal/httpconf"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/journal"
+	"github.com/deanturpin/lft2/internal/latency"
+	"github.com/deanturpin/lft2/internal/report"
+	"github.com/deanturpin/lft2/internal/risk"
+	"github.com/deanturpin/lft2/internal/schema"
+	"github.com/deanturpin/lft2/internal/version"
 )
 
-// Order represents an order from Alpaca /v2/orders
-type Order struct {
-	CreatedAt     string `json:"created_at"`
-	FilledAt      string `json:"filled_at"`
-	Symbol        string `json:"symbol"`
-	Qty           string `json:"qty"`
-	FilledQty     string `json:"filled_qty"`
-	FilledAvgPrice string `json:"filled_avg_price"`
-	Side          string `json:"side"` // "buy" or "sell"
-	Status        string `json:"status"` // "filled", "partially_filled", etc.
-	ClientOrderID string `json:"client_order_id"` // Our custom ID: SYMBOL_strategy_tp3_sl2_tsl1_timestamp
-}
-
-// Activity represents a processed trade for display
-type Activity struct {
-	TransactTime  string `json:"transaction_time"`
-	Symbol        string `json:"symbol"`
-	Qty           string `json:"qty"`
-	Price         string `json:"price"`
-	Side          string `json:"side"` // "buy" or "sell"
-	ClientOrderID string `json:"order_id"` // Our custom ID for dashboard display
-}
-
-// DailySummary represents the JSON output for GitHub Pages
-type DailySummary struct {
-	Date       string         `json:"date"`
-	Activities []Activity     `json:"activities"`
-	Summary    TradingSummary `json:"summary"`
-}
-
-type TradingSummary struct {
-	TotalTrades int    `json:"total_trades"`
-	Buys        int    `json:"buys"`
-	Sells       int    `json:"sells"`
-	NetPnL      string `json:"net_pnl"` // Simple approximation
-}
+// Summary rebuilds the day's trading summary from the broker's orders of
+// every status. Partial fills of cancelled and replaced orders count, and
+// bracket legs are reported under their entry, with P&L net of LFT2_FEES
+// and the journal's notes and tags. It also writes performance.json against
+// LFT2_BENCHMARK, times each order's fill for latency.json, writes the
+// pre-close preview within LFT2_PREVIEW_LEAD of the close, and marks the
+// summary final LFT2_REPORT_DELAY after it.
+func main() {
+	version.Handle("summary")
+	defer crash.Guard("summary", journal.DefaultPath)
 
-var client alpaca.Client
+	// Ctrl-C stops once daily-summary.json is written, before the pages
+	ctx := interrupt.Context()
 
-func main() {
-	fmt.Println("Low Frequency Trader v2 - Daily Summary\n")
+	fmt.Println("Low Frequency Trader v2 - Daily Summary")
+	fmt.Println()
 
 	// Load credentials
-	apiKey := os.Getenv("ALPACA_API_KEY")
-	apiSecret := os.Getenv("ALPACA_API_SECRET")
-	if apiKey == "" || apiSecret == "" {
-		log.Fatal("ALPACA_API_KEY and ALPACA_API_SECRET must be set")
+	client, err := alpaca.FromEnv()
+	if err != nil {
+		log.Fatal(err)
 	}
-	client = alpaca.New(apiKey, apiSecret, os.Getenv("ALPACA_BASE_URL"), "")
-
-	// Fetch today's filled orders from /v2/orders endpoint
-	now := time.Now()
-	today := now.Format("2006-01-02")
-	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
-
-	fmt.Printf("Fetching filled orders from %s onwards (filtering to %s)...\n", yesterday, today)
-
-	// Alpaca /v2/orders endpoint with filled status and date filter
-	url := fmt.Sprintf("%s/v2/orders?status=filled&after=%sT00:00:00Z&limit=500",
-		client.BaseURL, yesterday)
+	policy, err := httpconf.ForStage("summary")
+	if err != nil {
+		log.Fatal(err)
+	}
+	client.HTTP = policy
 
-	body, err := client.Get(url)
+	// Fee model from LFT2_FEES — regulatory pass-through fees by default
+	feeModel, err := fees.FromEnv()
 	if err != nil {
-		log.Fatalf("fetching orders: %v", err)
+		log.Fatalf("fee model: %v", err)
 	}
 
-	var orders []Order
-	if err := json.Unmarshal(body, &orders); err != nil {
-		log.Fatalf("parsing orders: %v", err)
+	// Notes added with `lft2 note` — a missing journal just means no notes
+	notes, err := journal.Load(journal.DefaultPath)
+	if err != nil {
+		fmt.Printf("  [skip] journal: %v\n", err)
+		notes = &journal.Journal{}
 	}
 
-	// Convert orders to activities and filter to today only
-	var targetActivities []Activity
-	for _, order := range orders {
-		// Use filled_at timestamp for filtering
-		if order.FilledAt == "" {
-			continue
-		}
-		if len(order.FilledAt) >= 10 && order.FilledAt[:10] == today {
-			targetActivities = append(targetActivities, Activity{
-				TransactTime:  order.FilledAt,
-				Symbol:        order.Symbol,
-				Qty:           order.FilledQty,
-				Price:         order.FilledAvgPrice,
-				Side:          order.Side,
-				ClientOrderID: order.ClientOrderID,
-			})
-		}
+	// Renamed strategies and retired versions — a missing file maps nothing
+	strategies, err := report.LoadStrategyMap(report.StrategyMapPath)
+	if err != nil {
+		fmt.Printf("  [skip] strategy map: %v\n", err)
+		strategies = report.StrategyMap{}
 	}
 
-	fmt.Printf("Found %d filled orders on %s\n", len(targetActivities), today)
+	reporter := report.Reporter{Broker: client, Fees: feeModel, Notes: notes, Strategies: strategies, Log: os.Stdout}
+	fmt.Printf("Fetching filled orders for %s...\n", reporter.Today())
 
-	// Calculate summary stats
-	buys := 0
-	sells := 0
-	for _, act := range targetActivities {
-		if act.Side == "buy" {
-			buys++
-		} else if act.Side == "sell" {
-			sells++
-		}
+	summary, err := reporter.Daily()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	summary := DailySummary{
-		Date:       today,
-		Activities: targetActivities,
-		Summary: TradingSummary{
-			TotalTrades: len(targetActivities),
-			Buys:        buys,
-			Sells:       sells,
-			NetPnL:      "calculated_by_dashboard", // Dashboard will compute from matched pairs
-		},
+	fmt.Printf("Found %d filled orders on %s\n", len(summary.Activities), summary.Date)
+
+	// The session's close decides whether this summary is final and whether
+	// the pre-close preview is due
+	schedule, err := report.ScheduleFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	now := time.Now()
+	if close, err := latestClose(client, now); err != nil {
+		fmt.Printf("  [skip] report schedule: %v\n", err)
+	} else {
+		summary.Final = schedule.Final(close, now)
+		if schedule.PreviewDue(close, now) {
+			if err := writePreview(client, close, now); err != nil {
+				fmt.Printf("  [skip] pre-close preview: %v\n", err)
+			}
+		}
 	}
 
 	// Write to docs/daily-summary.json
 	outFile := "docs/daily-summary.json"
+
+	// Fills new since the last cycle go to the event bus, if there is one;
+	// read the last cycle's summary before it's overwritten
+	if err := publishFills(outFile, summary); err != nil {
+		fmt.Printf("  [skip] fill events: %v\n", err)
+	}
 	f, err := os.Create(outFile)
 	if err != nil {
 		log.Fatalf("creating %s: %v", outFile, err)
@@ -139,166 +121,212 @@ func main() {
 		log.Fatalf("writing JSON: %v", err)
 	}
 
-	fmt.Printf("✓ Wrote %s (%d activities)\n", outFile, len(targetActivities))
+	fmt.Printf("✓ Wrote %s (%d activities)\n", outFile, len(summary.Activities))
+
+	if err := recordFills(latency.DefaultPath, summary.Activities); err != nil {
+		fmt.Printf("  [skip] latency: %v\n", err)
+	}
+
+	interrupt.Stop(ctx, "summary")
 
 	// Generate HTML summary page
 	htmlFile := "docs/daily-summary.html"
-	html := generateHTML(summary)
+	// Written by account earlier in the cycle
+	diff, err := report.LoadPositionsDiff(report.PositionsDiffPath)
+	if err != nil {
+		fmt.Printf("  [skip] positions diff: %v\n", err)
+	}
+	perf, err := measurePerformance(time.Now())
+	if err != nil {
+		fmt.Printf("  [skip] performance: %v\n", err)
+	}
+	html, err := report.DailyHTML(summary, diff, perf)
+	if err != nil {
+		log.Fatalf("rendering %s: %v", htmlFile, err)
+	}
 	if err := os.WriteFile(htmlFile, []byte(html), 0644); err != nil {
 		log.Fatalf("writing %s: %v", htmlFile, err)
 	}
 
 	fmt.Printf("✓ Wrote %s\n", htmlFile)
-}
 
-func generateHTML(s DailySummary) string {
-	html := `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Daily Trading Summary - ` + s.Date + `</title>
-    <style>
-        body {
-            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', monospace;
-            max-width: 1200px;
-            margin: 40px auto;
-            padding: 20px;
-            background: #0a0e14;
-            color: #c5cdd9;
-        }
-        h1 {
-            color: #6cb6ff;
-            border-bottom: 2px solid #1a1f29;
-            padding-bottom: 10px;
-        }
-        .summary {
-            background: #1a1f29;
-            padding: 20px;
-            border-radius: 8px;
-            margin: 20px 0;
-            display: grid;
-            grid-template-columns: repeat(auto-fit, minmax(200px, 1fr));
-            gap: 15px;
-        }
-        .stat {
-            padding: 10px;
-        }
-        .stat-label {
-            color: #7d8793;
-            font-size: 0.9em;
-        }
-        .stat-value {
-            font-size: 1.5em;
-            font-weight: bold;
-            color: #6cb6ff;
-        }
-        table {
-            width: 100%;
-            border-collapse: collapse;
-            margin-top: 20px;
-            background: #1a1f29;
-        }
-        th {
-            background: #0f1419;
-            padding: 12px;
-            text-align: left;
-            color: #6cb6ff;
-            border-bottom: 2px solid #0a0e14;
-        }
-        td {
-            padding: 10px 12px;
-            border-bottom: 1px solid #0f1419;
-        }
-        tr:hover {
-            background: #0f1419;
-        }
-        .buy { color: #7fd962; }
-        .sell { color: #ff6666; }
-        .time { color: #7d8793; font-size: 0.9em; }
-        .no-trades {
-            text-align: center;
-            padding: 40px;
-            color: #7d8793;
-            font-style: italic;
-        }
-    </style>
-</head>
-<body>
-    <h1>Daily Trading Summary</h1>
-    <p style="color: #7d8793;">Date: ` + s.Date + `</p>
-
-    <div class="summary">
-        <div class="stat">
-            <div class="stat-label">Total Trades</div>
-            <div class="stat-value">` + fmt.Sprintf("%d", s.Summary.TotalTrades) + `</div>
-        </div>
-        <div class="stat">
-            <div class="stat-label">Buys</div>
-            <div class="stat-value buy">` + fmt.Sprintf("%d", s.Summary.Buys) + `</div>
-        </div>
-        <div class="stat">
-            <div class="stat-label">Sells</div>
-            <div class="stat-value sell">` + fmt.Sprintf("%d", s.Summary.Sells) + `</div>
-        </div>
-    </div>
-`
-
-	if len(s.Activities) == 0 {
-		html += `    <div class="no-trades">No trades executed today</div>`
+	// Strategies page — skipped quietly if the backtest hasn't run
+	strategiesFile := "docs/strategies.html"
+	if html, err := report.StrategiesHTML("docs/strategies.json", "docs/equity-curves.json", report.PromotionsPath); err != nil {
+		fmt.Printf("  [skip] strategies page: %v\n", err)
+	} else if err := os.WriteFile(strategiesFile, []byte(html), 0644); err != nil {
+		fmt.Printf("  [skip] strategies page: %v\n", err)
 	} else {
-		html += `    <table>
-        <thead>
-            <tr>
-                <th>Time</th>
-                <th>Symbol</th>
-                <th>Side</th>
-                <th>Quantity</th>
-                <th>Price</th>
-                <th>Strategy / Exits</th>
-            </tr>
-        </thead>
-        <tbody>
-`
-		for _, act := range s.Activities {
-			time := act.TransactTime
-			if len(time) >= 19 {
-				time = time[11:19] // Extract HH:MM:SS
-			}
-			sideClass := ""
-			if act.Side == "buy" {
-				sideClass = " class=\"buy\""
-			} else if act.Side == "sell" {
-				sideClass = " class=\"sell\""
-			}
+		fmt.Printf("✓ Wrote %s\n", strategiesFile)
+	}
 
-			// Parse client_order_id to show strategy params
-			strategyInfo := act.ClientOrderID
-			if strategyInfo == "" {
-				strategyInfo = "—"
-			}
+	interrupt.Stop(ctx, "summary")
+}
 
-			html += `            <tr>
-                <td class="time">` + time + `</td>
-                <td><strong>` + act.Symbol + `</strong></td>
-                <td` + sideClass + `>` + act.Side + `</td>
-                <td>` + act.Qty + `</td>
-                <td>$` + act.Price + `</td>
-                <td style="font-size: 0.85em; color: #7d8793;">` + strategyInfo + `</td>
-            </tr>
-`
+// latestClose returns when the latest session to have opened closes: today's
+// once the market has opened, else the previous trading day's.
+func latestClose(client alpaca.Client, now time.Time) (time.Time, error) {
+	sessions, err := client.Calendar(now.AddDate(0, 0, -7).Format(time.DateOnly), now.Format(time.DateOnly))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("calendar: %w", err)
+	}
+	_, close, ok := report.Session(sessions, now)
+	if !ok {
+		return time.Time{}, fmt.Errorf("no session in the week to %s", now.Format(time.DateOnly))
+	}
+	return close, nil
+}
+
+// writePreview writes the pre-close preview: open positions, their
+// unrealised P&L and the exit orders still open on them, while there's time
+// to act on anything that looks wrong.
+func writePreview(client alpaca.Client, close, now time.Time) error {
+	positions, err := client.Positions()
+	if err != nil {
+		return fmt.Errorf("fetching positions: %w", err)
+	}
+	open, err := client.Orders("status=open&nested=true&limit=500")
+	if err != nil {
+		return fmt.Errorf("fetching open orders: %w", err)
+	}
+
+	preview := report.BuildPreview(positions, open, close, now)
+	if err := report.SavePreview(report.PreviewPath, preview); err != nil {
+		return err
+	}
+	html, err := report.PreviewHTML(preview)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(report.PreviewHTMLPath, []byte(html), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Wrote %s (%d positions, unrealised $%s)\n", report.PreviewPath, len(preview.Positions), preview.UnrealizedPL.Money())
+	if len(preview.Unprotected) > 0 {
+		fmt.Printf("⚠ no exit order open for %s\n", strings.Join(preview.Unprotected, ", "))
+	}
+	return nil
+}
+
+// measurePerformance writes the account's Sharpe, alpha and beta against
+// LFT2_BENCHMARK over its snapshot history, in excess of the LFT2_RISK_FREE
+// rate, to performance.json.
+func measurePerformance(now time.Time) (*report.Performance, error) {
+	rate, source, err := report.RiskFree(os.Getenv("LFT2_RISK_FREE"), get)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := report.LoadSnapshots(report.AccountHistoryDir)
+	if err != nil {
+		return nil, err
+	}
+	benchmark := os.Getenv("LFT2_BENCHMARK")
+	if benchmark == "" {
+		benchmark = report.DefaultBenchmark
+	}
+	returns, err := risk.DailyReturns("docs/bars", benchmark)
+	if err != nil {
+		fmt.Printf("  [skip] benchmark %s: %v\n", benchmark, err)
+	}
+
+	perf, err := report.Measure(snapshots, returns, rate, now)
+	if err != nil {
+		return nil, err
+	}
+	perf.RiskFreeSource, perf.Benchmark = source, benchmark
+	if err := perf.Save(report.PerformancePath); err != nil {
+		return nil, err
+	}
+	fmt.Printf("✓ Wrote %s (Sharpe %.2f, alpha %.2f%%, beta %.2f vs %s over %d days, risk-free %.2f%% %s)\n",
+		report.PerformancePath, perf.Sharpe, perf.Alpha*100, perf.Beta, benchmark, perf.Days, rate*100, source)
+	return &perf, nil
+}
+
+// get fetches url, failing on any status but 200.
+func get(url string) ([]byte, error) {
+	client := http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// recordFills completes the latency samples execute recorded with the fill
+// times of the day's activities, then reports each hop's distribution.
+// Bracket legs are exits the broker triggered, not orders execute timed.
+func recordFills(path string, acts []report.Activity) error {
+	timings, err := latency.Load(path)
+	if err != nil {
+		return err
+	}
+	if len(timings.Samples) == 0 {
+		return nil
+	}
+	for _, act := range acts {
+		if act.Exit == "" {
+			timings.Fill(act.ClientOrderID, act.TransactTime)
+		}
+	}
+	if err := latency.Save(path, timings, time.Now()); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Wrote %s\n", path)
+	for _, h := range timings.Hops {
+		if h.Samples > 0 {
+			fmt.Printf("  %-20s p50 %6.1fs  p90 %6.1fs  max %6.1fs  (%d orders)\n", h.Name, h.P50, h.P90, h.Max, h.Samples)
+		}
+	}
+	return nil
+}
+
+// publishFills sends the fills in summary that the previous summary at path
+// didn't have to the event bus in LFT2_EVENT_BUS, one event each. Every fill
+// of the day is new when the previous summary is from another day or
+// missing.
+func publishFills(path string, summary report.DailySummary) error {
+	bus, err := events.FromEnv()
+	if err != nil || bus == nil {
+		return err
+	}
+	defer bus.Close()
+
+	fresh := newFills(path, summary)
+	now := time.Now()
+	for _, act := range fresh {
+		if err := events.Publish(bus, events.Fills, act.Symbol, act, now); err != nil {
+			return err
 		}
-		html += `        </tbody>
-    </table>`
-	}
-
-	html += `
-    <p style="margin-top: 40px; color: #7d8793; font-size: 0.9em;">
-        Generated by <a href="https://github.com/deanturpin/lft2" style="color: #6cb6ff;">LFT2</a>
-        at ` + time.Now().Format("2006-01-02 15:04:05 MST") + `
-    </p>
-</body>
-</html>
-`
-	return html
+	}
+	fmt.Printf("✓ Published %d new fill(s) to the event bus\n", len(fresh))
+	return nil
+}
+
+// newFills returns the activities in summary that the summary at path
+// doesn't list.
+func newFills(path string, summary report.DailySummary) []report.Activity {
+	key := func(a report.Activity) string { return a.ClientOrderID + " " + a.TransactTime + " " + a.Exit }
+
+	seen := map[string]bool{}
+	var prev report.DailySummary
+	if data, err := os.ReadFile(path); err == nil && schema.Check(path, data) == nil &&
+		json.Unmarshal(data, &prev) == nil && prev.Date == summary.Date {
+		for _, a := range prev.Activities {
+			seen[key(a)] = true
+		}
+	}
+
+	var fresh []report.Activity
+	for _, a := range summary.Activities {
+		if !seen[key(a)] {
+			fresh = append(fresh, a)
+		}
+	}
+	return fresh
 }
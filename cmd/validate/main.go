@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/books"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/version"
+)
+
+type Config struct {
+	BarsDir       string
+	Output        string
+	TimeframeMin  int
+	MaxMissingPct float64
+}
+
+// Validate checks every bar file for missing bars within the session, bad
+// prices, and duplicate or out-of-order timestamps, and writes
+// data_quality.json for filter. Any bad price, duplicate or out-of-order bar
+// makes a symbol bad, as do missing bars past -max-missing percent of the
+// session's; fewer are a warning, since the iex feed has no bar where
+// nothing traded.
+func main() {
+	version.Handle("validate")
+	defer crash.Guard("validate")
+
+	// A book checks its own bars, at its own timeframe
+	_, book, err := books.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	minutes := 5
+	if book != nil {
+		minutes = book.TimeframeMin
+	}
+
+	cfg := Config{}
+	flag.StringVar(&cfg.BarsDir, "bars", book.Root()+"bars", "Bar data directory to check")
+	flag.StringVar(&cfg.Output, "output", book.Root()+"data_quality.json", "Report path; filter demotes the symbols it marks bad")
+	flag.IntVar(&cfg.TimeframeMin, "timeframe", minutes, "Bar timeframe in minutes, as fetch's -timeframe (default the book's, $LFT2_BOOK)")
+	flag.Float64Var(&cfg.MaxMissingPct, "max-missing", 25, "Percent of session bars a symbol may be missing before it's marked bad (iex has no bar where nothing traded)")
+	flag.Parse()
+
+	fmt.Println("Low Frequency Trader v2 - Validate Bars")
+	fmt.Println()
+
+	if cfg.TimeframeMin < 1 {
+		log.Fatalf("-timeframe must be at least 1, got %d", cfg.TimeframeMin)
+	}
+	symbols, err := barfile.Symbols(cfg.BarsDir)
+	if err != nil {
+		log.Fatalf("Error reading %s: %v", cfg.BarsDir, err)
+	}
+	fmt.Printf("Checking %d symbol(s) in %s/ for %d-minute bars\n\n", len(symbols), cfg.BarsDir, cfg.TimeframeMin)
+
+	// A report covering some of the files would clear the rest, so Ctrl-C
+	// leaves the last one in place
+	ctx := interrupt.Context()
+
+	timeframe := time.Duration(cfg.TimeframeMin) * time.Minute
+	results := make([]Result, 0, len(symbols))
+	for _, symbol := range symbols {
+		interrupt.Stop(ctx, "validate")
+		var r Result
+		data, err := barfile.Read(cfg.BarsDir, symbol)
+		if err != nil {
+			r = Result{Symbol: symbol, Status: StatusBad, Issues: []string{err.Error()}}
+		} else {
+			r = checkFile(symbol, data, timeframe, cfg.MaxMissingPct)
+		}
+		switch r.Status {
+		case StatusBad:
+			fmt.Printf("  ✗ %s: %s\n", symbol, strings.Join(r.Issues, ", "))
+		case StatusWarn:
+			fmt.Printf("  ⚠ %s: %s\n", symbol, strings.Join(r.Issues, ", "))
+		}
+		results = append(results, r)
+	}
+
+	report := buildReport(results, cfg.TimeframeMin, cfg.MaxMissingPct, time.Now())
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatalf("Error encoding report: %v", err)
+	}
+	if err := os.WriteFile(cfg.Output, append(data, '\n'), 0644); err != nil {
+		log.Fatalf("Error writing %s: %v", cfg.Output, err)
+	}
+	fmt.Printf("\n✓ %d of %d symbol(s) bad → %s\n", report.Bad, report.Checked, cfg.Output)
+}
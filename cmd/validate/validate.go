@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// Status of a symbol's bars
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn" // Some bars missing, under the limit
+	StatusBad  = "bad"  // Filter demotes these
+)
+
+// Result is one symbol's entry in data_quality.json.
+type Result struct {
+	Symbol        string   `json:"symbol"`
+	Bars          int      `json:"bars"`
+	Gaps          int      `json:"gaps"`         // Runs of missing bars inside a session
+	MissingBars   int      `json:"missing_bars"` // Bars those runs should have held
+	MissingPct    float64  `json:"missing_pct"`  // Of the bars the sessions covered should have
+	BadPrices     int      `json:"bad_prices"`   // A zero or negative open, high, low or close
+	BadTimestamps int      `json:"bad_timestamps,omitempty"`
+	Duplicates    int      `json:"duplicates"`
+	OutOfOrder    int      `json:"out_of_order"`
+	Status        string   `json:"status"`
+	Issues        []string `json:"issues,omitempty"`
+}
+
+// Report is the layout of docs/data_quality.json.
+type Report struct {
+	schema.Header
+	Timestamp     string   `json:"timestamp"`
+	TimeframeMin  int      `json:"timeframe_min"`
+	MaxMissingPct float64  `json:"max_missing_pct"`
+	Checked       int      `json:"checked"`
+	Bad           int      `json:"bad"`
+	Symbols       []Result `json:"symbols"` // Symbol order
+}
+
+// market is the exchange timezone sessions are measured in.
+var market = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+// Regular session, minutes after midnight in New York
+const (
+	sessionOpen  = 9*60 + 30
+	sessionClose = 16 * 60
+)
+
+// inSession says whether a bar starting at t opens during regular hours.
+func inSession(t time.Time) bool {
+	local := t.In(market)
+	m := local.Hour()*60 + local.Minute()
+	return m >= sessionOpen && m < sessionClose
+}
+
+// check runs every test over one symbol's bars, as stored. A gap is two
+// session bars on the same New York date further apart than the timeframe;
+// the time before a day's first bar and after its last isn't counted, so
+// half days and the cut-off start of the history don't read as gaps.
+func check(symbol string, bars []barfile.Bar, timeframe time.Duration, maxMissingPct float64) Result {
+	r := Result{Symbol: symbol, Bars: len(bars)}
+	seen := make(map[string]bool, len(bars))
+	var prev time.Time
+	inSessionBars := 0
+	for _, b := range bars {
+		if b.Open <= 0 || b.High <= 0 || b.Low <= 0 || b.Close <= 0 {
+			r.BadPrices++
+		}
+		if seen[b.Timestamp] {
+			r.Duplicates++
+			continue
+		}
+		seen[b.Timestamp] = true
+
+		t, err := time.Parse(time.RFC3339, b.Timestamp)
+		if err != nil {
+			r.BadTimestamps++
+			continue
+		}
+		if !prev.IsZero() && t.Before(prev) {
+			r.OutOfOrder++
+			continue
+		}
+		if inSession(t) {
+			inSessionBars++
+			if !prev.IsZero() && inSession(prev) && sameDay(prev, t) {
+				if missing := int(t.Sub(prev)/timeframe) - 1; missing > 0 {
+					r.Gaps++
+					r.MissingBars += missing
+				}
+			}
+		}
+		prev = t
+	}
+	if expected := inSessionBars + r.MissingBars; expected > 0 {
+		r.MissingPct = math.Round(float64(r.MissingBars)/float64(expected)*10000) / 100
+	}
+
+	r.Status = StatusOK
+	bad := func(n int, what string) {
+		if n > 0 {
+			r.Issues = append(r.Issues, fmt.Sprintf("%d %s", n, what))
+			r.Status = StatusBad
+		}
+	}
+	bad(r.BadPrices, "bad prices")
+	bad(r.BadTimestamps, "bad timestamps")
+	bad(r.Duplicates, "duplicate timestamps")
+	bad(r.OutOfOrder, "out-of-order bars")
+	if r.MissingBars > 0 {
+		r.Issues = append(r.Issues, fmt.Sprintf("%d missing bars in %d gaps (%.2f%%)", r.MissingBars, r.Gaps, r.MissingPct))
+		if r.Status == StatusOK {
+			r.Status = StatusWarn
+		}
+		if r.MissingPct > maxMissingPct {
+			r.Status = StatusBad
+		}
+	}
+	if len(bars) == 0 {
+		r.Issues = append(r.Issues, "no bars")
+		r.Status = StatusBad
+	}
+	return r
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.In(market).Format(time.DateOnly) == b.In(market).Format(time.DateOnly)
+}
+
+// checkFile parses one decoded bar file and checks it. A file that can't be
+// read is bad, as filter would otherwise rank on bars nobody looked at.
+func checkFile(symbol string, data []byte, timeframe time.Duration, maxMissingPct float64) Result {
+	unreadable := func(err error) Result {
+		return Result{Symbol: symbol, Status: StatusBad, Issues: []string{err.Error()}}
+	}
+	if err := schema.Check(symbol, data); err != nil {
+		return unreadable(err)
+	}
+	var sd barfile.Data
+	if err := json.Unmarshal(data, &sd); err != nil {
+		return unreadable(fmt.Errorf("parsing JSON: %w", err))
+	}
+	return check(symbol, sd.Bars, timeframe, maxMissingPct)
+}
+
+// buildReport collects results into the report, in symbol order.
+func buildReport(results []Result, timeframeMin int, maxMissingPct float64, now time.Time) Report {
+	sort.Slice(results, func(i, j int) bool { return results[i].Symbol < results[j].Symbol })
+	r := Report{
+		Header:        schema.Current(),
+		Timestamp:     now.UTC().Format(time.RFC3339),
+		TimeframeMin:  timeframeMin,
+		MaxMissingPct: maxMissingPct,
+		Checked:       len(results),
+		Symbols:       append([]Result{}, results...),
+	}
+	for _, res := range results {
+		if res.Status == StatusBad {
+			r.Bad++
+		}
+	}
+	return r
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+)
+
+// bar is a clean bar at ts, UTC.
+func bar(ts string) barfile.Bar {
+	return barfile.Bar{Timestamp: ts, Open: 10, High: 11, Low: 9, Close: 10.5, Volume: 100}
+}
+
+func TestCheck_Clean(t *testing.T) {
+	// 13:30Z is 09:30 in New York once the clocks have gone forward
+	bars := []barfile.Bar{bar("2026-03-10T13:30:00Z"), bar("2026-03-10T13:35:00Z"), bar("2026-03-10T13:40:00Z")}
+	r := check("AAPL", bars, 5*time.Minute, 25)
+	if r.Status != StatusOK || r.Gaps != 0 || len(r.Issues) != 0 {
+		t.Errorf("got %+v", r)
+	}
+}
+
+func TestCheck_Gaps(t *testing.T) {
+	bars := []barfile.Bar{
+		bar("2026-03-10T19:40:00Z"),
+		bar("2026-03-10T19:55:00Z"), // 15:55 New York: two bars missing before it
+		bar("2026-03-11T12:30:00Z"), // Overnight isn't a gap, nor is pre-market
+		bar("2026-03-11T13:30:00Z"), // Nor the hour from pre-market to the open
+		bar("2026-03-11T13:35:00Z"),
+	}
+	r := check("AAPL", bars, 5*time.Minute, 50)
+	if r.Gaps != 1 || r.MissingBars != 2 || r.Status != StatusWarn {
+		t.Errorf("got %+v", r)
+	}
+	// Four session bars and two missing
+	if r.MissingPct != 33.33 {
+		t.Errorf("missing: got %.2f%%", r.MissingPct)
+	}
+	if r := check("AAPL", bars, 5*time.Minute, 25); r.Status != StatusBad {
+		t.Errorf("over the limit: got %s", r.Status)
+	}
+}
+
+func TestCheck_Bad(t *testing.T) {
+	zero := bar("2026-03-10T14:40:00Z")
+	zero.Low = 0
+	bars := []barfile.Bar{
+		bar("2026-03-10T14:30:00Z"),
+		bar("2026-03-10T14:35:00Z"),
+		bar("2026-03-10T14:35:00Z"),
+		zero,
+		bar("2026-03-10T14:30:00Z"), // Seen already, so a duplicate not a step back
+		bar("2026-03-10T14:20:00Z"),
+		bar("yesterday"),
+	}
+	r := check("AAPL", bars, 5*time.Minute, 25)
+	if r.BadPrices != 1 || r.Duplicates != 2 || r.OutOfOrder != 1 || r.BadTimestamps != 1 || r.Status != StatusBad {
+		t.Errorf("got %+v", r)
+	}
+	if got := strings.Join(r.Issues, ", "); got != "1 bad prices, 1 bad timestamps, 2 duplicate timestamps, 1 out-of-order bars" {
+		t.Errorf("issues: got %s", got)
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	if r := checkFile("AAPL", []byte(`{"schema_version": 99}`), 5*time.Minute, 25); r.Status != StatusBad {
+		t.Errorf("newer schema: got %+v", r)
+	}
+	if r := checkFile("AAPL", []byte(`{"symbol": "AAPL", "bars": []}`), 5*time.Minute, 25); r.Status != StatusBad || r.Issues[0] != "no bars" {
+		t.Errorf("empty: got %+v", r)
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	r := buildReport([]Result{
+		{Symbol: "MSFT", Status: StatusBad},
+		{Symbol: "AAPL", Status: StatusWarn},
+	}, 5, 25, time.Date(2026, 3, 10, 21, 0, 0, 0, time.UTC))
+	if r.Checked != 2 || r.Bad != 1 || r.Symbols[0].Symbol != "AAPL" || r.Timestamp != "2026-03-10T21:00:00Z" {
+		t.Errorf("got %+v", r)
+	}
+}
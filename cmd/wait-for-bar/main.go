@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 	"time"
 
 	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/crash"
+	"github.com/deanturpin/lft2/internal/httpconf"
+	"github.com/deanturpin/lft2/internal/interrupt"
+	"github.com/deanturpin/lft2/internal/version"
 )
 
 // Alpaca clock response
@@ -33,19 +36,27 @@ func fetchClock(client alpaca.Client) (*Clock, error) {
 }
 
 func main() {
-	fmt.Println("Low Frequency Trader v2 - Wait for Bar\n")
+	version.Handle("wait-for-bar")
+	defer crash.Guard("wait-for-bar")
 
-	apiKey := os.Getenv("ALPACA_API_KEY")
-	apiSecret := os.Getenv("ALPACA_API_SECRET")
+	fmt.Println("Low Frequency Trader v2 - Wait for Bar")
+	fmt.Println()
 
-	if apiKey == "" || apiSecret == "" {
-		log.Fatal("ALPACA_API_KEY and ALPACA_API_SECRET must be set")
+	client, err := alpaca.FromEnv()
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	client := alpaca.New(apiKey, apiSecret, os.Getenv("ALPACA_BASE_URL"), "")
+	policy, err := httpconf.ForStage("wait-for-bar")
+	if err != nil {
+		log.Fatal(err)
+	}
+	client.HTTP = policy
+	ctx := interrupt.Context()
+	client = client.WithContext(ctx)
 
 	clock, err := fetchClock(client)
 	if err != nil {
+		interrupt.Stop(ctx, "wait-for-bar")
 		log.Fatalf("Failed to fetch exchange clock: %v", err)
 	}
 
@@ -85,7 +96,9 @@ func main() {
 	fmt.Printf("Waiting until: %02d:%02d:%02d UTC (%ds)\n",
 		target.Hour(), target.Minute(), target.Second(), waitSec)
 
-	time.Sleep(time.Duration(waitSec) * time.Second)
+	if !interrupt.Sleep(ctx, time.Duration(waitSec)*time.Second) {
+		interrupt.Exit("wait-for-bar")
+	}
 
 	fmt.Println("\nBar data should now be available")
 }
@@ -0,0 +1,64 @@
+package e2e
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// barLength matches the pipeline's 5-minute bars.
+const barLength = 5 * time.Minute
+
+// bar is one bar in Alpaca's wire format, as fetch reads it.
+type bar struct {
+	Timestamp string  `json:"t"`
+	Open      float64 `json:"o"`
+	High      float64 `json:"h"`
+	Low       float64 `json:"l"`
+	Close     float64 `json:"c"`
+	Volume    int64   `json:"v"`
+	VWAP      float64 `json:"vw"`
+	Trades    int64   `json:"n"`
+}
+
+// synthBars returns n bars for symbol, oldest first, the last one starting
+// at the 5-minute boundary at or before end. Bars run round the clock so the
+// latest is always fresh. Prices follow two sine waves plus noise seeded by
+// the symbol, so there are swings to trade and a symbol always gets the same
+// path.
+func synthBars(symbol string, n int, end time.Time) []bar {
+	h := fnv.New64a()
+	h.Write([]byte(symbol))
+	seed := h.Sum64()
+	rng := rand.New(rand.NewSource(int64(seed)))
+
+	base := 20.0 + float64(seed%200)
+	last := end.UTC().Truncate(barLength)
+	bars := make([]bar, n)
+	prev := base
+	for i := range bars {
+		wave := 0.03*math.Sin(2*math.Pi*float64(i)/60) + 0.01*math.Sin(2*math.Pi*float64(i)/7)
+		close := base * (1 + wave + 0.002*rng.NormFloat64())
+		open := prev
+		high := math.Max(open, close) * (1 + 0.001*rng.Float64())
+		low := math.Min(open, close) * (1 - 0.001*rng.Float64())
+		bars[i] = bar{
+			Timestamp: last.Add(-time.Duration(n-1-i) * barLength).Format(time.RFC3339),
+			Open:      round(open),
+			High:      round(high),
+			Low:       round(low),
+			Close:     round(close),
+			Volume:    50000 + rng.Int63n(100000),
+			VWAP:      round((high + low + close) / 3),
+			Trades:    200 + rng.Int63n(800),
+		}
+		prev = close
+	}
+	return bars
+}
+
+// round keeps prices to cents, as quoted.
+func round(price float64) float64 {
+	return math.Round(price*100) / 100
+}
@@ -0,0 +1,315 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// position is a holding at the mock broker.
+type position struct {
+	Symbol   string
+	Qty      float64
+	AvgEntry float64
+}
+
+// fill is an order the mock broker accepted and filled.
+type fill struct {
+	ID            string
+	ClientOrderID string
+	Symbol        string
+	Side          string
+	Qty           float64
+	Price         float64
+	FilledAt      time.Time
+}
+
+// broker is a mock Alpaca serving the trading and market data endpoints the
+// pipeline calls. Bars come from synthBars; market orders fill in full at
+// the symbol's last close, moving cash and positions as a real fill would.
+type broker struct {
+	mu        sync.Mutex
+	bars      map[string][]bar
+	cash      float64
+	positions map[string]*position
+	fills     []fill
+	now       func() time.Time
+}
+
+// newBroker holds bars for each symbol and starts with cash and the given
+// positions.
+func newBroker(symbols []string, bars int, cash float64, held []position, now time.Time) *broker {
+	b := &broker{
+		bars:      map[string][]bar{},
+		cash:      cash,
+		positions: map[string]*position{},
+		now:       func() time.Time { return now },
+	}
+	for _, s := range symbols {
+		b.bars[s] = synthBars(s, bars, now)
+	}
+	for _, p := range held {
+		p := p
+		b.positions[p.Symbol] = &p
+	}
+	return b
+}
+
+// last is the latest close for symbol, or 0 for an unknown one.
+func (b *broker) last(symbol string) float64 {
+	bars := b.bars[symbol]
+	if len(bars) == 0 {
+		return 0
+	}
+	return bars[len(bars)-1].Close
+}
+
+// Fills returns a copy of the orders filled so far.
+func (b *broker) Fills() []fill {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]fill(nil), b.fills...)
+}
+
+// Held returns the quantity held of symbol.
+func (b *broker) Held(symbol string) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if p, ok := b.positions[symbol]; ok {
+		return p.Qty
+	}
+	return 0
+}
+
+func (b *broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/v2/stocks/") && strings.HasSuffix(path, "/bars"):
+		b.serveBars(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "/v2/stocks/"), "/bars"))
+	case r.Method == http.MethodGet && path == "/v2/assets":
+		b.serveAssets(w)
+	case r.Method == http.MethodGet && path == "/v2/account":
+		b.serveAccount(w)
+	case r.Method == http.MethodGet && path == "/v2/positions":
+		b.servePositions(w)
+	case r.Method == http.MethodGet && path == "/v2/account/activities":
+		b.serveActivities(w, r)
+	case r.Method == http.MethodGet && path == "/v2/orders":
+		b.serveOrders(w, r)
+	case r.Method == http.MethodPost && path == "/v2/orders":
+		b.submit(w, r)
+	default:
+		http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+	}
+}
+
+// serveBars returns the most recent limit bars, newest first as requested
+// with sort=desc.
+func (b *broker) serveBars(w http.ResponseWriter, r *http.Request, symbol string) {
+	bars := b.bars[symbol]
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > len(bars) {
+		limit = len(bars)
+	}
+	page := make([]bar, 0, limit)
+	for i := len(bars) - 1; i >= len(bars)-limit; i-- {
+		page = append(page, bars[i])
+	}
+	reply(w, map[string]any{"symbol": symbol, "bars": page})
+}
+
+func (b *broker) serveAssets(w http.ResponseWriter) {
+	assets := []map[string]any{}
+	for symbol := range b.bars {
+		assets = append(assets, map[string]any{
+			"symbol": symbol, "name": "Synthetic " + symbol + " Inc. Common Stock",
+			"exchange": "NASDAQ", "class": "us_equity", "status": "active",
+			"tradable": true, "fractionable": true,
+		})
+	}
+	reply(w, assets)
+}
+
+// equity is cash plus every position at its last close.
+func (b *broker) equity() float64 {
+	equity := b.cash
+	for _, p := range b.positions {
+		equity += p.Qty * b.last(p.Symbol)
+	}
+	return equity
+}
+
+func (b *broker) serveAccount(w http.ResponseWriter) {
+	equity := money(b.equity())
+	reply(w, map[string]any{
+		"account_number": "E2E", "status": "ACTIVE", "currency": "USD",
+		"cash": money(b.cash), "buying_power": money(b.cash),
+		"portfolio_value": equity, "equity": equity, "last_equity": equity,
+		"long_market_value": money(b.equity() - b.cash), "short_market_value": "0",
+		"daytrade_count": 0, "pattern_day_trader": false,
+	})
+}
+
+func (b *broker) servePositions(w http.ResponseWriter) {
+	positions := []map[string]any{}
+	for _, p := range b.positions {
+		price := b.last(p.Symbol)
+		pl := (price - p.AvgEntry) * p.Qty
+		positions = append(positions, map[string]any{
+			"symbol": p.Symbol, "qty": strconv.FormatFloat(p.Qty, 'f', -1, 64),
+			"avg_entry_price": money(p.AvgEntry), "current_price": money(price),
+			"market_value": money(p.Qty * price), "cost_basis": money(p.Qty * p.AvgEntry),
+			"unrealized_pl": money(pl), "unrealized_plpc": fmt.Sprintf("%.4f", price/p.AvgEntry-1),
+			"change_today": "0", "side": "long", "asset_class": "us_equity",
+		})
+	}
+	reply(w, positions)
+}
+
+// newYork is the timezone Alpaca dates account activities in.
+var newYork = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+// serveActivities lists the fills on the requested day as FILL activities,
+// net of the cash they moved.
+func (b *broker) serveActivities(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	acts := []map[string]any{}
+	for _, f := range b.fills {
+		if date != "" && f.FilledAt.In(newYork).Format("2006-01-02") != date {
+			continue
+		}
+		net := f.Qty * f.Price
+		if f.Side == "buy" {
+			net = -net
+		}
+		acts = append(acts, map[string]any{
+			"id": f.ID, "activity_type": "FILL", "transaction_time": f.FilledAt.UTC().Format(time.RFC3339),
+			"symbol": f.Symbol, "side": f.Side, "qty": strconv.FormatFloat(f.Qty, 'f', -1, 64),
+			"price": money(f.Price), "net_amount": money(net),
+		})
+	}
+	reply(w, acts)
+}
+
+// serveOrders lists filled orders. Every order fills on submission, so none
+// is ever open.
+func (b *broker) serveOrders(w http.ResponseWriter, r *http.Request) {
+	orders := []map[string]any{}
+	if r.URL.Query().Get("status") != "open" {
+		for _, f := range b.fills {
+			orders = append(orders, order(f))
+		}
+	}
+	reply(w, orders)
+}
+
+// submit fills a market order at the last close. Alpaca's refusals for the
+// same mistakes are mirrored: an unknown symbol or bad quantity is a 422, a
+// buy beyond buying power or a sell of more than is held a 403.
+func (b *broker) submit(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Symbol        string `json:"symbol"`
+		Qty           string `json:"qty"`
+		Side          string `json:"side"`
+		Type          string `json:"type"`
+		ClientOrderID string `json:"client_order_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		refuse(w, http.StatusUnprocessableEntity, "invalid order: "+err.Error())
+		return
+	}
+	qty, err := strconv.ParseFloat(req.Qty, 64)
+	price := b.last(req.Symbol)
+	switch {
+	case err != nil || qty <= 0:
+		refuse(w, http.StatusUnprocessableEntity, "invalid qty "+req.Qty)
+		return
+	case price == 0:
+		refuse(w, http.StatusUnprocessableEntity, "asset "+req.Symbol+" not found")
+		return
+	case req.Type != "market":
+		refuse(w, http.StatusUnprocessableEntity, "only market orders are simulated")
+		return
+	}
+
+	held := b.positions[req.Symbol]
+	switch req.Side {
+	case "buy":
+		if qty*price > b.cash {
+			refuse(w, http.StatusForbidden, "insufficient buying power")
+			return
+		}
+		if held == nil {
+			held = &position{Symbol: req.Symbol}
+			b.positions[req.Symbol] = held
+		}
+		held.AvgEntry = (held.AvgEntry*held.Qty + price*qty) / (held.Qty + qty)
+		held.Qty += qty
+		b.cash -= qty * price
+	case "sell":
+		if held == nil || qty > held.Qty {
+			refuse(w, http.StatusForbidden, "insufficient qty available for order")
+			return
+		}
+		held.Qty -= qty
+		if held.Qty == 0 {
+			delete(b.positions, req.Symbol)
+		}
+		b.cash += qty * price
+	default:
+		refuse(w, http.StatusUnprocessableEntity, "invalid side "+req.Side)
+		return
+	}
+
+	f := fill{
+		ID:            fmt.Sprintf("e2e-%d", len(b.fills)+1),
+		ClientOrderID: req.ClientOrderID,
+		Symbol:        req.Symbol,
+		Side:          req.Side,
+		Qty:           qty,
+		Price:         price,
+		FilledAt:      b.now(),
+	}
+	b.fills = append(b.fills, f)
+	reply(w, order(f))
+}
+
+// order renders a fill as an Alpaca order.
+func order(f fill) map[string]any {
+	qty := strconv.FormatFloat(f.Qty, 'f', -1, 64)
+	at := f.FilledAt.UTC().Format(time.RFC3339)
+	return map[string]any{
+		"id": f.ID, "client_order_id": f.ClientOrderID, "symbol": f.Symbol,
+		"side": f.Side, "type": "market", "order_class": "simple", "status": "filled",
+		"qty": qty, "filled_qty": qty, "filled_avg_price": money(f.Price),
+		"created_at": at, "filled_at": at,
+	}
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func reply(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func refuse(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,162 @@
+package e2e
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 3, 10, 15, 2, 0, 0, time.UTC)
+
+// --- synthBars ---
+
+func TestSynthBars_Shape(t *testing.T) {
+	bars := synthBars("SYNA", 300, start)
+	if len(bars) != 300 {
+		t.Fatalf("got %d bars, want 300", len(bars))
+	}
+	if bars[len(bars)-1].Timestamp != "2026-03-10T15:00:00Z" {
+		t.Errorf("last bar: got %s, want the boundary before end", bars[len(bars)-1].Timestamp)
+	}
+	for i, b := range bars {
+		if b.High < b.Open || b.High < b.Close || b.Low > b.Open || b.Low > b.Close || b.Low <= 0 {
+			t.Fatalf("bar %d: invalid OHLC %+v", i, b)
+		}
+		if b.VWAP < b.Low || b.VWAP > b.High {
+			t.Fatalf("bar %d: VWAP outside the range %+v", i, b)
+		}
+		if i > 0 && b.Timestamp <= bars[i-1].Timestamp {
+			t.Fatalf("bar %d: not after the one before", i)
+		}
+	}
+}
+
+func TestSynthBars_Deterministic(t *testing.T) {
+	a, b := synthBars("SYNA", 50, start), synthBars("SYNA", 50, start)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("bar %d differs between runs", i)
+		}
+	}
+	if c := synthBars("SYNB", 50, start); c[0] == a[0] {
+		t.Error("different symbols got the same path")
+	}
+}
+
+// --- broker ---
+
+func post(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(url+"/v2/orders", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestBroker_FillsAtLastClose(t *testing.T) {
+	b := newBroker([]string{"SYNA"}, 10, 10000, nil, start)
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	if resp := post(t, srv.URL, `{"symbol":"SYNA","qty":"5","side":"buy","type":"market","client_order_id":"SYNA_x"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("buy: got HTTP %d", resp.StatusCode)
+	}
+	fills := b.Fills()
+	if len(fills) != 1 || fills[0].Price != b.last("SYNA") || fills[0].ClientOrderID != "SYNA_x" {
+		t.Fatalf("got %+v", fills)
+	}
+	if b.Held("SYNA") != 5 {
+		t.Errorf("held: got %g, want 5", b.Held("SYNA"))
+	}
+
+	if resp := post(t, srv.URL, `{"symbol":"SYNA","qty":"5","side":"sell","type":"market"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("sell: got HTTP %d", resp.StatusCode)
+	}
+	if b.Held("SYNA") != 0 || math.Abs(b.cash-10000) > 1e-9 {
+		t.Errorf("round trip: held %g, cash %g; want 0, 10000", b.Held("SYNA"), b.cash)
+	}
+}
+
+func TestBroker_Refusals(t *testing.T) {
+	b := newBroker([]string{"SYNA"}, 10, 100, []position{{Symbol: "SYNA", Qty: 1, AvgEntry: 10}}, start)
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"symbol":"SYNA","qty":"1000","side":"buy","type":"market"}`, http.StatusForbidden},
+		{`{"symbol":"SYNA","qty":"2","side":"sell","type":"market"}`, http.StatusForbidden},
+		{`{"symbol":"NOPE","qty":"1","side":"buy","type":"market"}`, http.StatusUnprocessableEntity},
+		{`{"symbol":"SYNA","qty":"0","side":"buy","type":"market"}`, http.StatusUnprocessableEntity},
+	} {
+		if resp := post(t, srv.URL, tc.body); resp.StatusCode != tc.want {
+			t.Errorf("%s: got HTTP %d, want %d", tc.body, resp.StatusCode, tc.want)
+		}
+	}
+	if len(b.Fills()) != 0 {
+		t.Errorf("refused orders filled: %+v", b.Fills())
+	}
+}
+
+func TestBroker_BarsNewestFirst(t *testing.T) {
+	b := newBroker([]string{"SYNA"}, 20, 0, nil, start)
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v2/stocks/SYNA/bars?timeframe=5Min&limit=5&sort=desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var got struct {
+		Bars []bar `json:"bars"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Bars) != 5 || got.Bars[0] != b.bars["SYNA"][19] || got.Bars[4] != b.bars["SYNA"][15] {
+		t.Errorf("got %+v", got.Bars)
+	}
+}
+
+func TestBroker_ActivitiesByDay(t *testing.T) {
+	b := newBroker([]string{"SYNA"}, 10, 10000, nil, start)
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+	post(t, srv.URL, `{"symbol":"SYNA","qty":"2","side":"buy","type":"market"}`)
+
+	type activity struct {
+		Type      string `json:"activity_type"`
+		Side      string `json:"side"`
+		NetAmount string `json:"net_amount"`
+	}
+	on := func(date string) []activity {
+		resp, err := http.Get(srv.URL + "/v2/account/activities?date=" + date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var acts []activity
+		if err := json.NewDecoder(resp.Body).Decode(&acts); err != nil {
+			t.Fatal(err)
+		}
+		return acts
+	}
+
+	if acts := on("2026-03-09"); len(acts) != 0 {
+		t.Errorf("day before: got %+v, want none", acts)
+	}
+	acts := on("2026-03-10")
+	want := activity{Type: "FILL", Side: "buy", NetAmount: money(-2 * b.last("SYNA"))}
+	if len(acts) != 1 || acts[0] != want {
+		t.Errorf("got %+v, want %+v", acts, want)
+	}
+}
@@ -0,0 +1,7 @@
+// Package e2e runs the pipeline end to end: fetch, filter, backtest,
+// account, entries, exits and execute, in a temporary workspace against a
+// mock Alpaca broker serving synthetic bars and filling orders at the last
+// close. The pipeline test needs the C++ modules built first, so it only runs
+// with the e2e build tag (make e2e); the broker, the bar generator and the
+// downtime scenarios, which run only Go stages, are tested on every run.
+package e2e
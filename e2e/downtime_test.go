@@ -0,0 +1,337 @@
+package e2e
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Downtime scenarios: the pipeline stops between stages or misses cycles
+// while the broker carries on, then restarts against artifacts that no longer
+// describe the account. Only Go stages run, so these need no C++ build; the
+// FIX files and positions.json that entries and exits would have left behind
+// are written directly.
+
+// fixTime is the FIX UTCTimestamp layout entries and exits use for tag 126.
+const fixTime = "20060102-15:04:05"
+
+// fixOrder is a market order as entries (side 1) or exits (side 2) writes it,
+// valid until the given time.
+func fixOrder(id, symbol string, side, qty int, until time.Time) string {
+	return fmt.Sprintf("8=FIX.5.0SP2|35=D|11=%s|55=%s|54=%d|38=%d|40=1|58=e2e|126=%s|\n",
+		id, symbol, side, qty, until.UTC().Format(fixTime))
+}
+
+// writeFix writes a .fix file with the heartbeat first, as the C++ modules do.
+func writeFix(t *testing.T, path string, orders ...string) {
+	t.Helper()
+	writeCycleFix(t, path, "", orders...)
+}
+
+// writeCycleFix writes a .fix file from pipeline cycle id, which the
+// heartbeat carries as tag 5001 when set.
+func writeCycleFix(t *testing.T, path, cycle string, orders ...string) {
+	t.Helper()
+	heartbeat := "8=FIX.5.0SP2|35=0|52=" + time.Now().UTC().Format(fixTime) + "|58=e2e|"
+	if cycle != "" {
+		heartbeat += "5001=" + cycle + "|"
+	}
+	body := heartbeat + "\n" + strings.Join(orders, "")
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeFile writes a workspace file, failing the test on error.
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// downtime is a workspace with the Go stages built and a mock broker.
+type downtime struct {
+	broker *broker
+	url    string
+	docs   string
+	run    func(stage string, args ...string)
+	try    func(stage string, args ...string) error
+}
+
+func newDowntime(t *testing.T, symbols []string, held []position) downtime {
+	return newDowntimeEnv(t, symbols, held)
+}
+
+// newDowntimeEnv is newDowntime with extra environment for every stage.
+func newDowntimeEnv(t *testing.T, symbols []string, held []position, extra ...string) downtime {
+	bin := t.TempDir()
+	buildGo(t, repoRoot(t), bin, "account", "execute", "summary", "reconcile")
+
+	workspace := t.TempDir()
+	docs := filepath.Join(workspace, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBroker(symbols, 50, 100000, held, time.Now())
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+	env := append(stageEnv(srv.URL, workspace), extra...)
+
+	return downtime{
+		broker: b,
+		url:    srv.URL,
+		docs:   docs,
+		run: func(stage string, args ...string) {
+			t.Helper()
+			run(t, workspace, env, filepath.Join(bin, stage), args...)
+		},
+		try: func(stage string, args ...string) error {
+			t.Helper()
+			return try(t, workspace, env, filepath.Join(bin, stage), args...)
+		},
+	}
+}
+
+// executionResult reads the outcome execute wrote.
+type executionResult struct {
+	Submitted int `json:"submitted"`
+	Skipped   int `json:"skipped"`
+	Orders    []struct {
+		Symbol string `json:"symbol"`
+		Side   string `json:"side"`
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	} `json:"orders"`
+}
+
+// reasons maps "symbol side" to each skipped order's reason.
+func (r executionResult) reasons() map[string]string {
+	out := map[string]string{}
+	for _, o := range r.Orders {
+		if o.Status == "skipped" {
+			out[o.Symbol+" "+o.Side] = o.Reason
+		}
+	}
+	return out
+}
+
+// The pipeline wrote its orders, then stopped before execute ran; execute
+// next runs hours later on the same files. Each intent has long expired, so
+// nothing reaches the broker: a stale buy would chase a signal from another
+// market and a stale sell would close a position exits no longer wants to.
+func TestDowntime_MissedCycles(t *testing.T) {
+	d := newDowntime(t, []string{"SYNA", "SYNB"}, []position{{Symbol: "SYNB", Qty: 10, AvgEntry: 50}})
+
+	written := time.Now().Add(-3 * time.Hour)
+	writeFix(t, filepath.Join(d.docs, "buy.fix"), fixOrder("SYNA_e2e-v1_tp1.00_sl1.00_tsl1.00_p0_x", "SYNA", 1, 10, written.Add(2*time.Minute)))
+	writeFix(t, filepath.Join(d.docs, "sell.fix"), fixOrder("SYNB_exit", "SYNB", 2, 10, written.Add(2*time.Minute)))
+
+	d.run("execute")
+
+	if fills := d.broker.Fills(); len(fills) != 0 {
+		t.Fatalf("stale orders reached the broker: %+v", fills)
+	}
+	if q := d.broker.Held("SYNB"); q != 10 {
+		t.Errorf("SYNB: held %g, want 10 untouched", q)
+	}
+	var result executionResult
+	readJSON(t, filepath.Join(d.docs, "execution-result.json"), &result)
+	if result.Submitted != 0 || result.Skipped != 2 {
+		t.Errorf("got %d submitted, %d skipped; want 0 and 2", result.Submitted, result.Skipped)
+	}
+	for order, why := range result.reasons() {
+		if !strings.HasPrefix(why, "intent expired") {
+			t.Errorf("%s: skipped for %q, want an expired intent", order, why)
+		}
+	}
+}
+
+// Execute placed a cycle's buy, then CI retried the step on the same files,
+// by which time the position had been closed by hand so "already held" no
+// longer stops a second buy. The cycle ID does: first from the log the last
+// run left, then, in a fresh container without it, from the cycle suffix on
+// the broker's order.
+func TestDowntime_RetriedExecute(t *testing.T) {
+	d := newDowntime(t, []string{"SYNA"}, nil)
+	buy := filepath.Join(d.docs, "buy.fix")
+	writeCycleFix(t, buy, "gh42", fixOrder("SYNA_e2e-v1_tp1.00_sl1.00_tsl1.00_p0_1741617300_cgh42", "SYNA", 1, 10, time.Now().Add(10*time.Minute)))
+
+	d.run("execute")
+	if fills := d.broker.Fills(); len(fills) != 1 {
+		t.Fatalf("first run: broker filled %d order(s), want 1", len(fills))
+	}
+	if resp := post(t, d.url, `{"symbol":"SYNA","qty":"10","side":"sell","type":"market","client_order_id":"manual"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("sell: got HTTP %d", resp.StatusCode)
+	}
+
+	for _, retry := range []struct{ name, why string }{
+		{"retry", "cycle gh42 already executed"},
+		{"fresh container", "cycle gh42 already has orders at the broker"},
+	} {
+		if retry.name == "fresh container" {
+			if err := os.Remove(filepath.Join(d.docs, "executed-cycles.json")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		d.run("execute")
+
+		if fills := d.broker.Fills(); len(fills) != 2 {
+			t.Fatalf("%s: broker has %d fill(s), want the buy and the manual sell", retry.name, len(fills))
+		}
+		var result executionResult
+		readJSON(t, filepath.Join(d.docs, "execution-result.json"), &result)
+		if got := result.reasons()["SYNA buy"]; got != retry.why {
+			t.Errorf("%s: skipped for %q, want %q", retry.name, got, retry.why)
+		}
+	}
+}
+
+// positions.json was written before the outage. While the pipeline was down
+// the last cycle's SYNA buy filled and SYNB was closed by hand, so entries
+// and exits, working from the stale file, ask to buy what's now held and sell
+// what's gone. Execute checks the live positions and refuses both, and the
+// next account run brings positions.json back in line.
+func TestDowntime_StalePositions(t *testing.T) {
+	d := newDowntime(t, []string{"SYNA", "SYNB"}, []position{{Symbol: "SYNB", Qty: 10, AvgEntry: 50}})
+	writeFile(t, filepath.Join(d.docs, "positions.json"),
+		`[{"symbol": "SYNB", "qty": "10", "avg_entry_price": "50", "side": "long", "client_order_id": ""}]`)
+
+	// While down
+	for _, body := range []string{
+		`{"symbol":"SYNA","qty":"10","side":"buy","type":"market","client_order_id":"SYNA_e2e-v1_tp1.00_sl1.00_tsl1.00_p0_x"}`,
+		`{"symbol":"SYNB","qty":"10","side":"sell","type":"market","client_order_id":"manual"}`,
+	} {
+		if resp := post(t, d.url, body); resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: got HTTP %d", body, resp.StatusCode)
+		}
+	}
+
+	// Back up: orders from the stale positions, still within their intent
+	until := time.Now().Add(10 * time.Minute)
+	writeFix(t, filepath.Join(d.docs, "buy.fix"), fixOrder("SYNA_e2e-v1_tp1.00_sl1.00_tsl1.00_p0_y", "SYNA", 1, 10, until))
+	writeFix(t, filepath.Join(d.docs, "sell.fix"), fixOrder("SYNB_exit", "SYNB", 2, 10, until))
+	d.run("execute")
+
+	if fills := d.broker.Fills(); len(fills) != 2 {
+		t.Fatalf("broker filled %d order(s), want only the 2 from the outage", len(fills))
+	}
+	if q := d.broker.Held("SYNA"); q != 10 {
+		t.Errorf("SYNA: held %g, want 10 — bought twice?", q)
+	}
+	var result executionResult
+	readJSON(t, filepath.Join(d.docs, "execution-result.json"), &result)
+	want := map[string]string{"SYNA buy": "already held", "SYNB sell": "not in live positions"}
+	if got := result.reasons(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("skipped: got %v, want %v", got, want)
+	}
+
+	d.run("account")
+	var positions []struct {
+		Symbol string `json:"symbol"`
+	}
+	readJSON(t, filepath.Join(d.docs, "positions.json"), &positions)
+	if len(positions) != 1 || positions[0].Symbol != "SYNA" {
+		t.Errorf("positions.json after account: got %+v, want SYNA alone", positions)
+	}
+}
+
+// An order filled while the pipeline was down, so the journal written at the
+// last cycle doesn't have it. Reconcile catches the difference and fails
+// under -strict. On restart account adopts the position into positions.json
+// at its real entry price, so exits protects it with the default levels (no
+// open order carries its own), and summary rebuilds the journal from the
+// broker's orders, after which reconcile balances.
+func TestDowntime_FilledWhileDown(t *testing.T) {
+	d := newDowntime(t, []string{"SYNA"}, nil)
+
+	// The last cycle before the outage
+	d.run("account")
+	d.run("summary")
+
+	// While down
+	if resp := post(t, d.url, `{"symbol":"SYNA","qty":"10","side":"buy","type":"market","client_order_id":"SYNA_e2e-v1_tp1.00_sl1.00_tsl1.00_p0_x"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("buy: got HTTP %d", resp.StatusCode)
+	}
+	price := d.broker.last("SYNA")
+
+	type reconciliation struct {
+		Status string `json:"status"`
+		Checks []struct {
+			Name string `json:"name"`
+			OK   bool   `json:"ok"`
+		} `json:"checks"`
+	}
+	failed := func(r reconciliation) []string {
+		var names []string
+		for _, c := range r.Checks {
+			if !c.OK {
+				names = append(names, c.Name)
+			}
+		}
+		return names
+	}
+
+	// Against the stale journal
+	err := d.try("reconcile", "-strict")
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		t.Fatalf("reconcile -strict on a stale journal: got %v, want a non-zero exit", err)
+	}
+	var stale reconciliation
+	readJSON(t, filepath.Join(d.docs, "reconciliation.json"), &stale)
+	if stale.Status != "mismatch" || !strings.Contains(strings.Join(failed(stale), " "), "fills") {
+		t.Errorf("stale journal: got %s with %v failing, want a fills mismatch", stale.Status, failed(stale))
+	}
+
+	// Back up
+	d.run("account")
+	var positions []struct {
+		Symbol        string `json:"symbol"`
+		AvgEntryPrice string `json:"avg_entry_price"`
+	}
+	readJSON(t, filepath.Join(d.docs, "positions.json"), &positions)
+	if len(positions) != 1 || positions[0].Symbol != "SYNA" {
+		t.Fatalf("positions.json: got %+v, want SYNA", positions)
+	}
+	if entry, _ := strconv.ParseFloat(positions[0].AvgEntryPrice, 64); entry != price {
+		t.Errorf("SYNA entry: got %s, want the fill price %g", positions[0].AvgEntryPrice, price)
+	}
+	var diff struct {
+		Changes []struct {
+			Symbol string `json:"symbol"`
+			Change string `json:"change"`
+		} `json:"changes"`
+	}
+	readJSON(t, filepath.Join(d.docs, "positions-diff.json"), &diff)
+	if len(diff.Changes) != 1 || diff.Changes[0].Symbol != "SYNA" || diff.Changes[0].Change != "opened" {
+		t.Errorf("positions-diff.json: got %+v, want SYNA opened", diff.Changes)
+	}
+
+	d.run("summary")
+	var journal struct {
+		Activities []struct {
+			Symbol string `json:"symbol"`
+		} `json:"activities"`
+	}
+	readJSON(t, filepath.Join(d.docs, "daily-summary.json"), &journal)
+	if len(journal.Activities) != 1 || journal.Activities[0].Symbol != "SYNA" {
+		t.Fatalf("journal: got %+v, want the SYNA fill adopted", journal.Activities)
+	}
+
+	d.run("reconcile", "-strict")
+	var rec reconciliation
+	readJSON(t, filepath.Join(d.docs, "reconciliation.json"), &rec)
+	if rec.Status != "ok" {
+		t.Errorf("after recovery: got %s with %v failing, want ok", rec.Status, failed(rec))
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/deanturpin/lft2/e2e
+
+go 1.21
@@ -0,0 +1,171 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Synthetic universe. LOSS is held at twice its price, so exits always has a
+// stop loss to send whatever the clock says; entries only signals while its
+// latest bar falls in market hours.
+var (
+	symbols = []string{"SYNA", "SYNB", "SYNC", "SYND", "LOSS"}
+	held    = position{Symbol: "LOSS", Qty: 10}
+)
+
+// fixOrders returns the orders in a .fix file, skipping the heartbeat.
+func fixOrders(t *testing.T, path string) []map[string]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	defer f.Close()
+
+	var orders []map[string]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := map[string]string{}
+		for _, pair := range strings.Split(scanner.Text(), "|") {
+			if k, v, ok := strings.Cut(pair, "="); ok {
+				fields[k] = v
+			}
+		}
+		if fields["35"] == "D" {
+			orders = append(orders, fields)
+		}
+	}
+	return orders
+}
+
+func TestPipeline(t *testing.T) {
+	root := repoRoot(t)
+	build := os.Getenv("LFT2_BUILD")
+	if build == "" {
+		build = filepath.Join(root, "build")
+	}
+	for _, module := range []string{"backtest", "entries", "exits"} {
+		if _, err := os.Stat(filepath.Join(build, module)); err != nil {
+			t.Fatalf("C++ module %s not built in %s — run make build (or make e2e)", module, build)
+		}
+	}
+	bin := t.TempDir()
+	buildGo(t, root, bin, "fetch", "filter", "account", "execute")
+
+	// Workspace: watchlist at the root, artifacts under docs/ as in the repo
+	workspace := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workspace, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	watchlist, _ := json.Marshal(map[string][]string{"symbols": symbols})
+	if err := os.WriteFile(filepath.Join(workspace, "watchlist.json"), watchlist, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBroker(symbols, 600, 100000, nil, time.Now())
+	held.AvgEntry = 2 * b.last("LOSS")
+	b.positions["LOSS"] = &held
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	env := stageEnv(srv.URL, workspace)
+	run(t, workspace, env, filepath.Join(bin, "fetch"), "-bars", "600")
+	run(t, workspace, env, filepath.Join(bin, "filter"))
+	run(t, workspace, env, filepath.Join(build, "backtest"), "--seed", "0")
+	run(t, workspace, env, filepath.Join(bin, "account"))
+	run(t, workspace, env, filepath.Join(build, "entries"))
+	run(t, workspace, env, filepath.Join(build, "exits"))
+	run(t, workspace, env, filepath.Join(bin, "execute"))
+
+	docs := filepath.Join(workspace, "docs")
+
+	// Every stage's artifacts are present and well formed
+	for _, s := range symbols {
+		var bars struct {
+			Count int `json:"count"`
+		}
+		readJSON(t, filepath.Join(docs, "bars", s+".json"), &bars)
+		if bars.Count != 600 {
+			t.Errorf("%s: fetched %d bars, want 600", s, bars.Count)
+		}
+	}
+	var candidates, strategies, account map[string]any
+	readJSON(t, filepath.Join(docs, "candidates.json"), &candidates)
+	readJSON(t, filepath.Join(docs, "strategies.json"), &strategies)
+	readJSON(t, filepath.Join(docs, "account.json"), &account)
+	if _, ok := strategies["recommendations"]; !ok {
+		t.Error("strategies.json has no recommendations")
+	}
+	var positions []struct {
+		Symbol string `json:"symbol"`
+	}
+	readJSON(t, filepath.Join(docs, "positions.json"), &positions)
+	if len(positions) != 1 || positions[0].Symbol != "LOSS" {
+		t.Errorf("positions.json: got %+v, want LOSS alone", positions)
+	}
+
+	// The losing position is closed in full
+	buys := fixOrders(t, filepath.Join(docs, "buy.fix"))
+	sells := fixOrders(t, filepath.Join(docs, "sell.fix"))
+	if len(sells) != 1 || sells[0]["55"] != "LOSS" {
+		t.Fatalf("sell.fix: got %v, want one LOSS order", sells)
+	}
+	if q := b.Held("LOSS"); q != 0 {
+		t.Errorf("LOSS still held: %g", q)
+	}
+
+	// Every order entries and exits wrote reached the broker and filled
+	fills := b.Fills()
+	if len(fills) != len(buys)+len(sells) {
+		t.Fatalf("broker filled %d order(s), FIX files hold %d", len(fills), len(buys)+len(sells))
+	}
+	byID := map[string]fill{}
+	for _, f := range fills {
+		byID[f.ClientOrderID] = f
+	}
+	for _, o := range append(buys, sells...) {
+		f, ok := byID[o["11"]]
+		if !ok || f.Symbol != o["55"] {
+			t.Errorf("order %s (%s) not filled as written: %+v", o["11"], o["55"], f)
+		}
+	}
+	t.Logf("%d buy(s), %d sell(s) filled", len(buys), len(sells))
+
+	// Each submission is on record, credentials withheld
+	logs, _ := filepath.Glob(filepath.Join(workspace, "audit", "orders-*.jsonl"))
+	var audited []string
+	for _, path := range logs {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		audited = append(audited, strings.Split(strings.TrimSpace(string(data)), "\n")...)
+	}
+	if len(audited) != len(fills) {
+		t.Errorf("audit log holds %d submission(s), broker filled %d", len(audited), len(fills))
+	}
+	for _, line := range audited {
+		if !strings.Contains(line, `"Apca-Api-Secret-Key":"[redacted]"`) {
+			t.Errorf("audit entry with the secret unredacted: %s", line)
+		}
+	}
+
+	// Execute's outcome agrees with the broker
+	var result struct {
+		Submitted int `json:"submitted"`
+		Rejected  int `json:"rejected"`
+		Errors    int `json:"errors"`
+	}
+	readJSON(t, filepath.Join(docs, "execution-result.json"), &result)
+	if result.Submitted != len(fills) || result.Rejected+result.Errors != 0 {
+		t.Errorf("execution-result.json: got %+v, want %d submitted and no failures", result, len(fills))
+	}
+}
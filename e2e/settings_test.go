@@ -0,0 +1,73 @@
+package e2e
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Bad settings: a corrupt optional file or a mistyped tuning variable that
+// execute reads. Whatever it disables, the exits must still reach the
+// broker, since a stop-loss or end-of-day close can't wait for the fix.
+func TestBadSettings_SellsStillGoOut(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   []string
+		files map[string]string // Workspace files, by path from its root
+		buy   string            // Why the buy is refused; "" if it goes out
+	}{
+		{
+			name:  "corrupt blocklist",
+			files: map[string]string{"blocklist.json": `{"blocked": [`},
+			buy:   "buys refused: parsing blocklist",
+		},
+		{
+			name: "mistyped order delay",
+			env:  []string{"LFT2_ORDER_DELAY=fast"},
+		},
+		{
+			name: "mistyped submit budget",
+			env:  []string{"LFT2_SUBMIT_BUDGET=soon"},
+		},
+		{
+			name: "malformed order tags",
+			env:  []string{"LFT2_ORDER_TAGS=experiment"},
+		},
+		{
+			name: "liquidity floor out of range",
+			env:  []string{"LFT2_LIQUIDITY_FLOOR=50"},
+		},
+		{
+			name:  "corrupt cycle log",
+			files: map[string]string{"docs/executed-cycles.json": `{"cycles": [`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDowntimeEnv(t, []string{"SYNA", "SYNB"}, []position{{Symbol: "SYNB", Qty: 10, AvgEntry: 50}}, tt.env...)
+			workspace := filepath.Dir(d.docs)
+			for path, body := range tt.files {
+				writeFile(t, filepath.Join(workspace, path), body)
+			}
+			until := time.Now().Add(10 * time.Minute)
+			writeFix(t, filepath.Join(d.docs, "buy.fix"), fixOrder("SYNA_e2e-v1_tp1.00_sl1.00_tsl1.00_p0_x", "SYNA", 1, 10, until))
+			writeFix(t, filepath.Join(d.docs, "sell.fix"), fixOrder("SYNB_exit", "SYNB", 2, 10, until))
+
+			d.try("execute")
+
+			if q := d.broker.Held("SYNB"); q != 0 {
+				t.Errorf("SYNB: still held %g, want the exit filled", q)
+			}
+			var result executionResult
+			readJSON(t, filepath.Join(d.docs, "execution-result.json"), &result)
+			got := result.reasons()["SYNA buy"]
+			switch {
+			case tt.buy == "" && (got != "" || d.broker.Held("SYNA") != 10):
+				t.Errorf("SYNA buy: skipped for %q, want it filled", got)
+			case tt.buy != "" && !strings.HasPrefix(got, tt.buy):
+				t.Errorf("SYNA buy: skipped for %q, want %q", got, tt.buy)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// repoRoot is the repository the pipeline is built from.
+func repoRoot(t *testing.T) string {
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// buildGo builds each Go stage into dir.
+func buildGo(t *testing.T, root, dir string, stages ...string) {
+	for _, stage := range stages {
+		cmd := exec.Command("go", "build", "-o", filepath.Join(dir, stage), ".")
+		cmd.Dir = filepath.Join(root, "cmd", stage)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("building %s: %v\n%s", stage, err, out)
+		}
+	}
+}
+
+// run runs one stage in the workspace and fails the test if it fails.
+func run(t *testing.T, workspace string, env []string, bin string, args ...string) {
+	t.Helper()
+	if err := try(t, workspace, env, bin, args...); err != nil {
+		t.Fatalf("%s failed: %v", filepath.Base(bin), err)
+	}
+}
+
+// try runs one stage in the workspace and returns how it exited, for stages
+// expected to fail.
+func try(t *testing.T, workspace string, env []string, bin string, args ...string) error {
+	t.Helper()
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = workspace
+	cmd.Env = env
+	out, err := cmd.CombinedOutput()
+	t.Logf("→ %s %s\n%s", filepath.Base(bin), strings.Join(args, " "), out)
+	return err
+}
+
+// stageEnv points the stages at the mock broker, with published artifacts
+// looked up in the workspace (where there are none) and every setting that
+// changes their behaviour cleared, so the host's environment can't leak in.
+func stageEnv(url, workspace string) []string {
+	return append(os.Environ(),
+		"ALPACA_API_KEY=e2e", "ALPACA_API_SECRET=e2e", "ALPACA_AUTH=", "ALPACA_OAUTH_TOKEN=",
+		"ALPACA_BASE_URL="+url, "ALPACA_DATA_URL="+url,
+		"ALPACA_DATA_API_KEY=", "ALPACA_DATA_API_SECRET=", "ALPACA_FEED=",
+		"LFT2_ARTIFACT_BASE="+filepath.Join(workspace, "published"),
+		"LFT2_ARTIFACT_CACHE=off", "LFT2_FUNDAMENTALS=",
+		"LFT2_ORDER_DELAY=0s", "LFT2_ORDER_JITTER=0s",
+		"LFT2_STATE_KEY=", "LFT2_STATE_KEYFILE=", "LFT2_MAX_VAR=",
+		"LFT2_CONFLICT_POLICY=", "LFT2_FEES=", "LFT2_TIMEZONE=",
+		"LFT2_ORDER_KEY=", "LFT2_EVENT_BUS=", "LFT2_CYCLE=",
+		"LFT2_RISK_FREE=", "LFT2_BENCHMARK=", "LFT2_LABELS=",
+		"LFT2_DATA_RATE=", "LFT2_STALE_POLICY=", "LFT2_MAX_SYMBOLS=", "LFT2_ORDER_TAGS=",
+		"LFT2_LIQUIDITY_FLOOR=", "LFT2_API_BUDGET=", "LFT2_BARS_GZIP=",
+		"LFT2_DATA_PROVIDER=", "LFT2_FALLBACK_PROVIDER=",
+		"LFT2_PREVIEW_LEAD=", "LFT2_REPORT_DELAY=", "LFT2_HTTP_CONFIG=", "LFT2_AUDIT_DIR=",
+		"LFT2_NOTIFY_WEBHOOK=", "LFT2_BOOK=", "LFT2_STORE=",
+	)
+}
+
+// readJSON decodes a workspace artifact, failing the test if it's missing or
+// malformed.
+func readJSON(t *testing.T, path string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("artifact missing: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+}
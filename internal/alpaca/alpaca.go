@@ -4,21 +4,39 @@ package alpaca
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/deanturpin/lft2/internal/httpconf"
 )
 
 // Client holds credentials and the base URLs for Alpaca's REST API.
 type Client struct {
-	APIKey    string
-	APISecret string
-	BaseURL   string // broker/account API  (paper-api.alpaca.markets)
-	DataURL   string // market data API     (data.alpaca.markets)
+	Auth    Auth            // KeyAuth from New; see AuthFromEnv for the others
+	BaseURL string          // broker/account API  (paper-api.alpaca.markets)
+	DataURL string          // market data API     (data.alpaca.markets)
+	HTTP    httpconf.Policy // Timeout and GET retries; zero for httpconf.Default
+	Audit   func(Exchange)  // Given every POST once it's answered or failed; nil for none
+	ctx     context.Context // From WithContext; nil for none
+}
+
+// Exchange is a POST as sent and what came back, for Client.Audit.
+// Request's headers carry the credentials; redacting them is the hook's job.
+type Exchange struct {
+	Sent     time.Time
+	Request  *http.Request
+	Body     []byte
+	Response *http.Response // Nil when nothing came back
+	Reply    []byte
+	Err      error // The request or reading the reply failed
 }
 
-// New returns a Client configured from the supplied credentials.
+// New returns a Client signing with an API key pair.
 // baseURL defaults to the paper trading endpoint if empty.
 // dataURL defaults to the standard data endpoint if empty.
 func New(apiKey, apiSecret, baseURL, dataURL string) Client {
@@ -28,51 +46,147 @@ func New(apiKey, apiSecret, baseURL, dataURL string) Client {
 	if dataURL == "" {
 		dataURL = "https://data.alpaca.markets"
 	}
-	return Client{APIKey: apiKey, APISecret: apiSecret, BaseURL: baseURL, DataURL: dataURL}
+	return Client{Auth: KeyAuth{Key: apiKey, Secret: apiSecret}, BaseURL: baseURL, DataURL: dataURL}
+}
+
+// WithContext returns a copy of c whose requests, and the waits between GET
+// retries, are abandoned once ctx is cancelled.
+func (c Client) WithContext(ctx context.Context) Client {
+	c.ctx = ctx
+	return c
+}
+
+func (c Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// policy is c.HTTP, or the default for a Client that didn't set one.
+func (c Client) policy() httpconf.Policy {
+	if c.HTTP == (httpconf.Policy{}) {
+		return httpconf.Default
+	}
+	return c.HTTP
+}
+
+// retryable reports whether a failed GET might succeed if sent again: a
+// timeout or other network error, a 5xx, or a 429. The wait is the
+// server's Retry-After when it gave one.
+func retryable(err error) (bool, time.Duration) {
+	var se *StatusError
+	if !errors.As(err, &se) {
+		return true, 0
+	}
+	if limited, wait := RateLimited(err); limited {
+		return true, wait
+	}
+	return se.StatusCode >= 500, 0
 }
 
-var httpClient = &http.Client{Timeout: 10 * time.Second}
+// StatusError is returned when Alpaca answers with anything other than 200.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // from the Retry-After header, 0 if absent
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// RateLimited reports whether err is a 429 from Alpaca, and how long the
+// server asked us to wait (0 if it didn't say).
+func RateLimited(err error) (bool, time.Duration) {
+	var se *StatusError
+	if errors.As(err, &se) && se.StatusCode == http.StatusTooManyRequests {
+		return true, se.RetryAfter
+	}
+	return false, 0
+}
+
+func statusError(resp *http.Response, body []byte) error {
+	var retryAfter time.Duration
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		retryAfter = time.Duration(secs) * time.Second
+	}
+	return &StatusError{StatusCode: resp.StatusCode, Body: string(body), RetryAfter: retryAfter}
+}
 
 // Post performs an authenticated POST request with a JSON body and returns the response body.
+// It's sent once: a POST that timed out may still have placed its order, so
+// retrying is left to callers that can check first.
 func (c Client) Post(url string, body []byte) ([]byte, error) {
-	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(c.context(), "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("APCA-API-KEY-ID", c.APIKey)
-	req.Header.Set("APCA-API-SECRET-KEY", c.APISecret)
+	c.Authorize(req)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := httpClient.Do(req)
+	sent := time.Now()
+	Count("POST", url, sent)
+	resp, err := (&http.Client{Timeout: c.policy().Timeout}).Do(req)
 	if err != nil {
+		c.audit(Exchange{Sent: sent, Request: req, Body: body, Err: err})
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
+	c.audit(Exchange{Sent: sent, Request: req, Body: body, Response: resp, Reply: respBody, Err: err})
 	if err != nil {
 		return nil, fmt.Errorf("reading response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+		return nil, statusError(resp, respBody)
 	}
 
 	return respBody, nil
 }
 
-// Get performs an authenticated GET request and returns the response body.
+func (c Client) audit(x Exchange) {
+	if c.Audit != nil {
+		c.Audit(x)
+	}
+}
+
+// Get performs an authenticated GET request and returns the response body,
+// retrying as c.HTTP allows.
 func (c Client) Get(url string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
+	p := c.policy()
+	for attempt := 1; ; attempt++ {
+		body, err := c.get(url, p.Timeout)
+		if err == nil || attempt > p.Retries {
+			return body, err
+		}
+		retry, wait := retryable(err)
+		if !retry {
+			return nil, err
+		}
+		t := time.NewTimer(max(wait, p.Delay(attempt)))
+		select {
+		case <-t.C:
+		case <-c.context().Done():
+			t.Stop()
+			return nil, err
+		}
+	}
+}
+
+func (c Client) get(url string, timeout time.Duration) ([]byte, error) {
+	req, err := http.NewRequestWithContext(c.context(), "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("APCA-API-KEY-ID", c.APIKey)
-	req.Header.Set("APCA-API-SECRET-KEY", c.APISecret)
+	c.Authorize(req)
 
-	resp, err := httpClient.Do(req)
+	Count("GET", url, time.Now())
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -84,7 +198,7 @@ func (c Client) Get(url string) ([]byte, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp, body)
 	}
 
 	return body, nil
@@ -0,0 +1,278 @@
+package alpaca
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/httpconf"
+)
+
+// --- RateLimited ---
+
+func TestPost_RateLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	_, err := New("k", "s", srv.URL, "").Post(srv.URL+"/v2/orders", []byte("{}"))
+	limited, wait := RateLimited(err)
+	if !limited || wait != 3*time.Second {
+		t.Errorf("got limited=%t wait=%v, want true 3s (err %v)", limited, wait, err)
+	}
+	if !strings.HasPrefix(err.Error(), "HTTP 429: ") {
+		t.Errorf("error text changed: %q", err)
+	}
+}
+
+func TestRateLimited_OtherErrors(t *testing.T) {
+	for _, err := range []error{nil, &StatusError{StatusCode: 403}, fmt.Errorf("HTTP request failed")} {
+		if limited, _ := RateLimited(err); limited {
+			t.Errorf("%v: reported as rate limited", err)
+		}
+	}
+}
+
+// --- retries ---
+
+func TestGet_Retries(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+
+	c := New("k", "s", srv.URL, "")
+	c.HTTP = httpconf.Policy{Timeout: time.Second, Retries: 2, Backoff: time.Millisecond}
+	if body, err := c.Get(srv.URL + "/v2/account"); err != nil || string(body) != "{}" || calls != 3 {
+		t.Errorf("got %q, %v after %d calls", body, err, calls)
+	}
+
+	// A client error is final however many retries are allowed
+	calls = 0
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+	if _, err := c.Get(srv.URL + "/v2/account"); err == nil || calls != 1 {
+		t.Errorf("403: got %v after %d calls, want one", err, calls)
+	}
+}
+
+func TestPost_NotRetried(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New("k", "s", srv.URL, "")
+	c.HTTP = httpconf.Policy{Timeout: time.Second, Retries: 3, Backoff: time.Millisecond}
+	if _, err := c.Post(srv.URL+"/v2/orders", []byte("{}")); err == nil || calls != 1 {
+		t.Errorf("got %v after %d calls, want one: an order must never be sent twice", err, calls)
+	}
+}
+
+func TestPost_Audit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"insufficient buying power"}`, http.StatusForbidden)
+	}))
+	var got []Exchange
+	c := New("k", "s", srv.URL, "")
+	c.Audit = func(x Exchange) { got = append(got, x) }
+
+	if _, err := c.Post(srv.URL+"/v2/orders", []byte(`{"symbol":"AAPL"}`)); err == nil {
+		t.Error("403: want an error")
+	}
+	srv.Close()
+	if _, err := c.Post(srv.URL+"/v2/orders", []byte(`{"symbol":"MSFT"}`)); err == nil {
+		t.Error("server gone: want an error")
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d exchanges, want one per POST", len(got))
+	}
+	if x := got[0]; x.Response.StatusCode != http.StatusForbidden || !strings.Contains(string(x.Reply), "buying power") ||
+		string(x.Body) != `{"symbol":"AAPL"}` || x.Request.Header.Get("APCA-API-KEY-ID") != "k" || x.Err != nil {
+		t.Errorf("answered: got %+v", x)
+	}
+	if x := got[1]; x.Response != nil || x.Err == nil || string(x.Body) != `{"symbol":"MSFT"}` {
+		t.Errorf("failed: got %+v", x)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New("k", "s", srv.URL, "").WithContext(ctx)
+	c.HTTP = httpconf.Policy{Timeout: time.Second, Retries: 3, Backoff: time.Hour}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := c.Get(srv.URL + "/v2/account"); err == nil || calls != 1 || time.Since(start) > 5*time.Second {
+		t.Errorf("got %v after %d calls, want the backoff abandoned", err, calls)
+	}
+	if _, err := c.Get(srv.URL + "/v2/account"); !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("cancelled: got %v after %d calls, want context.Canceled unsent", err, calls)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	c := New("k", "s", srv.URL, "")
+	c.HTTP = httpconf.Policy{Timeout: 50 * time.Millisecond}
+	if _, err := c.Post(srv.URL+"/v2/orders", []byte("{}")); err == nil || !strings.Contains(err.Error(), "HTTP request failed") {
+		t.Errorf("got %v, want a timeout", err)
+	}
+}
+
+// --- LatestQuotes ---
+
+func TestLatestQuotes(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("symbols")
+		fmt.Fprint(w, `{"quotes": {"AAPL": {"t": "2026-03-10T15:00:00Z", "bp": 199.98, "bs": 3, "ap": 200.02, "as": 5}}}`)
+	}))
+	defer srv.Close()
+
+	quotes, err := New("k", "s", "", srv.URL).LatestQuotes([]string{"AAPL", "NOPE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "AAPL,NOPE" {
+		t.Errorf("symbols: got %q", query)
+	}
+	if q, ok := quotes["AAPL"]; !ok || q.BidPrice != 199.98 || q.AskPrice != 200.02 {
+		t.Errorf("got %+v", quotes)
+	}
+	if _, ok := quotes["NOPE"]; ok {
+		t.Error("unquoted symbol present")
+	}
+
+	if _, err := New("k", "s", "", srv.URL).LatestQuotes(make([]string, MaxQuoteSymbols+1)); err == nil {
+		t.Error("over the symbol limit: want an error")
+	}
+}
+
+// --- Calendar ---
+
+func TestCalendar(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		fmt.Fprint(w, `[
+			{"date": "2025-12-24", "open": "09:30", "close": "13:00", "session_open": "0400", "session_close": "2000"},
+			{"date": "2025-12-26", "open": "09:30", "close": "16:00", "session_open": "0400", "session_close": "2000"}
+		]`)
+	}))
+	defer srv.Close()
+
+	sessions, err := New("k", "s", srv.URL, "").Calendar("2025-12-24", "2025-12-26")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "end=2025-12-26&start=2025-12-24" {
+		t.Errorf("query: got %q", query)
+	}
+	if len(sessions) != 2 || sessions[1].Date != "2025-12-26" {
+		t.Fatalf("got %+v", sessions)
+	}
+
+	// A half day, in New York winter time
+	open, close, err := sessions[0].Bounds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := open.UTC().Format(time.RFC3339); got != "2025-12-24T14:30:00Z" {
+		t.Errorf("open: got %s", got)
+	}
+	if got := close.Sub(open); got != 3*time.Hour+30*time.Minute {
+		t.Errorf("length: got %v, want 3h30m", got)
+	}
+
+	if _, _, err := (Session{Date: "2025-12-24", Open: "9am"}).Bounds(); err == nil {
+		t.Error("unparseable open: want an error")
+	}
+}
+
+// --- Usage ---
+
+func TestEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		method, url, want string
+	}{
+		{"GET", "https://data.alpaca.markets/v2/stocks/AAPL/bars?timeframe=5Min", "data GET /v2/stocks/{}/bars"},
+		{"GET", "https://data.alpaca.markets/v2/stocks/quotes/latest?symbols=AAPL,MSFT", "data GET /v2/stocks/quotes/latest"},
+		{"POST", "https://paper-api.alpaca.markets/v2/orders", "trading POST /v2/orders"},
+		{"GET", "https://paper-api.alpaca.markets/v2/orders/61e69015-8549-4bfd-b9c3-01e75843f47d", "trading GET /v2/orders/{}"},
+		{"GET", "https://paper-api.alpaca.markets/v2/account/activities?date=2026-03-10", "trading GET /v2/account/activities"},
+	} {
+		if got := Endpoint(tc.method, tc.url); got != tc.want {
+			t.Errorf("%s %s: got %q, want %q", tc.method, tc.url, got, tc.want)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	before, _ := Usage()
+	minute := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		Count("GET", "https://data.alpaca.markets/v2/stocks/AAPL/bars", minute.Add(time.Duration(i)*time.Second))
+	}
+	Count("GET", "https://data.alpaca.markets/v2/stocks/MSFT/bars", minute.Add(time.Minute))
+
+	calls, peaks := Usage()
+	if got := calls["data GET /v2/stocks/{}/bars"] - before["data GET /v2/stocks/{}/bars"]; got != 4 {
+		t.Errorf("bars calls: got %d, want 4", got)
+	}
+	if peaks[DataAPI] < 3 {
+		t.Errorf("data peak: got %d, want at least the 3 in one minute", peaks[DataAPI])
+	}
+}
+
+// --- Announcements ---
+
+func TestAnnouncements(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `[{"id": "a1", "ca_type": "split", "ca_sub_type": "forward_split",
+			"initiating_symbol": "NVDA", "ex_date": "2026-03-10", "old_rate": "1", "new_rate": "4"}]`)
+	}))
+	defer srv.Close()
+
+	got, err := New("k", "s", srv.URL, "").Announcements([]string{"Split", "Dividend"}, "2026-01-01", "2026-03-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("ca_types") != "Split,Dividend" || query.Get("since") != "2026-01-01" || query.Get("until") != "2026-03-31" {
+		t.Errorf("query: got %v", query)
+	}
+	if len(got) != 1 || got[0].InitiatingSymbol != "NVDA" || got[0].NewRate != 4 || got[0].OldRate != 1 {
+		t.Errorf("got %+v", got)
+	}
+}
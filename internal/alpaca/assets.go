@@ -0,0 +1,33 @@
+package alpaca
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Asset is the subset of /v2/assets fields used for universe screening.
+type Asset struct {
+	Symbol       string   `json:"symbol"`
+	Name         string   `json:"name"`
+	Exchange     string   `json:"exchange"`
+	Class        string   `json:"class"`
+	Status       string   `json:"status"`
+	Tradable     bool     `json:"tradable"`
+	Fractionable bool     `json:"fractionable"`
+	Attributes   []string `json:"attributes"`
+}
+
+// Assets returns every active US equity asset. One bulk request is far
+// cheaper than a lookup per symbol for large watchlists.
+func (c Client) Assets() ([]Asset, error) {
+	body, err := c.Get(c.BaseURL + "/v2/assets?status=active&asset_class=us_equity")
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []Asset
+	if err := json.Unmarshal(body, &assets); err != nil {
+		return nil, fmt.Errorf("parsing assets: %w", err)
+	}
+	return assets, nil
+}
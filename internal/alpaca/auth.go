@@ -0,0 +1,100 @@
+package alpaca
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Auth signs a request to Alpaca. Every Get and Post goes through the
+// client's; a caller with its own HTTP client uses Client.Authorize.
+type Auth interface {
+	Authorize(req *http.Request)
+}
+
+// KeyAuth is an API key pair from the Alpaca dashboard, sent as Alpaca's
+// own headers. It's what the trading and market data APIs take by default.
+type KeyAuth struct {
+	Key    string
+	Secret string
+}
+
+func (a KeyAuth) Authorize(req *http.Request) {
+	req.Header.Set("APCA-API-KEY-ID", a.Key)
+	req.Header.Set("APCA-API-SECRET-KEY", a.Secret)
+}
+
+// OAuth is an access token an Alpaca OAuth app was granted for a user's
+// account, sent as a bearer token.
+type OAuth struct {
+	Token string
+}
+
+func (a OAuth) Authorize(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+}
+
+// BrokerAuth is a Broker API key pair, production or sandbox, sent as HTTP
+// Basic credentials.
+type BrokerAuth struct {
+	Key    string
+	Secret string
+}
+
+func (a BrokerAuth) Authorize(req *http.Request) {
+	req.SetBasicAuth(a.Key, a.Secret)
+}
+
+// Schemes ALPACA_AUTH selects between
+const (
+	SchemeKey    = "key"
+	SchemeOAuth  = "oauth"
+	SchemeBroker = "broker"
+)
+
+// AuthFromEnv returns the credentials ALPACA_AUTH names: "key" (the
+// default) or "broker" take ALPACA_API_KEY and ALPACA_API_SECRET, "oauth"
+// takes ALPACA_OAUTH_TOKEN. Missing credentials are an error.
+func AuthFromEnv() (Auth, error) {
+	key, secret := os.Getenv("ALPACA_API_KEY"), os.Getenv("ALPACA_API_SECRET")
+	pair := func() error {
+		if key == "" || secret == "" {
+			return errors.New("ALPACA_API_KEY and ALPACA_API_SECRET must be set")
+		}
+		return nil
+	}
+	switch scheme := os.Getenv("ALPACA_AUTH"); scheme {
+	case "", SchemeKey:
+		return KeyAuth{Key: key, Secret: secret}, pair()
+	case SchemeBroker:
+		return BrokerAuth{Key: key, Secret: secret}, pair()
+	case SchemeOAuth:
+		token := os.Getenv("ALPACA_OAUTH_TOKEN")
+		if token == "" {
+			return nil, errors.New("ALPACA_OAUTH_TOKEN must be set when ALPACA_AUTH is oauth")
+		}
+		return OAuth{Token: token}, nil
+	default:
+		return nil, fmt.Errorf("ALPACA_AUTH: unknown scheme %q: want %s, %s or %s", scheme, SchemeKey, SchemeOAuth, SchemeBroker)
+	}
+}
+
+// FromEnv returns a Client for the credentials AuthFromEnv reads and the
+// endpoints in ALPACA_BASE_URL and ALPACA_DATA_URL, defaulted as for New.
+func FromEnv() (Client, error) {
+	auth, err := AuthFromEnv()
+	if err != nil {
+		return Client{}, err
+	}
+	c := New("", "", os.Getenv("ALPACA_BASE_URL"), os.Getenv("ALPACA_DATA_URL"))
+	c.Auth = auth
+	return c, nil
+}
+
+// Authorize signs req with c's credentials.
+func (c Client) Authorize(req *http.Request) {
+	if c.Auth != nil {
+		c.Auth.Authorize(req)
+	}
+}
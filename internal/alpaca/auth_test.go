@@ -0,0 +1,86 @@
+package alpaca
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		auth         Auth
+		header, want string
+	}{
+		{KeyAuth{Key: "k", Secret: "s"}, "APCA-API-KEY-ID", "k"},
+		{OAuth{Token: "tok"}, "Authorization", "Bearer tok"},
+		{BrokerAuth{Key: "k", Secret: "s"}, "Authorization", "Basic azpz"},
+	} {
+		c := New("", "", srv.URL, "")
+		c.Auth = tc.auth
+		if _, err := c.Get(srv.URL + "/v2/account"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Post(srv.URL+"/v2/orders", []byte(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+		if got.Get(tc.header) != tc.want {
+			t.Errorf("%T: %s is %q, want %q", tc.auth, tc.header, got.Get(tc.header), tc.want)
+		}
+	}
+	if got.Get("APCA-API-KEY-ID") != "" {
+		t.Error("broker request carries a trading key header")
+	}
+}
+
+func TestAuthFromEnv(t *testing.T) {
+	t.Setenv("ALPACA_API_KEY", "k")
+	t.Setenv("ALPACA_API_SECRET", "s")
+	t.Setenv("ALPACA_OAUTH_TOKEN", "")
+	for scheme, want := range map[string]Auth{
+		"":       KeyAuth{Key: "k", Secret: "s"},
+		"key":    KeyAuth{Key: "k", Secret: "s"},
+		"broker": BrokerAuth{Key: "k", Secret: "s"},
+	} {
+		t.Setenv("ALPACA_AUTH", scheme)
+		if got, err := AuthFromEnv(); err != nil || got != want {
+			t.Errorf("%q: got %#v, %v", scheme, got, err)
+		}
+	}
+
+	t.Setenv("ALPACA_AUTH", "oauth")
+	if _, err := AuthFromEnv(); err == nil {
+		t.Error("oauth without a token: want an error")
+	}
+	t.Setenv("ALPACA_OAUTH_TOKEN", "tok")
+	if got, err := AuthFromEnv(); err != nil || got != (OAuth{Token: "tok"}) {
+		t.Errorf("oauth: got %#v, %v", got, err)
+	}
+
+	t.Setenv("ALPACA_AUTH", "password")
+	if _, err := AuthFromEnv(); err == nil {
+		t.Error("unknown scheme: want an error")
+	}
+	t.Setenv("ALPACA_AUTH", "")
+	t.Setenv("ALPACA_API_SECRET", "")
+	if _, err := AuthFromEnv(); err == nil {
+		t.Error("no secret: want an error")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("ALPACA_AUTH", "oauth")
+	t.Setenv("ALPACA_OAUTH_TOKEN", "tok")
+	t.Setenv("ALPACA_BASE_URL", "")
+	t.Setenv("ALPACA_DATA_URL", "http://data.example")
+	c, err := FromEnv()
+	if err != nil || c.Auth != (OAuth{Token: "tok"}) || c.BaseURL != "https://paper-api.alpaca.markets" || c.DataURL != "http://data.example" {
+		t.Errorf("got %+v, %v", c, err)
+	}
+}
@@ -0,0 +1,53 @@
+package alpaca
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Session is one trading day from /v2/calendar. Open and close are New York
+// wall clock times, so a half day before a holiday closes at 13:00.
+type Session struct {
+	Date  string `json:"date"`  // YYYY-MM-DD
+	Open  string `json:"open"`  // HH:MM
+	Close string `json:"close"` // HH:MM
+}
+
+// market is the exchange timezone the calendar's times are in.
+var market = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+// Bounds returns when the session opens and closes.
+func (s Session) Bounds() (open, close time.Time, err error) {
+	open, err = time.ParseInLocation("2006-01-02 15:04", s.Date+" "+s.Open, market)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("session %s open: %w", s.Date, err)
+	}
+	close, err = time.ParseInLocation("2006-01-02 15:04", s.Date+" "+s.Close, market)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("session %s close: %w", s.Date, err)
+	}
+	return open, close, nil
+}
+
+// Calendar returns the trading sessions from start to end inclusive
+// (YYYY-MM-DD), oldest first. Weekends and market holidays are absent.
+func (c Client) Calendar(start, end string) ([]Session, error) {
+	query := url.Values{"start": {start}, "end": {end}}
+	body, err := c.Get(c.BaseURL + "/v2/calendar?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var sessions []Session
+	if err := json.Unmarshal(body, &sessions); err != nil {
+		return nil, fmt.Errorf("parsing calendar: %w", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,47 @@
+package alpaca
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MaxAnnouncementDays is the widest since-until window one announcements
+// request may cover.
+const MaxAnnouncementDays = 90
+
+// Announcement is a corporate action from /v2/corporate_actions. Rates are
+// shares: a 4-for-1 split has OldRate 1 and NewRate 4.
+type Announcement struct {
+	ID               string  `json:"id"`
+	CorporateAction  string  `json:"corporate_action_id"`
+	Type             string  `json:"ca_type"`     // split, dividend, merger, spinoff
+	SubType          string  `json:"ca_sub_type"` // e.g. forward_split, reverse_split, cash
+	InitiatingSymbol string  `json:"initiating_symbol"`
+	TargetSymbol     string  `json:"target_symbol"`
+	DeclarationDate  string  `json:"declaration_date"`
+	ExDate           string  `json:"ex_date"`
+	RecordDate       string  `json:"record_date"`
+	PayableDate      string  `json:"payable_date"`
+	Cash             Decimal `json:"cash"`
+	OldRate          Decimal `json:"old_rate"`
+	NewRate          Decimal `json:"new_rate"`
+}
+
+// Announcements returns the corporate actions of the given types (Split,
+// Dividend, Merger, Spinoff) with an ex date from since to until inclusive
+// (YYYY-MM-DD), across every symbol. Alpaca allows at most
+// MaxAnnouncementDays between them.
+func (c Client) Announcements(types []string, since, until string) ([]Announcement, error) {
+	query := url.Values{"ca_types": {strings.Join(types, ",")}, "since": {since}, "until": {until}}
+	body, err := c.Get(c.BaseURL + "/v2/corporate_actions/announcements?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var announcements []Announcement
+	if err := json.Unmarshal(body, &announcements); err != nil {
+		return nil, fmt.Errorf("parsing corporate actions: %w", err)
+	}
+	return announcements, nil
+}
@@ -0,0 +1,79 @@
+package alpaca
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Decimal is a money or quantity value. Alpaca encodes these as JSON strings
+// ("1234.56") but occasionally as bare numbers; both decode, and anything
+// that isn't a finite number is rejected rather than silently becoming zero.
+// Decimal marshals back to a string so files written from it keep Alpaca's
+// wire format.
+type Decimal float64
+
+// ParseDecimal parses a decimal string. Empty means zero.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid decimal %q", s)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal(f), nil
+}
+
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		*d = 0
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// Float returns the value as a float64.
+func (d Decimal) Float() float64 {
+	return float64(d)
+}
+
+// Round rounds half away from zero to the given number of decimal places.
+func (d Decimal) Round(places int) Decimal {
+	scale := math.Pow(10, float64(places))
+	return Decimal(math.Round(float64(d)*scale) / scale)
+}
+
+// Mul returns d × o rounded to places, for notional values such as
+// qty × price where float error would otherwise leak into the cents.
+func (d Decimal) Mul(o Decimal, places int) Decimal {
+	return (d * o).Round(places)
+}
+
+// String returns the shortest representation that round-trips, e.g. "1.5".
+func (d Decimal) String() string {
+	return strconv.FormatFloat(float64(d), 'f', -1, 64)
+}
+
+// Money formats the value to whole cents, e.g. "1234.50".
+func (d Decimal) Money() string {
+	return strconv.FormatFloat(float64(d.Round(2)), 'f', 2, 64)
+}
@@ -0,0 +1,60 @@
+package alpaca
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// --- Decimal ---
+
+func TestDecimal_UnmarshalStringAndNumber(t *testing.T) {
+	var v struct {
+		A Decimal `json:"a"`
+		B Decimal `json:"b"`
+		C Decimal `json:"c"`
+		D Decimal `json:"d"`
+	}
+	if err := json.Unmarshal([]byte(`{"a": "1234.56", "b": 7.25, "c": null, "d": ""}`), &v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.A != 1234.56 || v.B != 7.25 || v.C != 0 || v.D != 0 {
+		t.Errorf("got %+v", v)
+	}
+}
+
+func TestDecimal_RejectsGarbage(t *testing.T) {
+	for _, in := range []string{`"abc"`, `"NaN"`, `"+Inf"`, `true`} {
+		var d Decimal
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("%s: expected error, got %v", in, d)
+		}
+	}
+}
+
+func TestDecimal_MarshalKeepsStringFormat(t *testing.T) {
+	got, err := json.Marshal(struct {
+		Qty Decimal `json:"qty"`
+	}{Qty: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != `{"qty":"10"}` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestDecimal_RoundingAndMoney(t *testing.T) {
+	// 0.1 × 3 drifts to 0.30000000000000004 as a float
+	if got := Decimal(0.1).Mul(3, 2); got.String() != "0.3" {
+		t.Errorf("Mul: got %s, want 0.3", got)
+	}
+	if got := Decimal(0.125).Round(2); got != 0.13 {
+		t.Errorf("Round: got %v, want 0.13 (half away from zero)", got)
+	}
+	if got := Decimal(-1.005).Round(1); got != -1 {
+		t.Errorf("Round: got %v, want -1", got)
+	}
+	if got := Decimal(12).Money(); got != "12.00" {
+		t.Errorf("Money: got %s, want 12.00", got)
+	}
+}
@@ -1,3 +1,7 @@
 module github.com/deanturpin/lft2/internal/alpaca
 
 go 1.21
+
+require github.com/deanturpin/lft2/internal/httpconf v0.0.0
+
+replace github.com/deanturpin/lft2/internal/httpconf => ../../internal/httpconf
@@ -0,0 +1,41 @@
+package alpaca
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Quote is the latest NBBO for a symbol.
+type Quote struct {
+	Timestamp string  `json:"t"`
+	BidPrice  float64 `json:"bp"`
+	BidSize   float64 `json:"bs"`
+	AskPrice  float64 `json:"ap"`
+	AskSize   float64 `json:"as"`
+}
+
+// MaxQuoteSymbols is how many symbols one latest-quotes request may name.
+const MaxQuoteSymbols = 100
+
+// LatestQuotes returns the latest quote for each symbol, keyed by symbol.
+// Symbols without a quote are absent. At most MaxQuoteSymbols per call.
+func (c Client) LatestQuotes(symbols []string) (map[string]Quote, error) {
+	if len(symbols) > MaxQuoteSymbols {
+		return nil, fmt.Errorf("%d symbols in one quotes request, limit %d", len(symbols), MaxQuoteSymbols)
+	}
+	query := url.Values{"symbols": {strings.Join(symbols, ",")}}
+	body, err := c.Get(c.DataURL + "/v2/stocks/quotes/latest?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Quotes map[string]Quote `json:"quotes"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing quotes: %w", err)
+	}
+	return resp.Quotes, nil
+}
@@ -0,0 +1,179 @@
+package alpaca
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Account is the subset of /v2/account used across modules.
+type Account struct {
+	AccountNumber         string  `json:"account_number"`
+	Status                string  `json:"status"`
+	Currency              string  `json:"currency"`
+	Cash                  Decimal `json:"cash"`
+	PortfolioValue        Decimal `json:"portfolio_value"`
+	BuyingPower           Decimal `json:"buying_power"`
+	Equity                Decimal `json:"equity"`
+	LastEquity            Decimal `json:"last_equity"`
+	LongMarketValue       Decimal `json:"long_market_value"`
+	ShortMarketValue      Decimal `json:"short_market_value"`
+	InitialMargin         Decimal `json:"initial_margin"`
+	MaintenanceMargin     Decimal `json:"maintenance_margin"`
+	DaytradingBuyingPower Decimal `json:"daytrading_buying_power"`
+
+	// Restrictions. Orders placed while any of these apply are rejected with
+	// a generic 403, so callers check them up front.
+	TradingBlocked       bool `json:"trading_blocked"`
+	AccountBlocked       bool `json:"account_blocked"`
+	TradeSuspendedByUser bool `json:"trade_suspended_by_user"`
+	PatternDayTrader     bool `json:"pattern_day_trader"`
+	ShortingEnabled      bool `json:"shorting_enabled"`
+	DaytradeCount        int  `json:"daytrade_count"` // day trades in the last five sessions
+}
+
+// PDTMinEquity is the FINRA minimum equity for day trading a margin account
+// flagged as a pattern day trader.
+const PDTMinEquity = 25000
+
+// TradingBlock returns why no orders can be placed at all, or "" if trading
+// is allowed.
+func (a Account) TradingBlock() string {
+	switch {
+	case a.AccountBlocked:
+		return "account blocked by Alpaca"
+	case a.TradingBlocked:
+		return "trading blocked by Alpaca"
+	case a.TradeSuspendedByUser:
+		return "trading suspended by account holder"
+	}
+	return ""
+}
+
+// DayTradeBlock returns why opening a new intraday position would be refused
+// or get the account flagged, or "" if it's allowed. Below PDTMinEquity a
+// flagged account can't day trade, and a fourth day trade within five
+// sessions flags it.
+func (a Account) DayTradeBlock() string {
+	if a.Equity.Float() >= PDTMinEquity {
+		return ""
+	}
+	switch {
+	case a.PatternDayTrader:
+		return fmt.Sprintf("pattern day trader with equity $%s under $%d", a.Equity.Money(), PDTMinEquity)
+	case a.DaytradeCount >= 3:
+		return fmt.Sprintf("%d day trades in five sessions; another would flag pattern day trader", a.DaytradeCount)
+	}
+	return ""
+}
+
+// Position is an open position from /v2/positions.
+type Position struct {
+	Symbol         string  `json:"symbol"`
+	Qty            Decimal `json:"qty"`
+	AvgEntryPrice  Decimal `json:"avg_entry_price"`
+	CurrentPrice   Decimal `json:"current_price"`
+	MarketValue    Decimal `json:"market_value"`
+	CostBasis      Decimal `json:"cost_basis"`
+	UnrealizedPL   Decimal `json:"unrealized_pl"`
+	UnrealizedPLPC Decimal `json:"unrealized_plpc"`
+	ChangeToday    Decimal `json:"change_today"`
+	Side           string  `json:"side"`
+	AssetClass     string  `json:"asset_class"`
+}
+
+// Order is an order from /v2/orders.
+type Order struct {
+	ID             string  `json:"id"`
+	ClientOrderID  string  `json:"client_order_id"` // SYMBOL_strategy-v1_tp1.25_sl1.25_tsl1.00_phash_timestamp
+	CreatedAt      string  `json:"created_at"`
+	FilledAt       string  `json:"filled_at"`
+	Symbol         string  `json:"symbol"`
+	Qty            Decimal `json:"qty"`
+	FilledQty      Decimal `json:"filled_qty"`
+	FilledAvgPrice Decimal `json:"filled_avg_price"`
+	Side           string  `json:"side"`        // "buy" or "sell"
+	Status         string  `json:"status"`      // "filled", "partially_filled", "canceled", "replaced", etc.
+	Type           string  `json:"type"`        // "market", "limit", "stop", "trailing_stop", etc.
+	OrderClass     string  `json:"order_class"` // "simple", "bracket", "oco" or "oto"
+	Legs           []Order `json:"legs"`        // Child orders, returned when queried with nested=true
+
+	// Lifecycle. A replaced order points at its replacement and vice versa;
+	// either may carry fills of its own before it closed.
+	CanceledAt string `json:"canceled_at"`
+	ReplacedAt string `json:"replaced_at"`
+	ReplacedBy string `json:"replaced_by"`
+	Replaces   string `json:"replaces"`
+}
+
+// Account fetches the trading account.
+func (c Client) Account() (*Account, error) {
+	body, err := c.Get(c.BaseURL + "/v2/account")
+	if err != nil {
+		return nil, err
+	}
+	var account Account
+	if err := json.Unmarshal(body, &account); err != nil {
+		return nil, fmt.Errorf("parsing account: %w", err)
+	}
+	return &account, nil
+}
+
+// Positions fetches all open positions.
+func (c Client) Positions() ([]Position, error) {
+	body, err := c.Get(c.BaseURL + "/v2/positions")
+	if err != nil {
+		return nil, err
+	}
+	var positions []Position
+	if err := json.Unmarshal(body, &positions); err != nil {
+		return nil, fmt.Errorf("parsing positions: %w", err)
+	}
+	return positions, nil
+}
+
+// Orders fetches orders matching query, e.g. "status=filled&limit=500".
+func (c Client) Orders(query string) ([]Order, error) {
+	body, err := c.Get(c.BaseURL + "/v2/orders?" + query)
+	if err != nil {
+		return nil, err
+	}
+	var orders []Order
+	if err := json.Unmarshal(body, &orders); err != nil {
+		return nil, fmt.Errorf("parsing orders: %w", err)
+	}
+	return orders, nil
+}
+
+// Activity is an entry from /v2/account/activities. Trade activities (FILL,
+// PARTIAL_FILL) carry qty, price and side; everything else (FEE, DIV, CSD,
+// INT, ...) carries a signed net_amount.
+type Activity struct {
+	ID              string  `json:"id"`
+	ActivityType    string  `json:"activity_type"`
+	Date            string  `json:"date"`
+	TransactionTime string  `json:"transaction_time"`
+	Symbol          string  `json:"symbol"`
+	Side            string  `json:"side"`
+	Qty             Decimal `json:"qty"`
+	Price           Decimal `json:"price"`
+	NetAmount       Decimal `json:"net_amount"`
+	Description     string  `json:"description"`
+}
+
+// IsFill reports whether the activity is a trade execution.
+func (a Activity) IsFill() bool {
+	return a.ActivityType == "FILL" || a.ActivityType == "PARTIAL_FILL"
+}
+
+// Activities fetches account activities for one trading day (YYYY-MM-DD).
+func (c Client) Activities(date string) ([]Activity, error) {
+	body, err := c.Get(c.BaseURL + "/v2/account/activities?date=" + date + "&page_size=100")
+	if err != nil {
+		return nil, err
+	}
+	var activities []Activity
+	if err := json.Unmarshal(body, &activities); err != nil {
+		return nil, fmt.Errorf("parsing activities: %w", err)
+	}
+	return activities, nil
+}
@@ -0,0 +1,51 @@
+package alpaca
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// --- Account restrictions ---
+
+func TestAccount_ParsesRestrictions(t *testing.T) {
+	var a Account
+	body := `{"equity":"30000","trading_blocked":false,"account_blocked":true,
+		"pattern_day_trader":true,"shorting_enabled":false,"daytrade_count":2}`
+	if err := json.Unmarshal([]byte(body), &a); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !a.AccountBlocked || a.TradingBlocked || !a.PatternDayTrader || a.ShortingEnabled || a.DaytradeCount != 2 {
+		t.Errorf("got %+v", a)
+	}
+}
+
+func TestAccount_TradingBlock(t *testing.T) {
+	if got := (Account{}).TradingBlock(); got != "" {
+		t.Errorf("unrestricted account: got %q", got)
+	}
+	for _, a := range []Account{{AccountBlocked: true}, {TradingBlocked: true}, {TradeSuspendedByUser: true}} {
+		if a.TradingBlock() == "" {
+			t.Errorf("%+v should be blocked", a)
+		}
+	}
+}
+
+func TestAccount_DayTradeBlock(t *testing.T) {
+	cases := []struct {
+		name    string
+		account Account
+		want    string
+	}{
+		{"above minimum", Account{Equity: 30000, PatternDayTrader: true, DaytradeCount: 5}, ""},
+		{"small, no day trades", Account{Equity: 5000, DaytradeCount: 2}, ""},
+		{"small, flagged", Account{Equity: 5000, PatternDayTrader: true}, "pattern day trader"},
+		{"small, one from flag", Account{Equity: 5000, DaytradeCount: 3}, "another would flag"},
+	}
+	for _, c := range cases {
+		got := c.account.DayTradeBlock()
+		if (c.want == "") != (got == "") || !strings.Contains(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package alpaca
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIs a request can go to, each with its own rate limit
+const (
+	TradingAPI = "trading"
+	DataAPI    = "data"
+)
+
+// route matches path segments that are part of an endpoint rather than a
+// symbol or order ID in it.
+var route = regexp.MustCompile(`^([a-z_]+|v\d+(beta\d+)?)$`)
+
+// usage counts this process's requests, for the API budget in
+// internal/quota.
+var usage = struct {
+	sync.Mutex
+	calls   map[string]int           // By endpoint
+	minutes map[string]map[int64]int // By API, then Unix minute
+}{calls: map[string]int{}, minutes: map[string]map[int64]int{}}
+
+// Endpoint names the API and route a request went to, with symbols and IDs
+// folded out so every symbol's bars count as one endpoint:
+//
+//	data GET /v2/stocks/{}/bars
+func Endpoint(method, rawURL string) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		if !route.MatchString(s) {
+			segments[i] = "{}"
+		}
+	}
+	return API(path) + " " + method + " /" + strings.Join(segments, "/")
+}
+
+// API says which API a request path belongs to. Market data lives under
+// /v2/stocks and the beta routes; the rest is trading.
+func API(path string) string {
+	if u, err := url.Parse(path); err == nil && u.Path != "" {
+		path = u.Path
+	}
+	if strings.HasPrefix(path, "/v2/stocks") || strings.HasPrefix(path, "/v1beta") {
+		return DataAPI
+	}
+	return TradingAPI
+}
+
+// Count records one request at now. Get and Post count their own; a caller
+// with its own HTTP client counts it here.
+func Count(method, rawURL string, now time.Time) {
+	endpoint := Endpoint(method, rawURL)
+	api, _, _ := strings.Cut(endpoint, " ")
+	usage.Lock()
+	defer usage.Unlock()
+	usage.calls[endpoint]++
+	if usage.minutes[api] == nil {
+		usage.minutes[api] = map[int64]int{}
+	}
+	usage.minutes[api][now.Unix()/60]++
+}
+
+// Usage returns the requests counted so far by endpoint, and by API the most
+// made in any one clock minute.
+func Usage() (calls map[string]int, peaks map[string]int) {
+	usage.Lock()
+	defer usage.Unlock()
+	calls = make(map[string]int, len(usage.calls))
+	for e, n := range usage.calls {
+		calls[e] = n
+	}
+	peaks = map[string]int{}
+	for api, minutes := range usage.minutes {
+		for _, n := range minutes {
+			peaks[api] = max(peaks[api], n)
+		}
+	}
+	return calls, peaks
+}
@@ -0,0 +1,85 @@
+// Package artifact publishes pipeline artifacts (the JSON, FIX and HTML files
+// written under docs/) to a configurable destination — a local directory or an
+// S3-compatible bucket (AWS S3, or GCS via its S3 interoperability endpoint) —
+// and downloads them again from a configurable base URL.
+package artifact
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Store is a destination for pipeline artifacts. Names are slash-separated
+// paths relative to the store root, e.g. "strategies.json" or "bars/AAPL.json".
+type Store interface {
+	Put(name string, data []byte) error
+	String() string
+}
+
+// Open returns the Store described by rawURL:
+//
+//	/path/to/dir or file:///path  - local directory
+//	s3://bucket/prefix            - AWS S3 (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_REGION)
+//	gs://bucket/prefix            - Google Cloud Storage (GCS_HMAC_ACCESS_ID, GCS_HMAC_SECRET)
+//
+// The prefix may contain {date}, expanded to the current UTC date, so each
+// day's artifacts can be kept side by side (s3://bucket/lft2/{date}).
+func Open(rawURL string) (Store, error) {
+	rawURL = strings.ReplaceAll(rawURL, "{date}", time.Now().UTC().Format("2006-01-02"))
+
+	if !strings.Contains(rawURL, "://") {
+		return DirStore{Root: rawURL}, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing store URL: %w", err)
+	}
+
+	prefix := strings.Trim(u.Path, "/")
+
+	switch u.Scheme {
+	case "file":
+		return DirStore{Root: u.Path}, nil
+	case "s3":
+		region := os.Getenv("AWS_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		endpoint := os.Getenv("LFT2_S3_ENDPOINT")
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+		return newS3Store(endpoint, region, u.Host, prefix,
+			os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	case "gs":
+		// GCS accepts SigV4-signed requests with HMAC keys on its interop endpoint
+		return newS3Store("https://storage.googleapis.com", "auto", u.Host, prefix,
+			os.Getenv("GCS_HMAC_ACCESS_ID"), os.Getenv("GCS_HMAC_SECRET"))
+	default:
+		return nil, fmt.Errorf("unsupported store scheme %q", u.Scheme)
+	}
+}
+
+// DirStore writes artifacts beneath a local directory, creating
+// subdirectories as needed.
+type DirStore struct {
+	Root string
+}
+
+// Put writes data to Root/name.
+func (d DirStore) Put(name string, data []byte) error {
+	path := filepath.Join(d.Root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (d DirStore) String() string {
+	return d.Root
+}
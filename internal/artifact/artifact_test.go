@@ -0,0 +1,246 @@
+package artifact
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- Open ---
+
+func TestOpen_PlainPathIsDirStore(t *testing.T) {
+	s, err := Open("docs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d, ok := s.(DirStore); !ok || d.Root != "docs" {
+		t.Errorf("got %#v, want DirStore{docs}", s)
+	}
+}
+
+func TestOpen_S3(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-2")
+	s, err := Open("s3://my-bucket/lft2/live")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s3, ok := s.(S3Store)
+	if !ok {
+		t.Fatalf("got %T, want S3Store", s)
+	}
+	if s3.Bucket != "my-bucket" || s3.Prefix != "lft2/live" {
+		t.Errorf("bucket/prefix: got %q/%q", s3.Bucket, s3.Prefix)
+	}
+	if s3.Endpoint != "https://s3.eu-west-2.amazonaws.com" {
+		t.Errorf("endpoint: got %q", s3.Endpoint)
+	}
+}
+
+func TestOpen_DateExpanded(t *testing.T) {
+	s, err := Open("out/{date}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(s.String(), "{date}") {
+		t.Errorf("{date} not expanded: %s", s)
+	}
+}
+
+func TestOpen_MissingCredentials(t *testing.T) {
+	t.Setenv("GCS_HMAC_ACCESS_ID", "")
+	t.Setenv("GCS_HMAC_SECRET", "")
+	if _, err := Open("gs://bucket"); err == nil {
+		t.Error("expected error for missing credentials, got nil")
+	}
+}
+
+func TestOpen_UnknownScheme(t *testing.T) {
+	if _, err := Open("ftp://host/dir"); err == nil {
+		t.Error("expected error for unsupported scheme, got nil")
+	}
+}
+
+// --- DirStore ---
+
+func TestDirStore_PutCreatesSubdirs(t *testing.T) {
+	root := t.TempDir()
+	if err := (DirStore{Root: root}).Put("bars/AAPL.json", []byte("{}")); err != nil {
+		t.Fatalf("Put error: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(root, "bars", "AAPL.json"))
+	if err != nil {
+		t.Fatalf("reading written file: %v", err)
+	}
+	if string(got) != "{}" {
+		t.Errorf("content: got %q, want {}", got)
+	}
+}
+
+// --- S3Store ---
+
+func TestS3Store_PutSignedRequest(t *testing.T) {
+	var gotPath, gotAuth, gotType, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		gotType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+	defer srv.Close()
+
+	s, err := newS3Store(srv.URL, "us-east-1", "bucket", "lft2", "AKID", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("strategies.json", []byte(`{"ok":true}`)); err != nil {
+		t.Fatalf("Put error: %v", err)
+	}
+
+	if gotPath != "/bucket/lft2/strategies.json" {
+		t.Errorf("path: got %q", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/") {
+		t.Errorf("authorization: got %q", gotAuth)
+	}
+	if gotType != "application/json" {
+		t.Errorf("content type: got %q, want application/json", gotType)
+	}
+	if gotBody != `{"ok":true}` {
+		t.Errorf("body: got %q", gotBody)
+	}
+}
+
+func TestS3Store_PutError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	s, _ := newS3Store(srv.URL, "us-east-1", "bucket", "", "AKID", "secret")
+	if err := s.Put("x.json", nil); err == nil {
+		t.Error("expected error for 403 response, got nil")
+	}
+}
+
+// --- Fetch ---
+
+func TestFetch_LocalDir(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "candidates.json"), []byte(`{"symbols":["AAPL"]}`), 0644)
+
+	for _, base := range []string{root, "file://" + root} {
+		got, err := Fetch(base, "candidates.json")
+		if err != nil {
+			t.Fatalf("Fetch(%q) error: %v", base, err)
+		}
+		if string(got) != `{"symbols":["AAPL"]}` {
+			t.Errorf("Fetch(%q): got %q", base, got)
+		}
+	}
+}
+
+func TestFetch_HTTP(t *testing.T) {
+	t.Setenv("LFT2_ARTIFACT_CACHE", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/lft2/strategies.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	got, err := Fetch(srv.URL+"/lft2/", "strategies.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != "{}" {
+		t.Errorf("got %q, want {}", got)
+	}
+
+	if _, err := Fetch(srv.URL+"/lft2", "missing.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("404: got %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestFetch_ConditionalAndFallback(t *testing.T) {
+	t.Setenv("LFT2_ARTIFACT_CACHE", t.TempDir())
+	var status int
+	var conditional string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = r.Header.Get("If-None-Match")
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		if conditional == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"v":1}`))
+	}))
+	defer srv.Close()
+
+	status = http.StatusOK
+	if got, err := Fetch(srv.URL, "candidates.json"); err != nil || string(got) != `{"v":1}` {
+		t.Fatalf("first fetch: got %q, %v", got, err)
+	}
+	if got, err := Fetch(srv.URL, "candidates.json"); err != nil || string(got) != `{"v":1}` || conditional != `"v1"` {
+		t.Errorf("revalidation: got %q, %v with If-None-Match %q", got, err, conditional)
+	}
+
+	status = http.StatusBadGateway
+	if got, err := Fetch(srv.URL, "candidates.json"); err != nil || string(got) != `{"v":1}` {
+		t.Errorf("5xx: got %q, %v, want the cached copy", got, err)
+	}
+	status = http.StatusNotFound
+	if _, err := Fetch(srv.URL, "candidates.json"); err == nil {
+		t.Error("404: cached copy returned, want an error")
+	}
+	status = http.StatusBadGateway
+	if _, err := Fetch(srv.URL, "strategies.json"); err == nil {
+		t.Error("5xx with nothing cached: got nil error")
+	}
+}
+
+func TestFetch_CacheOff(t *testing.T) {
+	t.Setenv("LFT2_ARTIFACT_CACHE", "off")
+	up := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	if _, err := Fetch(srv.URL, "candidates.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	up = false
+	if _, err := Fetch(srv.URL, "candidates.json"); err == nil {
+		t.Error("cache disabled but a copy was returned")
+	}
+}
+
+func TestBase_EnvOverride(t *testing.T) {
+	t.Setenv("LFT2_ARTIFACT_BASE", "")
+	if Base() != DefaultBase {
+		t.Errorf("got %q, want default %q", Base(), DefaultBase)
+	}
+	t.Setenv("LFT2_ARTIFACT_BASE", "https://example.github.io/lft2")
+	if Base() != "https://example.github.io/lft2" {
+		t.Errorf("env override not applied: %q", Base())
+	}
+}
@@ -0,0 +1,84 @@
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cache keeps the last good copy of each downloaded artifact with its ETag
+// and Last-Modified, so a repeat download can be conditional and a failed one
+// can fall back. Entries are keyed by a hash of the URL.
+type cache string
+
+// cached is one entry's metadata, stored beside the body.
+type cached struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// openCache returns the cache directory from LFT2_ARTIFACT_CACHE, defaulting
+// to lft2/artifacts under the user cache directory. "off" disables caching,
+// as does having no user cache directory.
+func openCache() (cache, bool) {
+	dir := os.Getenv("LFT2_ARTIFACT_CACHE")
+	switch dir {
+	case "off":
+		return "", false
+	case "":
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return "", false
+		}
+		dir = filepath.Join(base, "lft2", "artifacts")
+	}
+	return cache(dir), true
+}
+
+func (c cache) path(url, ext string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(string(c), hex.EncodeToString(sum[:])+ext)
+}
+
+// load returns the cached body and metadata for url, if both are present.
+func (c cache) load(url string) ([]byte, cached, bool) {
+	var meta cached
+	data, err := os.ReadFile(c.path(url, ".json"))
+	if err != nil || json.Unmarshal(data, &meta) != nil || meta.URL != url {
+		return nil, cached{}, false
+	}
+	body, err := os.ReadFile(c.path(url, ".body"))
+	if err != nil {
+		return nil, cached{}, false
+	}
+	return body, meta, true
+}
+
+// store saves body and its metadata. The body is written first so a
+// metadata file never points at a missing or partial body.
+func (c cache) store(body []byte, meta cached) error {
+	if err := os.MkdirAll(string(c), 0755); err != nil {
+		return err
+	}
+	if err := writeAtomic(c.path(meta.URL, ".body"), body); err != nil {
+		return err
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(c.path(meta.URL, ".json"), data)
+}
+
+func writeAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,112 @@
+package artifact
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"time"
+)
+
+// DefaultBase is where the upstream pipeline publishes its artifacts.
+const DefaultBase = "https://deanturpin.github.io/lft2"
+
+// Base returns the artifact base from LFT2_ARTIFACT_BASE, falling back to
+// DefaultBase. Forks point this at their own Pages site, a bucket's public
+// URL, or a local docs/ directory when every stage runs on one machine.
+func Base() string {
+	if base := os.Getenv("LFT2_ARTIFACT_BASE"); base != "" {
+		return base
+	}
+	return DefaultBase
+}
+
+// NotFoundError is a 404 from an HTTP base. It matches fs.ErrNotExist, as a
+// missing file under a local base does, so callers can test for either.
+type NotFoundError struct {
+	URL string
+}
+
+func (e *NotFoundError) Error() string { return "HTTP 404 fetching " + e.URL }
+
+// Is reports whether target is fs.ErrNotExist.
+func (e *NotFoundError) Is(target error) bool { return target == fs.ErrNotExist }
+
+// Fetch returns the artifact name relative to base, which may be an
+// http(s) URL, a file:// URL or a local directory path.
+//
+// HTTP downloads are cached (see openCache). A cached artifact is requested
+// conditionally and reused on 304 Not Modified. When the server can't be
+// reached or answers 5xx, the cached copy is returned with a warning rather
+// than failing the stage. A 404 is never masked.
+func Fetch(base, name string) ([]byte, error) {
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		dir := strings.TrimPrefix(base, "file://")
+		return os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	}
+
+	url := strings.TrimRight(base, "/") + "/" + name
+	c, caching := openCache()
+	var stale []byte
+	var meta cached
+	var hit bool
+	if caching {
+		stale, meta, hit = c.load(url)
+	}
+
+	fallback := func(err error) ([]byte, error) {
+		if !hit {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "[WARNING] %v — using cached copy from %s\n", err, meta.FetchedAt.UTC().Format(time.RFC3339))
+		return stale, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	if hit && meta.ETag != "" {
+		req.Header.Set("If-None-Match", meta.ETag)
+	}
+	if hit && meta.LastModified != "" {
+		req.Header.Set("If-Modified-Since", meta.LastModified)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fallback(fmt.Errorf("HTTP request failed: %w", err))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fallback(fmt.Errorf("reading response: %w", err))
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && hit:
+		return stale, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, &NotFoundError{URL: url}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return fallback(fmt.Errorf("HTTP %d fetching %s", resp.StatusCode, url))
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("HTTP %d fetching %s", resp.StatusCode, url)
+	}
+
+	if caching {
+		// A cache that can't be written only costs the next download
+		c.store(body, cached{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			FetchedAt:    time.Now(),
+		})
+	}
+	return body, nil
+}
@@ -0,0 +1,125 @@
+// Package assets classifies tradeable symbols (plain equity, ETF, leveraged
+// or inverse ETF, ADR) from broker metadata, optionally enriches them with
+// market capitalisation from a fundamentals provider, and persists the result
+// in docs/assets.json, written by fetch and read by filter.
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// DefaultPath is where fetch writes asset metadata.
+const DefaultPath = "docs/assets.json"
+
+// Asset classes. Leveraged and inverse products pass numeric screens but
+// decay and gap in ways the mean-reverting strategies don't model.
+const (
+	Equity       = "equity"
+	ETF          = "etf"
+	LeveragedETF = "leveraged_etf"
+	InverseETF   = "inverse_etf"
+	ADR          = "adr"
+)
+
+// Info is the per-symbol metadata stored in assets.json.
+type Info struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Exchange string `json:"exchange"`
+	Class    string `json:"class"`
+
+	MarketCap float64 `json:"market_cap,omitempty"` // USD, 0 when no fundamentals provider is configured
+}
+
+// File is the on-disk layout of assets.json.
+type File struct {
+	schema.Header
+	Timestamp string          `json:"timestamp"`
+	Assets    map[string]Info `json:"assets"`
+}
+
+var (
+	fundPattern      = regexp.MustCompile(`(?i)\b(etf|etns?|fund|ishares|spdr|proshares|direxion|vanguard)\b|\btrust,? series\b`)
+	leveragedPattern = regexp.MustCompile(`(?i)\b(ultrashort|ultrapro|ultra)|\b(leveraged|[23]x|2x long|3x long)\b|\bdaily .*\b(bull|bear)\b`)
+	inversePattern   = regexp.MustCompile(`(?i)\b(inverse|short|bear)\b|\bultrashort`)
+	maturityPattern  = regexp.MustCompile(`(?i)\b(ultra[\s-]*)?short[\s-]+(term|duration|treasury|maturity)\b`)
+	adrPattern       = regexp.MustCompile(`(?i)\b(adr|ads|american depositary|depositary shares?)\b`)
+)
+
+// Classify derives an asset class from the broker's instrument name.
+// Alpaca has no explicit product-type field, but issuers name leveraged and
+// inverse products consistently ("ProShares UltraPro QQQ", "Direxion Daily
+// Semiconductor Bear 3X Shares"). A name is only read for leverage or
+// direction once it is a fund's, by a product word or an issuer that lists
+// nothing else: "Ultra Clean Holdings" and "Northern Trust Corporation" are
+// operating companies. Ultra is matched as a prefix, for "UltraShort" and
+// "UltraPro", but a short or ultra-short maturity ("Short-Term Bond",
+// "Ultra Short Duration") describes a bond fund's holdings, not its
+// direction or leverage.
+func Classify(name string) string {
+	fund := fundPattern.MatchString(name)
+	name = maturityPattern.ReplaceAllString(name, " ")
+	switch {
+	case fund && inversePattern.MatchString(name):
+		return InverseETF
+	case fund && leveragedPattern.MatchString(name):
+		return LeveragedETF
+	case adrPattern.MatchString(name):
+		return ADR
+	case fund:
+		return ETF
+	default:
+		return Equity
+	}
+}
+
+// ParseClasses splits a comma-separated class list, as used by flags.
+func ParseClasses(s string) []string {
+	var classes []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			classes = append(classes, c)
+		}
+	}
+	return classes
+}
+
+// Load reads assets.json. A missing file yields an empty map so callers can
+// treat metadata as optional.
+func Load(path string) (map[string]Info, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]Info{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading assets: %w", err)
+	}
+
+	if err := schema.Check(path, data); err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing assets: %w", err)
+	}
+	if f.Assets == nil {
+		f.Assets = map[string]Info{}
+	}
+	return f.Assets, nil
+}
+
+// Save writes assets.json.
+func Save(path string, f File) error {
+	f.Header = schema.Current()
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding assets: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
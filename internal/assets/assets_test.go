@@ -0,0 +1,193 @@
+package assets
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- Classify ---
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"Apple Inc. Common Stock", Equity},
+		{"SPDR S&P 500 ETF Trust", ETF},
+		{"Invesco QQQ Trust, Series 1", ETF},
+		{"ProShares UltraPro QQQ", LeveragedETF},
+		{"Direxion Daily Semiconductor Bull 3X Shares", LeveragedETF},
+		{"ProShares UltraPro Short QQQ", InverseETF},
+		{"Direxion Daily Semiconductor Bear 3X Shares", InverseETF},
+		{"ProShares Short S&P500", InverseETF},
+		{"ProShares UltraShort QQQ", InverseETF},
+		// Bond funds named for their maturity, not a short position
+		{"iShares Short Treasury Bond ETF", ETF},
+		{"Vanguard Short-Term Bond ETF", ETF},
+		{"SPDR Bloomberg Short Term High Yield Bond ETF", ETF},
+		{"iShares Ultra Short Duration Bond Active ETF", ETF},
+		{"PIMCO Enhanced Short Maturity Active Exchange-Traded Fund", ETF},
+		{"Taiwan Semiconductor Manufacturing Company Ltd. American Depositary Shares", ADR},
+		{"Alibaba Group Holding Limited ADS", ADR},
+		// Operating companies whose names share a fund's words
+		{"Ultra Clean Holdings, Inc. Common Stock", Equity},
+		{"Northern Trust Corporation Common Stock", Equity},
+		{"Universal Health Realty Income Trust Common Stock", Equity},
+		{"Bear Creek Mining Corp", Equity},
+	}
+	for _, c := range cases {
+		if got := Classify(c.name); got != c.want {
+			t.Errorf("Classify(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+// --- ParseClasses ---
+
+func TestParseClasses(t *testing.T) {
+	got := ParseClasses(" leveraged_etf, inverse_etf,,")
+	if len(got) != 2 || got[0] != LeveragedETF || got[1] != InverseETF {
+		t.Errorf("got %v", got)
+	}
+	if ParseClasses("") != nil {
+		t.Error("empty string should give nil")
+	}
+}
+
+// --- Load / Save ---
+
+func TestLoad_MissingFile(t *testing.T) {
+	got, err := Load("/nonexistent/assets.json")
+	if err != nil || len(got) != 0 {
+		t.Errorf("got %v, %v; want empty map, nil", got, err)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assets.json")
+	in := File{Assets: map[string]Info{"TQQQ": {Symbol: "TQQQ", Class: LeveragedETF}}}
+	if err := Save(path, in); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if got["TQQQ"].Class != LeveragedETF {
+		t.Errorf("got %+v", got["TQQQ"])
+	}
+}
+
+// --- Fundamentals ---
+
+func TestOpenFundamentals(t *testing.T) {
+	if p, err := OpenFundamentals(""); p != nil || err != nil {
+		t.Errorf("empty spec: got %v, %v; want nil, nil", p, err)
+	}
+	if _, err := OpenFundamentals("bogus"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+	t.Setenv("FMP_API_KEY", "")
+	if _, err := OpenFundamentals("fmp"); err == nil {
+		t.Error("expected error for fmp without API key")
+	}
+}
+
+func TestFileFundamentals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "caps.json")
+	os.WriteFile(path, []byte(`{"AAPL": 3.4e12, "TINY": 5e7}`), 0644)
+
+	caps, err := FileFundamentals{Path: path}.MarketCaps([]string{"AAPL", "MISSING"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if caps["AAPL"] != 3.4e12 {
+		t.Errorf("AAPL: got %g", caps["AAPL"])
+	}
+	if _, ok := caps["MISSING"]; ok {
+		t.Error("unknown symbol should be absent")
+	}
+	if _, ok := caps["TINY"]; ok {
+		t.Error("symbols not requested should be absent")
+	}
+}
+
+func TestFMPFundamentals_Batches(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("apikey") != "k" {
+			http.Error(w, "no key", http.StatusUnauthorized)
+			return
+		}
+		syms := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v3/market-capitalization/"), ",")
+		var rows []string
+		for _, s := range syms {
+			rows = append(rows, `{"symbol":"`+s+`","marketCap":1000}`)
+		}
+		w.Write([]byte("[" + strings.Join(rows, ",") + "]"))
+	}))
+	defer srv.Close()
+
+	symbols := make([]string, fmpBatch+1)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("S%d", i)
+	}
+
+	caps, err := FMPFundamentals{BaseURL: srv.URL, APIKey: "k"}.MarketCaps(symbols)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+	if len(caps) != len(symbols) || caps["S0"] != 1000 {
+		t.Errorf("got %d caps, S0=%g", len(caps), caps["S0"])
+	}
+}
+
+func TestFMPFundamentals_PartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		syms := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v3/market-capitalization/"), ",")
+		if syms[0] != "S0" {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		var rows []string
+		for _, s := range syms {
+			rows = append(rows, `{"symbol":"`+s+`","marketCap":1000}`)
+		}
+		w.Write([]byte("[" + strings.Join(rows, ",") + "]"))
+	}))
+	defer srv.Close()
+
+	symbols := make([]string, fmpBatch+1)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("S%d", i)
+	}
+	caps, err := FMPFundamentals{BaseURL: srv.URL, APIKey: "k"}.MarketCaps(symbols)
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("second batch failed: got %v", err)
+	}
+	if len(caps) != fmpBatch {
+		t.Errorf("first batch's caps: got %d, want %d", len(caps), fmpBatch)
+	}
+}
+
+func TestFMPFundamentals_KeyNotInErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.Close() // Connection refused, quoting the URL
+
+	_, err := FMPFundamentals{BaseURL: srv.URL, APIKey: "s3cr3t"}.MarketCaps([]string{"AAPL"})
+	if err == nil {
+		t.Fatal("want an error")
+	}
+	if strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("key in error: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package assets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Fundamentals supplies market capitalisation (USD) per symbol. Alpaca has
+// no fundamentals endpoint, so the source is pluggable.
+type Fundamentals interface {
+	MarketCaps(symbols []string) (map[string]float64, error)
+}
+
+// OpenFundamentals returns the provider described by spec:
+//
+//	""          - no provider (market cap enrichment disabled)
+//	file:PATH   - JSON object of symbol → market cap, maintained by hand
+//	fmp         - Financial Modeling Prep, key from FMP_API_KEY
+func OpenFundamentals(spec string) (Fundamentals, error) {
+	switch {
+	case spec == "":
+		return nil, nil
+	case strings.HasPrefix(spec, "file:"):
+		return FileFundamentals{Path: strings.TrimPrefix(spec, "file:")}, nil
+	case spec == "fmp":
+		key := os.Getenv("FMP_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("FMP_API_KEY not set")
+		}
+		return FMPFundamentals{BaseURL: "https://financialmodelingprep.com", APIKey: key}, nil
+	default:
+		return nil, fmt.Errorf("unknown fundamentals provider %q", spec)
+	}
+}
+
+// FileFundamentals reads market caps from a local JSON file, e.g.
+// {"AAPL": 3.4e12, "XYZ": 1.2e8}.
+type FileFundamentals struct {
+	Path string
+}
+
+func (f FileFundamentals) MarketCaps(symbols []string) (map[string]float64, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading fundamentals: %w", err)
+	}
+
+	var all map[string]float64
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("parsing fundamentals: %w", err)
+	}
+
+	caps := make(map[string]float64, len(symbols))
+	for _, s := range symbols {
+		if v, ok := all[s]; ok {
+			caps[s] = v
+		}
+	}
+	return caps, nil
+}
+
+// FMPFundamentals queries Financial Modeling Prep's batch market cap endpoint.
+type FMPFundamentals struct {
+	BaseURL string
+	APIKey  string
+}
+
+var fundamentalsClient = &http.Client{Timeout: 30 * time.Second}
+
+// fmpBatch is the maximum number of symbols per request.
+const fmpBatch = 100
+
+// MarketCaps requests the symbols in batches. A failed batch doesn't lose
+// the others: the caps that came back are returned with the batches' errors.
+func (f FMPFundamentals) MarketCaps(symbols []string) (map[string]float64, error) {
+	caps := make(map[string]float64, len(symbols))
+	var errs []error
+	for start := 0; start < len(symbols); start += fmpBatch {
+		end := min(start+fmpBatch, len(symbols))
+		if err := f.batch(symbols[start:end], caps); err != nil {
+			errs = append(errs, fmt.Errorf("symbols %s to %s: %w", symbols[start], symbols[end-1], err))
+		}
+	}
+	return caps, errors.Join(errs...)
+}
+
+// batch requests one batch's market caps into caps.
+func (f FMPFundamentals) batch(symbols []string, caps map[string]float64) error {
+	endpoint := fmt.Sprintf("%s/api/v3/market-capitalization/%s?apikey=%s",
+		f.BaseURL, strings.Join(symbols, ","), url.QueryEscape(f.APIKey))
+
+	resp, err := fundamentalsClient.Get(endpoint)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", redactQuery(err))
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("reading response: %w", redactQuery(err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var rows []struct {
+		Symbol    string  `json:"symbol"`
+		MarketCap float64 `json:"marketCap"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	for _, r := range rows {
+		caps[r.Symbol] = r.MarketCap
+	}
+	return nil
+}
+
+// redactQuery drops the query string, which carries the API key, from the
+// URL a request error quotes, so the key never reaches a log.
+func redactQuery(err error) error {
+	var ue *url.Error
+	if !errors.As(err, &ue) {
+		return err
+	}
+	redacted := ue.URL
+	if i := strings.IndexByte(redacted, '?'); i >= 0 {
+		redacted = redacted[:i] + "?apikey=REDACTED"
+	}
+	return &url.Error{Op: ue.Op, URL: redacted, Err: ue.Err}
+}
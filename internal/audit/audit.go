@@ -0,0 +1,154 @@
+// Package audit keeps an append-only record of every order request sent to
+// the broker and what came back, for when a fill is disputed and the question
+// is what exactly we asked for. Each exchange is one JSON line holding the
+// request and response bodies verbatim, in a file per UTC day. Credentials
+// in the request headers are redacted before anything is written.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDir is where execute keeps the log: outside docs/, as it holds
+// account details that shouldn't be published with the site.
+const DefaultDir = "audit"
+
+// DirFromEnv returns LFT2_AUDIT_DIR, or DefaultDir when it's unset.
+func DirFromEnv() string {
+	if dir := os.Getenv("LFT2_AUDIT_DIR"); dir != "" {
+		return dir
+	}
+	return DefaultDir
+}
+
+// Redacted stands in for a credential.
+const Redacted = "[redacted]"
+
+// secrets are headers whose values are never written. Alpaca's key ID is
+// kept in part, so the log still says which key sent an order.
+var secrets = map[string]bool{
+	"Apca-Api-Secret-Key": true,
+	"Authorization":       true,
+	"Cookie":              true,
+}
+
+const keyID = "Apca-Api-Key-Id"
+
+// Entry is one line of the log.
+type Entry struct {
+	Sent            string            `json:"sent"` // RFC3339 to the nanosecond, UTC
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	RequestHeaders  map[string]string `json:"request_headers"`
+	Request         string            `json:"request"`          // Body as sent
+	Status          int               `json:"status,omitempty"` // 0 when nothing came back
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	Response        string            `json:"response,omitempty"` // Body as received
+	Error           string            `json:"error,omitempty"`
+}
+
+// NewEntry describes a request sent at sent, with the response if one came
+// back, redacting the request's credentials.
+func NewEntry(sent time.Time, req *http.Request, body []byte, resp *http.Response, reply []byte, err error) Entry {
+	e := Entry{
+		Sent:           sent.UTC().Format(time.RFC3339Nano),
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: redact(req.Header),
+		Request:        string(body),
+	}
+	if resp != nil {
+		e.Status = resp.StatusCode
+		e.ResponseHeaders = flatten(resp.Header)
+		e.Response = string(reply)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+func flatten(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		out[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+func redact(h http.Header) map[string]string {
+	out := flatten(h)
+	for name, value := range out {
+		switch {
+		case secrets[name]:
+			out[name] = Redacted
+		case name == keyID && len(value) > 4:
+			out[name] = "…" + value[len(value)-4:]
+		case name == keyID:
+			out[name] = Redacted
+		}
+	}
+	return out
+}
+
+// Log appends entries to one file per UTC day in Dir, named
+// orders-YYYY-MM-DD.jsonl. Files are only ever opened for appending.
+type Log struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+// Open returns a Log in dir, creating it, and checks today's file can be
+// appended to, so a run that couldn't keep its record finds out before it
+// sends anything.
+func Open(dir string, now time.Time) (*Log, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	l := &Log{Dir: dir}
+	f, err := l.open(now)
+	if err != nil {
+		return nil, err
+	}
+	return l, f.Close()
+}
+
+// Path is the file entries sent at t go in.
+func (l *Log) Path(t time.Time) string {
+	return filepath.Join(l.Dir, "orders-"+t.UTC().Format(time.DateOnly)+".jsonl")
+}
+
+func (l *Log) open(t time.Time) (*os.File, error) {
+	return os.OpenFile(l.Path(t), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+}
+
+// Record appends e to the file for the day it was sent.
+func (l *Log) Record(e Entry) error {
+	sent, err := time.Parse(time.RFC3339Nano, e.Sent)
+	if err != nil {
+		return fmt.Errorf("audit entry: %w", err)
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := l.open(sent)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
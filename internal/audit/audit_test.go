@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewEntry_Redacts(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://paper-api.alpaca.markets/v2/orders", nil)
+	req.Header.Set("APCA-API-KEY-ID", "PKABCDEFGH1234")
+	req.Header.Set("APCA-API-SECRET-KEY", "hunter2")
+	req.Header.Set("Content-Type", "application/json")
+	resp := &http.Response{StatusCode: 200, Header: http.Header{"X-Request-Id": {"req-1"}}}
+	sent := time.Date(2026, 3, 10, 14, 35, 0, 123456789, time.UTC)
+
+	e := NewEntry(sent, req, []byte(`{"symbol": "AAPL"}`), resp, []byte(`{"id":"o-1"}`), nil)
+	if got := e.RequestHeaders["Apca-Api-Secret-Key"]; got != Redacted {
+		t.Errorf("secret: got %q", got)
+	}
+	if got := e.RequestHeaders["Apca-Api-Key-Id"]; got != "…1234" {
+		t.Errorf("key ID: got %q", got)
+	}
+	if e.Request != `{"symbol": "AAPL"}` || e.Response != `{"id":"o-1"}` || e.ResponseHeaders["X-Request-Id"] != "req-1" {
+		t.Errorf("bodies and headers should be kept as they were: got %+v", e)
+	}
+	if e.Sent != "2026-03-10T14:35:00.123456789Z" || e.Status != 200 {
+		t.Errorf("got %+v", e)
+	}
+
+	req.Header = http.Header{"Authorization": {"Bearer tok"}}
+	e = NewEntry(sent, req, nil, nil, nil, errors.New("connection reset"))
+	if e.RequestHeaders["Authorization"] != Redacted || e.Status != 0 || e.Error != "connection reset" {
+		t.Errorf("no response: got %+v", e)
+	}
+}
+
+func TestLog_AppendsByDay(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "audit")
+	day := time.Date(2026, 3, 10, 23, 59, 0, 0, time.UTC)
+	l, err := Open(dir, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", "https://example.com/v2/orders", nil)
+	for i, at := range []time.Time{day, day.Add(30 * time.Second), day.Add(2 * time.Minute)} {
+		if err := l.Record(NewEntry(at, req, []byte{byte('a' + i)}, nil, nil, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := func(path string) []Entry {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entries []Entry
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var e Entry
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, e)
+		}
+		return entries
+	}
+	if got := lines(filepath.Join(dir, "orders-2026-03-10.jsonl")); len(got) != 2 || got[0].Request != "a" || got[1].Request != "b" {
+		t.Errorf("first day: got %+v", got)
+	}
+	if got := lines(l.Path(day.Add(time.Hour))); len(got) != 1 || got[0].Request != "c" {
+		t.Errorf("after midnight: got %+v", got)
+	}
+
+	// Reopening adds to the day's file rather than starting it again
+	if _, err := Open(dir, day); err != nil {
+		t.Fatal(err)
+	}
+	if got := lines(l.Path(day)); len(got) != 2 {
+		t.Errorf("reopened: got %d entries, want 2", len(got))
+	}
+}
+
+func TestOpen_Unwritable(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(filepath.Join(file, "audit"), time.Now()); err == nil {
+		t.Error("directory under a file: want an error")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/deanturpin/lft2/internal/audit
+
+go 1.21
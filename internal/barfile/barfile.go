@@ -0,0 +1,152 @@
+// Package barfile reads and writes the per-symbol bar files under
+// docs/bars: {SYMBOL}.json, or {SYMBOL}.json.gz when fetch runs with -gzip.
+// Readers take either and get the JSON back; the manifest's checksums are
+// of the file as written, compressed or not. A symbol has one file at a
+// time, as Write removes the other form.
+package barfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// File name suffixes
+const (
+	Ext     = ".json"
+	GzipExt = ".json.gz"
+)
+
+// Name is symbol's file name, compressed or not.
+func Name(symbol string, compressed bool) string {
+	if compressed {
+		return symbol + GzipExt
+	}
+	return symbol + Ext
+}
+
+// Symbol returns the symbol a bar file name is for, and false for anything
+// that isn't a bar file.
+func Symbol(name string) (string, bool) {
+	for _, ext := range []string{GzipExt, Ext} {
+		if symbol, ok := strings.CutSuffix(name, ext); ok && symbol != "" {
+			return symbol, true
+		}
+	}
+	return "", false
+}
+
+// Symbols lists the symbols with a bar file in dir, sorted.
+func Symbols(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var symbols []string
+	for _, e := range entries {
+		if symbol, ok := Symbol(e.Name()); ok && !e.IsDir() && !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// Path is symbol's bar file in dir: the .json, else the .json.gz. With
+// neither it's the .json, and reading it fails with fs.ErrNotExist.
+func Path(dir, symbol string) string {
+	plain := filepath.Join(dir, Name(symbol, false))
+	if _, err := os.Stat(plain); errors.Is(err, fs.ErrNotExist) {
+		compressed := filepath.Join(dir, Name(symbol, true))
+		if _, err := os.Stat(compressed); err == nil {
+			return compressed
+		}
+	}
+	return plain
+}
+
+// ReadRaw returns symbol's bar file as it is on disk, for checksums.
+func ReadRaw(dir, symbol string) ([]byte, error) {
+	return os.ReadFile(Path(dir, symbol))
+}
+
+// Read returns symbol's bars as JSON, decompressed if need be.
+func Read(dir, symbol string) ([]byte, error) {
+	raw, err := ReadRaw(dir, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(raw)
+}
+
+// Compressed says whether data is gzipped.
+func Compressed(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// Decode returns a bar file's JSON: data itself, or gunzipped.
+func Decode(data []byte) ([]byte, error) {
+	if !Compressed(data) {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("opening gzip: %w", err)
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing: %w", err)
+	}
+	return out, nil
+}
+
+// Encode returns the bytes to write for JSON data, gzipped when compress
+// is set. The gzip header carries no name or time, so the same bars always
+// make the same file and the same checksum.
+func Encode(data []byte, compress bool) ([]byte, error) {
+	if !compress {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write saves JSON data as symbol's bar file in dir, compressed or not, and
+// removes the file in the other form. It's written beside its final name and
+// renamed, so a reader never sees it half written. It returns the bytes
+// written, for the manifest.
+func Write(dir, symbol string, data []byte, compress bool) ([]byte, error) {
+	raw, err := Encode(data, compress)
+	if err != nil {
+		return nil, fmt.Errorf("compressing %s: %w", symbol, err)
+	}
+	path := filepath.Join(dir, Name(symbol, compress))
+	if err := os.WriteFile(path+".tmp", raw, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return nil, errors.Join(err, os.Remove(path+".tmp"))
+	}
+	other := filepath.Join(dir, Name(symbol, !compress))
+	if err := os.Remove(other); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return raw, nil
+}
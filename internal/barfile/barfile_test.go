@@ -0,0 +1,89 @@
+package barfile
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const bars = `{"symbol": "AAPL", "bars": [{"t": "2026-03-10T13:30:00Z", "c": 200}]}`
+
+func TestSymbol(t *testing.T) {
+	for name, want := range map[string]string{
+		"AAPL.json":      "AAPL",
+		"BRK.B.json.gz":  "BRK.B",
+		"AAPL.json.tmp":  "",
+		".json":          "",
+		"bars-manifest":  "",
+		"AAPL.json.gz.x": "",
+	} {
+		got, ok := Symbol(name)
+		if got != want || ok != (want != "") {
+			t.Errorf("%s: got %q %v, want %q", name, got, ok, want)
+		}
+	}
+}
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		dir := t.TempDir()
+		raw, err := Write(dir, "AAPL", []byte(bars), compress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if Compressed(raw) != compress {
+			t.Errorf("compress %v: written bytes compressed %v", compress, Compressed(raw))
+		}
+		if onDisk, _ := ReadRaw(dir, "AAPL"); !bytes.Equal(onDisk, raw) {
+			t.Errorf("compress %v: ReadRaw isn't what Write returned", compress)
+		}
+		got, err := Read(dir, "AAPL")
+		if err != nil || string(got) != bars {
+			t.Errorf("compress %v: got %q %v", compress, got, err)
+		}
+	}
+}
+
+func TestWrite_RemovesOtherForm(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Write(dir, "AAPL", []byte(bars), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Write(dir, "AAPL", []byte(bars), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "AAPL.json")); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("AAPL.json left beside AAPL.json.gz")
+	}
+	if got := Path(dir, "AAPL"); !strings.HasSuffix(got, GzipExt) {
+		t.Errorf("path: got %s", got)
+	}
+	if symbols, _ := Symbols(dir); len(symbols) != 1 || symbols[0] != "AAPL" {
+		t.Errorf("symbols: got %v", symbols)
+	}
+}
+
+func TestEncode_Deterministic(t *testing.T) {
+	a, _ := Encode([]byte(bars), true)
+	b, _ := Encode([]byte(bars), true)
+	if !bytes.Equal(a, b) {
+		t.Error("the same bars compressed twice differ, so the manifest checksum would")
+	}
+}
+
+func TestRead_Missing(t *testing.T) {
+	if _, err := Read(t.TempDir(), "NOPE"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestDecode_Corrupt(t *testing.T) {
+	raw, _ := Encode([]byte(bars), true)
+	if _, err := Decode(raw[:len(raw)-6]); err == nil {
+		t.Error("truncated gzip: want an error")
+	}
+}
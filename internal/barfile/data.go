@@ -0,0 +1,68 @@
+package barfile
+
+import (
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// Bar is one bar in a bar file.
+type Bar struct {
+	Timestamp string  `json:"t"`
+	Open      float64 `json:"o"`
+	High      float64 `json:"h"`
+	Low       float64 `json:"l"`
+	Close     float64 `json:"c"`
+	Volume    int64   `json:"v"`
+}
+
+// Data is a bar file's contents. Every command that rewrites a bar file
+// decodes it into this, so fields one of them doesn't use still survive it.
+type Data struct {
+	schema.Header
+	Symbol    string   `json:"symbol"`
+	Bars      []Bar    `json:"bars"`
+	Count     int      `json:"count"`
+	Feed      string   `json:"feed"` // sip or iex: what backtests trained on
+	FetchedAt string   `json:"fetched_at"`
+	Splits    []string `json:"splits,omitempty"` // Corporate action IDs the bars are adjusted for
+	Source    string   `json:"source,omitempty"` // Provider the bars came from
+}
+
+// Canonical puts bars in the order every reader assumes: ascending by time,
+// one bar per timestamp. Two fetches in a day can overlap, and a provider's
+// pages needn't arrive in order. Timestamps are rewritten in UTC, so a bar
+// given with an offset matches its duplicate given in Z. Where two bars
+// share a time the later in bars is kept. A timestamp that doesn't parse is
+// left as it is, to sort as a string. It also returns how many duplicates
+// were dropped.
+func Canonical(bars []Bar) ([]Bar, int) {
+	out := make([]Bar, len(bars))
+	for i, b := range bars {
+		if t, err := time.Parse(time.RFC3339, b.Timestamp); err == nil {
+			b.Timestamp = t.UTC().Format(time.RFC3339Nano)
+		}
+		out[i] = b
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp < out[j].Timestamp })
+
+	kept := out[:0]
+	for _, b := range out {
+		if n := len(kept); n > 0 && kept[n-1].Timestamp == b.Timestamp {
+			kept[n-1] = b
+			continue
+		}
+		kept = append(kept, b)
+	}
+	return kept, len(bars) - len(kept)
+}
+
+// Merge adds fresh bars to saved ones, in canonical order. A fresh bar
+// replaces a saved one with the same timestamp. It also returns how many
+// timestamps the fresh bars added.
+func Merge(saved, fresh []Bar) ([]Bar, int) {
+	saved, _ = Canonical(saved)
+	merged, _ := Canonical(append(append(make([]Bar, 0, len(saved)+len(fresh)), saved...), fresh...))
+	return merged, len(merged) - len(saved)
+}
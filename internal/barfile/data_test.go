@@ -0,0 +1,62 @@
+package barfile
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	bars := []Bar{
+		{Timestamp: "2026-03-10T15:05:00Z", Close: 2},
+		{Timestamp: "2026-03-10T15:00:00Z", Close: 1},
+		{Timestamp: "2026-03-10T11:05:00-04:00", Close: 3}, // 15:05Z again, fetched later
+		{Timestamp: "2026-03-10T15:10:00Z", Close: 4},
+		{Timestamp: "2026-03-10T15:00:00Z", Close: 5},
+	}
+	got, dropped := Canonical(bars)
+	if dropped != 2 {
+		t.Errorf("dropped: got %d, want 2", dropped)
+	}
+	want := []Bar{
+		{Timestamp: "2026-03-10T15:00:00Z", Close: 5},
+		{Timestamp: "2026-03-10T15:05:00Z", Close: 3},
+		{Timestamp: "2026-03-10T15:10:00Z", Close: 4},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("bar %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	saved := []Bar{
+		{Timestamp: "2026-02-18T14:30:00Z", Close: 1},
+		{Timestamp: "2026-02-18T14:40:00Z", Close: 3},
+		{Timestamp: "2026-02-18T14:35:00Z", Close: 2},
+	}
+	// The last saved bar comes back, revised, with two new ones
+	fresh := []Bar{
+		{Timestamp: "2026-02-18T14:50:00Z", Close: 5},
+		{Timestamp: "2026-02-18T14:40:00Z", Close: 3.5},
+		{Timestamp: "2026-02-18T14:45:00Z", Close: 4},
+	}
+	merged, added := Merge(saved, fresh)
+	if added != 2 {
+		t.Errorf("added: got %d, want 2", added)
+	}
+	var got []string
+	for _, b := range merged {
+		got = append(got, fmt.Sprintf("%s=%g", b.Timestamp[11:16], b.Close))
+	}
+	if want := "14:30=1,14:35=2,14:40=3.5,14:45=4,14:50=5"; strings.Join(got, ",") != want {
+		t.Errorf("merged: got %s, want %s", strings.Join(got, ","), want)
+	}
+	if saved[1].Close != 3 {
+		t.Error("Merge changed the saved bars")
+	}
+}
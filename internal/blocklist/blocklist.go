@@ -0,0 +1,89 @@
+// Package blocklist loads the symbol block and allow lists consulted by
+// filter and execute (entries reads the same file from C++).
+package blocklist
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultPath is the blocklist location relative to the repository root.
+const DefaultPath = "blocklist.json"
+
+// Entry is a single listed symbol. Expires is an inclusive YYYY-MM-DD date;
+// empty means the entry never expires.
+type Entry struct {
+	Symbol  string `json:"symbol"`
+	Reason  string `json:"reason"`
+	Expires string `json:"expires,omitempty"`
+}
+
+// List holds blocked symbols and an optional allow list. When any allow
+// entry is active, every symbol not on it is treated as blocked.
+type List struct {
+	Blocked []Entry `json:"blocked"`
+	Allowed []Entry `json:"allowed,omitempty"`
+}
+
+// Load reads a blocklist file. A missing file is not an error — it yields an
+// empty list so the blocklist stays optional.
+func Load(path string) (*List, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &List{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading blocklist: %w", err)
+	}
+
+	var list List
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parsing blocklist: %w", err)
+	}
+
+	for i := range list.Blocked {
+		list.Blocked[i].Symbol = strings.ToUpper(strings.TrimSpace(list.Blocked[i].Symbol))
+	}
+	for i := range list.Allowed {
+		list.Allowed[i].Symbol = strings.ToUpper(strings.TrimSpace(list.Allowed[i].Symbol))
+	}
+
+	return &list, nil
+}
+
+// active reports whether e still applies on the given day.
+func (e Entry) active(today string) bool {
+	return e.Expires == "" || today <= e.Expires
+}
+
+// Check returns the entry blocking symbol at now, if any. Explicit blocks
+// take precedence; otherwise a non-empty allow list blocks everything it
+// doesn't name.
+func (l *List) Check(symbol string, now time.Time) (Entry, bool) {
+	today := now.UTC().Format("2006-01-02")
+
+	for _, e := range l.Blocked {
+		if e.Symbol == symbol && e.active(today) {
+			return e, true
+		}
+	}
+
+	allowListActive := false
+	for _, e := range l.Allowed {
+		if !e.active(today) {
+			continue
+		}
+		allowListActive = true
+		if e.Symbol == symbol {
+			return Entry{}, false
+		}
+	}
+
+	if allowListActive {
+		return Entry{Symbol: symbol, Reason: "not on allow list"}, true
+	}
+	return Entry{}, false
+}
@@ -0,0 +1,89 @@
+package blocklist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var today = time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+
+// --- Load ---
+
+func TestLoad_MissingFileIsEmpty(t *testing.T) {
+	list, err := Load("/nonexistent/blocklist.json")
+	if err != nil {
+		t.Fatalf("missing file should not error, got: %v", err)
+	}
+	if len(list.Blocked) != 0 {
+		t.Errorf("expected empty list, got %d entries", len(list.Blocked))
+	}
+}
+
+func TestLoad_NormalisesSymbols(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.json")
+	os.WriteFile(path, []byte(`{"blocked":[{"symbol":" tqqq ","reason":"leveraged"}]}`), 0644)
+	list, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Blocked[0].Symbol != "TQQQ" {
+		t.Errorf("got %q, want TQQQ", list.Blocked[0].Symbol)
+	}
+}
+
+func TestLoad_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.json")
+	os.WriteFile(path, []byte(`not json`), 0644)
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for invalid JSON, got nil")
+	}
+}
+
+// --- Check ---
+
+func TestCheck_Blocked(t *testing.T) {
+	list := &List{Blocked: []Entry{{Symbol: "GME", Reason: "meme"}}}
+	e, blocked := list.Check("GME", today)
+	if !blocked || e.Reason != "meme" {
+		t.Errorf("got blocked=%v reason=%q, want true/meme", blocked, e.Reason)
+	}
+	if _, blocked := list.Check("AAPL", today); blocked {
+		t.Error("AAPL should not be blocked")
+	}
+}
+
+func TestCheck_ExpiryInclusive(t *testing.T) {
+	list := &List{Blocked: []Entry{
+		{Symbol: "TODAY", Reason: "x", Expires: "2026-03-10"},
+		{Symbol: "PAST", Reason: "x", Expires: "2026-03-09"},
+	}}
+	if _, blocked := list.Check("TODAY", today); !blocked {
+		t.Error("entry expiring today should still block")
+	}
+	if _, blocked := list.Check("PAST", today); blocked {
+		t.Error("expired entry should not block")
+	}
+}
+
+func TestCheck_AllowList(t *testing.T) {
+	list := &List{Allowed: []Entry{{Symbol: "SPY"}}}
+	if _, blocked := list.Check("SPY", today); blocked {
+		t.Error("allowed symbol should pass")
+	}
+	e, blocked := list.Check("AAPL", today)
+	if !blocked || e.Reason != "not on allow list" {
+		t.Errorf("got blocked=%v reason=%q, want not on allow list", blocked, e.Reason)
+	}
+}
+
+func TestCheck_BlockBeatsAllow(t *testing.T) {
+	list := &List{
+		Blocked: []Entry{{Symbol: "SPY", Reason: "halted"}},
+		Allowed: []Entry{{Symbol: "SPY"}},
+	}
+	if _, blocked := list.Check("SPY", today); !blocked {
+		t.Error("explicit block should override allow list")
+	}
+}
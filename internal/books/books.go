@@ -0,0 +1,213 @@
+// Package books loads books.json, which splits the account into parallel
+// books, e.g. 5-minute scalps beside 60-minute swings. Each book has its own
+// bars, candidates, strategies and slice of the account, and keeps its files
+// under docs/books/{name}/; LFT2_BOOK picks the book a stage runs for, in Go
+// and C++ alike (src/paths.h). Without books.json there is one book, the
+// default, whose files are docs/ itself.
+package books
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DefaultPath is books.json, at the repository root beside rules.json.
+const DefaultPath = "books.json"
+
+// Env names the book a stage runs for; unset is the default book.
+const Env = "LFT2_BOOK"
+
+// maxName matches max_book in src/paths.h.
+const maxName = 16
+
+// Book is one entry in books.json.
+type Book struct {
+	Name         string   `json:"name"`
+	TimeframeMin int      `json:"timeframe"`  // Bar length in minutes, as fetch -timeframe takes it
+	Strategies   []string `json:"strategies"` // Bare names, each in one book only
+	Budget       float64  `json:"budget"`     // Fraction of account equity it may hold
+}
+
+// Config is books.json.
+type Config struct {
+	Books []Book `json:"books"`
+}
+
+// Load reads and checks path. A missing file is no books, not an error.
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Config{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// FromEnv loads books.json and the book named in LFT2_BOOK, nil for the
+// default book.
+func FromEnv() (Config, *Book, error) {
+	c, err := Load(DefaultPath)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	b, err := c.Select(os.Getenv(Env))
+	if err != nil {
+		return Config{}, nil, err
+	}
+	return c, b, nil
+}
+
+// Select returns the named book, nil for the default book (""). Naming a
+// book c doesn't have is an error, since running it would trade another
+// book's files.
+func (c Config) Select(name string) (*Book, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if !ValidName(name) {
+		return nil, fmt.Errorf("%s %q isn't a book name (a-z, 0-9 and '-', up to %d)", Env, name, maxName)
+	}
+	b, ok := c.Find(name)
+	if !ok {
+		return nil, fmt.Errorf("%s %q isn't in %s", Env, name, DefaultPath)
+	}
+	return &b, nil
+}
+
+// ValidName is valid_book in src/paths.h: lower-case letters, digits and
+// '-', so a name is a safe directory that can't leave docs/books/, and fits
+// on the end of a cycle ID, which keeps each book's orders its own.
+func ValidName(name string) bool {
+	if name == "" || len(name) > maxName {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks each book has a usable name, timeframe and budget, that
+// no strategy is in two books, and that the budgets don't add up to more
+// than the account.
+func (c Config) Validate() error {
+	names := map[string]bool{}
+	owners := map[string]string{}
+	total := 0.0
+	for _, b := range c.Books {
+		if !ValidName(b.Name) {
+			return fmt.Errorf("%q isn't a book name (a-z, 0-9 and '-', up to %d)", b.Name, maxName)
+		}
+		if names[b.Name] {
+			return fmt.Errorf("book %q is listed twice", b.Name)
+		}
+		names[b.Name] = true
+		if b.TimeframeMin < 1 {
+			return fmt.Errorf("book %q: timeframe must be a positive number of minutes", b.Name)
+		}
+		if b.Budget <= 0 || b.Budget > 1 {
+			return fmt.Errorf("book %q: budget %g isn't a fraction of the account", b.Name, b.Budget)
+		}
+		if len(b.Strategies) == 0 {
+			return fmt.Errorf("book %q has no strategies", b.Name)
+		}
+		for _, s := range b.Strategies {
+			if other, ok := owners[s]; ok {
+				return fmt.Errorf("strategy %q is in books %q and %q", s, other, b.Name)
+			}
+			owners[s] = b.Name
+		}
+		total += b.Budget
+	}
+	if total > 1+1e-9 {
+		return fmt.Errorf("budgets add up to %.0f%% of the account", total*100)
+	}
+	return nil
+}
+
+// Find returns the named book.
+func (c Config) Find(name string) (Book, bool) {
+	for _, b := range c.Books {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return Book{}, false
+}
+
+// Owner is the book trading strategy, by its bare name as
+// report.OrderStrategy recovers it from a client_order_id; "" for the
+// default book.
+func (c Config) Owner(strategy string) string {
+	for _, b := range c.Books {
+		for _, s := range b.Strategies {
+			if s == strategy {
+				return b.Name
+			}
+		}
+	}
+	return ""
+}
+
+// Budget is the named book's fraction of the account. The default book
+// has whatever the others leave; all of it without books.json.
+func (c Config) Budget(name string) float64 {
+	if b, ok := c.Find(name); ok {
+		return b.Budget
+	}
+	left := 1.0
+	for _, b := range c.Books {
+		left -= b.Budget
+	}
+	return max(0, left)
+}
+
+// Root is where the named book keeps its files, ending in '/': book_root
+// in src/paths.h. The default book ("") keeps them in docs/.
+func Root(name string) string {
+	if name == "" {
+		return "docs/"
+	}
+	return "docs/books/" + name + "/"
+}
+
+// Root is Root(b.Name), or docs/ for the default book (nil).
+func (b *Book) Root() string {
+	if b == nil {
+		return Root("")
+	}
+	return Root(b.Name)
+}
+
+// Allotment is a book's share of the account, which its account.json gives
+// entries in place of the account's own balances.
+type Allotment struct {
+	Equity      float64
+	Cash        float64
+	BuyingPower float64
+}
+
+// Allot gives a book budget of the account's equity, less the market value
+// its own positions already hold, and never more cash or buying power than
+// the account has left.
+func Allot(budget, equity, cash, buyingPower, held float64) Allotment {
+	share := budget * equity
+	free := max(0, share-held)
+	return Allotment{
+		Equity:      share,
+		Cash:        max(0, min(cash, free)),
+		BuyingPower: max(0, min(buyingPower, free)),
+	}
+}
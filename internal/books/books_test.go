@@ -0,0 +1,111 @@
+package books
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sample() Config {
+	return Config{Books: []Book{
+		{Name: "scalp", TimeframeMin: 5, Strategies: []string{"gap_fill", "dip_buy"}, Budget: 0.5},
+		{Name: "swing", TimeframeMin: 60, Strategies: []string{"momentum"}, Budget: 0.3},
+	}}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if c, err := Load(filepath.Join(dir, "books.json")); err != nil || len(c.Books) != 0 {
+		t.Errorf("missing file: got %+v, %v, want no books", c, err)
+	}
+
+	path := filepath.Join(dir, "books.json")
+	os.WriteFile(path, []byte(`{"books": [{"name": "swing", "timeframe": 60, "strategies": ["momentum"], "budget": 0.4}]}`), 0644)
+	c, err := Load(path)
+	if err != nil || len(c.Books) != 1 || c.Books[0].TimeframeMin != 60 {
+		t.Errorf("got %+v, %v", c, err)
+	}
+
+	os.WriteFile(path, []byte(`{"books": [{"name": "Swing", "timeframe": 60, "strategies": ["momentum"], "budget": 0.4}]}`), 0644)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "isn't a book name") {
+		t.Errorf("got %v, want a bad name rejected", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := sample().Validate(); err != nil {
+		t.Fatalf("sample: %v", err)
+	}
+	tests := []struct {
+		name string
+		edit func(*Config)
+		want string
+	}{
+		{"climbs out", func(c *Config) { c.Books[0].Name = "../scalp" }, "isn't a book name"},
+		{"twice", func(c *Config) { c.Books[1].Name = "scalp" }, "listed twice"},
+		{"no timeframe", func(c *Config) { c.Books[0].TimeframeMin = 0 }, "timeframe"},
+		{"no budget", func(c *Config) { c.Books[0].Budget = 0 }, "isn't a fraction"},
+		{"overspent", func(c *Config) { c.Books[0].Budget = 0.8 }, "110%"},
+		{"no strategies", func(c *Config) { c.Books[1].Strategies = nil }, "no strategies"},
+		{"shared", func(c *Config) { c.Books[1].Strategies = []string{"dip_buy"} }, `"dip_buy" is in books`},
+	}
+	for _, tt := range tests {
+		c := sample()
+		tt.edit(&c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	c := sample()
+	if b, err := c.Select(""); err != nil || b != nil || b.Root() != "docs/" {
+		t.Errorf("unset: got %+v, %v, want the default book", b, err)
+	}
+	if b, err := c.Select("swing"); err != nil || b == nil || b.Root() != "docs/books/swing/" {
+		t.Errorf("swing: got %+v, %v", b, err)
+	}
+	if _, err := c.Select("intraday"); err == nil {
+		t.Error("a book books.json doesn't have should be an error")
+	}
+	if _, err := c.Select("../x"); err == nil {
+		t.Error("a malformed name should be an error")
+	}
+}
+
+func TestOwnerAndBudget(t *testing.T) {
+	c := sample()
+	if got := c.Owner("momentum"); got != "swing" {
+		t.Errorf("momentum: got %q, want swing", got)
+	}
+	if got := c.Owner("external"); got != "" {
+		t.Errorf("external: got %q, want the default book", got)
+	}
+	if got := c.Budget("swing"); got != 0.3 {
+		t.Errorf("swing budget: got %g", got)
+	}
+	if got := c.Budget(""); got < 0.19 || got > 0.21 {
+		t.Errorf("default budget: got %g, want what's left, 0.2", got)
+	}
+	if got := (Config{}).Budget(""); got != 1 {
+		t.Errorf("no books: got %g, want the whole account", got)
+	}
+}
+
+func TestAllot(t *testing.T) {
+	// 30% of $10,000, $1,000 of it already held
+	got := Allot(0.3, 10000, 5000, 8000, 1000)
+	if got.Equity != 3000 || got.Cash != 2000 || got.BuyingPower != 2000 {
+		t.Errorf("got %+v, want $3,000 equity and $2,000 free", got)
+	}
+	// The account has less left than the book's share
+	if got := Allot(0.3, 10000, 500, 800, 1000); got.Cash != 500 || got.BuyingPower != 800 {
+		t.Errorf("got %+v, want the account's own cash and buying power", got)
+	}
+	// Over budget after gains: nothing more to spend
+	if got := Allot(0.3, 10000, 5000, 8000, 3500); got.Cash != 0 || got.BuyingPower != 0 {
+		t.Errorf("got %+v, want nothing free", got)
+	}
+}
@@ -0,0 +1,143 @@
+// Package corporate holds the corporate actions for the watchlist: splits,
+// dividends, mergers and spinoffs announced to Alpaca. cmd/corporate-actions
+// writes them to docs/corporate_actions.json, and fetch reads the splits to
+// keep saved bar history on one price scale.
+package corporate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// DefaultPath is where cmd/corporate-actions writes the announcements.
+const DefaultPath = "docs/corporate_actions.json"
+
+// Action types, as Alpaca names them
+const (
+	Split    = "split"
+	Dividend = "dividend"
+	Merger   = "merger"
+	Spinoff  = "spinoff"
+)
+
+// Action is one announced corporate action. Rates are shares: a 4-for-1
+// split has OldRate 1 and NewRate 4, a 1-for-10 reverse split 10 and 1.
+type Action struct {
+	ID           string  `json:"id"`
+	Symbol       string  `json:"symbol"`
+	Type         string  `json:"type"`
+	SubType      string  `json:"sub_type,omitempty"`
+	TargetSymbol string  `json:"target_symbol,omitempty"` // Mergers and spinoffs
+	ExDate       string  `json:"ex_date"`                 // YYYY-MM-DD, New York
+	Cash         float64 `json:"cash,omitempty"`          // Dividend per share
+	OldRate      float64 `json:"old_rate,omitempty"`
+	NewRate      float64 `json:"new_rate,omitempty"`
+}
+
+// File is the on-disk layout of corporate_actions.json.
+type File struct {
+	schema.Header
+	Timestamp string   `json:"timestamp"`
+	Since     string   `json:"since"`
+	Until     string   `json:"until"`
+	Actions   []Action `json:"actions"` // By ex date, then symbol
+}
+
+// market is the exchange timezone ex dates are in.
+var market = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+// PriceFactor is what a split multiplies prices before its ex date by to
+// put them on today's scale: 0.25 for a 4-for-1. Volumes take the inverse.
+// ok is false for anything that isn't a split with both rates.
+func (a Action) PriceFactor() (factor float64, ok bool) {
+	if a.Type != Split || a.OldRate <= 0 || a.NewRate <= 0 {
+		return 0, false
+	}
+	return a.OldRate / a.NewRate, true
+}
+
+// Effective says whether the action's ex date has come by now, so bars from
+// before it are on the old scale.
+func (a Action) Effective(now time.Time) bool {
+	return a.ExDate != "" && a.ExDate <= now.In(market).Format(time.DateOnly)
+}
+
+// Before says whether a bar at t traded before the ex date.
+func (a Action) Before(t time.Time) bool {
+	return t.In(market).Format(time.DateOnly) < a.ExDate
+}
+
+// Splits returns symbol's splits that have taken effect by now, oldest
+// first.
+func (f *File) Splits(symbol string, now time.Time) []Action {
+	var splits []Action
+	for _, a := range f.Actions {
+		if _, ok := a.PriceFactor(); ok && a.Symbol == symbol && a.Effective(now) {
+			splits = append(splits, a)
+		}
+	}
+	return splits
+}
+
+// Sort orders actions by ex date, then symbol, then ID.
+func Sort(actions []Action) {
+	sort.Slice(actions, func(i, j int) bool {
+		a, b := actions[i], actions[j]
+		if a.ExDate != b.ExDate {
+			return a.ExDate < b.ExDate
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.ID < b.ID
+	})
+}
+
+// Load reads corporate_actions.json. A missing file has no actions.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading corporate actions: %w", err)
+	}
+	return Parse(path, data)
+}
+
+// Parse decodes a corporate_actions.json read from name.
+func Parse(name string, data []byte) (*File, error) {
+	if err := schema.Check(name, data); err != nil {
+		return nil, err
+	}
+	f := &File{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return f, nil
+}
+
+// Save writes corporate_actions.json.
+func Save(path string, f *File) error {
+	f.Header = schema.Current()
+	if f.Actions == nil {
+		f.Actions = []Action{}
+	}
+	Sort(f.Actions)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding corporate actions: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,70 @@
+package corporate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPriceFactor(t *testing.T) {
+	for _, tc := range []struct {
+		action Action
+		factor float64
+		ok     bool
+	}{
+		{Action{Type: Split, OldRate: 1, NewRate: 4}, 0.25, true},
+		{Action{Type: Split, OldRate: 10, NewRate: 1}, 10, true},
+		{Action{Type: Split, OldRate: 0, NewRate: 4}, 0, false},
+		{Action{Type: Dividend, Cash: 0.24}, 0, false},
+	} {
+		if factor, ok := tc.action.PriceFactor(); factor != tc.factor || ok != tc.ok {
+			t.Errorf("%+v: got %v %v, want %v %v", tc.action, factor, ok, tc.factor, tc.ok)
+		}
+	}
+}
+
+func TestBefore(t *testing.T) {
+	a := Action{ExDate: "2026-03-10"}
+	// 00:30 UTC on the 10th is still the 9th in New York
+	if !a.Before(time.Date(2026, 3, 10, 0, 30, 0, 0, time.UTC)) {
+		t.Error("the evening before the ex date should be before it")
+	}
+	if a.Before(time.Date(2026, 3, 10, 13, 30, 0, 0, time.UTC)) {
+		t.Error("the ex date's open shouldn't be before it")
+	}
+}
+
+func TestSplits(t *testing.T) {
+	f := &File{Actions: []Action{
+		{ID: "1", Symbol: "NVDA", Type: Split, ExDate: "2026-03-10", OldRate: 1, NewRate: 4},
+		{ID: "2", Symbol: "NVDA", Type: Dividend, ExDate: "2026-03-05", Cash: 0.01},
+		{ID: "3", Symbol: "NVDA", Type: Split, ExDate: "2026-04-01", OldRate: 1, NewRate: 2},
+		{ID: "4", Symbol: "AAPL", Type: Split, ExDate: "2026-03-02", OldRate: 1, NewRate: 2},
+	}}
+	got := f.Splits("NVDA", time.Date(2026, 3, 12, 15, 0, 0, 0, time.UTC))
+	if len(got) != 1 || got[0].ID != "1" {
+		t.Errorf("got %+v, want only NVDA's effective split", got)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corporate_actions.json")
+	f := &File{Since: "2026-01-01", Until: "2026-03-31", Actions: []Action{
+		{ID: "b", Symbol: "MSFT", Type: Dividend, ExDate: "2026-03-10", Cash: 0.83},
+		{ID: "a", Symbol: "AAPL", Type: Dividend, ExDate: "2026-03-10", Cash: 0.26},
+	}}
+	if err := Save(path, f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Actions) != 2 || got.Actions[0].Symbol != "AAPL" || got.SchemaVersion == 0 {
+		t.Errorf("got %+v, want both, AAPL first, with a header", got)
+	}
+
+	if missing, err := Load(filepath.Join(t.TempDir(), "absent.json")); err != nil || len(missing.Actions) != 0 {
+		t.Errorf("missing: got %+v %v", missing, err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package crash turns a panic in any pipeline stage into a crash report
+// under docs/crash/ and a distinct exit code, so a nil-map panic mid-session
+// is published with the dashboard rather than lost in the CI log.
+package crash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// DefaultDir is where crash reports are written, relative to the repository
+// root.
+const DefaultDir = "docs/crash"
+
+// ExitCode is EX_SOFTWARE from sysexits.h, distinct from the 1 of log.Fatal
+// and a failed execute.
+const ExitCode = 70
+
+// Report is one docs/crash/{stage}-{time}.json.
+type Report struct {
+	schema.Header
+	Timestamp string            `json:"timestamp"`
+	Stage     string            `json:"stage"`
+	Panic     string            `json:"panic"`
+	Stack     string            `json:"stack"`
+	Inputs    map[string]string `json:"inputs"` // Path → SHA-256, "missing" or the read error
+}
+
+// Overridden by tests
+var (
+	dir  = DefaultDir
+	exit = os.Exit
+	now  = time.Now
+)
+
+// Guard recovers a panic in the calling goroutine, writes a crash report
+// naming stage and hashing each input file, and exits with ExitCode. It must
+// be deferred directly:
+//
+//	defer crash.Guard("filter", "docs/bars-manifest.json")
+//
+// Panics in other goroutines aren't caught.
+func Guard(stage string, inputs ...string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	report := Report{
+		Header:    schema.Current(),
+		Timestamp: now().UTC().Format(time.RFC3339),
+		Stage:     stage,
+		Panic:     fmt.Sprint(r),
+		Stack:     string(debug.Stack()),
+		Inputs:    Hashes(inputs),
+	}
+
+	fmt.Fprintf(os.Stderr, "\n✗ %s panicked: %s\n%s", stage, report.Panic, report.Stack)
+	path, err := Write(dir, report)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] writing crash report: %v\n", err)
+	} else {
+		fmt.Fprintf(os.Stderr, "  crash report: %s\n", path)
+	}
+
+	// Raised as an annotation on the workflow run when under GitHub Actions
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		fmt.Printf("::error title=%s crashed::%s (report: %s)\n", stage, report.Panic, path)
+	}
+	exit(ExitCode)
+}
+
+// Hashes returns the SHA-256 of each file, so a crash can be matched to the
+// exact inputs that caused it.
+func Hashes(paths []string) map[string]string {
+	hashes := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+			hashes[path] = "missing"
+		case err != nil:
+			hashes[path] = err.Error()
+		default:
+			sum := sha256.Sum256(data)
+			hashes[path] = hex.EncodeToString(sum[:])
+		}
+	}
+	return hashes
+}
+
+// Write saves report in dir as {stage}-{time}.json and returns its path.
+func Write(dir string, report Report) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	stamp := report.Timestamp
+	if t, err := time.Parse(time.RFC3339, stamp); err == nil {
+		stamp = t.Format("20060102T150405Z")
+	}
+	path := filepath.Join(dir, report.Stage+"-"+stamp+".json")
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding crash report: %w", err)
+	}
+	return path, os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,64 @@
+package crash
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- Guard ---
+
+func TestGuard_WritesReportAndExits(t *testing.T) {
+	reports := t.TempDir()
+	input := filepath.Join(t.TempDir(), "input.json")
+	os.WriteFile(input, []byte("abc"), 0644)
+
+	code := -1
+	dir, exit, now = reports, func(c int) { code = c }, func() time.Time {
+		return time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	}
+	t.Cleanup(func() { dir, exit, now = DefaultDir, os.Exit, time.Now })
+
+	func() {
+		defer Guard("filter", input, filepath.Join(reports, "absent.json"))
+		var m map[string]int
+		m["AAPL"]++
+	}()
+
+	if code != ExitCode {
+		t.Errorf("exit code %d, want %d", code, ExitCode)
+	}
+	data, err := os.ReadFile(filepath.Join(reports, "filter-20260310T150000Z.json"))
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if r.Stage != "filter" || !strings.Contains(r.Panic, "nil map") || !strings.Contains(r.Stack, "crash_test.go") {
+		t.Errorf("got stage=%q panic=%q", r.Stage, r.Panic)
+	}
+	if got := r.Inputs[input]; got != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("input hash: got %s", got)
+	}
+	if got := r.Inputs[filepath.Join(reports, "absent.json")]; got != "missing" {
+		t.Errorf("absent input: got %s", got)
+	}
+}
+
+func TestGuard_NoPanic(t *testing.T) {
+	called := false
+	exit = func(int) { called = true }
+	t.Cleanup(func() { exit = os.Exit })
+
+	func() {
+		defer Guard("fetch")
+	}()
+	if called {
+		t.Error("exited without a panic")
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/deanturpin/lft2/internal/crash
+
+go 1.21
+
+require github.com/deanturpin/lft2/internal/schema v0.0.0
+
+replace (
+	github.com/deanturpin/lft2/internal/schema => ../../internal/schema
+	github.com/deanturpin/lft2/internal/version => ../../internal/version
+)
@@ -0,0 +1,81 @@
+package dashboard
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// Line is one series on a chart, values in order.
+type Line struct {
+	Label  string
+	Values []float64
+}
+
+// Chart is a captioned line chart, rendered as inline SVG so pages stay
+// self-contained. Each line spans the full width, so series of different
+// lengths compare by shape rather than by time.
+type Chart struct {
+	Caption string
+	Lines   []Line
+}
+
+// Chart geometry in SVG user units; the SVG scales to the page width.
+const (
+	chartWidth  = 800
+	chartHeight = 240
+	chartPad    = 8
+)
+
+// palette colours lines in order, repeating for more than six.
+var palette = []string{"#6cb6ff", "#7fd962", "#e6b450", "#ff6666", "#c38fff", "#5ccfe6"}
+
+// svg renders the chart's lines and legend.
+func (c Chart) svg() template.HTML {
+	lo, hi := 0.0, 0.0
+	first := true
+	for _, l := range c.Lines {
+		for _, v := range l.Values {
+			if first || v < lo {
+				lo = v
+			}
+			if first || v > hi {
+				hi = v
+			}
+			first = false
+		}
+	}
+	if hi == lo {
+		lo, hi = lo-1, hi+1
+	}
+
+	x := func(i, n int) float64 {
+		if n < 2 {
+			return chartWidth / 2
+		}
+		return chartPad + float64(i)/float64(n-1)*(chartWidth-2*chartPad)
+	}
+	y := func(v float64) float64 {
+		return chartPad + (hi-v)/(hi-lo)*(chartHeight-2*chartPad)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg viewBox="0 0 %d %d" preserveAspectRatio="none" role="img">`, chartWidth, chartHeight)
+	for i, l := range c.Lines {
+		points := make([]string, len(l.Values))
+		for j, v := range l.Values {
+			points[j] = fmt.Sprintf("%.1f,%.1f", x(j, len(l.Values)), y(v))
+		}
+		fmt.Fprintf(&sb, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"><title>%s</title></polyline>`,
+			palette[i%len(palette)], strings.Join(points, " "), html.EscapeString(l.Label))
+	}
+	sb.WriteString(`</svg><div class="legend">`)
+	for i, l := range c.Lines {
+		fmt.Fprintf(&sb, `<span><i style="background:%s"></i>%s</span>`, palette[i%len(palette)], html.EscapeString(l.Label))
+	}
+	sb.WriteString(`</div>`)
+
+	// Labels are escaped above and everything else is generated here
+	return template.HTML(sb.String())
+}
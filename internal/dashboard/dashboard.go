@@ -0,0 +1,93 @@
+// Package dashboard renders the static HTML report pages published to
+// GitHub Pages. Every page shares one embedded layout and stylesheet, so the
+// dark/light theme toggle and the mobile card layout apply everywhere.
+package dashboard
+
+import (
+	_ "embed"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+//go:embed layout.html
+var layoutHTML string
+
+//go:embed style.css
+var styleCSS string
+
+var layout = template.Must(template.New("layout").Parse(layoutHTML))
+
+// Stat is a headline figure shown in the summary grid.
+type Stat struct {
+	Label string
+	Value string
+	Class string // optional: good, bad, warn, buy, sell
+}
+
+// Cell is a table cell. Class styles the cell; Bold emphasises it; Href
+// makes it a link; Spark draws a sparkline after the text; Title is shown
+// on hover, such as the raw identifier behind a label.
+type Cell struct {
+	Text  string
+	Class string
+	Bold  bool
+	Href  string
+	Spark *Spark
+	Title string
+}
+
+// Table is a captioned table. Empty is shown in place of the table when
+// there are no rows.
+type Table struct {
+	Caption string
+	Headers []string
+	Rows    [][]Cell
+	Empty   string
+}
+
+// Page is a complete report page.
+type Page struct {
+	Title       string
+	Subtitle    string
+	Stats       []Stat
+	Charts      []Chart
+	Tables      []Table
+	GeneratedAt time.Time
+}
+
+// Render returns the page as a standalone HTML document.
+func Render(p Page) (string, error) {
+	if p.GeneratedAt.IsZero() {
+		p.GeneratedAt = time.Now()
+	}
+
+	type chart struct {
+		Caption string
+		SVG     template.HTML
+	}
+	charts := make([]chart, len(p.Charts))
+	for i, c := range p.Charts {
+		charts[i] = chart{Caption: c.Caption, SVG: c.svg()}
+	}
+
+	data := struct {
+		Page
+		CSS         template.CSS
+		Charts      []chart
+		GeneratedAt string
+	}{
+		Page:        p,
+		CSS:         template.CSS(styleCSS),
+		Charts:      charts,
+		GeneratedAt: tz.Format(p.GeneratedAt),
+	}
+
+	var sb strings.Builder
+	if err := layout.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
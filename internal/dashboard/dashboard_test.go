@@ -0,0 +1,140 @@
+package dashboard
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender_StatsAndTable(t *testing.T) {
+	html, err := Render(Page{
+		Title:    "Daily Trading Summary",
+		Subtitle: "Date: 2026-03-10",
+		Stats:    []Stat{{Label: "Buys", Value: "2", Class: "buy"}},
+		Tables: []Table{{
+			Headers: []string{"Symbol", "Side"},
+			Rows:    [][]Cell{{{Text: "AAPL", Bold: true}, {Text: "buy", Class: "buy"}}},
+		}},
+		GeneratedAt: time.Date(2026, 3, 10, 16, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+
+	for _, want := range []string{
+		"<title>Daily Trading Summary - Date: 2026-03-10</title>",
+		`<div class="stat-value buy">2</div>`,
+		`<td data-label="Symbol"><strong>AAPL</strong></td>`,
+		`<td data-label="Side" class="buy">buy</td>`,
+		"toggleTheme()",
+		"--bg: #0a0e14",
+		"2026-03-10 12:00:00 EDT",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+}
+
+func TestRender_EmptyTable(t *testing.T) {
+	html, err := Render(Page{
+		Title:  "Summary",
+		Tables: []Table{{Headers: []string{"Symbol"}, Empty: "No trades executed today"}},
+	})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if !strings.Contains(html, `<div class="empty">No trades executed today</div>`) {
+		t.Error("empty message not rendered")
+	}
+	if strings.Contains(html, "<table>") {
+		t.Error("table rendered with no rows")
+	}
+}
+
+func TestRender_EscapesContent(t *testing.T) {
+	html, err := Render(Page{
+		Title:  "Summary",
+		Tables: []Table{{Headers: []string{"Note"}, Rows: [][]Cell{{{Text: "<script>x</script>"}}}}},
+	})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if strings.Contains(html, "<script>x</script>") {
+		t.Error("cell text was not HTML-escaped")
+	}
+}
+
+func TestRender_LinkCell(t *testing.T) {
+	html, err := Render(Page{
+		Title:  "Index",
+		Tables: []Table{{Headers: []string{"Artifact"}, Rows: [][]Cell{{{Text: "candidates.html", Href: "candidates.html"}}}}},
+	})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if !strings.Contains(html, `<a href="candidates.html">candidates.html</a>`) {
+		t.Error("link cell not rendered as anchor")
+	}
+}
+
+func TestRender_Chart(t *testing.T) {
+	html, err := Render(Page{
+		Title: "Strategies",
+		Charts: []Chart{{
+			Caption: "Equity Curves",
+			Lines: []Line{
+				{Label: "gap_fill", Values: []float64{1, 1.01, 0.99}},
+				{Label: "<momentum>", Values: []float64{1}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+
+	for _, want := range []string{
+		"<h2>Equity Curves</h2>",
+		`<polyline fill="none" stroke="#6cb6ff"`,
+		"8.0,120.0 400.0,8.0 792.0,232.0", // first point mid-range, then max, then min
+		"400.0,120.0",                     // single point centred
+		"&lt;momentum&gt;",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+	if strings.Contains(html, "<momentum>") {
+		t.Error("line label was not HTML-escaped")
+	}
+}
+
+func TestRender_Spark(t *testing.T) {
+	nan := math.NaN()
+	html, err := Render(Page{
+		Title: "Data Quality",
+		Tables: []Table{{Headers: []string{"Close", "Bars"}, Rows: [][]Cell{{
+			{Spark: &Spark{Values: []float64{1, 2, nan, 3}, Marks: []int{2}}},
+			{Text: "78", Spark: &Spark{Values: []float64{78, 0}, Columns: true, Marks: []int{1}}},
+		}}}},
+	})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+
+	for _, want := range []string{
+		`<svg class="spark"`,
+		`points="16.5,22.0 45.5,12.0"`, // the line breaks at the gap
+		`points="103.5,2.0"`,
+		`fill="var(--bad)"`,
+		`78<svg class="spark"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+	if n := strings.Count(html, `fill="var(--accent)"`); n != 1 {
+		t.Errorf("got %d columns, want 1 (a zero count draws nothing)", n)
+	}
+}
@@ -0,0 +1,7 @@
+module github.com/deanturpin/lft2/internal/dashboard
+
+go 1.21
+
+require github.com/deanturpin/lft2/internal/tz v0.0.0
+
+replace github.com/deanturpin/lft2/internal/tz => ../../internal/tz
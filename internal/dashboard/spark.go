@@ -0,0 +1,88 @@
+package dashboard
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+)
+
+// Spark is a small inline chart drawn in a table cell: a line through
+// Values, or a column per value when Columns is set. A NaN value is a gap —
+// the line breaks there. Marks are indices drawn in the bad colour, such as
+// missing days.
+type Spark struct {
+	Values  []float64
+	Columns bool
+	Marks   []int
+}
+
+// Sparkline geometry in SVG user units.
+const (
+	sparkWidth  = 120
+	sparkHeight = 24
+	sparkPad    = 2
+)
+
+// SVG renders the sparkline. It's called from the layout template.
+func (s *Spark) SVG() template.HTML {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range s.Values {
+		if !math.IsNaN(v) {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	if s.Columns {
+		lo = 0
+	}
+	if math.IsInf(lo, 1) {
+		lo, hi = 0, 1
+	}
+	if hi == lo {
+		lo, hi = lo-1, hi+1
+	}
+
+	n := len(s.Values)
+	step := float64(sparkWidth-2*sparkPad) / float64(max(n, 1))
+	x := func(i int) float64 { return sparkPad + (float64(i)+0.5)*step }
+	y := func(v float64) float64 {
+		return sparkPad + (hi-v)/(hi-lo)*(sparkHeight-2*sparkPad)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg class="spark" viewBox="0 0 %d %d" preserveAspectRatio="none">`, sparkWidth, sparkHeight)
+	for _, i := range s.Marks {
+		if i >= 0 && i < n {
+			fmt.Fprintf(&sb, `<rect x="%.1f" y="0" width="%.1f" height="%d" fill="var(--bad)" opacity="0.5"/>`,
+				x(i)-step/2, step, sparkHeight)
+		}
+	}
+	if s.Columns {
+		for i, v := range s.Values {
+			if !math.IsNaN(v) && v > 0 {
+				fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="var(--accent)"/>`,
+					x(i)-step*0.4, y(v), step*0.8, sparkHeight-sparkPad-y(v))
+			}
+		}
+	} else {
+		var points []string
+		flush := func() {
+			if len(points) > 0 {
+				fmt.Fprintf(&sb, `<polyline fill="none" stroke="var(--accent)" stroke-width="1.5" points="%s"/>`, strings.Join(points, " "))
+			}
+			points = nil
+		}
+		for i, v := range s.Values {
+			if math.IsNaN(v) {
+				flush()
+				continue
+			}
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(v)))
+		}
+		flush()
+	}
+	sb.WriteString(`</svg>`)
+
+	// Only numbers are interpolated
+	return template.HTML(sb.String())
+}
@@ -0,0 +1,131 @@
+// Package events publishes pipeline output to an optional message bus, for
+// deployments that split the stages across machines or containers. Files in
+// docs/ stay the source of truth and the default flow; with LFT2_EVENT_BUS
+// set, fetch also publishes each symbol's bars, signals each order intent
+// and summary each new fill, and `lft2 listen` on another host writes the
+// bars back out as files for its own filter and backtest.
+//
+// NATS (nats://) and Redis pub/sub (redis://) are supported, each through a
+// minimal client over its text protocol so there are no dependencies.
+// Delivery is at most once, as both buses give it: a subscriber that's down
+// misses events, and the next cycle's replace them.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// Event kinds, the second token of the subject.
+const (
+	Bars    = "bars"    // A symbol's bar file as fetch wrote it
+	Signals = "signals" // One order intent from buy.fix or sell.fix
+	Fills   = "fills"   // One fill from the daily summary
+)
+
+// timeout bounds connecting and each publish round trip.
+const timeout = 10 * time.Second
+
+// Bus is a connection to a message bus.
+type Bus interface {
+	// Publish sends data on subject and waits for the bus to accept it.
+	Publish(subject string, data []byte) error
+
+	// Subscribe calls handle with each message on subjects matching pattern
+	// until the connection closes or handle returns an error. In a pattern
+	// * matches one subject token and a trailing > the rest.
+	Subscribe(pattern string, handle func(subject string, data []byte) error) error
+
+	Close() error
+}
+
+// Event is the envelope every message is sent in.
+type Event struct {
+	schema.Header
+	Kind      string          `json:"kind"`
+	Key       string          `json:"key"` // The symbol
+	Timestamp string          `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Subject is where events of kind for key are published: lft2.bars.AAPL.
+// Dots in the key would split it into tokens, so they become underscores
+// (BRK.B publishes on lft2.bars.BRK_B).
+func Subject(kind, key string) string {
+	return "lft2." + kind + "." + strings.ReplaceAll(key, ".", "_")
+}
+
+// Publish wraps v in an event and publishes it. Data that's already JSON,
+// such as a bar file, is sent as is.
+func Publish(bus Bus, kind, key string, v any, now time.Time) error {
+	data, ok := v.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return fmt.Errorf("encoding %s event: %w", kind, err)
+		}
+	}
+	body, err := json.Marshal(Event{
+		Header:    schema.Current(),
+		Kind:      kind,
+		Key:       key,
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", kind, err)
+	}
+	return bus.Publish(Subject(kind, key), body)
+}
+
+// Decode reads an event, rejecting one from a newer schema.
+func Decode(subject string, data []byte) (Event, error) {
+	if err := schema.Check(subject, data); err != nil {
+		return Event{}, err
+	}
+	var e Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Event{}, fmt.Errorf("parsing %s: %w", subject, err)
+	}
+	return e, nil
+}
+
+// Dial connects to the bus at rawURL: nats://[user:pass@]host[:4222] or
+// redis://[:pass@]host[:6379].
+func Dial(rawURL string) (Bus, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("event bus URL: %w", err)
+	}
+	switch u.Scheme {
+	case "nats":
+		return dialNATS(u)
+	case "redis":
+		return dialRedis(u)
+	}
+	return nil, fmt.Errorf("event bus %q: want a nats:// or redis:// URL", rawURL)
+}
+
+// FromEnv connects to the bus in LFT2_EVENT_BUS, or returns nil when it's
+// unset and events are off.
+func FromEnv() (Bus, error) {
+	raw := os.Getenv("LFT2_EVENT_BUS")
+	if raw == "" {
+		return nil, nil
+	}
+	return Dial(raw)
+}
+
+// hostPort adds the default port when the URL has none.
+func hostPort(u *url.URL, port string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return u.Hostname() + ":" + port
+}
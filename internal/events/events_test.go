@@ -0,0 +1,258 @@
+package events
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer accepts one connection and runs serve on it.
+func fakeServer(t *testing.T, serve func(r *bufio.Reader, w io.Writer)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serve(bufio.NewReader(conn), conn)
+	}()
+	return ln.Addr().String()
+}
+
+func readLine(r *bufio.Reader) string {
+	line, _ := r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
+
+// recorder is a Bus that keeps what's published.
+type recorder struct {
+	subjects []string
+	bodies   [][]byte
+}
+
+func (r *recorder) Publish(subject string, data []byte) error {
+	r.subjects = append(r.subjects, subject)
+	r.bodies = append(r.bodies, data)
+	return nil
+}
+
+func (r *recorder) Subscribe(string, func(string, []byte) error) error { return nil }
+func (r *recorder) Close() error                                       { return nil }
+
+// --- Subject / Publish / Decode ---
+
+func TestSubject(t *testing.T) {
+	if got := Subject(Bars, "AAPL"); got != "lft2.bars.AAPL" {
+		t.Errorf("got %q", got)
+	}
+	if got := Subject(Bars, "BRK.B"); got != "lft2.bars.BRK_B" {
+		t.Errorf("dotted symbol: got %q", got)
+	}
+}
+
+func TestPublish_RoundTrip(t *testing.T) {
+	bus := &recorder{}
+	now := time.Date(2026, 3, 10, 15, 5, 40, 0, time.UTC)
+	if err := Publish(bus, Bars, "AAPL", []byte(`{"symbol":"AAPL","bars":[]}`), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := Publish(bus, Fills, "AAPL", map[string]string{"side": "buy"}, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(bus.subjects) != "[lft2.bars.AAPL lft2.fills.AAPL]" {
+		t.Errorf("subjects: got %v", bus.subjects)
+	}
+	e, err := Decode(bus.subjects[0], bus.bodies[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Kind != Bars || e.Key != "AAPL" || e.Timestamp != "2026-03-10T15:05:40Z" || string(e.Data) != `{"symbol":"AAPL","bars":[]}` {
+		t.Errorf("got %+v", e)
+	}
+	if e, _ := Decode(bus.subjects[1], bus.bodies[1]); string(e.Data) != `{"side":"buy"}` {
+		t.Errorf("fill data: got %s", e.Data)
+	}
+
+	if _, err := Decode("x", []byte(`{"schema_version": 99}`)); err == nil {
+		t.Error("an event from a newer schema should be rejected")
+	}
+}
+
+// --- Dial / FromEnv ---
+
+func TestDial_UnknownScheme(t *testing.T) {
+	if _, err := Dial("kafka://localhost:9092"); err == nil {
+		t.Error("kafka:// should be rejected")
+	}
+}
+
+func TestFromEnv_Unset(t *testing.T) {
+	t.Setenv("LFT2_EVENT_BUS", "")
+	if bus, err := FromEnv(); bus != nil || err != nil {
+		t.Errorf("got %v, %v; want no bus", bus, err)
+	}
+}
+
+// --- NATS ---
+
+func TestNATS(t *testing.T) {
+	got := make(chan string, 4)
+	addr := fakeServer(t, func(r *bufio.Reader, w io.Writer) {
+		io.WriteString(w, "INFO {\"server_id\":\"test\"}\r\n")
+		got <- readLine(r) // CONNECT
+		readLine(r)        // PING
+		io.WriteString(w, "PONG\r\n")
+
+		got <- readLine(r) + " " + readLine(r) // PUB and its payload
+		readLine(r)
+		io.WriteString(w, "PING\r\nPONG\r\n") // The server's own ping first
+		if pong := readLine(r); pong != "PONG" {
+			got <- "no PONG to the server's PING: " + pong
+			return
+		}
+
+		got <- readLine(r) // SUB
+		io.WriteString(w, "MSG lft2.bars.AAPL 1 5\r\nhello\r\n")
+	})
+
+	bus, err := Dial("nats://lft2:secret@" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bus.Close()
+	if connect := <-got; !strings.Contains(connect, `"user":"lft2"`) || !strings.Contains(connect, `"pass":"secret"`) {
+		t.Errorf("CONNECT without credentials: %s", connect)
+	}
+
+	if err := bus.Publish("lft2.fills.AAPL", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if pub := <-got; pub != "PUB lft2.fills.AAPL 2 {}" {
+		t.Errorf("got %q", pub)
+	}
+
+	stop := errors.New("stop")
+	var subject, data string
+	err = bus.Subscribe("lft2.bars.*", func(s string, d []byte) error {
+		subject, data = s, string(d)
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if sub := <-got; sub != "SUB lft2.bars.* 1" {
+		t.Errorf("got %q", sub)
+	}
+	if subject != "lft2.bars.AAPL" || data != "hello" {
+		t.Errorf("message: got %s %q", subject, data)
+	}
+}
+
+func TestNATS_PublishError(t *testing.T) {
+	addr := fakeServer(t, func(r *bufio.Reader, w io.Writer) {
+		io.WriteString(w, "INFO {}\r\n")
+		readLine(r)
+		readLine(r)
+		io.WriteString(w, "PONG\r\n")
+		readLine(r)
+		readLine(r)
+		readLine(r)
+		io.WriteString(w, "-ERR 'Permissions Violation for Publish'\r\n")
+	})
+
+	bus, err := Dial("nats://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bus.Close()
+	if err := bus.Publish("lft2.fills.AAPL", []byte("{}")); err == nil || !strings.Contains(err.Error(), "Permissions Violation") {
+		t.Errorf("got %v, want the server's error", err)
+	}
+}
+
+// --- Redis ---
+
+// readCommand reads a RESP array of bulk strings.
+func readCommand(r *bufio.Reader) []string {
+	n, _ := strconv.Atoi(strings.TrimPrefix(readLine(r), "*"))
+	args := make([]string, n)
+	for i := range args {
+		readLine(r) // $len
+		args[i] = readLine(r)
+	}
+	return args
+}
+
+func TestRedis(t *testing.T) {
+	got := make(chan string, 3)
+	addr := fakeServer(t, func(r *bufio.Reader, w io.Writer) {
+		got <- strings.Join(readCommand(r), " ")
+		io.WriteString(w, "+OK\r\n")
+		got <- strings.Join(readCommand(r), " ")
+		io.WriteString(w, ":2\r\n")
+		got <- strings.Join(readCommand(r), " ")
+		io.WriteString(w, "*3\r\n$10\r\npsubscribe\r\n$6\r\nlft2.*\r\n:1\r\n")
+		io.WriteString(w, "*4\r\n$8\r\npmessage\r\n$6\r\nlft2.*\r\n$15\r\nlft2.fills.MSFT\r\n$2\r\n{}\r\n")
+	})
+
+	bus, err := Dial("redis://:secret@" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bus.Close()
+	if auth := <-got; auth != "AUTH secret" {
+		t.Errorf("got %q", auth)
+	}
+
+	if err := bus.Publish("lft2.fills.AAPL", []byte(`{"a": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if pub := <-got; pub != `PUBLISH lft2.fills.AAPL {"a": 1}` {
+		t.Errorf("got %q", pub)
+	}
+
+	stop := errors.New("stop")
+	var subject string
+	err = bus.Subscribe("lft2.>", func(s string, d []byte) error {
+		subject = s
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if sub := <-got; sub != "PSUBSCRIBE lft2.*" {
+		t.Errorf("got %q", sub)
+	}
+	if subject != "lft2.fills.MSFT" {
+		t.Errorf("message: got %s", subject)
+	}
+}
+
+func TestRedis_PublishError(t *testing.T) {
+	addr := fakeServer(t, func(r *bufio.Reader, w io.Writer) {
+		readCommand(r)
+		io.WriteString(w, "-NOAUTH Authentication required.\r\n")
+	})
+
+	bus, err := Dial("redis://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bus.Close()
+	if err := bus.Publish("lft2.fills.AAPL", []byte("{}")); err == nil || !strings.Contains(err.Error(), "NOAUTH") {
+		t.Errorf("got %v, want the server's error", err)
+	}
+}
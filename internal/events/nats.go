@@ -0,0 +1,145 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// natsBus speaks the NATS client protocol: PUB and SUB commands, answered
+// with MSG, and PING/PONG to confirm the server has processed what came
+// before.
+type natsBus struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialNATS(u *url.URL) (*natsBus, error) {
+	conn, err := net.DialTimeout("tcp", hostPort(u, "4222"), timeout)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to NATS: %w", err)
+	}
+	b := &natsBus{conn: conn, r: bufio.NewReader(conn)}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// The server speaks first
+	line, err := b.line()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NATS handshake: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("NATS handshake: got %q, want INFO", line)
+	}
+
+	opts := map[string]any{"verbose": false, "pedantic": false, "name": "lft2", "lang": "go"}
+	if user := u.User; user != nil {
+		if pass, ok := user.Password(); ok {
+			opts["user"], opts["pass"] = user.Username(), pass
+		} else {
+			opts["auth_token"] = user.Username()
+		}
+	}
+	connect, _ := json.Marshal(opts)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\n", connect); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NATS handshake: %w", err)
+	}
+	if err := b.flush(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NATS handshake: %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+	return b, nil
+}
+
+// line reads one protocol line without its CRLF.
+func (b *natsBus) line() (string, error) {
+	line, err := b.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// flush sends PING and waits for the PONG, so an -ERR for anything sent
+// before it is seen here.
+func (b *natsBus) flush() error {
+	if _, err := io.WriteString(b.conn, "PING\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := b.line()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := io.WriteString(b.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (b *natsBus) Publish(subject string, data []byte) error {
+	b.conn.SetDeadline(time.Now().Add(timeout))
+	defer b.conn.SetDeadline(time.Time{})
+	if _, err := fmt.Fprintf(b.conn, "PUB %s %d\r\n%s\r\n", subject, len(data), data); err != nil {
+		return fmt.Errorf("publishing %s: %w", subject, err)
+	}
+	if err := b.flush(); err != nil {
+		return fmt.Errorf("publishing %s: %w", subject, err)
+	}
+	return nil
+}
+
+func (b *natsBus) Subscribe(pattern string, handle func(subject string, data []byte) error) error {
+	if _, err := fmt.Fprintf(b.conn, "SUB %s 1\r\n", pattern); err != nil {
+		return fmt.Errorf("subscribing to %s: %w", pattern, err)
+	}
+	for {
+		line, err := b.line()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PING":
+			if _, err := io.WriteString(b.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			f := strings.Fields(line)
+			n, err := strconv.Atoi(f[len(f)-1])
+			if len(f) < 4 || err != nil {
+				return fmt.Errorf("malformed NATS message %q", line)
+			}
+			data := make([]byte, n+2)
+			if _, err := io.ReadFull(b.r, data); err != nil {
+				return err
+			}
+			if err := handle(f[1], data[:n]); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (b *natsBus) Close() error {
+	return b.conn.Close()
+}
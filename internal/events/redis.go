@@ -0,0 +1,147 @@
+package events
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redisBus speaks enough RESP for Redis pub/sub: PUBLISH, PSUBSCRIBE and
+// AUTH.
+type redisBus struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialRedis(u *url.URL) (*redisBus, error) {
+	conn, err := net.DialTimeout("tcp", hostPort(u, "6379"), timeout)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to Redis: %w", err)
+	}
+	b := &redisBus{conn: conn, r: bufio.NewReader(conn)}
+
+	if user := u.User; user != nil {
+		args := []string{"AUTH"}
+		if pass, ok := user.Password(); ok {
+			if user.Username() != "" {
+				args = append(args, user.Username())
+			}
+			args = append(args, pass)
+		} else {
+			args = append(args, user.Username())
+		}
+		if _, err := b.call(args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("Redis AUTH: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// call sends a command and reads its reply.
+func (b *redisBus) call(args ...string) (any, error) {
+	b.conn.SetDeadline(time.Now().Add(timeout))
+	defer b.conn.SetDeadline(time.Time{})
+	if err := b.send(args...); err != nil {
+		return nil, err
+	}
+	return b.read()
+}
+
+// send writes a command as a RESP array of bulk strings.
+func (b *redisBus) send(args ...string) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(a), a)
+	}
+	_, err := io.WriteString(b.conn, sb.String())
+	return err
+}
+
+// read decodes one RESP value: a string, an int64, nil or a []any. An error
+// reply is returned as the error.
+func (b *redisBus) read() (any, error) {
+	line, err := b.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty Redis reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(b.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = b.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected Redis reply %q", line)
+}
+
+func (b *redisBus) Publish(subject string, data []byte) error {
+	if _, err := b.call("PUBLISH", subject, string(data)); err != nil {
+		return fmt.Errorf("publishing %s: %w", subject, err)
+	}
+	return nil
+}
+
+// Subscribe maps the NATS-style pattern to a Redis glob: both wildcards
+// become *, which in Redis also matches across dots.
+func (b *redisBus) Subscribe(pattern string, handle func(subject string, data []byte) error) error {
+	glob := strings.ReplaceAll(pattern, ">", "*")
+	if err := b.send("PSUBSCRIBE", glob); err != nil {
+		return fmt.Errorf("subscribing to %s: %w", pattern, err)
+	}
+	for {
+		v, err := b.read()
+		if err != nil {
+			return err
+		}
+		// pmessage <pattern> <channel> <payload>; the subscribe confirmation
+		// and anything else is ignored
+		msg, ok := v.([]any)
+		if !ok || len(msg) != 4 || msg[0] != "pmessage" {
+			continue
+		}
+		channel, _ := msg[2].(string)
+		payload, _ := msg[3].(string)
+		if err := handle(channel, []byte(payload)); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *redisBus) Close() error {
+	return b.conn.Close()
+}
@@ -0,0 +1,126 @@
+// Package fees models the costs a fill incurs beyond its price, so P&L in
+// the summary matches the broker's statements to the cent. Alpaca charges
+// no commission but passes through the SEC fee and FINRA Trading Activity
+// Fee on every sale.
+package fees
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Model returns the total fee in USD for a fill.
+type Model interface {
+	Fee(side string, qty, price float64) float64
+}
+
+// Schedule is a fee model built from per-fill rates. Regulatory fees apply
+// to sells only; commissions apply to both sides.
+type Schedule struct {
+	SECRate     float64 // fraction of sale proceeds
+	TAFPerShare float64 // USD per share sold
+	TAFMax      float64 // cap on TAF per fill
+	PerOrder    float64 // flat commission per fill
+	PerShare    float64 // commission per share
+	MinFee      float64 // minimum commission per fill, when any commission applies
+}
+
+// Regulatory is the current US pass-through schedule with no commission:
+// SEC fee $27.80 per $1M sold, FINRA TAF $0.000166 per share capped at $8.30.
+var Regulatory = Schedule{SECRate: 27.80e-6, TAFPerShare: 0.000166, TAFMax: 8.30}
+
+// None charges nothing, for comparing against gross results.
+var None = Schedule{}
+
+// Fee returns the fee for one fill. Each regulatory component is rounded up
+// to the cent, as brokers do when passing them through.
+func (s Schedule) Fee(side string, qty, price float64) float64 {
+	qty = math.Abs(qty)
+
+	commission := s.PerOrder + s.PerShare*qty
+	if commission > 0 {
+		commission = math.Max(commission, s.MinFee)
+	}
+	fee := roundCents(commission)
+
+	if side == "sell" {
+		fee += ceilCents(s.SECRate * qty * price)
+		fee += ceilCents(math.Min(s.TAFPerShare*qty, s.tafCap()))
+	}
+	return roundCents(fee)
+}
+
+// tafCap returns the TAF cap, or no cap when unset.
+func (s Schedule) tafCap() float64 {
+	if s.TAFMax == 0 {
+		return math.Inf(1)
+	}
+	return s.TAFMax
+}
+
+// ceilCents rounds up to the next whole cent. The small epsilon stops
+// float error (0.10000000000000001) rounding an exact cent up.
+func ceilCents(x float64) float64 {
+	return math.Ceil(x*100-1e-9) / 100
+}
+
+func roundCents(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+// Open returns the model described by spec, a comma-separated list starting
+// with a base schedule and followed by optional overrides:
+//
+//	""                              Regulatory
+//	"none"                          no fees
+//	"regulatory,per_order=1"        regulatory fees plus $1 per fill
+//	"none,per_share=0.005,min=1"    commission only
+func Open(spec string) (Model, error) {
+	parts := strings.Split(strings.TrimSpace(spec), ",")
+
+	var s Schedule
+	switch strings.TrimSpace(parts[0]) {
+	case "", "regulatory":
+		s = Regulatory
+	case "none":
+		s = None
+	default:
+		return nil, fmt.Errorf("unknown fee schedule %q", parts[0])
+	}
+
+	for _, p := range parts[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if !ok {
+			return nil, fmt.Errorf("fee override %q: want key=value", p)
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("fee override %q: invalid amount", p)
+		}
+		switch key {
+		case "sec_rate":
+			s.SECRate = v
+		case "taf_per_share":
+			s.TAFPerShare = v
+		case "taf_max":
+			s.TAFMax = v
+		case "per_order":
+			s.PerOrder = v
+		case "per_share":
+			s.PerShare = v
+		case "min":
+			s.MinFee = v
+		default:
+			return nil, fmt.Errorf("unknown fee override %q", key)
+		}
+	}
+	return s, nil
+}
+
+// FromEnv returns the model named by LFT2_FEES, defaulting to Regulatory.
+func FromEnv() (Model, error) {
+	return Open(os.Getenv("LFT2_FEES"))
+}
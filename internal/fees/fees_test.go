@@ -0,0 +1,60 @@
+package fees
+
+import "testing"
+
+// --- Schedule.Fee ---
+
+func TestRegulatory_BuysAreFree(t *testing.T) {
+	if got := Regulatory.Fee("buy", 100, 50); got != 0 {
+		t.Errorf("got %.2f, want 0", got)
+	}
+}
+
+func TestRegulatory_Sell(t *testing.T) {
+	// $5,000 proceeds: SEC 0.139 → 0.14; TAF 100 × 0.000166 = 0.0166 → 0.02
+	if got := Regulatory.Fee("sell", 100, 50); got != 0.16 {
+		t.Errorf("got %.4f, want 0.16", got)
+	}
+}
+
+func TestRegulatory_TAFCap(t *testing.T) {
+	// 100k shares would be $16.60 TAF uncapped
+	got := Regulatory.Fee("sell", 100000, 1)
+	want := 2.78 + 8.30
+	if got != want {
+		t.Errorf("got %.2f, want %.2f", got, want)
+	}
+}
+
+func TestSchedule_CommissionMinimum(t *testing.T) {
+	s := Schedule{PerShare: 0.005, MinFee: 1}
+	if got := s.Fee("buy", 10, 100); got != 1 {
+		t.Errorf("got %.2f, want minimum 1.00", got)
+	}
+	if got := s.Fee("buy", 1000, 100); got != 5 {
+		t.Errorf("got %.2f, want 5.00", got)
+	}
+}
+
+// --- Open ---
+
+func TestOpen(t *testing.T) {
+	m, err := Open("")
+	if err != nil || m != Model(Regulatory) {
+		t.Errorf("empty spec: got %+v, %v; want Regulatory", m, err)
+	}
+
+	m, err = Open("none,per_order=1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := m.Fee("sell", 100, 50); got != 1 {
+		t.Errorf("got %.2f, want 1.00", got)
+	}
+
+	for _, bad := range []string{"ibkr", "regulatory,per_order", "regulatory,per_order=-1", "none,bogus=1"} {
+		if _, err := Open(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
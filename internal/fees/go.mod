@@ -0,0 +1,3 @@
+module github.com/deanturpin/lft2/internal/fees
+
+go 1.21
@@ -0,0 +1,138 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Day is one trading day of a symbol's bars, dated in New York.
+type Day struct {
+	Date  string
+	Bars  int
+	Close float64 // Last close of the day
+	Flat  bool    // More than one bar and every close the same
+}
+
+// market is the exchange timezone trading days are dated in.
+var market = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+// tradingDate is the New York date of a bar timestamp, or "" if it doesn't
+// parse.
+func tradingDate(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return ""
+	}
+	return t.In(market).Format(time.DateOnly)
+}
+
+// DataQuality is one symbol's bar feed health, for the data QA page. Days
+// is aligned to the market's trading days from the symbol's first; a day
+// with no bars has a zero count.
+type DataQuality struct {
+	Symbol  string
+	Days    []Day
+	Missing []int // Indices into Days with no bars
+	Flat    int   // Days whose close never moved
+	Dirty   int   // Bars with insane prices or no volume
+	Stale   string
+	Score   float64 // In [0, 1]
+	Issues  []string
+}
+
+// MarketDays is every date any symbol has bars on, in order. A holiday has
+// no bars anywhere, so it never counts as a gap.
+func MarketDays(digests []Digest) []string {
+	seen := map[string]bool{}
+	for _, d := range digests {
+		for _, day := range d.Days {
+			seen[day.Date] = true
+		}
+	}
+	days := make([]string, 0, len(seen))
+	for date := range seen {
+		days = append(days, date)
+	}
+	sort.Strings(days)
+	return days
+}
+
+// AssessQuality scores a symbol's bars against the market's trading days.
+// A day the market traded but the symbol has no bars for, from its first
+// day on, is a gap; trailing gaps mean the feed stopped. A day whose close
+// never moved is a flat line, usually a feed repeating its last print. The
+// score is the share of clean bars times the share of days present times
+// the share of those days that moved, so any one failing drags it down.
+func AssessQuality(d Digest, marketDays []string) DataQuality {
+	q := DataQuality{Symbol: d.Symbol, Dirty: d.Count - d.Clean}
+	if len(d.Days) == 0 {
+		q.Issues = []string{"no bars"}
+		if d.Count > 0 {
+			q.Issues = []string{"unreadable bar timestamps"}
+		}
+		return q
+	}
+
+	have := make(map[string]Day, len(d.Days))
+	for _, day := range d.Days {
+		have[day.Date] = day
+	}
+	for _, date := range marketDays {
+		if date < d.Days[0].Date {
+			continue
+		}
+		day, ok := have[date]
+		if !ok {
+			q.Missing = append(q.Missing, len(q.Days))
+			day = Day{Date: date}
+		}
+		if day.Flat {
+			q.Flat++
+		}
+		q.Days = append(q.Days, day)
+	}
+
+	// Days missing at the end mean the feed stopped, not a gap in the middle
+	last := len(q.Days) - 1
+	for last >= 0 && q.Days[last].Bars == 0 {
+		last--
+	}
+	if trailing := len(q.Days) - 1 - last; trailing > 0 {
+		q.Stale = q.Days[last].Date
+	}
+
+	present := len(q.Days) - len(q.Missing)
+	q.Score = float64(d.Clean) / float64(d.Count) *
+		float64(present) / float64(len(q.Days)) *
+		(1 - float64(q.Flat)/float64(present))
+	q.Score = math.Round(q.Score*1000) / 1000
+
+	if q.Stale != "" {
+		q.Issues = append(q.Issues, "no bars since "+q.Stale)
+	}
+	if gaps := len(q.Missing) - (len(q.Days) - 1 - last); gaps > 0 {
+		q.Issues = append(q.Issues, plural(gaps, "missing day"))
+	}
+	if q.Flat > 0 {
+		q.Issues = append(q.Issues, plural(q.Flat, "flat day"))
+	}
+	if q.Dirty > 0 {
+		q.Issues = append(q.Issues, plural(q.Dirty, "bad bar"))
+	}
+	return q
+}
+
+func plural(n int, what string) string {
+	if n == 1 {
+		return "1 " + what
+	}
+	return fmt.Sprintf("%d %ss", n, what)
+}
@@ -0,0 +1,34 @@
+package filter
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// ParseDataQuality reads data_quality.json (written by validate) and returns
+// each symbol it marked bad, mapped to its issues. Run demotes them.
+func ParseDataQuality(name string, data []byte) (map[string]string, error) {
+	if err := schema.Check(name, data); err != nil {
+		return nil, err
+	}
+	var report struct {
+		Symbols []struct {
+			Symbol string   `json:"symbol"`
+			Status string   `json:"status"`
+			Issues []string `json:"issues"`
+		} `json:"symbols"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	bad := map[string]string{}
+	for _, s := range report.Symbols {
+		if s.Status == "bad" {
+			bad[s.Symbol] = strings.Join(s.Issues, ", ")
+		}
+	}
+	return bad, nil
+}
@@ -0,0 +1,168 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Digest is what filter keeps of one symbol's bars: running aggregates and
+// the timestamps at either end. A scan of thousands of symbols holds one of
+// these per symbol rather than every bar, so memory grows with the universe,
+// not with its history.
+type Digest struct {
+	Symbol        string
+	Count         int
+	AvgVolume     float64
+	AvgPrice      float64
+	AvgVolatility float64 // Mean (high-low)/close
+	LastRangePct  float64 // Last bar's (high-low)/close in percent
+	Momentum      float64 // Return over the last momentumBars bars
+	Clean         int     // Bars with sane prices and non-zero volume
+	First, Last   string  // Bar timestamps
+	Days          []Day   // One per New York trading day with bars, in order
+}
+
+// digester accumulates a Digest one bar at a time. Sums are kept in the same
+// order as CalculateStats so both give identical results.
+type digester struct {
+	d      Digest
+	volume int64
+	price  float64
+	rng    float64
+	first  float64   // First close, the momentum base for short histories
+	recent []float64 // Ring of the last momentumBars+1 closes
+	lo, hi float64   // Close range of the current day
+}
+
+func (g *digester) add(b Bar) {
+	if g.d.Count == 0 {
+		g.d.First = b.Timestamp
+		g.first = b.Close
+	}
+	g.d.Count++
+	g.d.Last = b.Timestamp
+
+	g.volume += b.Volume
+	g.price += b.Close
+	if b.Close > 0 {
+		g.rng += (b.High - b.Low) / b.Close
+	}
+	g.d.LastRangePct = LastRangePct([]Bar{b})
+	if b.Low > 0 && b.High >= b.Low && b.Close >= b.Low && b.Close <= b.High && b.Volume > 0 {
+		g.d.Clean++
+	}
+
+	if g.recent == nil {
+		g.recent = make([]float64, momentumBars+1)
+	}
+	g.recent[(g.d.Count-1)%len(g.recent)] = b.Close
+
+	g.day(b)
+}
+
+// day counts b towards its trading day. Bars arrive in time order, so a new
+// date starts a new day.
+func (g *digester) day(b Bar) {
+	date := tradingDate(b.Timestamp)
+	if date == "" {
+		return
+	}
+	if n := len(g.d.Days); n == 0 || g.d.Days[n-1].Date != date {
+		g.d.Days = append(g.d.Days, Day{Date: date})
+		g.lo, g.hi = b.Close, b.Close
+	}
+	d := &g.d.Days[len(g.d.Days)-1]
+	d.Bars++
+	d.Close = b.Close
+	g.lo, g.hi = math.Min(g.lo, b.Close), math.Max(g.hi, b.Close)
+	d.Flat = d.Bars > 1 && g.lo == g.hi
+}
+
+func (g *digester) digest() Digest {
+	d := g.d
+	if d.Count == 0 {
+		return d
+	}
+	n := float64(d.Count)
+	d.AvgVolume = float64(g.volume) / n
+	d.AvgPrice = g.price / n
+	d.AvgVolatility = g.rng / n
+
+	// The close momentumBars before the last, or the first if there's less
+	// history than that
+	start := g.first
+	if d.Count > momentumBars {
+		start = g.recent[d.Count%len(g.recent)]
+	}
+	if d.Count >= 2 && start > 0 {
+		last := g.recent[(d.Count-1)%len(g.recent)]
+		d.Momentum = last/start - 1
+	}
+	return d
+}
+
+// Summarise digests bars already in memory.
+func Summarise(bd *BarData) Digest {
+	g := digester{d: Digest{Symbol: bd.Symbol}}
+	for _, b := range bd.Bars {
+		g.add(b)
+	}
+	return g.digest()
+}
+
+// ReadDigest digests a bar file as written by fetch, decoding one bar at a
+// time so the file's bars are never held together.
+func ReadDigest(r io.Reader) (Digest, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return Digest{}, err
+	}
+
+	var g digester
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return Digest{}, err
+		}
+		switch key, _ := tok.(string); key {
+		case "symbol":
+			if err := dec.Decode(&g.d.Symbol); err != nil {
+				return Digest{}, fmt.Errorf("symbol: %w", err)
+			}
+		case "bars":
+			if err := expectDelim(dec, '['); err != nil {
+				return Digest{}, fmt.Errorf("bars: %w", err)
+			}
+			for dec.More() {
+				var b Bar
+				if err := dec.Decode(&b); err != nil {
+					return Digest{}, fmt.Errorf("bar %d: %w", g.d.Count, err)
+				}
+				g.add(b)
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return Digest{}, fmt.Errorf("bars: %w", err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return Digest{}, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	return g.digest(), nil
+}
+
+// expectDelim consumes the next token, which must be delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package filter
+
+import (
+	"encoding/json"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// ParseFailures reads fetch-failures.json (written by fetch) and returns
+// each symbol fetch couldn't refresh, mapped to the cause.
+func ParseFailures(name string, data []byte) (map[string]string, error) {
+	if err := schema.Check(name, data); err != nil {
+		return nil, err
+	}
+	var report struct {
+		Failures []struct {
+			Symbol string `json:"symbol"`
+			Cause  string `json:"cause"`
+		} `json:"failures"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	failures := make(map[string]string, len(report.Failures))
+	for _, f := range report.Failures {
+		failures[f.Symbol] = f.Cause
+	}
+	return failures, nil
+}
@@ -0,0 +1,463 @@
+// Package filter screens the fetched universe for tradeable candidates:
+// per-symbol statistics, criteria derived from the market as a whole, asset
+// class and blocklist rejections, and the composite score that ranks what
+// passes. It does no I/O of its own beyond reading a previous backtest for
+// expectancy — callers load bars and metadata and write the Output.
+//
+// Symbols are screened from their Digest, so a caller scanning thousands of
+// symbols can stream each bar file through ReadDigest and keep only that.
+package filter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/assets"
+	"github.com/deanturpin/lft2/internal/blocklist"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// Criteria are the thresholds a symbol must meet to be a candidate.
+type Criteria struct {
+	MinAvgVolume   float64 `json:"min_avg_volume"`
+	MinPrice       float64 `json:"min_price"`
+	MaxPrice       float64 `json:"max_price"`
+	MinBarCount    int     `json:"min_bar_count"`
+	MaxBarRangePct float64 `json:"max_bar_range_pct"` // Max (high-low)/close on last bar — spread proxy
+	MaxSpreadPct   float64 `json:"max_spread_pct"`    // Max quoted spread, used instead where quoted
+
+	ExcludeClasses []string `json:"exclude_classes,omitempty"` // Asset classes rejected outright, see internal/assets
+	MinMarketCap   float64  `json:"min_market_cap,omitempty"`  // USD; only applied to equities with known market cap
+}
+
+// BarData is a per-symbol bar file as written by fetch.
+type BarData struct {
+	Symbol    string `json:"symbol"`
+	Bars      []Bar  `json:"bars"`
+	Count     int    `json:"count"`
+	FetchedAt string `json:"fetched_at"`
+}
+
+type Bar struct {
+	Timestamp string  `json:"t"`
+	Open      float64 `json:"o"`
+	High      float64 `json:"h"`
+	Low       float64 `json:"l"`
+	Close     float64 `json:"c"`
+	Volume    int64   `json:"v"`
+}
+
+type SymbolStats struct {
+	Symbol        string  `json:"symbol"`
+	AvgVolume     float64 `json:"avg_volume"`
+	AvgPrice      float64 `json:"avg_price"`
+	AvgVolatility float64 `json:"avg_volatility"`
+	LastRangePct  float64 `json:"last_bar_range_pct"`
+	SpreadPct     float64 `json:"spread_pct,omitempty"` // Median quoted spread once enough are sampled, else the latest
+	BarCount      int     `json:"bar_count"`
+	AssetClass    string  `json:"asset_class,omitempty"`
+	MarketCap     float64 `json:"market_cap,omitempty"`
+	Tradeable     bool    `json:"tradeable"`
+	SkipReason    string  `json:"skip_reason,omitempty"`
+	Demoted       string  `json:"demoted,omitempty"` // Still tradeable, ranked after every clean symbol
+
+	Score      float64     `json:"score,omitempty"` // Composite in [0, 1], tradeable symbols only
+	ScoreParts *ScoreParts `json:"score_parts,omitempty"`
+}
+
+// RankedCandidate is an entry in the score-ordered candidate list.
+type RankedCandidate struct {
+	Symbol string  `json:"symbol"`
+	Score  float64 `json:"score"`
+}
+
+type MarketStats struct {
+	VolumeMin    float64 `json:"volume_min"`
+	VolumeMax    float64 `json:"volume_max"`
+	VolumeMedian float64 `json:"volume_median"`
+	PriceMin     float64 `json:"price_min"`
+	PriceMax     float64 `json:"price_max"`
+	PriceMedian  float64 `json:"price_median"`
+	VolMin       float64 `json:"volatility_min"`
+	VolMax       float64 `json:"volatility_max"`
+	VolMedian    float64 `json:"volatility_median"`
+}
+
+// Output is the layout of candidates.json.
+type Output struct {
+	schema.Header
+	Timestamp       string            `json:"timestamp"`
+	FirstBarTime    string            `json:"first_bar_time"`
+	LastBarTime     string            `json:"last_bar_time"`
+	Symbols         []string          `json:"symbols"` // Ranked by score, best first
+	Ranked          []RankedCandidate `json:"ranked"`
+	ScoreWeights    ScoreWeights      `json:"score_weights"`
+	Criteria        Criteria          `json:"criteria"`
+	MarketStats     MarketStats       `json:"market_stats"`
+	AllSymbols      []SymbolStats     `json:"all_symbols"` // Highest volume first
+	TotalCandidates int               `json:"total_candidates"`
+
+	Blocked []blocklist.Entry `json:"blocked,omitempty"` // Blocklist entries applied this run
+}
+
+// Options are the operator-set parts of the criteria.
+type Options struct {
+	ExcludeClasses []string
+	MinMarketCap   float64
+}
+
+// Input is everything one run needs, loaded by the caller.
+type Input struct {
+	Bars       []*BarData             // One per scanned symbol, held in memory
+	Digests    []Digest               // One per scanned symbol, streamed; see ReadDigest
+	Assets     map[string]assets.Info // Optional class and market cap metadata
+	Blocks     *blocklist.List        // Optional
+	Expectancy map[string]float64     // Best viable backtest avg_profit by symbol, see LoadExpectancy
+	Failures   map[string]string      // Symbols fetch couldn't refresh, by cause; see ParseFailures
+	Integrity  map[string]string      // Bar files that failed manifest verification, by problem
+	Demoted    map[string]string      // Symbols with bad bar data, by issues; see ParseDataQuality
+	Spreads    map[string]float64     // Quoted spread in percent, see spreads.File.Screening
+	Options    Options
+	Weights    ScoreWeights // Zero value uses DefaultWeights
+	Now        time.Time
+}
+
+// CalculateStats returns average volume, close and (high-low)/close.
+func CalculateStats(bars []Bar) (avgVolume float64, avgPrice float64, avgVolatility float64) {
+	if len(bars) == 0 {
+		return 0, 0, 0
+	}
+
+	var totalVolume int64
+	var totalPrice float64
+	var totalRange float64
+
+	for _, bar := range bars {
+		totalVolume += bar.Volume
+		totalPrice += bar.Close
+		if bar.Close > 0 {
+			totalRange += (bar.High - bar.Low) / bar.Close
+		}
+	}
+
+	count := float64(len(bars))
+	avgVolume = float64(totalVolume) / count
+	avgPrice = totalPrice / count
+	avgVolatility = totalRange / count
+
+	return avgVolume, avgPrice, avgVolatility
+}
+
+// Reason returns "" if the symbol passes all criteria, or a short
+// explanation of the first failing check.
+// Bars are counted directly rather than trusting the file's count field,
+// which may lag behind a pruned or partially written file.
+func Reason(data *BarData, criteria Criteria) string {
+	return DigestReason(Summarise(data), criteria)
+}
+
+// DigestReason is Reason for a symbol already digested.
+func DigestReason(d Digest, criteria Criteria) string {
+	if r := liquidityReason(d, criteria); r != "" {
+		return r
+	}
+	return SpreadReason(d, 0, false, criteria)
+}
+
+// liquidityReason checks history, volume and price.
+func liquidityReason(d Digest, criteria Criteria) string {
+	if d.Count == 0 || d.Count < criteria.MinBarCount {
+		return fmt.Sprintf("insufficient bars (%d < %d)", d.Count, criteria.MinBarCount)
+	}
+
+	avgVolume, avgPrice := d.AvgVolume, d.AvgPrice
+
+	if avgVolume < criteria.MinAvgVolume {
+		return fmt.Sprintf("low volume (%.0f < %.0f)", avgVolume, criteria.MinAvgVolume)
+	}
+	if avgPrice < criteria.MinPrice {
+		return fmt.Sprintf("price too low ($%.2f < $%.2f)", avgPrice, criteria.MinPrice)
+	}
+	if avgPrice > criteria.MaxPrice {
+		return fmt.Sprintf("price too high ($%.2f > $%.2f)", avgPrice, criteria.MaxPrice)
+	}
+	return ""
+}
+
+// SpreadReason rejects a symbol whose spread is too wide. The quoted spread
+// is used when there is one; otherwise the last bar's range stands in.
+func SpreadReason(d Digest, quotedPct float64, quoted bool, criteria Criteria) string {
+	if quoted {
+		if quotedPct > criteria.MaxSpreadPct {
+			return fmt.Sprintf("quoted spread too wide (%.3f%% > %.2f%%)", quotedPct, criteria.MaxSpreadPct)
+		}
+		return ""
+	}
+	// Spread proxy: reject if the last bar's range is implausibly wide.
+	// For liquid stocks (high-low)/close is typically <0.4%; wide spreads
+	// or illiquid stocks produce much larger values.
+	if d.LastRangePct > criteria.MaxBarRangePct {
+		return fmt.Sprintf("spread too wide (%.3f%% > %.2f%%)", d.LastRangePct, criteria.MaxBarRangePct)
+	}
+
+	return ""
+}
+
+// LastRangePct is the last bar's (high-low)/close in percent, 0 without bars.
+func LastRangePct(bars []Bar) float64 {
+	if len(bars) == 0 {
+		return 0
+	}
+	last := bars[len(bars)-1]
+	if last.Close <= 0 {
+		return 0
+	}
+	return (last.High - last.Low) / last.Close * 100.0
+}
+
+// AssetReason returns "" unless the symbol's asset class is excluded or it
+// is a micro-cap. Symbols without metadata pass — enrichment is best-effort,
+// and funds have no meaningful market cap.
+func AssetReason(info assets.Info, known bool, criteria Criteria) string {
+	if !known {
+		return ""
+	}
+	for _, c := range criteria.ExcludeClasses {
+		if info.Class == c {
+			return "excluded class: " + c
+		}
+	}
+	isCompany := info.Class == assets.Equity || info.Class == assets.ADR
+	if isCompany && info.MarketCap > 0 && info.MarketCap < criteria.MinMarketCap {
+		return fmt.Sprintf("market cap too low ($%.0fM < $%.0fM)", info.MarketCap/1e6, criteria.MinMarketCap/1e6)
+	}
+	return ""
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// Market summarises the whole scanned universe, before any filtering.
+func Market(allStats []SymbolStats) MarketStats {
+	if len(allStats) == 0 {
+		return MarketStats{}
+	}
+
+	volumes := make([]float64, 0, len(allStats))
+	prices := make([]float64, 0, len(allStats))
+	volatilities := make([]float64, 0, len(allStats))
+
+	for _, s := range allStats {
+		volumes = append(volumes, s.AvgVolume)
+		prices = append(prices, s.AvgPrice)
+		volatilities = append(volatilities, s.AvgVolatility)
+	}
+
+	return MarketStats{
+		VolumeMin:    math.Floor(minFloat64(volumes)),
+		VolumeMax:    math.Ceil(maxFloat64(volumes)),
+		VolumeMedian: median(volumes),
+		PriceMin:     minFloat64(prices),
+		PriceMax:     maxFloat64(prices),
+		PriceMedian:  median(prices),
+		VolMin:       minFloat64(volatilities),
+		VolMax:       maxFloat64(volatilities),
+		VolMedian:    median(volatilities),
+	}
+}
+
+func minFloat64(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+func maxFloat64(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	max := values[0]
+	for _, v := range values[1:] {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// DeriveCriteria sets thresholds from the market statistics, so the screen
+// adapts to whatever universe fetch collected.
+func DeriveCriteria(m MarketStats, opts Options) Criteria {
+	return Criteria{
+		MinAvgVolume:   m.VolumeMedian * 0.5, // Half of median volume
+		MinPrice:       10.0,                 // Keep minimum price floor
+		MaxPrice:       m.PriceMax * 1.1,     // Allow all prices up to max + 10%
+		MinBarCount:    100,                  // Minimum history for reliable strategy signals
+		MaxBarRangePct: 0.5,                  // 50 bps — spread proxy from last bar range
+		MaxSpreadPct:   0.2,                  // 20 bps — median NBBO spread, a fifth of a typical 1% target
+		ExcludeClasses: opts.ExcludeClasses,
+		MinMarketCap:   opts.MinMarketCap,
+	}
+}
+
+// Run screens, scores and ranks the input universe. Only digests are kept,
+// whether the caller passed bars or digested them itself, so memory grows
+// with the number of symbols rather than their history.
+func Run(in Input) Output {
+	weights := in.Weights
+	if weights == (ScoreWeights{}) {
+		weights = DefaultWeights
+	}
+
+	all := append([]Digest(nil), in.Digests...)
+	for _, bd := range in.Bars {
+		all = append(all, Summarise(bd))
+	}
+
+	// First pass: statistics for every symbol. The market medians need every
+	// symbol's averages, but that's one float each, so they stay exact.
+	digests := make(map[string]Digest, len(all))
+	allStats := make([]SymbolStats, 0, len(all))
+	for _, d := range all {
+		digests[d.Symbol] = d
+		allStats = append(allStats, SymbolStats{
+			Symbol:        d.Symbol,
+			AvgVolume:     d.AvgVolume,
+			AvgPrice:      d.AvgPrice,
+			AvgVolatility: d.AvgVolatility,
+			BarCount:      d.Count,
+		})
+	}
+
+	market := Market(allStats)
+	criteria := DeriveCriteria(market, in.Options)
+
+	// Second pass: annotate every symbol with tradeable flag
+	var applied []blocklist.Entry
+	for i, stats := range allStats {
+		d := digests[stats.Symbol]
+		allStats[i].LastRangePct = d.LastRangePct
+		quoted, sampled := in.Spreads[stats.Symbol]
+		allStats[i].SpreadPct = quoted
+
+		info, known := in.Assets[stats.Symbol]
+		allStats[i].AssetClass = info.Class
+		allStats[i].MarketCap = info.MarketCap
+
+		reason := ""
+		if entry, blocked := check(in.Blocks, stats.Symbol, in.Now); blocked {
+			reason = "blocked: " + entry.Reason
+			applied = append(applied, entry)
+		} else if cause, failed := in.Failures[stats.Symbol]; failed {
+			reason = "fetch failed: " + cause + " (stale bars)"
+		} else if problem, bad := in.Integrity[stats.Symbol]; bad {
+			reason = "integrity: " + problem
+		} else if r := AssetReason(info, known, criteria); r != "" {
+			reason = r
+		} else if r := liquidityReason(d, criteria); r != "" {
+			reason = r
+		} else {
+			reason = SpreadReason(d, quoted, sampled, criteria)
+		}
+
+		allStats[i].Tradeable = reason == ""
+		allStats[i].SkipReason = reason
+		if issues, bad := in.Demoted[stats.Symbol]; bad && reason == "" {
+			allStats[i].Demoted = "data quality: " + issues
+		}
+	}
+
+	// Symbols with no usable bars at all, because fetch couldn't refresh them
+	// or their file failed verification, are listed too so missing data is
+	// explicit. They're added after the market statistics, which they have
+	// nothing to contribute to.
+	missing := map[string]string{}
+	for symbol, problem := range in.Integrity {
+		missing[symbol] = "integrity: " + problem
+	}
+	for symbol, cause := range in.Failures {
+		missing[symbol] = "fetch failed: " + cause
+	}
+	symbols := make([]string, 0, len(missing))
+	for symbol := range missing {
+		if _, ok := digests[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		allStats = append(allStats, SymbolStats{Symbol: symbol, SkipReason: missing[symbol]})
+	}
+
+	// Rank candidates by composite score so entries can take the best
+	// first when capital is limited
+	scoreSymbols(allStats, digests, in.Expectancy, criteria, weights)
+	ranked := rankCandidates(allStats)
+	candidates := make([]string, 0, len(ranked))
+	for _, c := range ranked {
+		candidates = append(candidates, c.Symbol)
+	}
+
+	// Sort symbols by volume (highest first) for readability
+	sort.SliceStable(allStats, func(i, j int) bool {
+		return allStats[i].AvgVolume > allStats[j].AvgVolume
+	})
+
+	// Earliest and latest bar timestamps across all symbols
+	var firstBarTime, lastBarTime string
+	for _, d := range all {
+		if d.Count > 0 {
+			if firstBarTime == "" || d.First < firstBarTime {
+				firstBarTime = d.First
+			}
+			if lastBarTime == "" || d.Last > lastBarTime {
+				lastBarTime = d.Last
+			}
+		}
+	}
+
+	return Output{
+		Header:          schema.Current(),
+		Timestamp:       in.Now.UTC().Format(time.RFC3339),
+		FirstBarTime:    firstBarTime,
+		LastBarTime:     lastBarTime,
+		Symbols:         candidates,
+		Ranked:          ranked,
+		ScoreWeights:    weights,
+		Criteria:        criteria,
+		MarketStats:     market,
+		AllSymbols:      allStats,
+		TotalCandidates: len(candidates),
+		Blocked:         applied,
+	}
+}
+
+// check applies the blocklist, if there is one.
+func check(blocks *blocklist.List, symbol string, now time.Time) (blocklist.Entry, bool) {
+	if blocks == nil {
+		return blocklist.Entry{}, false
+	}
+	return blocks.Check(symbol, now)
+}
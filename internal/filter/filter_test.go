@@ -0,0 +1,619 @@
+package filter
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"bytes"
+
+	"encoding/json"
+
+	"fmt"
+	"github.com/deanturpin/lft2/internal/assets"
+	"github.com/deanturpin/lft2/internal/blocklist"
+)
+
+// makeBar is a helper that creates a Bar with close=c, high=c+spread, low=c-spread.
+func makeBar(c, spread float64, volume int64) Bar {
+	return Bar{Close: c, High: c + spread, Low: c - spread, Volume: volume}
+}
+
+// makeBars returns n identical bars.
+func makeBars(n int, c, spread float64, volume int64) []Bar {
+	bars := make([]Bar, n)
+	for i := range bars {
+		bars[i] = makeBar(c, spread, volume)
+	}
+	return bars
+}
+
+// --- CalculateStats ---
+
+func TestCalculateStats_Empty(t *testing.T) {
+	vol, price, vty := CalculateStats(nil)
+	if vol != 0 || price != 0 || vty != 0 {
+		t.Errorf("expected zeros for empty bars, got %.0f %.2f %.4f", vol, price, vty)
+	}
+}
+
+func TestCalculateStats_Single(t *testing.T) {
+	bars := []Bar{makeBar(100.0, 1.0, 1000)}
+	vol, price, vty := CalculateStats(bars)
+	if vol != 1000 {
+		t.Errorf("avg volume: got %.0f, want 1000", vol)
+	}
+	if price != 100.0 {
+		t.Errorf("avg price: got %.2f, want 100.00", price)
+	}
+	// volatility = (high-low)/close = 2/100 = 0.02
+	if math.Abs(vty-0.02) > 1e-9 {
+		t.Errorf("avg volatility: got %.6f, want 0.020000", vty)
+	}
+}
+
+func TestCalculateStats_Multiple(t *testing.T) {
+	bars := []Bar{
+		makeBar(100.0, 1.0, 1000),
+		makeBar(200.0, 2.0, 3000),
+	}
+	vol, price, vty := CalculateStats(bars)
+	if vol != 2000 {
+		t.Errorf("avg volume: got %.0f, want 2000", vol)
+	}
+	if price != 150.0 {
+		t.Errorf("avg price: got %.2f, want 150.00", price)
+	}
+	// bar1: 2/100=0.02, bar2: 4/200=0.02 → avg 0.02
+	if math.Abs(vty-0.02) > 1e-9 {
+		t.Errorf("avg volatility: got %.6f, want 0.020000", vty)
+	}
+}
+
+// --- median ---
+
+func TestMedian_Empty(t *testing.T) {
+	if median(nil) != 0 {
+		t.Error("expected 0 for empty slice")
+	}
+}
+
+func TestMedian_OddCount(t *testing.T) {
+	got := median([]float64{3, 1, 2})
+	if got != 2 {
+		t.Errorf("got %.1f, want 2.0", got)
+	}
+}
+
+func TestMedian_EvenCount(t *testing.T) {
+	got := median([]float64{1, 2, 3, 4})
+	if got != 2.5 {
+		t.Errorf("got %.1f, want 2.5", got)
+	}
+}
+
+// --- Reason ---
+
+func barData(symbol string, bars []Bar) *BarData {
+	return &BarData{Symbol: symbol, Bars: bars, Count: len(bars)}
+}
+
+var defaultCriteria = Criteria{
+	MinAvgVolume:   1000,
+	MinPrice:       10.0,
+	MaxPrice:       500.0,
+	MinBarCount:    100,
+	MaxBarRangePct: 0.5,
+	MaxSpreadPct:   0.2,
+}
+
+func TestFilterReason_InsufficientBars(t *testing.T) {
+	bars := makeBars(50, 100.0, 0.5, 2000)
+	reason := Reason(barData("X", bars), defaultCriteria)
+	if reason == "" {
+		t.Error("expected rejection for insufficient bars, got pass")
+	}
+}
+
+func TestFilterReason_LowVolume(t *testing.T) {
+	bars := makeBars(100, 100.0, 0.5, 100) // volume 100, below 1000 minimum
+	reason := Reason(barData("X", bars), defaultCriteria)
+	if reason == "" {
+		t.Error("expected rejection for low volume, got pass")
+	}
+}
+
+func TestFilterReason_PriceTooLow(t *testing.T) {
+	bars := makeBars(100, 5.0, 0.01, 5000) // price $5, below $10 minimum
+	reason := Reason(barData("X", bars), defaultCriteria)
+	if reason == "" {
+		t.Error("expected rejection for low price, got pass")
+	}
+}
+
+func TestFilterReason_PriceTooHigh(t *testing.T) {
+	bars := makeBars(100, 600.0, 1.0, 5000) // price $600, above $500 maximum
+	reason := Reason(barData("X", bars), defaultCriteria)
+	if reason == "" {
+		t.Error("expected rejection for high price, got pass")
+	}
+}
+
+func TestFilterReason_SpreadTooWide(t *testing.T) {
+	// 99 bars that pass everything, then one last bar with wide spread
+	bars := makeBars(99, 100.0, 0.1, 2000)
+	// Last bar: spread=1.0 → range = 2/100 = 2% → exceeds 0.5% limit
+	bars = append(bars, makeBar(100.0, 1.0, 2000))
+	reason := Reason(barData("X", bars), defaultCriteria)
+	if reason == "" {
+		t.Error("expected rejection for wide spread, got pass")
+	}
+}
+
+func TestFilterReason_Passes(t *testing.T) {
+	// 100 bars: price $100, spread $0.2 → range 0.4% which is < 0.5% max bar range
+	bars := makeBars(100, 100.0, 0.2, 2000)
+	reason := Reason(barData("X", bars), defaultCriteria)
+	if reason != "" {
+		t.Errorf("expected pass, got: %s", reason)
+	}
+}
+
+func TestSpreadReason_QuotedReplacesProxy(t *testing.T) {
+	// A wide last bar is let through on a tight quoted spread
+	wide := Digest{LastRangePct: 2.0}
+	if reason := SpreadReason(wide, 0.03, true, defaultCriteria); reason != "" {
+		t.Errorf("tight quotes: got %q, want pass", reason)
+	}
+	// And a narrow one rejected on a wide quoted spread
+	narrow := Digest{LastRangePct: 0.1}
+	if reason := SpreadReason(narrow, 0.35, true, defaultCriteria); !strings.HasPrefix(reason, "quoted spread too wide") {
+		t.Errorf("wide quotes: got %q", reason)
+	}
+	if reason := SpreadReason(wide, 0, false, defaultCriteria); !strings.HasPrefix(reason, "spread too wide") {
+		t.Errorf("unsampled: got %q, want the range proxy", reason)
+	}
+}
+
+// --- AssetReason ---
+
+func TestAssetReason_ExcludedClass(t *testing.T) {
+	criteria := Criteria{ExcludeClasses: []string{"leveraged_etf", "inverse_etf"}}
+	if r := AssetReason(assets.Info{Class: "leveraged_etf"}, true, criteria); r == "" {
+		t.Error("expected rejection for leveraged ETF, got pass")
+	}
+	if r := AssetReason(assets.Info{Class: "etf"}, true, criteria); r != "" {
+		t.Errorf("plain ETF should pass, got: %s", r)
+	}
+	if r := AssetReason(assets.Info{}, false, criteria); r != "" {
+		t.Errorf("unknown symbol should pass, got: %s", r)
+	}
+}
+
+func TestAssetReason_MarketCap(t *testing.T) {
+	criteria := Criteria{MinMarketCap: 300e6}
+	if r := AssetReason(assets.Info{Class: "equity", MarketCap: 50e6}, true, criteria); r == "" {
+		t.Error("expected rejection for micro-cap, got pass")
+	}
+	if r := AssetReason(assets.Info{Class: "equity", MarketCap: 2e9}, true, criteria); r != "" {
+		t.Errorf("large cap should pass, got: %s", r)
+	}
+	if r := AssetReason(assets.Info{Class: "equity"}, true, criteria); r != "" {
+		t.Errorf("unknown market cap should pass, got: %s", r)
+	}
+	if r := AssetReason(assets.Info{Class: "etf", MarketCap: 1e6}, true, criteria); r != "" {
+		t.Errorf("funds are exempt from market cap, got: %s", r)
+	}
+}
+
+// --- percentiles ---
+
+func TestPercentiles(t *testing.T) {
+	got := percentiles(map[string]float64{"A": 10, "B": 30, "C": 20, "D": 20})
+	if got["A"] != 0 || got["B"] != 1 {
+		t.Errorf("extremes: got A=%g B=%g", got["A"], got["B"])
+	}
+	if got["C"] != got["D"] {
+		t.Errorf("ties should share a rank: C=%g D=%g", got["C"], got["D"])
+	}
+	if one := percentiles(map[string]float64{"A": 5}); one["A"] != 1 {
+		t.Errorf("single value: got %g, want 1", one["A"])
+	}
+}
+
+// --- volatilityFit ---
+
+func TestVolatilityFit(t *testing.T) {
+	if got := volatilityFit(0.01, 0.01); got != 1 {
+		t.Errorf("at median: got %g, want 1", got)
+	}
+	if got := volatilityFit(0.04, 0.01); got > 1e-9 {
+		t.Errorf("4x median: got %g, want 0", got)
+	}
+	if volatilityFit(0.02, 0.01) != volatilityFit(0.005, 0.01) {
+		t.Error("fit should be symmetric in log space")
+	}
+}
+
+// --- quality ---
+
+func TestQuality(t *testing.T) {
+	clean := Bar{High: 11, Low: 9, Close: 10, Volume: 100}
+	bad := Bar{High: 9, Low: 11, Close: 10, Volume: 0}
+
+	if got := quality(Summarise(&BarData{Bars: []Bar{clean, clean, clean, bad}}), 2); got != 0.75 {
+		t.Errorf("got %g, want 0.75", got)
+	}
+	if got := quality(Summarise(&BarData{Bars: []Bar{clean}}), 2); got != 0.25 {
+		t.Errorf("short history: got %g, want 0.25", got)
+	}
+}
+
+// --- scoreSymbols / rankCandidates ---
+
+func TestScoreAndRank(t *testing.T) {
+	bars := func(from, to float64) *BarData {
+		return &BarData{Bars: []Bar{
+			{High: from + 1, Low: from - 1, Close: from, Volume: 1000},
+			{High: to + 1, Low: to - 1, Close: to, Volume: 1000},
+		}}
+	}
+	stats := []SymbolStats{
+		{Symbol: "LOW", AvgVolume: 1e5, AvgPrice: 10, AvgVolatility: 0.01, Tradeable: true},
+		{Symbol: "HIGH", AvgVolume: 1e7, AvgPrice: 100, AvgVolatility: 0.01, Tradeable: true},
+		{Symbol: "SKIP", AvgVolume: 1e8, AvgPrice: 500, AvgVolatility: 0.01},
+	}
+	data := map[string]Digest{"LOW": Summarise(bars(10, 9)), "HIGH": Summarise(bars(100, 110)), "SKIP": Summarise(bars(500, 600))}
+
+	scoreSymbols(stats, data, map[string]float64{"HIGH": 0.5}, Criteria{MinBarCount: 1}, DefaultWeights)
+
+	if stats[2].Score != 0 || stats[2].ScoreParts != nil {
+		t.Errorf("rejected symbol should not be scored: %+v", stats[2])
+	}
+	if stats[0].ScoreParts.Expectancy != 0.5 {
+		t.Errorf("missing backtest should be neutral, got %g", stats[0].ScoreParts.Expectancy)
+	}
+
+	ranked := rankCandidates(stats)
+	if len(ranked) != 2 || ranked[0].Symbol != "HIGH" {
+		t.Errorf("got %+v, want HIGH first of 2", ranked)
+	}
+}
+
+func TestRankCandidates_Demoted(t *testing.T) {
+	ranked := rankCandidates([]SymbolStats{
+		{Symbol: "GAPPY", Score: 0.9, Tradeable: true, Demoted: "data quality: 2 duplicate timestamps"},
+		{Symbol: "MSFT", Score: 0.4, Tradeable: true},
+		{Symbol: "AAPL", Score: 0.6, Tradeable: true},
+	})
+	var got []string
+	for _, c := range ranked {
+		got = append(got, c.Symbol)
+	}
+	if strings.Join(got, ",") != "AAPL,MSFT,GAPPY" {
+		t.Errorf("got %v, want the demoted symbol last", got)
+	}
+}
+
+// --- Market / DeriveCriteria ---
+
+func TestMarket(t *testing.T) {
+	m := Market([]SymbolStats{
+		{AvgVolume: 1000.4, AvgPrice: 10, AvgVolatility: 0.01},
+		{AvgVolume: 3000.6, AvgPrice: 50, AvgVolatility: 0.03},
+		{AvgVolume: 2000, AvgPrice: 20, AvgVolatility: 0.02},
+	})
+	if m.VolumeMin != 1000 || m.VolumeMax != 3001 || m.VolumeMedian != 2000 {
+		t.Errorf("volume: got %+v", m)
+	}
+	if m.PriceMin != 10 || m.PriceMax != 50 || m.PriceMedian != 20 {
+		t.Errorf("price: got %+v", m)
+	}
+	if m.VolMedian != 0.02 {
+		t.Errorf("volatility median: got %g", m.VolMedian)
+	}
+	if Market(nil) != (MarketStats{}) {
+		t.Error("empty universe should give zero stats")
+	}
+}
+
+func TestDeriveCriteria(t *testing.T) {
+	c := DeriveCriteria(MarketStats{VolumeMedian: 2e6, PriceMax: 500},
+		Options{ExcludeClasses: []string{assets.LeveragedETF}, MinMarketCap: 300e6})
+
+	if c.MinAvgVolume != 1e6 {
+		t.Errorf("min volume: got %g, want half the median", c.MinAvgVolume)
+	}
+	if math.Abs(c.MaxPrice-550) > 1e-9 {
+		t.Errorf("max price: got %g, want 10%% above the dearest symbol", c.MaxPrice)
+	}
+	if c.MinPrice != 10 || c.MinBarCount != 100 || c.MaxBarRangePct != 0.5 {
+		t.Errorf("fixed floors changed: %+v", c)
+	}
+	if len(c.ExcludeClasses) != 1 || c.MinMarketCap != 300e6 {
+		t.Errorf("options not carried: %+v", c)
+	}
+}
+
+// --- Run ---
+
+func TestRun(t *testing.T) {
+	// Every file spans the same week except AAPL, which starts earlier
+	stamped := func(bars []Bar) []Bar {
+		bars[0].Timestamp, bars[len(bars)-1].Timestamp = "2026-03-03T14:30:00Z", "2026-03-10T20:55:00Z"
+		return bars
+	}
+	liquid := stamped(makeBars(120, 100, 0.2, 5000))
+	liquid[0].Timestamp = "2026-03-02T14:30:00Z"
+	blocks := &blocklist.List{Blocked: []blocklist.Entry{{Symbol: "BLOCK", Reason: "earnings"}}}
+
+	out := Run(Input{
+		Bars: []*BarData{
+			barData("AAPL", liquid),
+			barData("MSFT", stamped(makeBars(120, 200, 0.4, 5000))),
+			barData("THIN", stamped(makeBars(120, 100, 0.2, 10))),   // below half the median volume
+			barData("SHORT", stamped(makeBars(20, 100, 0.2, 5000))), // too little history
+			barData("BLOCK", stamped(makeBars(120, 100, 0.2, 5000))),
+			barData("TQQQ", stamped(makeBars(120, 50, 0.1, 5000))),
+		},
+		Assets:  map[string]assets.Info{"TQQQ": {Class: assets.LeveragedETF}},
+		Blocks:  blocks,
+		Options: Options{ExcludeClasses: []string{assets.LeveragedETF}},
+		Now:     time.Date(2026, 3, 10, 21, 0, 0, 0, time.UTC),
+	})
+
+	if strings.Join(out.Symbols, ",") != "AAPL,MSFT" && strings.Join(out.Symbols, ",") != "MSFT,AAPL" {
+		t.Errorf("candidates: got %v, want AAPL and MSFT", out.Symbols)
+	}
+	if out.TotalCandidates != 2 || len(out.Ranked) != 2 || out.Symbols[0] != out.Ranked[0].Symbol {
+		t.Errorf("ranking inconsistent: %d %+v %v", out.TotalCandidates, out.Ranked, out.Symbols)
+	}
+	if out.ScoreWeights != DefaultWeights {
+		t.Errorf("zero weights should use defaults, got %+v", out.ScoreWeights)
+	}
+	if len(out.Blocked) != 1 || out.Blocked[0].Symbol != "BLOCK" {
+		t.Errorf("blocked: got %+v", out.Blocked)
+	}
+	if out.FirstBarTime != "2026-03-02T14:30:00Z" || out.LastBarTime != "2026-03-10T20:55:00Z" {
+		t.Errorf("bar range: got %s → %s", out.FirstBarTime, out.LastBarTime)
+	}
+	if out.Timestamp != "2026-03-10T21:00:00Z" {
+		t.Errorf("timestamp: got %s", out.Timestamp)
+	}
+
+	reasons := map[string]string{}
+	for i, s := range out.AllSymbols {
+		reasons[s.Symbol] = s.SkipReason
+		if i > 0 && s.AvgVolume > out.AllSymbols[i-1].AvgVolume {
+			t.Error("all_symbols should be sorted by volume, highest first")
+		}
+	}
+	for symbol, want := range map[string]string{
+		"THIN":  "low volume",
+		"SHORT": "insufficient bars",
+		"BLOCK": "blocked: earnings",
+		"TQQQ":  "excluded class",
+	} {
+		if !strings.HasPrefix(reasons[symbol], want) {
+			t.Errorf("%s: got reason %q, want %q", symbol, reasons[symbol], want)
+		}
+	}
+}
+
+func TestRun_Empty(t *testing.T) {
+	out := Run(Input{})
+	if out.TotalCandidates != 0 || len(out.Symbols) != 0 {
+		t.Errorf("got %+v", out)
+	}
+}
+
+func TestRun_FetchFailures(t *testing.T) {
+	out := Run(Input{
+		Bars: []*BarData{
+			barData("AAPL", makeBars(120, 100, 0.2, 5000)),
+			barData("MSFT", makeBars(120, 100, 0.2, 5000)),
+		},
+		Failures:  map[string]string{"MSFT": "rate_limited", "GONE": "bad_symbol"},
+		Integrity: map[string]string{"TORN": "checksum mismatch"},
+	})
+
+	if strings.Join(out.Symbols, ",") != "AAPL" {
+		t.Errorf("candidates: got %v, want AAPL", out.Symbols)
+	}
+	if out.MarketStats.VolumeMin != 5000 {
+		t.Errorf("a symbol without bars shouldn't count towards the market: %+v", out.MarketStats)
+	}
+	reasons := map[string]string{}
+	for _, s := range out.AllSymbols {
+		reasons[s.Symbol] = s.SkipReason
+	}
+	if reasons["MSFT"] != "fetch failed: rate_limited (stale bars)" || reasons["GONE"] != "fetch failed: bad_symbol" ||
+		reasons["TORN"] != "integrity: checksum mismatch" {
+		t.Errorf("got %q", reasons)
+	}
+}
+
+func TestRun_Demoted(t *testing.T) {
+	out := Run(Input{
+		Bars: []*BarData{
+			barData("AAPL", makeBars(120, 100, 0.2, 5000)),
+			barData("MSFT", makeBars(120, 100, 0.2, 5000)),
+		},
+		Demoted: map[string]string{"AAPL": "1 bad prices"},
+	})
+	if strings.Join(out.Symbols, ",") != "MSFT,AAPL" {
+		t.Errorf("candidates: got %v, want AAPL kept but last", out.Symbols)
+	}
+	for _, s := range out.AllSymbols {
+		if s.Symbol == "AAPL" && s.Demoted != "data quality: 1 bad prices" {
+			t.Errorf("AAPL: got %q", s.Demoted)
+		}
+	}
+}
+
+// --- ParseFailures ---
+
+func TestParseDataQuality(t *testing.T) {
+	got, err := ParseDataQuality("data_quality.json", []byte(`{"schema_version": 1, "symbols": [
+		{"symbol": "AAPL", "status": "ok"},
+		{"symbol": "MSFT", "status": "warn", "issues": ["3 missing bars in 1 gaps (0.50%)"]},
+		{"symbol": "GAPPY", "status": "bad", "issues": ["1 bad prices", "2 duplicate timestamps"]}]}`))
+	if err != nil || len(got) != 1 || got["GAPPY"] != "1 bad prices, 2 duplicate timestamps" {
+		t.Errorf("got %v, %v", got, err)
+	}
+}
+
+func TestParseFailures(t *testing.T) {
+	got, err := ParseFailures("fetch-failures.json", []byte(`{"schema_version": 1, "failures": [{"symbol": "MSFT", "cause": "rate_limited"}]}`))
+	if err != nil || len(got) != 1 || got["MSFT"] != "rate_limited" {
+		t.Errorf("got %v, %v", got, err)
+	}
+	if _, err := ParseFailures("fetch-failures.json", []byte(`{"schema_version": 99}`)); err == nil {
+		t.Error("newer schema should be refused")
+	}
+}
+
+// --- Summarise / ReadDigest ---
+
+func TestDigestMatchesBars(t *testing.T) {
+	var bars []Bar
+	for i := 0; i < momentumBars+25; i++ {
+		c := 100 + float64(i%7) - float64(i)/10
+		bars = append(bars, Bar{
+			Timestamp: fmt.Sprintf("2025-01-02T%02d:%02d:00Z", 14+i/60, i%60),
+			High:      c + 0.5, Low: c - 0.5, Close: c, Volume: int64(1000 + i),
+		})
+	}
+	bars[3].Volume = 0 // One dirty bar
+
+	for _, n := range []int{0, 1, 2, momentumBars, momentumBars + 1, len(bars)} {
+		bd := &BarData{Symbol: "AAPL", Bars: bars[:n]}
+		d := Summarise(bd)
+
+		vol, price, volatility := CalculateStats(bd.Bars)
+		if d.Count != n || d.AvgVolume != vol || d.AvgPrice != price || d.AvgVolatility != volatility {
+			t.Errorf("%d bars: stats %+v, want %g %g %g", n, d, vol, price, volatility)
+		}
+		if d.LastRangePct != LastRangePct(bd.Bars) {
+			t.Errorf("%d bars: range %g, want %g", n, d.LastRangePct, LastRangePct(bd.Bars))
+		}
+		want := 0.0
+		if n >= 2 {
+			want = bars[n-1].Close/bars[max(0, n-1-momentumBars)].Close - 1
+		}
+		if d.Momentum != want {
+			t.Errorf("%d bars: momentum %g, want %g", n, d.Momentum, want)
+		}
+		if n > 0 && (d.First != bars[0].Timestamp || d.Last != bars[n-1].Timestamp) {
+			t.Errorf("%d bars: span %s → %s", n, d.First, d.Last)
+		}
+	}
+}
+
+func TestReadDigest(t *testing.T) {
+	bd := BarData{Symbol: "AAPL", FetchedAt: "2025-01-02T21:00:00Z", Bars: []Bar{
+		{Timestamp: "2025-01-02T14:30:00Z", High: 101, Low: 99, Close: 100, Volume: 500},
+		{Timestamp: "2025-01-02T14:35:00Z", High: 102, Low: 100, Close: 101, Volume: 700},
+	}}
+	bd.Count = len(bd.Bars)
+	data, err := json.Marshal(struct {
+		Extra map[string]int `json:"extra"` // Unknown keys are skipped
+		BarData
+	}{map[string]int{"a": 1}, bd})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadDigest(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Summarise(&bd); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := ReadDigest(strings.NewReader(`{"symbol": "AAPL", "bars": [{"t": 1}]}`)); err == nil {
+		t.Error("malformed bar should fail")
+	}
+	if _, err := ReadDigest(strings.NewReader(`[]`)); err == nil {
+		t.Error("non-object should fail")
+	}
+}
+
+// --- Days / AssessQuality ---
+
+// dayBars returns bars at 5-minute intervals from 14:30 UTC on date.
+func dayBars(date string, closes ...float64) []Bar {
+	start, _ := time.Parse(time.RFC3339, date+"T14:30:00Z")
+	var bars []Bar
+	for i, c := range closes {
+		bars = append(bars, Bar{
+			Timestamp: start.Add(time.Duration(i) * 5 * time.Minute).Format(time.RFC3339),
+			High:      c + 0.5, Low: c - 0.5, Close: c, Volume: 100,
+		})
+	}
+	return bars
+}
+
+func TestDigestDays(t *testing.T) {
+	bars := append(dayBars("2026-10-13", 100, 101), dayBars("2026-10-14", 102, 102, 102)...)
+	// 01:00 UTC on the 16th is still the 15th in New York
+	bars = append(bars, Bar{Timestamp: "2026-10-16T01:00:00Z", High: 104, Low: 103, Close: 103.5, Volume: 100})
+
+	got := Summarise(&BarData{Symbol: "AAPL", Bars: bars}).Days
+	want := []Day{
+		{Date: "2026-10-13", Bars: 2, Close: 101},
+		{Date: "2026-10-14", Bars: 3, Close: 102, Flat: true},
+		{Date: "2026-10-15", Bars: 1, Close: 103.5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestAssessQuality(t *testing.T) {
+	full := Summarise(&BarData{Symbol: "SPY", Bars: append(append(append(
+		dayBars("2026-10-12", 1, 2), dayBars("2026-10-13", 1, 2)...),
+		dayBars("2026-10-14", 1, 2)...), dayBars("2026-10-15", 1, 2)...)})
+	late := Summarise(&BarData{Symbol: "NEW", Bars: append(dayBars("2026-10-14", 5, 6), dayBars("2026-10-15", 5, 6)...)})
+	gappy := Summarise(&BarData{Symbol: "GAP", Bars: append(append(
+		dayBars("2026-10-12", 1, 2), dayBars("2026-10-14", 3, 3)...), dayBars("2026-10-15", 1, 2)...)})
+	stale := Summarise(&BarData{Symbol: "OLD", Bars: append(dayBars("2026-10-12", 1, 2), dayBars("2026-10-13", 1, 2)...)})
+	days := MarketDays([]Digest{full, late, gappy, stale})
+	if len(days) != 4 {
+		t.Fatalf("market days: got %v", days)
+	}
+
+	if q := AssessQuality(full, days); q.Score != 1 || len(q.Issues) != 0 {
+		t.Errorf("full: score %g issues %v, want 1 and none", q.Score, q.Issues)
+	}
+	// A short history isn't a gap
+	if q := AssessQuality(late, days); q.Score != 1 || len(q.Days) != 2 {
+		t.Errorf("late: score %g over %d days, want 1 over 2", q.Score, len(q.Days))
+	}
+
+	q := AssessQuality(gappy, days)
+	if fmt.Sprint(q.Missing) != "[1]" || q.Flat != 1 || q.Stale != "" {
+		t.Errorf("gappy: missing %v flat %d stale %q", q.Missing, q.Flat, q.Stale)
+	}
+	if want := 0.5; q.Score != want { // 3 of 4 days, 2 of 3 moved
+		t.Errorf("gappy: score %g, want %g", q.Score, want)
+	}
+	if got := strings.Join(q.Issues, "; "); got != "1 missing day; 1 flat day" {
+		t.Errorf("gappy: issues %q", got)
+	}
+
+	q = AssessQuality(stale, days)
+	if q.Stale != "2026-10-13" || q.Score != 0.5 {
+		t.Errorf("stale: since %q score %g, want 2026-10-13 and 0.5", q.Stale, q.Score)
+	}
+	if got := strings.Join(q.Issues, "; "); got != "no bars since 2026-10-13" {
+		t.Errorf("stale: issues %q", got)
+	}
+
+	if q := AssessQuality(Digest{Symbol: "NONE"}, days); q.Score != 0 || fmt.Sprint(q.Issues) != "[no bars]" {
+		t.Errorf("empty: %+v", q)
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/deanturpin/lft2/internal/filter
+
+go 1.21
+
+require (
+	github.com/deanturpin/lft2/internal/assets v0.0.0
+	github.com/deanturpin/lft2/internal/blocklist v0.0.0
+	github.com/deanturpin/lft2/internal/schema v0.0.0
+)
+
+replace (
+	github.com/deanturpin/lft2/internal/assets => ../../internal/assets
+	github.com/deanturpin/lft2/internal/blocklist => ../../internal/blocklist
+	github.com/deanturpin/lft2/internal/schema => ../../internal/schema
+	github.com/deanturpin/lft2/internal/version => ../../internal/version
+)
@@ -0,0 +1,188 @@
+package filter
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// ScoreParts are the composite score's components, each in [0, 1].
+type ScoreParts struct {
+	Liquidity  float64 `json:"liquidity"`  // dollar volume percentile
+	Volatility float64 `json:"volatility"` // closeness to the universe's typical bar range
+	Momentum   float64 `json:"momentum"`   // recent return percentile
+	Quality    float64 `json:"quality"`    // share of clean bars, scaled by history length
+	Expectancy float64 `json:"expectancy"` // best viable backtest expectancy percentile
+}
+
+// ScoreWeights sum to 1 so the composite stays in [0, 1].
+type ScoreWeights = ScoreParts
+
+// DefaultWeights favour liquidity and backtest expectancy.
+var DefaultWeights = ScoreWeights{
+	Liquidity:  0.25,
+	Volatility: 0.20,
+	Momentum:   0.15,
+	Quality:    0.15,
+	Expectancy: 0.25,
+}
+
+// momentumBars is the look-back for the momentum component: one trading
+// day of 5-minute bars.
+const momentumBars = 78
+
+// composite combines parts with weights.
+func composite(p ScoreParts, w ScoreWeights) float64 {
+	return p.Liquidity*w.Liquidity + p.Volatility*w.Volatility + p.Momentum*w.Momentum +
+		p.Quality*w.Quality + p.Expectancy*w.Expectancy
+}
+
+// percentiles maps each key to its rank in [0, 1] (ties share the lower
+// rank). A single value ranks 1.
+func percentiles(values map[string]float64) map[string]float64 {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return values[keys[i]] < values[keys[j]] })
+
+	ranks := make(map[string]float64, len(keys))
+	for i, k := range keys {
+		if len(keys) == 1 {
+			ranks[k] = 1
+			continue
+		}
+		r := i
+		for r > 0 && values[keys[r-1]] == values[k] {
+			r--
+		}
+		ranks[k] = float64(r) / float64(len(keys)-1)
+	}
+	return ranks
+}
+
+// quality is the share of bars with sane prices and non-zero volume,
+// scaled down when history is shorter than twice the minimum bar count.
+func quality(d Digest, minBars int) float64 {
+	if d.Count == 0 {
+		return 0
+	}
+	coverage := 1.0
+	if minBars > 0 {
+		coverage = math.Min(1, float64(d.Count)/float64(2*minBars))
+	}
+	return float64(d.Clean) / float64(d.Count) * coverage
+}
+
+// volatilityFit scores how close a symbol's average bar range is to the
+// universe median: 1 at the median, falling to 0 at 4× either side. Too
+// quiet and targets are never reached; too wild and stops are.
+func volatilityFit(vol, median float64) float64 {
+	if vol <= 0 || median <= 0 {
+		return 0
+	}
+	return math.Max(0, 1-math.Abs(math.Log(vol/median))/math.Log(4))
+}
+
+// LoadExpectancy reads the previous run's strategies.json and returns each
+// symbol's best viable avg_profit, the mean return per backtest trade.
+// Backtest runs after filter, so this is always one cycle old; a missing file
+// scores neutral.
+func LoadExpectancy(path string) map[string]float64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var strategies struct {
+		Recommendations []struct {
+			Symbol    string  `json:"symbol"`
+			AvgProfit float64 `json:"avg_profit"`
+			Viable    bool    `json:"viable"`
+		} `json:"recommendations"`
+	}
+	if schema.Check(path, data) != nil || json.Unmarshal(data, &strategies) != nil {
+		return nil
+	}
+
+	best := map[string]float64{}
+	for _, r := range strategies.Recommendations {
+		if !r.Viable {
+			continue
+		}
+		if v, ok := best[r.Symbol]; !ok || r.AvgProfit > v {
+			best[r.Symbol] = r.AvgProfit
+		}
+	}
+	return best
+}
+
+// scoreSymbols fills Score and ScoreParts for every tradeable symbol.
+// Percentile components rank among tradeable symbols only, so rejects don't
+// skew the ranking.
+func scoreSymbols(stats []SymbolStats, digests map[string]Digest, expectancy map[string]float64, criteria Criteria, w ScoreWeights) {
+	dollarVolume := map[string]float64{}
+	moves := map[string]float64{}
+	var vols []float64
+	for _, s := range stats {
+		if !s.Tradeable {
+			continue
+		}
+		dollarVolume[s.Symbol] = s.AvgVolume * s.AvgPrice
+		if d, ok := digests[s.Symbol]; ok {
+			moves[s.Symbol] = d.Momentum
+		}
+		vols = append(vols, s.AvgVolatility)
+	}
+
+	liquidity := percentiles(dollarVolume)
+	mom := percentiles(moves)
+	exp := percentiles(expectancy)
+	medianVol := median(vols)
+
+	for i, s := range stats {
+		if !s.Tradeable {
+			continue
+		}
+		p := ScoreParts{
+			Liquidity:  liquidity[s.Symbol],
+			Volatility: volatilityFit(s.AvgVolatility, medianVol),
+			Momentum:   mom[s.Symbol],
+			Expectancy: 0.5, // neutral until the symbol has a viable backtest
+		}
+		if d, ok := digests[s.Symbol]; ok {
+			p.Quality = quality(d, criteria.MinBarCount)
+		}
+		if e, ok := exp[s.Symbol]; ok {
+			p.Expectancy = e
+		}
+		stats[i].ScoreParts = &p
+		stats[i].Score = math.Round(composite(p, w)*1000) / 1000
+	}
+}
+
+// rankCandidates returns tradeable symbols ordered by score, best first,
+// with symbol as a stable tie-break. Demoted symbols follow every clean one
+// whatever their score.
+func rankCandidates(stats []SymbolStats) []RankedCandidate {
+	var ranked []RankedCandidate
+	demoted := map[string]bool{}
+	for _, s := range stats {
+		if s.Tradeable {
+			ranked = append(ranked, RankedCandidate{Symbol: s.Symbol, Score: s.Score})
+			demoted[s.Symbol] = s.Demoted != ""
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if a, b := demoted[ranked[i].Symbol], demoted[ranked[j].Symbol]; a != b {
+			return b
+		}
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Symbol < ranked[j].Symbol
+	})
+	return ranked
+}
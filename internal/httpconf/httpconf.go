@@ -0,0 +1,129 @@
+// Package httpconf holds each stage's HTTP timeout and retry settings, read
+// from http.json at the repository root. Bulk data fetches want a long
+// timeout and several retries; order submission wants a short timeout and
+// to fail fast, so one setting for every stage suits neither.
+package httpconf
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultPath is the settings file relative to the repository root;
+// LFT2_HTTP_CONFIG names another.
+const DefaultPath = "http.json"
+
+// Policy is how a stage's requests are timed out and retried.
+type Policy struct {
+	Timeout time.Duration // Per attempt, including reading the body
+	Retries int           // Further attempts after a timeout, network error, 5xx or 429
+	Backoff time.Duration // Before the first retry, doubling for each after
+}
+
+// Default applies to a stage the file doesn't name, and to any field a
+// stage leaves out: the 10 second timeout every stage used to share, and no
+// retries.
+var Default = Policy{Timeout: 10 * time.Second, Backoff: time.Second}
+
+// setting is a Policy as written in the file, durations as Go strings
+// ("30s"). A field left out is inherited.
+type setting struct {
+	Timeout *string `json:"timeout"`
+	Retries *int    `json:"retries"`
+	Backoff *string `json:"backoff"`
+}
+
+// File is http.json: a "default" policy over Default, and one per stage
+// over that, keyed by the stage's name as its binary is called.
+type File map[string]setting
+
+// Load reads the settings at path. A missing file is not an error: every
+// stage gets Default.
+func Load(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading HTTP settings: %w", err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	// Checked up front so a typo fails every stage, not only the one it's for
+	for name := range f {
+		if _, err := f.For(name); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return f, nil
+}
+
+// For returns stage's policy: Default, overridden by the file's "default",
+// overridden by the stage's own entry.
+func (f File) For(stage string) (Policy, error) {
+	p := Default
+	for _, name := range []string{"default", stage} {
+		s, ok := f[name]
+		if !ok {
+			continue
+		}
+		if err := s.apply(&p); err != nil {
+			return Policy{}, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return p, nil
+}
+
+func (s setting) apply(p *Policy) error {
+	duration := func(field string, v *string, min time.Duration, out *time.Duration) error {
+		if v == nil {
+			return nil
+		}
+		d, err := time.ParseDuration(*v)
+		if err != nil || d < min {
+			return fmt.Errorf("%s must be a duration of at least %s, such as 30s, got %q", field, min, *v)
+		}
+		*out = d
+		return nil
+	}
+	if err := duration("timeout", s.Timeout, time.Second, &p.Timeout); err != nil {
+		return err
+	}
+	if err := duration("backoff", s.Backoff, 0, &p.Backoff); err != nil {
+		return err
+	}
+	if s.Retries != nil {
+		if *s.Retries < 0 || *s.Retries > 10 {
+			return fmt.Errorf("retries must be 0 to 10, got %d", *s.Retries)
+		}
+		p.Retries = *s.Retries
+	}
+	return nil
+}
+
+// ForStage loads LFT2_HTTP_CONFIG, or DefaultPath when unset, and returns
+// stage's policy.
+func ForStage(stage string) (Policy, error) {
+	path := os.Getenv("LFT2_HTTP_CONFIG")
+	if path == "" {
+		path = DefaultPath
+	}
+	f, err := Load(path)
+	if err != nil {
+		return Policy{}, err
+	}
+	return f.For(stage)
+}
+
+// Delay is how long to wait before retry attempt, counted from 1.
+func (p Policy) Delay(attempt int) time.Duration {
+	return p.Backoff << (attempt - 1)
+}
+
+func (p Policy) String() string {
+	return fmt.Sprintf("timeout %s, %d retries", p.Timeout, p.Retries)
+}
@@ -0,0 +1,73 @@
+package httpconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "http.json")
+	os.WriteFile(path, []byte(`{
+		"default": {"timeout": "15s"},
+		"fetch": {"timeout": "45s", "retries": 3},
+		"execute": {"timeout": "5s"}
+	}`), 0644)
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stage, want := range map[string]Policy{
+		"fetch":   {Timeout: 45 * time.Second, Retries: 3, Backoff: time.Second},
+		"execute": {Timeout: 5 * time.Second, Backoff: time.Second},
+		"summary": {Timeout: 15 * time.Second, Backoff: time.Second},
+	} {
+		if got, err := f.For(stage); err != nil || got != want {
+			t.Errorf("%s: got %+v, %v, want %+v", stage, got, err, want)
+		}
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), "http.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := f.For("fetch"); got != Default {
+		t.Errorf("got %+v, want the default", got)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for _, bad := range []string{
+		`{"fetch": {"timeout": "30"}}`,
+		`{"fetch": {"timeout": "100ms"}}`,
+		`{"execute": {"backoff": "-1s"}}`,
+		`{"fetch": {"retries": -1}}`,
+		`{"fetch": {"retries": 50}}`,
+		`[]`,
+	} {
+		path := filepath.Join(t.TempDir(), "http.json")
+		os.WriteFile(path, []byte(bad), 0644)
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: want an error", bad)
+		}
+	}
+}
+
+func TestForStage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.json")
+	os.WriteFile(path, []byte(`{"execute": {"timeout": "3s"}}`), 0644)
+	t.Setenv("LFT2_HTTP_CONFIG", path)
+	if p, err := ForStage("execute"); err != nil || p.Timeout != 3*time.Second {
+		t.Errorf("got %+v, %v", p, err)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	p := Policy{Backoff: 2 * time.Second}
+	if p.Delay(1) != 2*time.Second || p.Delay(3) != 8*time.Second {
+		t.Errorf("got %v then %v, want 2s then 8s", p.Delay(1), p.Delay(3))
+	}
+}
@@ -0,0 +1,61 @@
+// Package interrupt turns Ctrl-C into a cancelled context, so a command can
+// stop between units of work — a symbol, an order, a file — rather than part
+// way through one, write what it has, and exit with ExitCode.
+package interrupt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ExitCode is what an interrupted command exits with: 128 plus SIGINT, as a
+// shell reports it, so CI and scripts can tell it from a failure (1).
+const ExitCode = 130
+
+// Context returns a context cancelled by the first SIGINT or SIGTERM. A
+// second signal exits at once, for when finishing up is taking too long.
+func Context() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		fmt.Fprintf(os.Stderr, "\n⚠ %v: finishing up (again to stop now)\n", sig)
+		cancel()
+		<-signals
+		os.Exit(ExitCode)
+	}()
+	return ctx
+}
+
+// Exit reports that stage stopped early and exits with ExitCode. Anything
+// deferred doesn't run, so the caller writes its partial results first.
+func Exit(stage string) {
+	fmt.Fprintf(os.Stderr, "✗ %s interrupted\n", stage)
+	os.Exit(ExitCode)
+}
+
+// Stop calls Exit if ctx has been cancelled. Commands call it between steps,
+// once what the last one wrote is complete.
+func Stop(ctx context.Context, stage string) {
+	if ctx.Err() != nil {
+		Exit(stage)
+	}
+}
+
+// Sleep waits d or until ctx is cancelled, and reports whether it waited
+// the whole time.
+func Sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,52 @@
+package interrupt
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestContext(t *testing.T) {
+	ctx := Context()
+	if ctx.Err() != nil {
+		t.Fatal("cancelled before any signal")
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGINT)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGINT didn't cancel the context")
+	}
+}
+
+func TestSleep(t *testing.T) {
+	if !Sleep(context.Background(), time.Millisecond) {
+		t.Error("uncancelled: want the whole wait")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if Sleep(ctx, time.Hour) || time.Since(start) > time.Second {
+		t.Error("cancelled: want an early return")
+	}
+}
+
+// TestExit runs Stop in a child process, as it exits.
+func TestExit(t *testing.T) {
+	if os.Getenv("INTERRUPT_CHILD") == "1" {
+		ctx, cancel := context.WithCancel(context.Background())
+		Stop(ctx, "child") // Not yet cancelled, so a no-op
+		cancel()
+		Stop(ctx, "child")
+		os.Exit(0)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=TestExit")
+	cmd.Env = append(os.Environ(), "INTERRUPT_CHILD=1")
+	err := cmd.Run()
+	if exit, ok := err.(*exec.ExitError); !ok || exit.ExitCode() != ExitCode {
+		t.Errorf("got %v, want exit status %d", err, ExitCode)
+	}
+}
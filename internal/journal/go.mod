@@ -0,0 +1,14 @@
+module github.com/deanturpin/lft2/internal/journal
+
+go 1.21
+
+require (
+	github.com/deanturpin/lft2/internal/schema v0.0.0
+	github.com/deanturpin/lft2/internal/vault v0.0.0
+)
+
+replace (
+	github.com/deanturpin/lft2/internal/schema => ../../internal/schema
+	github.com/deanturpin/lft2/internal/vault => ../../internal/vault
+	github.com/deanturpin/lft2/internal/version => ../../internal/version
+)
@@ -0,0 +1,209 @@
+// Package journal stores freeform notes attached to orders, so the daily
+// summary reads as a trading journal rather than a bare fill log. Notes are
+// keyed by order ID — either Alpaca's order UUID or our client_order_id.
+// Orders can also carry key=value tags (experiment, regime, run) for slicing
+// live results; the client_order_id has no room for them, so they live here.
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+	"github.com/deanturpin/lft2/internal/vault"
+)
+
+// DefaultPath is the journal location relative to the repository root.
+const DefaultPath = "journal.json"
+
+// ExpiredUnsubmitted tags an order execute gave up on because the broker
+// kept rate limiting it past the cycle's time budget. It's recorded rather
+// than dropped so the next cycle can check whether the signal still holds.
+const ExpiredUnsubmitted = "expired-unsubmitted"
+
+// Note is a single annotation on an order.
+type Note struct {
+	OrderID   string    `json:"order_id"`
+	Text      string    `json:"text"`
+	Tag       string    `json:"tag,omitempty"` // machine-readable status, e.g. ExpiredUnsubmitted
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderTags are the tags an order was submitted with.
+type OrderTags struct {
+	OrderID   string            `json:"order_id"`
+	Tags      map[string]string `json:"tags"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Journal is the on-disk layout of journal.json.
+type Journal struct {
+	schema.Header
+	Notes []Note      `json:"notes"`
+	Tags  []OrderTags `json:"tags,omitempty"`
+}
+
+// Load reads the journal. A missing file yields an empty journal.
+func Load(path string) (*Journal, error) {
+	data, err := vault.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Journal{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+
+	if err := schema.Check(path, data); err != nil {
+		return nil, err
+	}
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("parsing journal: %w", err)
+	}
+	return &j, nil
+}
+
+// Save writes the journal.
+func (j *Journal) Save(path string) error {
+	j.Header = schema.Current()
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding journal: %w", err)
+	}
+	return vault.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Add appends a note to an order.
+func (j *Journal) Add(orderID, text string, now time.Time) error {
+	return j.AddTagged(orderID, "", text, now)
+}
+
+// AddTagged appends a note carrying a status tag.
+func (j *Journal) AddTagged(orderID, tag, text string, now time.Time) error {
+	orderID, text = strings.TrimSpace(orderID), strings.TrimSpace(text)
+	if orderID == "" {
+		return fmt.Errorf("order ID is required")
+	}
+	if text == "" {
+		return fmt.Errorf("note text is required")
+	}
+	j.Notes = append(j.Notes, Note{OrderID: orderID, Text: text, Tag: tag, CreatedAt: now.UTC()})
+	return nil
+}
+
+// For returns the notes attached to any of the given order IDs, oldest first.
+// Orders are known by two IDs, so callers pass both.
+func (j *Journal) For(orderIDs ...string) []Note {
+	var notes []Note
+	for _, n := range j.Notes {
+		for _, id := range orderIDs {
+			if id != "" && n.OrderID == id {
+				notes = append(notes, n)
+				break
+			}
+		}
+	}
+	return notes
+}
+
+// Tagged returns the notes carrying tag created at or after since, oldest
+// first.
+func (j *Journal) Tagged(tag string, since time.Time) []Note {
+	var notes []Note
+	for _, n := range j.Notes {
+		if n.Tag == tag && !n.CreatedAt.Before(since) {
+			notes = append(notes, n)
+		}
+	}
+	return notes
+}
+
+// TagsEnv names the tags execute attaches to every order it submits, as
+// comma-separated key=value pairs: experiment=wide-stops,regime=bull.
+const TagsEnv = "LFT2_ORDER_TAGS"
+
+// tagPattern is what a tag key or value may be: letters, digits, '.', '_'
+// and '-', so a tag reads the same in a CSV column or a table heading.
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// ParseTags reads key=value pairs separated by commas. Blank input is no
+// tags; a key given twice is an error rather than a silent overwrite.
+func ParseTags(s string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("tag %q isn't key=value", pair)
+		case !tagPattern.MatchString(key) || !tagPattern.MatchString(value):
+			return nil, fmt.Errorf("tag %q: keys and values are letters, digits, '.', '_' and '-'", pair)
+		case tags[key] != "":
+			return nil, fmt.Errorf("tag %q given twice", key)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// TagsFromEnv returns the tags in LFT2_ORDER_TAGS, none when it's unset.
+func TagsFromEnv() (map[string]string, error) {
+	tags, err := ParseTags(os.Getenv(TagsEnv))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", TagsEnv, err)
+	}
+	return tags, nil
+}
+
+// Tag records the tags an order was submitted with. No tags records nothing.
+func (j *Journal) Tag(orderID string, tags map[string]string, now time.Time) error {
+	orderID = strings.TrimSpace(orderID)
+	if orderID == "" {
+		return fmt.Errorf("order ID is required")
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	j.Tags = append(j.Tags, OrderTags{OrderID: orderID, Tags: tags, CreatedAt: now.UTC()})
+	return nil
+}
+
+// TagsFor returns the tags recorded against any of the given order IDs,
+// merged, with later records winning a key. Nil when there are none.
+func (j *Journal) TagsFor(orderIDs ...string) map[string]string {
+	var tags map[string]string
+	for _, t := range j.Tags {
+		for _, id := range orderIDs {
+			if id != "" && t.OrderID == id {
+				if tags == nil {
+					tags = map[string]string{}
+				}
+				for k, v := range t.Tags {
+					tags[k] = v
+				}
+				break
+			}
+		}
+	}
+	return tags
+}
+
+// FormatTags renders tags as key=value pairs sorted by key, the form
+// LFT2_ORDER_TAGS takes.
+func FormatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,142 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- Load ---
+
+func TestLoad_MissingFile(t *testing.T) {
+	j, err := Load("/nonexistent/journal.json")
+	if err != nil || len(j.Notes) != 0 {
+		t.Errorf("got %v, %v; want empty journal, nil", j, err)
+	}
+}
+
+func TestLoad_RefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	os.WriteFile(path, []byte(`{"schema_version": 99, "notes": []}`), 0644)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "schema_version 99") {
+		t.Errorf("got %v, want a schema error", err)
+	}
+}
+
+func TestLoad_UnversionedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	os.WriteFile(path, []byte(`{"notes": [{"order_id": "uuid-1", "text": "old"}]}`), 0644)
+	j, err := Load(path)
+	if err != nil || len(j.Notes) != 1 {
+		t.Errorf("got %+v, %v; want the note", j, err)
+	}
+}
+
+// --- Add / For ---
+
+func TestAdd_Validates(t *testing.T) {
+	var j Journal
+	if err := j.Add("", "text", time.Now()); err == nil {
+		t.Error("expected error for empty order ID")
+	}
+	if err := j.Add("abc", "  ", time.Now()); err == nil {
+		t.Error("expected error for empty text")
+	}
+}
+
+func TestFor_MatchesEitherID(t *testing.T) {
+	var j Journal
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	j.Add("uuid-1", "chased the gap", now)
+	j.Add("AAPL_sma_tp3_sl2_tsl1_1741615200", "sized too big", now)
+	j.Add("uuid-2", "unrelated", now)
+
+	got := j.For("uuid-1", "AAPL_sma_tp3_sl2_tsl1_1741615200")
+	if len(got) != 2 || got[0].Text != "chased the gap" || got[1].Text != "sized too big" {
+		t.Errorf("got %+v", got)
+	}
+	if len(j.For("", "")) != 0 {
+		t.Error("empty IDs should match nothing")
+	}
+}
+
+func TestTagged_FiltersByTagAndTime(t *testing.T) {
+	var j Journal
+	yesterday := time.Date(2026, 3, 9, 15, 0, 0, 0, time.UTC)
+	today := yesterday.Add(24 * time.Hour)
+	j.AddTagged("AAPL_old", ExpiredUnsubmitted, "rate limited", yesterday)
+	j.AddTagged("AAPL_new", ExpiredUnsubmitted, "rate limited", today)
+	j.Add("MSFT_note", "plain note", today)
+
+	got := j.Tagged(ExpiredUnsubmitted, today.Add(-time.Hour))
+	if len(got) != 1 || got[0].OrderID != "AAPL_new" || got[0].Tag != ExpiredUnsubmitted {
+		t.Errorf("got %+v", got)
+	}
+}
+
+// --- Save / Load ---
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	var j Journal
+	j.Add("uuid-1", "chased the gap", time.Now())
+	if err := j.Save(path); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if len(got.Notes) != 1 || got.Notes[0].OrderID != "uuid-1" {
+		t.Errorf("got %+v", got.Notes)
+	}
+	if got.SchemaVersion != 1 {
+		t.Errorf("schema_version: got %d, want 1", got.SchemaVersion)
+	}
+}
+
+// --- Tags ---
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags(" experiment=wide-stops, regime=bull ,run=gh42,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := FormatTags(tags); got != "experiment=wide-stops,regime=bull,run=gh42" {
+		t.Errorf("got %s", got)
+	}
+
+	if tags, err := ParseTags(""); err != nil || len(tags) != 0 {
+		t.Errorf("blank: got %v, %v, want no tags", tags, err)
+	}
+
+	for _, bad := range []string{"regime", "regime=", "=bull", "regime=bull market", "a=1,a=2", "run=a|b"} {
+		if _, err := ParseTags(bad); err == nil {
+			t.Errorf("%q: got nil, want an error", bad)
+		}
+	}
+}
+
+func TestTagsFor_MergesLineage(t *testing.T) {
+	var j Journal
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	j.Tag("AAPL_momentum-v1_tp1.25", map[string]string{"experiment": "a", "regime": "bull"}, now)
+	j.Tag("uuid-2", map[string]string{"regime": "bear"}, now.Add(time.Minute))
+	j.Tag("MSFT_momentum-v1_tp1.25", map[string]string{"experiment": "b"}, now)
+	if err := j.Tag("uuid-3", nil, now); err != nil || len(j.Tags) != 3 {
+		t.Errorf("no tags: got %v with %d records, want nothing recorded", err, len(j.Tags))
+	}
+	if err := j.Tag(" ", map[string]string{"a": "b"}, now); err == nil {
+		t.Error("blank order ID: want an error")
+	}
+
+	if got := FormatTags(j.TagsFor("uuid-2", "AAPL_momentum-v1_tp1.25")); got != "experiment=a,regime=bear" {
+		t.Errorf("got %s, want the later regime", got)
+	}
+	if got := j.TagsFor("uuid-9", ""); got != nil {
+		t.Errorf("untagged: got %v, want nil", got)
+	}
+}
@@ -0,0 +1,163 @@
+// Package labels turns the identifiers the pipeline writes into the words
+// report pages show: strategy names (mean_reversion-v2), exit reasons
+// (trailing_stop) and the client_order_ids entries builds from them. Every
+// page generator goes through here, so a strategy reads the same everywhere.
+// LFT2_LABELS names a JSON file that renames or translates any of them:
+//
+//	{"strategies": {"mean_reversion": "Retour à la moyenne"},
+//	 "exits": {"stop_loss": "Stop"}}
+package labels
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Built-in strategies, named as in src/entry.h
+var strategies = map[string]string{
+	"bollinger_breakout":  "Bollinger breakout",
+	"gap_fill":            "Gap fill",
+	"macd_crossover":      "MACD crossover",
+	"mean_reversion":      "Mean reversion",
+	"momentum":            "Momentum",
+	"morning_breakout":    "Morning breakout",
+	"price_dip":           "Price dip",
+	"rsi_oversold":        "RSI oversold",
+	"sma_crossover":       "SMA crossover",
+	"volatility_breakout": "Volatility breakout",
+	"volume_surge":        "Volume surge",
+}
+
+// Exit reasons, as exits writes them in FIX tag 58 and Alpaca's bracket
+// legs are classified (src/exits.cxx, internal/report)
+var exits = map[string]string{
+	"take_profit":          "Take profit",
+	"stop_loss":            "Stop loss",
+	"trailing_stop":        "Trailing stop",
+	"rule_exit":            "Exit rule",
+	"risk_off_liquidation": "Risk-off liquidation",
+	"stale_strategy":       "Strategy no longer recommended",
+}
+
+// overrides is the LFT2_LABELS file's layout.
+type overrides struct {
+	Strategies map[string]string `json:"strategies"`
+	Exits      map[string]string `json:"exits"`
+}
+
+var once sync.Once
+
+// load applies LFT2_LABELS once. A file that can't be read falls back to
+// the built-in labels with a warning rather than failing a report.
+func load() {
+	once.Do(func() {
+		path := os.Getenv("LFT2_LABELS")
+		if path == "" {
+			return
+		}
+		o, err := read(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "labels: %v, using the built-in labels\n", err)
+			return
+		}
+		apply(o)
+	})
+}
+
+// apply adds the overrides to the built-in labels.
+func apply(o overrides) {
+	for k, v := range o.Strategies {
+		strategies[k] = v
+	}
+	for k, v := range o.Exits {
+		exits[k] = v
+	}
+}
+
+func read(path string) (overrides, error) {
+	var o overrides
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return o, err
+	}
+	if err := json.Unmarshal(data, &o); err != nil {
+		return o, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return o, nil
+}
+
+// Strategy labels a strategy, with its version when it has one:
+// mean_reversion-v2 is "Mean reversion v2". A strategy without a label, such
+// as a scripted one from rules.json, has its underscores spaced out.
+func Strategy(name string) string {
+	load()
+	base, version := name, ""
+	if i := strings.LastIndex(name, "-v"); i > 0 {
+		if v, err := strconv.Atoi(name[i+2:]); err == nil && v > 0 {
+			base, version = name[:i], " v"+name[i+2:]
+		}
+	}
+	if label, ok := strategies[base]; ok {
+		return label + version
+	}
+	return humanise(base) + version
+}
+
+// Exit labels an exit reason; "" stays "".
+func Exit(reason string) string {
+	load()
+	if label, ok := exits[reason]; ok {
+		return label
+	}
+	return humanise(reason)
+}
+
+// Order labels a client_order_id written by entries,
+// {symbol}_{strategy}-v{N}_tp1.25_sl2.50_tsl1.00_p{hash}_{timestamp}[_c{cycle}],
+// as its strategy and exit levels: "Mean reversion v2 · TP 1.25% SL 2.50%
+// TSL 1.00%". Any other ID, such as an order placed by hand, is shown as it
+// is, and none as "—".
+func Order(symbol, clientOrderID string) string {
+	if clientOrderID == "" {
+		return "—"
+	}
+	rest, ok := strings.CutPrefix(clientOrderID, symbol+"_")
+	if !ok {
+		return clientOrderID
+	}
+	end := strings.LastIndex(rest, "_tp")
+	if end <= 0 {
+		return clientOrderID
+	}
+	label := Strategy(rest[:end])
+
+	var levels []string
+	for _, field := range strings.Split(rest[end+1:], "_") {
+		for _, l := range []struct{ prefix, name string }{{"tsl", "TSL"}, {"tp", "TP"}, {"sl", "SL"}} {
+			if v, ok := strings.CutPrefix(field, l.prefix); ok {
+				if _, err := strconv.ParseFloat(v, 64); err == nil {
+					levels = append(levels, l.name+" "+v+"%")
+				}
+				break
+			}
+		}
+	}
+	if len(levels) == 0 {
+		return label
+	}
+	return label + " · " + strings.Join(levels, " ")
+}
+
+// humanise spaces out an identifier and capitalises it: risk_off is
+// "Risk off".
+func humanise(id string) string {
+	s := strings.ReplaceAll(id, "_", " ")
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
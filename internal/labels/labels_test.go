@@ -0,0 +1,102 @@
+package labels
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// LFT2_LABELS is read once per process, so tests use the built-in labels
+// and apply overrides directly.
+
+// --- Strategy ---
+
+func TestStrategy(t *testing.T) {
+	cases := map[string]string{
+		"mean_reversion":    "Mean reversion",
+		"mean_reversion-v2": "Mean reversion v2",
+		"macd_crossover-v1": "MACD crossover v1",
+		"gap_and_go-v3":     "Gap and go v3", // Scripted, no label
+		"dip-vx":            "Dip-vx",
+	}
+	for name, want := range cases {
+		if got := Strategy(name); got != want {
+			t.Errorf("Strategy(%q): got %q, want %q", name, got, want)
+		}
+	}
+}
+
+// --- Exit ---
+
+func TestExit(t *testing.T) {
+	cases := map[string]string{
+		"take_profit":          "Take profit",
+		"trailing_stop":        "Trailing stop",
+		"risk_off_liquidation": "Risk-off liquidation",
+		"end_of_day":           "End of day",
+		"":                     "",
+	}
+	for reason, want := range cases {
+		if got := Exit(reason); got != want {
+			t.Errorf("Exit(%q): got %q, want %q", reason, got, want)
+		}
+	}
+}
+
+// --- Order ---
+
+func TestOrder(t *testing.T) {
+	cases := []struct{ symbol, id, want string }{
+		{"AAPL", "AAPL_mean_reversion-v2_tp1.25_sl2.50_tsl1.00_pdeadbeef_1741617300_cgh42",
+			"Mean reversion v2 · TP 1.25% SL 2.50% TSL 1.00%"},
+		{"MSFT", "MSFT_price_dip_tp3_sl2_tsl1", "Price dip · TP 3% SL 2% TSL 1%"},
+		{"BRK.B", "BRK.B_momentum-v1_tp2.00_sl1.00_tsl0.50_p1_2", "Momentum v1 · TP 2.00% SL 1.00% TSL 0.50%"},
+		{"AAPL", "manual-hedge", "manual-hedge"},
+		{"AAPL", "MSFT_momentum-v1_tp2.00_sl1.00_tsl0.50", "MSFT_momentum-v1_tp2.00_sl1.00_tsl0.50"},
+		{"AAPL", "", "—"},
+	}
+	for _, c := range cases {
+		if got := Order(c.symbol, c.id); got != c.want {
+			t.Errorf("Order(%q, %q): got %q, want %q", c.symbol, c.id, got, c.want)
+		}
+	}
+}
+
+// --- overrides ---
+
+func TestOverrides(t *testing.T) {
+	saved, savedExits := maps.Clone(strategies), maps.Clone(exits)
+	t.Cleanup(func() { strategies, exits = saved, savedExits })
+
+	path := filepath.Join(t.TempDir(), "labels.json")
+	body := `{"strategies": {"mean_reversion": "Retour à la moyenne", "gap_and_go": "Gap and go"}, "exits": {"stop_loss": "Stop"}}`
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	o, err := read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply(o)
+
+	if got := Strategy("mean_reversion-v2"); got != "Retour à la moyenne v2" {
+		t.Errorf("renamed strategy: got %q", got)
+	}
+	if got := Exit("stop_loss"); got != "Stop" {
+		t.Errorf("renamed exit: got %q", got)
+	}
+	if got := Exit("take_profit"); got != "Take profit" {
+		t.Errorf("built-in kept: got %q", got)
+	}
+
+	if _, err := read(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file: got nil, want an error")
+	}
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read(path); err == nil {
+		t.Error("malformed file: got nil, want an error")
+	}
+}
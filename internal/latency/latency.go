@@ -0,0 +1,188 @@
+// Package latency times each order from the bar that triggered it to its
+// fill, hop by hop, in docs/latency.json. Alpaca publishes a 5-minute bar
+// about 35 seconds after it closes; what's left of the bar after fetch, the
+// strategies and the broker have taken their share is the time a signal has
+// to be acted on before the next bar supersedes it.
+//
+// Execute records a sample for every order it submits, and summary fills in
+// the fill time from the broker's orders later in the cycle.
+package latency
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// DefaultPath is where execute and summary keep the samples.
+const DefaultPath = "docs/latency.json"
+
+// Window is how many samples are kept, newest last: several days of orders.
+const Window = 500
+
+// BarLength is the bar the pipeline trades on; a bar closes this long after
+// its timestamp.
+const BarLength = 5 * time.Minute
+
+// Sample is one order's timestamps, RFC 3339 in UTC. A hop whose end isn't
+// known yet is empty: Filled until the order fills.
+type Sample struct {
+	ClientOrderID string `json:"client_order_id"`
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"`
+	BarClose      string `json:"bar_close,omitempty"` // Close of the latest bar fetched for the symbol
+	Fetched       string `json:"fetched,omitempty"`   // Fetch finished writing bars
+	Signalled     string `json:"signalled,omitempty"` // Entries or exits wrote the order
+	Submitted     string `json:"submitted"`           // Execute posted it to the broker
+	Filled        string `json:"filled,omitempty"`    // The broker filled it
+}
+
+// Hop is the distribution of one hop's duration, in seconds.
+type Hop struct {
+	Name    string  `json:"name"`
+	Samples int     `json:"samples"`
+	P50     float64 `json:"p50_seconds"`
+	P90     float64 `json:"p90_seconds"`
+	Max     float64 `json:"max_seconds"`
+}
+
+// File is the on-disk layout of latency.json.
+type File struct {
+	schema.Header
+	Timestamp string   `json:"timestamp"`
+	Hops      []Hop    `json:"hops"`
+	Samples   []Sample `json:"samples"`
+}
+
+// hops are the stages timed, in pipeline order, with "total" spanning them
+// all. The publish hop includes Alpaca's delay and the wait for it.
+var hops = []struct {
+	name     string
+	from, to func(Sample) string
+}{
+	{"bar_close→fetched", func(s Sample) string { return s.BarClose }, func(s Sample) string { return s.Fetched }},
+	{"fetched→signalled", func(s Sample) string { return s.Fetched }, func(s Sample) string { return s.Signalled }},
+	{"signalled→submitted", func(s Sample) string { return s.Signalled }, func(s Sample) string { return s.Submitted }},
+	{"submitted→filled", func(s Sample) string { return s.Submitted }, func(s Sample) string { return s.Filled }},
+	{"total", func(s Sample) string { return s.BarClose }, func(s Sample) string { return s.Filled }},
+}
+
+// Load reads latency.json. A missing file has no samples.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading latency: %w", err)
+	}
+	return Parse(path, data)
+}
+
+// Parse decodes a latency.json; name labels errors.
+func Parse(name string, data []byte) (*File, error) {
+	if err := schema.Check(name, data); err != nil {
+		return nil, err
+	}
+	f := &File{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return f, nil
+}
+
+// Save summarises the samples and writes latency.json.
+func Save(path string, f *File, now time.Time) error {
+	f.Header = schema.Current()
+	f.Timestamp = now.UTC().Format(time.RFC3339)
+	f.Summarise()
+	if f.Samples == nil {
+		f.Samples = []Sample{}
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding latency: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Add records a sample, dropping the oldest beyond Window.
+func (f *File) Add(s Sample) {
+	f.Samples = append(f.Samples, s)
+	if n := len(f.Samples); n > Window {
+		f.Samples = append([]Sample(nil), f.Samples[n-Window:]...)
+	}
+}
+
+// Fill sets the fill time of the sample for clientOrderID, if it has none
+// yet. Later fills of a partially filled order don't move it. It reports
+// whether a sample was updated.
+func (f *File) Fill(clientOrderID, filled string) bool {
+	for i := len(f.Samples) - 1; i >= 0; i-- {
+		s := &f.Samples[i]
+		if s.ClientOrderID != clientOrderID {
+			continue
+		}
+		if s.Filled != "" {
+			return false
+		}
+		s.Filled = filled
+		return true
+	}
+	return false
+}
+
+// Summarise recomputes each hop's distribution from the samples with both
+// ends known.
+func (f *File) Summarise() {
+	f.Hops = make([]Hop, 0, len(hops))
+	for _, h := range hops {
+		var seconds []float64
+		for _, s := range f.Samples {
+			if d, ok := between(h.from(s), h.to(s)); ok {
+				seconds = append(seconds, d.Seconds())
+			}
+		}
+		hop := Hop{Name: h.name, Samples: len(seconds)}
+		if len(seconds) > 0 {
+			sort.Float64s(seconds)
+			hop.P50 = percentile(seconds, 0.5)
+			hop.P90 = percentile(seconds, 0.9)
+			hop.Max = round(seconds[len(seconds)-1])
+		}
+		f.Hops = append(f.Hops, hop)
+	}
+}
+
+// between is the time from one timestamp to another, if both parse.
+func between(from, to string) (time.Duration, bool) {
+	a, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return 0, false
+	}
+	b, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return 0, false
+	}
+	return b.Sub(a), true
+}
+
+// percentile interpolates linearly between the ranks either side of p.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	lo := int(rank)
+	if lo+1 >= len(sorted) {
+		return round(sorted[lo])
+	}
+	return round(sorted[lo] + (rank-float64(lo))*(sorted[lo+1]-sorted[lo]))
+}
+
+// round keeps durations to a tenth of a second.
+func round(seconds float64) float64 {
+	return math.Round(seconds*10) / 10
+}
@@ -0,0 +1,101 @@
+package latency
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// The 10:00 bar closes at 10:05; times are UTC
+func sample(id string, fetched, signalled, submitted, filled string) Sample {
+	return Sample{
+		ClientOrderID: id, Symbol: "AAPL", Side: "buy",
+		BarClose:  "2026-03-10T15:05:00Z",
+		Fetched:   fetched,
+		Signalled: signalled,
+		Submitted: submitted,
+		Filled:    filled,
+	}
+}
+
+// --- Summarise ---
+
+func TestSummarise(t *testing.T) {
+	f := &File{}
+	f.Add(sample("a", "2026-03-10T15:05:50Z", "2026-03-10T15:06:30Z", "2026-03-10T15:06:40Z", "2026-03-10T15:06:41.5Z"))
+	f.Add(sample("b", "2026-03-10T15:06:10Z", "2026-03-10T15:07:30Z", "2026-03-10T15:07:32Z", ""))
+	f.Add(sample("c", "2026-03-10T15:06:00Z", "2026-03-10T15:07:00Z", "2026-03-10T15:07:04Z", "2026-03-10T15:07:05Z"))
+	f.Summarise()
+
+	want := []Hop{
+		{Name: "bar_close→fetched", Samples: 3, P50: 60, P90: 68, Max: 70},
+		{Name: "fetched→signalled", Samples: 3, P50: 60, P90: 76, Max: 80},
+		{Name: "signalled→submitted", Samples: 3, P50: 4, P90: 8.8, Max: 10},
+		{Name: "submitted→filled", Samples: 2, P50: 1.3, P90: 1.5, Max: 1.5}, // b hasn't filled
+		{Name: "total", Samples: 2, P50: 113.3, P90: 122.7, Max: 125},
+	}
+	if got := fmt.Sprint(f.Hops); got != fmt.Sprint(want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+}
+
+func TestSummarise_MissingTimes(t *testing.T) {
+	f := &File{Samples: []Sample{{ClientOrderID: "x", Submitted: "2026-03-10T15:07:00Z"}}}
+	f.Summarise()
+	for _, h := range f.Hops {
+		if h.Samples != 0 {
+			t.Errorf("%s: %d samples from an order with only its submit time", h.Name, h.Samples)
+		}
+	}
+}
+
+// --- Add / Fill ---
+
+func TestAdd_KeepsWindow(t *testing.T) {
+	f := &File{}
+	for i := 0; i < Window+5; i++ {
+		f.Add(Sample{ClientOrderID: fmt.Sprint(i)})
+	}
+	if len(f.Samples) != Window || f.Samples[0].ClientOrderID != "5" {
+		t.Errorf("got %d samples from %s, want %d from 5", len(f.Samples), f.Samples[0].ClientOrderID, Window)
+	}
+}
+
+func TestFill(t *testing.T) {
+	f := &File{Samples: []Sample{{ClientOrderID: "a"}, {ClientOrderID: "b"}}}
+	if !f.Fill("b", "2026-03-10T15:07:05Z") {
+		t.Fatal("b not filled")
+	}
+	if f.Fill("b", "2026-03-10T15:09:00Z") {
+		t.Error("a later partial fill moved the fill time")
+	}
+	if f.Fill("unknown", "2026-03-10T15:07:05Z") {
+		t.Error("filled an order with no sample")
+	}
+	if f.Samples[0].Filled != "" || f.Samples[1].Filled != "2026-03-10T15:07:05Z" {
+		t.Errorf("got %+v", f.Samples)
+	}
+}
+
+// --- Load / Save ---
+
+func TestLoadSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "latency.json")
+	f, err := Load(path)
+	if err != nil || len(f.Samples) != 0 {
+		t.Fatalf("missing file: got %+v, %v", f, err)
+	}
+
+	f.Add(sample("a", "2026-03-10T15:05:50Z", "2026-03-10T15:06:30Z", "2026-03-10T15:06:40Z", ""))
+	if err := Save(path, f, time.Date(2026, 3, 10, 15, 7, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Samples) != 1 || got.Timestamp != "2026-03-10T15:07:00Z" || got.Hops[0].P50 != 50 {
+		t.Errorf("round trip: got %+v", got)
+	}
+}
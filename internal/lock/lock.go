@@ -0,0 +1,126 @@
+// Package lock keeps the pipeline's heavy jobs from running over each other
+// on one host. The nightly backtest and the live cycle both take the same
+// lock file; whichever comes second waits or gives up rather than competing
+// for the CPU and the bar files.
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+	"time"
+)
+
+// DefaultPath is the lock file, in the repo root beside journal.json.
+const DefaultPath = "lft2.lock"
+
+// Holder is what the lock file says about the process holding it.
+type Holder struct {
+	Name  string `json:"name"` // What it's running, e.g. "backtest"
+	PID   int    `json:"pid"`
+	Since string `json:"since"` // RFC3339
+}
+
+// HeldError is returned when another live process has the lock.
+type HeldError struct {
+	Holder Holder
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("locked by %s (pid %d) since %s", e.Holder.Name, e.Holder.PID, e.Holder.Since)
+}
+
+// Lock is a held lock file.
+type Lock struct {
+	path string
+}
+
+// TryAcquire creates the lock file at path for name. A file left by a
+// process that has since died is taken over; one held by a live process is
+// a *HeldError.
+func TryAcquire(path, name string) (*Lock, error) {
+	holder, _ := json.Marshal(Holder{Name: name, PID: os.Getpid(), Since: time.Now().UTC().Format(time.RFC3339)})
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = f.Write(append(holder, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		h, err := Read(path)
+		if err != nil {
+			return nil, err
+		}
+		if alive(h.PID) {
+			return nil, &HeldError{Holder: h}
+		}
+		// Stale: its holder was killed before it could let go
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%s: taken by another process while clearing a stale lock", path)
+}
+
+// Acquire is TryAcquire, retried every poll while the lock is held until
+// ctx is done, when it returns the last *HeldError.
+func Acquire(ctx context.Context, path, name string, poll time.Duration) (*Lock, error) {
+	for {
+		l, err := TryAcquire(path, name)
+		var held *HeldError
+		if !errors.As(err, &held) {
+			return l, err
+		}
+		t := time.NewTimer(poll)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, err
+		}
+	}
+}
+
+// Release removes the lock file.
+func (l *Lock) Release() error {
+	return os.Remove(l.path)
+}
+
+// Read returns the holder recorded in the lock file at path. A file too
+// short to parse, as while its holder is still writing it, reads as held by
+// this process so it isn't mistaken for stale.
+func Read(path string) (Holder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Holder{}, err
+	}
+	var h Holder
+	if err := json.Unmarshal(data, &h); err != nil || h.PID <= 0 {
+		return Holder{Name: "unknown", PID: os.Getpid()}, nil
+	}
+	return h, nil
+}
+
+// alive reports whether a process with pid exists.
+func alive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,91 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTryAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lft2.lock")
+	l, err := TryAcquire(path, "backtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, err := Read(path); err != nil || h.Name != "backtest" || h.PID != os.Getpid() {
+		t.Errorf("holder: got %+v, %v", h, err)
+	}
+
+	var held *HeldError
+	if _, err := TryAcquire(path, "live"); !errors.As(err, &held) || held.Holder.Name != "backtest" {
+		t.Errorf("second acquire: got %v", err)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	l, err = TryAcquire(path, "live")
+	if err != nil {
+		t.Fatalf("after release: %v", err)
+	}
+	l.Release()
+}
+
+func TestTryAcquire_Stale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lft2.lock")
+	// No process has pid 1<<30: the kernel caps them well below it
+	if err := os.WriteFile(path, []byte(`{"name": "backtest", "pid": 1073741824}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := TryAcquire(path, "live")
+	if err != nil {
+		t.Fatalf("stale lock not taken over: %v", err)
+	}
+	if h, _ := Read(path); h.Name != "live" {
+		t.Errorf("holder: got %+v", h)
+	}
+	l.Release()
+}
+
+func TestTryAcquire_Partial(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lft2.lock")
+	// A holder still writing the file mustn't be taken for stale
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var held *HeldError
+	if _, err := TryAcquire(path, "live"); !errors.As(err, &held) {
+		t.Errorf("got %v, want held", err)
+	}
+}
+
+func TestAcquire_Waits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lft2.lock")
+	first, err := TryAcquire(path, "backtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		first.Release()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	l, err := Acquire(ctx, path, "live", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("waiting for release: %v", err)
+	}
+	l.Release()
+
+	first, _ = TryAcquire(path, "backtest")
+	defer first.Release()
+	var held *HeldError
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := Acquire(ctx, path, "live", 10*time.Millisecond); !errors.As(err, &held) {
+		t.Errorf("timed out: got %v", err)
+	}
+}
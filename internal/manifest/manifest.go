@@ -0,0 +1,151 @@
+// Package manifest describes a set of per-symbol bar files in
+// docs/bars-manifest.json: each file's bar count, first and last timestamps
+// and SHA-256. Fetch writes it once every file is saved; filter and backtest
+// verify against it, so a partially published or corrupted set (an
+// interrupted Pages deploy, say) is caught before it drives trades.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// DefaultPath is where fetch writes the manifest, beside the bars directory
+// rather than in it so it isn't mistaken for a symbol.
+const DefaultPath = "docs/bars-manifest.json"
+
+// Entry describes one bar file.
+type Entry struct {
+	Symbol       string `json:"symbol"`
+	Count        int    `json:"count"`
+	FirstBarTime string `json:"first_bar_time"`
+	LastBarTime  string `json:"last_bar_time"`
+	SHA256       string `json:"sha256"` // Of the file's bytes as written, compressed or not
+}
+
+// File is the layout of bars-manifest.json.
+type File struct {
+	schema.Header
+	Timestamp string  `json:"timestamp"`
+	Symbols   []Entry `json:"symbols"` // Sorted by symbol
+}
+
+// Sum is the hex SHA-256 of data.
+func Sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Describe builds the entry for one bar file's contents, gzipped or not.
+func Describe(symbol string, data []byte) (Entry, error) {
+	decoded, err := barfile.Decode(data)
+	if err != nil {
+		return Entry{}, fmt.Errorf("%s: %w", symbol, err)
+	}
+	var bars struct {
+		Bars []struct {
+			Timestamp string `json:"t"`
+		} `json:"bars"`
+	}
+	if err := json.Unmarshal(decoded, &bars); err != nil {
+		return Entry{}, fmt.Errorf("%s: %w", symbol, err)
+	}
+	e := Entry{Symbol: symbol, Count: len(bars.Bars), SHA256: Sum(data)}
+	if n := len(bars.Bars); n > 0 {
+		e.FirstBarTime, e.LastBarTime = bars.Bars[0].Timestamp, bars.Bars[n-1].Timestamp
+	}
+	return e, nil
+}
+
+// Build describes every {SYMBOL}.json and {SYMBOL}.json.gz in dir.
+func Build(dir string, now time.Time) (File, error) {
+	symbols, err := barfile.Symbols(dir)
+	if err != nil {
+		return File{}, err
+	}
+	f := File{Header: schema.Current(), Timestamp: now.UTC().Format(time.RFC3339), Symbols: []Entry{}}
+	for _, symbol := range symbols {
+		data, err := barfile.ReadRaw(dir, symbol)
+		if err != nil {
+			return File{}, err
+		}
+		entry, err := Describe(symbol, data)
+		if err != nil {
+			return File{}, err
+		}
+		f.Symbols = append(f.Symbols, entry)
+	}
+	return f, nil
+}
+
+// Save writes the manifest.
+func Save(path string, f File) error {
+	f.Header = schema.Current()
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Parse reads a manifest; name is used in errors.
+func Parse(name string, data []byte) (File, error) {
+	if err := schema.Check(name, data); err != nil {
+		return File{}, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return File{}, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return f, nil
+}
+
+// Verify returns "" if data is the bar file the manifest lists for symbol,
+// or what's wrong with it.
+func (f File) Verify(symbol string, data []byte) string {
+	for _, e := range f.Symbols {
+		if e.Symbol != symbol {
+			continue
+		}
+		if sum := Sum(data); sum != e.SHA256 {
+			return fmt.Sprintf("checksum mismatch (%.12s, manifest %.12s)", sum, e.SHA256)
+		}
+		return ""
+	}
+	return "not in manifest"
+}
+
+// Put adds or replaces the entry for e's symbol, keeping the list sorted.
+func (f *File) Put(e Entry) {
+	i := sort.Search(len(f.Symbols), func(i int) bool { return f.Symbols[i].Symbol >= e.Symbol })
+	if i < len(f.Symbols) && f.Symbols[i].Symbol == e.Symbol {
+		f.Symbols[i] = e
+		return
+	}
+	f.Symbols = append(f.Symbols, Entry{})
+	copy(f.Symbols[i+1:], f.Symbols[i:])
+	f.Symbols[i] = e
+}
+
+// Missing returns the symbols the manifest lists that aren't in present.
+func (f File) Missing(present []string) []string {
+	have := make(map[string]bool, len(present))
+	for _, s := range present {
+		have[s] = true
+	}
+	var missing []string
+	for _, e := range f.Symbols {
+		if !have[e.Symbol] {
+			missing = append(missing, e.Symbol)
+		}
+	}
+	return missing
+}
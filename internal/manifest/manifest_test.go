@@ -0,0 +1,98 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- Describe / Sum ---
+
+func TestDescribe(t *testing.T) {
+	data := []byte(`{"symbol":"AAPL","bars":[{"t":"2026-03-02T14:30:00Z"},{"t":"2026-03-02T14:35:00Z"}]}`)
+	e, err := Describe("AAPL", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Count != 2 || e.FirstBarTime != "2026-03-02T14:30:00Z" || e.LastBarTime != "2026-03-02T14:35:00Z" {
+		t.Errorf("got %+v", e)
+	}
+	if e.SHA256 != Sum(data) || len(e.SHA256) != 64 {
+		t.Errorf("sha256 %q", e.SHA256)
+	}
+	if Sum([]byte("abc")) != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Error("Sum should be SHA-256")
+	}
+}
+
+// --- Build / Save / Parse / Verify ---
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	bars := filepath.Join(dir, "bars")
+	if err := os.Mkdir(bars, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"MSFT": `{"symbol":"MSFT","bars":[{"t":"2026-03-02T14:30:00Z"}]}`,
+		"AAPL": `{"symbol":"AAPL","bars":[]}`,
+	}
+	for symbol, content := range files {
+		if err := os.WriteFile(filepath.Join(bars, symbol+".json"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	built, err := Build(bars, time.Date(2026, 3, 2, 21, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "bars-manifest.json")
+	if err := Save(path, built); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(path, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.Symbols) != 2 || f.Symbols[0].Symbol != "AAPL" || f.SchemaVersion != 1 {
+		t.Fatalf("got %+v", f)
+	}
+	if got := f.Verify("MSFT", []byte(files["MSFT"])); got != "" {
+		t.Errorf("intact file: got %q", got)
+	}
+	if got := f.Verify("MSFT", []byte(files["MSFT"][:20])); !strings.HasPrefix(got, "checksum mismatch") {
+		t.Errorf("truncated file: got %q", got)
+	}
+	if got := f.Verify("TSLA", []byte(`{}`)); got != "not in manifest" {
+		t.Errorf("unlisted file: got %q", got)
+	}
+	if got := f.Missing([]string{"MSFT"}); len(got) != 1 || got[0] != "AAPL" {
+		t.Errorf("missing: got %v", got)
+	}
+}
+
+// --- Put ---
+
+func TestPut(t *testing.T) {
+	var f File
+	for _, s := range []string{"MSFT", "AAPL", "TSLA"} {
+		f.Put(Entry{Symbol: s, Count: 1})
+	}
+	f.Put(Entry{Symbol: "MSFT", Count: 2})
+
+	var got []string
+	for _, e := range f.Symbols {
+		got = append(got, e.Symbol)
+	}
+	if strings.Join(got, " ") != "AAPL MSFT TSLA" || f.Symbols[1].Count != 2 {
+		t.Errorf("got %+v", f.Symbols)
+	}
+}
@@ -0,0 +1,86 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// Alpaca serves market data from Alpaca's data API, and the clock from its
+// trading API.
+type Alpaca struct {
+	Client  alpaca.Client
+	BarFeed string // sip or iex; empty leaves it to Alpaca
+	Do      Doer
+}
+
+func (a *Alpaca) Name() string { return AlpacaName }
+
+func (a *Alpaca) Feed() string { return EffectiveFeed(a.BarFeed) }
+
+// alpacaTimeframe names a bar length as Alpaca takes it: minutes up to 59,
+// then whole hours, so a 60-minute book asks for 1Hour rather than 60Min.
+func alpacaTimeframe(minutes int) string {
+	if minutes >= 60 && minutes%60 == 0 && minutes < 24*60 {
+		return fmt.Sprintf("%dHour", minutes/60)
+	}
+	return fmt.Sprintf("%dMin", minutes)
+}
+
+// GetBars asks for the bars newest first from r.Start, so the limit keeps
+// the most recent. Without a start bound Alpaca only returns today's. The
+// feed is only named when one was chosen: not every plan may ask for sip.
+func (a *Alpaca) GetBars(symbol string, r BarsRequest) ([]Bar, error) {
+	url := fmt.Sprintf("%s/v2/stocks/%s/bars?timeframe=%s&limit=%d&sort=desc&start=%s",
+		a.Client.DataURL, symbol, alpacaTimeframe(r.TimeframeMin), r.Limit, r.Start)
+	if a.BarFeed != "" {
+		url += "&feed=" + a.BarFeed
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	a.Client.Authorize(req)
+
+	body, err := a.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Bars []Bar `json:"bars"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing bars: %w", err)
+	}
+	return recent(resp.Bars, r.Limit), nil
+}
+
+func (a *Alpaca) GetQuotes(symbols []string) (map[string]Quote, error) {
+	got, err := a.Client.LatestQuotes(symbols)
+	if err != nil {
+		return nil, err
+	}
+	quotes := make(map[string]Quote, len(got))
+	for symbol, q := range got {
+		quotes[symbol] = Quote(q)
+	}
+	return quotes, nil
+}
+
+func (a *Alpaca) GetClock() (Clock, error) {
+	body, err := a.Client.Get(a.Client.BaseURL + "/v2/clock")
+	if err != nil {
+		return Clock{}, err
+	}
+	var resp struct {
+		Timestamp time.Time `json:"timestamp"`
+		IsOpen    bool      `json:"is_open"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Clock{}, fmt.Errorf("parsing clock: %w", err)
+	}
+	return Clock{Open: resp.IsOpen, Time: resp.Timestamp}, nil
+}
@@ -0,0 +1,41 @@
+package marketdata
+
+import (
+	"fmt"
+	"os"
+)
+
+// DefaultFeed is recorded for bars asked for without naming a feed. Alpaca
+// then picks one by subscription, sip on a paid plan and iex on the free
+// one, so which tape they came from isn't known, only that it's the same
+// as the next request without a feed gets.
+const DefaultFeed = "default"
+
+// feeds are the bar feeds Alpaca recognises. IEX is one exchange's prints:
+// free on every plan, but thinner bars and gaps in quieter symbols.
+var feeds = map[string]bool{"sip": true, "iex": true}
+
+// FeedFromEnv returns ALPACA_FEED, or "" when unset, so requests leave the
+// feed to Alpaca as they did before it could be chosen.
+func FeedFromEnv() string {
+	return os.Getenv("ALPACA_FEED")
+}
+
+// CheckFeed reports an error for a feed Alpaca wouldn't recognise, before
+// every symbol fails with it. Empty is Alpaca's default.
+func CheckFeed(feed string) error {
+	if feed != "" && !feeds[feed] {
+		return fmt.Errorf("unknown feed %q: want sip or iex", feed)
+	}
+	return nil
+}
+
+// EffectiveFeed is the feed recorded for bars asked for from feed: feed
+// itself, or DefaultFeed when none was named. Bar files from before the
+// feed was recorded have none, and were fetched without naming one too.
+func EffectiveFeed(feed string) string {
+	if feed == "" {
+		return DefaultFeed
+	}
+	return feed
+}
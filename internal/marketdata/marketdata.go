@@ -0,0 +1,147 @@
+// Package marketdata puts the market data fetch reads behind one interface,
+// so bars, quotes and the market clock can come from Alpaca or Polygon.io,
+// with Yahoo Finance as a fallback for bars. Orders, positions, the calendar
+// and asset metadata stay on Alpaca's trading API whichever provider serves
+// the data.
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// Providers by name, as LFT2_DATA_PROVIDER and fetch's -provider take them
+const (
+	AlpacaName  = "alpaca"
+	PolygonName = "polygon"
+	YahooName   = "yahoo"
+)
+
+// DefaultProvider serves the data when LFT2_DATA_PROVIDER is unset.
+const DefaultProvider = AlpacaName
+
+// ErrUnsupported is returned for data a provider doesn't offer.
+var ErrUnsupported = errors.New("not supported")
+
+// MaxQuoteSymbols is how many symbols one GetQuotes call may name, whichever
+// the provider.
+const MaxQuoteSymbols = alpaca.MaxQuoteSymbols
+
+// Bar is one OHLCV bar, in the layout the bar files keep.
+type Bar struct {
+	Timestamp string  `json:"t"` // RFC3339, UTC
+	Open      float64 `json:"o"`
+	High      float64 `json:"h"`
+	Low       float64 `json:"l"`
+	Close     float64 `json:"c"`
+	Volume    int64   `json:"v"`
+}
+
+// Quote is the latest NBBO for a symbol.
+type Quote struct {
+	Timestamp string // RFC3339, to the nanosecond where the provider gives it
+	BidPrice  float64
+	BidSize   float64
+	AskPrice  float64
+	AskSize   float64
+}
+
+// Clock is the market's state as the provider sees it.
+type Clock struct {
+	Open bool
+	Time time.Time // The provider's time when it answered
+}
+
+// BarsRequest asks for the most recent Limit bars of TimeframeMin minutes,
+// none earlier than Start.
+type BarsRequest struct {
+	TimeframeMin int
+	Limit        int
+	Start        string // RFC3339
+}
+
+// Doer sends a request and returns the body of a 200, or an error. Fetch
+// passes its rate limiter, so every provider is paced and retried alike.
+type Doer func(*http.Request) ([]byte, error)
+
+// Provider is a source of market data.
+type Provider interface {
+	// Name is the provider's name, as Open takes it.
+	Name() string
+
+	// Feed is the tape the bars come from, sip or iex: what backtests
+	// trained on.
+	Feed() string
+
+	// GetBars returns up to r.Limit of the most recent bars, oldest first.
+	// A symbol without bars in the range returns none and no error.
+	GetBars(symbol string, r BarsRequest) ([]Bar, error)
+
+	// GetQuotes returns the latest quote for each of up to MaxQuoteSymbols
+	// symbols, keyed by symbol. Symbols without a quote are absent.
+	GetQuotes(symbols []string) (map[string]Quote, error)
+
+	// GetClock returns whether the market is open now.
+	GetClock() (Clock, error)
+}
+
+// Names returns the providers Open knows, sorted.
+func Names() []string {
+	names := []string{AlpacaName, PolygonName, YahooName}
+	sort.Strings(names)
+	return names
+}
+
+// FromEnv returns LFT2_DATA_PROVIDER, or DefaultProvider when unset.
+func FromEnv() string {
+	if name := os.Getenv("LFT2_DATA_PROVIDER"); name != "" {
+		return name
+	}
+	return DefaultProvider
+}
+
+// FallbackFromEnv returns LFT2_FALLBACK_PROVIDER, the provider asked for a
+// symbol's bars when the main one has none, or "" for no fallback.
+func FallbackFromEnv() string {
+	return os.Getenv("LFT2_FALLBACK_PROVIDER")
+}
+
+// Open returns the named provider. Alpaca serves bars from feed with the
+// client's credentials; Polygon reads POLYGON_API_KEY and, for a mock or a
+// proxy, POLYGON_BASE_URL; Yahoo needs no key, and reads YAHOO_BASE_URL.
+// Every data request goes through do.
+func Open(name string, client alpaca.Client, feed string, do Doer) (Provider, error) {
+	switch name {
+	case AlpacaName:
+		return &Alpaca{Client: client, BarFeed: feed, Do: do}, nil
+	case PolygonName:
+		key := os.Getenv("POLYGON_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("POLYGON_API_KEY required for the %s provider", PolygonName)
+		}
+		return &Polygon{BaseURL: os.Getenv("POLYGON_BASE_URL"), APIKey: key, Do: do}, nil
+	case YahooName:
+		return &Yahoo{BaseURL: os.Getenv("YAHOO_BASE_URL"), Do: do}, nil
+	default:
+		return nil, fmt.Errorf("unknown data provider %q: want one of %s", name, strings.Join(Names(), ", "))
+	}
+}
+
+// recent keeps the most recent limit of bars, given newest first, and puts
+// them in chronological order.
+func recent(bars []Bar, limit int) []Bar {
+	if limit > 0 && len(bars) > limit {
+		bars = bars[:limit]
+	}
+	for i, j := 0, len(bars)-1; i < j; i, j = i+1, j-1 {
+		bars[i], bars[j] = bars[j], bars[i]
+	}
+	return bars
+}
@@ -0,0 +1,309 @@
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// get is a Doer without fetch's rate limiter.
+func get(req *http.Request) ([]byte, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// --- Open ---
+
+func TestOpen(t *testing.T) {
+	t.Setenv("POLYGON_API_KEY", "")
+	client := alpaca.New("k", "s", "", "")
+	if p, err := Open(AlpacaName, client, "iex", get); err != nil || p.Name() != AlpacaName || p.Feed() != "iex" {
+		t.Errorf("alpaca: got %v, %v", p, err)
+	}
+	if _, err := Open(PolygonName, client, "iex", get); err == nil {
+		t.Error("polygon without POLYGON_API_KEY: want an error")
+	}
+	t.Setenv("POLYGON_API_KEY", "pk")
+	if p, err := Open(PolygonName, client, "iex", get); err != nil || p.Name() != PolygonName || p.Feed() != "sip" {
+		t.Errorf("polygon: got %v, %v", p, err)
+	}
+	if p, err := Open(YahooName, client, "iex", get); err != nil || p.Name() != YahooName {
+		t.Errorf("yahoo: got %v, %v", p, err)
+	}
+	if _, err := Open("iex", client, "iex", get); err == nil {
+		t.Error("unknown provider: want an error")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("LFT2_DATA_PROVIDER", "")
+	if got := FromEnv(); got != DefaultProvider {
+		t.Errorf("unset: got %q", got)
+	}
+	t.Setenv("LFT2_DATA_PROVIDER", PolygonName)
+	if got := FromEnv(); got != PolygonName {
+		t.Errorf("got %q", got)
+	}
+}
+
+// --- Alpaca ---
+
+func TestAlpacaGetBars(t *testing.T) {
+	var query, key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, key = r.URL.RawQuery, r.Header.Get("APCA-API-KEY-ID")
+		fmt.Fprint(w, `{"bars": [
+			{"t": "2026-03-10T15:05:00Z", "o": 2, "h": 2, "l": 2, "c": 2, "v": 20},
+			{"t": "2026-03-10T15:00:00Z", "o": 1, "h": 1, "l": 1, "c": 1, "v": 10},
+			{"t": "2026-03-10T14:55:00Z", "o": 0, "h": 0, "l": 0, "c": 0, "v": 5}
+		]}`)
+	}))
+	defer srv.Close()
+
+	p := &Alpaca{Client: alpaca.New("k", "s", "", srv.URL), BarFeed: "iex", Do: get}
+	bars, err := p.GetBars("AAPL", BarsRequest{TimeframeMin: 5, Limit: 2, Start: "2026-03-01T00:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "timeframe=5Min&limit=2&sort=desc&start=2026-03-01T00:00:00Z&feed=iex"; query != want {
+		t.Errorf("query: got %q, want %q", query, want)
+	}
+	if key != "k" {
+		t.Errorf("key header: got %q", key)
+	}
+	if len(bars) != 2 || bars[0].Close != 1 || bars[1].Close != 2 {
+		t.Errorf("want the two most recent, oldest first: got %+v", bars)
+	}
+}
+
+func TestAlpacaTimeframe(t *testing.T) {
+	for minutes, want := range map[int]string{5: "5Min", 59: "59Min", 60: "1Hour", 90: "90Min", 240: "4Hour"} {
+		if got := alpacaTimeframe(minutes); got != want {
+			t.Errorf("%d minutes: got %s, want %s", minutes, got, want)
+		}
+	}
+}
+
+func TestAlpacaGetClock(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/clock" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"timestamp": "2026-03-10T11:00:00-04:00", "is_open": true}`)
+	}))
+	defer srv.Close()
+
+	clock, err := (&Alpaca{Client: alpaca.New("k", "s", srv.URL, ""), Do: get}).GetClock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !clock.Open || clock.Time.UTC().Hour() != 15 {
+		t.Errorf("got %+v", clock)
+	}
+}
+
+// --- Polygon ---
+
+func TestPolygonGetBars(t *testing.T) {
+	var path, query, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query, auth = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"results": [
+			{"t": 1773155100000, "o": 2, "h": 2.5, "l": 1.5, "c": 2, "v": 200.4},
+			{"t": 1773154800000, "o": 1, "h": 1.5, "l": 0.5, "c": 1, "v": 100}
+		]}`)
+	}))
+	defer srv.Close()
+
+	p := &Polygon{BaseURL: srv.URL, APIKey: "pk", Do: get}
+	bars, err := p.GetBars("AAPL", BarsRequest{TimeframeMin: 5, Limit: 10, Start: "2026-03-01T00:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(path, "/v2/aggs/ticker/AAPL/range/5/minute/1772323200000/") {
+		t.Errorf("path: got %q", path)
+	}
+	if query != "adjusted=false&limit=50&sort=desc" {
+		t.Errorf("query: got %q", query)
+	}
+	if auth != "Bearer pk" {
+		t.Errorf("auth: got %q", auth)
+	}
+	want := []Bar{
+		{Timestamp: "2026-03-10T15:00:00Z", Open: 1, High: 1.5, Low: 0.5, Close: 1, Volume: 100},
+		{Timestamp: "2026-03-10T15:05:00Z", Open: 2, High: 2.5, Low: 1.5, Close: 2, Volume: 200},
+	}
+	if len(bars) != len(want) || bars[0] != want[0] || bars[1] != want[1] {
+		t.Errorf("got %+v, want %+v", bars, want)
+	}
+}
+
+func TestPolygonGetBars_BadStart(t *testing.T) {
+	p := &Polygon{APIKey: "pk", Do: get}
+	if _, err := p.GetBars("AAPL", BarsRequest{TimeframeMin: 5, Limit: 10, Start: "yesterday"}); err == nil {
+		t.Error("want an error")
+	}
+}
+
+func TestPolygonGetQuotes(t *testing.T) {
+	var tickers string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tickers = r.URL.Query().Get("tickers")
+		fmt.Fprint(w, `{"tickers": [
+			{"ticker": "AAPL", "lastQuote": {"P": 200.02, "S": 5, "p": 199.98, "s": 3, "t": 1773154800123456789}},
+			{"ticker": "NOPE", "lastQuote": {}}
+		]}`)
+	}))
+	defer srv.Close()
+
+	quotes, err := (&Polygon{BaseURL: srv.URL, APIKey: "pk", Do: get}).GetQuotes([]string{"AAPL", "NOPE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tickers != "AAPL,NOPE" {
+		t.Errorf("tickers: got %q", tickers)
+	}
+	want := Quote{Timestamp: "2026-03-10T15:00:00.123456789Z", BidPrice: 199.98, BidSize: 3, AskPrice: 200.02, AskSize: 5}
+	if quotes["AAPL"] != want {
+		t.Errorf("got %+v, want %+v", quotes["AAPL"], want)
+	}
+	if _, ok := quotes["NOPE"]; ok {
+		t.Error("unquoted symbol present")
+	}
+
+	if _, err := (&Polygon{BaseURL: srv.URL, APIKey: "pk", Do: get}).GetQuotes(make([]string, MaxQuoteSymbols+1)); err == nil {
+		t.Error("over the symbol limit: want an error")
+	}
+}
+
+func TestPolygonGetClock(t *testing.T) {
+	for market, open := range map[string]bool{"open": true, "extended-hours": false, "closed": false} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"market": %q, "serverTime": "2026-03-10T11:00:00-04:00"}`, market)
+		}))
+		clock, err := (&Polygon{BaseURL: srv.URL, APIKey: "pk", Do: get}).GetClock()
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if clock.Open != open {
+			t.Errorf("%s: got open=%t", market, clock.Open)
+		}
+	}
+}
+
+// --- Yahoo ---
+
+func TestYahooGetBars(t *testing.T) {
+	var path, query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query().Get("interval")
+		fmt.Fprint(w, `{"chart": {"result": [{
+			"timestamp": [1773154500, 1773154800, 1773155100],
+			"indicators": {"quote": [{
+				"open":   [0.5, null, 2],
+				"high":   [0.5, null, 2.5],
+				"low":    [0.5, null, 1.5],
+				"close":  [0.5, null, 2],
+				"volume": [50, null, 200.4]
+			}]}
+		}], "error": null}}`)
+	}))
+	defer srv.Close()
+
+	p := &Yahoo{BaseURL: srv.URL, Do: get}
+	bars, err := p.GetBars("ABCD", BarsRequest{TimeframeMin: 5, Limit: 1, Start: "2026-03-01T00:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v8/finance/chart/ABCD" || query != "5m" {
+		t.Errorf("got %s interval %s", path, query)
+	}
+	// The empty bar is dropped and the limit keeps the most recent
+	want := Bar{Timestamp: "2026-03-10T15:05:00Z", Open: 2, High: 2.5, Low: 1.5, Close: 2, Volume: 200}
+	if len(bars) != 1 || bars[0] != want {
+		t.Errorf("got %+v, want %+v", bars, want)
+	}
+
+	if _, err := p.GetBars("ABCD", BarsRequest{TimeframeMin: 10, Limit: 1, Start: "2026-03-01T00:00:00Z"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("10 minute bars: got %v, want ErrUnsupported", err)
+	}
+	if _, err := p.GetQuotes([]string{"ABCD"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("quotes: got %v, want ErrUnsupported", err)
+	}
+}
+
+func TestYahooGetBars_Empty(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"chart": {"result": [{"meta": {}, "indicators": {"quote": [{}]}}], "error": null}}`)
+	}))
+	defer srv.Close()
+
+	bars, err := (&Yahoo{BaseURL: srv.URL, Do: get}).GetBars("ABCD", BarsRequest{TimeframeMin: 5, Limit: 10, Start: "2026-03-01T00:00:00Z"})
+	if err != nil || len(bars) != 0 {
+		t.Errorf("got %+v, %v, want no bars and no error", bars, err)
+	}
+}
+
+func TestAlpacaGetBars_DefaultFeed(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		fmt.Fprint(w, `{"bars": []}`)
+	}))
+	defer srv.Close()
+
+	// Unchosen, the feed is left to Alpaca, which not every plan allows sip
+	p := &Alpaca{Client: alpaca.New("k", "s", "", srv.URL), Do: get}
+	if _, err := p.GetBars("AAPL", BarsRequest{TimeframeMin: 5, Limit: 2, Start: "2026-03-01T00:00:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(query, "feed=") {
+		t.Errorf("query names a feed: %q", query)
+	}
+	if p.Feed() != DefaultFeed || p.Feed() == "sip" {
+		t.Errorf("feed: got %q, want %s, not a tape the plan may not serve", p.Feed(), DefaultFeed)
+	}
+}
+
+func TestFeedFromEnv(t *testing.T) {
+	t.Setenv("ALPACA_FEED", "")
+	if got := FeedFromEnv(); got != "" {
+		t.Errorf("unset: got %q, want none", got)
+	}
+	t.Setenv("ALPACA_FEED", "iex")
+	if got := FeedFromEnv(); got != "iex" {
+		t.Errorf("got %q, want iex", got)
+	}
+}
+
+func TestCheckFeed(t *testing.T) {
+	for _, feed := range []string{"", "sip", "iex"} {
+		if err := CheckFeed(feed); err != nil {
+			t.Errorf("%q: %v", feed, err)
+		}
+	}
+	for _, feed := range []string{"SIP", "otc"} {
+		if err := CheckFeed(feed); err == nil {
+			t.Errorf("%q: got nil, want an error", feed)
+		}
+	}
+}
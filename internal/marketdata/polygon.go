@@ -0,0 +1,149 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// polygonURL is Polygon.io's REST API.
+const polygonURL = "https://api.polygon.io"
+
+// polygonMaxBase is the most minute bars one aggregates request may build
+// its bars from.
+const polygonMaxBase = 50000
+
+// Polygon serves market data from Polygon.io. Its aggregates are built from
+// every exchange's prints, so its bars are the consolidated tape.
+type Polygon struct {
+	BaseURL string // polygonURL when empty
+	APIKey  string
+	Do      Doer
+}
+
+func (p *Polygon) Name() string { return PolygonName }
+
+func (p *Polygon) Feed() string { return "sip" }
+
+// get sends an authenticated GET for path and query.
+func (p *Polygon) get(path string, query url.Values) ([]byte, error) {
+	base := p.BaseURL
+	if base == "" {
+		base = polygonURL
+	}
+	req, err := http.NewRequest("GET", base+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	return p.Do(req)
+}
+
+// GetBars asks for unadjusted aggregates from r.Start to now, newest first.
+// Polygon's limit counts the minute bars the aggregates are built from, not
+// the aggregates, so it's scaled by the timeframe. Fetch applies splits
+// itself from the corporate actions.
+func (p *Polygon) GetBars(symbol string, r BarsRequest) ([]Bar, error) {
+	start, err := time.Parse(time.RFC3339, r.Start)
+	if err != nil {
+		return nil, fmt.Errorf("bars start: %w", err)
+	}
+	path := fmt.Sprintf("/v2/aggs/ticker/%s/range/%d/minute/%d/%d",
+		url.PathEscape(symbol), r.TimeframeMin, start.UnixMilli(), time.Now().UnixMilli())
+	query := url.Values{
+		"adjusted": {"false"},
+		"sort":     {"desc"},
+		"limit":    {fmt.Sprint(min(polygonMaxBase, r.Limit*r.TimeframeMin))},
+	}
+	body, err := p.get(path, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Results []struct {
+			Time   int64   `json:"t"` // Unix milliseconds, the bar's start
+			Open   float64 `json:"o"`
+			High   float64 `json:"h"`
+			Low    float64 `json:"l"`
+			Close  float64 `json:"c"`
+			Volume float64 `json:"v"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing bars: %w", err)
+	}
+	bars := make([]Bar, 0, len(resp.Results))
+	for _, a := range resp.Results {
+		bars = append(bars, Bar{
+			Timestamp: time.UnixMilli(a.Time).UTC().Format(time.RFC3339),
+			Open:      a.Open,
+			High:      a.High,
+			Low:       a.Low,
+			Close:     a.Close,
+			Volume:    int64(math.Round(a.Volume)),
+		})
+	}
+	return recent(bars, r.Limit), nil
+}
+
+// GetQuotes reads each symbol's last quote from the snapshot endpoint.
+func (p *Polygon) GetQuotes(symbols []string) (map[string]Quote, error) {
+	if len(symbols) > MaxQuoteSymbols {
+		return nil, fmt.Errorf("%d symbols in one quotes request, limit %d", len(symbols), MaxQuoteSymbols)
+	}
+	body, err := p.get("/v2/snapshot/locale/us/markets/stocks/tickers", url.Values{"tickers": {strings.Join(symbols, ",")}})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Tickers []struct {
+			Ticker    string `json:"ticker"`
+			LastQuote struct {
+				AskPrice float64 `json:"P"`
+				AskSize  float64 `json:"S"`
+				BidPrice float64 `json:"p"`
+				BidSize  float64 `json:"s"`
+				Time     int64   `json:"t"` // Unix nanoseconds
+			} `json:"lastQuote"`
+		} `json:"tickers"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing quotes: %w", err)
+	}
+	quotes := map[string]Quote{}
+	for _, t := range resp.Tickers {
+		q := t.LastQuote
+		if q.Time == 0 {
+			continue
+		}
+		quotes[t.Ticker] = Quote{
+			Timestamp: time.Unix(0, q.Time).UTC().Format(time.RFC3339Nano),
+			BidPrice:  q.BidPrice,
+			BidSize:   q.BidSize,
+			AskPrice:  q.AskPrice,
+			AskSize:   q.AskSize,
+		}
+	}
+	return quotes, nil
+}
+
+func (p *Polygon) GetClock() (Clock, error) {
+	body, err := p.get("/v1/marketstatus/now", url.Values{})
+	if err != nil {
+		return Clock{}, err
+	}
+	var resp struct {
+		Market     string    `json:"market"` // open, closed, extended-hours
+		ServerTime time.Time `json:"serverTime"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Clock{}, fmt.Errorf("parsing market status: %w", err)
+	}
+	return Clock{Open: resp.Market == "open", Time: resp.ServerTime}, nil
+}
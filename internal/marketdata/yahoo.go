@@ -0,0 +1,125 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// yahooURL is Yahoo Finance's chart API.
+const yahooURL = "https://query1.finance.yahoo.com"
+
+// yahooIntervals are the intraday bar widths Yahoo's chart API serves, in
+// minutes.
+var yahooIntervals = map[int]bool{1: true, 2: true, 5: true, 15: true, 30: true, 60: true, 90: true}
+
+// Yahoo serves bars from Yahoo Finance's chart API, which needs no key. Its
+// intraday bars are consolidated, so they stand in as sip, and it keeps 60
+// days of 5 minute history. It has no NBBO or market clock to offer, so it
+// suits a fallback for symbols another provider has no bars for.
+type Yahoo struct {
+	BaseURL string // yahooURL when empty
+	Do      Doer
+}
+
+func (y *Yahoo) Name() string { return YahooName }
+
+func (y *Yahoo) Feed() string { return "sip" }
+
+// GetBars asks for the regular session's bars from r.Start to now, which
+// come oldest first. Bars Yahoo left empty, for minutes nothing traded, are
+// dropped.
+func (y *Yahoo) GetBars(symbol string, r BarsRequest) ([]Bar, error) {
+	if !yahooIntervals[r.TimeframeMin] {
+		return nil, fmt.Errorf("%w: %d minute bars", ErrUnsupported, r.TimeframeMin)
+	}
+	start, err := time.Parse(time.RFC3339, r.Start)
+	if err != nil {
+		return nil, fmt.Errorf("bars start: %w", err)
+	}
+	base := y.BaseURL
+	if base == "" {
+		base = yahooURL
+	}
+	query := url.Values{
+		"interval":       {fmt.Sprintf("%dm", r.TimeframeMin)},
+		"period1":        {fmt.Sprint(start.Unix())},
+		"period2":        {fmt.Sprint(time.Now().Unix())},
+		"includePrePost": {"false"},
+	}
+	req, err := http.NewRequest("GET", base+"/v8/finance/chart/"+url.PathEscape(symbol)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	// Yahoo turns away requests without a browser's user agent
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; lft2)")
+	body, err := y.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Chart struct {
+			Result []struct {
+				Timestamp  []int64 `json:"timestamp"` // Unix seconds, the bar's start
+				Indicators struct {
+					Quote []struct {
+						Open   []*float64 `json:"open"`
+						High   []*float64 `json:"high"`
+						Low    []*float64 `json:"low"`
+						Close  []*float64 `json:"close"`
+						Volume []*float64 `json:"volume"`
+					} `json:"quote"`
+				} `json:"indicators"`
+			} `json:"result"`
+			Error *struct {
+				Code        string `json:"code"`
+				Description string `json:"description"`
+			} `json:"error"`
+		} `json:"chart"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing bars: %w", err)
+	}
+	if e := resp.Chart.Error; e != nil {
+		return nil, fmt.Errorf("chart: %s: %s", e.Code, e.Description)
+	}
+	if len(resp.Chart.Result) == 0 || len(resp.Chart.Result[0].Indicators.Quote) == 0 {
+		return nil, nil
+	}
+
+	result := resp.Chart.Result[0]
+	q := result.Indicators.Quote[0]
+	var bars []Bar
+	for i, t := range result.Timestamp {
+		if i >= len(q.Open) || i >= len(q.High) || i >= len(q.Low) || i >= len(q.Close) || i >= len(q.Volume) {
+			break
+		}
+		if q.Open[i] == nil || q.High[i] == nil || q.Low[i] == nil || q.Close[i] == nil || q.Volume[i] == nil {
+			continue
+		}
+		bars = append(bars, Bar{
+			Timestamp: time.Unix(t, 0).UTC().Format(time.RFC3339),
+			Open:      *q.Open[i],
+			High:      *q.High[i],
+			Low:       *q.Low[i],
+			Close:     *q.Close[i],
+			Volume:    int64(math.Round(*q.Volume[i])),
+		})
+	}
+	if r.Limit > 0 && len(bars) > r.Limit {
+		bars = bars[len(bars)-r.Limit:]
+	}
+	return bars, nil
+}
+
+func (y *Yahoo) GetQuotes([]string) (map[string]Quote, error) {
+	return nil, fmt.Errorf("%w: quotes from %s", ErrUnsupported, YahooName)
+}
+
+func (y *Yahoo) GetClock() (Clock, error) {
+	return Clock{}, fmt.Errorf("%w: market clock from %s", ErrUnsupported, YahooName)
+}
@@ -0,0 +1,84 @@
+// Package notify sends alerts that want someone's attention, rather than a
+// line in a log or a cell on a dashboard page, to LFT2_NOTIFY_WEBHOOK. An
+// http(s) URL receives each alert as a JSON POST whose "text" field Slack,
+// Mattermost and Discord's /slack endpoint all show; anything else is a file
+// each alert is appended to as a JSON line. Unset, alerts are only printed.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Alert is one notification.
+type Alert struct {
+	Title string `json:"title"`
+	Text  string `json:"text"` // Title and body, for services that show only this
+	Time  string `json:"time"` // RFC3339, UTC
+}
+
+// Notifier delivers alerts to Dest.
+type Notifier struct {
+	Dest   string
+	Client *http.Client
+}
+
+// FromEnv returns a Notifier for LFT2_NOTIFY_WEBHOOK, or nil when it's unset
+// and alerts go nowhere but the log.
+func FromEnv() *Notifier {
+	dest := os.Getenv("LFT2_NOTIFY_WEBHOOK")
+	if dest == "" {
+		return nil
+	}
+	return &Notifier{Dest: dest, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Send delivers an alert titled title. A nil Notifier sends nothing.
+func (n *Notifier) Send(title, body string, now time.Time) error {
+	if n == nil {
+		return nil
+	}
+	a := Alert{Title: title, Text: title, Time: now.UTC().Format(time.RFC3339)}
+	if body != "" {
+		a.Text = title + "\n" + body
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(n.Dest, "http://") && !strings.HasPrefix(n.Dest, "https://") {
+		f, err := os.OpenFile(n.Dest, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	resp, err := n.Client.Post(n.Dest, "application/json", bytes.NewReader(data))
+	if err != nil {
+		// The URL may carry a token, as Slack's does; the error names it
+		return fmt.Errorf("notify: HTTP request failed: %v", redact(err, n.Dest))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notify: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(reply)))
+	}
+	return nil
+}
+
+// redact removes dest from err's message.
+func redact(err error, dest string) string {
+	return strings.ReplaceAll(err.Error(), dest, "LFT2_NOTIFY_WEBHOOK")
+}
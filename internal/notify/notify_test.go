@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 3, 10, 21, 0, 0, 0, time.UTC)
+
+func TestFromEnv_Unset(t *testing.T) {
+	t.Setenv("LFT2_NOTIFY_WEBHOOK", "")
+	n := FromEnv()
+	if n != nil {
+		t.Fatalf("got %+v, want nil", n)
+	}
+	if err := n.Send("title", "body", now); err != nil {
+		t.Errorf("nil notifier: %v", err)
+	}
+}
+
+func TestSend_Webhook(t *testing.T) {
+	var got Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("content type: got %q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	n := &Notifier{Dest: srv.URL, Client: srv.Client()}
+	if err := n.Send("gap_fill-v2 decaying", "win rate 31% vs 40% bound", now); err != nil {
+		t.Fatal(err)
+	}
+	want := Alert{Title: "gap_fill-v2 decaying", Text: "gap_fill-v2 decaying\nwin rate 31% vs 40% bound", Time: "2026-03-10T21:00:00Z"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestSend_WebhookError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such channel", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	n := &Notifier{Dest: srv.URL + "/hooks/T0KEN", Client: srv.Client()}
+	if err := n.Send("t", "", now); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("got %v, want the HTTP status", err)
+	}
+
+	// A refused connection's error names the URL; the token mustn't leak
+	srv.Close()
+	err := n.Send("t", "", now)
+	if err == nil || strings.Contains(err.Error(), "T0KEN") {
+		t.Errorf("got %v, want an error without the URL", err)
+	}
+}
+
+func TestSend_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.ndjson")
+	n := &Notifier{Dest: path}
+	for _, title := range []string{"one", "two"} {
+		if err := n.Send(title, "", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"text":"two"`) {
+		t.Errorf("got %q", lines)
+	}
+}
@@ -0,0 +1,238 @@
+// Package quota tracks Alpaca API usage against the documented rate limits.
+// Each stage that calls Alpaca adds its requests, by endpoint, to the day's
+// tally in docs/api-usage.json. Fetch forecasts whether the universe and
+// timeframe fit the data budget before it starts, and warns or trims the
+// universe when they don't.
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// DefaultPath is where the usage tally is kept.
+const DefaultPath = "docs/api-usage.json"
+
+// Alpaca's documented limits, in requests a minute. The data limit is the
+// free plan's; a paid plan raises it, which LFT2_DATA_RATE says.
+const (
+	TradingPerMinute = 200
+	DataPerMinute    = 200
+)
+
+const (
+	// Headroom is the share of a limit a forecast may plan to use, leaving
+	// the rest for retries and the other stages.
+	Headroom = 0.8
+
+	// KeepDays is how many days of usage the file keeps.
+	KeepDays = 7
+
+	// SessionMinutes is the regular session a day's cycles run in.
+	SessionMinutes = 390
+)
+
+// Budget policies from LFT2_API_BUDGET
+const (
+	Warn   = "warn"   // Log and flag on the dashboard, fetch everything
+	Reduce = "reduce" // Also trim the universe to what fits
+)
+
+// Day is one trading day's requests.
+type Day struct {
+	Calls map[string]int `json:"calls"`           // By endpoint, see alpaca.Endpoint
+	Peaks map[string]int `json:"peak_per_minute"` // By API: the most in one minute by any stage
+}
+
+// Forecast is whether a fetch of a universe fits the data budget: every
+// cycle's requests must go out within the cycle at the allowed rate.
+type Forecast struct {
+	Symbols          int     `json:"symbols"`
+	TimeframeMin     int     `json:"timeframe_min"`
+	Rate             int     `json:"rate"`               // Data requests a minute
+	RequestsPerCycle int     `json:"requests_per_cycle"` // Bars and quotes
+	MinutesPerCycle  float64 `json:"minutes_per_cycle"`  // At Rate
+	RequestsPerDay   int     `json:"requests_per_day"`   // Over the regular session
+	MaxSymbols       int     `json:"max_symbols"`        // Most that fit within Headroom
+	Fits             bool    `json:"fits"`
+}
+
+// File is the on-disk layout of api-usage.json.
+type File struct {
+	schema.Header
+	Timestamp string          `json:"timestamp"`
+	Limits    map[string]int  `json:"limits_per_minute"`  // By API
+	Forecast  *Forecast       `json:"forecast,omitempty"` // From the last fetch
+	Days      map[string]*Day `json:"days"`               // By New York date
+}
+
+// market is the exchange timezone days are counted in.
+var market = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+// PolicyFromEnv returns LFT2_API_BUDGET, Warn when unset.
+func PolicyFromEnv() (string, error) {
+	switch p := os.Getenv("LFT2_API_BUDGET"); p {
+	case "":
+		return Warn, nil
+	case Warn, Reduce:
+		return p, nil
+	default:
+		return "", fmt.Errorf("LFT2_API_BUDGET must be %s or %s, got %q", Warn, Reduce, p)
+	}
+}
+
+// requests is what fetching n symbols costs each cycle: one bars request
+// each and a quotes request per batch.
+func requests(n int) int {
+	return n + (n+alpaca.MaxQuoteSymbols-1)/alpaca.MaxQuoteSymbols
+}
+
+// Plan forecasts a fetch of symbols every timeframeMin minutes at rate data
+// requests a minute.
+func Plan(symbols, timeframeMin, rate int) Forecast {
+	f := Forecast{
+		Symbols:          symbols,
+		TimeframeMin:     timeframeMin,
+		Rate:             rate,
+		RequestsPerCycle: requests(symbols),
+	}
+	if rate < 1 || timeframeMin < 1 {
+		return f
+	}
+	f.MinutesPerCycle = math.Round(float64(f.RequestsPerCycle)/float64(rate)*100) / 100
+	f.RequestsPerDay = f.RequestsPerCycle * (SessionMinutes / timeframeMin)
+
+	budget := int(float64(rate*timeframeMin) * Headroom)
+	f.MaxSymbols = budget
+	for f.MaxSymbols > 0 && requests(f.MaxSymbols) > budget {
+		f.MaxSymbols--
+	}
+	f.Fits = symbols <= f.MaxSymbols
+	return f
+}
+
+// Load reads api-usage.json. A missing file is an empty tally.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{Days: map[string]*Day{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading API usage: %w", err)
+	}
+	if err := schema.Check(path, data); err != nil {
+		return nil, err
+	}
+	f := &File{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if f.Days == nil {
+		f.Days = map[string]*Day{}
+	}
+	return f, nil
+}
+
+// Save writes api-usage.json.
+func Save(path string, f *File) error {
+	f.Header = schema.Current()
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding API usage: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Add counts calls and peaks into now's day and drops days older than
+// KeepDays. A day's peak is the busiest minute any one stage saw; stages
+// run one after another, so they don't share a minute's limit.
+func (f *File) Add(calls, peaks map[string]int, dataRate int, now time.Time) {
+	day := now.In(market).Format(time.DateOnly)
+	d := f.Days[day]
+	if d == nil {
+		d = &Day{Calls: map[string]int{}, Peaks: map[string]int{}}
+		f.Days[day] = d
+	}
+	for endpoint, n := range calls {
+		d.Calls[endpoint] += n
+	}
+	for api, n := range peaks {
+		d.Peaks[api] = max(d.Peaks[api], n)
+	}
+
+	var days []string
+	for day := range f.Days {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	for len(days) > KeepDays {
+		delete(f.Days, days[0])
+		days = days[1:]
+	}
+
+	f.Limits = map[string]int{alpaca.TradingAPI: TradingPerMinute, alpaca.DataAPI: dataRate}
+	f.Timestamp = now.UTC().Format(time.RFC3339)
+}
+
+// Today returns now's day, nil if nothing has been counted.
+func (f *File) Today(now time.Time) *Day {
+	return f.Days[now.In(market).Format(time.DateOnly)]
+}
+
+// DataRate returns LFT2_DATA_RATE, the data limit in requests a minute, or
+// DataPerMinute when it's unset or unreadable. Fetch checks it strictly; the
+// other stages only label their usage with it.
+func DataRate() int {
+	if n, err := strconv.Atoi(os.Getenv("LFT2_DATA_RATE")); err == nil && n > 0 {
+		return n
+	}
+	return DataPerMinute
+}
+
+// Record adds this process's requests to the tally at path, and replaces
+// the forecast when one is given. A stage calls it once, as it finishes.
+func Record(path string, forecast *Forecast, now time.Time) error {
+	calls, peaks := alpaca.Usage()
+	if len(calls) == 0 && forecast == nil {
+		return nil
+	}
+	f, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if forecast != nil {
+		f.Forecast = forecast
+	}
+	f.Add(calls, peaks, DataRate(), now)
+	return Save(path, f)
+}
+
+// Near returns, by API, today's peak minute where it reached Headroom of
+// the limit: the stages are one busy cycle from being refused.
+func (f *File) Near(now time.Time) map[string]int {
+	near := map[string]int{}
+	today := f.Today(now)
+	if today == nil {
+		return near
+	}
+	for api, peak := range today.Peaks {
+		if limit := f.Limits[api]; limit > 0 && float64(peak) >= Headroom*float64(limit) {
+			near[api] = peak
+		}
+	}
+	return near
+}
@@ -0,0 +1,154 @@
+package quota
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// --- Plan ---
+
+func TestPlan_Fits(t *testing.T) {
+	f := Plan(100, 5, 200)
+	if f.RequestsPerCycle != 101 {
+		t.Errorf("requests per cycle: got %d, want 101 (100 bars and 1 quotes batch)", f.RequestsPerCycle)
+	}
+	if f.MinutesPerCycle != 0.51 {
+		t.Errorf("minutes per cycle: got %v, want 0.51", f.MinutesPerCycle)
+	}
+	if f.RequestsPerDay != 101*78 {
+		t.Errorf("requests per day: got %d, want %d", f.RequestsPerDay, 101*78)
+	}
+	if !f.Fits {
+		t.Errorf("100 symbols every 5 minutes at 200 a minute should fit: %+v", f)
+	}
+}
+
+func TestPlan_TooMany(t *testing.T) {
+	// 800 requests a cycle is the budget, and 793 bars and 8 batches is 801
+	f := Plan(1000, 5, 200)
+	if f.Fits {
+		t.Errorf("1000 symbols shouldn't fit: %+v", f)
+	}
+	if f.MaxSymbols != 792 {
+		t.Errorf("max symbols: got %d, want 792", f.MaxSymbols)
+	}
+	if requests(f.MaxSymbols) > 800 || requests(f.MaxSymbols+1) <= 800 {
+		t.Errorf("max symbols %d isn't the most that fit", f.MaxSymbols)
+	}
+}
+
+func TestPlan_NoRate(t *testing.T) {
+	if f := Plan(10, 5, 0); f.Fits || f.MaxSymbols != 0 {
+		t.Errorf("no rate should fit nothing: %+v", f)
+	}
+}
+
+// --- Add and Near ---
+
+func TestAdd_SumsCallsAndKeepsPeaks(t *testing.T) {
+	f := &File{Days: map[string]*Day{}}
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	f.Add(map[string]int{"data GET /v2/stocks/{}/bars": 50}, map[string]int{"data": 40}, 200, now)
+	f.Add(map[string]int{"data GET /v2/stocks/{}/bars": 30}, map[string]int{"data": 20}, 200, now.Add(5*time.Minute))
+
+	day := f.Today(now)
+	if day == nil {
+		t.Fatal("no day for now")
+	}
+	if got := day.Calls["data GET /v2/stocks/{}/bars"]; got != 80 {
+		t.Errorf("calls: got %d, want 80", got)
+	}
+	if got := day.Peaks["data"]; got != 40 {
+		t.Errorf("peak: got %d, want the larger 40", got)
+	}
+	if f.Limits["data"] != 200 || f.Limits["trading"] != TradingPerMinute {
+		t.Errorf("limits: got %v", f.Limits)
+	}
+}
+
+func TestAdd_CountsNewYorkDays(t *testing.T) {
+	f := &File{Days: map[string]*Day{}}
+	// 01:00 UTC on the 11th is still the 10th in New York
+	f.Add(map[string]int{"trading GET /v2/account": 1}, nil, 200, time.Date(2026, 3, 11, 1, 0, 0, 0, time.UTC))
+	if f.Days["2026-03-10"] == nil {
+		t.Errorf("got days %v, want 2026-03-10", f.Days)
+	}
+}
+
+func TestAdd_KeepsAWeek(t *testing.T) {
+	f := &File{Days: map[string]*Day{}}
+	start := time.Date(2026, 3, 9, 15, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		f.Add(map[string]int{"trading GET /v2/account": 1}, nil, 200, start.AddDate(0, 0, i))
+	}
+	if len(f.Days) != KeepDays {
+		t.Errorf("got %d days, want %d", len(f.Days), KeepDays)
+	}
+	if f.Days["2026-03-11"] != nil || f.Days["2026-03-12"] == nil {
+		t.Errorf("should drop the oldest days, got %v", f.Days)
+	}
+}
+
+func TestNear(t *testing.T) {
+	f := &File{Days: map[string]*Day{}}
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	f.Add(nil, map[string]int{"data": 170, "trading": 20}, 200, now)
+	near := f.Near(now)
+	if near["data"] != 170 || len(near) != 1 {
+		t.Errorf("got %v, want only data at 170 of 200", near)
+	}
+	if len(f.Near(now.AddDate(0, 0, 1))) != 0 {
+		t.Error("yesterday's peak shouldn't count today")
+	}
+}
+
+// --- Record, Load and Save ---
+
+func TestRecord_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-usage.json")
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	forecast := Plan(50, 5, 200)
+	if err := Record(path, &forecast, now); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Forecast == nil || f.Forecast.Symbols != 50 {
+		t.Errorf("forecast: got %+v", f.Forecast)
+	}
+	if f.SchemaVersion == 0 {
+		t.Error("no schema header")
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), "absent.json"))
+	if err != nil || f.Days == nil {
+		t.Errorf("got %v %v, want an empty tally", f, err)
+	}
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	for _, tc := range []struct {
+		env  string
+		want string
+		ok   bool
+	}{
+		{"", Warn, true},
+		{"warn", Warn, true},
+		{"reduce", Reduce, true},
+		{"block", "", false},
+	} {
+		t.Run(fmt.Sprintf("%q", tc.env), func(t *testing.T) {
+			t.Setenv("LFT2_API_BUDGET", tc.env)
+			got, err := PolicyFromEnv()
+			if got != tc.want || (err == nil) != tc.ok {
+				t.Errorf("got %q %v", got, err)
+			}
+		})
+	}
+}
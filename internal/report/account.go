@@ -0,0 +1,220 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/schema"
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+// AccountHistoryDir holds one account snapshot per trading day, named by
+// date. Account overwrites the day's file every cycle, so the last cycle's
+// balances stand as the day's close.
+const AccountHistoryDir = "docs/account-history"
+
+// AccountChangesPath is where reconcile writes the day-over-day report.
+const AccountChangesPath = "docs/account-changes.json"
+
+// DefaultJump is the day-over-day move, as a fraction of the previous
+// equity (or in margin usage, as a fraction of equity), that's flagged when
+// trades and transfers don't account for it.
+const DefaultJump = 0.05
+
+// AccountSnapshot is the account's balances at the end of a cycle.
+type AccountSnapshot struct {
+	schema.Header
+	Date              string         `json:"date"` // Trading day, reporting timezone
+	Timestamp         string         `json:"timestamp"`
+	Cash              alpaca.Decimal `json:"cash"`
+	Equity            alpaca.Decimal `json:"equity"`
+	BuyingPower       alpaca.Decimal `json:"buying_power"`
+	LongMarketValue   alpaca.Decimal `json:"long_market_value"`
+	ShortMarketValue  alpaca.Decimal `json:"short_market_value"`
+	InitialMargin     alpaca.Decimal `json:"initial_margin"`
+	MaintenanceMargin alpaca.Decimal `json:"maintenance_margin"`
+	MarginUsage       float64        `json:"margin_usage"` // Initial margin / equity
+}
+
+// Snapshot records the account's balances at now.
+func Snapshot(a alpaca.Account, now time.Time) AccountSnapshot {
+	s := AccountSnapshot{
+		Header:            schema.Current(),
+		Date:              tz.Date(now),
+		Timestamp:         now.UTC().Format(time.RFC3339),
+		Cash:              a.Cash.Round(2),
+		Equity:            a.Equity.Round(2),
+		BuyingPower:       a.BuyingPower.Round(2),
+		LongMarketValue:   a.LongMarketValue.Round(2),
+		ShortMarketValue:  a.ShortMarketValue.Round(2),
+		InitialMargin:     a.InitialMargin.Round(2),
+		MaintenanceMargin: a.MaintenanceMargin.Round(2),
+	}
+	if a.Equity > 0 {
+		s.MarginUsage = round4(a.InitialMargin.Float() / a.Equity.Float())
+	}
+	return s
+}
+
+// SaveSnapshot writes s to dir as its date's file, replacing any earlier
+// snapshot from the same day.
+func SaveSnapshot(dir string, s AccountSnapshot) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding account snapshot: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, s.Date+".json"), append(data, '\n'), 0644)
+}
+
+// PreviousSnapshot returns the latest snapshot in dir from before date, or
+// nil when there's none — a missing directory included.
+func PreviousSnapshot(dir, date string) (*AccountSnapshot, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	for i := len(names) - 1; i >= 0; i-- {
+		day := strings.TrimSuffix(filepath.Base(names[i]), ".json")
+		if day >= date {
+			continue
+		}
+		data, err := os.ReadFile(names[i])
+		if err != nil {
+			return nil, err
+		}
+		if err := schema.Check(names[i], data); err != nil {
+			return nil, err
+		}
+		var s AccountSnapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", names[i], err)
+		}
+		return &s, nil
+	}
+	return nil, nil
+}
+
+// Flows are the day's movements the journal and broker can explain: the
+// journal's net trade cash flow after fees, the broker's non-trade activity
+// (deposits, dividends, interest), and how many buys filled.
+type Flows struct {
+	TradeCash alpaca.Decimal
+	Other     alpaca.Decimal
+	Buys      int
+}
+
+// AccountChange is one balance compared with the previous day's.
+// Unexplained is what the recorded trades and transfers don't account for.
+type AccountChange struct {
+	Name        string  `json:"name"`
+	Before      float64 `json:"before"`
+	After       float64 `json:"after"`
+	Change      float64 `json:"change"`
+	Unexplained float64 `json:"unexplained"`
+	Flagged     bool    `json:"flagged"`
+	Note        string  `json:"note,omitempty"`
+}
+
+// AccountChanges is docs/account-changes.json. It carries today's snapshot
+// so a fresh checkout can diff against the published copy.
+type AccountChanges struct {
+	schema.Header
+	Date      string          `json:"date"`
+	Previous  string          `json:"previous"` // Date of the snapshot compared against
+	Timestamp string          `json:"timestamp"`
+	Status    string          `json:"status"` // "ok" or "unexplained"
+	Changes   []AccountChange `json:"changes"`
+	Current   AccountSnapshot `json:"current"`
+}
+
+// CompareAccounts reports the change in cash, equity and margin usage from
+// prev to cur and flags those the day's flows don't explain:
+//
+//   - cash should move by exactly the trade cash flow and other activity, to
+//     within tolerance dollars;
+//   - equity moves with prices, so only a move beyond jump of the previous
+//     equity, after other activity, is flagged;
+//   - margin usage rising by more than jump without a buy is flagged.
+func CompareAccounts(prev, cur AccountSnapshot, f Flows, tolerance alpaca.Decimal, jump float64) AccountChanges {
+	r := AccountChanges{
+		Header:    schema.Current(),
+		Date:      cur.Date,
+		Previous:  prev.Date,
+		Timestamp: cur.Timestamp,
+		Status:    "ok",
+		Current:   cur,
+	}
+
+	cash := change("cash", prev.Cash.Float(), cur.Cash.Float())
+	cash.Unexplained = ((cur.Cash - prev.Cash) - f.TradeCash - f.Other).Round(2).Float()
+	cash.Flagged = math.Abs(cash.Unexplained) > tolerance.Float()
+	if cash.Flagged {
+		cash.Note = fmt.Sprintf("$%.2f not explained by trades ($%s) or other activity ($%s)",
+			cash.Unexplained, f.TradeCash.Money(), f.Other.Money())
+	}
+
+	equity := change("equity", prev.Equity.Float(), cur.Equity.Float())
+	equity.Unexplained = round2(equity.Change - f.Other.Float())
+	if prev.Equity > 0 && math.Abs(equity.Unexplained) > jump*prev.Equity.Float() {
+		equity.Flagged = true
+		equity.Note = fmt.Sprintf("moved %+.1f%% beyond transfers; more than %.0f%% is unusual for market moves alone",
+			100*equity.Unexplained/prev.Equity.Float(), 100*jump)
+	}
+
+	margin := AccountChange{Name: "margin_usage", Before: prev.MarginUsage, After: cur.MarginUsage, Change: round4(cur.MarginUsage - prev.MarginUsage)}
+	if f.Buys == 0 {
+		margin.Unexplained = margin.Change
+		if margin.Change > jump {
+			margin.Flagged = true
+			margin.Note = "margin usage rose with no buys recorded"
+		}
+	}
+
+	r.Changes = []AccountChange{cash, equity, margin}
+	for _, c := range r.Changes {
+		if c.Flagged {
+			r.Status = "unexplained"
+		}
+	}
+	return r
+}
+
+func change(name string, before, after float64) AccountChange {
+	return AccountChange{Name: name, Before: round2(before), After: round2(after), Change: round2(after - before)}
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
+
+func round4(v float64) float64 { return math.Round(v*10000) / 10000 }
+
+// ParseAccountChanges decodes an account-changes.json; name labels errors.
+func ParseAccountChanges(name string, data []byte) (AccountChanges, error) {
+	if err := schema.Check(name, data); err != nil {
+		return AccountChanges{}, err
+	}
+	var c AccountChanges
+	if err := json.Unmarshal(data, &c); err != nil {
+		return AccountChanges{}, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return c, nil
+}
+
+// SaveAccountChanges writes c to path.
+func SaveAccountChanges(path string, c AccountChanges) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding account changes: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// --- Snapshot ---
+
+func TestSnapshot_MarginUsage(t *testing.T) {
+	s := Snapshot(alpaca.Account{Cash: 1000, Equity: 50000, InitialMargin: 12500}, closeTime)
+	if s.Date != "2026-03-10" || s.MarginUsage != 0.25 {
+		t.Errorf("got %+v", s)
+	}
+	if s := Snapshot(alpaca.Account{}, closeTime); s.MarginUsage != 0 {
+		t.Errorf("no equity: got margin usage %g", s.MarginUsage)
+	}
+}
+
+// --- SaveSnapshot / PreviousSnapshot ---
+
+func TestPreviousSnapshot_LatestBeforeDate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "account-history")
+	if prev, err := PreviousSnapshot(dir, "2026-03-10"); prev != nil || err != nil {
+		t.Fatalf("missing directory: got %+v, %v; want nil", prev, err)
+	}
+
+	for i, day := range []time.Time{closeTime.AddDate(0, 0, -4), closeTime.AddDate(0, 0, -1), closeTime} {
+		s := Snapshot(alpaca.Account{Cash: alpaca.Decimal(1000 + i)}, day)
+		if err := SaveSnapshot(dir, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prev, err := PreviousSnapshot(dir, "2026-03-10")
+	if err != nil || prev == nil || prev.Date != "2026-03-09" || prev.Cash != 1001 {
+		t.Errorf("got %+v, %v; want the 9 March snapshot", prev, err)
+	}
+}
+
+func TestSaveSnapshot_ReplacesSameDay(t *testing.T) {
+	dir := t.TempDir()
+	SaveSnapshot(dir, Snapshot(alpaca.Account{Cash: 1}, closeTime.Add(-time.Hour)))
+	SaveSnapshot(dir, Snapshot(alpaca.Account{Cash: 2}, closeTime))
+	if names, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(names) != 1 {
+		t.Fatalf("got %v, want one file for the day", names)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "2026-03-10.json"))
+	if !strings.Contains(string(data), `"cash": "2"`) {
+		t.Errorf("later snapshot not kept: %s", data)
+	}
+}
+
+// --- CompareAccounts ---
+
+func TestCompareAccounts_ExplainedByTrades(t *testing.T) {
+	prev := AccountSnapshot{Date: "2026-03-09", Cash: 10000, Equity: 50000, MarginUsage: 0.1}
+	cur := AccountSnapshot{Date: "2026-03-10", Cash: 9010, Equity: 50400, MarginUsage: 0.12}
+
+	// Bought $1000, $10 dividend; equity up 0.8% with the market
+	got := CompareAccounts(prev, cur, Flows{TradeCash: -1000, Other: 10, Buys: 1}, 0.01, DefaultJump)
+	if got.Status != "ok" || got.Previous != "2026-03-09" {
+		t.Fatalf("got %+v, want ok", got)
+	}
+	if cash := got.Changes[0]; cash.Change != -990 || cash.Unexplained != 0 {
+		t.Errorf("cash: got %+v", cash)
+	}
+}
+
+func TestCompareAccounts_FlagsUnexplained(t *testing.T) {
+	prev := AccountSnapshot{Date: "2026-03-09", Cash: 10000, Equity: 50000, MarginUsage: 0.1}
+	cur := AccountSnapshot{Date: "2026-03-10", Cash: 7500, Equity: 45000, MarginUsage: 0.3}
+
+	got := CompareAccounts(prev, cur, Flows{}, 0.01, DefaultJump)
+	if got.Status != "unexplained" {
+		t.Fatalf("got status %q, want unexplained", got.Status)
+	}
+	for _, c := range got.Changes {
+		if !c.Flagged || c.Note == "" {
+			t.Errorf("%s not flagged: %+v", c.Name, c)
+		}
+	}
+	if cash := got.Changes[0]; cash.Unexplained != -2500 {
+		t.Errorf("cash unexplained: got %g, want -2500", cash.Unexplained)
+	}
+
+	// The same margin rise with a buy recorded is expected
+	got = CompareAccounts(prev, cur, Flows{Buys: 1}, 0.01, DefaultJump)
+	if margin := got.Changes[2]; margin.Flagged {
+		t.Errorf("margin flagged despite a buy: %+v", margin)
+	}
+}
@@ -0,0 +1,173 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// StrategyMapPath is where renames and retired versions are mapped, at the
+// repository root beside rules.json.
+const StrategyMapPath = "strategy-map.json"
+
+// StrategyMap maps a strategy as it appears in old order IDs to the one its
+// fills should be reported under. Keys are a versioned name (dip-v1) or a
+// bare name, which matches every version without its own entry; values are
+// versioned names:
+//
+//	{"price_dip": "dip_buy-v1", "momentum-v1": "momentum-v2"}
+type StrategyMap map[string]string
+
+// LoadStrategyMap reads the mapping file. A missing file is an empty map.
+func LoadStrategyMap(path string) (StrategyMap, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return StrategyMap{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m StrategyMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for from, to := range m {
+		if _, v := splitVersion(to); v == 0 {
+			return nil, fmt.Errorf("%s: %q maps to %q, which has no -vN version", path, from, to)
+		}
+	}
+	return m, nil
+}
+
+// Key is the name+version a strategy's fills are grouped under, after any
+// mapping: the versioned name's own entry first, then the bare name's.
+func (m StrategyMap) Key(name string, version int) string {
+	key := fmt.Sprintf("%s-v%d", name, version)
+	if to, ok := m[key]; ok {
+		return to
+	}
+	if to, ok := m[name]; ok {
+		return to
+	}
+	return key
+}
+
+// OrderStrategy recovers the strategy and its version from a client_order_id
+// written by entries: {symbol}_{strategy}-v{N}_tp…. IDs from before
+// strategies were versioned carry the bare name and count as version 1, the
+// logic every built-in started at. ok is false for IDs entries didn't write,
+// such as orders placed by hand.
+func OrderStrategy(symbol, clientOrderID string) (name string, version int, ok bool) {
+	rest, found := strings.CutPrefix(clientOrderID, symbol+"_")
+	if !found {
+		return "", 0, false
+	}
+	end := strings.LastIndex(rest, "_tp")
+	if end <= 0 {
+		return "", 0, false
+	}
+	name, version = splitVersion(rest[:end])
+	if version == 0 {
+		version = 1
+	}
+	return name, version, true
+}
+
+// OrderParams recovers the params_hash from a client_order_id written by
+// entries: …_tsl{pct}_p{hash}_{timestamp}. ok is false for IDs without one.
+func OrderParams(clientOrderID string) (hash string, ok bool) {
+	i := strings.LastIndex(clientOrderID, "_tsl")
+	if i < 0 {
+		return "", false
+	}
+	_, rest, found := strings.Cut(clientOrderID[i+1:], "_p")
+	if !found {
+		return "", false
+	}
+	hash, _, found = strings.Cut(rest, "_")
+	if !found || hash == "" {
+		return "", false
+	}
+	return hash, true
+}
+
+// splitVersion splits "name-vN" into its parts. version is 0 when there's
+// no suffix.
+func splitVersion(s string) (name string, version int) {
+	i := strings.LastIndex(s, "-v")
+	if i <= 0 {
+		return s, 0
+	}
+	v, err := strconv.Atoi(s[i+2:])
+	if err != nil || v < 1 {
+		return s, 0
+	}
+	return s[:i], v
+}
+
+// StrategyTally is one strategy version's fills and closed round trips on
+// the day.
+type StrategyTally struct {
+	Strategy   string         `json:"strategy"` // name-vN, after mapping
+	Buys       int            `json:"buys"`
+	Sells      int            `json:"sells"`
+	Bought     alpaca.Decimal `json:"bought"`
+	Sold       alpaca.Decimal `json:"sold"`
+	RoundTrips int            `json:"round_trips"`
+	PnL        alpaca.Decimal `json:"pnl"` // Round trips, before fees
+}
+
+// ByStrategy groups the day's fills and round trips by strategy version, so
+// fills from changed logic aren't reported with the old. Fills that entries
+// didn't place are left out. Sorted by strategy.
+func ByStrategy(acts []Activity, trips []RoundTrip, m StrategyMap) []StrategyTally {
+	tallies := map[string]*StrategyTally{}
+	tally := func(symbol, id string) *StrategyTally {
+		name, version, ok := OrderStrategy(symbol, id)
+		if !ok {
+			return nil
+		}
+		key := m.Key(name, version)
+		t, ok := tallies[key]
+		if !ok {
+			t = &StrategyTally{Strategy: key}
+			tallies[key] = t
+		}
+		return t
+	}
+
+	for _, act := range acts {
+		t := tally(act.Symbol, act.ClientOrderID)
+		if t == nil {
+			continue
+		}
+		switch act.Side {
+		case "buy":
+			t.Buys++
+			t.Bought += act.Value
+		case "sell":
+			t.Sells++
+			t.Sold += act.Value
+		}
+	}
+	for _, trip := range trips {
+		if t := tally(trip.Symbol, trip.ClientOrderID); t != nil {
+			t.RoundTrips++
+			t.PnL += trip.PnL
+		}
+	}
+
+	out := make([]StrategyTally, 0, len(tallies))
+	for _, t := range tallies {
+		t.Bought, t.Sold, t.PnL = t.Bought.Round(2), t.Sold.Round(2), t.PnL.Round(2)
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Strategy < out[j].Strategy })
+	return out
+}
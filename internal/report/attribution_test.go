@@ -0,0 +1,121 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// --- OrderStrategy ---
+
+func TestOrderStrategy(t *testing.T) {
+	for _, tc := range []struct {
+		id      string
+		name    string
+		version int
+		ok      bool
+	}{
+		{"AAPL_mean_reversion-v2_tp1.25_sl1.25_tsl1.00_pdeadbeef_20260310T150000", "mean_reversion", 2, true},
+		{"AAPL_mean_reversion_tp1.25_sl1.25_tsl1.00_pdeadbeef_20260310T150000", "mean_reversion", 1, true},
+		{"AAPL_my-rule_tp1.25_sl1.25_tsl1.00_pdeadbeef_20260310T150000", "my-rule", 1, true},
+		{"MSFT_mean_reversion-v2_tp1.25_sl1.25_tsl1.00_pdeadbeef_20260310T150000", "", 0, false},
+		{"EXIT_AAPL_1_123456", "", 0, false},
+		{"", "", 0, false},
+	} {
+		name, version, ok := OrderStrategy("AAPL", tc.id)
+		if name != tc.name || version != tc.version || ok != tc.ok {
+			t.Errorf("%q: got %q v%d %v, want %q v%d %v", tc.id, name, version, ok, tc.name, tc.version, tc.ok)
+		}
+	}
+}
+
+// --- OrderParams ---
+
+func TestOrderParams(t *testing.T) {
+	for _, tc := range []struct {
+		id   string
+		hash string
+		ok   bool
+	}{
+		{"AAPL_mean_reversion-v2_tp1.25_sl1.25_tsl1.00_pdeadbeef_20260310T150000", "deadbeef", true},
+		{"AAPL_price_dip-v1_tp1.25_sl1.25_tsl1.00_p0000abcd_1741617300", "0000abcd", true},
+		{"AAPL_mean_reversion-v2_tp1.25_sl1.25_tsl1.00_20260310T150000", "", false},
+		{"EXIT_AAPL_1_123456", "", false},
+		{"", "", false},
+	} {
+		if hash, ok := OrderParams(tc.id); hash != tc.hash || ok != tc.ok {
+			t.Errorf("%q: got %q %v, want %q %v", tc.id, hash, ok, tc.hash, tc.ok)
+		}
+	}
+}
+
+// --- StrategyMap ---
+
+func TestStrategyMap_Key(t *testing.T) {
+	m := StrategyMap{"price_dip": "dip_buy-v1", "momentum-v1": "momentum-v2"}
+	for _, tc := range []struct {
+		name    string
+		version int
+		want    string
+	}{
+		{"price_dip", 1, "dip_buy-v1"},
+		{"price_dip", 3, "dip_buy-v1"}, // Bare name covers every version
+		{"momentum", 1, "momentum-v2"},
+		{"momentum", 3, "momentum-v3"},
+		{"gap_fill", 1, "gap_fill-v1"},
+	} {
+		if got := m.Key(tc.name, tc.version); got != tc.want {
+			t.Errorf("%s v%d: got %q, want %q", tc.name, tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestLoadStrategyMap(t *testing.T) {
+	dir := t.TempDir()
+	if m, err := LoadStrategyMap(filepath.Join(dir, "missing.json")); err != nil || len(m) != 0 {
+		t.Errorf("missing file: got %v, %v; want an empty map", m, err)
+	}
+
+	path := filepath.Join(dir, "strategy-map.json")
+	os.WriteFile(path, []byte(`{"price_dip": "dip_buy-v1"}`), 0644)
+	if m, err := LoadStrategyMap(path); err != nil || m["price_dip"] != "dip_buy-v1" {
+		t.Errorf("got %v, %v", m, err)
+	}
+
+	os.WriteFile(path, []byte(`{"price_dip": "dip_buy"}`), 0644)
+	if _, err := LoadStrategyMap(path); err == nil {
+		t.Error("unversioned target: want an error")
+	}
+}
+
+// --- ByStrategy ---
+
+func TestByStrategy_GroupsByVersion(t *testing.T) {
+	acts := []Activity{
+		{Symbol: "AAPL", Side: "buy", Value: 1000, ClientOrderID: "AAPL_momentum-v2_tp1.25_sl1.25_tsl1.00_pdeadbeef_20260310T150000"},
+		{Symbol: "MSFT", Side: "buy", Value: 500, ClientOrderID: "MSFT_momentum_tp1.25_sl1.25_tsl1.00_pdeadbeef_20260309T150000"},
+		{Symbol: "MSFT", Side: "sell", Value: 510, ClientOrderID: "MSFT_momentum_tp1.25_sl1.25_tsl1.00_pdeadbeef_20260309T150000"},
+		{Symbol: "NVDA", Side: "buy", Value: 200, ClientOrderID: "by-hand"},
+	}
+	trips := []RoundTrip{
+		{Symbol: "MSFT", PnL: 10, ClientOrderID: "MSFT_momentum_tp1.25_sl1.25_tsl1.00_pdeadbeef_20260309T150000"},
+	}
+
+	got := ByStrategy(acts, trips, nil)
+	if len(got) != 2 {
+		t.Fatalf("got %+v, want v1 and v2 apart and the hand order left out", got)
+	}
+	v1, v2 := got[0], got[1]
+	if v1.Strategy != "momentum-v1" || v1.Buys != 1 || v1.Sells != 1 || v1.RoundTrips != 1 || v1.PnL.Money() != "10.00" {
+		t.Errorf("v1: got %+v", v1)
+	}
+	if v2.Strategy != "momentum-v2" || v2.Buys != 1 || v2.Bought.Money() != "1000.00" || v2.RoundTrips != 0 {
+		t.Errorf("v2: got %+v", v2)
+	}
+
+	// Mapping the old version onto the new merges them
+	merged := ByStrategy(acts, trips, StrategyMap{"momentum-v1": "momentum-v2"})
+	if len(merged) != 1 || merged[0].Buys != 2 || merged[0].Sells != 1 {
+		t.Errorf("mapped: got %+v", merged)
+	}
+}
@@ -0,0 +1,91 @@
+package report
+
+import "github.com/deanturpin/lft2/internal/alpaca"
+
+// Exit reasons, named as in src/exit.h.
+const (
+	TakeProfit   = "take_profit"
+	StopLoss     = "stop_loss"
+	TrailingStop = "trailing_stop"
+)
+
+// ExitReason names the exit a bracket leg represents from its order type:
+// the limit leg takes profit and the stop legs cut the loss. Anything else
+// is reported by its type.
+func ExitReason(leg alpaca.Order) string {
+	switch leg.Type {
+	case "limit":
+		return TakeProfit
+	case "stop", "stop_limit":
+		return StopLoss
+	case "trailing_stop":
+		return TrailingStop
+	}
+	return leg.Type
+}
+
+// flatten lists every order followed by its legs, and maps each leg's ID to
+// the order that opened it. An order already seen (a leg also returned at the
+// top level) is listed once.
+func flatten(orders []alpaca.Order) (all []alpaca.Order, parents map[string]alpaca.Order) {
+	parents = map[string]alpaca.Order{}
+	seen := map[string]bool{}
+	var walk func(order alpaca.Order)
+	walk = func(order alpaca.Order) {
+		if order.ID != "" && seen[order.ID] {
+			return
+		}
+		seen[order.ID] = true
+		all = append(all, order)
+		for _, leg := range order.Legs {
+			parents[leg.ID] = order
+			walk(leg)
+		}
+	}
+	for _, order := range orders {
+		walk(order)
+	}
+	return all, parents
+}
+
+// RoundTrip is a bracket entry and the leg that closed it, reported as one
+// trade.
+type RoundTrip struct {
+	Symbol        string            `json:"symbol"`
+	ClientOrderID string            `json:"order_id"` // The entry's, which the legs share
+	Qty           alpaca.Decimal    `json:"qty"`
+	EntryPrice    alpaca.Decimal    `json:"entry_price"`
+	ExitPrice     alpaca.Decimal    `json:"exit_price"`
+	Exit          string            `json:"exit"`           // take_profit, stop_loss or trailing_stop
+	PnL           alpaca.Decimal    `json:"pnl"`            // Before fees
+	ClosedAt      string            `json:"closed"`         // When the exit leg filled
+	Tags          map[string]string `json:"tags,omitempty"` // The entry's order tags
+}
+
+// RoundTrips pairs each bracket leg that filled on day with its entry. The
+// entry may have filled earlier; its price comes from the parent order.
+func RoundTrips(orders []alpaca.Order, day string) []RoundTrip {
+	all, parents := flatten(orders)
+	var trips []RoundTrip
+	for _, leg := range all {
+		entry, ok := parents[leg.ID]
+		if !ok || leg.FilledQty <= 0 || !on(fillTime(leg), day) {
+			continue
+		}
+		move := leg.FilledAvgPrice - entry.FilledAvgPrice
+		if entry.Side == "sell" {
+			move = -move
+		}
+		trips = append(trips, RoundTrip{
+			Symbol:        entry.Symbol,
+			ClientOrderID: entry.ClientOrderID,
+			Qty:           leg.FilledQty,
+			EntryPrice:    entry.FilledAvgPrice,
+			ExitPrice:     leg.FilledAvgPrice,
+			Exit:          ExitReason(leg),
+			PnL:           leg.FilledQty.Mul(move, 2),
+			ClosedAt:      fillTime(leg),
+		})
+	}
+	return trips
+}
@@ -0,0 +1,122 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/labels"
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+// ordersPage is the most orders Alpaca returns for one request.
+const ordersPage = 500
+
+// StatementHeader is the first row of a CSV statement. The columns follow the
+// generic broker layout most portfolio trackers import: one row per fill,
+// with a positive quantity and the direction in Action.
+var StatementHeader = []string{"Date", "Time", "Symbol", "Action", "Quantity", "Price", "Fees", "Amount", "Currency", "Reference", "Notes"}
+
+// Statement returns every fill from from to to (YYYY-MM-DD in the reporting
+// timezone, inclusive), oldest first, built by the same rules as the daily
+// summary. Orders are paged from the day before from, as for Daily.
+func (r Reporter) Statement(from, to string) ([]Activity, error) {
+	first, err := time.ParseInLocation(tz.DateLayout, from, tz.Location())
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date %q", from)
+	}
+	last, err := time.ParseInLocation(tz.DateLayout, to, tz.Location())
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date %q", to)
+	}
+	if last.Before(first) {
+		return nil, fmt.Errorf("to date %s is before from date %s", to, from)
+	}
+
+	orders, err := r.ordersBetween(first.AddDate(0, 0, -1), last.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("fetching orders: %w", err)
+	}
+
+	var acts []Activity
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		acts = append(acts, r.Activities(orders, day.Format(tz.DateLayout))...)
+	}
+	sort.SliceStable(acts, func(i, j int) bool {
+		a, _ := tz.Parse(acts[i].TransactTime)
+		b, _ := tz.Parse(acts[j].TransactTime)
+		return a.Before(b)
+	})
+	return acts, nil
+}
+
+// ordersBetween fetches every order submitted between after and until, a
+// page at a time. Each page starts from the last order of the one before, so
+// orders are deduplicated by ID.
+func (r Reporter) ordersBetween(after, until time.Time) ([]alpaca.Order, error) {
+	seen := map[string]bool{}
+	var all []alpaca.Order
+	start := after.UTC().Format(time.RFC3339)
+	for {
+		page, err := r.Broker.Orders(fmt.Sprintf("status=all&nested=true&direction=asc&after=%s&until=%s&limit=%d",
+			start, until.UTC().Format(time.RFC3339), ordersPage))
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, order := range page {
+			if !seen[order.ID] {
+				seen[order.ID] = true
+				all = append(all, order)
+				added++
+			}
+		}
+		if len(page) < ordersPage || added == 0 || page[len(page)-1].CreatedAt == "" {
+			return all, nil
+		}
+		start = page[len(page)-1].CreatedAt
+	}
+}
+
+// WriteStatement writes activities as a CSV statement. Fees are each fill's
+// modelled fees; Amount is the gross value before them. An exit reason, the
+// order's tags and any journal notes go in Notes.
+func WriteStatement(w io.Writer, acts []Activity) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(StatementHeader); err != nil {
+		return err
+	}
+	for _, act := range acts {
+		date, clock := act.TransactTime, ""
+		if t, err := tz.Parse(act.TransactTime); err == nil {
+			date, clock = tz.Date(t), tz.Clock(t)
+		}
+		var notes []string
+		if act.Exit != "" {
+			notes = append(notes, labels.Exit(act.Exit))
+		}
+		if act.Status != "" {
+			notes = append(notes, act.Status)
+		}
+		notes = append(notes, notesOf(act)...)
+
+		action := "Buy"
+		if act.Side == "sell" {
+			action = "Sell"
+		}
+		row := []string{
+			date, clock, act.Symbol, action,
+			act.Qty.String(), act.Price.String(), act.Fee.String(), act.Value.Money(),
+			"USD", act.ClientOrderID, strings.Join(notes, "; "),
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
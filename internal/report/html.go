@@ -0,0 +1,301 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deanturpin/lft2/internal/dashboard"
+	"github.com/deanturpin/lft2/internal/labels"
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+// DailyHTML renders a daily summary as a dashboard page, with the change in
+// positions since the previous cycle when diff isn't nil and risk-adjusted
+// performance when perf isn't.
+func DailyHTML(s DailySummary, diff *PositionsDiff, perf *Performance) (string, error) {
+	var rows [][]dashboard.Cell
+	for _, act := range s.Activities {
+		time := act.TransactTime
+		if t, err := tz.Parse(act.TransactTime); err == nil {
+			time = tz.Clock(t)
+		}
+
+		// A partial fill on an order that was then withdrawn says so, as
+		// does the exit a bracket leg took
+		side := act.Side
+		if act.Status != "" {
+			side += " (" + act.Status + ")"
+		}
+		if act.Exit != "" {
+			side += " · " + labels.Exit(act.Exit)
+		}
+
+		rows = append(rows, []dashboard.Cell{
+			{Text: time, Class: "time"},
+			{Text: act.Symbol, Bold: true},
+			{Text: side, Class: act.Side},
+			{Text: act.Qty.String()},
+			{Text: "$" + act.Price.Money()},
+			{Text: "$" + act.Value.Money()},
+			{Text: "$" + act.Fee.Money(), Class: "detail"},
+			{Text: labels.Order(act.Symbol, act.ClientOrderID), Class: "detail", Title: act.ClientOrderID},
+			{Text: strings.Join(notesOf(act), "; ")},
+		})
+	}
+
+	var trips [][]dashboard.Cell
+	for _, trip := range s.RoundTrips {
+		closed := trip.ClosedAt
+		if t, err := tz.Parse(trip.ClosedAt); err == nil {
+			closed = tz.Clock(t)
+		}
+		pnlClass := "buy"
+		if trip.PnL < 0 {
+			pnlClass = "sell"
+		}
+		trips = append(trips, []dashboard.Cell{
+			{Text: closed, Class: "time"},
+			{Text: trip.Symbol, Bold: true},
+			{Text: trip.Qty.String()},
+			{Text: "$" + trip.EntryPrice.Money()},
+			{Text: "$" + trip.ExitPrice.Money()},
+			{Text: labels.Exit(trip.Exit)},
+			{Text: "$" + trip.PnL.Money(), Class: pnlClass},
+			{Text: labels.Order(trip.Symbol, trip.ClientOrderID), Class: "detail", Title: trip.ClientOrderID},
+		})
+	}
+
+	var strategies [][]dashboard.Cell
+	for _, t := range s.ByStrategy {
+		pnlClass := "buy"
+		if t.PnL < 0 {
+			pnlClass = "sell"
+		}
+		strategies = append(strategies, []dashboard.Cell{
+			{Text: labels.Strategy(t.Strategy), Bold: true, Title: t.Strategy},
+			{Text: fmt.Sprintf("%d", t.Buys)},
+			{Text: fmt.Sprintf("%d", t.Sells)},
+			{Text: "$" + t.Bought.Money()},
+			{Text: "$" + t.Sold.Money()},
+			{Text: fmt.Sprintf("%d", t.RoundTrips)},
+			{Text: "$" + t.PnL.Money(), Class: pnlClass},
+		})
+	}
+
+	var tagged [][]dashboard.Cell
+	for _, t := range s.ByTag {
+		pnlClass := "buy"
+		if t.PnL < 0 {
+			pnlClass = "sell"
+		}
+		tagged = append(tagged, []dashboard.Cell{
+			{Text: t.Tag, Bold: true},
+			{Text: fmt.Sprintf("%d", t.Buys)},
+			{Text: fmt.Sprintf("%d", t.Sells)},
+			{Text: "$" + t.Bought.Money()},
+			{Text: "$" + t.Sold.Money()},
+			{Text: fmt.Sprintf("%d", t.RoundTrips)},
+			{Text: "$" + t.PnL.Money(), Class: pnlClass},
+		})
+	}
+
+	cashClass := "buy"
+	if s.Summary.NetCashFlow < 0 {
+		cashClass = "sell"
+	}
+
+	tables := []dashboard.Table{{
+		Headers: []string{"Time", "Symbol", "Side", "Quantity", "Price", "Value", "Fee", "Strategy / Exits", "Notes"},
+		Rows:    rows,
+		Empty:   "No trades executed today",
+	}, {
+		Caption: "Bracket round trips",
+		Headers: []string{"Closed", "Symbol", "Quantity", "Entry", "Exit", "Reason", "P&L", "Strategy / Exits"},
+		Rows:    trips,
+		Empty:   "No bracket exits today",
+	}, {
+		Caption: "By strategy version",
+		Headers: []string{"Strategy", "Buys", "Sells", "Bought", "Sold", "Round Trips", "P&L"},
+		Rows:    strategies,
+		Empty:   "No strategy fills today",
+	}}
+	// Only shown when orders were tagged, which most runs aren't
+	if len(tagged) > 0 {
+		tables = append(tables, dashboard.Table{
+			Caption: "By order tag",
+			Headers: []string{"Tag", "Buys", "Sells", "Bought", "Sold", "Round Trips", "P&L"},
+			Rows:    tagged,
+		})
+	}
+	if diff != nil {
+		tables = append(tables, positionsTable(*diff))
+	}
+	if perf != nil {
+		tables = append(tables, performanceTable(*perf))
+	}
+
+	status := "preliminary, until the close"
+	if s.Final {
+		status = "final"
+	}
+	return dashboard.Render(dashboard.Page{
+		Title:    "Daily Trading Summary",
+		Subtitle: "Date: " + s.Date + " (" + tz.Location().String() + ") · " + status,
+		Stats: []dashboard.Stat{
+			{Label: "Total Trades", Value: fmt.Sprintf("%d", s.Summary.TotalTrades)},
+			{Label: "Buys", Value: fmt.Sprintf("%d", s.Summary.Buys), Class: "buy"},
+			{Label: "Sells", Value: fmt.Sprintf("%d", s.Summary.Sells), Class: "sell"},
+			{Label: "Cancelled", Value: fmt.Sprintf("%d", s.Summary.Canceled)},
+			{Label: "Replaced", Value: fmt.Sprintf("%d", s.Summary.Replaced)},
+			{Label: "Fees", Value: "$" + s.Summary.Fees.Money()},
+			{Label: "Net Cash Flow", Value: "$" + s.Summary.NetCashFlow.Money(), Class: cashClass},
+		},
+		Tables: tables,
+	})
+}
+
+// performanceTable sets the account's risk-adjusted figures beside the
+// benchmark's over the same days.
+func performanceTable(p Performance) dashboard.Table {
+	pct := func(v float64) string { return fmt.Sprintf("%.2f%%", v*100) }
+	rows := [][]dashboard.Cell{{
+		{Text: "Account", Bold: true},
+		{Text: fmt.Sprintf("%d", p.Days)},
+		{Text: pct(p.Return)},
+		{Text: pct(p.Volatility)},
+		{Text: fmt.Sprintf("%.2f", p.Sharpe)},
+		{Text: pct(p.Alpha)},
+		{Text: fmt.Sprintf("%.2f", p.Beta)},
+	}}
+	if p.BenchmarkDays >= 2 {
+		rows = append(rows, []dashboard.Cell{
+			{Text: p.Benchmark, Bold: true},
+			{Text: fmt.Sprintf("%d", p.BenchmarkDays)},
+			{Text: pct(p.BenchmarkRet)},
+			{Text: pct(p.BenchmarkVol)},
+			{Text: fmt.Sprintf("%.2f", p.BenchmarkSharpe)},
+			{Text: "—"},
+			{Text: "1.00"},
+		})
+	}
+	return dashboard.Table{
+		Caption: fmt.Sprintf("Performance %s to %s, risk-free %s (%s)", p.From, p.To, pct(p.RiskFree), p.RiskFreeSource),
+		Headers: []string{"", "Days", "Return", "Volatility", "Sharpe", "Alpha", "Beta"},
+		Rows:    rows,
+	}
+}
+
+// positionsTable shows each position's change since the previous cycle.
+func positionsTable(d PositionsDiff) dashboard.Table {
+	var rows [][]dashboard.Cell
+	for _, c := range d.Changes {
+		class := ""
+		switch c.Change {
+		case Opened:
+			class = "buy"
+		case Closed:
+			class = "sell"
+		}
+		plClass := "buy"
+		if c.PLChange < 0 {
+			plClass = "sell"
+		}
+		after, pl := "—", "—"
+		if c.Change != Closed {
+			after = c.QtyAfter.String()
+			pl = "$" + c.PLAfter.Money()
+		}
+		rows = append(rows, []dashboard.Cell{
+			{Text: c.Symbol, Bold: true},
+			{Text: c.Change, Class: class},
+			{Text: c.QtyBefore.String()},
+			{Text: after},
+			{Text: pl},
+			{Text: "$" + c.PLChange.Money(), Class: plClass},
+		})
+	}
+
+	caption := "Positions since the previous cycle"
+	if t, err := tz.Parse(d.Previous); err == nil {
+		caption += " (" + tz.Format(t) + ")"
+	}
+	return dashboard.Table{
+		Caption: caption,
+		Headers: []string{"Symbol", "Change", "Qty Before", "Qty After", "Unrealised P&L", "P&L Change"},
+		Rows:    rows,
+		Empty:   "No positions this cycle or last",
+	}
+}
+
+// notesOf is what the Notes column shows for a fill: its order tags, then
+// its journal notes.
+func notesOf(act Activity) []string {
+	var notes []string
+	if tags := tagNote(act.Tags); tags != "" {
+		notes = append(notes, tags)
+	}
+	return append(notes, act.Notes...)
+}
+
+// PreviewHTML renders the pre-close preview: each open position, its
+// unrealised P&L and the exits waiting on it. A position with no exit open
+// is flagged, as nothing will close it.
+func PreviewHTML(p Preview) (string, error) {
+	var rows [][]dashboard.Cell
+	for _, pos := range p.Positions {
+		plClass := "buy"
+		if pos.UnrealizedPL < 0 {
+			plClass = "sell"
+		}
+		exits := make([]string, 0, len(pos.Exits))
+		for _, e := range pos.Exits {
+			exit := fmt.Sprintf("%s %s", labels.Exit(e.Exit), e.Qty)
+			if e.Status == "held" {
+				exit += " (held)"
+			}
+			exits = append(exits, exit)
+		}
+		exitCell := dashboard.Cell{Text: strings.Join(exits, "; ")}
+		if len(exits) == 0 {
+			exitCell = dashboard.Cell{Text: "none open", Class: "bad"}
+		}
+		rows = append(rows, []dashboard.Cell{
+			{Text: pos.Symbol, Bold: true},
+			{Text: pos.Side},
+			{Text: pos.Qty.String()},
+			{Text: "$" + pos.AvgEntryPrice.Money()},
+			{Text: "$" + pos.CurrentPrice.Money()},
+			{Text: "$" + pos.MarketValue.Money()},
+			{Text: "$" + pos.UnrealizedPL.Money(), Class: plClass},
+			{Text: fmt.Sprintf("%.2f%%", pos.UnrealizedPLPC.Float()*100), Class: plClass},
+			exitCell,
+		})
+	}
+
+	closes := p.Close
+	if t, err := tz.Parse(p.Close); err == nil {
+		closes = tz.Format(t)
+	}
+	plClass := "buy"
+	if p.UnrealizedPL < 0 {
+		plClass = "sell"
+	}
+	unprotectedClass := ""
+	if len(p.Unprotected) > 0 {
+		unprotectedClass = "bad"
+	}
+	return dashboard.Render(dashboard.Page{
+		Title:    "Pre-close Preview",
+		Subtitle: "Market closes " + closes + " (" + tz.Location().String() + ")",
+		Stats: []dashboard.Stat{
+			{Label: "Open Positions", Value: fmt.Sprintf("%d", len(p.Positions))},
+			{Label: "Unrealised P&L", Value: "$" + p.UnrealizedPL.Money(), Class: plClass},
+			{Label: "Without Exits", Value: fmt.Sprintf("%d", len(p.Unprotected)), Class: unprotectedClass},
+		},
+		Tables: []dashboard.Table{{
+			Headers: []string{"Symbol", "Side", "Quantity", "Entry", "Price", "Value", "Unrealised P&L", "Change", "Pending Exits"},
+			Rows:    rows,
+			Empty:   "No open positions",
+		}},
+	})
+}
@@ -0,0 +1,248 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// PerformancePath is where summary writes the risk-adjusted figures.
+const PerformancePath = "docs/performance.json"
+
+// DefaultBenchmark is the symbol performance is measured against. Override
+// with LFT2_BENCHMARK.
+const DefaultBenchmark = "SPY"
+
+// TradingDays annualises daily figures.
+const TradingDays = 252
+
+// FREDSeries is the risk-free rate pulled for LFT2_RISK_FREE=fred: the
+// 3-month Treasury bill, secondary market, in percent.
+const FREDSeries = "DTB3"
+
+// fredURL is FRED's CSV download for a series; no API key needed.
+const fredURL = "https://fred.stlouisfed.org/graph/fredgraph.csv?id="
+
+// Performance is the on-disk layout of performance.json: the account's
+// daily returns from its snapshots, in excess of the risk-free rate and
+// against a benchmark, annualised where a rate is.
+type Performance struct {
+	schema.Header
+	Timestamp       string  `json:"timestamp"`
+	From            string  `json:"from"` // First snapshot
+	To              string  `json:"to"`   // Last snapshot
+	Days            int     `json:"days"` // Daily returns measured
+	RiskFree        float64 `json:"risk_free"`
+	RiskFreeSource  string  `json:"risk_free_source"` // "constant", "FRED DTB3 2026-03-09" or "none"
+	Return          float64 `json:"return"`           // Over the whole period
+	Volatility      float64 `json:"volatility"`
+	Sharpe          float64 `json:"sharpe"` // Excess return over its volatility
+	Benchmark       string  `json:"benchmark"`
+	BenchmarkDays   int     `json:"benchmark_days"` // Days both have a return
+	BenchmarkRet    float64 `json:"benchmark_return"`
+	BenchmarkVol    float64 `json:"benchmark_volatility"`
+	BenchmarkSharpe float64 `json:"benchmark_sharpe"`
+	Beta            float64 `json:"beta"`
+	Alpha           float64 `json:"alpha"` // Jensen's: excess return beta doesn't explain
+}
+
+// RiskFree reads a risk-free rate spec as set in LFT2_RISK_FREE and returns
+// the annual rate and where it came from. Empty is no rate; a number is a
+// constant, as a fraction ("0.045") or percent ("4.5%"); "fred" or
+// "fred:SERIES" pulls the latest observation of a FRED percent series with
+// get.
+func RiskFree(spec string, get func(url string) ([]byte, error)) (float64, string, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "":
+		return 0, "none", nil
+	case spec == "fred" || strings.HasPrefix(spec, "fred:"):
+		series := strings.TrimPrefix(strings.TrimPrefix(spec, "fred"), ":")
+		if series == "" {
+			series = FREDSeries
+		}
+		data, err := get(fredURL + series)
+		if err != nil {
+			return 0, "", fmt.Errorf("pulling FRED %s: %w", series, err)
+		}
+		date, pct, err := latestObservation(data)
+		if err != nil {
+			return 0, "", fmt.Errorf("FRED %s: %w", series, err)
+		}
+		return pct / 100, "FRED " + series + " " + date, nil
+	}
+
+	s, scale := spec, 1.0
+	if strings.HasSuffix(s, "%") {
+		s, scale = strings.TrimSuffix(s, "%"), 0.01
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if rate := v * scale; err == nil && rate >= -0.05 && rate < 0.5 {
+		return rate, "constant", nil
+	}
+	return 0, "", fmt.Errorf("LFT2_RISK_FREE must be an annual rate (0.045 or 4.5%%) or fred[:SERIES], got %q", spec)
+}
+
+// latestObservation returns the last dated value in a FRED CSV. Holidays
+// are "." and skipped.
+func latestObservation(data []byte) (string, float64, error) {
+	date, value, found := "", 0.0, false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		day, v, ok := strings.Cut(strings.TrimSpace(sc.Text()), ",")
+		if !ok {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			continue // Header
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			date, value, found = day, f, true
+		}
+	}
+	if !found {
+		return "", 0, fmt.Errorf("no observations")
+	}
+	return date, value, nil
+}
+
+// LoadSnapshots reads every account snapshot in dir, oldest first. A missing
+// directory has none.
+func LoadSnapshots(dir string) ([]AccountSnapshot, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	var snapshots []AccountSnapshot
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := schema.Check(name, data); err != nil {
+			return nil, err
+		}
+		var s AccountSnapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", name, err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
+
+// Measure computes performance from the daily snapshots, the benchmark's
+// daily returns keyed by day, and an annual risk-free rate. Equity moves
+// are taken as returns, so a deposit or withdrawal reads as one. It fails
+// with fewer than two daily returns, as volatility is then undefined.
+func Measure(snapshots []AccountSnapshot, benchmark map[string]float64, riskFree float64, now time.Time) (Performance, error) {
+	p := Performance{
+		Header:    schema.Current(),
+		Timestamp: now.UTC().Format(time.RFC3339),
+		RiskFree:  riskFree,
+	}
+	days, returns := []string{}, []float64{}
+	for i := 1; i < len(snapshots); i++ {
+		prev := snapshots[i-1].Equity.Float()
+		if prev <= 0 {
+			continue
+		}
+		days = append(days, snapshots[i].Date)
+		returns = append(returns, snapshots[i].Equity.Float()/prev-1)
+	}
+	if len(returns) < 2 {
+		return p, fmt.Errorf("%d daily return(s) in the account history, need at least 2", len(returns))
+	}
+	p.From, p.To, p.Days = snapshots[0].Date, snapshots[len(snapshots)-1].Date, len(returns)
+
+	daily := math.Pow(1+riskFree, 1.0/TradingDays) - 1
+	p.Return = round4(compound(returns))
+	p.Volatility = round4(stddev(returns) * math.Sqrt(TradingDays))
+	p.Sharpe = round4(sharpe(returns, daily))
+
+	// The benchmark over the days the account has a return for
+	var mine, theirs []float64
+	for i, day := range days {
+		if b, ok := benchmark[day]; ok {
+			mine = append(mine, returns[i])
+			theirs = append(theirs, b)
+		}
+	}
+	p.BenchmarkDays = len(theirs)
+	if len(theirs) < 2 {
+		return p, nil
+	}
+	p.BenchmarkRet = round4(compound(theirs))
+	p.BenchmarkVol = round4(stddev(theirs) * math.Sqrt(TradingDays))
+	p.BenchmarkSharpe = round4(sharpe(theirs, daily))
+	if v := variance(theirs); v > 0 {
+		beta := covariance(mine, theirs) / v
+		p.Beta = round4(beta)
+		p.Alpha = round4((mean(mine) - daily - beta*(mean(theirs)-daily)) * TradingDays)
+	}
+	return p, nil
+}
+
+// sharpe is the annualised mean excess return over its volatility, zero when
+// returns don't vary.
+func sharpe(returns []float64, riskFree float64) float64 {
+	excess := make([]float64, len(returns))
+	for i, r := range returns {
+		excess[i] = r - riskFree
+	}
+	sd := stddev(excess)
+	if sd == 0 {
+		return 0
+	}
+	return mean(excess) / sd * math.Sqrt(TradingDays)
+}
+
+func compound(returns []float64) float64 {
+	g := 1.0
+	for _, r := range returns {
+		g *= 1 + r
+	}
+	return g - 1
+}
+
+func mean(xs []float64) float64 {
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// covariance and variance are over a sample, dividing by n-1.
+func covariance(xs, ys []float64) float64 {
+	mx, my := mean(xs), mean(ys)
+	sum := 0.0
+	for i := range xs {
+		sum += (xs[i] - mx) * (ys[i] - my)
+	}
+	return sum / float64(len(xs)-1)
+}
+
+func variance(xs []float64) float64 { return covariance(xs, xs) }
+
+func stddev(xs []float64) float64 { return math.Sqrt(variance(xs)) }
+
+// Save writes performance.json.
+func (p Performance) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding performance: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
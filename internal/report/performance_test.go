@@ -0,0 +1,155 @@
+package report
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// --- RiskFree ---
+
+func TestRiskFree(t *testing.T) {
+	offline := func(string) ([]byte, error) { return nil, errors.New("offline") }
+	cases := []struct {
+		spec   string
+		rate   float64
+		source string
+	}{
+		{"", 0, "none"},
+		{"0.045", 0.045, "constant"},
+		{" 4.5% ", 0.045, "constant"},
+		{"0", 0, "constant"},
+	}
+	for _, c := range cases {
+		rate, source, err := RiskFree(c.spec, offline)
+		if err != nil || math.Abs(rate-c.rate) > 1e-12 || source != c.source {
+			t.Errorf("%q: got %g %q %v, want %g %q", c.spec, rate, source, err, c.rate, c.source)
+		}
+	}
+	for _, spec := range []string{"4.5 percent", "45", "fred"} {
+		if _, _, err := RiskFree(spec, offline); err == nil {
+			t.Errorf("%q: got nil, want an error", spec)
+		}
+	}
+}
+
+func TestRiskFree_FRED(t *testing.T) {
+	var asked string
+	get := func(url string) ([]byte, error) {
+		asked = url
+		return []byte("observation_date,DGS1MO\n2026-03-05,4.31\n2026-03-06,4.29\n2026-03-09,.\n"), nil
+	}
+	rate, source, err := RiskFree("fred:DGS1MO", get)
+	if err != nil || math.Abs(rate-0.0429) > 1e-12 || source != "FRED DGS1MO 2026-03-06" {
+		t.Errorf("got %g %q %v, want the last observation before the holiday", rate, source, err)
+	}
+	if !strings.HasSuffix(asked, "id=DGS1MO") {
+		t.Errorf("pulled %s", asked)
+	}
+
+	if _, _, err := RiskFree("fred", get); err != nil || !strings.HasSuffix(asked, "id="+FREDSeries) {
+		t.Errorf("default series: pulled %s, %v", asked, err)
+	}
+	empty := func(string) ([]byte, error) { return []byte("observation_date,DTB3\n"), nil }
+	if _, _, err := RiskFree("fred", empty); err == nil || !strings.Contains(err.Error(), "no observations") {
+		t.Errorf("empty series: got %v", err)
+	}
+}
+
+// --- Measure ---
+
+// snapshots is a day's closing equity for each value, from 2 March 2026.
+func snapshots(equity ...float64) []AccountSnapshot {
+	var out []AccountSnapshot
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	for i, e := range equity {
+		out = append(out, AccountSnapshot{Date: day.AddDate(0, 0, i).Format("2006-01-02"), Equity: alpaca.Decimal(e)})
+	}
+	return out
+}
+
+func TestMeasure(t *testing.T) {
+	now := time.Date(2026, 3, 6, 21, 0, 0, 0, time.UTC)
+	account := snapshots(100000, 101000, 100495, 102504.9, 102504.9)
+	// Account returns 1%, -0.5%, 2%, 0%; the benchmark moved half as much
+	benchmark := map[string]float64{"2026-03-03": 0.005, "2026-03-04": -0.0025, "2026-03-05": 0.01, "2026-03-06": 0}
+
+	p, err := Measure(account, benchmark, 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.From != "2026-03-02" || p.To != "2026-03-06" || p.Days != 4 || p.BenchmarkDays != 4 {
+		t.Errorf("period: got %s to %s, %d days, %d benchmark days", p.From, p.To, p.Days, p.BenchmarkDays)
+	}
+	if p.Return != 0.025 {
+		t.Errorf("return: got %g, want 0.025", p.Return)
+	}
+	if p.Beta != 2 || p.Alpha != 0 {
+		t.Errorf("got beta %g alpha %g, want 2 and 0 for a levered benchmark", p.Beta, p.Alpha)
+	}
+	// Returns 0.01, -0.005, 0.02, 0: mean 0.00625, sample sd 0.0111
+	want := round4(0.00625 / stddev([]float64{0.01, -0.005, 0.02, 0}) * math.Sqrt(TradingDays))
+	if p.Sharpe != want || p.Sharpe != p.BenchmarkSharpe {
+		t.Errorf("Sharpe: got %g and benchmark %g, want both %g", p.Sharpe, p.BenchmarkSharpe, want)
+	}
+
+	// A risk-free rate lowers the Sharpe. Twice the benchmark's moves without
+	// paying that rate to borrow for them shows up as alpha of the rate
+	withRate, err := Measure(account, benchmark, 0.05, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withRate.Sharpe >= p.Sharpe || withRate.RiskFree != 0.05 {
+		t.Errorf("Sharpe with a 5%% rate: got %g, want under %g", withRate.Sharpe, p.Sharpe)
+	}
+	daily := math.Pow(1.05, 1.0/TradingDays) - 1
+	if got, want := withRate.Alpha, round4(daily*TradingDays); math.Abs(got-want) > 1e-4 {
+		t.Errorf("alpha with a 5%% rate: got %g, want %g", got, want)
+	}
+}
+
+func TestMeasure_Sparse(t *testing.T) {
+	now := time.Now()
+	if _, err := Measure(snapshots(100000, 100500), nil, 0, now); err == nil {
+		t.Error("one daily return: got nil, want an error")
+	}
+
+	// No benchmark bars for the period: the account's figures stand alone
+	p, err := Measure(snapshots(100000, 100500, 100000), map[string]float64{"2026-03-03": 0.01}, 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BenchmarkDays != 1 || p.Beta != 0 || p.BenchmarkSharpe != 0 {
+		t.Errorf("got %+v, want no benchmark comparison", p)
+	}
+}
+
+func TestLoadSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	for _, s := range snapshots(100000, 101000) {
+		s.Header = Snapshot(alpaca.Account{}, time.Now()).Header
+		if err := SaveSnapshot(dir, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := LoadSnapshots(dir)
+	if err != nil || len(got) != 2 || got[0].Date != "2026-03-02" || got[1].Equity != 101000 {
+		t.Errorf("got %+v, %v", got, err)
+	}
+	if got, err := LoadSnapshots(filepath.Join(dir, "missing")); err != nil || len(got) != 0 {
+		t.Errorf("missing dir: got %v, %v", got, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "2026-03-04.json"), []byte(`{"schema_version": 99}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSnapshots(dir); err == nil {
+		t.Error("newer schema: got nil, want an error")
+	}
+}
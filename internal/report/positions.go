@@ -0,0 +1,165 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// PositionsDiffPath is where account writes the change in positions since
+// the previous cycle.
+const PositionsDiffPath = "docs/positions-diff.json"
+
+// How a position changed between cycles
+const (
+	Opened  = "opened"
+	Closed  = "closed"
+	Resized = "resized"
+	Held    = "held"
+)
+
+// Holding is one position as snapshotted at the end of account.
+type Holding struct {
+	Symbol       string         `json:"symbol"`
+	Qty          alpaca.Decimal `json:"qty"`
+	MarketValue  alpaca.Decimal `json:"market_value"`
+	UnrealizedPL alpaca.Decimal `json:"unrealized_pl"`
+}
+
+// PositionChange compares one symbol's position with the previous cycle's.
+// A closed position has no P&L after, and its change is left at zero as the
+// realised figure comes from the fills instead.
+type PositionChange struct {
+	Symbol    string         `json:"symbol"`
+	Change    string         `json:"change"`
+	QtyBefore alpaca.Decimal `json:"qty_before"`
+	QtyAfter  alpaca.Decimal `json:"qty_after"`
+	PLBefore  alpaca.Decimal `json:"pl_before"`
+	PLAfter   alpaca.Decimal `json:"pl_after"`
+	PLChange  alpaca.Decimal `json:"pl_change"`
+}
+
+// PositionsDiff is docs/positions-diff.json. It carries this cycle's
+// snapshot so the next cycle can diff against it.
+type PositionsDiff struct {
+	schema.Header
+	Timestamp string           `json:"timestamp"`
+	Previous  string           `json:"previous,omitempty"` // Timestamp of the snapshot compared against
+	Positions []Holding        `json:"positions"`
+	Changes   []PositionChange `json:"changes"`
+}
+
+// Holdings snapshots broker positions.
+func Holdings(positions []alpaca.Position) []Holding {
+	holdings := make([]Holding, 0, len(positions))
+	for _, p := range positions {
+		holdings = append(holdings, Holding{Symbol: p.Symbol, Qty: p.Qty, MarketValue: p.MarketValue, UnrealizedPL: p.UnrealizedPL})
+	}
+	return holdings
+}
+
+// DiffPositions compares two snapshots by symbol, in symbol order.
+func DiffPositions(prev, cur []Holding) []PositionChange {
+	before := map[string]Holding{}
+	for _, h := range prev {
+		before[h.Symbol] = h
+	}
+	after := map[string]Holding{}
+	for _, h := range cur {
+		after[h.Symbol] = h
+	}
+
+	var changes []PositionChange
+	for _, h := range cur {
+		old, had := before[h.Symbol]
+		c := PositionChange{
+			Symbol:    h.Symbol,
+			Change:    Held,
+			QtyBefore: old.Qty,
+			QtyAfter:  h.Qty,
+			PLBefore:  old.UnrealizedPL,
+			PLAfter:   h.UnrealizedPL,
+			PLChange:  (h.UnrealizedPL - old.UnrealizedPL).Round(2),
+		}
+		switch {
+		case !had:
+			c.Change = Opened
+		case h.Qty != old.Qty:
+			c.Change = Resized
+		}
+		changes = append(changes, c)
+	}
+	for _, h := range prev {
+		if _, held := after[h.Symbol]; !held {
+			changes = append(changes, PositionChange{Symbol: h.Symbol, Change: Closed, QtyBefore: h.Qty, PLBefore: h.UnrealizedPL})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Symbol < changes[j].Symbol })
+	return changes
+}
+
+// NewPositionsDiff diffs cur against the snapshot in prev, which may be nil
+// on the first cycle, when every position shows as opened.
+func NewPositionsDiff(prev *PositionsDiff, cur []Holding, now time.Time) PositionsDiff {
+	d := PositionsDiff{
+		Header:    schema.Current(),
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Positions: cur,
+	}
+	var before []Holding
+	if prev != nil {
+		d.Previous = prev.Timestamp
+		before = prev.Positions
+	}
+	d.Changes = DiffPositions(before, cur)
+	if d.Positions == nil {
+		d.Positions = []Holding{}
+	}
+	if d.Changes == nil {
+		d.Changes = []PositionChange{}
+	}
+	return d
+}
+
+// ParsePositionsDiff decodes a positions-diff.json; name labels errors.
+func ParsePositionsDiff(name string, data []byte) (PositionsDiff, error) {
+	if err := schema.Check(name, data); err != nil {
+		return PositionsDiff{}, err
+	}
+	var d PositionsDiff
+	if err := json.Unmarshal(data, &d); err != nil {
+		return PositionsDiff{}, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return d, nil
+}
+
+// LoadPositionsDiff reads path. A missing file is nil, not an error.
+func LoadPositionsDiff(path string) (*PositionsDiff, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d, err := ParsePositionsDiff(path, data)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SavePositionsDiff writes d to path.
+func SavePositionsDiff(path string, d PositionsDiff) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding positions diff: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
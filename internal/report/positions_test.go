@@ -0,0 +1,63 @@
+package report
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// --- DiffPositions ---
+
+func TestDiffPositions(t *testing.T) {
+	prev := []Holding{
+		{Symbol: "AAPL", Qty: 10, UnrealizedPL: 5},
+		{Symbol: "MSFT", Qty: 4, UnrealizedPL: -2},
+		{Symbol: "TSLA", Qty: 2, UnrealizedPL: 8},
+	}
+	cur := []Holding{
+		{Symbol: "NVDA", Qty: 3, UnrealizedPL: 1.25},
+		{Symbol: "AAPL", Qty: 10, UnrealizedPL: 7.5},
+		{Symbol: "MSFT", Qty: 6, UnrealizedPL: -1},
+	}
+	got := DiffPositions(prev, cur)
+	want := []PositionChange{
+		{Symbol: "AAPL", Change: Held, QtyBefore: 10, QtyAfter: 10, PLBefore: 5, PLAfter: 7.5, PLChange: 2.5},
+		{Symbol: "MSFT", Change: Resized, QtyBefore: 4, QtyAfter: 6, PLBefore: -2, PLAfter: -1, PLChange: 1},
+		{Symbol: "NVDA", Change: Opened, QtyAfter: 3, PLAfter: 1.25, PLChange: 1.25},
+		{Symbol: "TSLA", Change: Closed, QtyBefore: 2, PLBefore: 8},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s: got %+v, want %+v", want[i].Symbol, got[i], want[i])
+		}
+	}
+}
+
+// --- NewPositionsDiff / Save / Load ---
+
+func TestPositionsDiff_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "positions-diff.json")
+	if prev, err := LoadPositionsDiff(path); err != nil || prev != nil {
+		t.Fatalf("missing file: got %+v, %v", prev, err)
+	}
+
+	first := NewPositionsDiff(nil, nil, time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC))
+	if first.Previous != "" || first.Positions == nil || first.Changes == nil {
+		t.Errorf("first cycle: got %+v", first)
+	}
+	if err := SavePositionsDiff(path, first); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	prev, err := LoadPositionsDiff(path)
+	if err != nil || prev == nil {
+		t.Fatalf("load: got %+v, %v", prev, err)
+	}
+
+	next := NewPositionsDiff(prev, []Holding{{Symbol: "AAPL", Qty: 1}}, time.Date(2026, 3, 10, 15, 5, 0, 0, time.UTC))
+	if next.Previous != "2026-03-10T15:00:00Z" || len(next.Changes) != 1 || next.Changes[0].Change != Opened {
+		t.Errorf("second cycle: got %+v", next)
+	}
+}
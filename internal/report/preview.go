@@ -0,0 +1,190 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// Where summary writes the pre-close preview
+const (
+	PreviewPath     = "docs/preclose-preview.json"
+	PreviewHTMLPath = "docs/preclose-preview.html"
+)
+
+// Defaults for LFT2_PREVIEW_LEAD and LFT2_REPORT_DELAY
+const (
+	DefaultPreviewLead = 15 * time.Minute
+	DefaultReportDelay = 0
+)
+
+// Schedule is when, relative to the session's close, summary writes the
+// pre-close preview and marks the daily summary final.
+type Schedule struct {
+	PreviewLead time.Duration // Preview from this long before the close; 0 for none
+	ReportDelay time.Duration // Final from this long after the close
+}
+
+// ScheduleFromEnv reads LFT2_PREVIEW_LEAD and LFT2_REPORT_DELAY as Go
+// durations ("15m", "1h"). Unset values keep the defaults; a lead of "0"
+// turns the preview off.
+func ScheduleFromEnv() (Schedule, error) {
+	parse := func(name string, def time.Duration) (time.Duration, error) {
+		s := os.Getenv(name)
+		if s == "" {
+			return def, nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("%s must be a non-negative duration such as 15m, got %q", name, s)
+		}
+		return d, nil
+	}
+	var s Schedule
+	var err error
+	if s.PreviewLead, err = parse("LFT2_PREVIEW_LEAD", DefaultPreviewLead); err != nil {
+		return Schedule{}, err
+	}
+	if s.ReportDelay, err = parse("LFT2_REPORT_DELAY", DefaultReportDelay); err != nil {
+		return Schedule{}, err
+	}
+	return s, nil
+}
+
+// Session returns the bounds of the latest session in sessions to have
+// opened by now, which is today's once the market has opened. False when
+// none has.
+func Session(sessions []alpaca.Session, now time.Time) (open, close time.Time, ok bool) {
+	for _, s := range sessions {
+		o, c, err := s.Bounds()
+		if err != nil || o.After(now) {
+			continue
+		}
+		if !ok || o.After(open) {
+			open, close, ok = o, c, true
+		}
+	}
+	return open, close, ok
+}
+
+// PreviewDue says whether now is within the lead before close.
+func (s Schedule) PreviewDue(close, now time.Time) bool {
+	return s.PreviewLead > 0 && !now.Before(close.Add(-s.PreviewLead)) && now.Before(close)
+}
+
+// Final says whether the report delay after close has passed.
+func (s Schedule) Final(close, now time.Time) bool {
+	return !now.Before(close.Add(s.ReportDelay))
+}
+
+// PendingExit is an open order that would close some or all of a position.
+type PendingExit struct {
+	Symbol        string         `json:"symbol"`
+	ClientOrderID string         `json:"order_id"`
+	Side          string         `json:"side"`
+	Qty           alpaca.Decimal `json:"qty"`
+	Type          string         `json:"type"`
+	Exit          string         `json:"exit"`   // take_profit, stop_loss, trailing_stop, or the order type
+	Status        string         `json:"status"` // A bracket's stop leg is held until its take profit fills or is cancelled
+}
+
+// PreviewPosition is an open position with the exits waiting on it.
+type PreviewPosition struct {
+	Symbol         string         `json:"symbol"`
+	Side           string         `json:"side"`
+	Qty            alpaca.Decimal `json:"qty"`
+	AvgEntryPrice  alpaca.Decimal `json:"avg_entry_price"`
+	CurrentPrice   alpaca.Decimal `json:"current_price"`
+	MarketValue    alpaca.Decimal `json:"market_value"`
+	UnrealizedPL   alpaca.Decimal `json:"unrealized_pl"`
+	UnrealizedPLPC alpaca.Decimal `json:"unrealized_plpc"`
+	Exits          []PendingExit  `json:"exits"`
+}
+
+// Preview is docs/preclose-preview.json: what's still open shortly before
+// the close, so anything that looks wrong can be handled by hand while the
+// market is open.
+type Preview struct {
+	schema.Header
+	Timestamp    string            `json:"timestamp"`
+	Close        string            `json:"close"` // The session's close, RFC3339
+	Positions    []PreviewPosition `json:"positions"`
+	UnrealizedPL alpaca.Decimal    `json:"unrealized_pl"`
+	Unprotected  []string          `json:"unprotected"` // Positions with no exit order open
+}
+
+// BuildPreview lists positions, in symbol order, with the open orders on
+// the other side of each. Open orders are as queried with nested=true, so
+// bracket legs come under their entry.
+func BuildPreview(positions []alpaca.Position, open []alpaca.Order, close, now time.Time) Preview {
+	all, _ := flatten(open)
+	exits := map[string][]PendingExit{}
+	for _, o := range all {
+		if o.Symbol == "" || o.Status == "filled" {
+			continue
+		}
+		exits[o.Symbol] = append(exits[o.Symbol], PendingExit{
+			Symbol:        o.Symbol,
+			ClientOrderID: o.ClientOrderID,
+			Side:          o.Side,
+			Qty:           o.Qty - o.FilledQty,
+			Type:          o.Type,
+			Exit:          ExitReason(o),
+			Status:        o.Status,
+		})
+	}
+
+	p := Preview{
+		Header:      schema.Current(),
+		Timestamp:   now.UTC().Format(time.RFC3339),
+		Close:       close.UTC().Format(time.RFC3339),
+		Positions:   []PreviewPosition{},
+		Unprotected: []string{},
+	}
+	for _, pos := range positions {
+		// A long position closes with a sell, a short with a buy
+		closing := "sell"
+		if pos.Side == "short" {
+			closing = "buy"
+		}
+		pp := PreviewPosition{
+			Symbol:         pos.Symbol,
+			Side:           pos.Side,
+			Qty:            pos.Qty,
+			AvgEntryPrice:  pos.AvgEntryPrice,
+			CurrentPrice:   pos.CurrentPrice,
+			MarketValue:    pos.MarketValue,
+			UnrealizedPL:   pos.UnrealizedPL,
+			UnrealizedPLPC: pos.UnrealizedPLPC,
+			Exits:          []PendingExit{},
+		}
+		for _, e := range exits[pos.Symbol] {
+			if e.Side == closing {
+				pp.Exits = append(pp.Exits, e)
+			}
+		}
+		if len(pp.Exits) == 0 {
+			p.Unprotected = append(p.Unprotected, pos.Symbol)
+		}
+		p.UnrealizedPL += pos.UnrealizedPL
+		p.Positions = append(p.Positions, pp)
+	}
+	p.UnrealizedPL = p.UnrealizedPL.Round(2)
+	sort.Slice(p.Positions, func(i, j int) bool { return p.Positions[i].Symbol < p.Positions[j].Symbol })
+	sort.Strings(p.Unprotected)
+	return p
+}
+
+// SavePreview writes p to path.
+func SavePreview(path string, p Preview) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding preview: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
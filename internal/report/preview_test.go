@@ -0,0 +1,137 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+func TestScheduleFromEnv(t *testing.T) {
+	t.Setenv("LFT2_PREVIEW_LEAD", "")
+	t.Setenv("LFT2_REPORT_DELAY", "")
+	s, err := ScheduleFromEnv()
+	if err != nil || s.PreviewLead != DefaultPreviewLead || s.ReportDelay != DefaultReportDelay {
+		t.Errorf("unset: got %+v, %v", s, err)
+	}
+
+	t.Setenv("LFT2_PREVIEW_LEAD", "30m")
+	t.Setenv("LFT2_REPORT_DELAY", "10m")
+	if s, err := ScheduleFromEnv(); err != nil || s.PreviewLead != 30*time.Minute || s.ReportDelay != 10*time.Minute {
+		t.Errorf("got %+v, %v", s, err)
+	}
+
+	for _, bad := range []string{"15", "-5m", "soon"} {
+		t.Setenv("LFT2_PREVIEW_LEAD", bad)
+		if _, err := ScheduleFromEnv(); err == nil {
+			t.Errorf("%q: want an error", bad)
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	sessions := []alpaca.Session{
+		{Date: "2026-11-25", Open: "09:30", Close: "16:00"},
+		{Date: "2026-11-27", Open: "09:30", Close: "13:00"}, // Half day after Thanksgiving
+		{Date: "2026-11-30", Open: "09:30", Close: "16:00"},
+	}
+	at := func(s string) time.Time {
+		t, _ := time.Parse(time.RFC3339, s)
+		return t
+	}
+
+	_, close, ok := Session(sessions, at("2026-11-27T17:50:00Z"))
+	if !ok || !close.Equal(at("2026-11-27T18:00:00Z")) {
+		t.Errorf("half day: got %s, %t", close, ok)
+	}
+	// Before Monday's open the latest session is still Friday's
+	if _, close, ok := Session(sessions, at("2026-11-30T12:00:00Z")); !ok || !close.Equal(at("2026-11-27T18:00:00Z")) {
+		t.Errorf("before the open: got %s, %t", close, ok)
+	}
+	if _, _, ok := Session(sessions, at("2026-11-20T15:00:00Z")); ok {
+		t.Error("before every session: want none")
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	close := time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)
+	s := Schedule{PreviewLead: 15 * time.Minute, ReportDelay: 5 * time.Minute}
+	for _, tc := range []struct {
+		offset         time.Duration
+		preview, final bool
+	}{
+		{-20 * time.Minute, false, false},
+		{-15 * time.Minute, true, false},
+		{-time.Minute, true, false},
+		{0, false, false},
+		{5 * time.Minute, false, true},
+	} {
+		now := close.Add(tc.offset)
+		if got := s.PreviewDue(close, now); got != tc.preview {
+			t.Errorf("%s: preview due %t, want %t", tc.offset, got, tc.preview)
+		}
+		if got := s.Final(close, now); got != tc.final {
+			t.Errorf("%s: final %t, want %t", tc.offset, got, tc.final)
+		}
+	}
+	if (Schedule{}).PreviewDue(close, close.Add(-time.Minute)) {
+		t.Error("zero lead: preview should be off")
+	}
+}
+
+func TestBuildPreview(t *testing.T) {
+	positions := []alpaca.Position{
+		{Symbol: "MSFT", Side: "long", Qty: 5, UnrealizedPL: -12.5},
+		{Symbol: "AAPL", Side: "long", Qty: 10, UnrealizedPL: 20.25},
+	}
+	open := []alpaca.Order{{
+		// A bracket whose entry filled: its exits wait as legs
+		ID: "e1", Symbol: "AAPL", Side: "buy", Status: "filled", ClientOrderID: "AAPL_momentum_1",
+		Legs: []alpaca.Order{
+			{ID: "tp", Symbol: "AAPL", Side: "sell", Type: "limit", Status: "new", Qty: 10, ClientOrderID: "AAPL_momentum_1"},
+			{ID: "sl", Symbol: "AAPL", Side: "sell", Type: "stop", Status: "held", Qty: 10, ClientOrderID: "AAPL_momentum_1"},
+		},
+	}, {
+		// An entry waiting on MSFT isn't an exit
+		ID: "e2", Symbol: "MSFT", Side: "buy", Type: "market", Status: "accepted", Qty: 5,
+	}}
+	close := time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)
+
+	p := BuildPreview(positions, open, close, close.Add(-10*time.Minute))
+	if len(p.Positions) != 2 || p.Positions[0].Symbol != "AAPL" {
+		t.Fatalf("want both positions in symbol order: got %+v", p.Positions)
+	}
+	exits := p.Positions[0].Exits
+	if len(exits) != 2 || exits[0].Exit != TakeProfit || exits[1].Exit != StopLoss || exits[1].Status != "held" {
+		t.Errorf("AAPL exits: got %+v", exits)
+	}
+	if len(p.Unprotected) != 1 || p.Unprotected[0] != "MSFT" {
+		t.Errorf("unprotected: got %v", p.Unprotected)
+	}
+	if p.UnrealizedPL != 7.75 || p.Close != "2026-03-10T20:00:00Z" {
+		t.Errorf("got %s unrealised, close %s", p.UnrealizedPL, p.Close)
+	}
+
+	html, err := PreviewHTML(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Pre-close Preview", "AAPL", "(held)", "none open", "$7.75"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+}
+
+func TestDailyHTML_Final(t *testing.T) {
+	for final, want := range map[bool]string{false: "preliminary", true: "· final"} {
+		html, err := DailyHTML(DailySummary{Date: "2026-03-10", Final: final}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(html, want) {
+			t.Errorf("final=%t: page missing %q", final, want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// PromotionsPath is where lft2 promote writes each change's paper cohort,
+// read back on its next run and shown on the strategies page.
+const PromotionsPath = "docs/promotions.json"
+
+// Where a change stands in its paper cohort
+const (
+	Shadow   = "shadow"   // Trading on paper, not yet proven
+	Diverged = "diverged" // Enough paper trades, but too far from its backtest
+	Promoted = "promoted" // Ready for the live account
+)
+
+// Promotion is one new or re-tuned recommendation's paper record against
+// its backtest, and where that leaves it.
+type Promotion struct {
+	Symbol     string  `json:"symbol"`
+	Strategy   string  `json:"strategy"` // name-vN
+	ParamsHash string  `json:"params_hash"`
+	Status     string  `json:"status"`
+	Since      string  `json:"since"`                 // First day in the cohort, YYYY-MM-DD
+	PromotedOn string  `json:"promoted_on,omitempty"` // Day it was promoted
+	Expected   float64 `json:"expected"`              // Backtest avg_profit per trade
+	Trades     int     `json:"trades"`                // Closed on the paper account
+	Observed   float64 `json:"observed"`              // Mean paper return per trade, net of fees
+	Passed     bool    `json:"passed"`                // Within tolerance over enough trades
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// Key identifies the change: symbol, versioned strategy and parameters.
+func (p Promotion) Key() string {
+	return p.Symbol + " " + p.Strategy + " " + p.ParamsHash
+}
+
+// Promotions is promotions.json.
+type Promotions struct {
+	schema.Header
+	Timestamp string      `json:"timestamp"`
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	MinTrades int         `json:"min_trades"`
+	Tolerance float64     `json:"tolerance"`
+	Days      int         `json:"days"`    // On paper before a change can be promoted
+	Promote   bool        `json:"promote"` // Every change promoted
+	Changes   []Promotion `json:"changes"`
+}
+
+// ParsePromotions decodes a promotions.json read from name.
+func ParsePromotions(name string, data []byte) (Promotions, error) {
+	if err := schema.Check(name, data); err != nil {
+		return Promotions{}, err
+	}
+	var p Promotions
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Promotions{}, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return p, nil
+}
+
+// LoadPromotions reads path. A missing file is nil, not an error.
+func LoadPromotions(path string) (*Promotions, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParsePromotions(path, data)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
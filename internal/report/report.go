@@ -0,0 +1,269 @@
+// Package report builds the daily trading summary and the strategies page
+// from broker orders and backtest output. The broker and clock are injected
+// so the order→activity rules can be tested without the live API.
+package report
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/fees"
+	"github.com/deanturpin/lft2/internal/journal"
+	"github.com/deanturpin/lft2/internal/schema"
+	"github.com/deanturpin/lft2/internal/tz"
+)
+
+// Broker is the part of the Alpaca client the daily summary reads.
+type Broker interface {
+	Orders(query string) ([]alpaca.Order, error)
+}
+
+// Activity represents a processed trade for display
+type Activity struct {
+	TransactTime  string            `json:"transaction_time"`
+	Symbol        string            `json:"symbol"`
+	Qty           alpaca.Decimal    `json:"qty"`
+	Price         alpaca.Decimal    `json:"price"`
+	Value         alpaca.Decimal    `json:"value"`            // Qty × Price, rounded to cents
+	Fee           alpaca.Decimal    `json:"fee"`              // Commission and regulatory fees
+	Side          string            `json:"side"`             // "buy" or "sell"
+	ClientOrderID string            `json:"order_id"`         // Our custom ID for dashboard display
+	Status        string            `json:"status,omitempty"` // Set when the order closed without filling in full
+	Exit          string            `json:"exit,omitempty"`   // Set on a bracket leg: take_profit, stop_loss…
+	Notes         []string          `json:"notes,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"` // From LFT2_ORDER_TAGS when it was submitted
+}
+
+// DailySummary represents the JSON output for GitHub Pages
+type DailySummary struct {
+	schema.Header
+	Date       string          `json:"date"`
+	Activities []Activity      `json:"activities"`
+	Summary    TradingSummary  `json:"summary"`
+	RoundTrips []RoundTrip     `json:"round_trips,omitempty"` // Bracket entries closed today
+	ByStrategy []StrategyTally `json:"by_strategy,omitempty"` // Grouped by name+version
+	ByTag      []TagTally      `json:"by_tag,omitempty"`      // Grouped by each key=value order tag
+	Final      bool            `json:"final"`                 // Written once LFT2_REPORT_DELAY past the close
+}
+
+type TradingSummary struct {
+	TotalTrades int            `json:"total_trades"`
+	Buys        int            `json:"buys"`
+	Sells       int            `json:"sells"`
+	Fees        alpaca.Decimal `json:"fees"`
+	NetCashFlow alpaca.Decimal `json:"net_cash_flow"` // Sell proceeds − buy cost − fees
+	NetPnL      string         `json:"net_pnl"`       // Simple approximation
+	Canceled    int            `json:"canceled"`      // Orders cancelled today with nothing filled
+	Replaced    int            `json:"replaced"`      // Orders replaced today with nothing filled
+}
+
+// Summarise totals the day's fills. Each value is already in whole cents
+// and the totals are rounded again, so the result matches the broker's
+// statement exactly.
+func Summarise(acts []Activity) TradingSummary {
+	s := TradingSummary{TotalTrades: len(acts)}
+	for _, act := range acts {
+		switch act.Side {
+		case "buy":
+			s.Buys++
+			s.NetCashFlow -= act.Value
+		case "sell":
+			s.Sells++
+			s.NetCashFlow += act.Value
+		}
+		s.Fees += act.Fee
+	}
+	s.Fees = s.Fees.Round(2)
+	s.NetCashFlow = (s.NetCashFlow - s.Fees).Round(2)
+	s.NetPnL = "calculated_by_dashboard" // Dashboard will compute from matched pairs
+	return s
+}
+
+// Reporter builds daily summaries. Now defaults to time.Now, Notes to an
+// empty journal, Strategies to no renames and Log to discarding.
+type Reporter struct {
+	Broker     Broker
+	Fees       fees.Model
+	Notes      *journal.Journal
+	Strategies StrategyMap // strategy-map.json
+	Now        func() time.Time
+	Log        io.Writer // skipped orders are reported here
+}
+
+// Today is the trading day in the reporting timezone, not the host's.
+func (r Reporter) Today() string {
+	return tz.Date(r.now())
+}
+
+// journal is the notes and tags, empty when there's no journal.
+func (r Reporter) journal() *journal.Journal {
+	if r.Notes == nil {
+		return &journal.Journal{}
+	}
+	return r.Notes
+}
+
+func (r Reporter) now() time.Time {
+	if r.Now == nil {
+		return time.Now()
+	}
+	return r.Now()
+}
+
+// Daily fetches recent orders and summarises those that traded or closed
+// today. Orders from yesterday onwards are requested so a UTC query boundary
+// can't drop early fills in a timezone behind UTC. Every status is requested,
+// not just filled, so shares bought by an order later cancelled or replaced
+// still count, and bracket legs are requested nested under their entry.
+func (r Reporter) Daily() (DailySummary, error) {
+	now := r.now()
+	today := tz.Date(now)
+	yesterday := tz.Date(now.AddDate(0, 0, -1))
+
+	orders, err := r.Broker.Orders(fmt.Sprintf("status=all&nested=true&after=%sT00:00:00Z&limit=500", yesterday))
+	if err != nil {
+		return DailySummary{}, fmt.Errorf("fetching orders: %w", err)
+	}
+
+	acts := r.Activities(orders, today)
+	trips := RoundTrips(orders, today)
+	for i := range trips {
+		trips[i].Tags = r.journal().TagsFor(trips[i].ClientOrderID)
+	}
+	summary := Summarise(acts)
+	summary.Canceled, summary.Replaced = Withdrawn(orders, today)
+	return DailySummary{
+		Header:     schema.Current(),
+		Date:       today,
+		Activities: acts,
+		Summary:    summary,
+		RoundTrips: trips,
+		ByStrategy: ByStrategy(acts, trips, r.Strategies),
+		ByTag:      ByTag(acts, trips),
+	}, nil
+}
+
+// Withdrawn counts the orders cancelled and replaced on day before anything
+// filled. They aren't trades, but a run of them usually means something is
+// chasing the price.
+func Withdrawn(orders []alpaca.Order, day string) (canceled, replaced int) {
+	for _, order := range orders {
+		if order.FilledQty > 0 {
+			continue
+		}
+		if on(order.CanceledAt, day) {
+			canceled++
+		} else if on(order.ReplacedAt, day) {
+			replaced++
+		}
+	}
+	return canceled, replaced
+}
+
+// on reports whether timestamp falls on day in the reporting timezone.
+func on(timestamp, day string) bool {
+	t, err := tz.Parse(timestamp)
+	return timestamp != "" && err == nil && tz.Date(t) == day
+}
+
+// fillTime is when an order's filled quantity traded. Alpaca only sets
+// filled_at once an order fills in full, so a partial fill on an order that
+// was then cancelled or replaced is dated by when it closed.
+func fillTime(order alpaca.Order) string {
+	switch {
+	case order.FilledAt != "":
+		return order.FilledAt
+	case order.CanceledAt != "":
+		return order.CanceledAt
+	default:
+		return order.ReplacedAt
+	}
+}
+
+// lineage lists the IDs a journal note may be filed under for order: its own,
+// its bracket entry's if it's a leg, and those of every order it replaced, so
+// a note made against the original still shows once the order has been
+// amended or exited.
+func lineage(order alpaca.Order, byID, parents map[string]alpaca.Order) []string {
+	ids := []string{order.ID, order.ClientOrderID}
+	if entry, ok := parents[order.ID]; ok {
+		ids = append(ids, lineage(entry, byID, parents)...)
+	}
+	seen := map[string]bool{order.ID: true}
+	for prev := order.Replaces; prev != "" && !seen[prev]; {
+		seen[prev] = true
+		ids = append(ids, prev)
+		parent, ok := byID[prev]
+		if !ok {
+			break
+		}
+		ids = append(ids, parent.ClientOrderID)
+		prev = parent.Replaces
+	}
+	return ids
+}
+
+// Activities converts the orders filled on day (YYYY-MM-DD in the reporting
+// timezone) to activities. Quantities and values come from the filled part
+// of each order, so a partial fill reports only what traded. Each order in a
+// replacement chain reports only its own fills, so nothing is counted twice.
+// Bracket legs are reported under their entry's client_order_id with the exit
+// they represent.
+func (r Reporter) Activities(orders []alpaca.Order, day string) []Activity {
+	notes := r.journal()
+	log := r.Log
+	if log == nil {
+		log = io.Discard
+	}
+
+	all, parents := flatten(orders)
+	byID := map[string]alpaca.Order{}
+	for _, order := range all {
+		byID[order.ID] = order
+	}
+
+	var acts []Activity
+	for _, order := range all {
+		stamp := fillTime(order)
+		if stamp == "" || order.FilledQty <= 0 {
+			continue
+		}
+		filledAt, err := tz.Parse(stamp)
+		if err != nil {
+			fmt.Fprintf(log, "  [skip] %s: bad filled_at %q\n", order.Symbol, stamp)
+			continue
+		}
+		if tz.Date(filledAt) != day {
+			continue
+		}
+
+		act := Activity{
+			TransactTime:  stamp,
+			Symbol:        order.Symbol,
+			Qty:           order.FilledQty,
+			Price:         order.FilledAvgPrice,
+			Value:         order.FilledQty.Mul(order.FilledAvgPrice, 2),
+			Side:          order.Side,
+			ClientOrderID: order.ClientOrderID,
+		}
+		if order.Status != "filled" {
+			act.Status = order.Status
+		}
+		if entry, ok := parents[order.ID]; ok {
+			act.ClientOrderID = entry.ClientOrderID
+			act.Exit = ExitReason(order)
+		}
+		if r.Fees != nil {
+			act.Fee = alpaca.Decimal(r.Fees.Fee(order.Side, order.FilledQty.Float(), order.FilledAvgPrice.Float()))
+		}
+		ids := lineage(order, byID, parents)
+		for _, n := range notes.For(ids...) {
+			act.Notes = append(act.Notes, n.Text)
+		}
+		act.Tags = notes.TagsFor(ids...)
+		acts = append(acts, act)
+	}
+	return acts
+}
@@ -0,0 +1,389 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/fees"
+	"github.com/deanturpin/lft2/internal/journal"
+)
+
+// fakeBroker returns canned orders and records the query.
+type fakeBroker struct {
+	orders []alpaca.Order
+	err    error
+	query  string
+}
+
+func (b *fakeBroker) Orders(query string) ([]alpaca.Order, error) {
+	b.query = query
+	return b.orders, b.err
+}
+
+// 16:00 New York on 10 March 2026
+var closeTime = time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)
+
+func clock() time.Time { return closeTime }
+
+// --- Reporter.Daily ---
+
+func TestDaily_QueriesFromYesterday(t *testing.T) {
+	b := &fakeBroker{}
+	s, err := Reporter{Broker: b, Now: clock}.Daily()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(b.query, "status=all") || !strings.Contains(b.query, "nested=true") || !strings.Contains(b.query, "after=2026-03-09T00:00:00Z") {
+		t.Errorf("query: got %q", b.query)
+	}
+	if s.Date != "2026-03-10" || len(s.Activities) != 0 || s.Summary.TotalTrades != 0 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestDaily_BrokerError(t *testing.T) {
+	_, err := Reporter{Broker: &fakeBroker{err: errors.New("HTTP 500")}, Now: clock}.Daily()
+	if err == nil || !strings.Contains(err.Error(), "fetching orders") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestDaily_FiltersToToday(t *testing.T) {
+	b := &fakeBroker{orders: []alpaca.Order{
+		{Symbol: "AAPL", Side: "buy", FilledAt: "2026-03-10T14:35:00Z", FilledQty: 10, FilledAvgPrice: 100},
+		// 01:00 UTC on the 10th is still the 9th in New York
+		{Symbol: "MSFT", Side: "buy", FilledAt: "2026-03-10T01:00:00Z", FilledQty: 1, FilledAvgPrice: 400},
+		{Symbol: "NVDA", Side: "buy", FilledAt: "2026-03-09T15:00:00Z", FilledQty: 1, FilledAvgPrice: 900},
+	}}
+	s, err := Reporter{Broker: b, Now: clock}.Daily()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Activities) != 1 || s.Activities[0].Symbol != "AAPL" {
+		t.Errorf("got %+v, want AAPL only", s.Activities)
+	}
+	if s.Summary.Buys != 1 || s.Summary.NetCashFlow.Money() != "-1000.00" {
+		t.Errorf("summary: got %+v", s.Summary)
+	}
+}
+
+// --- Reporter.Activities ---
+
+func TestActivities_PartialFillUsesFilledQty(t *testing.T) {
+	orders := []alpaca.Order{{
+		Symbol: "AAPL", Side: "buy", Status: "partially_filled",
+		Qty: 100, FilledQty: 40, FilledAvgPrice: 50.125, FilledAt: "2026-03-10T15:00:00Z",
+	}}
+	acts := Reporter{}.Activities(orders, "2026-03-10")
+	if len(acts) != 1 {
+		t.Fatalf("got %d activities, want 1", len(acts))
+	}
+	if acts[0].Qty != 40 || acts[0].Value.Money() != "2005.00" {
+		t.Errorf("got qty=%s value=%s, want 40 and 2005.00", acts[0].Qty, acts[0].Value.Money())
+	}
+}
+
+func TestActivities_SkipsUnfilledAndBadTimestamps(t *testing.T) {
+	var log strings.Builder
+	orders := []alpaca.Order{
+		// Replaced before anything traded: no fill time, nothing filled
+		{Symbol: "AAPL", Side: "buy", Status: "replaced", Qty: 10},
+		{Symbol: "MSFT", Side: "buy", Status: "filled", FilledAt: "yesterday", FilledQty: 1, FilledAvgPrice: 1},
+	}
+	acts := Reporter{Log: &log}.Activities(orders, "2026-03-10")
+	if len(acts) != 0 {
+		t.Errorf("got %+v, want none", acts)
+	}
+	if !strings.Contains(log.String(), `MSFT: bad filled_at "yesterday"`) {
+		t.Errorf("log: got %q", log.String())
+	}
+}
+
+func TestActivities_CancelledAfterPartialFill(t *testing.T) {
+	// filled_at stays null when the rest of the order is cancelled
+	orders := []alpaca.Order{{
+		Symbol: "AAPL", Side: "buy", Status: "canceled",
+		Qty: 100, FilledQty: 30, FilledAvgPrice: 10, CanceledAt: "2026-03-10T15:30:00Z",
+	}}
+	acts := Reporter{}.Activities(orders, "2026-03-10")
+	if len(acts) != 1 {
+		t.Fatalf("got %d activities, want 1", len(acts))
+	}
+	if acts[0].Qty != 30 || acts[0].Status != "canceled" || acts[0].TransactTime != "2026-03-10T15:30:00Z" {
+		t.Errorf("got %+v", acts[0])
+	}
+}
+
+func TestActivities_ReplacementChainCountsEachFillOnce(t *testing.T) {
+	notes := &journal.Journal{}
+	notes.Add("AAPL_gap_fill_1", "limit too tight", closeTime)
+
+	orders := []alpaca.Order{
+		{
+			ID: "uuid-1", ClientOrderID: "AAPL_gap_fill_1", Symbol: "AAPL", Side: "buy", Status: "replaced",
+			Qty: 100, FilledQty: 40, FilledAvgPrice: 10, ReplacedAt: "2026-03-10T15:00:00Z", ReplacedBy: "uuid-2",
+		},
+		{
+			ID: "uuid-2", ClientOrderID: "AAPL_gap_fill_2", Symbol: "AAPL", Side: "buy", Status: "filled",
+			Qty: 60, FilledQty: 60, FilledAvgPrice: 11, FilledAt: "2026-03-10T15:05:00Z", Replaces: "uuid-1",
+		},
+	}
+	acts := Reporter{Notes: notes}.Activities(orders, "2026-03-10")
+	if len(acts) != 2 {
+		t.Fatalf("got %d activities, want 2", len(acts))
+	}
+	s := Summarise(acts)
+	if s.Buys != 2 || s.NetCashFlow.Money() != "-1060.00" {
+		t.Errorf("summary: got %+v, want 2 buys costing 1060.00", s)
+	}
+	if acts[0].Status != "replaced" || acts[1].Status != "" {
+		t.Errorf("status: got %q and %q", acts[0].Status, acts[1].Status)
+	}
+	// The note made against the original follows it to the replacement
+	if len(acts[1].Notes) != 1 || acts[1].Notes[0] != "limit too tight" {
+		t.Errorf("replacement notes: got %v", acts[1].Notes)
+	}
+}
+
+// bracket is an entry filled yesterday whose stop leg filled today; the
+// take-profit leg was cancelled by the fill.
+func bracket() alpaca.Order {
+	return alpaca.Order{
+		ID: "uuid-1", ClientOrderID: "AAPL_gap_fill_1", Symbol: "AAPL", Side: "buy", Status: "filled",
+		OrderClass: "bracket", Type: "market", FilledQty: 10, FilledAvgPrice: 100, FilledAt: "2026-03-09T15:00:00Z",
+		Legs: []alpaca.Order{
+			{ID: "uuid-2", ClientOrderID: "leg-tp", Symbol: "AAPL", Side: "sell", Status: "canceled", Type: "limit", CanceledAt: "2026-03-10T16:00:00Z"},
+			{ID: "uuid-3", ClientOrderID: "leg-sl", Symbol: "AAPL", Side: "sell", Status: "filled", Type: "stop", FilledQty: 10, FilledAvgPrice: 98.5, FilledAt: "2026-03-10T16:00:00Z"},
+		},
+	}
+}
+
+func TestActivities_BracketLegReportedUnderEntry(t *testing.T) {
+	notes := &journal.Journal{}
+	notes.Add("AAPL_gap_fill_1", "entered on the gap", closeTime)
+
+	acts := Reporter{Notes: notes}.Activities([]alpaca.Order{bracket()}, "2026-03-10")
+	if len(acts) != 1 {
+		t.Fatalf("got %+v, want the stop leg only", acts)
+	}
+	got := acts[0]
+	if got.Side != "sell" || got.Exit != StopLoss || got.ClientOrderID != "AAPL_gap_fill_1" {
+		t.Errorf("got %+v", got)
+	}
+	if len(got.Notes) != 1 || got.Notes[0] != "entered on the gap" {
+		t.Errorf("notes: got %v", got.Notes)
+	}
+}
+
+func TestActivities_LegAlsoAtTopLevelCountedOnce(t *testing.T) {
+	entry := bracket()
+	orders := []alpaca.Order{entry, entry.Legs[1]}
+	if acts := (Reporter{}).Activities(orders, "2026-03-10"); len(acts) != 1 {
+		t.Errorf("got %d activities, want 1", len(acts))
+	}
+}
+
+func TestActivities_FeesAndNotes(t *testing.T) {
+	notes := &journal.Journal{}
+	notes.Add("uuid-1", "chased the gap", closeTime)
+	notes.Add("AAPL_gap_fill_1", "sized too big", closeTime)
+
+	orders := []alpaca.Order{{
+		ID: "uuid-1", ClientOrderID: "AAPL_gap_fill_1", Symbol: "AAPL", Side: "sell",
+		FilledAt: "2026-03-10T15:00:00Z", FilledQty: 10, FilledAvgPrice: 100,
+	}}
+	feeModel, err := fees.Open("none,per_order=1")
+	if err != nil {
+		t.Fatalf("fee model: %v", err)
+	}
+	acts := Reporter{Fees: feeModel, Notes: notes}.Activities(orders, "2026-03-10")
+	if len(acts) != 1 || acts[0].Fee.Money() != "1.00" {
+		t.Fatalf("got %+v, want one activity with a $1 fee", acts)
+	}
+	if strings.Join(acts[0].Notes, "; ") != "chased the gap; sized too big" {
+		t.Errorf("notes: got %v", acts[0].Notes)
+	}
+}
+
+// --- Withdrawn ---
+
+func TestWithdrawn(t *testing.T) {
+	orders := []alpaca.Order{
+		{Symbol: "AAPL", Status: "canceled", CanceledAt: "2026-03-10T15:00:00Z"},
+		{Symbol: "MSFT", Status: "replaced", ReplacedAt: "2026-03-10T15:00:00Z"},
+		{Symbol: "NVDA", Status: "replaced", ReplacedAt: "2026-03-10T15:00:00Z"},
+		// Traded before it was cancelled, so it's an activity instead
+		{Symbol: "TSLA", Status: "canceled", CanceledAt: "2026-03-10T15:00:00Z", FilledQty: 1},
+		// Cancelled yesterday
+		{Symbol: "AMD", Status: "canceled", CanceledAt: "2026-03-09T15:00:00Z"},
+	}
+	canceled, replaced := Withdrawn(orders, "2026-03-10")
+	if canceled != 1 || replaced != 2 {
+		t.Errorf("got canceled=%d replaced=%d, want 1 and 2", canceled, replaced)
+	}
+}
+
+// --- RoundTrips ---
+
+func TestRoundTrips_PairsLegWithEntry(t *testing.T) {
+	trips := RoundTrips([]alpaca.Order{bracket()}, "2026-03-10")
+	if len(trips) != 1 {
+		t.Fatalf("got %+v, want one round trip", trips)
+	}
+	got := trips[0]
+	if got.Exit != StopLoss || got.EntryPrice != 100 || got.ExitPrice != 98.5 || got.PnL.Money() != "-15.00" {
+		t.Errorf("got %+v", got)
+	}
+	if trips := RoundTrips([]alpaca.Order{bracket()}, "2026-03-11"); len(trips) != 0 {
+		t.Errorf("next day: got %+v, want none", trips)
+	}
+}
+
+func TestRoundTrips_ShortEntry(t *testing.T) {
+	entry := bracket()
+	entry.Side = "sell"
+	entry.Legs[1].Side = "buy"
+	if trips := RoundTrips([]alpaca.Order{entry}, "2026-03-10"); len(trips) != 1 || trips[0].PnL.Money() != "15.00" {
+		t.Errorf("got %+v, want a 15.00 gain", trips)
+	}
+}
+
+func TestExitReason(t *testing.T) {
+	for typ, want := range map[string]string{
+		"limit": TakeProfit, "stop": StopLoss, "stop_limit": StopLoss, "trailing_stop": TrailingStop, "market": "market",
+	} {
+		if got := ExitReason(alpaca.Order{Type: typ}); got != want {
+			t.Errorf("%s: got %q, want %q", typ, got, want)
+		}
+	}
+}
+
+// --- Summarise ---
+
+func TestSummarise_NetCashFlowAfterFees(t *testing.T) {
+	got := Summarise([]Activity{
+		{Side: "buy", Value: 1000.00},
+		{Side: "sell", Value: 1012.50, Fee: 0.03},
+		{Side: "buy", Value: 250.10, Fee: 1.00},
+	})
+	if got.Buys != 2 || got.Sells != 1 || got.TotalTrades != 3 {
+		t.Errorf("counts: got %+v", got)
+	}
+	if got.Fees.Money() != "1.03" {
+		t.Errorf("fees: got %s, want 1.03", got.Fees.Money())
+	}
+	if got.NetCashFlow.Money() != "-238.63" {
+		t.Errorf("net cash flow: got %s, want -238.63", got.NetCashFlow.Money())
+	}
+}
+
+// --- DailyHTML ---
+
+func TestDailyHTML(t *testing.T) {
+	acts := []Activity{{
+		TransactTime: "2026-03-10T15:00:00Z", Symbol: "AAPL", Side: "buy",
+		Qty: 10, Price: 100, Value: 1000, ClientOrderID: "AAPL_gap_fill_1", Notes: []string{"chased"},
+	}, {
+		TransactTime: "2026-03-10T15:30:00Z", Symbol: "MSFT", Side: "sell", Exit: TrailingStop,
+		Qty: 5, Price: 400, Value: 2000, ClientOrderID: "MSFT_gap_fill-v2_tp1.25_sl2.50_tsl1.00_p1_1741617300",
+	}}
+	diff := NewPositionsDiff(nil, []Holding{{Symbol: "AAPL", Qty: 10, UnrealizedPL: 4.5}}, time.Date(2026, 3, 10, 15, 5, 0, 0, time.UTC))
+	html, err := DailyHTML(DailySummary{Date: "2026-03-10", Activities: acts, Summary: Summarise(acts)}, &diff, nil)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	for _, want := range []string{"Date: 2026-03-10", "11:00:00", "AAPL_gap_fill_1", "chased", "$1000.00", "Positions since the previous cycle", "opened", "$4.50",
+		"sell · Trailing stop", "Gap fill v2 · TP 1.25% SL 2.50% TSL 1.00%", `title="MSFT_gap_fill-v2_tp1.25_sl2.50_tsl1.00_p1_1741617300"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+}
+
+// --- Reporter.Statement / WriteStatement ---
+
+// pagedBroker serves orders a page at a time and records each query.
+type pagedBroker struct {
+	pages   [][]alpaca.Order
+	queries []string
+}
+
+func (b *pagedBroker) Orders(query string) ([]alpaca.Order, error) {
+	b.queries = append(b.queries, query)
+	if len(b.pages) == 0 {
+		return nil, nil
+	}
+	page := b.pages[0]
+	b.pages = b.pages[1:]
+	return page, nil
+}
+
+func TestStatement_RangeOldestFirst(t *testing.T) {
+	b := &fakeBroker{orders: []alpaca.Order{
+		{ID: "2", Symbol: "MSFT", Side: "sell", FilledAt: "2026-03-10T15:00:00Z", FilledQty: 1, FilledAvgPrice: 400},
+		{ID: "1", Symbol: "AAPL", Side: "buy", FilledAt: "2026-03-09T14:35:00Z", FilledQty: 10, FilledAvgPrice: 100},
+		// Outside the range either side
+		{ID: "3", Symbol: "NVDA", Side: "buy", FilledAt: "2026-03-08T15:00:00Z", FilledQty: 1, FilledAvgPrice: 900},
+		{ID: "4", Symbol: "TSLA", Side: "buy", FilledAt: "2026-03-11T15:00:00Z", FilledQty: 1, FilledAvgPrice: 200},
+	}}
+	acts, err := Reporter{Broker: b, Now: clock}.Statement("2026-03-09", "2026-03-10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(acts) != 2 || acts[0].Symbol != "AAPL" || acts[1].Symbol != "MSFT" {
+		t.Fatalf("got %+v", acts)
+	}
+	if !strings.Contains(b.query, "after=2026-03-08T") || !strings.Contains(b.query, "until=2026-03-11T") {
+		t.Errorf("query: got %q", b.query)
+	}
+}
+
+func TestStatement_BadRange(t *testing.T) {
+	r := Reporter{Broker: &fakeBroker{}, Now: clock}
+	if _, err := r.Statement("2026-03-10", "2026-03-09"); err == nil {
+		t.Error("expected an error for a reversed range")
+	}
+	if _, err := r.Statement("March", "2026-03-09"); err == nil {
+		t.Error("expected an error for a bad date")
+	}
+}
+
+func TestStatement_Pages(t *testing.T) {
+	full := make([]alpaca.Order, ordersPage)
+	for i := range full {
+		full[i] = alpaca.Order{ID: fmt.Sprintf("o%d", i), CreatedAt: "2026-03-09T15:00:00Z"}
+	}
+	last := full[len(full)-1]
+	b := &pagedBroker{pages: [][]alpaca.Order{full, {last, {ID: "fill", Symbol: "AAPL", Side: "buy", FilledAt: "2026-03-09T15:30:00Z", FilledQty: 1, FilledAvgPrice: 100}}}}
+	acts, err := Reporter{Broker: b, Now: clock}.Statement("2026-03-09", "2026-03-09")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(b.queries) != 2 || !strings.Contains(b.queries[1], "after=2026-03-09T15:00:00Z") {
+		t.Errorf("queries: got %q", b.queries)
+	}
+	if len(acts) != 1 || acts[0].Symbol != "AAPL" {
+		t.Errorf("got %+v", acts)
+	}
+}
+
+func TestWriteStatement(t *testing.T) {
+	acts := []Activity{
+		{TransactTime: "2026-03-10T14:35:00Z", Symbol: "AAPL", Side: "buy", Qty: 10, Price: 100.5, Value: 1005, Fee: 0.01, ClientOrderID: "AAPL_x"},
+		{TransactTime: "2026-03-10T15:00:00Z", Symbol: "AAPL", Side: "sell", Qty: 10, Price: 101, Value: 1010, ClientOrderID: "AAPL_x", Exit: "take_profit", Notes: []string{"clean exit"}},
+	}
+	var buf strings.Builder
+	if err := WriteStatement(&buf, acts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "Date,Time,Symbol,Action,Quantity,Price,Fees,Amount,Currency,Reference,Notes\n" +
+		"2026-03-10,10:35:00,AAPL,Buy,10,100.5,0.01,1005.00,USD,AAPL_x,\n" +
+		"2026-03-10,11:00:00,AAPL,Sell,10,101,0,1010.00,USD,AAPL_x,Take profit; clean exit\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
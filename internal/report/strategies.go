@@ -0,0 +1,304 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/deanturpin/lft2/internal/dashboard"
+	"github.com/deanturpin/lft2/internal/labels"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// Recommendation is the subset of strategies.json shown on the strategies page
+type Recommendation struct {
+	Symbol     string  `json:"symbol"`
+	Strategy   string  `json:"strategy"`
+	Version    int     `json:"version"` // 0 in backtests from before versioning
+	ParamsHash string  `json:"params_hash"`
+	WinRate    float64 `json:"win_rate"`
+	AvgProfit  float64 `json:"avg_profit"`
+	TradeCount int     `json:"trade_count"`
+	Viable     bool    `json:"viable"`
+
+	// Max order notional before slippage erodes the edge; nil in backtests
+	// from before it was estimated
+	Capacity *float64 `json:"capacity"`
+
+	// Excursions, as fractions of entry price
+	AvgMAE       float64 `json:"avg_mae"`
+	AvgMFE       float64 `json:"avg_mfe"`
+	AvgWinnerMAE float64 `json:"avg_winner_mae"`
+	AvgLoserMFE  float64 `json:"avg_loser_mfe"`
+}
+
+// Excursion is the trade-weighted MAE/MFE for one strategy across symbols
+type Excursion struct {
+	Strategy  string
+	Trades    int
+	MAE       float64
+	MFE       float64
+	WinnerMAE float64
+	LoserMFE  float64
+}
+
+// EquityCurve is one strategy's cumulative backtest equity from
+// equity-curves.json: unit stakes, so 1.05 means +5% of one position's size.
+type EquityCurve struct {
+	Strategy    string    `json:"strategy"`
+	Trades      int       `json:"trades"`
+	FinalEquity float64   `json:"final_equity"`
+	MaxDrawdown float64   `json:"max_drawdown"`
+	Timestamps  []string  `json:"timestamps"`
+	Equity      []float64 `json:"equity"`
+}
+
+// LoadEquityCurves reads the backtest's equity curves. A missing file gives
+// no curves, as backtests from before the file existed still render.
+func LoadEquityCurves(path string) ([]EquityCurve, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := schema.Check(path, data); err != nil {
+		return nil, err
+	}
+	var f struct {
+		Curves []EquityCurve `json:"curves"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return f.Curves, nil
+}
+
+// Label is the strategy as it appears in order IDs: name-vN, or the bare
+// name when the backtest predates versioning.
+func (r Recommendation) Label() string {
+	if r.Version < 1 {
+		return r.Strategy
+	}
+	return fmt.Sprintf("%s-v%d", r.Strategy, r.Version)
+}
+
+// equityChart plots each strategy's curve and tabulates its outcome.
+func equityChart(curves []EquityCurve) (dashboard.Chart, [][]dashboard.Cell) {
+	chart := dashboard.Chart{Caption: "Equity Curves (unit stake per trade)"}
+	var rows [][]dashboard.Cell
+	for _, c := range curves {
+		chart.Lines = append(chart.Lines, dashboard.Line{Label: labels.Strategy(c.Strategy), Values: c.Equity})
+
+		class := "buy"
+		if c.FinalEquity < 1 {
+			class = "sell"
+		}
+		rows = append(rows, []dashboard.Cell{
+			{Text: labels.Strategy(c.Strategy), Bold: true, Title: c.Strategy},
+			{Text: fmt.Sprintf("%d", c.Trades)},
+			{Text: fmt.Sprintf("%+.2f%%", (c.FinalEquity-1)*100), Class: class},
+			{Text: fmt.Sprintf("%.2f%%", c.MaxDrawdown*100), Class: "sell"},
+		})
+	}
+	return chart, rows
+}
+
+// StrategiesHTML renders the backtest recommendations and equity curves
+// as a dashboard page, with each new or re-tuned recommendation's place in
+// the paper cohort from promotionsPath, when lft2 promote has written one.
+func StrategiesHTML(jsonPath, curvesPath, promotionsPath string) (string, error) {
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		return "", err
+	}
+
+	if err := schema.Check(jsonPath, data); err != nil {
+		return "", err
+	}
+	var strategies struct {
+		Timestamp       string           `json:"timestamp"`
+		Recommendations []Recommendation `json:"recommendations"`
+	}
+	if err := json.Unmarshal(data, &strategies); err != nil {
+		return "", fmt.Errorf("parsing %s: %w", jsonPath, err)
+	}
+
+	promotions, err := LoadPromotions(promotionsPath)
+	if err != nil {
+		return "", err
+	}
+	cohort := map[string]Promotion{}
+	if promotions != nil {
+		for _, p := range promotions.Changes {
+			cohort[p.Key()] = p
+		}
+	}
+
+	viable, shadow, promoted := 0, 0, 0
+	var rows [][]dashboard.Cell
+	for _, r := range strategies.Recommendations {
+		status, class := "—", "detail"
+		if r.Viable {
+			status, class = "viable", "good"
+			viable++
+		}
+		stage := Promotion{Symbol: r.Symbol, Strategy: fmt.Sprintf("%s-v%d", r.Strategy, max(r.Version, 1)), ParamsHash: r.ParamsHash}
+		stage, inCohort := cohort[stage.Key()]
+		if r.Viable && inCohort {
+			if stage.Status == Promoted {
+				promoted++
+			} else {
+				shadow++
+			}
+		}
+		rows = append(rows, []dashboard.Cell{
+			{Text: r.Symbol, Bold: true},
+			{Text: labels.Strategy(r.Label()), Title: r.Label()},
+			{Text: fmt.Sprintf("%.1f%%", r.WinRate*100)},
+			{Text: fmt.Sprintf("%+.2f%%", r.AvgProfit*100)},
+			{Text: fmt.Sprintf("%d", r.TradeCount)},
+			{Text: fmt.Sprintf("%.2f%%", r.AvgMAE*100), Class: "sell"},
+			{Text: fmt.Sprintf("%+.2f%%", r.AvgMFE*100), Class: "buy"},
+			{Text: capacityText(r.Capacity)},
+			{Text: status, Class: class},
+			promotionCell(stage, r.Viable && inCohort),
+		})
+	}
+
+	var excursionRows [][]dashboard.Cell
+	for _, e := range excursionsByStrategy(strategies.Recommendations) {
+		excursionRows = append(excursionRows, []dashboard.Cell{
+			{Text: labels.Strategy(e.Strategy), Bold: true, Title: e.Strategy},
+			{Text: fmt.Sprintf("%d", e.Trades)},
+			{Text: fmt.Sprintf("%.2f%%", e.MAE*100), Class: "sell"},
+			{Text: fmt.Sprintf("%+.2f%%", e.MFE*100), Class: "buy"},
+			{Text: fmt.Sprintf("%.2f%%", e.WinnerMAE*100)},
+			{Text: fmt.Sprintf("%+.2f%%", e.LoserMFE*100)},
+		})
+	}
+
+	curves, err := LoadEquityCurves(curvesPath)
+	if err != nil {
+		return "", err
+	}
+	var charts []dashboard.Chart
+	chart, curveRows := equityChart(curves)
+	if len(curves) > 0 {
+		charts = append(charts, chart)
+	}
+
+	return dashboard.Render(dashboard.Page{
+		Title:    "Strategy Recommendations",
+		Subtitle: "Backtest: " + strategies.Timestamp,
+		Stats: []dashboard.Stat{
+			{Label: "Tested", Value: fmt.Sprintf("%d", len(strategies.Recommendations))},
+			{Label: "Viable", Value: fmt.Sprintf("%d", viable), Class: "good"},
+			{Label: "On Paper", Value: fmt.Sprintf("%d", shadow)},
+			{Label: "Promoted", Value: fmt.Sprintf("%d", promoted), Class: "good"},
+		},
+		Charts: charts,
+		Tables: []dashboard.Table{
+			{
+				Caption: "Equity by Strategy",
+				Headers: []string{"Strategy", "Trades", "Return", "Max Drawdown"},
+				Rows:    curveRows,
+				Empty:   "No equity curves",
+			},
+			{
+				Caption: "Excursions by Strategy",
+				Headers: []string{"Strategy", "Trades", "Avg MAE", "Avg MFE", "Winner MAE", "Loser MFE"},
+				Rows:    excursionRows,
+				Empty:   "No trades",
+			},
+			{
+				Caption: "Recommendations",
+				Headers: []string{"Symbol", "Strategy", "Win Rate", "Avg Profit", "Trades", "MAE", "MFE", "Capacity", "Status", "Promotion"},
+				Rows:    rows,
+				Empty:   "No strategies tested",
+			},
+		},
+	})
+}
+
+// promotionCell shows where a recommendation stands in the paper cohort,
+// with the reason on hover. Those the live account already trades, or
+// lft2 promote hasn't judged, have none.
+func promotionCell(p Promotion, inCohort bool) dashboard.Cell {
+	if !inCohort {
+		return dashboard.Cell{Text: "—", Class: "detail"}
+	}
+	switch p.Status {
+	case Promoted:
+		return dashboard.Cell{Text: "promoted " + p.PromotedOn, Class: "good"}
+	case Diverged:
+		return dashboard.Cell{Text: "diverged", Class: "sell", Title: p.Reason}
+	}
+	return dashboard.Cell{Text: fmt.Sprintf("paper since %s, %d trade(s)", p.Since, p.Trades), Title: p.Reason}
+}
+
+// capacityText formats a recommendation's capacity in whole dollars.
+func capacityText(capacity *float64) string {
+	if capacity == nil {
+		return "—"
+	}
+	return fmt.Sprintf("$%.0f", *capacity)
+}
+
+// excursionsByStrategy aggregates MAE/MFE per strategy, weighting each
+// symbol by its trade count. Winners whose MAE sits near the stop loss
+// suggest stops are too tight; losers whose MFE reached the take profit
+// suggest targets are too modest.
+func excursionsByStrategy(recs []Recommendation) []Excursion {
+	type sums struct {
+		trades, winners, losers       float64
+		mae, mfe, winnerMAE, loserMFE float64
+	}
+	byStrategy := map[string]*sums{}
+	var order []string
+
+	for _, r := range recs {
+		if r.TradeCount == 0 {
+			continue
+		}
+		s, ok := byStrategy[r.Strategy]
+		if !ok {
+			s = &sums{}
+			byStrategy[r.Strategy] = s
+			order = append(order, r.Strategy)
+		}
+		n := float64(r.TradeCount)
+		winners := math.Round(n * r.WinRate)
+		s.trades += n
+		s.winners += winners
+		s.losers += n - winners
+		s.mae += r.AvgMAE * n
+		s.mfe += r.AvgMFE * n
+		s.winnerMAE += r.AvgWinnerMAE * winners
+		s.loserMFE += r.AvgLoserMFE * (n - winners)
+	}
+
+	sort.Strings(order)
+	excursions := make([]Excursion, 0, len(order))
+	for _, name := range order {
+		s := byStrategy[name]
+		e := Excursion{
+			Strategy: name,
+			Trades:   int(s.trades),
+			MAE:      s.mae / s.trades,
+			MFE:      s.mfe / s.trades,
+		}
+		if s.winners > 0 {
+			e.WinnerMAE = s.winnerMAE / s.winners
+		}
+		if s.losers > 0 {
+			e.LoserMFE = s.loserMFE / s.losers
+		}
+		excursions = append(excursions, e)
+	}
+	return excursions
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- excursionsByStrategy ---
+
+func TestExcursionsByStrategy_WeightsByTrades(t *testing.T) {
+	got := excursionsByStrategy([]Recommendation{
+		{Strategy: "rsi_oversold", TradeCount: 4, WinRate: 0.5, AvgMAE: -0.01, AvgMFE: 0.02, AvgWinnerMAE: -0.004, AvgLoserMFE: 0.006},
+		{Strategy: "rsi_oversold", TradeCount: 1, WinRate: 1.0, AvgMAE: -0.02, AvgMFE: 0.03, AvgWinnerMAE: -0.02},
+		{Strategy: "gap_fill", TradeCount: 2, WinRate: 0.5, AvgMAE: -0.005, AvgMFE: 0.01},
+		{Strategy: "momentum", TradeCount: 0},
+	})
+
+	if len(got) != 2 || got[0].Strategy != "gap_fill" || got[1].Strategy != "rsi_oversold" {
+		t.Fatalf("got %+v, want gap_fill and rsi_oversold in order", got)
+	}
+
+	rsi := got[1]
+	if rsi.Trades != 5 {
+		t.Errorf("trades: got %d, want 5", rsi.Trades)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if !near(rsi.MAE, -0.012) {
+		t.Errorf("MAE: got %g, want -0.012", rsi.MAE)
+	}
+	if !near(rsi.WinnerMAE, (-0.004*2+-0.02)/3) {
+		t.Errorf("winner MAE: got %g", rsi.WinnerMAE)
+	}
+	if !near(rsi.LoserMFE, 0.006) {
+		t.Errorf("loser MFE: got %g, want 0.006", rsi.LoserMFE)
+	}
+}
+
+// --- LoadEquityCurves / equityChart ---
+
+func TestLoadEquityCurves(t *testing.T) {
+	if got, err := LoadEquityCurves("/nonexistent/equity-curves.json"); got != nil || err != nil {
+		t.Errorf("missing file: got %v, %v; want nil, nil", got, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "equity-curves.json")
+	os.WriteFile(path, []byte(`{"timestamp": "2026-03-10T21:00:00Z", "curves": [
+  {"strategy": "gap_fill", "trades": 2, "final_equity": 0.9900, "max_drawdown": 0.0196,
+    "timestamps": ["2026-03-09T15:00:00Z", "2026-03-10T15:00:00Z"],
+    "equity": [1.0100, 0.9900]}
+]}`), 0644)
+
+	curves, err := LoadEquityCurves(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(curves) != 1 || len(curves[0].Equity) != 2 || curves[0].Timestamps[1] != "2026-03-10T15:00:00Z" {
+		t.Fatalf("got %+v", curves)
+	}
+
+	chart, rows := equityChart(curves)
+	if len(chart.Lines) != 1 || chart.Lines[0].Label != "Gap fill" {
+		t.Errorf("chart: got %+v", chart)
+	}
+	if rows[0][2].Text != "-1.00%" || rows[0][2].Class != "sell" {
+		t.Errorf("return cell: got %+v", rows[0][2])
+	}
+}
+
+// --- capacityText ---
+
+func TestCapacityText(t *testing.T) {
+	capacity := 12345.6
+	if got := capacityText(&capacity); got != "$12346" {
+		t.Errorf("capacityText = %q, want $12346", got)
+	}
+	if got := capacityText(nil); got != "—" {
+		t.Errorf("capacityText(nil) = %q, want —", got)
+	}
+}
+
+// --- Recommendation.Label ---
+
+func TestRecommendationLabel(t *testing.T) {
+	if got := (Recommendation{Strategy: "momentum", Version: 2}).Label(); got != "momentum-v2" {
+		t.Errorf("Label = %q, want momentum-v2", got)
+	}
+	if got := (Recommendation{Strategy: "momentum"}).Label(); got != "momentum" {
+		t.Errorf("unversioned Label = %q, want momentum", got)
+	}
+}
+
+// --- StrategiesHTML ---
+
+func TestStrategiesHTML_Promotion(t *testing.T) {
+	dir := t.TempDir()
+	strategies := filepath.Join(dir, "strategies.json")
+	os.WriteFile(strategies, []byte(`{"timestamp": "2026-03-10T21:00:00Z", "recommendations": [
+		{"symbol": "AAPL", "strategy": "gap_fill", "version": 2, "params_hash": "deadbeef", "viable": true},
+		{"symbol": "MSFT", "strategy": "gap_fill", "version": 2, "params_hash": "deadbeef", "viable": true},
+		{"symbol": "NVDA", "strategy": "gap_fill", "version": 2, "params_hash": "feedface", "viable": true}
+	]}`), 0644)
+	promotions := filepath.Join(dir, "promotions.json")
+	os.WriteFile(promotions, []byte(`{"changes": [
+		{"symbol": "AAPL", "strategy": "gap_fill-v2", "params_hash": "deadbeef", "status": "promoted", "since": "2026-03-01", "promoted_on": "2026-03-10"},
+		{"symbol": "MSFT", "strategy": "gap_fill-v2", "params_hash": "deadbeef", "status": "shadow", "since": "2026-03-08", "trades": 2},
+		{"symbol": "NVDA", "strategy": "gap_fill-v2", "params_hash": "0ld0ld00", "status": "shadow", "since": "2026-02-01"}
+	]}`), 0644)
+
+	html, err := StrategiesHTML(strategies, filepath.Join(dir, "none.json"), promotions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"promoted 2026-03-10", "paper since 2026-03-08, 2 trade(s)"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	// NVDA's cohort was for parameters it no longer has
+	if strings.Contains(html, "2026-02-01") {
+		t.Error("page shows a cohort for other parameters")
+	}
+
+	if _, err := StrategiesHTML(strategies, filepath.Join(dir, "none.json"), filepath.Join(dir, "none.json")); err != nil {
+		t.Errorf("without promotions.json: %v", err)
+	}
+}
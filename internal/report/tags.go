@@ -0,0 +1,74 @@
+package report
+
+import (
+	"sort"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/journal"
+)
+
+// TagTally is the day's fills and closed round trips for orders carrying
+// one tag, from LFT2_ORDER_TAGS when they were submitted.
+type TagTally struct {
+	Tag        string         `json:"tag"` // key=value
+	Buys       int            `json:"buys"`
+	Sells      int            `json:"sells"`
+	Bought     alpaca.Decimal `json:"bought"`
+	Sold       alpaca.Decimal `json:"sold"`
+	RoundTrips int            `json:"round_trips"`
+	PnL        alpaca.Decimal `json:"pnl"` // Round trips, before fees
+}
+
+// ByTag groups the day's fills and round trips by each key=value tag their
+// orders carry, so live results can be sliced by experiment. An order with
+// two tags counts under both; untagged orders are left out. Sorted by tag.
+func ByTag(acts []Activity, trips []RoundTrip) []TagTally {
+	tallies := map[string]*TagTally{}
+	each := func(tags map[string]string, fn func(t *TagTally)) {
+		for k, v := range tags {
+			key := k + "=" + v
+			t, ok := tallies[key]
+			if !ok {
+				t = &TagTally{Tag: key}
+				tallies[key] = t
+			}
+			fn(t)
+		}
+	}
+
+	for _, act := range acts {
+		each(act.Tags, func(t *TagTally) {
+			switch act.Side {
+			case "buy":
+				t.Buys++
+				t.Bought += act.Value
+			case "sell":
+				t.Sells++
+				t.Sold += act.Value
+			}
+		})
+	}
+	for _, trip := range trips {
+		each(trip.Tags, func(t *TagTally) {
+			t.RoundTrips++
+			t.PnL += trip.PnL
+		})
+	}
+
+	out := make([]TagTally, 0, len(tallies))
+	for _, t := range tallies {
+		t.Bought, t.Sold, t.PnL = t.Bought.Round(2), t.Sold.Round(2), t.PnL.Round(2)
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tag < out[j].Tag })
+	return out
+}
+
+// tagNote is an order's tags as they appear among its notes, empty when
+// it has none.
+func tagNote(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return journal.FormatTags(tags)
+}
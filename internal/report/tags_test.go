@@ -0,0 +1,71 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+	"github.com/deanturpin/lft2/internal/journal"
+)
+
+// --- ByTag ---
+
+func TestByTag_CountsUnderEachTag(t *testing.T) {
+	acts := []Activity{
+		{Side: "buy", Value: 1000, Tags: map[string]string{"experiment": "a", "cohort": "x"}},
+		{Side: "sell", Value: 1010, Tags: map[string]string{"experiment": "a"}},
+		{Side: "buy", Value: 500, Tags: map[string]string{"experiment": "b"}},
+		{Side: "buy", Value: 50}, // Untagged, left out
+	}
+	trips := []RoundTrip{{PnL: 10, Tags: map[string]string{"experiment": "a"}}}
+
+	got := ByTag(acts, trips)
+	if len(got) != 3 || got[0].Tag != "cohort=x" || got[1].Tag != "experiment=a" || got[2].Tag != "experiment=b" {
+		t.Fatalf("got %+v, want cohort=x, experiment=a, experiment=b", got)
+	}
+	a := got[1]
+	if a.Buys != 1 || a.Sells != 1 || a.Bought.Money() != "1000.00" || a.Sold.Money() != "1010.00" {
+		t.Errorf("experiment=a fills: got %+v", a)
+	}
+	if a.RoundTrips != 1 || a.PnL.Money() != "10.00" {
+		t.Errorf("experiment=a round trips: got %+v", a)
+	}
+	if ByTag(nil, nil) == nil || len(ByTag([]Activity{{Side: "buy"}}, nil)) != 0 {
+		t.Error("want an empty, non-nil tally with nothing tagged")
+	}
+}
+
+func TestActivities_TagsFromLineage(t *testing.T) {
+	notes := &journal.Journal{}
+	notes.Tag("AAPL_gap_fill_1", map[string]string{"experiment": "a"}, closeTime)
+
+	acts := Reporter{Notes: notes}.Activities([]alpaca.Order{bracket()}, "2026-03-10")
+	if len(acts) != 1 || acts[0].Tags["experiment"] != "a" {
+		t.Fatalf("got %+v, want the stop leg tagged experiment=a", acts)
+	}
+	if got := strings.Join(notesOf(acts[0]), "; "); got != "experiment=a" {
+		t.Errorf("notes column: got %q", got)
+	}
+}
+
+func TestDailyHTML_ByTag(t *testing.T) {
+	acts := []Activity{{
+		TransactTime: "2026-03-10T15:00:00Z", Symbol: "AAPL", Side: "buy", Qty: 10, Price: 100, Value: 1000,
+		Tags: map[string]string{"experiment": "a"}, Notes: []string{"chased"},
+	}}
+	s := DailySummary{Date: "2026-03-10", Activities: acts, Summary: Summarise(acts), ByTag: ByTag(acts, nil)}
+	html, err := DailyHTML(s, nil, nil)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	for _, want := range []string{"By order tag", "experiment=a; chased"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+
+	s.ByTag = nil
+	if html, _ := DailyHTML(s, nil, nil); strings.Contains(html, "By order tag") {
+		t.Error("untagged day shouldn't have a tag table")
+	}
+}
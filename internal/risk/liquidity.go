@@ -0,0 +1,184 @@
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// LiquidityPath is where account writes the liquidity curves.
+const LiquidityPath = "docs/liquidity.json"
+
+const (
+	// BucketMinutes is the width of each slice of the regular session, so
+	// 09:30 to 16:00 New York is 13 buckets.
+	BucketMinutes = 30
+
+	// Buckets is how many slices the regular session is cut into.
+	Buckets = 390 / BucketMinutes
+
+	// MinLiquidityDays is how many sessions of history a curve needs. Fewer
+	// and one busy afternoon would be read as the symbol's shape.
+	MinLiquidityDays = 5
+)
+
+// newYork is the exchange timezone the session buckets are cut in.
+var newYork = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+// Curve is how a symbol's volume spreads across the session: one weight per
+// bucket, where 1 is the session's average and 0.5 half of it.
+type Curve struct {
+	Days    int       `json:"days"`    // Sessions averaged
+	Weights []float64 `json:"weights"` // One per bucket, from the open
+}
+
+// Liquidity is the on-disk layout of liquidity.json.
+type Liquidity struct {
+	schema.Header
+	Timestamp     string           `json:"timestamp"`
+	BucketMinutes int              `json:"bucket_minutes"`
+	Symbols       map[string]Curve `json:"symbols"`
+}
+
+// Bucket returns which slice of the regular session t falls in, and false
+// outside it.
+func Bucket(t time.Time) (int, bool) {
+	t = t.In(newYork)
+	minute := t.Hour()*60 + t.Minute() - (9*60 + 30)
+	if minute < 0 || minute >= Buckets*BucketMinutes {
+		return 0, false
+	}
+	return minute / BucketMinutes, true
+}
+
+// LiquidityCurve reads docs/bars/{symbol}.json and averages each session's
+// share of volume by bucket. Days are weighted equally, so one heavy day
+// doesn't set the shape, and days without regular-session volume are left
+// out. ok is false with fewer than MinLiquidityDays sessions.
+func LiquidityCurve(barsDir, symbol string) (c Curve, ok bool, err error) {
+	data, err := barfile.Read(barsDir, symbol)
+	if err != nil {
+		return Curve{}, false, err
+	}
+	var f struct {
+		Bars []struct {
+			Timestamp string  `json:"t"`
+			Volume    float64 `json:"v"`
+		} `json:"bars"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Curve{}, false, fmt.Errorf("parsing %s bars: %w", symbol, err)
+	}
+
+	byDay := map[string]*[Buckets]float64{}
+	for _, b := range f.Bars {
+		t, err := time.Parse(time.RFC3339, b.Timestamp)
+		if err != nil || b.Volume <= 0 {
+			continue
+		}
+		bucket, in := Bucket(t)
+		if !in {
+			continue
+		}
+		day := t.In(newYork).Format(time.DateOnly)
+		if byDay[day] == nil {
+			byDay[day] = &[Buckets]float64{}
+		}
+		byDay[day][bucket] += b.Volume
+	}
+
+	var shares [Buckets]float64
+	for _, volumes := range byDay {
+		total := 0.0
+		for _, v := range volumes {
+			total += v
+		}
+		for i, v := range volumes {
+			shares[i] += v / total
+		}
+		c.Days++
+	}
+	if c.Days < MinLiquidityDays {
+		return Curve{Days: c.Days}, false, nil
+	}
+
+	// An even spread gives each bucket 1/Buckets of the day
+	c.Weights = make([]float64, Buckets)
+	for i, s := range shares {
+		c.Weights[i] = math.Round(s/float64(c.Days)*Buckets*1e4) / 1e4
+	}
+	return c, true, nil
+}
+
+// Factor is the share of a full-size entry to place at t: the bucket's
+// weight, capped at 1 so busy stretches never size up, and floored at
+// floor so a thin one still trades. Outside the session, or without a
+// weight for the bucket, it's 1.
+func (c Curve) Factor(t time.Time, floor float64) float64 {
+	bucket, in := Bucket(t)
+	if !in || bucket >= len(c.Weights) {
+		return 1
+	}
+	return math.Max(floor, math.Min(1, c.Weights[bucket]))
+}
+
+// LiquidityFloorFromEnv returns LFT2_LIQUIDITY_FLOOR, the smallest share of
+// a full-size entry liquidity sizing leaves, or 0 when unset: time-weighted
+// sizing is off.
+func LiquidityFloorFromEnv() (float64, error) {
+	s := os.Getenv("LFT2_LIQUIDITY_FLOOR")
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 || v > 1 {
+		return 0, fmt.Errorf("LFT2_LIQUIDITY_FLOOR must be a fraction above 0 and up to 1, got %q", s)
+	}
+	return v, nil
+}
+
+// LoadLiquidity reads liquidity.json. A missing file has no curves, and
+// every entry goes in at full size.
+func LoadLiquidity(path string) (*Liquidity, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Liquidity{Symbols: map[string]Curve{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading liquidity: %w", err)
+	}
+	if err := schema.Check(path, data); err != nil {
+		return nil, err
+	}
+	l := &Liquidity{}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if l.Symbols == nil {
+		l.Symbols = map[string]Curve{}
+	}
+	return l, nil
+}
+
+// SaveLiquidity writes liquidity.json.
+func SaveLiquidity(path string, l Liquidity) error {
+	l.Header = schema.Current()
+	l.BucketMinutes = BucketMinutes
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding liquidity: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
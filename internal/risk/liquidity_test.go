@@ -0,0 +1,129 @@
+package risk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- Bucket ---
+
+func TestBucket(t *testing.T) {
+	for _, tc := range []struct {
+		at     time.Time
+		bucket int
+		in     bool
+	}{
+		{time.Date(2026, 3, 10, 13, 30, 0, 0, time.UTC), 0, true},           // 09:30, the open
+		{time.Date(2026, 3, 10, 16, 10, 0, 0, time.UTC), 5, true},           // 12:10
+		{time.Date(2026, 3, 10, 19, 55, 0, 0, time.UTC), Buckets - 1, true}, // 15:55
+		{time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC), 0, false},           // 16:00, the close
+		{time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC), 0, false},           // Pre-market
+	} {
+		if bucket, in := Bucket(tc.at); bucket != tc.bucket || in != tc.in {
+			t.Errorf("%s: got %d %v, want %d %v", tc.at, bucket, in, tc.bucket, tc.in)
+		}
+	}
+}
+
+// --- LiquidityCurve ---
+
+// writeSessions writes days of 30-minute bars, volume 100 a bar except
+// 1000 in the first and 25 in bucket 5.
+func writeSessions(t *testing.T, dir string, days int) {
+	t.Helper()
+	var bars []string
+	for d := 0; d < days; d++ {
+		open := time.Date(2026, 3, 9+d, 13, 30, 0, 0, time.UTC) // After the clocks change
+		for b := 0; b < Buckets; b++ {
+			v := 100
+			switch b {
+			case 0:
+				v = 1000
+			case 5:
+				v = 25
+			}
+			at := open.Add(time.Duration(b*BucketMinutes) * time.Minute)
+			bars = append(bars, fmt.Sprintf(`{"t": %q, "v": %d}`, at.Format(time.RFC3339), v))
+		}
+		// After hours, left out
+		bars = append(bars, fmt.Sprintf(`{"t": %q, "v": 99999}`, open.Add(7*time.Hour).Format(time.RFC3339)))
+	}
+	os.WriteFile(filepath.Join(dir, "AAPL.json"), []byte(`{"symbol": "AAPL", "bars": [`+strings.Join(bars, ",")+`]}`), 0644)
+}
+
+func TestLiquidityCurve(t *testing.T) {
+	dir := t.TempDir()
+	writeSessions(t, dir, MinLiquidityDays)
+
+	c, ok, err := LiquidityCurve(dir, "AAPL")
+	if err != nil || !ok {
+		t.Fatalf("got %+v, %v, %v", c, ok, err)
+	}
+	// A day's volume is 1000 + 25 + 11*100 = 2125
+	if c.Days != MinLiquidityDays || len(c.Weights) != Buckets {
+		t.Fatalf("got %d days, %d weights", c.Days, len(c.Weights))
+	}
+	if c.Weights[0] != 6.1176 || c.Weights[5] != 0.1529 || c.Weights[1] != 0.6118 {
+		t.Errorf("weights: got %v", c.Weights)
+	}
+
+	lunch := time.Date(2026, 3, 10, 16, 10, 0, 0, time.UTC)
+	if f := c.Factor(lunch, 0.25); f != 0.25 {
+		t.Errorf("lunch factor: got %g, want the 0.25 floor", f)
+	}
+	if f := c.Factor(time.Date(2026, 3, 10, 13, 35, 0, 0, time.UTC), 0.25); f != 1 {
+		t.Errorf("open factor: got %g, want capped at 1", f)
+	}
+	if f := c.Factor(time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC), 0.25); f != 1 {
+		t.Errorf("after hours factor: got %g, want 1", f)
+	}
+}
+
+func TestLiquidityCurve_TooFewDays(t *testing.T) {
+	dir := t.TempDir()
+	writeSessions(t, dir, MinLiquidityDays-1)
+	if c, ok, err := LiquidityCurve(dir, "AAPL"); ok || err != nil || c.Days != MinLiquidityDays-1 {
+		t.Errorf("got %+v, %v, %v", c, ok, err)
+	}
+	if _, _, err := LiquidityCurve(dir, "NONE"); err == nil {
+		t.Error("expected error for missing bar file")
+	}
+}
+
+// --- LiquidityFloorFromEnv / LoadLiquidity ---
+
+func TestLiquidityFloorFromEnv(t *testing.T) {
+	t.Setenv("LFT2_LIQUIDITY_FLOOR", "")
+	if v, err := LiquidityFloorFromEnv(); v != 0 || err != nil {
+		t.Errorf("unset: got %g, %v", v, err)
+	}
+	t.Setenv("LFT2_LIQUIDITY_FLOOR", "0.5")
+	if v, err := LiquidityFloorFromEnv(); v != 0.5 || err != nil {
+		t.Errorf("0.5: got %g, %v", v, err)
+	}
+	for _, bad := range []string{"0", "1.5", "half"} {
+		t.Setenv("LFT2_LIQUIDITY_FLOOR", bad)
+		if _, err := LiquidityFloorFromEnv(); err == nil {
+			t.Errorf("%q: want an error", bad)
+		}
+	}
+}
+
+func TestLoadSaveLiquidity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "liquidity.json")
+	if l, err := LoadLiquidity(path); err != nil || len(l.Symbols) != 0 {
+		t.Fatalf("missing file: got %+v, %v", l, err)
+	}
+	l := Liquidity{Symbols: map[string]Curve{"AAPL": {Days: 5, Weights: []float64{2, 0.5}}}}
+	if err := SaveLiquidity(path, l); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadLiquidity(path)
+	if err != nil || got.BucketMinutes != BucketMinutes || got.Symbols["AAPL"].Weights[1] != 0.5 {
+		t.Errorf("got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,178 @@
+// Package risk estimates portfolio risk over the current positions from
+// stored bar history: a one-day historical-simulation value at risk and a
+// worst-day stress loss. Account writes the estimate to docs/exposure.json,
+// and entries stops opening positions while VaR exceeds the configured share
+// of equity. It also builds each candidate's intraday volume curve for
+// docs/liquidity.json, an optional modifier execute sizes buys by.
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// DefaultPath is where account writes the exposure estimate.
+const DefaultPath = "docs/exposure.json"
+
+// DefaultConfidence is the VaR confidence level.
+const DefaultConfidence = 0.95
+
+// DefaultMaxVaR is the largest one-day VaR, as a fraction of equity, at which
+// entries still open positions. Override with LFT2_MAX_VAR.
+const DefaultMaxVaR = 0.02
+
+// Holding is a position's current market value in USD.
+type Holding struct {
+	Symbol string  `json:"symbol"`
+	Value  float64 `json:"value"`
+}
+
+// Exposure is the on-disk layout of exposure.json. Entries reads var_pct and
+// max_var_pct with a minimal JSON scanner, so scalar fields come first.
+type Exposure struct {
+	schema.Header
+	Timestamp   string  `json:"timestamp"`
+	Equity      float64 `json:"equity"`
+	GrossValue  float64 `json:"gross_value"`   // Sum of position values
+	Confidence  float64 `json:"confidence"`    // e.g. 0.95
+	Scenarios   int     `json:"scenarios"`     // Historical days simulated
+	VaR         float64 `json:"var"`           // One-day loss not exceeded at Confidence, USD
+	VaRPct      float64 `json:"var_pct"`       // VaR / equity
+	MaxVaRPct   float64 `json:"max_var_pct"`   // Entry gate threshold
+	WorstDay    float64 `json:"worst_day"`     // Loss on the worst historical day, USD
+	WorstDayPct float64 `json:"worst_day_pct"` // WorstDay / equity
+	WorstDate   string  `json:"worst_date,omitempty"`
+	Breached    bool    `json:"breached"` // VaRPct > MaxVaRPct: entries paused
+
+	Holdings []Holding `json:"holdings"`
+}
+
+// MaxVaRFromEnv returns LFT2_MAX_VAR as a fraction of equity, or
+// DefaultMaxVaR when unset.
+func MaxVaRFromEnv() (float64, error) {
+	s := os.Getenv("LFT2_MAX_VAR")
+	if s == "" {
+		return DefaultMaxVaR, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 || v >= 1 {
+		return 0, fmt.Errorf("LFT2_MAX_VAR must be a fraction between 0 and 1, got %q", s)
+	}
+	return v, nil
+}
+
+// DailyReturns reads docs/bars/{symbol}.json and returns close-to-close
+// returns keyed by the later day (YYYY-MM-DD), using each day's last bar.
+func DailyReturns(barsDir, symbol string) (map[string]float64, error) {
+	data, err := barfile.Read(barsDir, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var f struct {
+		Bars []struct {
+			Timestamp string  `json:"t"`
+			Close     float64 `json:"c"`
+		} `json:"bars"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s bars: %w", symbol, err)
+	}
+
+	// Bars are in time order, so the last bar seen for a day is its close
+	closes := map[string]float64{}
+	var days []string
+	for _, b := range f.Bars {
+		if len(b.Timestamp) < 10 || b.Close <= 0 {
+			continue
+		}
+		day := b.Timestamp[:10]
+		if _, ok := closes[day]; !ok {
+			days = append(days, day)
+		}
+		closes[day] = b.Close
+	}
+	sort.Strings(days)
+
+	returns := make(map[string]float64, len(days))
+	for i := 1; i < len(days); i++ {
+		returns[days[i]] = closes[days[i]]/closes[days[i-1]] - 1
+	}
+	return returns, nil
+}
+
+// Estimate replays each historical day's returns against today's holdings.
+// A symbol with no return on a day contributes nothing to that day's P&L.
+// VaR is the loss at the confidence quantile of the simulated losses.
+func Estimate(holdings []Holding, returns map[string]map[string]float64, equity, confidence float64) Exposure {
+	e := Exposure{
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		Equity:     equity,
+		Confidence: confidence,
+		Holdings:   holdings,
+	}
+	for _, h := range holdings {
+		e.GrossValue += math.Abs(h.Value)
+	}
+
+	pnl := map[string]float64{}
+	for _, h := range holdings {
+		for day, r := range returns[h.Symbol] {
+			pnl[day] += h.Value * r
+		}
+	}
+	if len(pnl) == 0 {
+		return e
+	}
+
+	days := make([]string, 0, len(pnl))
+	losses := make([]float64, 0, len(pnl))
+	for day, p := range pnl {
+		days = append(days, day)
+		losses = append(losses, -p)
+	}
+	sort.Float64s(losses)
+
+	e.Scenarios = len(losses)
+	i := int(math.Ceil(confidence*float64(len(losses)))) - 1
+	e.VaR = math.Max(0, losses[max(0, min(i, len(losses)-1))])
+	e.WorstDay = math.Max(0, losses[len(losses)-1])
+	if e.WorstDay > 0 {
+		sort.Strings(days)
+		for _, day := range days {
+			if -pnl[day] == losses[len(losses)-1] {
+				e.WorstDate = day
+				break
+			}
+		}
+	}
+	if equity > 0 {
+		e.VaRPct = e.VaR / equity
+		e.WorstDayPct = e.WorstDay / equity
+	}
+	return e
+}
+
+// Gate sets the entry threshold and whether VaR breaches it.
+func (e *Exposure) Gate(maxVaR float64) {
+	e.MaxVaRPct = maxVaR
+	e.Breached = e.VaRPct > maxVaR
+}
+
+// Save writes exposure.json.
+func Save(path string, e Exposure) error {
+	e.Header = schema.Current()
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding exposure: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,117 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// --- DailyReturns ---
+
+func TestDailyReturns_LastBarOfEachDay(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "AAPL.json"), []byte(`{"symbol": "AAPL", "bars": [
+		{"t": "2026-03-09T14:30:00Z", "c": 90},
+		{"t": "2026-03-09T20:55:00Z", "c": 100},
+		{"t": "2026-03-10T14:30:00Z", "c": 104},
+		{"t": "2026-03-10T20:55:00Z", "c": 110},
+		{"t": "2026-03-11T20:55:00Z", "c": 99}
+	]}`), 0644)
+
+	got, err := DailyReturns(dir, "AAPL")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d returns, want 2: %v", len(got), got)
+	}
+	if math.Abs(got["2026-03-10"]-0.10) > 1e-9 || math.Abs(got["2026-03-11"]+0.10) > 1e-9 {
+		t.Errorf("got %v, want +10%% then -10%%", got)
+	}
+}
+
+func TestDailyReturns_MissingFile(t *testing.T) {
+	if _, err := DailyReturns(t.TempDir(), "NONE"); err == nil {
+		t.Error("expected error for missing bar file")
+	}
+}
+
+// --- Estimate ---
+
+func TestEstimate_QuantileAndWorstDay(t *testing.T) {
+	// Twenty days of returns: losses of 1%..20% on a $1000 holding
+	returns := map[string]float64{}
+	for i := 1; i <= 20; i++ {
+		returns[dayN(i)] = -float64(i) / 100
+	}
+
+	e := Estimate([]Holding{{Symbol: "AAPL", Value: 1000}}, map[string]map[string]float64{"AAPL": returns}, 10000, 0.95)
+
+	if e.Scenarios != 20 {
+		t.Errorf("scenarios: got %d, want 20", e.Scenarios)
+	}
+	if math.Abs(e.VaR-190) > 1e-9 {
+		t.Errorf("VaR: got %g, want 190 (19th of 20 losses)", e.VaR)
+	}
+	if math.Abs(e.WorstDay-200) > 1e-9 || e.WorstDate != dayN(20) {
+		t.Errorf("worst day: got %g on %s", e.WorstDay, e.WorstDate)
+	}
+	if math.Abs(e.VaRPct-0.019) > 1e-9 {
+		t.Errorf("VaR pct: got %g, want 0.019", e.VaRPct)
+	}
+}
+
+func TestEstimate_OffsettingPositions(t *testing.T) {
+	returns := map[string]map[string]float64{
+		"A": {"2026-03-10": 0.05, "2026-03-11": -0.05},
+		"B": {"2026-03-10": -0.05, "2026-03-11": 0.05},
+	}
+	e := Estimate([]Holding{{"A", 1000}, {"B", 1000}}, returns, 10000, 0.95)
+	if e.VaR != 0 || e.WorstDay != 0 {
+		t.Errorf("perfect hedge should carry no risk: VaR %g, worst %g", e.VaR, e.WorstDay)
+	}
+}
+
+func TestEstimate_NoHistory(t *testing.T) {
+	e := Estimate([]Holding{{"AAPL", 1000}}, nil, 10000, 0.95)
+	if e.Scenarios != 0 || e.VaR != 0 || e.GrossValue != 1000 {
+		t.Errorf("got %+v", e)
+	}
+}
+
+// --- Gate ---
+
+func TestGate(t *testing.T) {
+	e := Exposure{VaRPct: 0.03}
+	e.Gate(0.02)
+	if !e.Breached || e.MaxVaRPct != 0.02 {
+		t.Errorf("got %+v, want breached at 0.02", e)
+	}
+	e.Gate(0.05)
+	if e.Breached {
+		t.Error("3% VaR should pass a 5% gate")
+	}
+}
+
+// --- MaxVaRFromEnv ---
+
+func TestMaxVaRFromEnv(t *testing.T) {
+	t.Setenv("LFT2_MAX_VAR", "")
+	if v, err := MaxVaRFromEnv(); err != nil || v != DefaultMaxVaR {
+		t.Errorf("unset: got %g, %v", v, err)
+	}
+	t.Setenv("LFT2_MAX_VAR", "0.05")
+	if v, err := MaxVaRFromEnv(); err != nil || v != 0.05 {
+		t.Errorf("got %g, %v; want 0.05", v, err)
+	}
+	t.Setenv("LFT2_MAX_VAR", "5")
+	if _, err := MaxVaRFromEnv(); err == nil {
+		t.Error("expected error for value >= 1")
+	}
+}
+
+func dayN(i int) string {
+	return fmt.Sprintf("2026-02-%02d", i)
+}
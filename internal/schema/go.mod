@@ -0,0 +1,7 @@
+module github.com/deanturpin/lft2/internal/schema
+
+go 1.21
+
+require github.com/deanturpin/lft2/internal/version v0.0.0
+
+replace github.com/deanturpin/lft2/internal/version => ../../internal/version
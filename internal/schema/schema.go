@@ -0,0 +1,96 @@
+// Package schema versions the JSON artifacts the pipeline writes, so the
+// dashboard and the pipeline stages can be upgraded independently.
+//
+// The compatibility policy:
+//
+//   - Adding a field is backward compatible and doesn't change Version;
+//     readers ignore fields they don't know.
+//   - Removing or renaming a field, or changing its type, units or meaning,
+//     bumps Version. Writers emit only the current version.
+//   - Readers accept any version up to their own. A file without
+//     schema_version predates versioning and reads as version 1, which is
+//     the shape those files already had.
+//   - A version newer than the reader's is refused with an error naming
+//     both, rather than being half-understood.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/deanturpin/lft2/internal/version"
+)
+
+// Version is the schema version this build writes and the newest it reads.
+const Version = 1
+
+// Header carries the schema version, the build of the code that wrote the
+// artifact and the pipeline cycle it was written in. Embed it first in an
+// artifact's top-level struct so schema_version is the first key written.
+type Header struct {
+	SchemaVersion int    `json:"schema_version"`
+	Build         string `json:"build,omitempty"` // Commit, from internal/version; empty when unstamped
+	Cycle         string `json:"cycle,omitempty"` // From LFT2_CYCLE; empty outside a pipeline run
+}
+
+// Current is the header writers stamp on every artifact.
+func Current() Header {
+	return Header{SchemaVersion: Version, Build: version.Build(), Cycle: Cycle()}
+}
+
+// CycleEnv holds the ID of the pipeline run in progress. The Makefile sets
+// one per run, and a CI retry of the run keeps it; see src/cycle.h.
+const CycleEnv = "LFT2_CYCLE"
+
+// MaxCycleLength is the longest cycle ID accepted.
+const MaxCycleLength = 32
+
+// Cycle returns this run's cycle ID, or "" when unset or malformed.
+func Cycle() string {
+	if id := os.Getenv(CycleEnv); ValidCycle(id) {
+		return id
+	}
+	return ""
+}
+
+// ValidCycle reports whether id is letters, digits and '-', up to
+// MaxCycleLength: safe inside a client_order_id, whose fields are separated
+// by '_'.
+func ValidCycle(id string) bool {
+	if id == "" || len(id) > MaxCycleLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Of returns the schema version of an encoded artifact, 1 if it has none.
+func Of(data []byte) (int, error) {
+	var h Header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return 0, err
+	}
+	if h.SchemaVersion == 0 {
+		return 1, nil
+	}
+	return h.SchemaVersion, nil
+}
+
+// Check reports an error if name was written by a newer pipeline than this
+// build understands. Unparseable data is left for the caller's own decoding
+// to report.
+func Check(name string, data []byte) error {
+	v, err := Of(data)
+	if err != nil {
+		return nil
+	}
+	if v > Version {
+		return fmt.Errorf("%s has schema_version %d, newer than %d which this build reads — upgrade the reader", name, v, Version)
+	}
+	return nil
+}
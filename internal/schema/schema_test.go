@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// --- Current ---
+
+func TestCurrent_WrittenFirst(t *testing.T) {
+	t.Setenv(CycleEnv, "")
+	out, err := json.Marshal(struct {
+		Header
+		Date string `json:"date"`
+	}{Current(), "2026-03-10"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if got := string(out); got != `{"schema_version":1,"date":"2026-03-10"}` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestCurrent_Cycle(t *testing.T) {
+	t.Setenv(CycleEnv, "gh42")
+	out, _ := json.Marshal(Current())
+	if got := string(out); got != `{"schema_version":1,"cycle":"gh42"}` {
+		t.Errorf("got %s", got)
+	}
+
+	t.Setenv(CycleEnv, "run_7")
+	if c := Current().Cycle; c != "" {
+		t.Errorf("malformed cycle stamped: %q", c)
+	}
+}
+
+// --- ValidCycle ---
+
+func TestValidCycle(t *testing.T) {
+	for id, want := range map[string]bool{
+		"gh9876543210":                        true,
+		"20260310T143500":                     true,
+		"run-7":                               true,
+		"":                                    false,
+		"a_b":                                 false,
+		"a|b":                                 false,
+		strings.Repeat("a", MaxCycleLength+1): false,
+	} {
+		if got := ValidCycle(id); got != want {
+			t.Errorf("%q: got %v, want %v", id, got, want)
+		}
+	}
+}
+
+// --- Of ---
+
+func TestOf(t *testing.T) {
+	for data, want := range map[string]int{
+		`{"schema_version": 1, "date": "x"}`: 1,
+		`{"schema_version": 3}`:              3,
+		`{"date": "x"}`:                      1, // predates versioning
+	} {
+		got, err := Of([]byte(data))
+		if err != nil || got != want {
+			t.Errorf("%s: got %d, %v; want %d", data, got, err, want)
+		}
+	}
+	if _, err := Of([]byte(`[1, 2]`)); err == nil {
+		t.Error("array: expected an error")
+	}
+}
+
+// --- Check ---
+
+func TestCheck(t *testing.T) {
+	if err := Check("a.json", []byte(`{"schema_version": 1}`)); err != nil {
+		t.Errorf("current: %v", err)
+	}
+	if err := Check("a.json", []byte(`{}`)); err != nil {
+		t.Errorf("unversioned: %v", err)
+	}
+	if err := Check("a.json", []byte(`not json`)); err != nil {
+		t.Errorf("garbage is the caller's to report: %v", err)
+	}
+	err := Check("a.json", []byte(`{"schema_version": 2}`))
+	if err == nil || !strings.Contains(err.Error(), "a.json has schema_version 2") {
+		t.Errorf("newer: got %v", err)
+	}
+}
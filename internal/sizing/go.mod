@@ -0,0 +1,10 @@
+module github.com/deanturpin/lft2/internal/sizing
+
+go 1.21
+
+require github.com/deanturpin/lft2/internal/alpaca v0.0.0
+
+replace (
+	github.com/deanturpin/lft2/internal/alpaca => ../../internal/alpaca
+	github.com/deanturpin/lft2/internal/httpconf => ../../internal/httpconf
+)
@@ -0,0 +1,64 @@
+// Package sizing turns an intended quantity into one the broker accepts. The
+// rules are explicit so an order never goes out as a float's string form
+// ("0.30000000000000004") that Alpaca rejects, or as a fraction of a share
+// that can't be traded fractionally.
+package sizing
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// Alpaca's limits on fractional orders
+const (
+	Precision   = 9   // Decimal places accepted in qty
+	MinNotional = 1.0 // Smallest fractional order, in dollars
+)
+
+// Float error tolerated before rounding down, so 2.9999999999 is 3 shares
+// rather than 2
+const epsilon = 1e-6
+
+// Rules say how one order's quantity may be expressed.
+type Rules struct {
+	Fractional bool // Fractions of a share allowed; otherwise whole shares only
+}
+
+// Shares is the rule for entries, which are sized in whole shares.
+var Shares = Rules{}
+
+// Position is the rule for closing a position, which may already hold a
+// fraction of a share.
+var Position = Rules{Fractional: true}
+
+// Qty rounds qty towards zero to whole shares, or to Precision places when
+// fractional. Never rounding up means an order can't exceed the intended
+// size or the position held.
+func (r Rules) Qty(qty alpaca.Decimal) alpaca.Decimal {
+	scale := 1.0
+	if r.Fractional {
+		scale = math.Pow(10, Precision)
+	}
+	q := float64(qty) * scale
+	if q < 0 {
+		return alpaca.Decimal(-math.Floor(-q+epsilon) / scale)
+	}
+	return alpaca.Decimal(math.Floor(q+epsilon) / scale)
+}
+
+// Order rounds qty with Qty and checks the result can be submitted at price.
+// A price of zero skips the notional check, for a market order with no
+// quote to hand.
+func (r Rules) Order(qty, price alpaca.Decimal) (alpaca.Decimal, error) {
+	rounded := r.Qty(qty)
+	if rounded <= 0 {
+		return 0, fmt.Errorf("qty %s is under one tradable unit", qty)
+	}
+	whole := rounded == alpaca.Decimal(math.Trunc(float64(rounded)))
+	if !whole && price > 0 && rounded.Mul(price, 2) < MinNotional {
+		return 0, fmt.Errorf("qty %s at $%s is under the $%.2f fractional minimum", rounded, price.Money(), MinNotional)
+	}
+	return rounded, nil
+}
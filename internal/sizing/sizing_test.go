@@ -0,0 +1,55 @@
+package sizing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deanturpin/lft2/internal/alpaca"
+)
+
+// --- Qty ---
+
+func TestQty(t *testing.T) {
+	cases := []struct {
+		rules Rules
+		qty   alpaca.Decimal
+		want  string
+	}{
+		{Shares, 3, "3"},
+		{Shares, 3.7, "3"},
+		{Shares, 2.9999999999, "3"},
+		{Shares, 0.5, "0"},
+		{Position, 0.1 + 0.2, "0.3"},
+		{Position, 1.1234567899, "1.123456789"},
+		{Position, 12, "12"},
+		{Position, -0.5, "-0.5"},
+	}
+	for _, c := range cases {
+		if got := c.rules.Qty(c.qty).String(); got != c.want {
+			t.Errorf("%+v %v: got %s, want %s", c.rules, float64(c.qty), got, c.want)
+		}
+	}
+}
+
+// --- Order ---
+
+func TestOrder(t *testing.T) {
+	if got, err := Shares.Order(10.4, 0); err != nil || got != 10 {
+		t.Errorf("whole shares: got %s, %v", got, err)
+	}
+	if _, err := Shares.Order(0.9, 150); err == nil || !strings.Contains(err.Error(), "under one tradable unit") {
+		t.Errorf("sub-share entry: got %v", err)
+	}
+	if got, err := Position.Order(0.25, 180); err != nil || got != 0.25 {
+		t.Errorf("fractional position: got %s, %v", got, err)
+	}
+	if _, err := Position.Order(0.004, 180); err == nil || !strings.Contains(err.Error(), "$1.00 fractional minimum") {
+		t.Errorf("dust position: got %v", err)
+	}
+	if got, err := Position.Order(0.004, 0); err != nil || got != 0.004 {
+		t.Errorf("no price: got %s, %v", got, err)
+	}
+	if got, err := Position.Order(1, 0.5); err != nil || got != 1 {
+		t.Errorf("whole share under $1: got %s, %v", got, err)
+	}
+}
@@ -0,0 +1,133 @@
+package spreads
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// QuotesPath is where fetch writes each cycle's latest quotes.
+const QuotesPath = "docs/quotes.json"
+
+const (
+	// SnapshotAge is how long a quotes.json stays current: one pipeline
+	// cycle. An older one is from a run that has since stopped, and its
+	// spreads say nothing about now.
+	SnapshotAge = 10 * time.Minute
+
+	// MaxEntryBps is the widest latest quote execute buys into. It's well
+	// above filter's median cap, as one quote is noisier than a median, so
+	// only a spread blown out at the moment of entry stops a buy.
+	MaxEntryBps = 50.0
+)
+
+// Quote is one symbol's latest usable NBBO.
+type Quote struct {
+	Bid       float64 `json:"bid"`
+	Ask       float64 `json:"ask"`
+	BidSize   float64 `json:"bid_size"`
+	AskSize   float64 `json:"ask_size"`
+	SpreadBps float64 `json:"spread_bps"` // Of the mid
+	Quoted    string  `json:"quoted"`
+}
+
+// Quotes is the on-disk layout of quotes.json: the latest quote for each
+// symbol fetch sampled this cycle, where spreads.json keeps the history.
+type Quotes struct {
+	schema.Header
+	Timestamp string           `json:"timestamp"`
+	Symbols   map[string]Quote `json:"symbols"`
+}
+
+// LoadQuotes reads quotes.json. A missing file is an empty set, so the
+// readers carry on with whatever spread they'd use without it.
+func LoadQuotes(path string) (*Quotes, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Quotes{Symbols: map[string]Quote{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading quotes: %w", err)
+	}
+	if err := schema.Check(path, data); err != nil {
+		return nil, err
+	}
+	q := &Quotes{}
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if q.Symbols == nil {
+		q.Symbols = map[string]Quote{}
+	}
+	return q, nil
+}
+
+// SaveQuotes writes quotes.json.
+func SaveQuotes(path string, q *Quotes) error {
+	q.Header = schema.Current()
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding quotes: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Add records symbol's latest quote, if it's usable by the same rules as
+// File.Add. It reports why a quote was skipped, or "" if it was added.
+func (q *Quotes) Add(symbol string, bid, ask, bidSize, askSize float64, quoted, now time.Time) string {
+	if reason := unusable(bid, ask, quoted, now); reason != "" {
+		return reason
+	}
+	q.Symbols[symbol] = Quote{
+		Bid:       bid,
+		Ask:       ask,
+		BidSize:   bidSize,
+		AskSize:   askSize,
+		SpreadBps: spreadBps(bid, ask),
+		Quoted:    quoted.UTC().Format(time.RFC3339),
+	}
+	return ""
+}
+
+// Current reports whether the snapshot was taken within SnapshotAge of now.
+func (q *Quotes) Current(now time.Time) bool {
+	t, err := time.Parse(time.RFC3339, q.Timestamp)
+	return err == nil && now.Sub(t) <= SnapshotAge
+}
+
+// Spread returns symbol's latest quoted spread in basis points, if the
+// snapshot is current and has a quote for it.
+func (q *Quotes) Spread(symbol string, now time.Time) (float64, bool) {
+	if !q.Current(now) {
+		return 0, false
+	}
+	quote, ok := q.Symbols[symbol]
+	return quote.SpreadBps, ok
+}
+
+// Spreads returns the latest quoted spread, in percent, for each symbol in
+// a current snapshot, and nothing from a stale one.
+func (q *Quotes) Spreads(now time.Time) map[string]float64 {
+	latest := map[string]float64{}
+	if !q.Current(now) {
+		return latest
+	}
+	for symbol, quote := range q.Symbols {
+		latest[symbol] = quote.SpreadBps / 100
+	}
+	return latest
+}
+
+// Screening returns the spread, in percent, filter screens each symbol on:
+// the median where f has reliable statistics, otherwise the latest quote
+// from a current snapshot. Symbols with neither are left to the bar range.
+func (f *File) Screening(latest *Quotes, now time.Time) map[string]float64 {
+	screening := latest.Spreads(now)
+	for symbol, pct := range f.Medians() {
+		screening[symbol] = pct
+	}
+	return screening
+}
@@ -0,0 +1,75 @@
+package spreads
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// --- Quotes ---
+
+func TestQuotes_Add(t *testing.T) {
+	q := &Quotes{Symbols: map[string]Quote{}}
+	if reason := q.Add("AAPL", 199.99, 200.01, 3, 5, open, open); reason != "" {
+		t.Fatalf("skipped: %s", reason)
+	}
+	if reason := q.Add("THIN", 0, 10, 0, 1, open, open); reason == "" {
+		t.Error("one-sided quote added")
+	}
+	got := q.Symbols["AAPL"]
+	if len(q.Symbols) != 1 || got.SpreadBps != 1 || got.BidSize != 3 || got.Quoted != "2026-03-10T15:00:00Z" {
+		t.Errorf("got %+v", q.Symbols)
+	}
+}
+
+func TestQuotes_Current(t *testing.T) {
+	q := &Quotes{
+		Timestamp: open.Format(time.RFC3339),
+		Symbols:   map[string]Quote{"AAPL": {SpreadBps: 2}},
+	}
+	if bps, ok := q.Spread("AAPL", open.Add(time.Minute)); !ok || bps != 2 {
+		t.Errorf("current: got %g, %v", bps, ok)
+	}
+	if _, ok := q.Spread("MSFT", open); ok {
+		t.Error("unquoted symbol reported")
+	}
+	later := open.Add(SnapshotAge + time.Second)
+	if _, ok := q.Spread("AAPL", later); ok || len(q.Spreads(later)) != 0 {
+		t.Error("stale snapshot used")
+	}
+	if _, ok := (&Quotes{}).Spread("AAPL", open); ok {
+		t.Error("empty snapshot used")
+	}
+}
+
+func TestScreening_MedianThenLatest(t *testing.T) {
+	f := &File{Symbols: map[string]*Stats{
+		"AAPL": {Samples: make([]float64, MinSamples), MedianBps: 2},
+		"NEW":  {Samples: make([]float64, 1), MedianBps: 90},
+	}}
+	latest := &Quotes{
+		Timestamp: open.Format(time.RFC3339),
+		Symbols:   map[string]Quote{"AAPL": {SpreadBps: 40}, "NEW": {SpreadBps: 5}},
+	}
+	got := f.Screening(latest, open)
+	if len(got) != 2 || got["AAPL"] != 0.02 || got["NEW"] != 0.05 {
+		t.Errorf("got %v, want AAPL's median and NEW's latest quote", got)
+	}
+}
+
+func TestLoadSaveQuotes_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.json")
+	q, err := LoadQuotes(path)
+	if err != nil || len(q.Symbols) != 0 {
+		t.Fatalf("missing file: got %+v, %v", q, err)
+	}
+	q.Add("AAPL", 199.99, 200.01, 3, 5, open, open)
+	q.Timestamp = open.Format(time.RFC3339)
+	if err := SaveQuotes(path, q); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadQuotes(path)
+	if err != nil || got.Symbols["AAPL"].Ask != 200.01 || got.SchemaVersion == 0 {
+		t.Errorf("got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,201 @@
+// Package spreads keeps rolling statistics of quoted NBBO spreads per symbol
+// in docs/spreads.json. Fetch samples the latest quotes each cycle during
+// the regular session and filter screens on the median once a symbol has
+// enough samples, in place of the last bar's range. The cycle's quotes
+// themselves go in docs/quotes.json, which filter falls back on before
+// then and execute checks before each buy.
+package spreads
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/schema"
+)
+
+// DefaultPath is where fetch writes the spread statistics.
+const DefaultPath = "docs/spreads.json"
+
+const (
+	// Window is how many samples are kept per symbol, newest last: about a
+	// day and a half of 5-minute cycles.
+	Window = 120
+
+	// MinSamples is how many samples a symbol needs before its statistics
+	// replace the bar range proxy.
+	MinSamples = 20
+
+	// MaxQuoteAge is how old a quote may be and still be sampled. An older
+	// one is left over from a halt or a thin stretch and isn't the spread
+	// anyone could trade at now.
+	MaxQuoteAge = 2 * time.Minute
+)
+
+// Stats are one symbol's recent quoted spreads, in basis points of the mid.
+type Stats struct {
+	Samples   []float64 `json:"samples"` // Newest last, at most Window
+	MedianBps float64   `json:"median_bps"`
+	MeanBps   float64   `json:"mean_bps"`
+	P90Bps    float64   `json:"p90_bps"`
+	Updated   string    `json:"updated"` // Quote time of the newest sample
+}
+
+// Reliable reports whether there are enough samples to trust the statistics.
+func (s Stats) Reliable() bool {
+	return len(s.Samples) >= MinSamples
+}
+
+// File is the on-disk layout of spreads.json.
+type File struct {
+	schema.Header
+	Timestamp string            `json:"timestamp"`
+	Symbols   map[string]*Stats `json:"symbols"`
+}
+
+// Load reads spreads.json. A missing file is an empty set, so sampling can
+// start from nothing.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{Symbols: map[string]*Stats{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading spreads: %w", err)
+	}
+	return Parse(path, data)
+}
+
+// Parse decodes a spreads.json; name labels errors.
+func Parse(name string, data []byte) (*File, error) {
+	if err := schema.Check(name, data); err != nil {
+		return nil, err
+	}
+	f := &File{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	if f.Symbols == nil {
+		f.Symbols = map[string]*Stats{}
+	}
+	return f, nil
+}
+
+// Save writes spreads.json.
+func Save(path string, f *File) error {
+	f.Header = schema.Current()
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding spreads: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// market is the exchange timezone the regular session is defined in.
+var market = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+// InSession reports whether t falls in the regular session, 09:30 to 16:00
+// New York on a weekday. Holidays aren't known here; quotes on a holiday are
+// stale and fail the age check instead.
+func InSession(t time.Time) bool {
+	t = t.In(market)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	return minute >= 9*60+30 && minute < 16*60
+}
+
+// Add records a quote for symbol taken at quoted, if it's usable: from the
+// regular session, no older than MaxQuoteAge at now, and neither one-sided
+// nor crossed. It reports why a quote was skipped, or "" if it was added.
+func (f *File) Add(symbol string, bid, ask float64, quoted, now time.Time) string {
+	if reason := unusable(bid, ask, quoted, now); reason != "" {
+		return reason
+	}
+
+	s, ok := f.Symbols[symbol]
+	if !ok {
+		s = &Stats{}
+		f.Symbols[symbol] = s
+	}
+	s.Samples = append(s.Samples, spreadBps(bid, ask))
+	if n := len(s.Samples); n > Window {
+		s.Samples = append([]float64(nil), s.Samples[n-Window:]...)
+	}
+	s.Updated = quoted.UTC().Format(time.RFC3339)
+	s.summarise()
+	return ""
+}
+
+// unusable reports why a quote can't be sampled, or "" if it can.
+func unusable(bid, ask float64, quoted, now time.Time) string {
+	switch {
+	case !InSession(quoted):
+		return "outside the regular session"
+	case now.Sub(quoted) > MaxQuoteAge:
+		return fmt.Sprintf("quote %s old", now.Sub(quoted).Round(time.Second))
+	case bid <= 0 || ask <= 0:
+		return "one-sided quote"
+	case ask < bid:
+		return "crossed quote"
+	}
+	return ""
+}
+
+// spreadBps is the quoted spread in basis points of the mid.
+func spreadBps(bid, ask float64) float64 {
+	return round((ask - bid) / ((bid + ask) / 2) * 1e4)
+}
+
+// summarise recomputes the statistics from the samples.
+func (s *Stats) summarise() {
+	sorted := append([]float64(nil), s.Samples...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	s.MeanBps = round(sum / float64(len(sorted)))
+	s.MedianBps = percentile(sorted, 0.5)
+	s.P90Bps = percentile(sorted, 0.9)
+}
+
+// percentile interpolates linearly between the ranks either side of p.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p * float64(len(sorted)-1)
+	lo := int(rank)
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	return round(sorted[lo] + (rank-float64(lo))*(sorted[lo+1]-sorted[lo]))
+}
+
+// round keeps spreads to a hundredth of a basis point.
+func round(bps float64) float64 {
+	return math.Round(bps*100) / 100
+}
+
+// Medians returns the median quoted spread, in percent, for each symbol with
+// reliable statistics.
+func (f *File) Medians() map[string]float64 {
+	medians := map[string]float64{}
+	for symbol, s := range f.Symbols {
+		if s.Reliable() {
+			medians[symbol] = s.MedianBps / 100
+		}
+	}
+	return medians
+}
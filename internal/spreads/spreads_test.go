@@ -0,0 +1,101 @@
+package spreads
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 11:00 New York on Tuesday 10 March 2026
+var open = time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+
+// --- InSession ---
+
+func TestInSession(t *testing.T) {
+	for _, tc := range []struct {
+		at   time.Time
+		want bool
+	}{
+		{open, true},
+		{time.Date(2026, 3, 10, 13, 30, 0, 0, time.UTC), true},  // 09:30, the open
+		{time.Date(2026, 3, 10, 13, 29, 0, 0, time.UTC), false}, // Pre-market
+		{time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC), false},  // 16:00, the close
+		{time.Date(2026, 3, 14, 15, 0, 0, 0, time.UTC), false},  // Saturday
+	} {
+		if got := InSession(tc.at); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.at, got, tc.want)
+		}
+	}
+}
+
+// --- File.Add ---
+
+func TestAdd_SkipsUnusableQuotes(t *testing.T) {
+	f := &File{Symbols: map[string]*Stats{}}
+	for _, tc := range []struct {
+		bid, ask float64
+		quoted   time.Time
+	}{
+		{99.99, 100.01, open.Add(-5 * time.Minute)},                    // Stale
+		{99.99, 100.01, time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC)}, // After hours
+		{0, 100.01, open},      // One-sided
+		{100.02, 100.01, open}, // Crossed
+	} {
+		if reason := f.Add("AAPL", tc.bid, tc.ask, tc.quoted, open); reason == "" {
+			t.Errorf("%+v: added, want skipped", tc)
+		}
+	}
+	if len(f.Symbols) != 0 {
+		t.Errorf("got %+v, want nothing recorded", f.Symbols)
+	}
+}
+
+func TestAdd_RollingStats(t *testing.T) {
+	f := &File{Symbols: map[string]*Stats{}}
+	// Spreads of 1 to Window+10 cents on a $100 mid: 1 bp per cent
+	for i := 1; i <= Window+10; i++ {
+		half := float64(i) / 200
+		if reason := f.Add("AAPL", 100-half, 100+half, open, open); reason != "" {
+			t.Fatalf("sample %d skipped: %s", i, reason)
+		}
+	}
+	s := f.Symbols["AAPL"]
+	if len(s.Samples) != Window || s.Samples[0] != 11 {
+		t.Fatalf("got %d samples starting %g, want the newest %d", len(s.Samples), s.Samples[0], Window)
+	}
+	if s.MedianBps != 70.5 || s.MeanBps != 70.5 || s.P90Bps != 118.1 {
+		t.Errorf("got median %g mean %g p90 %g", s.MedianBps, s.MeanBps, s.P90Bps)
+	}
+	if !s.Reliable() {
+		t.Error("full window not reliable")
+	}
+}
+
+// --- Medians / Load / Save ---
+
+func TestMedians_OnlyReliable(t *testing.T) {
+	f := &File{Symbols: map[string]*Stats{
+		"AAPL": {Samples: make([]float64, MinSamples), MedianBps: 2},
+		"THIN": {Samples: make([]float64, MinSamples-1), MedianBps: 80},
+	}}
+	m := f.Medians()
+	if len(m) != 1 || m["AAPL"] != 0.02 {
+		t.Errorf("got %v, want AAPL alone at 0.02%%", m)
+	}
+}
+
+func TestLoadSave_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spreads.json")
+	f, err := Load(path)
+	if err != nil || len(f.Symbols) != 0 {
+		t.Fatalf("missing file: got %+v, %v", f, err)
+	}
+	f.Add("AAPL", 199.99, 200.01, open, open)
+	if err := Save(path, f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil || got.Symbols["AAPL"] == nil || got.Symbols["AAPL"].MedianBps != 1 {
+		t.Errorf("got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,259 @@
+//go:build sqlite
+
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+	"github.com/deanturpin/lft2/internal/schema"
+
+	_ "modernc.org/sqlite" // Pure Go, so no cgo in any stage
+)
+
+func init() {
+	openSQLite = openDB
+}
+
+// One row per bar, document and fill, keyed by book.
+const ddl = `
+CREATE TABLE IF NOT EXISTS series (
+	book       TEXT NOT NULL,
+	symbol     TEXT NOT NULL,
+	feed       TEXT NOT NULL,
+	source     TEXT NOT NULL,
+	splits     TEXT NOT NULL,
+	fetched_at TEXT NOT NULL,
+	PRIMARY KEY (book, symbol)
+);
+CREATE TABLE IF NOT EXISTS bars (
+	book   TEXT NOT NULL,
+	symbol TEXT NOT NULL,
+	t      TEXT NOT NULL,
+	o      REAL NOT NULL,
+	h      REAL NOT NULL,
+	l      REAL NOT NULL,
+	c      REAL NOT NULL,
+	v      INTEGER NOT NULL,
+	PRIMARY KEY (book, symbol, t)
+);
+CREATE TABLE IF NOT EXISTS documents (
+	book     TEXT NOT NULL,
+	name     TEXT NOT NULL,
+	saved_at TEXT NOT NULL,
+	body     TEXT NOT NULL,
+	PRIMARY KEY (book, name)
+);
+CREATE TABLE IF NOT EXISTS fills (
+	order_id        TEXT PRIMARY KEY,
+	client_order_id TEXT NOT NULL,
+	symbol          TEXT NOT NULL,
+	side            TEXT NOT NULL,
+	qty             REAL NOT NULL,
+	price           REAL NOT NULL,
+	filled_at       TEXT NOT NULL,
+	strategy        TEXT NOT NULL,
+	book            TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fills_filled_at ON fills (filled_at);
+`
+
+// sqliteStore is a Store in SQLite.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// openDB opens or creates the database at path. Writers queue behind one
+// connection, as fetch saves symbols from several goroutines, and wait
+// rather than fail while another stage holds the lock.
+func openDB(path string) (Store, error) {
+	conn, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec(ddl); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	return &sqliteStore{db: conn}, nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqliteStore) PutBars(book string, d *barfile.Data) error {
+	splits, err := json.Marshal(d.Splits)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM bars WHERE book = ? AND symbol = ?`, book, d.Symbol); err != nil {
+		return fmt.Errorf("storing %s: %w", d.Symbol, err)
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO series VALUES (?, ?, ?, ?, ?, ?)`,
+		book, d.Symbol, d.Feed, d.Source, string(splits), d.FetchedAt); err != nil {
+		return fmt.Errorf("storing %s: %w", d.Symbol, err)
+	}
+	insert, err := tx.Prepare(`INSERT OR REPLACE INTO bars VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, b := range d.Bars {
+		if _, err := insert.Exec(book, d.Symbol, b.Timestamp, b.Open, b.High, b.Low, b.Close, b.Volume); err != nil {
+			return fmt.Errorf("storing %s: %w", d.Symbol, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) Bars(book, symbol string) (*barfile.Data, error) {
+	d := barfile.Data{Header: schema.Current(), Symbol: symbol}
+	var splits string
+	err := s.db.QueryRow(`SELECT feed, source, splits, fetched_at FROM series WHERE book = ? AND symbol = ?`,
+		book, symbol).Scan(&d.Feed, &d.Source, &splits, &d.FetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", symbol, err)
+	}
+	if err := json.Unmarshal([]byte(splits), &d.Splits); err != nil {
+		return nil, fmt.Errorf("reading %s splits: %w", symbol, err)
+	}
+	rows, err := s.db.Query(`SELECT t, o, h, l, c, v FROM bars WHERE book = ? AND symbol = ? ORDER BY t`, book, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", symbol, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b barfile.Bar
+		if err := rows.Scan(&b.Timestamp, &b.Open, &b.High, &b.Low, &b.Close, &b.Volume); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", symbol, err)
+		}
+		d.Bars = append(d.Bars, b)
+	}
+	d.Count = len(d.Bars)
+	return &d, rows.Err()
+}
+
+func (s *sqliteStore) Symbols(book string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT symbol FROM series WHERE book = ? ORDER BY symbol`, book)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, rows.Err()
+}
+
+func (s *sqliteStore) Put(book, name string, body []byte, now time.Time) error {
+	if !json.Valid(body) {
+		return fmt.Errorf("storing %s: not JSON", name)
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO documents VALUES (?, ?, ?, ?)`,
+		book, name, now.UTC().Format(time.RFC3339), string(body))
+	if err != nil {
+		return fmt.Errorf("storing %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) Get(book, name string) ([]byte, time.Time, error) {
+	var body, savedAt string
+	err := s.db.QueryRow(`SELECT body, saved_at FROM documents WHERE book = ? AND name = ?`,
+		book, name).Scan(&body, &savedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+	at, err := time.Parse(time.RFC3339, savedAt)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+	return []byte(body), at, nil
+}
+
+func (s *sqliteStore) PutFills(fills []Fill) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var before int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM fills`).Scan(&before); err != nil {
+		return 0, err
+	}
+	insert, err := tx.Prepare(`INSERT OR REPLACE INTO fills VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer insert.Close()
+	for _, f := range fills {
+		if _, err := insert.Exec(f.OrderID, f.ClientOrderID, f.Symbol, f.Side, f.Qty, f.Price,
+			f.FilledAt, f.Strategy, f.Book); err != nil {
+			return 0, fmt.Errorf("storing fill %s: %w", f.OrderID, err)
+		}
+	}
+	var after int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM fills`).Scan(&after); err != nil {
+		return 0, err
+	}
+	return after - before, tx.Commit()
+}
+
+func (s *sqliteStore) Fills(since time.Time) ([]Fill, error) {
+	rows, err := s.db.Query(`SELECT order_id, client_order_id, symbol, side, qty, price, filled_at, strategy, book
+		FROM fills WHERE filled_at >= ? ORDER BY filled_at, order_id`, since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var fills []Fill
+	for rows.Next() {
+		var f Fill
+		if err := rows.Scan(&f.OrderID, &f.ClientOrderID, &f.Symbol, &f.Side, &f.Qty, &f.Price,
+			&f.FilledAt, &f.Strategy, &f.Book); err != nil {
+			return nil, err
+		}
+		fills = append(fills, f)
+	}
+	return fills, rows.Err()
+}
+
+func (s *sqliteStore) Count(book string) (Counts, error) {
+	var c Counts
+	for _, q := range []struct {
+		query string
+		n     *int
+	}{
+		{`SELECT COUNT(*) FROM series WHERE book = ?`, &c.Symbols},
+		{`SELECT COUNT(*) FROM bars WHERE book = ?`, &c.Bars},
+		{`SELECT COUNT(*) FROM documents WHERE book = ?`, &c.Documents},
+		{`SELECT COUNT(*) FROM fills WHERE book = ?`, &c.Fills},
+	} {
+		if err := s.db.QueryRow(q.query, book).Scan(q.n); err != nil {
+			return Counts{}, err
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,148 @@
+//go:build sqlite
+
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+)
+
+func open(t *testing.T) Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "lft2.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestBars(t *testing.T) {
+	s := open(t)
+	if d, err := s.Bars("", "AAPL"); err != nil || d != nil {
+		t.Fatalf("empty store: got %+v, %v", d, err)
+	}
+
+	d := &barfile.Data{
+		Symbol: "AAPL", Feed: "iex", Source: "alpaca", FetchedAt: "2026-10-16T20:00:00Z",
+		Splits: []string{"split-1"},
+		Bars: []barfile.Bar{
+			{Timestamp: "2026-10-16T19:55:00Z", Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100},
+			{Timestamp: "2026-10-16T19:50:00Z", Open: 1, High: 1, Low: 1, Close: 1, Volume: 10},
+		},
+	}
+	if err := s.PutBars("", d); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Bars("", "AAPL")
+	if err != nil || got == nil {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	if got.Count != 2 || got.Bars[0].Timestamp != "2026-10-16T19:50:00Z" || got.Bars[1].Volume != 100 {
+		t.Errorf("bars: got %+v, want both, oldest first", got.Bars)
+	}
+	if got.Feed != "iex" || got.Source != "alpaca" || len(got.Splits) != 1 || got.SchemaVersion == 0 {
+		t.Errorf("series: got %+v", got)
+	}
+
+	// A second put replaces the series, and other books keep their own
+	d.Bars = d.Bars[:1]
+	if err := s.PutBars("", d); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PutBars("swing", d); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Bars("", "AAPL"); got.Count != 1 {
+		t.Errorf("replaced: got %d bars, want 1", got.Count)
+	}
+	if symbols, err := s.Symbols("swing"); err != nil || len(symbols) != 1 || symbols[0] != "AAPL" {
+		t.Errorf("swing symbols: got %v, %v", symbols, err)
+	}
+	if got, _ := s.Bars("scalp", "AAPL"); got != nil {
+		t.Errorf("scalp: got %+v, want none", got)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	s := open(t)
+	now := time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)
+	if err := s.Put("", Candidates, []byte(`{"candidates": []}`), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("", Candidates, []byte(`{"candidates": ["AAPL"]}`), now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	body, at, err := s.Get("", Candidates)
+	if err != nil || string(body) != `{"candidates": ["AAPL"]}` || !at.Equal(now.Add(time.Minute)) {
+		t.Errorf("got %s at %v, %v, want the latest", body, at, err)
+	}
+	if body, _, err := s.Get("swing", Candidates); err != nil || body != nil {
+		t.Errorf("swing: got %s, %v, want none", body, err)
+	}
+	if err := s.Put("", Recommendations, []byte("{truncated"), now); err == nil {
+		t.Error("a document that isn't JSON should be refused")
+	}
+}
+
+func TestFills(t *testing.T) {
+	s := open(t)
+	fills := []Fill{
+		{OrderID: "b", Symbol: "AAPL", Side: "sell", Qty: 1, Price: 101, FilledAt: "2026-10-16T15:00:00Z"},
+		{OrderID: "a", Symbol: "AAPL", Side: "buy", Qty: 1, Price: 100, FilledAt: "2026-10-15T15:00:00Z",
+			Strategy: "momentum", Book: "swing"},
+	}
+	if n, err := s.PutFills(fills); err != nil || n != 2 {
+		t.Fatalf("got %d new, %v, want 2", n, err)
+	}
+	if n, err := s.PutFills(fills[:1]); err != nil || n != 0 {
+		t.Errorf("again: got %d new, %v, want none", n, err)
+	}
+	got, err := s.Fills(time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(got) != 2 || got[0].OrderID != "a" || got[0].Book != "swing" {
+		t.Errorf("got %+v, %v, want both, oldest first", got, err)
+	}
+	if got, _ := s.Fills(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)); len(got) != 1 {
+		t.Errorf("since the 16th: got %+v, want the sell", got)
+	}
+	if c, err := s.Count("swing"); err != nil || c.Fills != 1 {
+		t.Errorf("swing counts: got %+v, %v", c, err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lft2.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PutBars("", &barfile.Data{Symbol: "MSFT", Bars: []barfile.Bar{{Timestamp: "2026-10-16T19:55:00Z"}}}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if c, err := s.Count(""); err != nil || c.Symbols != 1 || c.Bars != 1 {
+		t.Errorf("got %+v, %v, want what was stored before", c, err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(Env, "")
+	if s, err := FromEnv(); s != nil || err != nil {
+		t.Errorf("unset: got %v, %v, want no store", s, err)
+	}
+	t.Setenv(Env, filepath.Join(t.TempDir(), "lft2.db"))
+	s, err := FromEnv()
+	if err != nil || s == nil {
+		t.Fatalf("got %v, %v", s, err)
+	}
+	s.Close()
+}
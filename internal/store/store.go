@@ -0,0 +1,107 @@
+// Package store keeps bars, candidates, recommendations and fills in one
+// database, named by LFT2_STORE. With it set the database is the source of
+// truth: fetch merges into the bars it holds, filter and account record
+// candidates and fills in it, and the backtest's recommendations are
+// imported after each run. The JSON files under docs/ are still written, for
+// the dashboard and the C++ stages, and lft2 store export writes them again
+// from the database, e.g. on a fresh host. Unset, the JSON files are all
+// there is, as before.
+//
+// The database is SQLite, built only with -tags sqlite, as make does when
+// LFT2_STORE is set, so a build without a store neither downloads nor
+// compiles the driver.
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/deanturpin/lft2/internal/barfile"
+)
+
+// Env names the database; unset is no store.
+const Env = "LFT2_STORE"
+
+// Documents the store keeps whole, as the stage that writes them does: a
+// book's latest candidates from filter and recommendations from backtest.
+const (
+	Candidates      = "candidates.json"
+	Recommendations = "strategies.json"
+)
+
+// Documents lists them, in the order export writes them.
+var Documents = []string{Candidates, Recommendations}
+
+// Store is an open database. Books are keyed as books.json names them, ""
+// for the default book, so a book's bars are its own timeframe's.
+type Store interface {
+	// PutBars replaces the book's bars for d.Symbol with d's, so the store
+	// holds exactly what the bar file does, split adjustments included.
+	PutBars(book string, d *barfile.Data) error
+	// Bars returns the book's bars for symbol as a bar file has them,
+	// oldest first, or nil if the store has none.
+	Bars(book, symbol string) (*barfile.Data, error)
+	// Symbols lists the symbols the book has bars for, sorted.
+	Symbols(book string) ([]string, error)
+	// Put saves body as the book's latest document name.
+	Put(book, name string, body []byte, now time.Time) error
+	// Get returns the book's latest document name and when it was saved,
+	// or nil if the store has none.
+	Get(book, name string) ([]byte, time.Time, error)
+	// PutFills records fills, replacing any already recorded under the same
+	// order ID, e.g. once a partial fill completes. It returns how many are
+	// new.
+	PutFills(fills []Fill) (int, error)
+	// Fills returns the fills at or after since, oldest first.
+	Fills(since time.Time) ([]Fill, error)
+	// Count returns what the store holds for book.
+	Count(book string) (Counts, error)
+	// Close closes the database.
+	Close() error
+}
+
+// Fill is one filled order, attributed as account attributes positions.
+type Fill struct {
+	OrderID       string
+	ClientOrderID string
+	Symbol        string
+	Side          string // buy or sell
+	Qty           float64
+	Price         float64 // Average fill price
+	FilledAt      string  // RFC 3339, as Alpaca gives it
+	Strategy      string  // From the client_order_id; "" for orders entries didn't write
+	Book          string  // Book trading Strategy; "" for the default book
+}
+
+// Counts is what the store holds for one book.
+type Counts struct {
+	Symbols   int
+	Bars      int
+	Documents int
+	Fills     int // Attributed to the book
+}
+
+// openSQLite is set by sqlite.go, in builds tagged sqlite.
+var openSQLite func(path string) (Store, error)
+
+// ErrNoDriver is returned opening a store from a build without -tags sqlite.
+var ErrNoDriver = errors.New("built without SQLite: rebuild with -tags sqlite, as make does with " + Env + " set")
+
+// Open opens or creates the database at path.
+func Open(path string) (Store, error) {
+	if openSQLite == nil {
+		return nil, fmt.Errorf("opening %s: %w", path, ErrNoDriver)
+	}
+	return openSQLite(path)
+}
+
+// FromEnv opens the database LFT2_STORE names, or returns nil without one.
+func FromEnv() (Store, error) {
+	path := os.Getenv(Env)
+	if path == "" {
+		return nil, nil
+	}
+	return Open(path)
+}
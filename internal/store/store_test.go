@@ -0,0 +1,21 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpen_WithoutSQLite(t *testing.T) {
+	if openSQLite != nil {
+		t.Skip("built with -tags sqlite")
+	}
+	t.Setenv(Env, filepath.Join(t.TempDir(), "lft2.db"))
+	if s, err := FromEnv(); s != nil || !errors.Is(err, ErrNoDriver) {
+		t.Errorf("got %v, %v, want ErrNoDriver", s, err)
+	}
+	t.Setenv(Env, "")
+	if s, err := FromEnv(); s != nil || err != nil {
+		t.Errorf("unset: got %v, %v, want no store", s, err)
+	}
+}
@@ -0,0 +1,98 @@
+// Package tz converts timestamps into the reporting timezone. Artifacts
+// store UTC (RFC 3339); summaries, dashboard pages and logs render in the
+// zone named by LFT2_TIMEZONE, defaulting to the exchange's own zone so
+// times line up with the market open and close.
+package tz
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	_ "time/tzdata" // CI containers don't always ship a zoneinfo database
+)
+
+// DefaultZone is the reporting timezone when LFT2_TIMEZONE is unset.
+const DefaultZone = "America/New_York"
+
+// Layouts used across reports.
+const (
+	DateLayout     = "2006-01-02"
+	TimeLayout     = "15:04:05"
+	DateTimeLayout = "2006-01-02 15:04:05 MST"
+)
+
+var (
+	once sync.Once
+	loc  *time.Location
+)
+
+// Location returns the reporting timezone. An unknown zone name falls back
+// to DefaultZone with a warning rather than failing the pipeline.
+func Location() *time.Location {
+	once.Do(func() {
+		name := os.Getenv("LFT2_TIMEZONE")
+		if name == "" {
+			name = DefaultZone
+		}
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			fmt.Fprintf(os.Stderr, "tz: unknown LFT2_TIMEZONE %q, using %s\n", name, DefaultZone)
+			loc, _ = time.LoadLocation(DefaultZone)
+		}
+	})
+	return loc
+}
+
+// In converts t to the reporting timezone.
+func In(t time.Time) time.Time {
+	return t.In(Location())
+}
+
+// Format renders t as a full date, time and zone abbreviation.
+func Format(t time.Time) string {
+	return In(t).Format(DateTimeLayout)
+}
+
+// Date returns the calendar date of t in the reporting timezone.
+func Date(t time.Time) string {
+	return In(t).Format(DateLayout)
+}
+
+// Clock returns the time of day of t in the reporting timezone.
+func Clock(t time.Time) string {
+	return In(t).Format(TimeLayout)
+}
+
+// Parse reads an RFC 3339 timestamp, as stored in artifacts and returned by
+// Alpaca, and converts it to the reporting timezone.
+func Parse(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return In(t), nil
+}
+
+// logWriter prefixes each log line with a reporting-timezone timestamp.
+type logWriter struct {
+	out io.Writer
+	now func() time.Time
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.out, Format(w.now())+" "); err != nil {
+		return 0, err
+	}
+	return w.out.Write(p)
+}
+
+// SetLog makes the standard logger stamp lines in the reporting timezone
+// instead of the host's local time.
+func SetLog() {
+	log.SetFlags(0)
+	log.SetOutput(logWriter{out: os.Stderr, now: time.Now})
+}
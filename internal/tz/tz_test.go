@@ -0,0 +1,49 @@
+package tz
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// Location is resolved once per process, so tests use the default zone.
+
+func TestFormat_DefaultZone(t *testing.T) {
+	ts := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC) // after the US DST change
+	if got := Format(ts); got != "2026-03-10 10:30:00 EDT" {
+		t.Errorf("got %q, want 2026-03-10 10:30:00 EDT", got)
+	}
+	if got := Clock(ts); got != "10:30:00" {
+		t.Errorf("got %q, want 10:30:00", got)
+	}
+}
+
+func TestDate_CrossesMidnight(t *testing.T) {
+	// 02:00 UTC is still the previous evening in New York
+	ts := time.Date(2026, 3, 11, 2, 0, 0, 0, time.UTC)
+	if got := Date(ts); got != "2026-03-10" {
+		t.Errorf("got %q, want 2026-03-10", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := Parse("2026-01-15T20:00:00Z")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Location() != Location() || got.Hour() != 15 {
+		t.Errorf("got %v, want 15:00 in %v", got, Location())
+	}
+	if _, err := Parse("not a time"); err == nil {
+		t.Error("expected error for invalid timestamp")
+	}
+}
+
+func TestLogWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := logWriter{out: &buf, now: func() time.Time { return time.Date(2026, 1, 15, 20, 0, 0, 0, time.UTC) }}
+	w.Write([]byte("hello\n"))
+	if got := buf.String(); got != "2026-01-15 15:00:00 EST hello\n" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,171 @@
+// Package vault optionally encrypts state files at rest with AES-256-GCM,
+// for operators on shared machines. With no key configured it reads and
+// writes plain files, so callers swap os.ReadFile/os.WriteFile for
+// ReadFile/WriteFile and need nothing else.
+//
+// The key comes from LFT2_STATE_KEY or, failing that, the file named by
+// LFT2_STATE_KEYFILE, as 64 hex characters or 32 bytes of base64
+// (`lft2 key` prints a fresh one). Encrypted files are text — a header line
+// then base64 — so they still diff and commit cleanly. Plain files are
+// always readable, which lets an existing journal be encrypted on its next
+// save.
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// header starts every encrypted file and is authenticated with it, so a
+// file can't be passed off as another format version.
+const header = "lft2-vault aes-256-gcm v1\n"
+
+// KeySize is the AES-256 key length in bytes.
+const KeySize = 32
+
+// ErrNoKey is returned when reading an encrypted file without a key.
+var ErrNoKey = errors.New("file is encrypted; set LFT2_STATE_KEY or LFT2_STATE_KEYFILE")
+
+// ParseKey decodes a key given as hex or base64.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := hex.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("key must be %d bytes as hex or base64", KeySize)
+}
+
+// KeyFromEnv returns the configured key, or nil when encryption is off.
+func KeyFromEnv() ([]byte, error) {
+	if s := os.Getenv("LFT2_STATE_KEY"); s != "" {
+		key, err := ParseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("LFT2_STATE_KEY: %w", err)
+		}
+		return key, nil
+	}
+	if path := os.Getenv("LFT2_STATE_KEYFILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("LFT2_STATE_KEYFILE: %w", err)
+		}
+		key, err := ParseKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("LFT2_STATE_KEYFILE %s: %w", path, err)
+		}
+		return key, nil
+	}
+	return nil, nil
+}
+
+// NewKey returns a random key, hex encoded.
+func NewKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// Encrypted reports whether data is a sealed file.
+func Encrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(header))
+}
+
+func gcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts plaintext under key with a fresh random nonce.
+func Seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := gcm(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(header))
+
+	out := []byte(header)
+	out = append(out, base64.StdEncoding.EncodeToString(sealed)...)
+	return append(out, '\n'), nil
+}
+
+// Open decrypts a sealed file. Plain data is returned unchanged.
+func Open(key, data []byte) ([]byte, error) {
+	if !Encrypted(data) {
+		return data, nil
+	}
+	if key == nil {
+		return nil, ErrNoKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data[len(header):])))
+	if err != nil {
+		return nil, fmt.Errorf("decoding encrypted file: %w", err)
+	}
+	aead, err := gcm(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("encrypted file is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(header))
+	if err != nil {
+		return nil, errors.New("decrypting: wrong key or corrupted file")
+	}
+	return plain, nil
+}
+
+// ReadFile reads path, decrypting it if it's sealed. Errors from the read
+// itself are returned as is, so os.IsNotExist still works.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := KeyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := Open(key, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plain, nil
+}
+
+// WriteFile writes data to path, sealed and readable only by the owner when
+// a key is configured.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	key, err := KeyFromEnv()
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return os.WriteFile(path, data, perm)
+	}
+	sealed, err := Seal(key, data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, sealed, 0600)
+}
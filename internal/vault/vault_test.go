@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+// --- ParseKey ---
+
+func TestParseKey(t *testing.T) {
+	hexKey, err := ParseKey(testKey)
+	if err != nil {
+		t.Fatalf("hex: %v", err)
+	}
+	b64Key, err := ParseKey(base64.StdEncoding.EncodeToString(hexKey) + "\n")
+	if err != nil || string(b64Key) != string(hexKey) {
+		t.Errorf("base64: got %x, %v", b64Key, err)
+	}
+	for _, bad := range []string{"", "abcd", testKey[:62], "not a key at all"} {
+		if _, err := ParseKey(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+// --- Seal / Open ---
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	key, _ := ParseKey(testKey)
+	sealed, err := Seal(key, []byte(`{"notes": []}`))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if !Encrypted(sealed) || strings.Contains(string(sealed), "notes") {
+		t.Errorf("not sealed: %s", sealed)
+	}
+	plain, err := Open(key, sealed)
+	if err != nil || string(plain) != `{"notes": []}` {
+		t.Errorf("got %q, %v", plain, err)
+	}
+}
+
+func TestOpen_PlainPassesThrough(t *testing.T) {
+	plain, err := Open(nil, []byte(`{"notes": []}`))
+	if err != nil || string(plain) != `{"notes": []}` {
+		t.Errorf("got %q, %v", plain, err)
+	}
+}
+
+func TestOpen_WrongOrMissingKey(t *testing.T) {
+	key, _ := ParseKey(testKey)
+	sealed, _ := Seal(key, []byte("secret"))
+
+	if _, err := Open(nil, sealed); !errors.Is(err, ErrNoKey) {
+		t.Errorf("no key: got %v", err)
+	}
+	other := make([]byte, KeySize)
+	if _, err := Open(other, sealed); err == nil || !strings.Contains(err.Error(), "wrong key") {
+		t.Errorf("wrong key: got %v", err)
+	}
+}
+
+// --- ReadFile / WriteFile ---
+
+func TestWriteFile_EncryptsWhenKeySet(t *testing.T) {
+	t.Setenv("LFT2_STATE_KEY", testKey)
+	path := filepath.Join(t.TempDir(), "journal.json")
+	if err := WriteFile(path, []byte("secret"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	if !Encrypted(raw) {
+		t.Errorf("on disk: got %q, want sealed", raw)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode: got %v, want 0600", info.Mode().Perm())
+	}
+	got, err := ReadFile(path)
+	if err != nil || string(got) != "secret" {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
+
+func TestWriteFile_PlainWithoutKey(t *testing.T) {
+	t.Setenv("LFT2_STATE_KEY", "")
+	t.Setenv("LFT2_STATE_KEYFILE", "")
+	path := filepath.Join(t.TempDir(), "journal.json")
+	if err := WriteFile(path, []byte("plain"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if raw, _ := os.ReadFile(path); string(raw) != "plain" {
+		t.Errorf("got %q", raw)
+	}
+}
+
+func TestKeyFromEnv_Keyfile(t *testing.T) {
+	keyfile := filepath.Join(t.TempDir(), "state.key")
+	os.WriteFile(keyfile, []byte(testKey+"\n"), 0600)
+	t.Setenv("LFT2_STATE_KEY", "")
+	t.Setenv("LFT2_STATE_KEYFILE", keyfile)
+
+	key, err := KeyFromEnv()
+	if err != nil || len(key) != KeySize {
+		t.Errorf("got %x, %v", key, err)
+	}
+}
+
+func TestReadFile_MissingFileIsNotExist(t *testing.T) {
+	if _, err := ReadFile("/nonexistent/journal.json"); !os.IsNotExist(err) {
+		t.Errorf("got %v, want a not-exist error", err)
+	}
+}
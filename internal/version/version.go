@@ -0,0 +1,123 @@
+// Package version identifies the code a binary was built from, so any
+// artifact or order can be traced back to its commit. The Makefile stamps the
+// commit and build time with -ldflags (see LDFLAGS); a plain go build in a
+// git checkout falls back to the VCS stamp Go records, and a test binary has
+// neither.
+package version
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+)
+
+// Set at link time:
+//
+//	-X github.com/deanturpin/lft2/internal/version.commit=$(git rev-parse HEAD)
+var (
+	commit   string
+	built    string // RFC 3339
+	modified string // "true" when the tree had uncommitted changes
+)
+
+// Info is what a binary knows about its own build.
+type Info struct {
+	Commit   string   `json:"commit,omitempty"`
+	Built    string   `json:"built,omitempty"`
+	Modified bool     `json:"modified,omitempty"`
+	Go       string   `json:"go"`
+	Modules  []string `json:"modules,omitempty"` // path@version of each module linked in
+}
+
+// read is overridden by tests.
+var read = debug.ReadBuildInfo
+
+// Get returns the build information, preferring the link-time stamp.
+func Get() Info {
+	info := Info{Commit: commit, Built: built, Modified: modified == "true", Go: runtime.Version()}
+	bi, ok := read()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch {
+		case s.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = s.Value
+		case s.Key == "vcs.time" && info.Built == "":
+			info.Built = s.Value
+		case s.Key == "vcs.modified" && commit == "":
+			info.Modified = s.Value == "true"
+		}
+	}
+	for _, m := range bi.Deps {
+		v := m.Version
+		if m.Replace != nil {
+			v = m.Replace.Path
+		}
+		info.Modules = append(info.Modules, m.Path+"@"+v)
+	}
+	sort.Strings(info.Modules)
+	return info
+}
+
+// Short is the commit's first 12 characters, with -dirty for a modified
+// tree, or "" when the build wasn't stamped.
+func (i Info) Short() string {
+	if i.Commit == "" {
+		return ""
+	}
+	s := i.Commit
+	if len(s) > 12 {
+		s = s[:12]
+	}
+	if i.Modified {
+		s += "-dirty"
+	}
+	return s
+}
+
+// Build is Get().Short(), what artifacts are stamped with.
+func Build() string {
+	return Get().Short()
+}
+
+// Write prints name's build information, one item per line.
+func Write(w io.Writer, name string) {
+	i := Get()
+	short := i.Short()
+	if short == "" {
+		short = "unknown commit"
+	}
+	fmt.Fprintf(w, "%s %s\n", name, short)
+	if i.Built != "" {
+		fmt.Fprintf(w, "  built   %s\n", i.Built)
+	}
+	fmt.Fprintf(w, "  go      %s\n", i.Go)
+	for _, m := range i.Modules {
+		fmt.Fprintf(w, "  module  %s\n", m)
+	}
+}
+
+// Requested reports whether args ask for the version.
+func Requested(args []string) bool {
+	for _, a := range args {
+		if a == "--version" || a == "-version" {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle prints the version and exits if the command line asks for it. Call
+// it first in main, before flags are parsed:
+//
+//	version.Handle("fetch")
+func Handle(name string) {
+	if Requested(os.Args[1:]) {
+		Write(os.Stdout, name)
+		os.Exit(0)
+	}
+}
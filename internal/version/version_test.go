@@ -0,0 +1,82 @@
+package version
+
+import (
+	"bytes"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+// stamp sets the link-time variables and build info for one test.
+func stamp(t *testing.T, c, b, m string, bi *debug.BuildInfo) {
+	t.Helper()
+	oldCommit, oldBuilt, oldModified, oldRead := commit, built, modified, read
+	t.Cleanup(func() { commit, built, modified, read = oldCommit, oldBuilt, oldModified, oldRead })
+	commit, built, modified = c, b, m
+	read = func() (*debug.BuildInfo, bool) { return bi, bi != nil }
+}
+
+// --- Get ---
+
+func TestGet_LinkTime(t *testing.T) {
+	stamp(t, "0123456789abcdef0123", "2026-03-10T14:00:00Z", "true", &debug.BuildInfo{
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "ffff"}, {Key: "vcs.modified", Value: "false"}},
+	})
+	i := Get()
+	if i.Commit != "0123456789abcdef0123" || i.Built != "2026-03-10T14:00:00Z" || !i.Modified {
+		t.Errorf("got %+v, want the link-time stamp", i)
+	}
+	if got := i.Short(); got != "0123456789ab-dirty" {
+		t.Errorf("Short: got %q", got)
+	}
+}
+
+func TestGet_VCSFallback(t *testing.T) {
+	stamp(t, "", "", "", &debug.BuildInfo{
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abcdef1234567890"},
+			{Key: "vcs.time", Value: "2026-03-09T10:00:00Z"},
+			{Key: "vcs.modified", Value: "false"},
+		},
+		Deps: []*debug.Module{
+			{Path: "github.com/deanturpin/lft2/internal/tz", Version: "v0.0.0", Replace: &debug.Module{Path: "../../internal/tz"}},
+			{Path: "github.com/deanturpin/lft2/internal/alpaca", Version: "v0.0.0"},
+		},
+	})
+	i := Get()
+	if i.Short() != "abcdef123456" || i.Built != "2026-03-09T10:00:00Z" {
+		t.Errorf("got %+v", i)
+	}
+	want := []string{"github.com/deanturpin/lft2/internal/alpaca@v0.0.0", "github.com/deanturpin/lft2/internal/tz@../../internal/tz"}
+	if strings.Join(i.Modules, " ") != strings.Join(want, " ") {
+		t.Errorf("modules: got %v, want %v", i.Modules, want)
+	}
+}
+
+func TestGet_Unstamped(t *testing.T) {
+	stamp(t, "", "", "", nil)
+	if b := Build(); b != "" {
+		t.Errorf("got %q, want no build", b)
+	}
+	var out bytes.Buffer
+	Write(&out, "fetch")
+	if !strings.HasPrefix(out.String(), "fetch unknown commit\n  go      go") {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+// --- Requested ---
+
+func TestRequested(t *testing.T) {
+	for args, want := range map[string]bool{
+		"--version":      true,
+		"-version":       true,
+		"-dir docs":      false,
+		"note --version": true,
+		"":               false,
+	} {
+		if got := Requested(strings.Fields(args)); got != want {
+			t.Errorf("%q: got %v, want %v", args, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer upgrades one request and runs serve on the connection.
+func fakeServer(t *testing.T, serve func(r *bufio.Reader, w io.Writer)) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "not a websocket", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + Accept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+		serve(rw.Reader, conn)
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// frame builds an unmasked server frame.
+func frame(fin bool, op byte, payload []byte) []byte {
+	b := []byte{op, 0}
+	if fin {
+		b[0] |= 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		b[1] = byte(n)
+	default:
+		b[1] = 126
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	}
+	return append(b, payload...)
+}
+
+// readClientFrame reads and unmasks one client frame, failing if it isn't
+// masked.
+func readClientFrame(r *bufio.Reader) (op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	if head[1]&0x80 == 0 {
+		return 0, nil, errors.New("client frame not masked")
+	}
+	n := int(head[1] & 0x7F)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	var mask [4]byte
+	io.ReadFull(r, mask[:])
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return head[0] & 0x0F, payload, nil
+}
+
+func TestAccept(t *testing.T) {
+	// The worked example from RFC 6455 section 1.3
+	if got := Accept("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	got := make(chan string, 1)
+	url := fakeServer(t, func(r *bufio.Reader, w io.Writer) {
+		op, payload, err := readClientFrame(r)
+		if err != nil || op != opText {
+			got <- ""
+			return
+		}
+		got <- string(payload)
+		// A ping between the fragments of a long echo
+		long := []byte(strings.Repeat("x", 300))
+		w.Write(frame(false, opText, long[:100]))
+		w.Write(frame(true, opPing, []byte("hi")))
+		w.Write(frame(true, opContinuation, long[100:]))
+		if op, payload, _ := readClientFrame(r); op != opPong || string(payload) != "hi" {
+			w.Write(frame(true, opText, []byte("no pong")))
+			return
+		}
+		w.Write(frame(true, opClose, []byte{0x03, 0xE8}))
+		readClientFrame(r)
+	})
+
+	c, err := Dial(url+"/stream", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	if err := c.Write([]byte(`{"action":"auth"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if s := <-got; s != `{"action":"auth"}` {
+		t.Errorf("server got %q", s)
+	}
+	msg, err := c.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != strings.Repeat("x", 300) {
+		t.Errorf("got %d bytes %.20q", len(msg), msg)
+	}
+	if _, err := c.Read(); !errors.Is(err, ErrClosed) {
+		t.Errorf("after close: got %v, want ErrClosed", err)
+	}
+}
+
+func TestDial_NotUpgraded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	_, err := Dial("ws"+strings.TrimPrefix(srv.URL, "http"), time.Second)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 403") {
+		t.Errorf("got %v, want HTTP 403", err)
+	}
+}
+
+func TestDial_BadAccept(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		http.ReadRequest(bufio.NewReader(conn))
+		io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: nope\r\n\r\n")
+	}()
+	if _, err := Dial("ws://"+ln.Addr().String(), time.Second); err == nil {
+		t.Error("want an error for a bad Sec-WebSocket-Accept")
+	}
+}
+
+func TestDial_UnknownScheme(t *testing.T) {
+	if _, err := Dial("http://localhost", time.Second); err == nil {
+		t.Error("want an error")
+	}
+}
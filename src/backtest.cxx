@@ -2,22 +2,38 @@
 // Uses the same constexpr entry/exit code as live trading
 
 #include "bar.h"
+#include "capacity.h"
+#include "cycle.h"
 #include "entry.h"
 #include "exit.h"
+#include "fill.h"
 #include "json.h"
 #include "market.h"
 #include "params.h"
 #include "paths.h"
+#include "sandbox.h"
+#include "script.h"
+#include "sha256.h"
+#include "shard.h"
+#include "version.h"
+#include "warm.h"
 #include <algorithm>
+#include <charconv>
 #include <chrono>
 #include <cmath>
+#include <cstdint>
+#include <cstdlib>
 #include <ctime>
 #include <filesystem>
 #include <fstream>
 #include <iomanip>
+#include <iterator>
 #include <limits>
+#include <map>
+#include <optional>
 #include <print>
 #include <ranges>
+#include <span>
 #include <sstream>
 #include <string>
 #include <vector>
@@ -26,6 +42,8 @@ struct Trade {
   double entry_price;
   double exit_price;
   double profit_pct;
+  double mae_pct; // Maximum adverse excursion: worst low vs entry (<= 0)
+  double mfe_pct; // Maximum favourable excursion: best high vs entry (>= 0)
   bool win;
   int duration_bars;
   exit_reason reason;          // Why did it exit?
@@ -40,12 +58,23 @@ struct StrategyResult {
   double avg_profit = 0.0;
   int trade_count = 0;
   double total_return = 0.0;
+  double avg_mae = 0.0;        // Mean MAE across trades
+  double avg_mfe = 0.0;        // Mean MFE across trades
+  double avg_winner_mae = 0.0; // Mean MAE of winners: near the stop = too tight
+  double avg_loser_mfe = 0.0;  // Mean MFE of losers: above target = too modest
   int min_duration_bars = 0;
   int max_duration_bars = 0;
   std::string first_timestamp;
   std::string last_timestamp;
+  std::size_t required_bars = 0; // Warm-up history the strategy needs live
+  double capacity = 0.0;          // Max order notional (capacity.h)
+  int version = 0;                // Strategy logic version (entry.h)
+  trading_params params = default_params; // Exit levels the trades used
+  std::string indicator_params;           // Indicator settings, or the rule
+  std::uint32_t params_hash = 0;          // Identifies params + indicators
   std::vector<Trade> trades; // Per-trade details for debug output
   bool viable = false;       // True if win_rate >= 0.50 && trade_count >= 5
+  std::string as_of;         // Run that evaluated it, earlier if carried
 };
 
 // Convert exit_reason to string for output
@@ -59,6 +88,8 @@ constexpr std::string_view exit_reason_str(exit_reason r) {
     return "trailing_stop";
   case exit_reason::risk_off:
     return "risk_off";
+  case exit_reason::rule_exit:
+    return "rule_exit";
   case exit_reason::end_of_data:
     return "end_of_data";
   default:
@@ -66,13 +97,28 @@ constexpr std::string_view exit_reason_str(exit_reason r) {
   }
 }
 
-// Backtest a specific strategy on bar data
+// Backtest a specific strategy on bar data. Fills follow the fill model
+// (fill.h). A user rule (script.h) supplies its own warm-up and may add an
+// exit expression to the standard exits. Exits use params, which only the
+// sandbox (sandbox.h) changes.
 template <typename EntryFunc>
 StrategyResult backtest_strategy(std::span<const bar> bars,
                                  EntryFunc entry_func,
-                                 std::string_view strategy_name) {
+                                 std::string_view strategy_name,
+                                 const fill::model &fills,
+                                 const script::rule *rule = nullptr,
+                                 trading_params params = default_params) {
   auto result = StrategyResult{};
   result.strategy_name = std::string{strategy_name};
+  result.params = params;
+  result.required_bars = rule ? rule->lookback : required_bars(strategy_name);
+  result.version = rule ? rule->version : strategy_version(strategy_name);
+  const auto window = rule ? rule->window : strategy_window(strategy_name);
+  result.indicator_params =
+      rule ? std::format("entry={};exit={}", rule->entry, rule->exit)
+           : std::string{strategy_params(strategy_name)};
+  result.params_hash =
+      params_hash(strategy_name, result.indicator_params, result.params);
 
   if (bars.empty()) {
     std::println("  ✗ {} - no bars", strategy_name);
@@ -86,25 +132,46 @@ StrategyResult backtest_strategy(std::span<const bar> bars,
   auto position = std::optional<::position>{};
   auto entry_bar_index = 0uz;
 
-  // Walk through bars: now is the signal bar (close), next is the fill
-  // bar (open). Stop one bar early so lookahead is always valid.
-  for (auto i = 20uz; i + 1 < bars.size(); ++i) {
+  // Price extremes since entry, for MAE/MFE
+  auto low_since_entry = 0.0;
+  auto high_since_entry = 0.0;
+
+  // Partial fills scale each trade's return by its filled share: the
+  // expectation, or a seeded draw per trade when the model is sampled
+  const auto delay = fill::delay_bars(fills);
+  auto draws = fill::generator{fill::stream(fills.seed, strategy_name)};
+  auto filled = 1.0;
+
+  // Walk through bars: now is the signal bar (close), next is the fill bar
+  // (open, plus any latency). Stop early so lookahead is always valid.
+  for (auto i = 20uz; i + 1 + delay < bars.size(); ++i) {
     const auto &now = bars[i];
-    const auto &next = bars[i + 1];
+    const auto &next = bars[i + 1 + delay];
+    const auto fill_price = fill::price(next, fills);
     auto history = std::span{bars.data(), i + 1};
 
     if (!market::market_open(now.timestamp))
       continue;
 
-    // Risk-off: liquidate any open position; fill at next bar's open like any
-    // exit
+    if (position) {
+      low_since_entry = std::min(low_since_entry, now.low);
+      high_since_entry = std::max(high_since_entry, now.high);
+    }
+
+    // Risk-off: liquidate any open position; fill like any exit
     if (position && market::risk_off(now.timestamp)) {
-      auto profit_pct =
-          (next.open - position->entry_price) / position->entry_price;
+      auto profit_pct = (fill_price - position->entry_price) /
+                        position->entry_price * filled;
       trades.push_back(
           Trade{.entry_price = position->entry_price,
-                .exit_price = next.open,
+                .exit_price = fill_price,
                 .profit_pct = profit_pct,
+                .mae_pct = (std::min(low_since_entry, fill_price) -
+                            position->entry_price) /
+                           position->entry_price,
+                .mfe_pct = (std::max(high_since_entry, fill_price) -
+                            position->entry_price) /
+                           position->entry_price,
                 .win = profit_pct > 0.0,
                 .duration_bars = static_cast<int>(i - entry_bar_index),
                 .reason = exit_reason::risk_off,
@@ -117,21 +184,30 @@ StrategyResult backtest_strategy(std::span<const bar> bars,
     // Update trailing stop to track peak price while in position
     if (position) {
       auto peak =
-          position->trailing_stop / (1.0 - default_params.trailing_stop_pct);
+          position->trailing_stop / (1.0 - result.params.trailing_stop_pct);
       if (now.close > peak)
         position->trailing_stop =
-            now.close * (1.0 - default_params.trailing_stop_pct);
+            now.close * (1.0 - result.params.trailing_stop_pct);
     }
 
-    // Exit signal fires on now's close; fill at next bar's open
+    // Exit signal fires on now's close; fill on the fill bar
     auto exit_check = position ? check_exit(*position, now) : exit_reason::none;
+    if (exit_check == exit_reason::none && position && rule &&
+        !rule->exit.empty() && script::fires(rule->exit, history, rule->budget))
+      exit_check = exit_reason::rule_exit;
     if (exit_check != exit_reason::none) {
-      auto profit_pct =
-          (next.open - position->entry_price) / position->entry_price;
+      auto profit_pct = (fill_price - position->entry_price) /
+                        position->entry_price * filled;
       trades.push_back(
           Trade{.entry_price = position->entry_price,
-                .exit_price = next.open,
+                .exit_price = fill_price,
                 .profit_pct = profit_pct,
+                .mae_pct = (std::min(low_since_entry, fill_price) -
+                            position->entry_price) /
+                           position->entry_price,
+                .mfe_pct = (std::max(high_since_entry, fill_price) -
+                            position->entry_price) /
+                           position->entry_price,
                 .win = profit_pct > 0.0,
                 .duration_bars = static_cast<int>(i - entry_bar_index),
                 .reason = exit_check,
@@ -139,15 +215,18 @@ StrategyResult backtest_strategy(std::span<const bar> bars,
                 .exit_timestamp = std::string{next.timestamp}});
       position.reset();
     }
-    // Entry signal fires on now's close; fill at next bar's open
+    // Entry signal fires on now's close; fill on the fill bar
     else if (!position && !market::risk_off(now.timestamp) &&
-             entry_func(history)) {
-      auto levels = calculate_levels(next.open, default_params);
-      position = ::position{.entry_price = next.open,
+             market::in_window(window, now.timestamp) && entry_func(history)) {
+      auto levels = calculate_levels(fill_price, result.params);
+      position = ::position{.entry_price = fill_price,
                             .take_profit = levels.take_profit,
                             .stop_loss = levels.stop_loss,
                             .trailing_stop = levels.trailing_stop};
       entry_bar_index = i;
+      filled = fill::fraction(fills, draws.uniform());
+      low_since_entry = fill_price;
+      high_since_entry = fill_price;
     }
   }
 
@@ -158,13 +237,23 @@ StrategyResult backtest_strategy(std::span<const bar> bars,
 
   auto wins = 0uz;
   auto total_profit = 0.0;
+  auto total_mae = 0.0;
+  auto total_mfe = 0.0;
+  auto winner_mae = 0.0;
+  auto loser_mfe = 0.0;
   auto min_duration = std::numeric_limits<int>::max();
   auto max_duration = 0;
 
   for (const auto &trade : trades) {
-    if (trade.win)
+    if (trade.win) {
       wins++;
+      winner_mae += trade.mae_pct;
+    } else {
+      loser_mfe += trade.mfe_pct;
+    }
     total_profit += trade.profit_pct;
+    total_mae += trade.mae_pct;
+    total_mfe += trade.mfe_pct;
     min_duration = std::min(min_duration, trade.duration_bars);
     max_duration = std::max(max_duration, trade.duration_bars);
   }
@@ -173,6 +262,12 @@ StrategyResult backtest_strategy(std::span<const bar> bars,
   result.win_rate = static_cast<double>(wins) / trades.size();
   result.avg_profit = total_profit / trades.size();
   result.total_return = total_profit;
+  result.avg_mae = total_mae / trades.size();
+  result.avg_mfe = total_mfe / trades.size();
+  if (wins > 0)
+    result.avg_winner_mae = winner_mae / wins;
+  if (wins < trades.size())
+    result.avg_loser_mfe = loser_mfe / (trades.size() - wins);
   result.min_duration_bars = min_duration;
   result.max_duration_bars = max_duration;
   result.trades = trades; // Store for debug output
@@ -180,17 +275,446 @@ StrategyResult backtest_strategy(std::span<const bar> bars,
   return result;
 }
 
+// Cumulative equity for one strategy across every symbol it traded. Each
+// trade is a unit stake, so equity is 1 plus the running sum of returns in
+// exit order — overlapping trades on different symbols all count, as they
+// would live with one position per symbol.
+struct EquityCurve {
+  std::string strategy;
+  std::vector<std::string> timestamps{};
+  std::vector<std::string> symbols{}; // Who traded each point, for merging
+  std::vector<double> equity{};
+  double max_drawdown = 0.0; // Largest peak-to-trough fall, fraction of peak
+};
+
+std::vector<EquityCurve>
+equity_curves(const std::vector<StrategyResult> &results) {
+  using traded = std::pair<const Trade *, const std::string *>;
+  auto by_strategy = std::map<std::string, std::vector<traded>>{};
+  for (const auto &r : results)
+    for (const auto &t : r.trades)
+      by_strategy[r.strategy_name].emplace_back(&t, &r.symbol);
+
+  auto curves = std::vector<EquityCurve>{};
+  for (auto &[strategy, trades] : by_strategy) {
+    std::ranges::stable_sort(trades, {},
+                             [](const traded &t) -> const std::string & {
+                               return t.first->exit_timestamp;
+                             });
+
+    auto curve = EquityCurve{.strategy = strategy};
+    auto equity = 1.0;
+    auto peak = 1.0;
+    for (const auto &[t, symbol] : trades) {
+      equity += t->profit_pct;
+      peak = std::max(peak, equity);
+      curve.max_drawdown = std::max(curve.max_drawdown, (peak - equity) / peak);
+      curve.timestamps.push_back(t->exit_timestamp);
+      curve.symbols.push_back(*symbol);
+      curve.equity.push_back(equity);
+    }
+    curves.push_back(std::move(curve));
+  }
+  return curves;
+}
+
+// Load fill.json; a missing file or key keeps the instant full-fill default
+fill::model load_fill_model() {
+  auto ifs = std::ifstream{paths::fill};
+  if (!ifs)
+    return {};
+
+  auto content = std::string{std::istreambuf_iterator<char>(ifs), {}};
+  auto obj = std::string_view{content};
+  if (auto brace = obj.find('{'); brace != std::string_view::npos)
+    obj.remove_prefix(brace + 1);
+
+  // json_number returns 0 for a missing key, which isn't neutral for
+  // partial_fraction
+  auto number = [&](std::string_view key, double fallback) {
+    return obj.contains(std::format("\"{}\"", key)) ? json_number(obj, key)
+                                                      : fallback;
+  };
+  return {.latency_seconds = number("latency_seconds", 0.0),
+          .partial_probability = number("partial_probability", 0.0),
+          .partial_fraction = number("partial_fraction", 1.0),
+          .sampled = number("sampled", 0.0) != 0.0};
+}
+
+// SHA-256 of each bar file, by symbol, from the manifest fetch writes once
+// every file is saved. nullopt without a manifest, so older bar sets still run
+std::optional<std::map<std::string, std::string>> load_manifest() {
+  auto ifs = std::ifstream{paths::bars_manifest};
+  if (!ifs)
+    return std::nullopt;
+
+  auto content = std::string{std::istreambuf_iterator<char>(ifs), {}};
+  auto sums = std::map<std::string, std::string>{};
+  json_foreach_object(content, [&](std::string_view obj) {
+    sums[std::string{json_string(obj, "symbol")}] = json_string(obj, "sha256");
+  });
+  return sums;
+}
+
+// Empty if the symbol's bar file is the one the manifest describes, else
+// what's wrong: a partially published or corrupted set mustn't be backtested
+std::string verify_bars(const std::map<std::string, std::string> &sums,
+                        std::string_view symbol) {
+  auto it = sums.find(std::string{symbol});
+  if (it == sums.end())
+    return "not in manifest";
+
+  // The checksum is of the file as written, compressed or not
+  auto content = read_bar_file(symbol);
+  if (content.empty())
+    return "in manifest but missing";
+  if (sha256::hex(content) != it->second)
+    return "checksum mismatch";
+  return {};
+}
+
+// Output timestamps honour SOURCE_DATE_EPOCH, the reproducible-builds
+// convention, so two runs over the same inputs can be compared byte for byte
 std::string get_iso_timestamp() {
-  auto now = std::chrono::system_clock::now();
-  auto time = std::chrono::system_clock::to_time_t(now);
+  auto time = std::chrono::system_clock::to_time_t(
+      std::chrono::system_clock::now());
+  if (auto epoch = std::getenv("SOURCE_DATE_EPOCH"))
+    time = static_cast<std::time_t>(std::strtoll(epoch, nullptr, 10));
   auto ss = std::ostringstream{};
   ss << std::put_time(std::gmtime(&time), "%Y-%m-%dT%H:%M:%SZ");
   return ss.str();
 }
 
-int main() {
+// One candidate as the last run left it (warm.h)
+struct cached_symbol {
+  std::string sha256; // Of the bar file its results came from
+  std::string as_of;  // When they were computed
+  std::vector<StrategyResult> results{}; // Those with trades, as output
+};
+
+struct backtest_cache {
+  std::string key; // warm::key the results were computed under
+  std::map<std::string, cached_symbol> symbols{};
+};
+
+// The run key: what every result depends on besides its own bar file
+std::string run_key(const fill::model &fills,
+                    std::span<const script::rule> rules) {
+  auto inputs = std::format(
+      "seed={} latency={} partial={} fraction={} sampled={}", fills.seed,
+      fills.latency_seconds, fills.partial_probability, fills.partial_fraction,
+      fills.sampled);
+  for (const auto &r : rules)
+    inputs += std::format(" rule={}|{}|{}|{}|{}|{}-{}", r.name, r.entry,
+                          r.exit, r.budget, r.version, r.window.open.count(),
+                          r.window.close.count());
+  return warm::key(version::build, sha256::hex(inputs));
+}
+
+// Doubles are cached quoted at full precision, so a carried result prints
+// exactly as it did when it was computed
+double cached_number(std::string_view obj, std::string_view key) {
+  auto s = json_string(obj, key);
+  auto v = 0.0;
+  std::from_chars(s.data(), s.data() + s.size(), v);
+  return v;
+}
+
+// An object's scalar keys, which the cache writes before its nested array
+std::string_view scalars(std::string_view obj, std::string_view array_key) {
+  return obj.substr(0, obj.find(std::format("\"{}\"", array_key)));
+}
+
+// From an array's key to the end of the object, for json_foreach_object
+std::string_view array(std::string_view obj, std::string_view array_key) {
+  auto at = obj.find(std::format("\"{}\"", array_key));
+  return at == std::string_view::npos ? std::string_view{} : obj.substr(at);
+}
+
+constexpr exit_reason parse_exit_reason(std::string_view s) {
+  for (auto r : {exit_reason::take_profit, exit_reason::stop_loss,
+                 exit_reason::trailing_stop, exit_reason::risk_off,
+                 exit_reason::rule_exit, exit_reason::end_of_data})
+    if (exit_reason_str(r) == s)
+      return r;
+  return exit_reason::none;
+}
+
+static_assert(parse_exit_reason("trailing_stop") == exit_reason::trailing_stop);
+static_assert(parse_exit_reason("unknown") == exit_reason::none);
+
+// Load the last run's cache; empty when there isn't one
+backtest_cache load_cache(const std::string &path) {
+  auto ifs = std::ifstream{path};
+  if (!ifs)
+    return {};
+
+  auto content = std::string{std::istreambuf_iterator<char>(ifs), {}};
+  auto text = std::string_view{content};
+  auto cache = backtest_cache{};
+  cache.key = json_string(scalars(text.substr(1), "symbols"), "key");
+
+  json_foreach_object(array(text, "symbols"), [&](std::string_view obj) {
+    auto head = scalars(obj, "results");
+    auto symbol = std::string{json_string(head, "symbol")};
+    auto entry = cached_symbol{.sha256 = std::string{json_string(head, "sha256")},
+                               .as_of = std::string{json_string(head, "as_of")}};
+
+    json_foreach_object(array(obj, "results"), [&](std::string_view rec) {
+      auto fields = scalars(rec, "trades");
+      auto r = StrategyResult{
+          .symbol = symbol,
+          .strategy_name = std::string{json_string(fields, "strategy")},
+          .win_rate = cached_number(fields, "win_rate"),
+          .avg_profit = cached_number(fields, "avg_profit"),
+          .trade_count = json_number<int>(fields, "trade_count"),
+          .total_return = cached_number(fields, "total_return"),
+          .avg_mae = cached_number(fields, "avg_mae"),
+          .avg_mfe = cached_number(fields, "avg_mfe"),
+          .avg_winner_mae = cached_number(fields, "avg_winner_mae"),
+          .avg_loser_mfe = cached_number(fields, "avg_loser_mfe"),
+          .min_duration_bars = json_number<int>(fields, "min_duration_bars"),
+          .max_duration_bars = json_number<int>(fields, "max_duration_bars"),
+          .first_timestamp = std::string{json_string(fields, "first_timestamp")},
+          .last_timestamp = std::string{json_string(fields, "last_timestamp")},
+          .required_bars = json_number<std::size_t>(fields, "required_bars"),
+          .capacity = cached_number(fields, "capacity"),
+          .version = json_number<int>(fields, "version"),
+          .params = {.take_profit_pct = cached_number(fields, "take_profit_pct"),
+                     .stop_loss_pct = cached_number(fields, "stop_loss_pct"),
+                     .trailing_stop_pct =
+                         cached_number(fields, "trailing_stop_pct")},
+          .indicator_params =
+              std::string{json_string(fields, "indicator_params")},
+          .viable = json_number<int>(fields, "viable") != 0,
+          .as_of = entry.as_of};
+      auto hash = json_string(fields, "params_hash");
+      std::from_chars(hash.data(), hash.data() + hash.size(), r.params_hash,
+                      16);
+
+      json_foreach_object(array(rec, "trades"), [&](std::string_view t) {
+        r.trades.push_back(Trade{
+            .entry_price = cached_number(t, "entry_price"),
+            .exit_price = cached_number(t, "exit_price"),
+            .profit_pct = cached_number(t, "profit_pct"),
+            .mae_pct = cached_number(t, "mae_pct"),
+            .mfe_pct = cached_number(t, "mfe_pct"),
+            .win = json_number<int>(t, "win") != 0,
+            .duration_bars = json_number<int>(t, "duration_bars"),
+            .reason = parse_exit_reason(json_string(t, "reason")),
+            .entry_timestamp = std::string{json_string(t, "entry_timestamp")},
+            .exit_timestamp = std::string{json_string(t, "exit_timestamp")}});
+      });
+      entry.results.push_back(std::move(r));
+    });
+
+    if (!symbol.empty())
+      cache.symbols[symbol] = std::move(entry);
+  });
+  return cache;
+}
+
+// Write this run's cache for the next run to start warm from
+bool save_cache(const std::string &path, const backtest_cache &cache,
+                std::string_view timestamp) {
+  auto ofs = std::ofstream{path};
+  if (!ofs)
+    return false;
+
+  ofs << std::format("{{\"schema_version\": {}, {}\"timestamp\": \"{}\", "
+                     "\"key\": \"{}\", \"symbols\": [",
+                     paths::schema_version, version::json_key(), timestamp,
+                     cache.key);
+  auto sep = "";
+  for (const auto &[symbol, entry] : cache.symbols) {
+    ofs << std::format(
+        "{}\n  {{\"symbol\": \"{}\", \"sha256\": \"{}\", \"as_of\": \"{}\", "
+        "\"results\": [",
+        sep, symbol, entry.sha256, entry.as_of);
+    for (auto i = 0uz; i < entry.results.size(); ++i) {
+      const auto &r = entry.results[i];
+      ofs << std::format(
+          R"({}
+    {{"strategy": "{}", "version": {}, "take_profit_pct": "{}", "stop_loss_pct": "{}", "trailing_stop_pct": "{}", "indicator_params": "{}", "params_hash": "{:08x}", "win_rate": "{}", "avg_profit": "{}", "total_return": "{}", "avg_mae": "{}", "avg_mfe": "{}", "avg_winner_mae": "{}", "avg_loser_mfe": "{}", "trade_count": {}, "required_bars": {}, "capacity": "{}", "viable": {}, "min_duration_bars": {}, "max_duration_bars": {}, "first_timestamp": "{}", "last_timestamp": "{}", "trades": [)",
+          i ? "," : "", r.strategy_name, r.version, r.params.take_profit_pct,
+          r.params.stop_loss_pct, r.params.trailing_stop_pct,
+          r.indicator_params, r.params_hash, r.win_rate, r.avg_profit,
+          r.total_return, r.avg_mae, r.avg_mfe, r.avg_winner_mae,
+          r.avg_loser_mfe, r.trade_count, r.required_bars, r.capacity,
+          r.viable ? 1 : 0, r.min_duration_bars, r.max_duration_bars,
+          r.first_timestamp, r.last_timestamp);
+      for (auto j = 0uz; j < r.trades.size(); ++j) {
+        const auto &t = r.trades[j];
+        ofs << std::format(
+            R"({}
+      {{"entry_price": "{}", "exit_price": "{}", "profit_pct": "{}", "mae_pct": "{}", "mfe_pct": "{}", "win": {}, "duration_bars": {}, "reason": "{}", "entry_timestamp": "{}", "exit_timestamp": "{}"}})",
+            j ? "," : "", t.entry_price, t.exit_price, t.profit_pct,
+            t.mae_pct, t.mfe_pct, t.win ? 1 : 0, t.duration_bars,
+            exit_reason_str(t.reason), t.entry_timestamp, t.exit_timestamp);
+      }
+      ofs << "]}";
+    }
+    ofs << "]}";
+    sep = ",";
+  }
+  ofs << "\n]}\n";
+  return static_cast<bool>(ofs);
+}
+
+struct options {
+  std::uint64_t seed = 0;
+  std::optional<shard::spec> shard{}; // Set by --shard, even 1/1
+  bool cold = false;                  // Ignore the cache (warm.h)
+  std::string try_strategy{};         // Set by --try, with try_symbol
+  std::string try_symbol{};
+  trading_params params = default_params; // Exit levels for --try
+  bool levels = false;                    // Set by --tp, --sl or --tsl
+};
+
+// --seed N seeds every random draw; the default 0 is as reproducible as any
+// other value. --shard i/n tests only shard i of n. --cold evaluates every
+// candidate, whatever the last run left. --try STRATEGY SYMBOL runs the
+// sandbox instead, with --tp, --sl and --tsl percentages for its exits.
+// Returns nullopt on a malformed flag.
+std::optional<options> parse_args(std::span<char *const> args) {
+  auto opts = options{};
+  for (auto i = 1uz; i < args.size(); ++i) {
+    auto arg = std::string_view{args[i]};
+    if (arg == "--cold") {
+      opts.cold = true;
+      continue;
+    }
+    if (arg == "--try") {
+      if (i + 2 >= args.size())
+        return std::nullopt;
+      opts.try_strategy = args[++i];
+      opts.try_symbol = args[++i];
+      continue;
+    }
+    if (arg != "--seed" && arg != "--shard" && arg != "--tp" &&
+        arg != "--sl" && arg != "--tsl")
+      return std::nullopt;
+    if (++i == args.size())
+      return std::nullopt;
+    auto value = std::string_view{args[i]};
+
+    if (arg == "--tp" || arg == "--sl" || arg == "--tsl") {
+      auto pct = sandbox::parse_pct(value);
+      if (!pct)
+        return std::nullopt;
+      auto &level = arg == "--tp"   ? opts.params.take_profit_pct
+                    : arg == "--sl" ? opts.params.stop_loss_pct
+                                    : opts.params.trailing_stop_pct;
+      level = *pct;
+      opts.levels = true;
+      continue;
+    }
+
+    if (arg == "--shard") {
+      auto spec = shard::parse(value);
+      if (!spec)
+        return std::nullopt;
+      opts.shard = *spec;
+      continue;
+    }
+
+    auto [end, ec] =
+        std::from_chars(value.data(), value.data() + value.size(), opts.seed);
+    if (ec != std::errc{} || end != value.data() + value.size())
+      return std::nullopt;
+  }
+  return opts;
+}
+
+// The sandbox: one strategy on one symbol's stored bars, with the run's fill
+// model and the exit levels given. Prints the trades, their stats and the
+// equity, and writes nothing. Returns the exit code.
+int run_try(const options &opts) {
+  const auto &name = opts.try_strategy;
+  const auto &symbol = opts.try_symbol;
+  auto rules = script::load_rules();
+  auto rule = script::find_rule(rules, name);
+  if (!rule && strategy_version(name) == 0) {
+    std::println("✗ unknown strategy {}: not built in, and not in {}", name,
+                 paths::rules);
+    return 1;
+  }
+
+  auto bars = load_bars(symbol);
+  if (bars.empty()) {
+    std::println("✗ {} - bar data not found", symbol);
+    return 1;
+  }
+
+  auto fills = load_fill_model();
+  fills.seed = fill::stream(opts.seed, symbol);
+  auto entry = [&](std::span<const bar> history) {
+    return rule ? script::fires(rule->entry, history, rule->budget)
+                : dispatch_entry(name, history);
+  };
+  auto r = backtest_strategy(bars, entry, name, fills, rule, opts.params);
+
+  std::println("{} {}-v{} ({}) over {} bars, {} → {}", symbol, name,
+               r.version, r.indicator_params, bars.size(),
+               bars.front().timestamp, bars.back().timestamp);
+  std::println("Exits: take profit {:.2f}%, stop loss {:.2f}%, trailing stop "
+               "{:.2f}%\n",
+               r.params.take_profit_pct * 100.0, r.params.stop_loss_pct * 100.0,
+               r.params.trailing_stop_pct * 100.0);
+
+  if (r.trades.empty()) {
+    std::println("No trades");
+    return 0;
+  }
+
+  auto equity = std::vector<double>{};
+  auto peak = 1.0;
+  auto drawdown = 0.0;
+  for (const auto &t : r.trades) {
+    std::println("  {} → {}  ${:.2f} → ${:.2f}  {:+.2f}%  {} ({} bars)",
+                 t.entry_timestamp, t.exit_timestamp, t.entry_price,
+                 t.exit_price, t.profit_pct * 100.0, exit_reason_str(t.reason),
+                 t.duration_bars);
+    equity.push_back((equity.empty() ? 1.0 : equity.back()) + t.profit_pct);
+    peak = std::max(peak, equity.back());
+    drawdown = std::max(drawdown, (peak - equity.back()) / peak);
+  }
+
+  r.viable = r.win_rate >= 0.50 && r.trade_count >= 5;
+  std::println("");
+  std::println("{} trades, {:.1f}% win, {:.2f}% avg profit, {:+.2f}% total",
+               r.trade_count, r.win_rate * 100.0, r.avg_profit * 100.0,
+               r.total_return * 100.0);
+  std::println("MAE {:.2f}%, MFE {:.2f}%, {}-{} bars held, {} (≥50% win, ≥5 "
+               "trades)",
+               r.avg_mae * 100.0, r.avg_mfe * 100.0, r.min_duration_bars,
+               r.max_duration_bars, r.viable ? "viable" : "not viable");
+  std::println("Equity {} {:.4f}, max drawdown {:.2f}%",
+               sandbox::sparkline(equity), equity.back(), drawdown * 100.0);
+  return 0;
+}
+
+int main(int argc, char *argv[]) {
+  auto args = std::span{argv, static_cast<std::size_t>(argc)};
+  if (version::requested(args | std::views::drop(1))) {
+    version::print("backtest");
+    return 0;
+  }
+
+  auto opts = parse_args(args);
+  if (!opts || (opts->levels && opts->try_strategy.empty())) {
+    std::println("Usage: backtest [--seed N] [--shard i/n] [--cold]");
+    std::println("       backtest --try STRATEGY SYMBOL [--seed N] [--tp PCT] "
+                 "[--sl PCT] [--tsl PCT]");
+    return 2;
+  }
+  if (!opts->try_strategy.empty())
+    return run_try(*opts);
+
   std::println("Backtest Module - Testing strategies");
   std::println("");
+  auto sharded = opts->shard.has_value();
+  auto part = opts->shard.value_or(shard::whole);
 
   // Load candidates from filter output
   auto candidates_file = std::filesystem::path{paths::candidates};
@@ -206,17 +730,88 @@ int main() {
 
   // Extract symbols array using json.h helper
   auto candidates = std::vector<std::string>{};
+  auto ranked = 0uz;
   json_string_array(json_str, "symbols", [&](std::string_view sym) {
-    candidates.emplace_back(sym);
+    if (shard::owns(part, ranked++))
+      candidates.emplace_back(sym);
   });
 
-  std::println("Testing {} candidates from filter", candidates.size());
+  if (sharded)
+    std::println("Testing {} of {} candidates from filter (shard {}/{})",
+                 candidates.size(), ranked, part.index, part.count);
+  else
+    std::println("Testing {} candidates from filter", candidates.size());
   std::println("");
 
   auto all_results = std::vector<StrategyResult>{};
 
+  auto run_fills = load_fill_model();
+  run_fills.seed = opts->seed;
+  std::println("Fill model: {:.0f}s latency, {:.0f}% partial fills at {:.0f}% "
+               "({:.1f}% expected filled, {}, seed {})\n",
+               run_fills.latency_seconds,
+               run_fills.partial_probability * 100.0,
+               run_fills.partial_fraction * 100.0,
+               fill::expected_fraction(run_fills) * 100.0,
+               run_fills.sampled ? "sampled" : "scaled", run_fills.seed);
+
+  // User-defined strategies run alongside the built-ins
+  auto rules = script::load_rules();
+  if (!rules.empty())
+    std::println("Loaded {} user rule(s) from {}\n", rules.size(),
+                 paths::rules);
+
+  auto manifest = load_manifest();
+  if (!manifest)
+    std::println("[WARNING] {} not found — bar files not verified\n",
+                 paths::bars_manifest);
+
+  // Candidates unchanged since the last run carry their results forward; a
+  // shard keeps its own cache, so a candidate that moves shard runs cold
+  auto now = get_iso_timestamp();
+  auto cache_file =
+      sharded ? paths::shards + std::format("backtest-cache-{}-of-{}.json",
+                                            part.index, part.count)
+              : paths::backtest_cache;
+  auto last = opts->cold ? backtest_cache{} : load_cache(cache_file);
+  auto next = backtest_cache{.key = run_key(run_fills, rules)};
+  if (opts->cold)
+    std::println("Cold run — every candidate evaluated\n");
+  else if (next.key.empty())
+    std::println("[WARNING] unstamped build — every candidate evaluated\n");
+  else if (!last.symbols.empty() && last.key != next.key)
+    std::println("Build or inputs changed since {} — every candidate "
+                 "evaluated\n",
+                 cache_file);
+  auto warm_count = 0;
+
   // Test each candidate with all three strategies
   for (const auto &symbol : candidates) {
+    if (manifest) {
+      if (auto problem = verify_bars(*manifest, symbol); !problem.empty()) {
+        std::println("✗ {} - integrity: {}", symbol, problem);
+        continue;
+      }
+    }
+
+    // The manifest's checksum was just verified; without one, hash the file
+    auto sum = std::string{};
+    if (manifest)
+      sum = manifest->at(symbol);
+    else if (auto content = read_bar_file(symbol); !content.empty())
+      sum = sha256::hex(content);
+
+    if (auto it = last.symbols.find(symbol);
+        it != last.symbols.end() &&
+        warm::reusable(last.key, next.key, it->second.sha256, sum)) {
+      std::println("↺ {} - unchanged since {}, {} result(s) carried forward",
+                   symbol, it->second.as_of, it->second.results.size());
+      std::ranges::copy(it->second.results, std::back_inserter(all_results));
+      next.symbols[symbol] = std::move(it->second);
+      ++warm_count;
+      continue;
+    }
+
     auto bars = load_bars(symbol);
     if (bars.empty()) {
       std::println("✗ {} - bar data not found", symbol);
@@ -227,67 +822,95 @@ int main() {
       continue;
     }
 
+    // Each symbol draws from its own stream, so adding or dropping a
+    // candidate doesn't shift the draws of the others
+    auto fills = run_fills;
+    fills.seed = fill::stream(run_fills.seed, symbol);
+
     // Test all strategies
     auto results = std::vector<StrategyResult>{};
 
     results.push_back(
-        backtest_strategy(bars, volume_surge_dip, "volume_surge"));
+        backtest_strategy(bars, volume_surge_dip, "volume_surge", fills));
     results.back().symbol = symbol;
 
     results.push_back(
-        backtest_strategy(bars, mean_reversion, "mean_reversion"));
+        backtest_strategy(bars, mean_reversion, "mean_reversion", fills));
     results.back().symbol = symbol;
 
-    results.push_back(
-        backtest_strategy(bars, sma_crossover<10, 20>, "sma_crossover"));
+    results.push_back(backtest_strategy(bars, sma_crossover<10, 20>,
+                                        "sma_crossover", fills));
     results.back().symbol = symbol;
 
-    results.push_back(backtest_strategy(bars, price_dip, "price_dip"));
+    results.push_back(backtest_strategy(bars, price_dip, "price_dip", fills));
     results.back().symbol = symbol;
 
-    results.push_back(
-        backtest_strategy(bars, volatility_breakout, "volatility_breakout"));
+    results.push_back(backtest_strategy(bars, volatility_breakout,
+                                        "volatility_breakout", fills));
     results.back().symbol = symbol;
 
-    results.push_back(backtest_strategy(bars, rsi_oversold, "rsi_oversold"));
+    results.push_back(
+        backtest_strategy(bars, rsi_oversold, "rsi_oversold", fills));
     results.back().symbol = symbol;
 
-    results.push_back(
-        backtest_strategy(bars, bollinger_breakout, "bollinger_breakout"));
+    results.push_back(backtest_strategy(bars, bollinger_breakout,
+                                        "bollinger_breakout", fills));
     results.back().symbol = symbol;
 
     results.push_back(
-        backtest_strategy(bars, macd_crossover, "macd_crossover"));
+        backtest_strategy(bars, macd_crossover, "macd_crossover", fills));
     results.back().symbol = symbol;
 
-    results.push_back(backtest_strategy(bars, gap_fill, "gap_fill"));
+    results.push_back(backtest_strategy(bars, gap_fill, "gap_fill", fills));
     results.back().symbol = symbol;
 
-    results.push_back(backtest_strategy(bars, momentum, "momentum"));
+    results.push_back(backtest_strategy(bars, momentum, "momentum", fills));
     results.back().symbol = symbol;
 
     results.push_back(
-        backtest_strategy(bars, morning_breakout, "morning_breakout"));
+        backtest_strategy(bars, morning_breakout, "morning_breakout", fills));
     results.back().symbol = symbol;
 
+    for (const auto &rule : rules) {
+      auto entry = [&](std::span<const bar> history) {
+        return script::fires(rule.entry, history, rule.budget);
+      };
+      results.push_back(
+          backtest_strategy(bars, entry, rule.name, fills, &rule));
+      results.back().symbol = symbol;
+    }
+
+    // Liquidity is per symbol; capacity also depends on each strategy's edge
+    auto dollar_volume = capacity::dollar_volume(bars);
+    auto spread = capacity::spread_proxy(bars);
+    std::println("    {} - ${:.0f} per bar, {:.3f}% range (spread proxy)",
+                 symbol, dollar_volume, spread * 100.0);
+
     // Mark each strategy as viable and collect ALL results (not just best)
     auto viable_count = 0;
+    auto evaluated = cached_symbol{.sha256 = sum, .as_of = now};
     for (auto &r : results) {
       // Viable = win_rate >= 50% AND minimum 5 trades for statistical validity
       r.viable = (r.win_rate >= 0.50 && r.trade_count >= 5);
+      r.capacity = capacity::estimate(dollar_volume, spread, r.avg_profit);
+      r.as_of = now;
 
       if (r.trade_count > 0) {
         auto viable_marker = r.viable ? "✓" : "✗";
         std::println("    {} {} - {}: {} trades, {:.1f}% win, {:.2f}% avg "
-                     "profit",
+                     "profit, MAE {:.2f}%, MFE {:.2f}%, capacity ${:.0f}",
                      viable_marker, symbol, r.strategy_name, r.trade_count,
-                     r.win_rate * 100.0, r.avg_profit * 100.0);
+                     r.win_rate * 100.0, r.avg_profit * 100.0,
+                     r.avg_mae * 100.0, r.avg_mfe * 100.0, r.capacity);
 
         // Show per-trade breakdown
         for (const auto &t : r.trades) {
-          std::println("      ${:.2f} → ${:.2f} ({:+.2f}%, {}, {} bars)",
-                       t.entry_price, t.exit_price, t.profit_pct * 100.0,
-                       exit_reason_str(t.reason), t.duration_bars);
+          std::println(
+              "      ${:.2f} → ${:.2f} ({:+.2f}%, {}, {} bars, MAE {:.2f}%, "
+              "MFE {:.2f}%)",
+              t.entry_price, t.exit_price, t.profit_pct * 100.0,
+              exit_reason_str(t.reason), t.duration_bars, t.mae_pct * 100.0,
+              t.mfe_pct * 100.0);
         }
 
         if (r.viable)
@@ -296,8 +919,11 @@ int main() {
         // Add ALL results with trades to output (entries module will filter
         // by viable flag)
         all_results.push_back(r);
+        evaluated.results.push_back(r);
       }
     }
+    if (!sum.empty())
+      next.symbols[symbol] = std::move(evaluated);
 
     if (viable_count > 0) {
       std::println("✓ {} - {} viable strateg{}", symbol, viable_count,
@@ -318,20 +944,38 @@ int main() {
   std::println("  Total tested: {}", all_results.size());
   std::println("  Viable (≥50% win, ≥5 trades): {}", viable_count);
   std::println("  Non-viable: {}", all_results.size() - viable_count);
+  std::println("  Candidates carried forward unchanged: {}", warm_count);
 
   // Sort by: symbol (alphabetical) → viable (true first) → win_rate
   // (descending) This ensures entries module sees viable strategies first for
-  // each symbol
+  // each symbol. Ties fall back to the strategy name so the order never
+  // depends on the sort algorithm.
   std::ranges::sort(all_results, [](const auto &a, const auto &b) {
     if (a.symbol != b.symbol)
       return a.symbol < b.symbol; // Alphabetical by symbol
     if (a.viable != b.viable)
-      return a.viable > b.viable;   // Viable first
-    return a.win_rate > b.win_rate; // Higher win rate first
+      return a.viable > b.viable; // Viable first
+    if (a.win_rate != b.win_rate)
+      return a.win_rate > b.win_rate; // Higher win rate first
+    return a.strategy_name < b.strategy_name;
   });
 
-  // Write strategies.json
+  // A shard writes its share under docs/shards/ for lft2 merge to join; the
+  // shard key there lets merge check the set is complete
   auto output_file = std::filesystem::path{paths::strategies};
+  auto curves_file = std::filesystem::path{paths::equity_curves};
+  auto shard_key = std::string{};
+  if (sharded) {
+    auto suffix = std::format("{}-of-{}.json", part.index, part.count);
+    output_file = paths::shards + "strategies-" + suffix;
+    curves_file = paths::shards + "equity-curves-" + suffix;
+    shard_key =
+        std::format("\"shard\": \"{}/{}\", ", part.index, part.count);
+    auto ec = std::error_code{};
+    std::filesystem::create_directories(paths::shards, ec);
+  }
+
+  // Write strategies.json
   auto ofs = std::ofstream{output_file};
 
   if (!ofs) {
@@ -339,8 +983,12 @@ int main() {
     return 1;
   }
 
-  ofs << "{\"timestamp\": \"" << get_iso_timestamp()
-      << "\", \"recommendations\": [\n";
+  ofs << std::format(
+      "{{\"schema_version\": {}, {}{}\"timestamp\": \"{}\", \"seed\": {}, "
+      "{}\"fill_sampled\": {}, \"recommendations\": [\n",
+      paths::schema_version, version::json_key(), cycle::json_key(),
+      now, run_fills.seed, shard_key,
+      run_fills.sampled ? 1 : 0);
 
   for (auto i = 0uz; i < all_results.size(); ++i) {
     const auto &rec = all_results[i];
@@ -349,29 +997,47 @@ int main() {
         R"(    {{
       "symbol": "{}",
       "strategy": "{}",
+      "version": {},
+      "take_profit_pct": {:.4f},
+      "stop_loss_pct": {:.4f},
+      "trailing_stop_pct": {:.4f},
+      "indicator_params": "{}",
+      "params_hash": "{:08x}",
       "win_rate": {:.3f},
       "avg_profit": {:.4f},
+      "avg_mae": {:.4f},
+      "avg_mfe": {:.4f},
+      "avg_winner_mae": {:.4f},
+      "avg_loser_mfe": {:.4f},
       "trade_count": {},
+      "required_bars": {},
+      "capacity": {:.2f},
       "viable": {},
       "min_duration_bars": {},
       "max_duration_bars": {},
       "first_timestamp": "{}",
       "last_timestamp": "{}",
+      "as_of": "{}",
       "trades": [
 )",
-        rec.symbol, rec.strategy_name, rec.win_rate, rec.avg_profit,
-        rec.trade_count, rec.viable ? "true" : "false", rec.min_duration_bars,
-        rec.max_duration_bars, rec.first_timestamp, rec.last_timestamp);
+        rec.symbol, rec.strategy_name, rec.version, rec.params.take_profit_pct,
+        rec.params.stop_loss_pct, rec.params.trailing_stop_pct,
+        rec.indicator_params, rec.params_hash, rec.win_rate, rec.avg_profit,
+        rec.avg_mae, rec.avg_mfe, rec.avg_winner_mae, rec.avg_loser_mfe,
+        rec.trade_count, rec.required_bars, rec.capacity,
+        rec.viable ? "true" : "false", rec.min_duration_bars,
+        rec.max_duration_bars, rec.first_timestamp, rec.last_timestamp,
+        rec.as_of);
 
     // Export per-trade details
     for (auto j = 0uz; j < rec.trades.size(); ++j) {
       const auto &t = rec.trades[j];
       auto trade_sep = j + 1 < rec.trades.size() ? "," : "";
       ofs << std::format(
-          R"(        {{"entry": {:.2f}, "exit": {:.2f}, "profit_pct": {:.4f}, "reason": "{}", "duration": {}}}{}
+          R"(        {{"entry": {:.2f}, "exit": {:.2f}, "profit_pct": {:.4f}, "mae": {:.4f}, "mfe": {:.4f}, "reason": "{}", "duration": {}}}{}
 )",
-          t.entry_price, t.exit_price, t.profit_pct, exit_reason_str(t.reason),
-          t.duration_bars, trade_sep);
+          t.entry_price, t.exit_price, t.profit_pct, t.mae_pct, t.mfe_pct,
+          exit_reason_str(t.reason), t.duration_bars, trade_sep);
     }
 
     ofs << std::format("      ]\n    }}{}\n", sep);
@@ -381,5 +1047,52 @@ int main() {
 
   std::println("Wrote {}", output_file.string());
 
+  // Equity curves for the dashboard — parallel timestamp/equity arrays per
+  // strategy, the same shape as Alpaca's portfolio history
+  auto curves = equity_curves(all_results);
+  auto curves_out = std::ofstream{curves_file};
+  if (!curves_out) {
+    std::println("Error: Could not write {}", curves_file.string());
+    return 1;
+  }
+
+  curves_out << std::format(
+      "{{\"schema_version\": {}, {}{}\"timestamp\": \"{}\", \"seed\": {}, "
+      "{}\"curves\": [\n",
+      paths::schema_version, version::json_key(), cycle::json_key(),
+      now, run_fills.seed, shard_key);
+  for (auto i = 0uz; i < curves.size(); ++i) {
+    const auto &c = curves[i];
+    curves_out << std::format(
+        R"(  {{"strategy": "{}", "trades": {}, "final_equity": {:.4f}, "max_drawdown": {:.4f},
+    "timestamps": [)",
+        c.strategy, c.equity.size(), c.equity.empty() ? 1.0 : c.equity.back(),
+        c.max_drawdown);
+    for (auto j = 0uz; j < c.timestamps.size(); ++j)
+      curves_out << std::format("{}\"{}\"", j ? ", " : "", c.timestamps[j]);
+    curves_out << "],\n    \"equity\": [";
+    // Merge rebuilds each trade's return from the steps between points, so a
+    // shard keeps full precision and says which symbol traded each point
+    for (auto j = 0uz; j < c.equity.size(); ++j)
+      curves_out << (sharded ? std::format("{}{}", j ? ", " : "", c.equity[j])
+                             : std::format("{}{:.4f}", j ? ", " : "",
+                                           c.equity[j]));
+    if (sharded) {
+      curves_out << "],\n    \"symbols\": [";
+      for (auto j = 0uz; j < c.symbols.size(); ++j)
+        curves_out << std::format("{}\"{}\"", j ? ", " : "", c.symbols[j]);
+    }
+    curves_out << std::format("]}}{}\n", i + 1 < curves.size() ? "," : "");
+  }
+  curves_out << "]}\n";
+
+  std::println("Wrote {} ({} strateg{})", curves_file.string(), curves.size(),
+               curves.size() == 1 ? "y" : "ies");
+
+  // Last, so a failed run leaves the previous cache to start from
+  if (!save_cache(cache_file, next, now))
+    std::println("[WARNING] could not write {} — the next run starts cold",
+                 cache_file);
+
   return 0;
 }
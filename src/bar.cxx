@@ -1,4 +1,5 @@
 #include "bar.h"
+#include "gzip.h"
 #include "json.h"
 #include "paths.h"
 #include <filesystem>
@@ -6,14 +7,29 @@
 #include <string>
 #include <vector>
 
-// Load bars from docs/bars/{symbol}.json produced by the fetch module.
-// Uses the json.h parser — same logic as the constexpr path.
+std::string read_bar_file(std::string_view symbol) {
+  for (const auto &path : {paths::bars(symbol), paths::bars_gz(symbol)}) {
+    auto ifs = std::ifstream{std::filesystem::path{path}, std::ios::binary};
+    if (ifs)
+      return std::string{std::istreambuf_iterator<char>(ifs), {}};
+  }
+  return {};
+}
+
+// Load bars from docs/bars/{symbol}.json produced by the fetch module,
+// decompressing a .json.gz. Uses the json.h parser — same logic as the
+// constexpr path.
 std::vector<bar> load_bars(std::string_view symbol) {
-  auto ifs = std::ifstream{std::filesystem::path{paths::bars(symbol)}};
-  if (!ifs)
+  auto content = read_bar_file(symbol);
+  if (gzip::is_gzip(content)) {
+    auto json = gzip::decompress(content);
+    if (!json)
+      return {};
+    content = std::move(*json);
+  }
+  if (content.empty())
     return {};
 
-  auto content = std::string{std::istreambuf_iterator<char>(ifs), {}};
   auto s = std::string_view{content};
 
   // Scan the top-level object for the "bars" key.
@@ -202,5 +202,10 @@ static_assert(
 } // namespace
 
 // Implemented in bar.cxx — separated from constexpr logic above.
-// Reads docs/bars/{symbol}.json produced by the fetch module.
+// Reads docs/bars/{symbol}.json produced by the fetch module, or
+// {symbol}.json.gz when fetch compressed it.
 std::vector<bar> load_bars(std::string_view symbol);
+
+// The symbol's bar file as it is on disk: {symbol}.json, else
+// {symbol}.json.gz. Empty when there's neither.
+std::string read_bar_file(std::string_view symbol);
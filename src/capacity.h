@@ -0,0 +1,91 @@
+#pragma once
+#include "bar.h"
+#include <algorithm>
+#include <cmath>
+#include <span>
+
+// Strategy capacity: the largest order notional a symbol can take before
+// expected slippage eats too much of a strategy's edge. Negligible at toy
+// account sizes, but it's what stops a growing account pushing size into
+// names that can't absorb it.
+//
+// Slippage follows the square-root impact law, scaled by the bar range as a
+// spread proxy (as filter uses it): trading N dollars into a bar that turns
+// over V costs about spread * sqrt(N / V) each way. Capacity is the N at which
+// a round trip costs max_erosion of the average profit per trade, capped at
+// max_participation of a bar's dollar volume however large the edge.
+
+namespace capacity {
+
+constexpr auto max_erosion = 0.5;        // Share of the edge slippage may take
+constexpr auto max_participation = 0.10; // Share of a bar's dollar volume
+
+// Mean dollar volume per bar, priced at the VWAP where there is one
+constexpr double dollar_volume(std::span<const bar> bars) {
+  if (bars.empty())
+    return 0.0;
+  auto total = 0.0;
+  for (const auto &b : bars)
+    total += b.volume * (b.vwap > 0.0 ? b.vwap : b.close);
+  return total / static_cast<double>(bars.size());
+}
+
+// Mean (high-low)/close as a fraction. Averaged over the history rather than
+// the last bar alone so one wide bar doesn't swing the estimate.
+constexpr double spread_proxy(std::span<const bar> bars) {
+  auto total = 0.0;
+  auto count = 0uz;
+  for (const auto &b : bars)
+    if (b.close > 0.0) {
+      total += (b.high - b.low) / b.close;
+      ++count;
+    }
+  return count ? total / static_cast<double>(count) : 0.0;
+}
+
+// Max order notional for a strategy with the given average profit per trade
+// (a fraction). No edge, no capacity.
+constexpr double estimate(double dollar_volume, double spread, double edge) {
+  if (dollar_volume <= 0.0 || edge <= 0.0)
+    return 0.0;
+  auto cap = max_participation * dollar_volume;
+  if (spread <= 0.0)
+    return cap;
+  auto root = max_erosion * edge / (2.0 * spread);
+  return std::min(dollar_volume * root * root, cap);
+}
+
+// Unit tests
+namespace {
+constexpr auto near(double a, double b) { return std::abs(a - b) < 1e-6; }
+
+// $1M a bar with a 0.2% range: a 0.08% edge supports 1% of the bar
+static_assert(near(estimate(1e6, 0.002, 0.0008), 1e4));
+
+// A large edge is capped by participation
+static_assert(estimate(1e6, 0.002, 0.008) == 1e5);
+
+// Four times the spread takes a sixteenth of the size
+static_assert(near(estimate(1e6, 0.008, 0.0008), 625.0));
+
+// No edge, no volume: nothing; no spread: participation alone
+static_assert(estimate(1e6, 0.002, 0.0) == 0.0);
+static_assert(estimate(1e6, 0.002, -0.01) == 0.0);
+static_assert(estimate(0.0, 0.002, 0.01) == 0.0);
+static_assert(estimate(1e6, 0.0, 0.01) == 1e5);
+
+constexpr bar test_bars[] = {
+    {.close = 100.0, .high = 101.0, .low = 99.0, .vwap = 100.0, .volume = 1000},
+    {.close = 50.0, .high = 50.5, .low = 49.5, .volume = 2000},
+};
+
+// VWAP where present, the close otherwise
+static_assert(dollar_volume(test_bars) == 100000.0);
+static_assert(dollar_volume(std::span<const bar>{}) == 0.0);
+
+// 2% on each bar
+static_assert(near(spread_proxy(test_bars), 0.02));
+static_assert(spread_proxy(std::span<const bar>{}) == 0.0);
+} // namespace
+
+} // namespace capacity
@@ -0,0 +1,176 @@
+#pragma once
+#include <cstddef>
+#include <optional>
+#include <span>
+#include <string_view>
+
+// Conflict resolution between the recommended strategies for one symbol.
+// On a given bar one strategy's entry can fire while another's exit rule
+// fires: one says buy, the other says get out. Entries is long-only and holds
+// at most one position per symbol, so the question is only whether to buy,
+// and under which strategy. The policy answers it:
+//
+//   skip               opposing signals cancel: no entry (the default)
+//   prefer_expectancy  the signal with the highest backtest expectancy
+//                      (avg_profit per trade) wins; a tie with a sell does
+//                      nothing
+//   net                signals cancel one for one and the majority acts; a
+//                      tie does nothing
+//
+// Without a sell there's no conflict: the first buy in rank order enters, as
+// it always has.
+
+namespace conflict {
+
+enum class side { none, buy, sell };
+
+enum class policy { skip, prefer_expectancy, net };
+
+constexpr auto default_policy = policy::skip;
+
+// One strategy's signal on the latest bar, with its backtest expectancy
+struct vote {
+  side signal = side::none;
+  double expectancy = 0.0;
+};
+
+// No vote wins
+constexpr auto none = static_cast<std::size_t>(-1);
+
+constexpr std::string_view name(policy p) {
+  switch (p) {
+  case policy::skip:
+    return "skip";
+  case policy::prefer_expectancy:
+    return "prefer_expectancy";
+  case policy::net:
+    return "net";
+  }
+  return "unknown";
+}
+
+// Policy from its name, or nullopt if there's no such policy
+constexpr std::optional<policy> parse(std::string_view s) {
+  for (auto p : {policy::skip, policy::prefer_expectancy, policy::net})
+    if (name(p) == s)
+      return p;
+  return std::nullopt;
+}
+
+// A rule's signal from its entry and exit expressions. A rule whose entry
+// and exit both fire contradicts itself and says nothing.
+constexpr side classify(bool entry, bool exit) {
+  if (entry == exit)
+    return side::none;
+  return entry ? side::buy : side::sell;
+}
+
+// True if the votes include both a buy and a sell
+constexpr bool opposed(std::span<const vote> votes) {
+  auto buy = false;
+  auto sell = false;
+  for (const auto &v : votes) {
+    buy |= v.signal == side::buy;
+    sell |= v.signal == side::sell;
+  }
+  return buy && sell;
+}
+
+// Index of the buy to place under policy p, or none
+constexpr std::size_t resolve(policy p, std::span<const vote> votes) {
+  auto first_buy = none;
+  auto buys = 0uz;
+  auto sells = 0uz;
+  for (auto i = 0uz; i < votes.size(); ++i) {
+    if (votes[i].signal == side::buy) {
+      if (first_buy == none)
+        first_buy = i;
+      ++buys;
+    }
+    if (votes[i].signal == side::sell)
+      ++sells;
+  }
+  if (buys == 0 || sells == 0)
+    return first_buy;
+
+  switch (p) {
+  case policy::skip:
+    return none;
+
+  case policy::prefer_expectancy: {
+    auto best = none;
+    auto tied = false;
+    for (auto i = 0uz; i < votes.size(); ++i) {
+      if (votes[i].signal == side::none)
+        continue;
+      if (best == none || votes[i].expectancy > votes[best].expectancy) {
+        best = i;
+        tied = false;
+      } else if (votes[i].expectancy == votes[best].expectancy &&
+                 votes[i].signal != votes[best].signal)
+        tied = true;
+    }
+    return tied || votes[best].signal != side::buy ? none : best;
+  }
+
+  case policy::net:
+    return buys > sells ? first_buy : none;
+  }
+  return none;
+}
+
+// Unit tests
+namespace {
+constexpr auto buy(double e) { return vote{side::buy, e}; }
+constexpr auto sell(double e) { return vote{side::sell, e}; }
+constexpr auto quiet = vote{};
+
+constexpr vote agree[] = {quiet, buy(0.002), buy(0.004)};
+constexpr vote against[] = {buy(0.004), sell(0.002)};
+constexpr vote sell_better[] = {buy(0.002), sell(0.004)};
+constexpr vote tie[] = {buy(0.003), sell(0.003)};
+constexpr vote majority[] = {sell(0.01), buy(0.001), quiet, buy(0.002)};
+constexpr vote even[] = {buy(0.001), sell(0.001), buy(0.002), sell(0.002)};
+constexpr vote sells_only[] = {sell(0.01), quiet};
+
+// Names round-trip; unknown names are rejected
+static_assert(parse("skip") == policy::skip);
+static_assert(parse(name(policy::prefer_expectancy)) ==
+              policy::prefer_expectancy);
+static_assert(parse("net") == policy::net);
+static_assert(!parse("vote"));
+
+// Entry and exit together cancel
+static_assert(classify(true, false) == side::buy);
+static_assert(classify(false, true) == side::sell);
+static_assert(classify(true, true) == side::none);
+static_assert(classify(false, false) == side::none);
+
+// Agreement isn't a conflict: the first buy enters under every policy
+static_assert(!opposed(agree));
+static_assert(resolve(policy::skip, agree) == 1);
+static_assert(resolve(policy::prefer_expectancy, agree) == 1);
+static_assert(resolve(policy::net, agree) == 1);
+
+// No buy, nothing to place
+static_assert(resolve(policy::net, sells_only) == none);
+static_assert(resolve(policy::prefer_expectancy, sells_only) == none);
+
+// Skip: any sell vetoes the entry
+static_assert(opposed(against));
+static_assert(resolve(policy::skip, against) == none);
+static_assert(resolve(policy::skip, majority) == none);
+
+// Prefer expectancy: the stronger strategy decides, ties do nothing
+static_assert(resolve(policy::prefer_expectancy, against) == 0);
+static_assert(resolve(policy::prefer_expectancy, sell_better) == none);
+static_assert(resolve(policy::prefer_expectancy, tie) == none);
+static_assert(resolve(policy::prefer_expectancy, majority) == none);
+
+// Net: the majority acts through the first buy, an even split does nothing
+static_assert(resolve(policy::net, majority) == 1);
+static_assert(resolve(policy::net, against) == none);
+static_assert(resolve(policy::net, even) == none);
+} // namespace
+
+} // namespace conflict
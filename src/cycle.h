@@ -0,0 +1,67 @@
+#pragma once
+#include <cstdlib>
+#include <format>
+#include <string>
+#include <string_view>
+
+// Pipeline cycle IDs. Each run of the pipeline has an ID in LFT2_CYCLE (the
+// Makefile sets one per run; a CI retry of the same run keeps it). Entries
+// appends it to each client_order_id as _c{cycle}, entries and exits write it
+// in the .fix heartbeat (FIX tag 5001), and execute refuses intents from a
+// cycle it has already executed, so retrying execute can't place an order
+// twice. Unset, orders carry no cycle and execute checks nothing.
+
+namespace cycle {
+
+constexpr auto max_length = 32uz;
+
+// Letters, digits and '-', up to max_length: safe inside a client_order_id,
+// whose fields are separated by '_'
+constexpr bool valid(std::string_view id) {
+  if (id.empty() || id.size() > max_length)
+    return false;
+  for (auto c : id)
+    if (!(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') &&
+        !(c >= '0' && c <= '9') && c != '-')
+      return false;
+  return true;
+}
+
+// The suffix entries appends to a client_order_id, empty without a cycle
+constexpr std::string order_suffix(std::string_view id) {
+  auto suffix = std::string{};
+  if (!id.empty())
+    suffix.append("_c").append(id);
+  return suffix;
+}
+
+// This run's cycle from LFT2_CYCLE; empty when unset or malformed
+inline std::string id() {
+  auto env = std::getenv("LFT2_CYCLE");
+  if (!env || !valid(env))
+    return {};
+  return env;
+}
+
+// `"cycle": "...", ` for an artifact's header, after schema_version; nothing
+// without a cycle, as Go leaves the key out
+inline std::string json_key() {
+  auto c = id();
+  return c.empty() ? std::string{} : std::format("\"cycle\": \"{}\", ", c);
+}
+
+// Unit tests
+namespace {
+static_assert(valid("gh9876543210"));
+static_assert(valid("20260310T143500"));
+static_assert(valid("run-7"));
+static_assert(!valid(""));
+static_assert(!valid("a_b"));
+static_assert(!valid("a|b"));
+static_assert(!valid("a b"));
+static_assert(!valid("0123456789012345678901234567890123"));
+static_assert(order_suffix("gh42") == "_cgh42");
+static_assert(order_suffix("").empty());
+} // namespace
+
+} // namespace cycle
@@ -1,28 +1,50 @@
 #include "bar.h"
+#include "conflict.h"
+#include "cycle.h"
 #include "entry.h"
 #include "fix.h"
 #include "json.h"
 #include "market.h"
 #include "params.h"
 #include "paths.h"
+#include "script.h"
+#include "version.h"
+#include <algorithm>
+#include <cctype>
+#include <charconv>
 #include <chrono>
+#include <cstdlib>
 #include <fstream>
 #include <print>
+#include <ranges>
+#include <span>
 #include <sstream>
 #include <string>
+#include <unordered_map>
+#include <utility>
 #include <vector>
 
-// Candidate from strategies.json
+// Candidate from strategies.json, with the parameters it was backtested with,
+// or an external signal from signals-inbox.json
 struct Candidate {
   std::string symbol;
   std::string strategy;
+  trading_params params = default_params;
+  std::string params_hash{};
+  std::string source{}; // Set for external signals, which have already fired
+  double capacity = -1.0; // Max order notional from backtest; < 0 unknown
+  int version = 0;        // Strategy logic version; 0 leaves the name bare
+  double expectancy = 0.0; // Backtest avg_profit per trade; 0 for external
 };
 
-// Account info
+// Account info, or a book's share of it with books.json
 struct AccountInfo {
   double cash;
   double portfolio_value;
   double buying_power;
+  double budget = -1.0; // Book's fraction of the account; < 0 without books
+  int bar_minutes = 5;  // The book's bar length
+  std::vector<std::string> strategies{}; // The book's; empty trades any
 };
 
 // Load recommended candidates from strategies.json
@@ -52,8 +74,33 @@ std::vector<Candidate> load_candidates() {
         if (viable_str != "true")
           return; // Skip non-viable strategies
 
-        auto c = Candidate{std::string{json_string(obj, "symbol")},
-                           std::string{json_string(obj, "strategy")}};
+        auto c = Candidate{.symbol = std::string{json_string(obj, "symbol")},
+                           .strategy =
+                               std::string{json_string(obj, "strategy")}};
+
+        // Older strategies.json files predate per-recommendation parameters
+        if (auto tp = json_number(obj, "take_profit_pct"); tp > 0.0)
+          c.params = trading_params{
+              .take_profit_pct = tp,
+              .stop_loss_pct = json_number(obj, "stop_loss_pct"),
+              .trailing_stop_pct = json_number(obj, "trailing_stop_pct")};
+        c.params_hash = std::string{json_string(obj, "params_hash")};
+        c.expectancy = json_number(obj, "avg_profit");
+
+        // Older files have no version; assume the built-in's current one
+        c.version = static_cast<int>(json_number(obj, "version"));
+        if (c.version < 1)
+          c.version = strategy_version(c.strategy);
+
+        // Older files have no capacity estimate, so sizing goes uncapped
+        if (obj.find(R"("capacity")") != std::string_view::npos)
+          c.capacity = json_number(obj, "capacity");
+        if (c.params_hash.empty())
+          c.params_hash =
+              std::format("{:08x}", params_hash(c.strategy,
+                                                strategy_params(c.strategy),
+                                                c.params));
+
         if (!c.symbol.empty() && !c.strategy.empty())
           candidates.push_back(c);
       });
@@ -61,6 +108,110 @@ std::vector<Candidate> load_candidates() {
   return candidates;
 }
 
+// Load unexpired external signals from signals-inbox.json. Each one has
+// already fired, so it skips strategy evaluation but goes through every other
+// check and is sized like any other entry. A missing file has none.
+std::vector<Candidate> load_inbox() {
+  auto ifs = std::ifstream{paths::signals_inbox};
+  if (!ifs)
+    return {};
+
+  auto content = std::string{std::istreambuf_iterator<char>(ifs), {}};
+  auto start = content.find(R"("signals")");
+  if (start == std::string::npos)
+    return {};
+
+  // RFC 3339 UTC times compare correctly as strings
+  auto now = std::format("{:%Y-%m-%dT%H:%M:%SZ}",
+                         std::chrono::floor<std::chrono::seconds>(
+                             std::chrono::system_clock::now()));
+  auto signals = std::vector<Candidate>{};
+  json_foreach_object(
+      std::string_view{content}.substr(start), [&](std::string_view obj) {
+        auto expires = json_string(obj, "expires");
+        if (!expires.empty() && now > expires)
+          return;
+
+        auto c = Candidate{.symbol = std::string{json_string(obj, "symbol")},
+                           .strategy = "external",
+                           .source = std::string{json_string(obj, "source")}};
+        for (auto &ch : c.symbol)
+          ch = static_cast<char>(std::toupper(static_cast<unsigned char>(ch)));
+        if (c.source.empty())
+          c.source = "inbox";
+        if (auto tp = json_number(obj, "take_profit_pct"); tp > 0.0)
+          c.params = trading_params{
+              .take_profit_pct = tp,
+              .stop_loss_pct = json_number(obj, "stop_loss_pct"),
+              .trailing_stop_pct = json_number(obj, "trailing_stop_pct")};
+        c.params_hash = std::format(
+            "{:08x}", params_hash(c.strategy, "", c.params));
+
+        if (!c.symbol.empty())
+          signals.push_back(c);
+      });
+  return signals;
+}
+
+// Load filter's score ranking from candidates.json — "symbols" is ordered
+// best first, so the index is the rank
+std::unordered_map<std::string, std::size_t> load_ranks() {
+  auto ifs = std::ifstream{paths::candidates};
+  if (!ifs)
+    return {};
+
+  auto content = std::string{std::istreambuf_iterator<char>(ifs), {}};
+  auto ranks = std::unordered_map<std::string, std::size_t>{};
+  json_string_array(content, "symbols", [&](std::string_view sym) {
+    ranks.emplace(std::string{sym}, ranks.size());
+  });
+  return ranks;
+}
+
+// Portfolio VaR gate from exposure.json, written by the account module.
+// Returns {var_pct, max_var_pct}; a missing file or zero limit never gates.
+std::pair<double, double> load_var_gate() {
+  auto ifs = std::ifstream{paths::exposure};
+  if (!ifs)
+    return {};
+
+  auto content = std::string{std::istreambuf_iterator<char>(ifs), {}};
+  auto obj = std::string_view{content};
+  if (auto brace = obj.find('{'); brace != std::string_view::npos)
+    obj.remove_prefix(brace + 1);
+
+  return {json_number(obj, "var_pct"), json_number(obj, "max_var_pct")};
+}
+
+// Conflict policy from LFT2_CONFLICT_POLICY, defaulting to skip. An unknown
+// name is reported and falls back to the default rather than trading on a
+// typo.
+conflict::policy load_conflict_policy() {
+  auto env = std::getenv("LFT2_CONFLICT_POLICY");
+  if (!env || !*env)
+    return conflict::default_policy;
+  if (auto p = conflict::parse(env))
+    return *p;
+  std::println("⚠️  Unknown LFT2_CONFLICT_POLICY \"{}\" — using {}", env,
+               conflict::name(conflict::default_policy));
+  return conflict::default_policy;
+}
+
+// Most symbols to trade from LFT2_MAX_SYMBOLS, the same cap fetch -live
+// applies; 0 when unset. Anything that isn't a count is reported and ignored.
+std::size_t load_max_symbols() {
+  auto var = std::getenv("LFT2_MAX_SYMBOLS");
+  if (!var || !*var)
+    return 0;
+  auto env = std::string_view{var};
+  auto n = 0uz;
+  auto [end, ec] = std::from_chars(env.data(), env.data() + env.size(), n);
+  if (ec == std::errc{} && end == env.data() + env.size())
+    return n;
+  std::println("⚠️  LFT2_MAX_SYMBOLS \"{}\" isn't a symbol count — no cap", env);
+  return 0;
+}
+
 // Load account balance
 AccountInfo load_account_info() {
   auto ifs = std::ifstream{paths::account};
@@ -74,8 +225,80 @@ AccountInfo load_account_info() {
   if (auto brace = obj.find('{'); brace != std::string_view::npos)
     obj.remove_prefix(brace + 1);
 
-  return {json_number(obj, "cash"), json_number(obj, "portfolio_value"),
-          json_number(obj, "buying_power")};
+  auto info = AccountInfo{.cash = json_number(obj, "cash"),
+                          .portfolio_value = json_number(obj, "portfolio_value"),
+                          .buying_power = json_number(obj, "buying_power")};
+
+  // A book's slice, from account with books.json
+  if (obj.find(R"("budget")") != std::string_view::npos)
+    info.budget = json_number(obj, "budget");
+  if (auto minutes = json_number<int>(obj, "bar_minutes"); minutes > 0)
+    info.bar_minutes = minutes;
+  json_string_array(content, "strategies", [&](std::string_view s) {
+    info.strategies.emplace_back(s);
+  });
+  return info;
+}
+
+// Symbol blocks from blocklist.json — same rules as internal/blocklist in Go:
+// explicit blocks win, and an active allow list blocks everything not on it.
+// Expiry dates are inclusive and compared as YYYY-MM-DD strings.
+struct Blocklist {
+  std::vector<std::pair<std::string, std::string>> blocked; // symbol, reason
+  std::vector<std::string> allowed;
+
+  // Returns why symbol is blocked, or empty if it may be traded
+  std::string reason(std::string_view symbol) const {
+    for (const auto &[sym, why] : blocked)
+      if (sym == symbol)
+        return why;
+    if (!allowed.empty() && std::ranges::find(allowed, symbol) == allowed.end())
+      return "not on allow list";
+    return {};
+  }
+};
+
+// Load active blocklist entries — a missing file blocks nothing
+Blocklist load_blocklist() {
+  auto ifs = std::ifstream{paths::blocklist};
+  if (!ifs)
+    return {};
+
+  auto content = std::string{std::istreambuf_iterator<char>(ifs), {}};
+  auto today = std::format("{:%Y-%m-%d}", std::chrono::floor<std::chrono::days>(
+                                              std::chrono::system_clock::now()));
+  auto list = Blocklist{};
+
+  auto upper = [](std::string_view s) {
+    auto out = std::string{s};
+    for (auto &c : out)
+      c = static_cast<char>(std::toupper(static_cast<unsigned char>(c)));
+    return out;
+  };
+
+  // Visit each object in the named array, skipping expired entries
+  auto each_active = [&](std::string_view key, auto fn) {
+    auto start = content.find(std::format(R"("{}")", key));
+    if (start == std::string::npos)
+      return;
+    auto array = std::string_view{content}.substr(start);
+    array = array.substr(0, array.find(']') + 1);
+    json_foreach_object(array, [&](std::string_view obj) {
+      auto expires = json_string(obj, "expires");
+      if (!expires.empty() && today > expires)
+        return;
+      fn(upper(json_string(obj, "symbol")), std::string{json_string(obj, "reason")});
+    });
+  };
+
+  each_active("blocked", [&](std::string sym, std::string why) {
+    list.blocked.emplace_back(std::move(sym), std::move(why));
+  });
+  each_active("allowed", [&](std::string sym, std::string) {
+    list.allowed.push_back(std::move(sym));
+  });
+
+  return list;
 }
 
 // Load existing positions to avoid duplicates
@@ -108,7 +331,13 @@ std::vector<std::string> load_existing_symbols() {
   return symbols;
 }
 
-int main() {
+int main(int argc, char *argv[]) {
+  auto args = std::span{argv, static_cast<std::size_t>(argc)};
+  if (version::requested(args | std::views::drop(1))) {
+    version::print("entries");
+    return 0;
+  }
+
   std::println("Low Frequency Trader v2 - Entry Module\n");
 
   // Open buy.fix immediately — heartbeat confirms entries ran, truncates stale
@@ -117,21 +346,76 @@ int main() {
     std::ofstream{paths::buy_fix} << fix::heartbeat("entries");
   }
 
-  // Load candidates
+  // This book's share of the account, and the strategies it trades
+  auto account = load_account_info();
+  if (!paths::book.empty())
+    std::println("Book {}: {} strategy(ies), {}-minute bars", paths::book,
+                 account.strategies.size(), account.bar_minutes);
+
+  // Load candidates, then any external signals. The inbox belongs to the
+  // default book, or every book would enter each signal.
   auto candidates = load_candidates();
+  if (!account.strategies.empty()) {
+    auto others = std::erase_if(candidates, [&](const Candidate &c) {
+      return !std::ranges::contains(account.strategies, c.strategy);
+    });
+    if (others)
+      std::println("[skip] {} candidate(s) for other books' strategies",
+                   others);
+  }
+  auto inbox = paths::book.empty() ? load_inbox() : std::vector<Candidate>{};
+  if (!inbox.empty())
+    std::println("{} external signal(s) from {}", inbox.size(),
+                 paths::signals_inbox);
+  candidates.insert(candidates.end(), inbox.begin(), inbox.end());
   if (candidates.empty()) {
     std::println("No candidates to evaluate");
     return 0;
   }
 
+  // Highest-scored symbols first so limited capital goes to the best
+  // candidates; unranked symbols keep their order at the back
+  {
+    auto ranks = load_ranks();
+    auto rank = [&](const Candidate &c) {
+      auto it = ranks.find(c.symbol);
+      return it == ranks.end() ? ranks.size() : it->second;
+    };
+    std::ranges::stable_sort(candidates, {}, rank);
+  }
+
+  // Only the best ranked symbols, however many the backtest recommends, so a
+  // permissive run can't spread the account over hundreds. External signals
+  // were asked for by name and aren't counted.
+  if (auto max_symbols = load_max_symbols(); max_symbols > 0) {
+    auto kept = std::vector<std::string>{};
+    auto dropped = std::erase_if(candidates, [&](const Candidate &c) {
+      if (!c.source.empty() || std::ranges::contains(kept, c.symbol))
+        return false;
+      if (kept.size() == max_symbols)
+        return true;
+      kept.push_back(c.symbol);
+      return false;
+    });
+    if (dropped)
+      std::println("[skip] {} candidate(s) beyond LFT2_MAX_SYMBOLS {}", dropped,
+                   max_symbols);
+  }
+
   std::println("Evaluating {} candidate(s)...", candidates.size());
 
-  // Load account info — abort if buying_power is zero (likely a parse/API
-  // failure)
-  auto account = load_account_info();
+  // A book with nothing left to spend has nothing to do
+  if (account.budget >= 0.0 && account.buying_power <= 0.0) {
+    std::println("\n[skip] no buying power left in the book's {:.0f}% of the "
+                 "account",
+                 account.budget * 100);
+    return 0;
+  }
+
+  // Abort if buying_power is zero (likely a parse/API failure)
   if (account.buying_power <= 0.0) {
-    std::println("\n❌ ERROR: buying power is zero — docs/account.json "
-                 "missing or invalid");
+    std::println("\n❌ ERROR: buying power is zero — {} missing or invalid",
+                 paths::account);
     std::println("   Run the account module first: make account");
     return 1;
   }
@@ -140,12 +424,86 @@ int main() {
   std::println("  Portfolio Value: ${:.2f}", account.portfolio_value);
   std::println("  Buying Power: ${:.2f}", account.buying_power);
 
+  // Correlated losses across current positions could already exceed what we
+  // can stomach in a day — no new risk until they come down
+  if (auto [var_pct, max_var_pct] = load_var_gate();
+      max_var_pct > 0.0 && var_pct > max_var_pct) {
+    std::println("\n⛔ Portfolio VaR {:.2f}% of equity exceeds {:.2f}% — no "
+                 "new entries",
+                 var_pct * 100, max_var_pct * 100);
+    return 0;
+  }
+
   constexpr auto max_order_value = 2000.0;
 
+  // Top-K: capital covers at most this many full-size orders
+  auto max_entries =
+      static_cast<std::size_t>(account.buying_power / max_order_value);
+  std::println("  Capacity: top {} candidate(s) by score", max_entries);
+
+  // Cluster limit: when many symbols signal on the same bar it's usually one
+  // market-wide move, and correlated entries defeat per-trade risk budgeting.
+  // Take only the top-ranked signals.
+  constexpr auto max_cluster_entries = 3uz;
+  auto signal_count = 0uz;
+
   // Load existing positions to avoid duplicates
   auto existing_symbols = load_existing_symbols();
   std::println("\nCurrently holding {} position(s)", existing_symbols.size());
 
+  // Blocklist may have changed since filter ran — re-check before buying
+  auto blocklist = load_blocklist();
+
+  // User-defined strategies from rules.json
+  auto rules = script::load_rules();
+
+  // What to do when a symbol's strategies disagree on the latest bar
+  auto policy = load_conflict_policy();
+  std::println("Conflict policy: {}", conflict::name(policy));
+
+  // Hours a candidate may enter in; external signals have no strategy to
+  // restrict them
+  auto window_of = [&](const Candidate &c) {
+    if (!c.source.empty())
+      return market::whole_session;
+    auto rule = script::find_rule(rules, c.strategy);
+    return rule ? rule->window : strategy_window(c.strategy);
+  };
+
+  // A candidate's signal on the latest bar: a buy when its entry fires, a
+  // sell when a rule's exit fires. External signals have already fired, and
+  // a strategy outside its window says nothing.
+  auto signal_of = [&](const Candidate &c,
+                       std::span<const bar> history) -> conflict::side {
+    if (!c.source.empty())
+      return conflict::side::buy;
+    if (history.empty() ||
+        !market::in_window(window_of(c), history.back().timestamp))
+      return conflict::side::none;
+    auto rule = script::find_rule(rules, c.strategy);
+    auto need = rule ? rule->lookback : required_bars(c.strategy);
+    if (history.size() < need)
+      return conflict::side::none;
+    if (!rule)
+      return dispatch_entry(c.strategy, history) ? conflict::side::buy
+                                                 : conflict::side::none;
+    return conflict::classify(
+        script::fires(rule->entry, history, rule->budget),
+        !rule->exit.empty() && script::fires(rule->exit, history, rule->budget));
+  };
+
+  // The candidate chosen to enter each symbol, resolved once per symbol
+  auto chosen = std::unordered_map<std::string, std::size_t>{};
+
+  // Orders are signed for execute to verify when LFT2_ORDER_KEY is set, and
+  // tagged with the pipeline cycle when LFT2_CYCLE is
+  auto order_key = fix::order_key();
+  auto order_suffix = cycle::order_suffix(cycle::id());
+  if (auto env = std::getenv("LFT2_CYCLE"); env && order_suffix.empty())
+    std::println("[WARNING] LFT2_CYCLE \"{}\" isn't a cycle ID (letters, "
+                 "digits and '-', up to {}) — orders not tagged",
+                 env, cycle::max_length);
+
   // Collect buy orders
   auto buy_orders = std::vector<std::string>{};
   auto seq_num = 1;
@@ -154,7 +512,8 @@ int main() {
                "Status");
   std::println("{}", std::string(60, '-'));
 
-  for (const auto &candidate : candidates) {
+  for (auto i = 0uz; i < candidates.size(); ++i) {
+    const auto &candidate = candidates[i];
     auto prefix =
         std::format("{:<6} {:<24}", candidate.symbol, candidate.strategy);
 
@@ -164,6 +523,11 @@ int main() {
       continue;
     }
 
+    if (auto why = blocklist.reason(candidate.symbol); !why.empty()) {
+      std::println("{}           ⛔ blocked: {}", prefix, why);
+      continue;
+    }
+
     auto bars = load_bars(candidate.symbol);
     if (bars.size() < 25) {
       std::println("{}           ⚠️  {} bars", prefix, bars.size());
@@ -173,8 +537,9 @@ int main() {
     auto latest_price = bars.back().close;
     auto last_ts = bars.back().timestamp;
 
-    // During market hours, skip if latest bar is more than 20 minutes old.
-    // Alpaca free tier has a 15-minute data delay, so allow up to 20 minutes.
+    // During market hours, skip if latest bar is more than a bar and 15
+    // minutes old: Alpaca free tier has a 15-minute data delay, so 5-minute
+    // bars may be up to 20 minutes old.
     if (market::market_open(last_ts)) {
       auto now = std::chrono::system_clock::now();
       auto bar_time = std::chrono::sys_seconds{};
@@ -182,7 +547,7 @@ int main() {
       std::chrono::from_stream(ss, "%Y-%m-%dT%H:%M:%SZ", bar_time);
       auto age =
           std::chrono::duration_cast<std::chrono::minutes>(now - bar_time);
-      if (age > std::chrono::minutes{20}) {
+      if (age > std::chrono::minutes{account.bar_minutes + 15}) {
         std::println("{} {:>8.2f}  ⏭️  stale ({}m)", prefix, latest_price,
                      age.count());
         continue;
@@ -199,25 +564,93 @@ int main() {
                    latest_price, last_ts);
       continue;
     }
+    auto window = window_of(candidate);
+    if (!market::in_window(window, last_ts)) {
+      std::println("{} {:>8.2f}  ⏭️  outside window ({} ET)", prefix,
+                   latest_price, market::window_text(window));
+      continue;
+    }
 
-    auto should_enter = dispatch_entry(candidate.strategy, bars);
-    if (!should_enter) {
-      std::println("{} {:>8.2f}  ⏭️  no signal", prefix, latest_price);
+    // Too little history makes every strategy return false, which would
+    // look exactly like "no signal" — report it instead
+    auto rule = script::find_rule(rules, candidate.strategy);
+    auto need = rule ? rule->lookback : required_bars(candidate.strategy);
+    if (bars.size() < need) {
+      std::println("{} {:>8.2f}  ⚠️  insufficient history: {} bars, {} needs "
+                   "{}",
+                   prefix, latest_price, bars.size(), candidate.strategy,
+                   need);
       continue;
     }
 
-    auto shares = static_cast<int>(max_order_value / latest_price);
+    auto signal = signal_of(candidate, bars);
+    if (signal != conflict::side::buy) {
+      std::println("{} {:>8.2f}  ⏭️  {}", prefix, latest_price,
+                   signal == conflict::side::sell ? "exit signal"
+                                                  : "no signal");
+      continue;
+    }
+
+    // Every candidate for the symbol votes on the same bars; the policy
+    // decides whether any of them enters, and which
+    auto [pick, fresh] = chosen.try_emplace(candidate.symbol, conflict::none);
+    if (fresh) {
+      auto members = std::vector<std::size_t>{};
+      auto votes = std::vector<conflict::vote>{};
+      for (auto j = 0uz; j < candidates.size(); ++j)
+        if (candidates[j].symbol == candidate.symbol) {
+          members.push_back(j);
+          votes.push_back({signal_of(candidates[j], bars),
+                           candidates[j].expectancy});
+        }
+      auto winner = conflict::resolve(policy, votes);
+      if (winner != conflict::none)
+        pick->second = members[winner];
+      if (conflict::opposed(votes))
+        std::println("   ⚖️  {} strategies disagree — {}: {}",
+                     candidate.symbol, conflict::name(policy),
+                     winner == conflict::none
+                         ? std::string{"no entry"}
+                         : candidates[members[winner]].strategy);
+    }
+    if (pick->second != i) {
+      std::println("{} {:>8.2f}  ⏭️  conflict ({})", prefix, latest_price,
+                   conflict::name(policy));
+      continue;
+    }
+    ++signal_count;
+
+    if (buy_orders.size() >= max_entries) {
+      std::println("{} {:>8.2f}  ⏭️  capital limit (top {})", prefix,
+                   latest_price, max_entries);
+      continue;
+    }
+    if (buy_orders.size() >= max_cluster_entries) {
+      std::println("{} {:>8.2f}  ⏭️  cluster limit (top {})", prefix,
+                   latest_price, max_cluster_entries);
+      continue;
+    }
+
+    // Capped by the notional the symbol can absorb before slippage erodes
+    // the strategy's edge (capacity.h)
+    auto order_limit = max_order_value;
+    if (candidate.capacity >= 0.0 && candidate.capacity < order_limit) {
+      order_limit = candidate.capacity;
+      std::println("   Capacity caps the order at ${:.0f}", order_limit);
+    }
+
+    auto shares = static_cast<int>(order_limit / latest_price);
     if (shares < 1) {
       std::println("{} {:>8.2f}  ❌ too expensive (< 1 share for ${})", prefix,
-                   latest_price, static_cast<int>(max_order_value));
+                   latest_price, static_cast<int>(order_limit));
       continue;
     }
 
     auto order_value = shares * latest_price;
-    if (order_value > max_order_value) {
+    if (order_value > order_limit) {
       // This should never happen — abort loudly if it does
       std::println("❌ ABORT: order value ${:.2f} exceeds limit ${} — BUG",
-                   order_value, static_cast<int>(max_order_value));
+                   order_value, static_cast<int>(order_limit));
       return 1;
     }
     if (order_value > account.buying_power) {
@@ -229,24 +662,52 @@ int main() {
     std::println("   ✅ Entry signal! Buying {} shares (${:.2f})", shares,
                  order_value);
 
-    // Generate FIX buy order — encode symbol, strategy and risk params in the
-    // client_order_id (tag 11) so every field is visible in Alpaca's order
-    // history without needing a separate lookup.
-    // Format: AAPL_mean_reversion_tp3_sl2_tsl1_20260218T143000
+    // Generate FIX buy order — encode symbol, strategy, risk params and the
+    // parameter hash in the client_order_id (tag 11) so every field is
+    // visible in Alpaca's order history without needing a separate lookup,
+    // and exits can close the position with the levels it was opened with.
+    // The strategy carries its version so fills from different logic aren't
+    // attributed together, and the pipeline cycle ends it so a retried
+    // execute can tell it already ran.
+    // Format: AAPL_mean_reversion-v1_tp1.25_sl1.25_tsl1.00_p1a2b3c4d_20260218T143000_cgh42
     auto now_ts = std::format("{:%Y%m%dT%H%M%S}",
                               std::chrono::floor<std::chrono::seconds>(
                                   std::chrono::system_clock::now()));
-    auto order_id =
-        std::format("{}_{}_tp{:.2f}_sl{:.2f}_tsl{:.2f}_{}", candidate.symbol,
-                    candidate.strategy, default_params.take_profit_pct * 100,
-                    default_params.stop_loss_pct * 100,
-                    default_params.trailing_stop_pct * 100, now_ts);
-
-    buy_orders.push_back(fix::new_order_single(
-        order_id, candidate.symbol, fix::SIDE_BUY, shares, seq_num,
-        fix::ORD_TYPE_MARKET, 0.0, candidate.strategy));
+    auto strategy = candidate.version > 0
+                        ? std::format("{}-v{}", candidate.strategy,
+                                      candidate.version)
+                        : candidate.strategy;
+    auto order_id = std::format(
+        "{}_{}_tp{:.2f}_sl{:.2f}_tsl{:.2f}_p{}_{}{}", candidate.symbol,
+        strategy, candidate.params.take_profit_pct * 100,
+        candidate.params.stop_loss_pct * 100,
+        candidate.params.trailing_stop_pct * 100, candidate.params_hash,
+        now_ts, order_suffix);
+
+    auto text = candidate.source.empty()
+                    ? strategy
+                    : std::format("external:{}", candidate.source);
+
+    // A strategy with a window carries it in the order — effective from the
+    // open (tag 168) and expiring by the close (126) — for execute to check
+    auto expires = fix::expire_time();
+    auto effective = std::string{};
+    if (window != market::whole_session) {
+      auto today = std::chrono::floor<std::chrono::days>(
+          std::chrono::system_clock::now());
+      expires = fix::expire_time(market::utc_time(today, window.close));
+      effective = fix::timestamp(market::utc_time(today, window.open));
+    }
+    buy_orders.push_back(fix::sign(
+        fix::new_order_single(order_id, candidate.symbol, fix::SIDE_BUY, shares,
+                              seq_num, fix::ORD_TYPE_MARKET, 0.0, text,
+                              expires, effective),
+        order_key));
     seq_num++;
 
+    // One entry per symbol per cycle, whichever source signalled first
+    existing_symbols.push_back(candidate.symbol);
+
     std::println("{} {:>8.2f}  ✅ buy {} shares (${:.2f})", prefix,
                  latest_price, shares, order_value);
 
@@ -259,8 +720,12 @@ int main() {
   for (const auto &order : buy_orders)
     ofs << order;
 
-  std::println("\n✓ Generated {} buy order(s) in docs/buy.fix",
-               buy_orders.size());
+  std::println("\n✓ Generated {} buy order(s) in docs/buy.fix{}",
+               buy_orders.size(), order_key.empty() ? "" : " (signed)");
+  if (signal_count > max_cluster_entries)
+    std::println("⚠️  Signal cluster: {} entries signalled on this bar, took "
+                 "the top {} — likely a market-wide move",
+                 signal_count, buy_orders.size());
 
   std::println("Remaining cash: ${:.2f}", account.cash);
   return 0;
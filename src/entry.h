@@ -1,5 +1,6 @@
 #pragma once
 #include "json.h"
+#include "market.h"
 #include "nstd.h"
 #include <span>
 #include <string_view>
@@ -797,6 +798,168 @@ constexpr bool dispatch_entry(std::string_view strategy,
   return false;
 }
 
+// Minimum history each strategy needs before its signal means anything —
+// the same windows the strategies check internally. Callers must compare
+// against this before dispatching: with too few bars a strategy returns
+// false, which is indistinguishable from "no signal". Returns 0 for an
+// unknown strategy.
+constexpr std::size_t required_bars(std::string_view strategy) {
+  if (strategy == "volume_surge")
+    return 20;
+  if (strategy == "mean_reversion")
+    return 20;
+  if (strategy == "sma_crossover")
+    return 20 + 1; // long period + previous bar for the cross
+  if (strategy == "price_dip")
+    return 2;
+  if (strategy == "volatility_breakout")
+    return 20 + 5; // lookback + recent window
+  if (strategy == "rsi_oversold")
+    return 14 + 1;
+  if (strategy == "bollinger_breakout")
+    return 20;
+  if (strategy == "macd_crossover")
+    return 26 + 9; // slow EMA + signal period
+  if (strategy == "gap_fill")
+    return 2;
+  if (strategy == "momentum")
+    return 4;
+  if (strategy == "morning_breakout")
+    return 13; // first hour (12 bars) + current
+  return 0;
+}
+
+static_assert(required_bars("macd_crossover") == 35);
+static_assert(required_bars("unknown") == 0);
+
+// Indicator parameters each strategy runs with — fixed at compile time today,
+// but recorded in strategies.json and hashed into client_order_ids so a fill
+// can be traced to the exact parameterisation that produced it. Keep in step
+// with the constants in each strategy above. Empty for an unknown strategy.
+constexpr std::string_view strategy_params(std::string_view strategy) {
+  if (strategy == "volume_surge")
+    return "lookback=20,volume_ratio=2.0,drop_pct=1.0";
+  if (strategy == "mean_reversion")
+    return "lookback=20,z=-2.0";
+  if (strategy == "sma_crossover")
+    return "short=10,long=20";
+  if (strategy == "price_dip")
+    return "drop_pct=1.0";
+  if (strategy == "volatility_breakout")
+    return "lookback=20,recent=5,expansion=1.5";
+  if (strategy == "rsi_oversold")
+    return "period=14,threshold=30";
+  if (strategy == "bollinger_breakout")
+    return "period=20,sigma=2.0";
+  if (strategy == "macd_crossover")
+    return "fast=12,slow=26,signal=9";
+  if (strategy == "gap_fill")
+    return "gap_pct=2.0";
+  if (strategy == "momentum")
+    return "up_bars=3";
+  if (strategy == "morning_breakout")
+    return "first_hour_bars=12,volume_ratio=1.5";
+  return {};
+}
+
+static_assert(strategy_params("sma_crossover") == "short=10,long=20");
+static_assert(strategy_params("unknown").empty());
+
+// Logic version of each strategy. Bump it whenever a strategy's signal
+// changes, so fills from before and after aren't attributed to the same
+// thing: it's recorded in strategies.json and entries writes it into the
+// client_order_id as a suffix on the name (mean_reversion-v1). Renames and
+// history from before versioning are mapped in strategy-map.json. 0 for an
+// unknown strategy.
+constexpr int strategy_version(std::string_view strategy) {
+  if (strategy == "volume_surge")
+    return 1;
+  if (strategy == "mean_reversion")
+    return 2; // execution window
+  if (strategy == "sma_crossover")
+    return 1;
+  if (strategy == "price_dip")
+    return 1;
+  if (strategy == "volatility_breakout")
+    return 2; // execution window
+  if (strategy == "rsi_oversold")
+    return 1;
+  if (strategy == "bollinger_breakout")
+    return 2; // execution window
+  if (strategy == "macd_crossover")
+    return 1;
+  if (strategy == "gap_fill")
+    return 1;
+  if (strategy == "momentum")
+    return 1;
+  if (strategy == "morning_breakout")
+    return 2; // execution window
+  return 0;
+}
+
+static_assert(strategy_version("mean_reversion") == 2);
+static_assert(strategy_version("unknown") == 0);
+
+// Hours of the session (ET) each strategy may enter in; the rest use the
+// whole session. Backtest and entries both apply it, and entries writes it
+// into the order for execute to check. Changing a window changes which
+// signals are taken, so bump the strategy's version with it.
+constexpr market::window strategy_window(std::string_view strategy) {
+  using namespace std::chrono_literals;
+  // Reversion to the mean needs the opening's price discovery over first
+  if (strategy == "mean_reversion")
+    return {10h, 15h + 30min};
+  // Breakouts follow the morning's range expansion
+  if (strategy == "volatility_breakout" || strategy == "bollinger_breakout")
+    return {9h + 30min, 11h + 30min};
+  // Breaks above the first hour's high, so only once there is one
+  if (strategy == "morning_breakout")
+    return {10h + 30min, 11h + 30min};
+  return market::whole_session;
+}
+
+static_assert(strategy_window("mean_reversion") ==
+              *market::parse_window("10:00-15:30"));
+static_assert(strategy_window("momentum") == market::whole_session);
+static_assert(strategy_window("unknown") == market::whole_session);
+
+// Every built-in strategy has a version
+static_assert([] {
+  for (auto name : {"volume_surge", "mean_reversion", "sma_crossover",
+                    "price_dip", "volatility_breakout", "rsi_oversold",
+                    "bollinger_breakout", "macd_crossover", "gap_fill",
+                    "momentum", "morning_breakout"})
+    if (strategy_version(name) < 1)
+      return false;
+  return true;
+}());
+
+// Each strategy stays silent one bar short of its requirement
+static_assert([] {
+  auto bars = std::array<bar, 40>{};
+  for (auto i = 0uz; i < bars.size(); ++i)
+    bars[i] = bar{.close = 100.0 - i, // steady decline trips dip strategies
+                  .high = 101.0 - i,
+                  .low = 99.0 - i,
+                  .open = 100.5 - i,
+                  .vwap = 100.0 - i,
+                  .volume = 1000u + static_cast<std::uint32_t>(i) * 100,
+                  .num_trades = 50,
+                  .timestamp = "2026-01-01T15:00:00Z"};
+  for (auto name : {"volume_surge", "mean_reversion", "sma_crossover",
+                    "price_dip", "volatility_breakout", "rsi_oversold",
+                    "bollinger_breakout", "macd_crossover", "gap_fill",
+                    "momentum", "morning_breakout"}) {
+    auto need = required_bars(name);
+    if (need == 0 || need > bars.size())
+      return false;
+    auto short_history = std::span<const bar>{bars.data(), need - 1};
+    if (dispatch_entry(name, short_history))
+      return false;
+  }
+  return true;
+}());
+
 // is_entry: insufficient history returns false for all strategies
 static_assert([] {
   auto bars = std::array<bar, 5>{};
@@ -4,13 +4,18 @@
 // entry/exit opportunities
 
 #include "bar.h"
+#include "cycle.h"
 #include "entry.h"
 #include "exit.h"
 #include "json.h"
 #include "paths.h"
+#include "script.h"
+#include "version.h"
 #include <filesystem>
 #include <fstream>
 #include <print>
+#include <ranges>
+#include <span>
 #include <string>
 #include <vector>
 
@@ -64,7 +69,13 @@ std::vector<Candidate> load_strategies() {
   return candidates;
 }
 
-int main() {
+int main(int argc, char *argv[]) {
+  auto args = std::span{argv, static_cast<std::size_t>(argc)};
+  if (version::requested(args | std::views::drop(1))) {
+    version::print("evaluate");
+    return 0;
+  }
+
   std::println("Low Frequency Trader v2 - Market Evaluator\n");
 
   auto candidates = load_strategies();
@@ -76,17 +87,21 @@ int main() {
   std::println("Loaded {} candidates from strategies.json", candidates.size());
 
   auto signals = std::vector<Signal>{};
+  auto rules = script::load_rules();
 
   for (const auto &candidate : candidates) {
     auto bars = load_bars(candidate.symbol);
 
-    if (bars.size() < 20) {
-      std::println("Warning: Insufficient bars for {} (got {})",
-                   candidate.symbol, bars.size());
+    auto rule = script::find_rule(rules, candidate.strategy);
+    auto need = rule ? rule->lookback : required_bars(candidate.strategy);
+    if (bars.size() < need) {
+      std::println("Warning: Insufficient bars for {} {} (got {}, needs {})",
+                   candidate.symbol, candidate.strategy, bars.size(), need);
       continue;
     }
 
-    auto should_enter = dispatch_entry(candidate.strategy, bars);
+    auto should_enter = rule ? script::fires(rule->entry, bars, rule->budget)
+                             : dispatch_entry(candidate.strategy, bars);
 
     if (should_enter) {
       signals.push_back({
@@ -109,7 +124,9 @@ int main() {
     return 1;
   }
 
-  ofs << "{\"signals\": [\n";
+  ofs << std::format("{{\"schema_version\": {}, {}{}\"signals\": [\n",
+                     paths::schema_version, version::json_key(),
+                     cycle::json_key());
   for (auto i = 0uz; i < signals.size(); ++i) {
     const auto &sig = signals[i];
     auto sep = i + 1 < signals.size() ? "," : "";
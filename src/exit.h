@@ -16,6 +16,7 @@ enum class exit_reason {
   stop_loss,     // Hit stop loss
   trailing_stop, // Fell below trailing stop
   risk_off,      // Market closing (last 30 min)
+  rule_exit,     // User rule's exit expression fired (script.h)
   end_of_data    // Backtest data ran out
 };
 
@@ -1,14 +1,22 @@
 #include "bar.h"
+#include "cycle.h"
 #include "exit.h"
 #include "fix.h"
 #include "json.h"
 #include "market.h"
 #include "params.h"
 #include "paths.h"
+#include "script.h"
+#include "stale.h"
+#include "version.h"
 #include <chrono>
+#include <cstdlib>
 #include <fstream>
 #include <iostream>
+#include <optional>
 #include <print>
+#include <ranges>
+#include <span>
 #include <string>
 #include <vector>
 
@@ -20,6 +28,7 @@ struct Position {
   std::string side;
   std::string client_order_id; // Original buy order ID (contains strategy +
                                // exit params)
+  std::string book{};          // Set by account with books.json
 };
 
 // Parse positions.json from account module
@@ -38,13 +47,92 @@ std::vector<Position> load_positions() {
         .avg_entry_price = json_number(obj, "avg_entry_price"),
         .side = std::string{json_string(obj, "side")},
         .client_order_id = std::string{json_string(obj, "client_order_id")},
+        .book = std::string{json_string(obj, "book")},
     });
   });
 
   return positions;
 }
 
-int main() {
+// Viable recommendations from strategies.json, or nullopt when there's no
+// file to check against: without one every position would look stale
+std::optional<std::vector<stale::pair>> load_recommendations() {
+  auto ifs = std::ifstream{paths::strategies};
+  if (!ifs)
+    return std::nullopt;
+
+  auto content = std::string{std::istreambuf_iterator<char>(ifs), {}};
+  auto rec_start = content.find(R"("recommendations")");
+  if (rec_start == std::string::npos)
+    return std::nullopt;
+  auto array_start = content.find('[', rec_start);
+  if (array_start == std::string::npos)
+    return std::nullopt;
+
+  auto recommendations = std::vector<stale::pair>{};
+  json_foreach_object(
+      std::string_view{content}.substr(array_start), [&](std::string_view obj) {
+        if (json_string(obj, "viable") == "true")
+          recommendations.emplace_back(json_string(obj, "symbol"),
+                                       json_string(obj, "strategy"));
+      });
+  return recommendations;
+}
+
+// Stale position policy from LFT2_STALE_POLICY, defaulting to flag. An
+// unknown name is reported and falls back to the default rather than
+// selling on a typo.
+stale::policy load_stale_policy() {
+  auto env = std::getenv("LFT2_STALE_POLICY");
+  if (!env || !*env)
+    return stale::default_policy;
+  if (auto p = stale::parse(env))
+    return *p;
+  std::println("⚠️  Unknown LFT2_STALE_POLICY \"{}\" — using {}", env,
+               stale::name(stale::default_policy));
+  return stale::default_policy;
+}
+
+// A position whose strategy is no longer recommended for its symbol
+struct StalePosition {
+  std::string symbol;
+  std::string strategy;
+  std::string client_order_id;
+  bool exited; // Sold this cycle, for any reason
+};
+
+// Write stale-positions.json, replacing the last cycle's, so the dashboard
+// shows only what's stale now
+void write_stale(stale::policy policy, std::span<const StalePosition> flagged) {
+  auto ofs = std::ofstream{paths::stale_positions};
+  if (!ofs) {
+    std::println("⚠️  Could not write {}", paths::stale_positions);
+    return;
+  }
+  ofs << std::format(
+      "{{\"schema_version\": {}, {}{}\"timestamp\": \"{:%Y-%m-%dT%H:%M:%SZ}\", "
+      "\"policy\": \"{}\", \"positions\": [",
+      paths::schema_version, version::json_key(), cycle::json_key(),
+      std::chrono::floor<std::chrono::seconds>(std::chrono::system_clock::now()),
+      stale::name(policy));
+  for (auto i = 0uz; i < flagged.size(); ++i) {
+    const auto &p = flagged[i];
+    ofs << std::format(
+        "{}\n  {{\"symbol\": \"{}\", \"strategy\": \"{}\", "
+        "\"client_order_id\": \"{}\", \"exited\": {}}}",
+        i ? "," : "", p.symbol, p.strategy, p.client_order_id,
+        p.exited ? "true" : "false");
+  }
+  ofs << (flagged.empty() ? "]}\n" : "\n]}\n");
+}
+
+int main(int argc, char *argv[]) {
+  auto args = std::span{argv, static_cast<std::size_t>(argc)};
+  if (version::requested(args | std::views::drop(1))) {
+    version::print("exits");
+    return 0;
+  }
+
   std::println("Low Frequency Trader v2 - Exit Module\n");
 
   // Open sell.fix immediately — heartbeat confirms exits ran, truncates stale
@@ -53,19 +141,40 @@ int main() {
     std::ofstream{paths::sell_fix} << fix::heartbeat("exits");
   }
 
-  // Load open positions
+  // Orders are signed for execute to verify when LFT2_ORDER_KEY is set
+  auto order_key = fix::order_key();
+
+  // Load open positions. With books.json each is its own book's to close:
+  // another book's would be judged on this book's bars
   auto positions = load_positions();
+  if (auto others = std::erase_if(
+          positions, [](const Position &p) { return p.book != paths::book; }))
+    std::println("[skip] {} position(s) held by other books", others);
+
+  // Positions whose strategy the latest backtest no longer recommends
+  auto stale_policy = load_stale_policy();
+  auto recommendations = load_recommendations();
+  auto flagged = std::vector<StalePosition>{};
 
   if (positions.empty()) {
     std::println("No open positions to check");
+    write_stale(stale_policy, flagged);
     return 0;
   }
 
+  std::println("Stale position policy: {}", stale::name(stale_policy));
+  if (!recommendations)
+    std::println("[skip] stale positions: no recommendations in {}",
+                 paths::strategies);
+
   std::println("Checking {} position(s) for exit signals...", positions.size());
 
   // Check if we need to liquidate everything (using latest bar timestamp)
   // We'll check per-position using the bar timestamp
 
+  // User rules with an exit expression close their own positions
+  auto rules = script::load_rules();
+
   // Collect sell orders
   auto sell_orders = std::vector<std::string>{};
   auto seq_num = 1;
@@ -74,6 +183,20 @@ int main() {
     std::println("\n📊 Checking {} ({} shares @ ${:.2f})", pos.symbol, pos.qty,
                  pos.avg_entry_price);
 
+    // Opened by a strategy no longer recommended for this symbol: its edge
+    // is stale
+    auto strategy = stale::order_strategy(pos.symbol, pos.client_order_id);
+    auto is_stale = recommendations && !strategy.empty() &&
+                    !stale::recommended(*recommendations, pos.symbol, strategy);
+    if (is_stale) {
+      std::println("   ⚠️  [WARNING] {} is no longer recommended for {}",
+                   strategy, pos.symbol);
+      flagged.push_back({.symbol = pos.symbol,
+                         .strategy = std::string{strategy},
+                         .client_order_id = pos.client_order_id,
+                         .exited = false});
+    }
+
     // Load latest bars for this symbol
     auto bars = load_bars(pos.symbol);
 
@@ -102,8 +225,10 @@ int main() {
     }
     // Check normal exit conditions using our exit logic
     else {
-      // Create position using shared params from params.h
-      auto levels = calculate_levels(pos.avg_entry_price, default_params);
+      // Exit with the levels the position was opened with, falling back to
+      // the shared defaults for orders without them
+      auto params = order_params(pos.client_order_id).value_or(default_params);
+      auto levels = calculate_levels(pos.avg_entry_price, params);
       auto mock_position = position{
           .entry_price = pos.avg_entry_price,
           .take_profit = levels.take_profit,
@@ -116,17 +241,32 @@ int main() {
         should_exit = true;
 
         // Determine which condition triggered
-        if (profit_pct >= default_params.take_profit_pct * 100.0)
+        if (profit_pct >= params.take_profit_pct * 100.0)
           exit_reason = "take_profit";
-        else if (profit_pct <= -default_params.stop_loss_pct * 100.0)
+        else if (profit_pct <= -params.stop_loss_pct * 100.0)
           exit_reason = "stop_loss";
         else
           exit_reason = "trailing_stop";
       }
+      // Opened by a user rule whose exit expression now fires
+      else if (auto rule = script::rule_for_order(rules, pos.symbol,
+                                                  pos.client_order_id);
+               rule && !rule->exit.empty() && bars.size() >= rule->lookback &&
+               script::fires(rule->exit, bars, rule->budget)) {
+        should_exit = true;
+        exit_reason = "rule_exit";
+      }
+      // Its strategy is stale and the policy is to get out
+      else if (is_stale && stale_policy == stale::policy::exit) {
+        should_exit = true;
+        exit_reason = "stale_strategy";
+      }
     }
 
     if (should_exit) {
       std::println("   ✅ Exit signal: {}", exit_reason);
+      if (is_stale)
+        flagged.back().exited = true;
 
       // Reuse original buy order's client_order_id so the sell is linked to the
       // buy Falls back to generating an ID if client_order_id is missing
@@ -137,9 +277,12 @@ int main() {
                     std::chrono::system_clock::now().time_since_epoch().count())
               : pos.client_order_id;
 
-      sell_orders.push_back(fix::new_order_single(
-          order_id, pos.symbol, fix::SIDE_SELL, static_cast<int>(pos.qty),
-          seq_num, fix::ORD_TYPE_MARKET, 0.0, exit_reason));
+      sell_orders.push_back(fix::sign(
+          fix::new_order_single(order_id, pos.symbol, fix::SIDE_SELL,
+                                static_cast<int>(pos.qty), seq_num,
+                                fix::ORD_TYPE_MARKET, 0.0, exit_reason,
+                                fix::expire_time()),
+          order_key));
       seq_num++;
     } else {
       std::println("   ⏭️  No exit signal - holding position");
@@ -152,8 +295,12 @@ int main() {
   for (const auto &order : sell_orders)
     ofs << order;
 
-  std::println("\n✓ Generated {} sell order(s) in docs/sell.fix",
-               sell_orders.size());
+  std::println("\n✓ Generated {} sell order(s) in docs/sell.fix{}",
+               sell_orders.size(), order_key.empty() ? "" : " (signed)");
+
+  write_stale(stale_policy, flagged);
+  std::println("✓ Wrote {} ({} stale position(s))", paths::stale_positions,
+               flagged.size());
 
   return 0;
 }
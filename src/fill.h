@@ -0,0 +1,156 @@
+#pragma once
+#include "bar.h"
+#include <algorithm>
+#include <cmath>
+#include <cstddef>
+#include <cstdint>
+#include <string_view>
+
+// Fill model for the backtest. By default a signal on one bar's close fills in
+// full at the next bar's open; live market orders instead arrive some seconds
+// later and occasionally fill only in part. Configured by fill.json at the
+// repo root, e.g.
+//   {"latency_seconds": 20, "partial_probability": 0.1, "partial_fraction": 0.5}
+//
+// Partial fills scale every trade by the expected filled share unless
+// "sampled": 1 is set, when each trade draws whether it fills in part from a
+// generator seeded by backtest --seed. Either way a run is reproducible.
+
+namespace fill {
+
+constexpr auto bar_seconds = 300.0; // 5-minute bars
+
+struct model {
+  double latency_seconds = 0.0;     // Signal to fill
+  double partial_probability = 0.0; // Chance an order fills only in part
+  double partial_fraction = 1.0;    // Share filled when it does
+  bool sampled = false;             // Draw partials per trade, not expected
+  std::uint64_t seed = 0;           // Seeds the draws when sampled
+};
+
+// Whole bars skipped before the fill bar
+constexpr std::size_t delay_bars(const model &m) {
+  return static_cast<std::size_t>(std::max(m.latency_seconds, 0.0) /
+                                  bar_seconds);
+}
+
+// Fill price on the fill bar: the open, drifted towards the close by the
+// latency left over after the whole bars
+constexpr double price(const bar &b, const model &m) {
+  auto remainder = std::max(m.latency_seconds, 0.0) -
+                   static_cast<double>(delay_bars(m)) * bar_seconds;
+  return b.open + (b.close - b.open) * (remainder / bar_seconds);
+}
+
+// Expected share of a unit stake that fills. Deterministic so backtests stay
+// reproducible — profits are scaled by it rather than sampled.
+constexpr double expected_fraction(const model &m) {
+  auto p = std::clamp(m.partial_probability, 0.0, 1.0);
+  auto f = std::clamp(m.partial_fraction, 0.0, 1.0);
+  return 1.0 - p * (1.0 - f);
+}
+
+// Share of a unit stake that fills for one trade, given a uniform draw in
+// [0, 1). Unsampled models ignore the draw.
+constexpr double fraction(const model &m, double draw) {
+  if (!m.sampled)
+    return expected_fraction(m);
+  return draw < std::clamp(m.partial_probability, 0.0, 1.0)
+             ? std::clamp(m.partial_fraction, 0.0, 1.0)
+             : 1.0;
+}
+
+// Derive an independent seed for one stream (a symbol, then a strategy) so
+// draws don't depend on the order streams run in. FNV-1a over the name.
+constexpr std::uint64_t stream(std::uint64_t seed, std::string_view name) {
+  auto h = 0xcbf29ce484222325ull ^ seed;
+  for (auto c : name) {
+    h ^= static_cast<unsigned char>(c);
+    h *= 0x100000001b3ull;
+  }
+  return h;
+}
+
+// SplitMix64: small, fast and fully specified, so the same seed gives the
+// same draws with any compiler or standard library (unlike std::
+// distributions).
+struct generator {
+  std::uint64_t state;
+
+  constexpr std::uint64_t next() {
+    auto z = (state += 0x9e3779b97f4a7c15ull);
+    z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9ull;
+    z = (z ^ (z >> 27)) * 0x94d049bb133111ebull;
+    return z ^ (z >> 31);
+  }
+
+  // Uniform in [0, 1) from the top 53 bits
+  constexpr double uniform() {
+    return static_cast<double>(next() >> 11) * 0x1.0p-53;
+  }
+};
+
+// Unit tests
+namespace {
+constexpr auto test_bar = bar{.close = 102.0,
+                              .high = 103.0,
+                              .low = 99.0,
+                              .open = 100.0,
+                              .vwap = 101.0,
+                              .volume = 1000,
+                              .num_trades = 50,
+                              .timestamp = "2025-01-01T15:00:00Z"};
+
+// Default: instant full fill at the open
+static_assert(delay_bars(model{}) == 0);
+static_assert(price(test_bar, model{}) == 100.0);
+static_assert(expected_fraction(model{}) == 1.0);
+
+// Half a bar of latency fills halfway from open to close
+static_assert(price(test_bar, model{.latency_seconds = 150.0}) == 101.0);
+
+// A bar and a half skips one bar, then drifts half way
+static_assert(delay_bars(model{.latency_seconds = 450.0}) == 1);
+static_assert(price(test_bar, model{.latency_seconds = 450.0}) == 101.0);
+
+// Negative latency is treated as none
+static_assert(price(test_bar, model{.latency_seconds = -10.0}) == 100.0);
+
+// 20% of orders filling 50% gives 90% expected
+static_assert(std::abs(expected_fraction(model{.partial_probability = 0.2,
+                                               .partial_fraction = 0.5}) -
+                       0.9) < 1e-12);
+
+// Out-of-range settings are clamped
+static_assert(expected_fraction(model{.partial_probability = 2.0,
+                                      .partial_fraction = -1.0}) == 0.0);
+
+// Unsampled models always scale by the expectation
+static_assert(fraction(model{.partial_probability = 0.5,
+                             .partial_fraction = 0.5},
+                       0.0) == 0.75);
+
+// Sampled: a draw under the probability fills in part, otherwise in full
+constexpr auto sampled = model{
+    .partial_probability = 0.25, .partial_fraction = 0.4, .sampled = true};
+static_assert(fraction(sampled, 0.1) == 0.4);
+static_assert(fraction(sampled, 0.25) == 1.0);
+
+// The same seed gives the same draws; streams differ by name
+constexpr double second_draw(std::uint64_t seed) {
+  auto g = generator{seed};
+  g.uniform();
+  return g.uniform();
+}
+static_assert(second_draw(42) == second_draw(42));
+static_assert(second_draw(42) != second_draw(43));
+static_assert(second_draw(42) >= 0.0 && second_draw(42) < 1.0);
+static_assert(stream(42, "AAPL") == stream(42, "AAPL"));
+static_assert(stream(42, "AAPL") != stream(42, "MSFT"));
+static_assert(stream(42, "AAPL") != stream(7, "AAPL"));
+
+// SplitMix64 reference value for state 0
+static_assert(generator{0}.next() == 0xe220a8397b1dcdafull);
+} // namespace
+
+} // namespace fill
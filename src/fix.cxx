@@ -1,17 +1,48 @@
+#include "cycle.h"
 #include "fix.h"
+#include "version.h"
+#include <algorithm>
 #include <chrono>
+#include <cstdlib>
 #include <format>
 
 namespace fix {
 
-// Heartbeat with UTC timestamp (tag 52) and free-text status (tag 58).
-// Always written as seq_num=0 to distinguish from order messages.
+// Heartbeat with UTC timestamp (tag 52), free-text status (tag 58), the
+// build of the module writing it (tag 1604) and the pipeline cycle (tag
+// 5001), which execute applies to every order that follows. Always written
+// as seq_num=0 to distinguish from order messages.
 // NOT constexpr because it uses std::chrono::system_clock::now()
 std::string heartbeat(std::string_view text) {
   auto now = std::chrono::system_clock::now();
   auto ts = std::format("{:%Y%m%d-%H:%M:%S}", now);
-  return build(HEARTBEAT,
-               std::format("{}={}|{}={}|", SENDING_TIME, ts, TEXT, text), 0);
+  auto body = std::format("{}={}|{}={}|", SENDING_TIME, ts, TEXT, text);
+  if (!version::build.empty())
+    body += std::format("{}={}|", APPL_SYSTEM_VERSION, version::build);
+  if (auto c = cycle::id(); !c.empty())
+    body += std::format("{}={}|", CYCLE_ID, c);
+  return build(HEARTBEAT, body, 0);
+}
+
+std::string timestamp(std::chrono::sys_seconds t) {
+  return std::format("{:%Y%m%d-%H:%M:%S}", t);
+}
+
+std::string expire_time() {
+  auto now = std::chrono::floor<std::chrono::seconds>(
+      std::chrono::system_clock::now());
+  return timestamp(valid_until(now));
+}
+
+std::string expire_time(std::chrono::sys_seconds close) {
+  auto now = std::chrono::floor<std::chrono::seconds>(
+      std::chrono::system_clock::now());
+  return timestamp(std::min(valid_until(now), close));
+}
+
+std::string order_key() {
+  auto key = std::getenv("LFT2_ORDER_KEY");
+  return key ? key : "";
 }
 
 } // namespace fix
@@ -1,4 +1,6 @@
 #pragma once
+#include "sha256.h"
+#include <chrono>
 #include <format>
 #include <string>
 #include <string_view>
@@ -31,7 +33,12 @@ constexpr auto ORD_TYPE = 40;       // Order type (1=market, 2=limit)
 constexpr auto PRICE = 44;          // Limit price
 constexpr auto TIME_IN_FORCE = 59;  // Time validity (0=day, 3=IOC, 4=FOK)
 constexpr auto TEXT = 58;           // Free text comment
+constexpr auto EXPIRE_TIME = 126;   // UTC time after which the order is void
+constexpr auto EFFECTIVE_TIME = 168; // UTC time before which it isn't valid
 constexpr auto CHECKSUM = 10;       // Message checksum
+constexpr auto SIGNATURE = 89;      // HMAC-SHA256 of the message, see sign()
+constexpr auto APPL_SYSTEM_VERSION = 1604; // Build that wrote the file
+constexpr auto CYCLE_ID = 5001; // User-defined: pipeline cycle, see cycle.h
 
 // Side values
 constexpr auto SIDE_BUY = "1";
@@ -65,13 +72,36 @@ constexpr std::string build(std::string_view msg_type, std::string_view body,
                      checksum);
 }
 
+// Order intents are valid until the end of the wall-clock bar they were
+// generated in, plus a grace period for execute's rate-limit retries. The
+// bar is the pipeline's, not the data's, which may lag by 15 minutes.
+constexpr auto BAR_LENGTH = std::chrono::minutes{5};
+constexpr auto INTENT_GRACE = std::chrono::minutes{2};
+
+constexpr std::chrono::sys_seconds valid_until(std::chrono::sys_seconds now) {
+  auto bar_start = now - now.time_since_epoch() % BAR_LENGTH;
+  return bar_start + BAR_LENGTH + INTENT_GRACE;
+}
+
+namespace {
+using namespace std::chrono_literals;
+constexpr auto day = std::chrono::sys_days{std::chrono::January / 2 / 2026};
+// 14:31:10 is in the 14:30 bar, so its intents lapse at 14:37
+static_assert(valid_until(day + 14h + 31min + 10s) == day + 14h + 37min);
+static_assert(valid_until(day + 14h + 35min) == day + 14h + 42min);
+} // namespace
+
 // Build a NewOrderSingle (D) FIX message for a market or limit order.
-// price > 0 adds tag 44; text non-empty adds tag 58.
+// price > 0 adds tag 44; text non-empty adds tag 58; expire_time non-empty
+// (UTC, YYYYMMDD-HH:MM:SS) adds tag 126, after which execute refuses it;
+// effective_time likewise adds tag 168, before which execute refuses it.
 constexpr std::string
 new_order_single(std::string_view order_id, std::string_view symbol,
                  std::string_view side, int quantity, int seq_num = 1,
                  std::string_view ord_type = ORD_TYPE_MARKET,
-                 double price = 0.0, std::string_view text = "") {
+                 double price = 0.0, std::string_view text = "",
+                 std::string_view expire_time = "",
+                 std::string_view effective_time = "") {
   auto body = std::format("{}={}|{}=1|{}={}|{}={}|{}={}|{}={}|{}={}|",
                           CL_ORD_ID, order_id, HANDL_INST, SYMBOL, symbol, SIDE,
                           side, ORDER_QTY, quantity, ORD_TYPE, ord_type,
@@ -83,14 +113,52 @@ new_order_single(std::string_view order_id, std::string_view symbol,
   if (!text.empty())
     body += std::format("{}={}|", TEXT, text);
 
+  if (!expire_time.empty())
+    body += std::format("{}={}|", EXPIRE_TIME, expire_time);
+
+  if (!effective_time.empty())
+    body += std::format("{}={}|", EFFECTIVE_TIME, effective_time);
+
   return build(NEW_ORDER_SINGLE, body, seq_num);
 }
 
+// Sign a message with the pipeline's order key: tag 89, appended as the last
+// field, is the HMAC-SHA256 in hex of every byte before it. Execute verifies
+// it, so an order edited or corrupted on disk between stages is refused. An
+// empty key leaves the message unsigned.
+constexpr std::string sign(std::string_view message, std::string_view key) {
+  if (key.empty())
+    return std::string{message};
+  auto signed_part = message.substr(0, message.find_last_not_of('\n') + 1);
+  return std::string{signed_part} + "89=" + sha256::hmac(key, signed_part) +
+         "|\n";
+}
+
+namespace {
+static_assert(sign("35=D|\n", "") == "35=D|\n");
+static_assert(
+    sign("8=FIX.5.0SP2|35=D|55=AAPL|10=001|\n", "k") ==
+    "8=FIX.5.0SP2|35=D|55=AAPL|10=001|89="
+    "14b619f076761cad29576804f18c5965ad7f1abdffdcedab17b6afe92f89a2ca|\n");
+} // namespace
+
+// The order key from LFT2_ORDER_KEY, or empty to leave orders unsigned
+std::string order_key();
+
 // Heartbeat message — prepended to every .fix file so execute can confirm
 // the C++ binary ran successfully even when there are no orders.
 // NOT constexpr because it uses std::chrono::system_clock::now()
 std::string heartbeat(std::string_view);
 
+// Tag 126 value for an intent generated now, see valid_until
+std::string expire_time();
+
+// The same, but no later than close: the end of the strategy's window
+std::string expire_time(std::chrono::sys_seconds close);
+
+// A UTC time in the FIX timestamp format tags 126 and 168 use
+std::string timestamp(std::chrono::sys_seconds t);
+
 // NOTE: build() and new_order_single() are marked constexpr for future C++26
 // compliance, but std::format isn't fully constexpr in gcc-15 yet. The
 // functions work correctly at runtime and will become compile-time evaluable
@@ -0,0 +1,340 @@
+#pragma once
+#include <array>
+#include <cstdint>
+#include <optional>
+#include <string>
+#include <string_view>
+
+// gzip (RFC 1952) decompression, with the DEFLATE (RFC 1951) it wraps, for
+// the {SYMBOL}.json.gz bar files fetch writes with -gzip. Written out here,
+// like sha256.h, so the modules build without zlib. Only inflate: nothing in
+// C++ writes compressed files. Huffman codes are decoded a bit at a time
+// (canonical decoding, as in zlib's puff), which is slow beside zlib but
+// only ever sees one bar file at a time.
+
+namespace gzip {
+
+namespace detail {
+
+// Bits come least significant first, as DEFLATE packs them
+struct bit_reader {
+  std::string_view in;
+  std::size_t pos{};
+  std::uint32_t bits{};
+  int count{};
+  bool overrun{};
+
+  constexpr std::uint32_t take(int n) {
+    while (count < n) {
+      if (pos == in.size()) {
+        overrun = true;
+        return 0;
+      }
+      bits |= std::uint32_t{static_cast<std::uint8_t>(in[pos++])} << count;
+      count += 8;
+    }
+    auto value = bits & ((std::uint32_t{1} << n) - 1);
+    bits >>= n;
+    count -= n;
+    return value;
+  }
+
+  // Drop what's left of the current byte, for a stored block
+  constexpr void align() {
+    bits = 0;
+    count = 0;
+  }
+};
+
+// Canonical Huffman code: how many codes there are of each length, and the
+// symbols in code order
+struct huffman {
+  std::array<std::uint16_t, 16> count{};
+  std::array<std::uint16_t, 288> symbol{};
+};
+
+// False for lengths that over-subscribe the code space
+constexpr bool build(huffman &h, const std::uint8_t *lengths, int n) {
+  h.count.fill(0);
+  for (auto i = 0; i < n; ++i)
+    ++h.count[lengths[i]];
+  if (h.count[0] == n)
+    return true;
+
+  auto left = 1;
+  for (auto len = 1; len < 16; ++len) {
+    left = left * 2 - h.count[len];
+    if (left < 0)
+      return false;
+  }
+
+  auto offsets = std::array<std::uint16_t, 16>{};
+  for (auto len = 1; len < 15; ++len)
+    offsets[len + 1] = offsets[len] + h.count[len];
+  for (auto i = 0; i < n; ++i)
+    if (lengths[i] != 0)
+      h.symbol[offsets[lengths[i]]++] = static_cast<std::uint16_t>(i);
+  return true;
+}
+
+// The next symbol, or -1 for a code that isn't in the table
+constexpr int decode(bit_reader &br, const huffman &h) {
+  auto code = 0, first = 0, index = 0;
+  for (auto len = 1; len < 16; ++len) {
+    code |= static_cast<int>(br.take(1));
+    auto count = int{h.count[len]};
+    if (code - count < first)
+      return h.symbol[index + (code - first)];
+    index += count;
+    first = (first + count) << 1;
+    code <<= 1;
+  }
+  return -1;
+}
+
+constexpr auto length_base = std::array<std::uint16_t, 29>{
+    3,  4,  5,  6,  7,  8,  9,  10, 11,  13,  15,  17,  19,  23, 27,
+    31, 35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258};
+constexpr auto length_extra = std::array<std::uint8_t, 29>{
+    0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2,
+    2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0};
+constexpr auto distance_base = std::array<std::uint16_t, 30>{
+    1,   2,   3,   4,   5,   7,    9,    13,   17,   25,
+    33,  49,  65,  97,  129, 193,  257,  385,  513,  769,
+    1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577};
+constexpr auto distance_extra = std::array<std::uint8_t, 30>{
+    0, 0, 0, 0, 1, 1, 2, 2,  3,  3,  4,  4,  5,  5,  6,
+    6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13};
+
+// One compressed block's literals and back references
+constexpr bool codes(bit_reader &br, std::string &out, const huffman &lengths,
+                     const huffman &distances) {
+  while (true) {
+    auto symbol = decode(br, lengths);
+    if (symbol < 0 || br.overrun)
+      return false;
+    if (symbol < 256) {
+      out += static_cast<char>(symbol);
+      continue;
+    }
+    if (symbol == 256)
+      return true;
+
+    symbol -= 257;
+    if (symbol >= 29)
+      return false;
+    auto len = length_base[symbol] + br.take(length_extra[symbol]);
+    auto d = decode(br, distances);
+    if (d < 0 || d >= 30)
+      return false;
+    auto distance = distance_base[d] + br.take(distance_extra[d]);
+    if (br.overrun || distance > out.size())
+      return false;
+    for (auto i = 0u; i < len; ++i)
+      out += out[out.size() - distance];
+  }
+}
+
+constexpr bool stored(bit_reader &br, std::string &out) {
+  br.align();
+  if (br.in.size() - br.pos < 4)
+    return false;
+  auto byte = [&](std::size_t i) {
+    return std::uint32_t{static_cast<std::uint8_t>(br.in[br.pos + i])};
+  };
+  auto len = byte(0) | byte(1) << 8;
+  auto complement = byte(2) | byte(3) << 8;
+  if (len != (~complement & 0xffff) || br.in.size() - br.pos - 4 < len)
+    return false;
+  out += br.in.substr(br.pos + 4, len);
+  br.pos += 4 + len;
+  return true;
+}
+
+constexpr bool fixed(bit_reader &br, std::string &out) {
+  auto lengths = std::array<std::uint8_t, 288>{};
+  for (auto i = 0uz; i < lengths.size(); ++i)
+    lengths[i] = i < 144 ? 8 : i < 256 ? 9 : i < 280 ? 7 : 8;
+  auto distances = std::array<std::uint8_t, 30>{};
+  distances.fill(5);
+
+  auto lencode = huffman{}, distcode = huffman{};
+  build(lencode, lengths.data(), 288);
+  build(distcode, distances.data(), 30);
+  return codes(br, out, lencode, distcode);
+}
+
+constexpr bool dynamic(bit_reader &br, std::string &out) {
+  auto nlen = static_cast<int>(br.take(5)) + 257;
+  auto ndist = static_cast<int>(br.take(5)) + 1;
+  auto ncode = static_cast<int>(br.take(4)) + 4;
+  if (br.overrun || nlen > 286 || ndist > 30)
+    return false;
+
+  // Code lengths for the code length alphabet, in this order
+  constexpr auto order = std::array<std::uint8_t, 19>{
+      16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15};
+  auto lengths = std::array<std::uint8_t, 320>{};
+  for (auto i = 0; i < ncode; ++i)
+    lengths[order[i]] = static_cast<std::uint8_t>(br.take(3));
+  auto lencode = huffman{};
+  if (!build(lencode, lengths.data(), 19))
+    return false;
+
+  // Literal/length and distance code lengths, run-length encoded
+  lengths.fill(0);
+  for (auto i = 0; i < nlen + ndist;) {
+    auto symbol = decode(br, lencode);
+    if (symbol < 0 || br.overrun)
+      return false;
+    if (symbol < 16) {
+      lengths[i++] = static_cast<std::uint8_t>(symbol);
+      continue;
+    }
+    auto repeat = std::uint8_t{};
+    auto times = 0u;
+    if (symbol == 16) {
+      if (i == 0)
+        return false;
+      repeat = lengths[i - 1];
+      times = 3 + br.take(2);
+    } else if (symbol == 17)
+      times = 3 + br.take(3);
+    else
+      times = 11 + br.take(7);
+    if (i + static_cast<int>(times) > nlen + ndist)
+      return false;
+    while (times-- > 0)
+      lengths[i++] = repeat;
+  }
+  if (lengths[256] == 0)
+    return false;
+
+  auto distcode = huffman{};
+  if (!build(lencode, lengths.data(), nlen) ||
+      !build(distcode, lengths.data() + nlen, ndist))
+    return false;
+  return codes(br, out, lencode, distcode);
+}
+
+// CRC-32 (IEEE), as the gzip trailer carries
+constexpr auto crc_table = [] {
+  auto table = std::array<std::uint32_t, 256>{};
+  for (auto n = 0u; n < 256; ++n) {
+    auto c = n;
+    for (auto k = 0; k < 8; ++k)
+      c = c & 1 ? 0xedb88320 ^ (c >> 1) : c >> 1;
+    table[n] = c;
+  }
+  return table;
+}();
+
+constexpr std::uint32_t crc32(std::string_view data) {
+  auto c = ~std::uint32_t{};
+  for (auto byte : data)
+    c = crc_table[(c ^ static_cast<std::uint8_t>(byte)) & 0xff] ^ (c >> 8);
+  return ~c;
+}
+
+} // namespace detail
+
+// True for data that starts with the gzip magic number
+constexpr bool is_gzip(std::string_view data) {
+  return data.size() >= 2 && data[0] == '\x1f' && data[1] == '\x8b';
+}
+
+// The decompressed contents of a single-member gzip file, or nullopt when
+// it's truncated, corrupt or fails its CRC
+constexpr std::optional<std::string> decompress(std::string_view in) {
+  if (in.size() < 18 || !is_gzip(in) || in[2] != 8)
+    return std::nullopt;
+
+  // Optional header fields: extra, name, comment, header CRC
+  auto flags = static_cast<std::uint8_t>(in[3]);
+  auto pos = 10uz;
+  if (flags & 4) {
+    if (in.size() < pos + 2)
+      return std::nullopt;
+    pos += 2 + (static_cast<std::uint8_t>(in[pos]) |
+                static_cast<std::uint8_t>(in[pos + 1]) << 8);
+  }
+  for (auto field : {8, 16})
+    if (flags & field) {
+      auto end = in.find('\0', pos);
+      if (end == std::string_view::npos)
+        return std::nullopt;
+      pos = end + 1;
+    }
+  if (flags & 2)
+    pos += 2;
+  if (pos >= in.size())
+    return std::nullopt;
+
+  auto br = detail::bit_reader{.in = in, .pos = pos};
+  auto out = std::string{};
+  for (auto last = 0u; last == 0;) {
+    last = br.take(1);
+    auto ok = false;
+    switch (br.take(2)) {
+    case 0:
+      ok = detail::stored(br, out);
+      break;
+    case 1:
+      ok = detail::fixed(br, out);
+      break;
+    case 2:
+      ok = detail::dynamic(br, out);
+      break;
+    }
+    if (!ok || br.overrun)
+      return std::nullopt;
+  }
+
+  // Trailer: CRC-32 then length mod 2^32, both little-endian
+  if (in.size() - br.pos < 8)
+    return std::nullopt;
+  auto word = [&](std::size_t at) {
+    auto w = std::uint32_t{};
+    for (auto i = 0; i < 4; ++i)
+      w |= std::uint32_t{static_cast<std::uint8_t>(in[at + i])} << (8 * i);
+    return w;
+  };
+  if (word(br.pos) != detail::crc32(out) ||
+      word(br.pos + 4) != static_cast<std::uint32_t>(out.size()))
+    return std::nullopt;
+  return out;
+}
+
+// Test vectors from Python's gzip module: one block of each type, and a
+// flipped CRC bit
+namespace detail {
+using namespace std::string_view_literals;
+constexpr auto fixed_block =
+    "\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\x03\xcb\x48\xcd\xc9\xc9\xd7"
+    "\x51\xc8\x40\xa2\x00\x9f\xa1\xca\x09\x13\x00\x00\x00"sv;
+constexpr auto stored_block =
+    "\x1f\x8b\x08\x00\x00\x00\x00\x00\x04\x03\x01\x06\x00\xf9\xff\x73"
+    "\x74\x6f\x72\x65\x64\x0b\xf9\x43\x56\x06\x00\x00\x00"sv;
+constexpr auto dynamic_block =
+    "\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\x03\x75\xcc\xbb\x0d\x80\x30"
+    "\x10\x04\xd1\x5e\x2e\xb6\xa5\xfb\x12\xb8\x0e\x22\x10\x11\x2d\x90"
+    "\x21\xf7\x0e\xe1\x06\x5e\x69\xc2\xd1\x3b\x5f\x79\x64\x88\xab\x6f"
+    "\x5d\xa3\x9b\xee\x16\x43\xf5\xef\x90\x26\xb7\x0c\x57\x9d\x6d\x39"
+    "\x15\x4e\xb5\x9e\x0c\x25\x23\x92\xa1\x64\x44\x72\x94\x9c\x48\x8e"
+    "\x92\x13\x29\x50\x0a\x22\x05\x4a\x41\xa4\x44\x29\x89\x94\x28\x25"
+    "\x91\x0a\xa5\x22\x52\xa1\x54\x35\xaf\x0f\x70\x7d\x77\x8b\xbd\x01"
+    "\x00\x00"sv;
+constexpr auto bad_crc =
+    "\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\x03\xcb\x48\xcd\xc9\xc9\xd7"
+    "\x51\xc8\x40\xa2\x00\x9e\xa1\xca\x09\x13\x00\x00\x00"sv;
+} // namespace detail
+
+static_assert(decompress(detail::fixed_block) == "hello, hello, hello");
+static_assert(decompress(detail::stored_block) == "stored");
+static_assert(decompress(detail::dynamic_block)->size() == 445);
+static_assert(decompress(detail::dynamic_block)->ends_with(
+    R"({"t":"2026-03-10T13:55:00Z","c":255}])"));
+static_assert(!decompress(detail::bad_crc));
+static_assert(!decompress("not gzip"));
+
+} // namespace gzip
@@ -1,5 +1,7 @@
 #pragma once
 #include <chrono>
+#include <optional>
+#include <string>
 #include <string_view>
 
 // NYSE market hours and trading rules.
@@ -146,4 +148,91 @@ static_assert(risk_off("2026-02-16T20:15:00Z"));  // 15:15 ET - last 45 minutes
 static_assert(!risk_off("2026-02-16T21:00:00Z")); // 16:00 ET - market closed
 static_assert(!risk_off("2026-02-16T13:00:00Z")); // 08:00 ET - pre-market
 
+// ============================================================
+// Execution windows
+// ============================================================
+
+// Time of day, in ET, a strategy may enter. Intraday styles have their hours:
+// a breakout wants the morning's range expansion, mean reversion the calmer
+// middle of the day. The default is the whole session, so risk_off alone
+// decides.
+struct window {
+  minutes open = SESSION_OPEN_ET;
+  minutes close = SESSION_CLOSE_ET;
+
+  constexpr bool operator==(const window &) const = default;
+};
+
+constexpr auto whole_session = window{};
+
+// True if timestamp falls in the window: open inclusive, close exclusive
+constexpr bool in_window(const window &w, std::string_view timestamp) {
+  auto t = ny_minutes(timestamp);
+  return t >= w.open && t < w.close;
+}
+// 15:00 UTC in February is 10:00 EST, in July 11:00 EDT
+static_assert(in_window({10h, 11h}, "2026-02-16T15:00:00Z"));
+static_assert(!in_window({10h, 11h}, "2026-07-01T15:00:00Z"));
+static_assert(!in_window({10h, 11h}, "2026-02-16T14:59:00Z"));
+static_assert(in_window(whole_session, "2026-02-16T14:30:00Z"));
+static_assert(!in_window(whole_session, "bad"));
+
+// Parse "HH:MM-HH:MM" (ET). The window must open before it closes and lie
+// within the session; anything else is nullopt.
+constexpr std::optional<window> parse_window(std::string_view s) {
+  if (s.size() != 11 || s[2] != ':' || s[5] != '-' || s[8] != ':')
+    return std::nullopt;
+  auto oh = parse2(s.substr(0, 2));
+  auto om = parse2(s.substr(3, 2));
+  auto ch = parse2(s.substr(6, 2));
+  auto cm = parse2(s.substr(9, 2));
+  if (oh < 0 || om < 0 || ch < 0 || cm < 0 || om > 59 || cm > 59)
+    return std::nullopt;
+  auto w = window{minutes{oh * 60 + om}, minutes{ch * 60 + cm}};
+  if (w.open >= w.close || w.open < SESSION_OPEN_ET ||
+      w.close > SESSION_CLOSE_ET)
+    return std::nullopt;
+  return w;
+}
+static_assert(parse_window("10:00-15:30") == window{10h, 15h + 30min});
+static_assert(parse_window("09:30-16:00") == whole_session);
+static_assert(!parse_window("11:00-10:00")); // closes before it opens
+static_assert(!parse_window("08:00-10:00")); // pre-market
+static_assert(!parse_window("10:00-16:30")); // after the close
+static_assert(!parse_window("10:60-11:00"));
+static_assert(!parse_window("10:00"));
+
+// "HH:MM-HH:MM" for display, as parse_window reads it
+constexpr std::string window_text(const window &w) {
+  auto out = std::string{};
+  for (auto m : {w.open, w.close}) {
+    if (!out.empty())
+      out += '-';
+    auto h = m.count() / 60;
+    auto mm = m.count() % 60;
+    out += static_cast<char>('0' + h / 10);
+    out += static_cast<char>('0' + h % 10);
+    out += ':';
+    out += static_cast<char>('0' + mm / 10);
+    out += static_cast<char>('0' + mm % 10);
+  }
+  return out;
+}
+static_assert(window_text({10h, 15h + 30min}) == "10:00-15:30");
+static_assert(window_text(whole_session) == "09:30-16:00");
+
+// The UTC time of et (minutes after midnight in New York) on day, for
+// writing a window's bounds into an order
+constexpr std::chrono::sys_seconds utc_time(std::chrono::sys_days day,
+                                            minutes et) {
+  auto month = static_cast<unsigned>(std::chrono::year_month_day{day}.month());
+  return day + et - utc_offset(month);
+}
+static_assert(utc_time(std::chrono::sys_days{std::chrono::February / 16 / 2026},
+                       10h) ==
+              std::chrono::sys_days{std::chrono::February / 16 / 2026} + 15h);
+static_assert(utc_time(std::chrono::sys_days{std::chrono::July / 1 / 2026},
+                       10h) ==
+              std::chrono::sys_days{std::chrono::July / 1 / 2026} + 14h);
+
 } // namespace market
@@ -1,4 +1,7 @@
 #pragma once
+#include <cstdint>
+#include <optional>
+#include <string_view>
 
 // Trading parameters for position management
 struct trading_params {
@@ -27,6 +30,66 @@ constexpr auto calculate_levels(double entry_price, trading_params params) {
                     entry_price * (1.0 - params.trailing_stop_pct)};
 }
 
+// Identify an exact parameterisation: FNV-1a over the strategy name, its
+// indicator parameters and the exit levels in basis points. Entries writes
+// it into the client_order_id so fills can be attributed to the parameters
+// that produced them.
+constexpr std::uint32_t params_hash(std::string_view strategy,
+                                    std::string_view indicators,
+                                    trading_params params) {
+  auto hash = std::uint32_t{2166136261u};
+  auto mix = [&](std::uint8_t byte) {
+    hash ^= byte;
+    hash *= 16777619u;
+  };
+  for (auto text : {strategy, std::string_view{"|"}, indicators})
+    for (auto c : text)
+      mix(static_cast<std::uint8_t>(c));
+  for (auto pct : {params.take_profit_pct, params.stop_loss_pct,
+                   params.trailing_stop_pct}) {
+    auto bp = static_cast<std::uint32_t>(pct * 10000.0 + 0.5);
+    for (auto shift = 0; shift < 32; shift += 8)
+      mix(static_cast<std::uint8_t>(bp >> shift));
+  }
+  return hash;
+}
+
+// Recover the exit levels entries encoded in a client_order_id:
+// {symbol}_{strategy}-v{N}_tp1.25_sl1.25_tsl1.00_p{hash}_{timestamp}[_c{cycle}],
+// percentages to two places. Empty when any level is missing, e.g. orders placed by hand.
+constexpr std::optional<trading_params> order_params(std::string_view id) {
+  auto level = [&](std::string_view tag) -> std::optional<double> {
+    auto pos = id.rfind(tag);
+    if (pos == std::string_view::npos)
+      return std::nullopt;
+    auto s = id.substr(pos + tag.size());
+    auto value = 0.0;
+    auto scale = 0.0;
+    auto digits = 0;
+    for (auto c : s) {
+      if (c == '.' && scale == 0.0)
+        scale = 1.0;
+      else if (c >= '0' && c <= '9') {
+        value = value * 10.0 + (c - '0');
+        scale *= 10.0;
+        ++digits;
+      } else
+        break;
+    }
+    if (digits == 0)
+      return std::nullopt;
+    return (scale > 0.0 ? value / scale : value) / 100.0;
+  };
+
+  auto tp = level("_tp");
+  auto sl = level("_sl");
+  auto tsl = level("_tsl");
+  if (!tp || !sl || !tsl)
+    return std::nullopt;
+  return trading_params{
+      .take_profit_pct = *tp, .stop_loss_pct = *sl, .trailing_stop_pct = *tsl};
+}
+
 // Unit tests
 namespace {
 
@@ -68,4 +131,38 @@ static_assert(
     }(),
     "Custom params (20%, 10%, 5%) should give TP=240, SL=180, trailing=190 for "
     "entry=200");
+
+// Test: hash is stable and sensitive to every input
+static_assert(params_hash("momentum", "bars=4", default_params) ==
+              params_hash("momentum", "bars=4", default_params));
+static_assert(params_hash("momentum", "bars=4", default_params) !=
+              params_hash("momentum", "bars=5", default_params));
+static_assert(params_hash("momentum", "bars=4", default_params) !=
+              params_hash("gap_fill", "bars=4", default_params));
+static_assert(params_hash("momentum", "bars=4", default_params) !=
+              params_hash("momentum", "bars=4",
+                          trading_params{0.02, 0.0125, 0.01}));
+
+// Test: levels round-trip through a client_order_id
+static_assert([] {
+  auto p = order_params("AAPL_mean_reversion_tp1.25_sl2.50_tsl1.00_pdeadbeef_"
+                        "20260218T143000");
+  return p && p->take_profit_pct == 1.25 / 100.0 &&
+         p->stop_loss_pct == 2.50 / 100.0 &&
+         p->trailing_stop_pct == 1.00 / 100.0;
+}());
+
+// Test: the strategy version doesn't disturb the levels
+static_assert(order_params("AAPL_mean_reversion-v2_tp1.25_sl2.50_tsl1.00_"
+                           "pdeadbeef_20260218T143000")
+                  ->stop_loss_pct == 2.50 / 100.0);
+
+// Test: nor does the pipeline cycle
+static_assert(order_params("AAPL_mean_reversion-v2_tp1.25_sl2.50_tsl1.00_"
+                           "pdeadbeef_20260218T143000_cgh42")
+                  ->trailing_stop_pct == 1.00 / 100.0);
+
+// Test: IDs without levels yield nothing
+static_assert(!order_params("EXIT_AAPL_1_123456"));
+static_assert(!order_params("AAPL_price_dip_tp_sl_tsl_20260218T143000"));
 } // namespace
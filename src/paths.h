@@ -1,21 +1,69 @@
 #pragma once
+#include <cstdlib>
+#include <print>
 #include <string>
 #include <string_view>
 
 // Centralised file paths for the JSON pipeline.
 // All modules read/write to docs/ so the Svelte dashboard and GitHub Pages
-// deployment can pick them up.
+// deployment can pick them up. A book set in LFT2_BOOK (see books.json) keeps
+// its own files under docs/books/{book}/ instead, so books with different
+// timeframes and strategies can run side by side.
 
 namespace paths {
 
-using namespace std::string_view_literals;
+constexpr auto max_book = 16uz;
+
+// Lower-case letters, digits and '-', up to max_book: safe as a directory
+// name, can't climb out of docs/books/, and short enough to add to a cycle
+// ID (cycle.h), which the Makefile does to keep books' orders apart
+constexpr bool valid_book(std::string_view name) {
+  if (name.empty() || name.size() > max_book)
+    return false;
+  for (auto c : name)
+    if (!(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-')
+      return false;
+  return true;
+}
+
+static_assert(valid_book("swing-60m"));
+static_assert(!valid_book("../swing"));
+static_assert(!valid_book("swing_60m"));
+static_assert(!valid_book(""));
+
+// Output root for a book; the default book, unnamed, is docs/ itself
+constexpr std::string book_root(std::string_view book) {
+  if (book.empty())
+    return "docs/";
+  return "docs/books/" + std::string{book} + "/";
+}
+
+static_assert(book_root("") == "docs/");
+static_assert(book_root("swing") == "docs/books/swing/");
+
+// This run's book from LFT2_BOOK, empty when unset. A malformed name stops
+// the module: falling back to docs/ would trade another book's files.
+inline std::string load_book() {
+  auto env = std::getenv("LFT2_BOOK");
+  if (!env || !*env)
+    return {};
+  if (!valid_book(env)) {
+    std::println("[ERROR] LFT2_BOOK \"{}\" isn't a book name (a-z, 0-9 and '-', "
+                 "up to {})",
+                 env, max_book);
+    std::exit(2);
+  }
+  return env;
+}
+
+const auto book = load_book();
 
 // Output root — all pipeline files live here for GitHub Pages pickup
-constexpr auto root = "docs/"sv;
+const auto root = book_root(book);
 
-// Helper to build a path from root at compile time
-constexpr std::string path(std::string_view name) {
-  return std::string{root} + std::string{name};
+// Helper to build a path from root
+inline std::string path(std::string_view name) {
+  return root + std::string{name};
 }
 
 const auto strategies = path("strategies.json");
@@ -23,17 +71,44 @@ const auto candidates = path("candidates.json");
 const auto account = path("account.json");
 const auto positions = path("positions.json");
 const auto signals = path("signals.json");
+const auto equity_curves = path("equity-curves.json");
+const auto shards = path("shards/"); // backtest --shard outputs
+const auto backtest_cache = path("backtest-cache.json"); // warm.h
+const auto exposure = path("exposure.json");
+const auto stale_positions = path("stale-positions.json");
+const auto bars_manifest = path("bars-manifest.json");
 const auto buy_fix = path("buy.fix");
 const auto sell_fix = path("sell.fix");
 
 // All paths share the same root prefix by construction
 
-// Per-symbol bar data written by the fetch module
-constexpr std::string bars(std::string_view symbol) {
-  return std::string{root} + "bars/" + std::string{symbol} + ".json";
+// Written as the first key of every JSON artifact. Bump it only for breaking
+// changes, in step with Version in internal/schema, which sets the policy
+constexpr auto schema_version = 1;
+
+// Configuration lives at the repo root, not under docs/
+const auto blocklist = std::string{"blocklist.json"};
+const auto rules = std::string{"rules.json"};
+const auto fill = std::string{"fill.json"};
+const auto signals_inbox = std::string{"signals-inbox.json"};
+
+// Per-symbol bar data written by the fetch module, under a given root
+constexpr std::string bars_in(std::string_view dir, std::string_view symbol) {
+  return std::string{dir} + "bars/" + std::string{symbol} + ".json";
 }
 
-static_assert(bars("AAPL") == "docs/bars/AAPL.json");
-static_assert(bars("TSLA") == "docs/bars/TSLA.json");
+static_assert(bars_in("docs/", "AAPL") == "docs/bars/AAPL.json");
+static_assert(bars_in(book_root("swing"), "TSLA") ==
+              "docs/books/swing/bars/TSLA.json");
+
+// The same for this run's book
+inline std::string bars(std::string_view symbol) {
+  return bars_in(root, symbol);
+}
+
+// The same, gzipped, as fetch writes it with -gzip
+inline std::string bars_gz(std::string_view symbol) {
+  return bars(symbol) + ".gz";
+}
 
 } // namespace paths
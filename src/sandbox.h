@@ -0,0 +1,92 @@
+#pragma once
+#include <algorithm>
+#include <array>
+#include <cstddef>
+#include <optional>
+#include <span>
+#include <string>
+#include <string_view>
+
+// Strategy sandbox: `backtest --try STRATEGY SYMBOL`, behind `lft2 try`,
+// backtests one strategy on one symbol's stored bars with the exit levels
+// given, and prints the trades, their stats and the equity as a sparkline.
+// It writes nothing, so it's safe to run between pipeline cycles.
+
+namespace sandbox {
+
+// A percentage such as "1.25" as a fraction, above 0 and below 100, or
+// nullopt
+constexpr std::optional<double> parse_pct(std::string_view s) {
+  if (s.empty() || s.size() > 16)
+    return std::nullopt;
+  auto value = 0.0;
+  auto scale = 0.0; // Set at the decimal point, then tenths, hundredths...
+  auto digits = 0;
+  for (auto c : s) {
+    if (c == '.' && scale == 0.0) {
+      scale = 1.0;
+      continue;
+    }
+    if (c < '0' || c > '9')
+      return std::nullopt;
+    value = value * 10.0 + (c - '0');
+    scale *= 10.0;
+    ++digits;
+  }
+  if (scale > 1.0)
+    value /= scale;
+  if (digits == 0 || value <= 0.0 || value >= 100.0)
+    return std::nullopt;
+  return value / 100.0;
+}
+
+// Eighths of a character cell, lowest first
+constexpr auto blocks = std::array<std::string_view, 8>{"▁", "▂", "▃", "▄",
+                                                        "▅", "▆", "▇", "█"};
+
+// values as one block per column, scaled from their lowest to their highest.
+// Longer series keep the last value of each of width columns, so the final
+// point is always drawn; a flat series sits mid-height.
+constexpr std::string sparkline(std::span<const double> values,
+                                std::size_t width = 60) {
+  if (values.empty() || width == 0)
+    return {};
+  auto columns = std::min(values.size(), width);
+  auto lo = values.front();
+  auto hi = values.front();
+  for (auto v : values) {
+    lo = std::min(lo, v);
+    hi = std::max(hi, v);
+  }
+
+  auto line = std::string{};
+  for (auto c = 0uz; c < columns; ++c) {
+    auto v = values[(c + 1) * values.size() / columns - 1];
+    auto level = hi > lo ? static_cast<std::size_t>((v - lo) / (hi - lo) * 7.0 + 0.5)
+                         : 3uz;
+    line += blocks[level];
+  }
+  return line;
+}
+
+// Unit tests
+namespace {
+static_assert(parse_pct("1.25") == 0.0125);
+static_assert(parse_pct("2") == 0.02);
+static_assert(parse_pct(".5") == 0.005);
+static_assert(!parse_pct(""));
+static_assert(!parse_pct("0"));
+static_assert(!parse_pct("100"));
+static_assert(!parse_pct("1.2.5"));
+static_assert(!parse_pct("-1"));
+static_assert(!parse_pct("."));
+
+static_assert(sparkline(std::array{1.0, 2.0, 3.0}) == "▁▅█");
+static_assert(sparkline(std::array{1.0, 1.0}) == "▄▄"); // Flat
+static_assert(sparkline(std::span<const double>{}).empty());
+// Six points in three columns keep every second, ending with the last
+static_assert(sparkline(std::array{0.0, 7.0, 0.0, 3.0, 0.0, 1.0}, 3) ==
+              "█▄▂");
+} // namespace
+
+} // namespace sandbox
@@ -0,0 +1,99 @@
+#include "script.h"
+#include "bar.h"
+#include "entry.h"
+#include "json.h"
+#include "paths.h"
+#include <algorithm>
+#include <format>
+#include <fstream>
+#include <print>
+#include <string>
+#include <vector>
+
+namespace script {
+
+// Load and validate rules.json — names end up in client_order_ids, so they're
+// restricted to lower-case letters, digits and underscores
+std::vector<rule> load_rules() {
+  auto ifs = std::ifstream{paths::rules};
+  if (!ifs)
+    return {};
+
+  auto content = std::string{std::istreambuf_iterator<char>(ifs), {}};
+  auto start = content.find(R"("rules")");
+  if (start == std::string::npos)
+    return {};
+
+  auto rules = std::vector<rule>{};
+  json_foreach_object(
+      std::string_view{content}.substr(start), [&](std::string_view obj) {
+        auto r = rule{.name = std::string{json_string(obj, "name")},
+                      .entry = std::string{json_string(obj, "entry")},
+                      .exit = std::string{json_string(obj, "exit")}};
+        if (auto budget = json_number(obj, "budget"); budget > 0.0)
+          r.budget = std::min(static_cast<std::size_t>(budget), max_budget);
+        if (auto version = json_number(obj, "version"); version >= 1.0)
+          r.version = static_cast<int>(version);
+        auto window = json_string(obj, "window");
+
+        auto reject = [&](std::string_view why) {
+          std::println("⚠️  {}: skipping rule '{}': {}", paths::rules, r.name,
+                       why);
+        };
+
+        if (r.name.empty() || r.entry.empty())
+          return reject("needs a name and an entry expression");
+        if (!std::ranges::all_of(r.name, [](char c) {
+              return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+                     c == '_';
+            }))
+          return reject("name must be lower-case letters, digits and _");
+        if (required_bars(r.name) > 0)
+          return reject("name clashes with a built-in strategy");
+        if (find_rule(rules, r.name))
+          return reject("duplicate name");
+        if (!window.empty()) {
+          auto w = market::parse_window(window);
+          if (!w)
+            return reject(std::format(
+                "window \"{}\" must be HH:MM-HH:MM within 09:30-16:00 ET",
+                window));
+          r.window = *w;
+        }
+
+        auto entry = check(r.entry, r.budget);
+        if (!entry.error.empty())
+          return reject(std::format("entry: {}", entry.error));
+        r.lookback = entry.lookback;
+
+        if (!r.exit.empty()) {
+          auto exit = check(r.exit, r.budget);
+          if (!exit.error.empty())
+            return reject(std::format("exit: {}", exit.error));
+          r.lookback = std::max(r.lookback, exit.lookback);
+        }
+
+        rules.push_back(std::move(r));
+      });
+
+  return rules;
+}
+
+const rule *find_rule(std::span<const rule> rules, std::string_view name) {
+  auto it = std::ranges::find(rules, name, &rule::name);
+  return it == rules.end() ? nullptr : &*it;
+}
+
+const rule *rule_for_order(std::span<const rule> rules,
+                           std::string_view symbol,
+                           std::string_view client_order_id) {
+  // Any version of the rule: the name is followed by -vN, or by the levels
+  // in orders from before strategies were versioned
+  for (const auto &r : rules)
+    if (client_order_id.starts_with(std::format("{}_{}-v", symbol, r.name)) ||
+        client_order_id.starts_with(std::format("{}_{}_tp", symbol, r.name)))
+      return &r;
+  return nullptr;
+}
+
+} // namespace script
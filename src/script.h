@@ -0,0 +1,470 @@
+#pragma once
+#include "bar.h"
+#include "market.h"
+#include <algorithm>
+#include <array>
+#include <span>
+#include <string>
+#include <string_view>
+#include <vector>
+
+// User-defined strategies: entry and exit rules written as expressions in
+// rules.json and evaluated per bar, so new ideas can be tried without
+// recompiling.
+//
+//   {"rules": [{"name": "deep_dip",
+//               "entry": "close < sma(20) * 0.98 and rsi(14) < 30",
+//               "exit": "close > sma(20)", "window": "10:00-15:30"}]}
+//
+// The optional window limits the hours (ET) the rule may enter in, as
+// strategy_window does for the built-ins.
+//
+// Values: numbers, the latest bar's open/high/low/close/vwap/volume, and the
+// window functions sma(n), highest(n), lowest(n), avg_volume(n), change(n)
+// (percent) and rsi(n). Operators: + - * / < <= > >= == != and or not, with
+// parentheses. Comparisons and logic yield 1 or 0; a rule fires when its
+// value is non-zero.
+//
+// Sandboxed by construction: there is no I/O, no variables and no loops. Each
+// value, operator and bar read by a window function costs one step. The cost
+// is fixed by the expression, so a rule over its budget is rejected at load,
+// and evaluation stops at the budget regardless.
+
+namespace script {
+
+constexpr auto default_budget = 10'000uz;
+constexpr auto max_budget = 100'000uz;
+constexpr auto max_window = 500uz;
+constexpr auto max_length = 1'000uz; // Also bounds recursion depth
+
+struct result {
+  double value{};
+  std::string_view error{}; // Empty on success
+  std::size_t lookback{};   // Bars of history the expression reads
+  std::size_t steps{};      // Evaluation cost
+};
+
+namespace detail {
+
+constexpr bool is_alpha(char c) {
+  return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_';
+}
+
+constexpr bool is_digit(char c) { return c >= '0' && c <= '9'; }
+
+constexpr bool is_field(std::string_view name) {
+  return name == "open" || name == "high" || name == "low" ||
+         name == "close" || name == "vwap" || name == "volume";
+}
+
+constexpr bool is_function(std::string_view name) {
+  return name == "sma" || name == "highest" || name == "lowest" ||
+         name == "avg_volume" || name == "change" || name == "rsi";
+}
+
+// Recursive descent parser that evaluates as it goes. With run unset it only
+// parses, which yields the lookback and cost without touching history.
+struct evaluator {
+  std::string_view src;
+  std::span<const bar> history;
+  bool run{};
+  std::size_t budget{};
+  std::size_t pos{};
+  std::size_t steps{};
+  std::size_t lookback{};
+  std::string_view error{};
+
+  constexpr bool failed() const { return !error.empty(); }
+
+  constexpr void fail(std::string_view why) {
+    if (error.empty())
+      error = why;
+  }
+
+  constexpr bool step(std::size_t n = 1) {
+    steps += n;
+    if (steps > budget)
+      fail("evaluation budget exceeded");
+    return !failed();
+  }
+
+  constexpr void skip_space() {
+    while (pos < src.size() && (src[pos] == ' ' || src[pos] == '\t' ||
+                                src[pos] == '\n' || src[pos] == '\r'))
+      ++pos;
+  }
+
+  // Consume tok if it's next; keywords must not run into an identifier
+  constexpr bool accept(std::string_view tok) {
+    skip_space();
+    if (src.substr(pos, tok.size()) != tok)
+      return false;
+    auto end = pos + tok.size();
+    if (is_alpha(tok[0]) && end < src.size() &&
+        (is_alpha(src[end]) || is_digit(src[end])))
+      return false;
+    pos = end;
+    return true;
+  }
+
+  constexpr std::string_view identifier() {
+    skip_space();
+    auto start = pos;
+    while (pos < src.size() &&
+           (is_alpha(src[pos]) || (pos > start && is_digit(src[pos]))))
+      ++pos;
+    return src.substr(start, pos - start);
+  }
+
+  constexpr double number() {
+    skip_space();
+    auto start = pos;
+    auto value = 0.0;
+    while (pos < src.size() && is_digit(src[pos]))
+      value = value * 10.0 + (src[pos++] - '0');
+    if (pos < src.size() && src[pos] == '.') {
+      ++pos;
+      auto scale = 0.1;
+      while (pos < src.size() && is_digit(src[pos])) {
+        value += (src[pos++] - '0') * scale;
+        scale /= 10.0;
+      }
+    }
+    if (pos == start)
+      fail("expected a number");
+    return value;
+  }
+
+  constexpr double disjunction() {
+    auto v = conjunction();
+    while (!failed() && accept("or")) {
+      auto rhs = conjunction();
+      v = (v != 0.0 || rhs != 0.0) ? 1.0 : 0.0;
+      step();
+    }
+    return v;
+  }
+
+  constexpr double conjunction() {
+    auto v = negation();
+    while (!failed() && accept("and")) {
+      auto rhs = negation();
+      v = (v != 0.0 && rhs != 0.0) ? 1.0 : 0.0;
+      step();
+    }
+    return v;
+  }
+
+  constexpr double negation() {
+    if (accept("not")) {
+      auto v = negation();
+      step();
+      return v == 0.0 ? 1.0 : 0.0;
+    }
+    return comparison();
+  }
+
+  constexpr double comparison() {
+    auto lhs = sum();
+    if (failed())
+      return 0.0;
+
+    // Two-character operators first so "<=" isn't read as "<"
+    auto compare = [&](auto op) {
+      auto rhs = sum();
+      step();
+      return op(lhs, rhs) ? 1.0 : 0.0;
+    };
+    if (accept("<="))
+      return compare([](double a, double b) { return a <= b; });
+    if (accept(">="))
+      return compare([](double a, double b) { return a >= b; });
+    if (accept("=="))
+      return compare([](double a, double b) { return a == b; });
+    if (accept("!="))
+      return compare([](double a, double b) { return a != b; });
+    if (accept("<"))
+      return compare([](double a, double b) { return a < b; });
+    if (accept(">"))
+      return compare([](double a, double b) { return a > b; });
+    return lhs;
+  }
+
+  constexpr double sum() {
+    auto v = term();
+    while (!failed()) {
+      if (accept("+"))
+        v += term();
+      else if (accept("-"))
+        v -= term();
+      else
+        break;
+      step();
+    }
+    return v;
+  }
+
+  constexpr double term() {
+    auto v = unary();
+    while (!failed()) {
+      if (accept("*"))
+        v *= unary();
+      else if (accept("/")) {
+        auto d = unary();
+        v = d == 0.0 ? 0.0 : v / d; // No infinities
+      } else
+        break;
+      step();
+    }
+    return v;
+  }
+
+  constexpr double unary() {
+    if (accept("-")) {
+      auto v = unary();
+      step();
+      return -v;
+    }
+    return primary();
+  }
+
+  constexpr double primary() {
+    if (!step())
+      return 0.0;
+
+    if (accept("(")) {
+      auto v = disjunction();
+      if (!accept(")"))
+        fail("expected ')'");
+      return v;
+    }
+
+    skip_space();
+    if (pos < src.size() && (is_digit(src[pos]) || src[pos] == '.'))
+      return number();
+
+    auto name = identifier();
+    if (name.empty()) {
+      fail("expected a value");
+      return 0.0;
+    }
+
+    if (accept("(")) {
+      auto n = number();
+      if (!accept(")"))
+        fail("expected ')'");
+      return call(name, n);
+    }
+    return field(name);
+  }
+
+  constexpr double field(std::string_view name) {
+    if (!is_field(name)) {
+      fail("unknown field");
+      return 0.0;
+    }
+    lookback = std::max(lookback, 1uz);
+    if (!run)
+      return 0.0;
+    if (history.empty()) {
+      fail("insufficient history");
+      return 0.0;
+    }
+
+    const auto &b = history.back();
+    if (name == "open")
+      return b.open;
+    if (name == "high")
+      return b.high;
+    if (name == "low")
+      return b.low;
+    if (name == "vwap")
+      return b.vwap;
+    if (name == "volume")
+      return b.volume;
+    return b.close;
+  }
+
+  constexpr double call(std::string_view name, double arg) {
+    if (failed())
+      return 0.0;
+    if (!is_function(name)) {
+      fail("unknown function");
+      return 0.0;
+    }
+    auto n = static_cast<std::size_t>(arg);
+    if (arg < 1.0 || static_cast<double>(n) != arg || n > max_window) {
+      fail("window must be a whole number from 1 to 500");
+      return 0.0;
+    }
+
+    // change and rsi compare each bar with the one before
+    auto need = (name == "change" || name == "rsi") ? n + 1 : n;
+    lookback = std::max(lookback, need);
+    if (!step(need) || !run)
+      return 0.0;
+    if (history.size() < need) {
+      fail("insufficient history");
+      return 0.0;
+    }
+
+    auto window = history.last(need);
+    if (name == "highest") {
+      auto v = window.front().high;
+      for (const auto &b : window)
+        v = std::max(v, b.high);
+      return v;
+    }
+    if (name == "lowest") {
+      auto v = window.front().low;
+      for (const auto &b : window)
+        v = std::min(v, b.low);
+      return v;
+    }
+    if (name == "change") {
+      auto first = window.front().close;
+      return first == 0.0 ? 0.0 : (window.back().close - first) / first * 100.0;
+    }
+    if (name == "rsi") {
+      auto gains = 0.0;
+      auto losses = 0.0;
+      for (auto i = 1uz; i < window.size(); ++i) {
+        auto delta = window[i].close - window[i - 1].close;
+        if (delta > 0.0)
+          gains += delta;
+        else
+          losses -= delta;
+      }
+      if (losses == 0.0)
+        return 100.0;
+      return 100.0 - 100.0 / (1.0 + gains / losses);
+    }
+
+    // sma and avg_volume
+    auto total = 0.0;
+    for (const auto &b : window)
+      total += name == "sma" ? b.close : static_cast<double>(b.volume);
+    return total / static_cast<double>(n);
+  }
+};
+
+} // namespace detail
+
+// Evaluate src against history (oldest first, latest bar last)
+constexpr result evaluate(std::string_view src, std::span<const bar> history,
+                          std::size_t budget = default_budget,
+                          bool run = true) {
+  if (src.size() > max_length)
+    return {.error = "rule too long"};
+
+  auto e = detail::evaluator{
+      .src = src, .history = history, .run = run, .budget = budget};
+  auto value = e.disjunction();
+  e.skip_space();
+  if (!e.failed() && e.pos != src.size())
+    e.fail("unexpected input");
+  return {.value = value, .error = e.error, .lookback = e.lookback,
+          .steps = e.steps};
+}
+
+// Parse without evaluating: reports syntax errors, lookback and cost
+constexpr result check(std::string_view src,
+                       std::size_t budget = default_budget) {
+  return evaluate(src, {}, budget, false);
+}
+
+// True when the rule evaluates cleanly to a non-zero value
+constexpr bool fires(std::string_view src, std::span<const bar> history,
+                     std::size_t budget = default_budget) {
+  auto r = evaluate(src, history, budget);
+  return r.error.empty() && r.value != 0.0;
+}
+
+// Unit tests
+namespace {
+// Ten bars closing 100..109, volume 1000..1900
+constexpr auto rising = [] {
+  auto bars = std::array<bar, 10>{};
+  for (auto i = 0uz; i < bars.size(); ++i)
+    bars[i] = bar{.close = 100.0 + i,
+                  .high = 101.0 + i,
+                  .low = 99.0 + i,
+                  .open = 99.5 + i,
+                  .vwap = 100.0 + i,
+                  .volume = 1000u + static_cast<std::uint32_t>(i) * 100,
+                  .num_trades = 50,
+                  .timestamp = "2026-01-01T15:00:00Z"};
+  return bars;
+}();
+
+// Test: arithmetic precedence
+static_assert(evaluate("1 + 2 * 3", {}).value == 7.0);
+static_assert(evaluate("(1 + 2) * 3", {}).value == 9.0);
+static_assert(evaluate("-2 * -1.5", {}).value == 3.0);
+
+// Test: division by zero yields zero rather than infinity
+static_assert(evaluate("1 / 0", {}).value == 0.0);
+
+// Test: logic and comparisons
+static_assert(evaluate("not 1 or 0 and 1", {}).value == 0.0);
+static_assert(evaluate("2 >= 2 and 1 != 2", {}).value == 1.0);
+
+// Test: fields and windows read the latest bars
+static_assert(evaluate("close", rising).value == 109.0);
+static_assert(evaluate("sma(4)", rising).value == 107.5);
+static_assert(evaluate("highest(3) - lowest(3)", rising).value == 4.0);
+static_assert(evaluate("rsi(5)", rising).value == 100.0);
+static_assert(fires("close > sma(5) and volume > avg_volume(5)", rising));
+static_assert(!fires("close < sma(5)", rising));
+
+// Test: check reports lookback and cost without history
+static_assert(check("close > sma(20)").lookback == 20);
+static_assert(check("change(5) < -2").lookback == 6);
+static_assert(check("close > sma(20)").steps == 1 + 1 + 20 + 1);
+
+// Test: errors
+static_assert(check("close >").error == "expected a value");
+static_assert(check("closed > 1").error == "unknown field");
+static_assert(check("ema(5) > 1").error == "unknown function");
+static_assert(!check("sma(1000)").error.empty());
+static_assert(!check("sma(2.5)").error.empty());
+static_assert(check("close close").error == "unexpected input");
+static_assert(check("(close > 1").error == "expected ')'");
+static_assert(check("1 andx 1").error == "unexpected input");
+
+// Test: over-budget rules are rejected
+static_assert(check("sma(20) > sma(20)", 30).error ==
+              "evaluation budget exceeded");
+
+// Test: too little history fails instead of reading out of bounds
+static_assert(evaluate("sma(20)", rising).error == "insufficient history");
+static_assert(!fires("sma(20) > 0", rising));
+} // namespace
+
+// Rule from rules.json: a named entry expression and optional exit
+// expression, validated at load.
+struct rule {
+  std::string name;
+  std::string entry;
+  std::string exit;
+  std::size_t budget = default_budget;
+  std::size_t lookback = 0; // Warm-up bars, the larger of entry and exit
+  int version = 1;          // Bumped by hand when the expressions change
+  market::window window = market::whole_session; // ET hours it may enter
+};
+
+// Implemented in script.cxx — reads rules.json, reporting and skipping
+// invalid rules. A missing file means no user strategies.
+std::vector<rule> load_rules();
+
+// The rule with this strategy name, or nullptr for built-in strategies
+const rule *find_rule(std::span<const rule> rules, std::string_view name);
+
+// The rule that opened a position, recovered from the client_order_id that
+// entries writes: {symbol}_{strategy}-v{N}_tp..._{timestamp}. Any version of
+// the rule matches, as do IDs from before strategies were versioned.
+const rule *rule_for_order(std::span<const rule> rules,
+                           std::string_view symbol,
+                           std::string_view client_order_id);
+
+} // namespace script
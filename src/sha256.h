@@ -0,0 +1,169 @@
+#pragma once
+#include <algorithm>
+#include <array>
+#include <cstdint>
+#include <string>
+#include <string_view>
+
+// SHA-256 (FIPS 180-4) of a byte string, as lower-case hex. Backtest uses it
+// to check bar files against the bars-manifest.json fetch writes, so the
+// digests must match Go's crypto/sha256 byte for byte. HMAC-SHA256 (RFC 2104)
+// signs order intents for execute to verify with crypto/hmac.
+
+namespace sha256 {
+
+namespace detail {
+
+constexpr auto k = std::array<std::uint32_t, 64>{
+    0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1,
+    0x923f82a4, 0xab1c5ed5, 0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3,
+    0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174, 0xe49b69c1, 0xefbe4786,
+    0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+    0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147,
+    0x06ca6351, 0x14292967, 0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13,
+    0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85, 0xa2bfe8a1, 0xa81a664b,
+    0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+    0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a,
+    0x5b9cca4f, 0x682e6ff3, 0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208,
+    0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2};
+
+constexpr std::uint32_t rotr(std::uint32_t x, int n) {
+  return (x >> n) | (x << (32 - n));
+}
+
+// Fold one 64-byte block into the running state
+constexpr void compress(std::array<std::uint32_t, 8> &h,
+                        const std::array<std::uint8_t, 64> &block) {
+  auto w = std::array<std::uint32_t, 64>{};
+  for (auto i = 0uz; i < 16; ++i)
+    w[i] = std::uint32_t{block[i * 4]} << 24 |
+           std::uint32_t{block[i * 4 + 1]} << 16 |
+           std::uint32_t{block[i * 4 + 2]} << 8 |
+           std::uint32_t{block[i * 4 + 3]};
+  for (auto i = 16uz; i < 64; ++i) {
+    auto s0 = rotr(w[i - 15], 7) ^ rotr(w[i - 15], 18) ^ (w[i - 15] >> 3);
+    auto s1 = rotr(w[i - 2], 17) ^ rotr(w[i - 2], 19) ^ (w[i - 2] >> 10);
+    w[i] = w[i - 16] + s0 + w[i - 7] + s1;
+  }
+
+  auto [a, b, c, d, e, f, g, hh] = h;
+  for (auto i = 0uz; i < 64; ++i) {
+    auto t1 = hh + (rotr(e, 6) ^ rotr(e, 11) ^ rotr(e, 25)) +
+              ((e & f) ^ (~e & g)) + k[i] + w[i];
+    auto t2 = (rotr(a, 2) ^ rotr(a, 13) ^ rotr(a, 22)) +
+              ((a & b) ^ (a & c) ^ (b & c));
+    hh = g;
+    g = f;
+    f = e;
+    e = d + t1;
+    d = c;
+    c = b;
+    b = a;
+    a = t1 + t2;
+  }
+
+  h[0] += a;
+  h[1] += b;
+  h[2] += c;
+  h[3] += d;
+  h[4] += e;
+  h[5] += f;
+  h[6] += g;
+  h[7] += hh;
+}
+
+} // namespace detail
+
+// Raw 32-byte digest
+constexpr std::array<std::uint8_t, 32> digest(std::string_view data) {
+  auto h = std::array<std::uint32_t, 8>{0x6a09e667, 0xbb67ae85, 0x3c6ef372,
+                                        0xa54ff53a, 0x510e527f, 0x9b05688c,
+                                        0x1f83d9ab, 0x5be0cd19};
+
+  // Message, a 1 bit, zero padding, then the length in bits, in 64-byte
+  // blocks
+  auto block = std::array<std::uint8_t, 64>{};
+  auto used = 0uz;
+  auto push = [&](std::uint8_t byte) {
+    block[used++] = byte;
+    if (used == block.size()) {
+      detail::compress(h, block);
+      used = 0;
+    }
+  };
+
+  for (auto c : data)
+    push(static_cast<std::uint8_t>(c));
+  push(0x80);
+  while (used != 56)
+    push(0);
+  auto bits = static_cast<std::uint64_t>(data.size()) * 8;
+  for (auto shift = 56; shift >= 0; shift -= 8)
+    push(static_cast<std::uint8_t>(bits >> shift));
+
+  auto out = std::array<std::uint8_t, 32>{};
+  for (auto i = 0uz; i < out.size(); ++i)
+    out[i] = static_cast<std::uint8_t>(h[i / 4] >> (24 - 8 * (i % 4)));
+  return out;
+}
+
+namespace detail {
+constexpr std::string to_hex(const std::array<std::uint8_t, 32> &bytes) {
+  constexpr auto digits = std::string_view{"0123456789abcdef"};
+  auto out = std::string{};
+  for (auto byte : bytes) {
+    out += digits[byte >> 4];
+    out += digits[byte & 0xf];
+  }
+  return out;
+}
+} // namespace detail
+
+constexpr std::string hex(std::string_view data) {
+  return detail::to_hex(digest(data));
+}
+
+// HMAC-SHA256 of message under key, as lower-case hex. Keys longer than a
+// block are hashed first, as the RFC requires.
+constexpr std::string hmac(std::string_view key, std::string_view message) {
+  auto block = std::array<std::uint8_t, 64>{};
+  if (key.size() > block.size()) {
+    auto hashed = digest(key);
+    std::copy(hashed.begin(), hashed.end(), block.begin());
+  } else
+    for (auto i = 0uz; i < key.size(); ++i)
+      block[i] = static_cast<std::uint8_t>(key[i]);
+
+  auto pad = [&](std::uint8_t with) {
+    auto out = std::string{};
+    for (auto byte : block)
+      out += static_cast<char>(byte ^ with);
+    return out;
+  };
+  auto inner = digest(pad(0x36) + std::string{message});
+  auto outer = pad(0x5c);
+  outer.append(inner.begin(), inner.end());
+  return hex(outer);
+}
+
+// FIPS 180-4 test vectors, including one that spills into a second block
+static_assert(
+    hex("") ==
+    "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855");
+static_assert(
+    hex("abc") ==
+    "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad");
+static_assert(
+    hex("abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq") ==
+    "248d6a61d20638b8e5c026930c3e6039a33ce45964ff2167f6ecedd419db06c1");
+
+// RFC 4231 test cases 2 and 6 (a key longer than the block)
+static_assert(
+    hmac("Jefe", "what do ya want for nothing?") ==
+    "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843");
+static_assert(
+    hmac(std::string(131, '\xaa'),
+         "Test Using Larger Than Block-Size Key - Hash Key First") ==
+    "60e431591ee0b67f0d8a26aacbf5b77f8e0bc6213728c5140546040f0ee37f54");
+
+} // namespace sha256
@@ -0,0 +1,93 @@
+#pragma once
+#include <cstddef>
+#include <optional>
+#include <string_view>
+
+// Backtest sharding. `backtest --shard i/n` tests only its share of the
+// candidates so CI can split a large universe across n parallel jobs, and
+// `lft2 merge` joins the shard outputs into one strategies.json. Shards are
+// numbered from 1. Shard i takes candidates i, i+n, i+2n... in filter's rank
+// order, so the strongest (and most traded) candidates spread evenly across
+// jobs, and every candidate lands in exactly one shard.
+
+namespace shard {
+
+struct spec {
+  std::size_t index = 1;
+  std::size_t count = 1;
+};
+
+// The whole universe in one run, as without --shard
+constexpr auto whole = spec{};
+
+// A positive decimal, or nullopt
+constexpr std::optional<std::size_t> parse_count(std::string_view s) {
+  if (s.empty() || s.size() > 6)
+    return std::nullopt;
+  auto n = 0uz;
+  for (auto c : s) {
+    if (c < '0' || c > '9')
+      return std::nullopt;
+    n = n * 10 + static_cast<std::size_t>(c - '0');
+  }
+  if (n == 0)
+    return std::nullopt;
+  return n;
+}
+
+// "i/n" with 1 <= i <= n, or nullopt
+constexpr std::optional<spec> parse(std::string_view s) {
+  auto slash = s.find('/');
+  if (slash == std::string_view::npos)
+    return std::nullopt;
+  auto index = parse_count(s.substr(0, slash));
+  auto count = parse_count(s.substr(slash + 1));
+  if (!index || !count || *index > *count)
+    return std::nullopt;
+  return spec{*index, *count};
+}
+
+// True if shard s tests the candidate at position (from 0) in rank order
+constexpr bool owns(spec s, std::size_t position) {
+  return position % s.count == s.index - 1;
+}
+
+// Unit tests
+namespace {
+static_assert(parse("1/1")->index == 1);
+static_assert(parse("3/4")->index == 3);
+static_assert(parse("3/4")->count == 4);
+static_assert(parse("12/16")->index == 12);
+static_assert(!parse("0/4"));
+static_assert(!parse("5/4"));
+static_assert(!parse("4"));
+static_assert(!parse("/4"));
+static_assert(!parse("1/"));
+static_assert(!parse("-1/4"));
+static_assert(!parse("1/4x"));
+
+// One shard owns everything
+static_assert(owns(whole, 0) && owns(whole, 1) && owns(whole, 99));
+
+// Round robin in rank order
+static_assert(owns(spec{1, 3}, 0) && owns(spec{1, 3}, 3));
+static_assert(owns(spec{2, 3}, 1) && owns(spec{3, 3}, 5));
+static_assert(!owns(spec{1, 3}, 1) && !owns(spec{3, 3}, 0));
+
+// Each position belongs to exactly one shard
+constexpr bool partitioned(std::size_t count, std::size_t positions) {
+  for (auto p = 0uz; p < positions; ++p) {
+    auto owners = 0uz;
+    for (auto i = 1uz; i <= count; ++i)
+      owners += owns(spec{i, count}, p) ? 1 : 0;
+    if (owners != 1)
+      return false;
+  }
+  return true;
+}
+static_assert(partitioned(1, 10));
+static_assert(partitioned(4, 50));
+static_assert(partitioned(7, 50));
+} // namespace
+
+} // namespace shard
@@ -0,0 +1,128 @@
+#pragma once
+#include <array>
+#include <optional>
+#include <span>
+#include <string>
+#include <string_view>
+#include <utility>
+
+// Positions running on a stale edge. Entries only buys a symbol under a
+// strategy strategies.json recommends, but the daily backtest can stop
+// recommending the pair while the position is still open. Each cycle exits
+// checks every position opened by a strategy against the current
+// recommendations, and the policy says what to do with one no longer among
+// them:
+//
+//   flag  report it in the log and docs/stale-positions.json, and let its
+//         own exit levels close it (the default)
+//   exit  also sell it, with exit reason stale_strategy
+//
+// Positions without a strategy in their client_order_id (placed by hand, or
+// from an external signal) are never stale.
+
+namespace stale {
+
+enum class policy { flag, exit };
+
+constexpr auto default_policy = policy::flag;
+
+constexpr std::string_view name(policy p) {
+  switch (p) {
+  case policy::flag:
+    return "flag";
+  case policy::exit:
+    return "exit";
+  }
+  return "unknown";
+}
+
+// Policy from its name, or nullopt if there's no such policy
+constexpr std::optional<policy> parse(std::string_view s) {
+  for (auto p : {policy::flag, policy::exit})
+    if (name(p) == s)
+      return p;
+  return std::nullopt;
+}
+
+// The strategy a position was opened by, from the client_order_id entries
+// wrote for it: {symbol}_{strategy}[-v{N}]_tp..., without the version. Empty
+// when the ID doesn't carry one.
+constexpr std::string_view order_strategy(std::string_view symbol,
+                                          std::string_view id) {
+  if (symbol.empty() || !id.starts_with(symbol) ||
+      !id.substr(symbol.size()).starts_with('_'))
+    return {};
+  auto rest = id.substr(symbol.size() + 1);
+  auto end = rest.find("_tp");
+  if (end == std::string_view::npos)
+    return {};
+  auto strategy = rest.substr(0, end);
+
+  // Drop a -vN version suffix
+  if (auto v = strategy.rfind("-v");
+      v != std::string_view::npos && v + 2 < strategy.size()) {
+    auto digits = true;
+    for (auto c : strategy.substr(v + 2))
+      digits = digits && c >= '0' && c <= '9';
+    if (digits)
+      strategy = strategy.substr(0, v);
+  }
+  if (strategy == "external")
+    return {};
+  return strategy;
+}
+
+// A viable recommendation: symbol and strategy
+using pair = std::pair<std::string, std::string>;
+
+// True if the position's symbol and strategy are still recommended
+constexpr bool recommended(std::span<const pair> recommendations,
+                           std::string_view symbol,
+                           std::string_view strategy) {
+  for (const auto &[s, name] : recommendations)
+    if (s == symbol && name == strategy)
+      return true;
+  return false;
+}
+
+// Unit tests
+namespace {
+
+static_assert(parse("flag") == policy::flag);
+static_assert(parse("exit") == policy::exit);
+static_assert(!parse("sell"));
+static_assert(parse(name(default_policy)) == default_policy);
+
+static_assert(order_strategy("AAPL", "AAPL_mean_reversion-v2_tp1.25_sl1.25_"
+                                     "tsl1.00_p1a2b3c4d_20260218T143000") ==
+              "mean_reversion");
+static_assert(order_strategy("AAPL",
+                             "AAPL_momentum_tp1.25_sl1.25_tsl1.00_20260218") ==
+              "momentum");
+static_assert(order_strategy("BRK.B", "BRK.B_gap_fill-v1_tp2.00_sl1.00_tsl0."
+                                      "75_p0_20260218T143000_cgh42") ==
+              "gap_fill");
+
+// A version-like suffix that isn't one is part of the name
+static_assert(order_strategy("AAPL", "AAPL_my-vwap_tp1.00_sl1.00_tsl1.00") ==
+              "my-vwap");
+
+// Not from a strategy: external signals, orders by hand, another symbol
+static_assert(order_strategy("AAPL", "AAPL_external_tp1.25_sl1.25_tsl1.00_p0_"
+                                     "20260218T143000")
+                  .empty());
+static_assert(order_strategy("AAPL", "").empty());
+static_assert(order_strategy("AAPL", "manual-buy").empty());
+static_assert(order_strategy("AAPL", "AAPLX_momentum_tp1.25").empty());
+static_assert(order_strategy("AAPL", "EXIT_AAPL_1_123").empty());
+
+static_assert([] {
+  auto recs = std::array{pair{"AAPL", "momentum"}, pair{"MSFT", "gap_fill"}};
+  return recommended(recs, "AAPL", "momentum") &&
+         !recommended(recs, "AAPL", "gap_fill") &&
+         !recommended(recs, "TSLA", "momentum");
+}());
+
+} // namespace
+
+} // namespace stale
@@ -0,0 +1,57 @@
+#pragma once
+#include <format>
+#include <print>
+#include <ranges>
+#include <string>
+#include <string_view>
+
+// Build identity: the commit a module was compiled from, as the Go binaries
+// report it (internal/version). The Makefile passes it through CMake
+// (-DLFT2_BUILD=...); a bare cmake build leaves it empty, and then artifacts
+// go unstamped.
+
+#ifndef LFT2_BUILD
+#define LFT2_BUILD ""
+#endif
+
+namespace version {
+
+using namespace std::string_view_literals;
+
+// First 12 characters of the commit, with -dirty for a modified tree
+constexpr auto build = std::string_view{LFT2_BUILD};
+
+// True if the command line asks for the version
+constexpr bool requested(const std::ranges::range auto &args) {
+  for (const auto &arg : args) {
+    auto a = std::string_view{arg};
+    if (a == "--version"sv || a == "-version"sv)
+      return true;
+  }
+  return false;
+}
+
+// `"build": "...", ` for an artifact's header, after schema_version; nothing
+// when unstamped, as Go leaves the key out
+inline std::string json_key() {
+  return build.empty() ? std::string{}
+                       : std::format("\"build\": \"{}\", ", build);
+}
+
+// What --version prints
+inline void print(std::string_view name) {
+  std::println("{} {}", name, build.empty() ? "unknown commit"sv : build);
+  std::println("  compiler gcc {}", __VERSION__);
+}
+
+// Unit tests
+namespace {
+constexpr std::string_view plain[] = {"backtest", "--seed", "3"};
+constexpr std::string_view asked[] = {"backtest", "--version"};
+constexpr std::string_view single[] = {"entries", "-version"};
+static_assert(!requested(plain));
+static_assert(requested(asked));
+static_assert(requested(single));
+} // namespace
+
+} // namespace version
@@ -0,0 +1,46 @@
+#pragma once
+#include <string>
+#include <string_view>
+
+// Warm/cold split of the backtest. Each run keeps what it evaluated in a
+// cache beside its output: every candidate's results, the checksum of the
+// bar file they came from and when they were computed. On the next run a
+// candidate that was evaluated last time, over the same bar file, under the
+// same run key is warm: its results are carried forward, marked as of the
+// run that computed them, instead of being backtested again. Anything new to
+// the candidate list, or whose bars changed, is cold and evaluated afresh,
+// so nightly runtime follows what changed rather than the universe size.
+
+namespace warm {
+
+// What every result depends on beyond its bar file: the build, which fixes
+// the strategy code and parameters, the seed, the fill model and the user
+// rules. Without a build stamp a changed strategy can't be told from an
+// unchanged one, so there is no key and every candidate runs cold.
+constexpr std::string key(std::string_view build, std::string_view inputs) {
+  if (build.empty())
+    return {};
+  return std::string{build} + "/" + std::string{inputs};
+}
+
+// True if a cached candidate can be carried forward: a key to match, the
+// same key as the cache was written under, and the same bar file
+constexpr bool reusable(std::string_view cached_key, std::string_view run_key,
+                        std::string_view cached_sha256,
+                        std::string_view sha256) {
+  return !run_key.empty() && cached_key == run_key && !sha256.empty() &&
+         cached_sha256 == sha256;
+}
+
+// Unit tests
+namespace {
+static_assert(key("", "seed=0") == "");
+static_assert(key("0123456789ab", "seed=0") == "0123456789ab/seed=0");
+static_assert(reusable("k", "k", "abc", "abc"));
+static_assert(!reusable("k", "k", "abc", "abd")); // Bars changed
+static_assert(!reusable("k", "j", "abc", "abc")); // Build or inputs changed
+static_assert(!reusable("", "", "abc", "abc"));   // Unstamped build
+static_assert(!reusable("k", "k", "", ""));       // Checksum unknown
+} // namespace
+
+} // namespace warm
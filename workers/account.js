@@ -62,9 +62,11 @@ export default {
 
     if (url.pathname === '/api/daily-summary') {
       try {
-        const response = await fetch('https://deanturpin.github.io/lft2/daily-summary.json');
+        // Same base the Go stages download artifacts from, so a fork serves its own
+        const base = (env.LFT2_ARTIFACT_BASE || 'https://deanturpin.github.io/lft2').replace(/\/+$/, '');
+        const response = await fetch(`${base}/daily-summary.json`);
         if (!response.ok) {
-          throw new Error(`GitHub Pages returned ${response.status}`);
+          throw new Error(`${base} returned ${response.status}`);
         }
         const data = await response.json();
         return new Response(JSON.stringify(data), {